@@ -0,0 +1,78 @@
+// Package anomaly は外れ値に強い統計量（中央値・MAD）による異常検知の計算を提供します
+package anomaly
+
+import (
+	"math"
+	"sort"
+)
+
+// madScale はMADを正規分布の標準偏差に換算する係数です
+const madScale = 1.4826
+
+// meanADScale は平均絶対偏差を正規分布の標準偏差に換算する係数です
+const meanADScale = 1.2533
+
+// Median は中央値を返します（空の場合は0）
+func Median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// MAD は中央絶対偏差（median absolute deviation）を返します
+func MAD(values []float64) float64 {
+	median := Median(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+	}
+	return Median(deviations)
+}
+
+// Score は基準値の分布に対する観測値の外れ具合を表します
+type Score struct {
+	Value  float64
+	Median float64
+	MAD    float64
+	// Scale はzスコアの計算に使った標準偏差の推定値です
+	Scale float64
+	// Z は中央値からのずれを Scale で割った値です
+	Z float64
+	// SampleSize は基準値の件数です
+	SampleSize int
+}
+
+// RobustZ は基準値の中央値とMADから観測値のロバストzスコアを計算します
+//
+// 基準値のばらつきが小さくMADが0になる場合（取消が通常0件の日が続く等）は、
+// 平均絶対偏差、それも0なら minScale を標準偏差の推定値として使います。
+func RobustZ(value float64, baseline []float64, minScale float64) Score {
+	score := Score{Value: value, SampleSize: len(baseline)}
+	if len(baseline) == 0 {
+		return score
+	}
+
+	score.Median = Median(baseline)
+	score.MAD = MAD(baseline)
+	score.Scale = score.MAD * madScale
+	if score.Scale == 0 {
+		var sum float64
+		for _, v := range baseline {
+			sum += math.Abs(v - score.Median)
+		}
+		score.Scale = sum / float64(len(baseline)) * meanADScale
+	}
+	score.Scale = math.Max(score.Scale, minScale)
+	if score.Scale > 0 {
+		score.Z = (value - score.Median) / score.Scale
+	}
+	return score
+}
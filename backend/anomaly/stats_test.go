@@ -0,0 +1,64 @@
+package anomaly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMedianAndMAD(t *testing.T) {
+	assert.Equal(t, 0.0, Median(nil))
+	assert.Equal(t, 3.0, Median([]float64{5, 1, 3}))
+	assert.Equal(t, 2.5, Median([]float64{4, 1, 3, 2}))
+
+	// 外れ値 100 の影響を受けない
+	assert.Equal(t, 1.0, MAD([]float64{1, 2, 3, 4, 100}))
+}
+
+func TestRobustZ(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     float64
+		baseline  []float64
+		minScale  float64
+		wantZ     float64
+		wantScale float64
+	}{
+		{
+			name:      "MADによるzスコア",
+			value:     20,
+			baseline:  []float64{9, 10, 11, 10, 10, 12, 8},
+			wantScale: 1.4826,
+			wantZ:     10 / 1.4826,
+		},
+		{
+			name:      "MADが0の場合は平均絶対偏差を使う",
+			value:     4,
+			baseline:  []float64{0, 0, 0, 0, 2},
+			wantScale: 0.4 * 1.2533,
+			wantZ:     4 / (0.4 * 1.2533),
+		},
+		{
+			name:      "ばらつきがない場合は最小スケールを使う",
+			value:     3,
+			baseline:  []float64{0, 0, 0},
+			minScale:  1,
+			wantScale: 1,
+			wantZ:     3,
+		},
+		{
+			name:     "基準値がない場合は0",
+			value:    3,
+			minScale: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := RobustZ(tt.value, tt.baseline, tt.minScale)
+			assert.InDelta(t, tt.wantScale, score.Scale, 1e-9)
+			assert.InDelta(t, tt.wantZ, score.Z, 1e-9)
+			assert.Equal(t, len(tt.baseline), score.SampleSize)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+// Package calendar は日本の国民の祝日と店舗のイベントによる営業カレンダーを提供します
+// 売上分析での日の種類の判定や、需要予測の説明変数として使います
+package calendar
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Holiday は祝日です（Date は日付のみを表し、時刻は 00:00 UTC です）
+type Holiday struct {
+	Date time.Time
+	Name string
+}
+
+// 「国民の祝日に関する法律」に基づく振替休日・国民の休日の名称
+const (
+	substituteHolidayName = "振替休日"
+	citizensHolidayName   = "国民の休日"
+)
+
+// Japan は日本の国民の祝日のカレンダーです（2007年以降の祝日法に基づきます）
+// 年ごとの祝日は初回の参照時に計算してキャッシュします
+type Japan struct {
+	mu    sync.Mutex
+	years map[int]map[string]string
+}
+
+func NewJapan() *Japan {
+	return &Japan{years: make(map[int]map[string]string)}
+}
+
+// HolidayName は日付が祝日の場合にその名称を返します（日付は日付部分のみを使います）
+func (j *Japan) HolidayName(date time.Time) (string, bool) {
+	holidays := j.year(date.Year())
+	name, ok := holidays[dateKey(date)]
+	return name, ok
+}
+
+// Holidays は指定年の祝日を日付順に返します
+func (j *Japan) Holidays(year int) []Holiday {
+	holidays := j.year(year)
+	result := make([]Holiday, 0, len(holidays))
+	for key, name := range holidays {
+		d, _ := time.Parse("2006-01-02", key)
+		result = append(result, Holiday{Date: d, Name: name})
+	}
+	sort.Slice(result, func(i, k int) bool {
+		return result[i].Date.Before(result[k].Date)
+	})
+	return result
+}
+
+func (j *Japan) year(year int) map[string]string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if holidays, ok := j.years[year]; ok {
+		return holidays
+	}
+	holidays := japaneseHolidays(year)
+	j.years[year] = holidays
+	return holidays
+}
+
+// japaneseHolidays は指定年の祝日（振替休日・国民の休日を含む）を計算します
+func japaneseHolidays(year int) map[string]string {
+	holidays := make(map[string]string)
+	add := func(month time.Month, day int, name string) {
+		holidays[dateKey(date(year, month, day))] = name
+	}
+
+	add(time.January, 1, "元日")
+	add(time.January, nthMonday(year, time.January, 2), "成人の日")
+	add(time.February, 11, "建国記念の日")
+	switch {
+	case year >= 2020:
+		add(time.February, 23, "天皇誕生日")
+	case year <= 2018:
+		add(time.December, 23, "天皇誕生日")
+	}
+	add(time.March, vernalEquinox(year), "春分の日")
+	add(time.April, 29, "昭和の日")
+	add(time.May, 3, "憲法記念日")
+	add(time.May, 4, "みどりの日")
+	add(time.May, 5, "こどもの日")
+	add(time.September, nthMonday(year, time.September, 3), "敬老の日")
+	add(time.September, autumnalEquinox(year), "秋分の日")
+	add(time.November, 3, "文化の日")
+	add(time.November, 23, "勤労感謝の日")
+
+	// 東京オリンピック・パラリンピックに伴う特例（2020年・2021年）
+	switch year {
+	case 2020:
+		add(time.July, 23, "海の日")
+		add(time.July, 24, "スポーツの日")
+		add(time.August, 10, "山の日")
+	case 2021:
+		add(time.July, 22, "海の日")
+		add(time.July, 23, "スポーツの日")
+		add(time.August, 8, "山の日")
+	default:
+		add(time.July, nthMonday(year, time.July, 3), "海の日")
+		if year >= 2016 {
+			add(time.August, 11, "山の日")
+		}
+		if year >= 2020 {
+			add(time.October, nthMonday(year, time.October, 2), "スポーツの日")
+		} else {
+			add(time.October, nthMonday(year, time.October, 2), "体育の日")
+		}
+	}
+
+	// 天皇の即位に伴う特例（2019年）
+	if year == 2019 {
+		add(time.May, 1, "天皇の即位の日")
+		add(time.October, 22, "即位礼正殿の儀の行われる日")
+	}
+
+	// 国民の休日: 前日と翌日が祝日である平日
+	for d := date(year, time.January, 2); d.Year() == year; d = d.AddDate(0, 0, 1) {
+		if _, ok := holidays[dateKey(d)]; ok || d.Weekday() == time.Sunday {
+			continue
+		}
+		_, before := holidays[dateKey(d.AddDate(0, 0, -1))]
+		_, after := holidays[dateKey(d.AddDate(0, 0, 1))]
+		if before && after {
+			holidays[dateKey(d)] = citizensHolidayName
+		}
+	}
+
+	// 振替休日: 日曜日の祝日の後の最初の祝日でない日
+	keys := make([]string, 0, len(holidays))
+	for key := range holidays {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		d, _ := time.Parse("2006-01-02", key)
+		if d.Weekday() != time.Sunday {
+			continue
+		}
+		sub := d.AddDate(0, 0, 1)
+		for {
+			if _, ok := holidays[dateKey(sub)]; !ok {
+				break
+			}
+			sub = sub.AddDate(0, 0, 1)
+		}
+		if sub.Year() == year {
+			holidays[dateKey(sub)] = substituteHolidayName
+		}
+	}
+
+	return holidays
+}
+
+// vernalEquinox は春分日（1980〜2099年の近似式）を返します
+func vernalEquinox(year int) int {
+	return int(20.8431+0.242194*float64(year-1980)) - (year-1980)/4
+}
+
+// autumnalEquinox は秋分日（1980〜2099年の近似式）を返します
+func autumnalEquinox(year int) int {
+	return int(23.2488+0.242194*float64(year-1980)) - (year-1980)/4
+}
+
+// nthMonday は指定月の第n月曜日の日を返します
+func nthMonday(year int, month time.Month, n int) int {
+	first := date(year, month, 1)
+	offset := (int(time.Monday) - int(first.Weekday()) + 7) % 7
+	return 1 + offset + (n-1)*7
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// dateKey は日付のキー（2006-01-02）を返します
+func dateKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,65 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJapanHolidays(t *testing.T) {
+	j := NewJapan()
+
+	var got []string
+	for _, h := range j.Holidays(2024) {
+		got = append(got, h.Date.Format("01-02")+" "+h.Name)
+	}
+	assert.Equal(t, []string{
+		"01-01 元日",
+		"01-08 成人の日",
+		"02-11 建国記念の日",
+		"02-12 振替休日",
+		"02-23 天皇誕生日",
+		"03-20 春分の日",
+		"04-29 昭和の日",
+		"05-03 憲法記念日",
+		"05-04 みどりの日",
+		"05-05 こどもの日",
+		"05-06 振替休日",
+		"07-15 海の日",
+		"08-11 山の日",
+		"08-12 振替休日",
+		"09-16 敬老の日",
+		"09-22 秋分の日",
+		"09-23 振替休日",
+		"10-14 スポーツの日",
+		"11-03 文化の日",
+		"11-04 振替休日",
+		"11-23 勤労感謝の日",
+	}, got)
+}
+
+func TestJapanHolidayName(t *testing.T) {
+	j := NewJapan()
+	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
+
+	tests := []struct {
+		date    time.Time
+		want    string
+		holiday bool
+	}{
+		{date: time.Date(2019, 4, 30, 0, 0, 0, 0, jst), want: "国民の休日", holiday: true},
+		{date: time.Date(2019, 5, 1, 0, 0, 0, 0, jst), want: "天皇の即位の日", holiday: true},
+		{date: time.Date(2019, 5, 2, 0, 0, 0, 0, jst), want: "国民の休日", holiday: true},
+		{date: time.Date(2021, 7, 23, 0, 0, 0, 0, jst), want: "スポーツの日", holiday: true},
+		{date: time.Date(2026, 9, 22, 0, 0, 0, 0, jst), want: "国民の休日", holiday: true},
+		{date: time.Date(2025, 3, 20, 23, 0, 0, 0, jst), want: "春分の日", holiday: true},
+		{date: time.Date(2025, 12, 23, 0, 0, 0, 0, jst), holiday: false},
+		{date: time.Date(2024, 6, 3, 0, 0, 0, 0, jst), holiday: false},
+	}
+	for _, tt := range tests {
+		name, ok := j.HolidayName(tt.date)
+		assert.Equal(t, tt.holiday, ok, tt.date.Format("2006-01-02"))
+		assert.Equal(t, tt.want, name, tt.date.Format("2006-01-02"))
+	}
+}
@@ -0,0 +1,60 @@
+package calendar
+
+import (
+	"time"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// Store は国民の祝日と店舗のイベントを合わせた、1店舗の営業カレンダーです
+type Store struct {
+	holidays *Japan
+	events   []*models.StoreEvent
+}
+
+// NewStore は祝日カレンダーと店舗のイベント（全店舗共通のものを含む）から営業カレンダーを作成します
+func NewStore(holidays *Japan, events []*models.StoreEvent) *Store {
+	return &Store{
+		holidays: holidays,
+		events:   events,
+	}
+}
+
+// Day は日付の種類と、その日の祝日名・イベントを返します（日付は店舗のタイムゾーンで指定します）
+func (s *Store) Day(date time.Time) models.CalendarDay {
+	key := dateKey(date)
+	day := models.CalendarDay{
+		Date:    key,
+		Weekday: date.Weekday().String(),
+		DayType: models.DayTypeWeekday,
+	}
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		day.DayType = models.DayTypeWeekend
+	}
+	if name, ok := s.HolidayName(date); ok {
+		day.DayType = models.DayTypeHoliday
+		day.HolidayName = name
+	}
+
+	for _, event := range s.events {
+		if key < event.StartDate || key > event.EndDate {
+			continue
+		}
+		day.Events = append(day.Events, event)
+		switch {
+		case event.Type == models.StoreEventClosure:
+			day.DayType = models.DayTypeClosed
+		case day.DayType != models.DayTypeClosed:
+			day.DayType = models.DayTypeEvent
+		}
+	}
+	return day
+}
+
+// HolidayName は日付が国民の祝日の場合にその名称を返します
+func (s *Store) HolidayName(date time.Time) (string, bool) {
+	if s.holidays == nil {
+		return "", false
+	}
+	return s.holidays.HolidayName(date)
+}
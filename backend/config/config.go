@@ -2,12 +2,94 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 )
 
 // Config はアプリケーションの設定を保持します
 type Config struct {
 	MongoURI string
 	Port     string
+
+	// StoreTimezone は営業日の区切りや時間帯分析に使う店舗のタイムゾーンです
+	StoreTimezone string
+
+	// 消費税・適格請求書
+	TaxRounding               string
+	TaxInclusivePricing       bool
+	InvoiceRegistrationNumber string
+	InvoiceIssuerName         string
+
+	// IdempotencyKeyTTL は冪等キーと応答を保存する期間です
+	IdempotencyKeyTTL time.Duration
+
+	// 会員ポイント
+	LoyaltyAmountPerPoint   float64
+	LoyaltyLowCO2Threshold  float64
+	LoyaltyLowCO2Multiplier float64
+	LoyaltyPointValue       float64
+
+	// 異常検知
+	AnomalyDetectionInterval time.Duration
+	AnomalyZThreshold        float64
+
+	// 売上エクスポート
+	ExportDir               string
+	ExportFileTTL           time.Duration
+	ExportSyncMaxDays       int
+	ExportMaxConcurrentJobs int
+
+	// 商品のABC分析（区分を分ける累積構成比）
+	ABCClassAThreshold float64
+	ABCClassBThreshold float64
+
+	// 売上予測（既定のモデルと予測に使う履歴の日数）
+	ForecastModel       string
+	ForecastHistoryDays int
+
+	// FleetBatteryReserve は配送機体の割り当て時に帰着後も残しておくバッテリー残量（%）です
+	FleetBatteryReserve float64
+
+	// 配送ルートの計画（機体の平均速度 km/h、配送先での引き渡し時間、道路データファイル）
+	// RoutingRoadGraph を省略した場合、配送ロボットの移動距離は直線距離で求めます
+	RoutingRobotSpeed  float64
+	RoutingDroneSpeed  float64
+	RoutingServiceTime time.Duration
+	RoutingRoadGraph   string
+
+	// 配送のライブ追跡（再接続時に再送できるよう保持するイベントの件数と、接続維持のための送信間隔）
+	TrackingBufferSize        int
+	TrackingHeartbeatInterval time.Duration
+
+	// 配送機体の計測値（受信した計測値と1分ごとの集約値の保存期間、集約の実行間隔、通信中とみなす時間）
+	TelemetryRawRetention    time.Duration
+	TelemetryRollupRetention time.Duration
+	TelemetryRollupInterval  time.Duration
+	TelemetryOnlineTimeout   time.Duration
+
+	// 配送の到着予測（予測の更新間隔と、機体の平均の走行速度を求める過去の期間）
+	ETARefreshInterval time.Duration
+	ETASpeedHistory    time.Duration
+
+	// エネルギー効率レポート（バッテリー容量が未登録の機体に使う容量（Wh）と、効率の悪化と判定する悪化率）
+	EnergyRobotBatteryCapacity float64
+	EnergyDroneBatteryCapacity float64
+	EnergyDegradationThreshold float64
+
+	// 配送時間枠（仮押さえの有効期間、期限切れの仮押さえを失効させる間隔、配送実績が少ない場合の往復時間）
+	SlotHoldTTL         time.Duration
+	SlotExpiryInterval  time.Duration
+	SlotDefaultTripTime time.Duration
+
+	// 配送の試行回数の上限（失敗後の再配送はこの回数まで）
+	DeliveryMaxAttempts int
+
+	// 配送の証跡（受け渡し地点として認める配送先からの距離（m）、写真の最大サイズ（バイト）、受取人の暗証番号の照合回数の上限、写真などのブロブの保存先）
+	ProofOfDeliveryRadius         float64
+	ProofOfDeliveryMaxPhotoSize   int
+	ProofOfDeliveryMaxPINAttempts int
+	BlobDir                       string
 }
 
 // NewConfig は新しい設定を作成します
@@ -16,6 +98,67 @@ func NewConfig() *Config {
 		// 環境変数から設定を読み込み、デフォルト値を設定
 		MongoURI: getEnv("MONGODB_URI", "mongodb://localhost:27017"),
 		Port:     getEnv("PORT", "8080"),
+
+		StoreTimezone: getEnv("STORE_TIMEZONE", "Asia/Tokyo"),
+
+		TaxRounding:               getEnv("TAX_ROUNDING", "floor"),
+		TaxInclusivePricing:       getEnv("TAX_INCLUSIVE_PRICING", "true") == "true",
+		InvoiceRegistrationNumber: getEnv("INVOICE_REGISTRATION_NUMBER", ""),
+		InvoiceIssuerName:         getEnv("INVOICE_ISSUER_NAME", ""),
+
+		IdempotencyKeyTTL: getEnvDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+
+		LoyaltyAmountPerPoint:   getEnvFloat("LOYALTY_AMOUNT_PER_POINT", 100),
+		LoyaltyLowCO2Threshold:  getEnvFloat("LOYALTY_LOW_CO2_THRESHOLD", 0.5),
+		LoyaltyLowCO2Multiplier: getEnvFloat("LOYALTY_LOW_CO2_MULTIPLIER", 2),
+		LoyaltyPointValue:       getEnvFloat("LOYALTY_POINT_VALUE", 1),
+
+		AnomalyDetectionInterval: getEnvDuration("ANOMALY_DETECTION_INTERVAL", 15*time.Minute),
+		AnomalyZThreshold:        getEnvFloat("ANOMALY_Z_THRESHOLD", 3.5),
+
+		ExportDir:               getEnv("EXPORT_DIR", filepath.Join(os.TempDir(), "smart-store-exports")),
+		ExportFileTTL:           getEnvDuration("EXPORT_FILE_TTL", 24*time.Hour),
+		ExportSyncMaxDays:       getEnvInt("EXPORT_SYNC_MAX_DAYS", 31),
+		ExportMaxConcurrentJobs: getEnvInt("EXPORT_MAX_CONCURRENT_JOBS", 2),
+
+		ABCClassAThreshold: getEnvFloat("ABC_CLASS_A_THRESHOLD", 0.8),
+		ABCClassBThreshold: getEnvFloat("ABC_CLASS_B_THRESHOLD", 0.95),
+
+		ForecastModel:       getEnv("FORECAST_MODEL", "weekday_seasonal"),
+		ForecastHistoryDays: getEnvInt("FORECAST_HISTORY_DAYS", 182),
+
+		FleetBatteryReserve: getEnvFloat("FLEET_BATTERY_RESERVE", 20),
+
+		RoutingRobotSpeed:  getEnvFloat("ROUTING_ROBOT_SPEED", 6),
+		RoutingDroneSpeed:  getEnvFloat("ROUTING_DRONE_SPEED", 40),
+		RoutingServiceTime: getEnvDuration("ROUTING_SERVICE_TIME", 3*time.Minute),
+		RoutingRoadGraph:   getEnv("ROUTING_ROAD_GRAPH", ""),
+
+		TrackingBufferSize:        getEnvInt("TRACKING_BUFFER_SIZE", 1000),
+		TrackingHeartbeatInterval: getEnvDuration("TRACKING_HEARTBEAT_INTERVAL", 15*time.Second),
+
+		TelemetryRawRetention:    getEnvDuration("TELEMETRY_RAW_RETENTION", 7*24*time.Hour),
+		TelemetryRollupRetention: getEnvDuration("TELEMETRY_ROLLUP_RETENTION", 90*24*time.Hour),
+		TelemetryRollupInterval:  getEnvDuration("TELEMETRY_ROLLUP_INTERVAL", time.Minute),
+		TelemetryOnlineTimeout:   getEnvDuration("TELEMETRY_ONLINE_TIMEOUT", 30*time.Second),
+
+		ETARefreshInterval: getEnvDuration("ETA_REFRESH_INTERVAL", time.Minute),
+		ETASpeedHistory:    getEnvDuration("ETA_SPEED_HISTORY", 7*24*time.Hour),
+
+		EnergyRobotBatteryCapacity: getEnvFloat("ENERGY_ROBOT_BATTERY_CAPACITY", 1000),
+		EnergyDroneBatteryCapacity: getEnvFloat("ENERGY_DRONE_BATTERY_CAPACITY", 500),
+		EnergyDegradationThreshold: getEnvFloat("ENERGY_DEGRADATION_THRESHOLD", 0.15),
+
+		SlotHoldTTL:         getEnvDuration("SLOT_HOLD_TTL", 10*time.Minute),
+		SlotExpiryInterval:  getEnvDuration("SLOT_EXPIRY_INTERVAL", time.Minute),
+		SlotDefaultTripTime: getEnvDuration("SLOT_DEFAULT_TRIP_TIME", 45*time.Minute),
+
+		DeliveryMaxAttempts: getEnvInt("DELIVERY_MAX_ATTEMPTS", 3),
+
+		ProofOfDeliveryRadius:         getEnvFloat("PROOF_OF_DELIVERY_RADIUS", 50),
+		ProofOfDeliveryMaxPhotoSize:   getEnvInt("PROOF_OF_DELIVERY_MAX_PHOTO_SIZE", 10<<20),
+		ProofOfDeliveryMaxPINAttempts: getEnvInt("PROOF_OF_DELIVERY_MAX_PIN_ATTEMPTS", 5),
+		BlobDir:                       getEnv("BLOB_DIR", filepath.Join(os.TempDir(), "smart-store-blobs")),
 	}
 }
 
@@ -26,3 +169,33 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvFloat は環境変数を数値として取得し、存在しないか不正な場合はデフォルト値を返します
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+// getEnvInt は環境変数を整数として取得し、存在しないか不正な場合はデフォルト値を返します
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDuration は環境変数を時間（例: 24h）として取得し、存在しないか不正な場合はデフォルト値を返します
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultValue
+}
@@ -47,6 +47,12 @@ func NewMongoDB(uri string) (*MongoDB, error) {
 	// データベース取得
 	db := client.Database(defaultDBName)
 
+	// 以前のフィールド名で保存されたデータの移行（インデックスの設定より先に行います）
+	if err := models.MigrateLegacyFields(db); err != nil {
+		log.Printf("Failed to migrate legacy fields: %v", err)
+		return nil, err
+	}
+
 	// インデックスの設定
 	if err := models.SetupIndexes(db); err != nil {
 		log.Printf("Failed to setup indexes: %v", err)
@@ -0,0 +1,60 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// utf8BOM はExcelにUTF-8として認識させるためのバイト順マークです
+const utf8BOM = "\ufeff"
+
+// csvTimeLayout はExcelが日時として解釈できる形式です
+const csvTimeLayout = "2006-01-02 15:04:05"
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return nil, err
+	}
+	cw := &csvWriter{
+		w:      csv.NewWriter(w),
+		record: make([]string, len(columns)),
+	}
+	// Excelで改行を正しく扱えるようCRLFで出力
+	cw.w.UseCRLF = true
+
+	for i, col := range columns {
+		cw.record[i] = col.name
+	}
+	if err := cw.w.Write(cw.record); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(row *Row) error {
+	for i, v := range row.values() {
+		switch v := v.(type) {
+		case string:
+			cw.record[i] = v
+		case int64:
+			cw.record[i] = strconv.FormatInt(v, 10)
+		case float64:
+			cw.record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case time.Time:
+			cw.record[i] = v.Format(csvTimeLayout)
+		}
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
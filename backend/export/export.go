@@ -0,0 +1,151 @@
+// Package export は売上明細をCSV・Excel（XLSX）・Parquetで出力します
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Format はエクスポートの出力形式です
+type Format string
+
+const (
+	FormatCSV     Format = "csv" // Excelで開けるようBOM付きUTF-8
+	FormatXLSX    Format = "xlsx"
+	FormatParquet Format = "parquet"
+)
+
+// ParseFormat は文字列から出力形式を取得します（未指定の場合はCSV）
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case "":
+		return FormatCSV, nil
+	case FormatCSV, FormatXLSX, FormatParquet:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", s)
+	}
+}
+
+// ContentType は出力形式に対応するContent-Typeを返します
+func (f Format) ContentType() string {
+	switch f {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Extension はファイルの拡張子を返します
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// Row は売上明細1行分のエクスポート内容です
+type Row struct {
+	SaleID                string
+	SoldAt                time.Time // 店舗のタイムゾーンでの販売日時
+	StoreID               string
+	RegisterID            string
+	TerminalTransactionID string
+	PaymentMethod         string
+	LineNo                int
+	ProductID             string
+	ProductName           string
+	Category              string
+	Quantity              int
+	UnitPrice             float64
+	Amount                float64 // 単価×数量
+	Discount              float64 // 明細に対する値引額
+	TaxClass              string
+	TaxRate               int     // %
+	TaxableAmount         float64 // 税抜金額
+	TaxAmount             float64 // 明細ごとに計算した消費税額（売上全体の税額とは端数が異なる場合があります）
+}
+
+// columnType は列の値の型です
+type columnType int
+
+const (
+	columnString columnType = iota
+	columnInt
+	columnFloat
+	columnTime
+)
+
+// column はエクスポートの列定義です
+type column struct {
+	name string
+	typ  columnType
+}
+
+// columns は全形式で共通の列の並びです（Row.values と対応）
+var columns = []column{
+	{"sale_id", columnString},
+	{"sold_at", columnTime},
+	{"store_id", columnString},
+	{"register_id", columnString},
+	{"terminal_transaction_id", columnString},
+	{"payment_method", columnString},
+	{"line_no", columnInt},
+	{"product_id", columnString},
+	{"product_name", columnString},
+	{"category", columnString},
+	{"quantity", columnInt},
+	{"unit_price", columnFloat},
+	{"amount", columnFloat},
+	{"discount", columnFloat},
+	{"tax_class", columnString},
+	{"tax_rate", columnInt},
+	{"taxable_amount", columnFloat},
+	{"tax_amount", columnFloat},
+}
+
+// values は列の並びに対応する値を返します
+func (r *Row) values() []interface{} {
+	return []interface{}{
+		r.SaleID,
+		r.SoldAt,
+		r.StoreID,
+		r.RegisterID,
+		r.TerminalTransactionID,
+		r.PaymentMethod,
+		int64(r.LineNo),
+		r.ProductID,
+		r.ProductName,
+		r.Category,
+		int64(r.Quantity),
+		r.UnitPrice,
+		r.Amount,
+		r.Discount,
+		r.TaxClass,
+		int64(r.TaxRate),
+		r.TaxableAmount,
+		r.TaxAmount,
+	}
+}
+
+// Writer は明細を1行ずつ書き出します
+// Close を呼ぶまで出力は完結しません（XLSX・Parquetはファイル末尾に目録を書き込みます）
+type Writer interface {
+	Write(row *Row) error
+	Close() error
+}
+
+// NewWriter は出力形式に対応する Writer を作成します
+func NewWriter(w io.Writer, f Format) (Writer, error) {
+	switch f {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	case FormatParquet:
+		return newParquetWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", f)
+	}
+}
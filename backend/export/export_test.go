@@ -0,0 +1,161 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleRows() []*Row {
+	jst := time.FixedZone("JST", 9*60*60)
+	return []*Row{
+		{
+			SaleID:        "65a1b2c3",
+			SoldAt:        time.Date(2024, 1, 5, 9, 30, 0, 0, jst),
+			StoreID:       "store-1",
+			RegisterID:    "reg-1",
+			PaymentMethod: "cash",
+			LineNo:        1,
+			ProductID:     "p1",
+			ProductName:   "オーガニック牛乳, 1L",
+			Category:      "乳製品",
+			Quantity:      2,
+			UnitPrice:     216,
+			Amount:        432,
+			TaxClass:      "reduced",
+			TaxRate:       8,
+			TaxableAmount: 400,
+			TaxAmount:     32,
+		},
+		{
+			SaleID:        "65a1b2c3",
+			SoldAt:        time.Date(2024, 1, 5, 9, 30, 0, 0, jst),
+			LineNo:        2,
+			ProductName:   "<エコバッグ>",
+			Quantity:      1,
+			UnitPrice:     330.5,
+			Amount:        330.5,
+			Discount:      30,
+			TaxClass:      "standard",
+			TaxRate:       10,
+			TaxableAmount: 273,
+			TaxAmount:     27,
+		},
+	}
+}
+
+func writeAll(t *testing.T, f Format) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, f)
+	require.NoError(t, err)
+	for _, row := range sampleRows() {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestCSVWriter(t *testing.T) {
+	out := string(writeAll(t, FormatCSV))
+
+	assert.True(t, strings.HasPrefix(out, utf8BOM+"sale_id,sold_at,"))
+	lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, `65a1b2c3,2024-01-05 09:30:00,store-1,reg-1,,cash,1,p1,"オーガニック牛乳, 1L",乳製品,2,216,432,0,reduced,8,400,32`, lines[1])
+	assert.Contains(t, lines[2], ",330.5,330.5,30,standard,10,")
+}
+
+func TestXLSXWriter(t *testing.T) {
+	out := writeAll(t, FormatXLSX)
+
+	zr, err := zip.NewReader(bytes.NewReader(out), int64(len(out)))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(b)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		assert.Contains(t, files, name)
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="A1" t="inlineStr" s="1"><is><t xml:space="preserve">sale_id</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="K2"><v>2</v></c>`)
+	assert.Contains(t, sheet, `&lt;エコバッグ&gt;`)
+	// 2024-01-05 09:30 のシリアル値
+	assert.Contains(t, sheet, `<c r="B2" s="2"><v>45296.395833333336</v></c>`)
+	assert.True(t, strings.HasSuffix(sheet, "</sheetData></worksheet>"))
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "AZ", columnName(51))
+}
+
+func TestParquetWriter(t *testing.T) {
+	out := writeAll(t, FormatParquet)
+
+	require.True(t, bytes.HasPrefix(out, []byte(parquetMagic)))
+	require.True(t, bytes.HasSuffix(out, []byte(parquetMagic)))
+
+	footerLen := int(binary.LittleEndian.Uint32(out[len(out)-8 : len(out)-4]))
+	require.Less(t, footerLen, len(out)-12)
+	footer := out[len(out)-8-footerLen : len(out)-8]
+	for _, col := range columns {
+		assert.True(t, bytes.Contains(footer, []byte(col.name)), col.name)
+	}
+
+	// 先頭の列（sale_id）のページにはPLAINエンコーディングの文字列が並ぶ
+	firstPage := out[len(parquetMagic) : len(out)-8-footerLen]
+	value := append([]byte{8, 0, 0, 0}, "65a1b2c3"...)
+	assert.Equal(t, 2, bytes.Count(firstPage, value))
+}
+
+func TestParquetWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatParquet)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	out := buf.Bytes()
+	assert.True(t, bytes.HasPrefix(out, []byte(parquetMagic)))
+	assert.True(t, bytes.HasSuffix(out, []byte(parquetMagic)))
+}
+
+func TestThriftWriter(t *testing.T) {
+	var w thriftWriter
+	w.fieldI32(1, 1)
+	w.fieldI64(3, -1)
+	w.fieldBinary(20, "ab")
+	w.stop()
+	// フィールドIDの差分が15を超える場合は型とIDを別に書き出す
+	assert.Equal(t, []byte{0x15, 0x02, 0x26, 0x01, 0x08, 0x28, 0x02, 'a', 'b', 0x00}, w.buf.Bytes())
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCSV, f)
+
+	f, err = ParseFormat("parquet")
+	assert.NoError(t, err)
+	assert.Equal(t, ".parquet", f.Extension())
+
+	_, err = ParseFormat("json")
+	assert.Error(t, err)
+}
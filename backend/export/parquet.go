@@ -0,0 +1,352 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+// parquetMagic はParquetファイルの先頭と末尾に置くマジックナンバーです
+const parquetMagic = "PAR1"
+
+// parquetRowGroupSize は1つの行グループにまとめる行数です（この行数ごとにメモリ上の列データを書き出します）
+const parquetRowGroupSize = 50000
+
+// Parquetの物理型・論理型・エンコーディング（parquet.thrift の定義値）
+const (
+	parquetTypeInt64     = 2
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMillis = 9
+
+	parquetRepetitionRequired = 0
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetCodecUncompressed = 0
+	parquetPageTypeData      = 0
+)
+
+// parquetWriter は非圧縮・PLAINエンコーディングの最小構成のParquetライターです
+// 全列をREQUIREDとして定義するため、定義レベル・繰り返しレベルは書き出しません
+type parquetWriter struct {
+	w         *countingWriter
+	columns   []bytes.Buffer
+	buffered  int
+	rowGroups []parquetRowGroup
+	numRows   int64
+}
+
+// parquetRowGroup は書き出し済みの行グループの情報です（フッターに記録）
+type parquetRowGroup struct {
+	numRows int64
+	chunks  []parquetColumnChunk
+}
+
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	return &parquetWriter{
+		w:       &countingWriter{w: w},
+		columns: make([]bytes.Buffer, len(columns)),
+	}
+}
+
+func (pw *parquetWriter) Write(row *Row) error {
+	if pw.w.n == 0 {
+		if _, err := io.WriteString(pw.w, parquetMagic); err != nil {
+			return err
+		}
+	}
+
+	var b [8]byte
+	for i, v := range row.values() {
+		col := &pw.columns[i]
+		switch v := v.(type) {
+		case string:
+			binary.LittleEndian.PutUint32(b[:4], uint32(len(v)))
+			col.Write(b[:4])
+			col.WriteString(v)
+		case int64:
+			binary.LittleEndian.PutUint64(b[:], uint64(v))
+			col.Write(b[:])
+		case float64:
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+			col.Write(b[:])
+		case time.Time:
+			binary.LittleEndian.PutUint64(b[:], uint64(v.UnixMilli()))
+			col.Write(b[:])
+		}
+	}
+
+	pw.buffered++
+	if pw.buffered >= parquetRowGroupSize {
+		return pw.flushRowGroup()
+	}
+	return nil
+}
+
+// flushRowGroup はメモリ上の列データを1つの行グループとして書き出します（1列1ページ）
+func (pw *parquetWriter) flushRowGroup() error {
+	if pw.buffered == 0 {
+		return nil
+	}
+
+	group := parquetRowGroup{numRows: int64(pw.buffered)}
+	for i := range pw.columns {
+		data := pw.columns[i].Bytes()
+
+		var header thriftWriter
+		header.fieldI32(1, parquetPageTypeData)
+		header.fieldI32(2, int32(len(data)))
+		header.fieldI32(3, int32(len(data)))
+		header.beginStruct(5)
+		header.fieldI32(1, int32(pw.buffered))
+		header.fieldI32(2, parquetEncodingPlain)
+		header.fieldI32(3, parquetEncodingRLE)
+		header.fieldI32(4, parquetEncodingRLE)
+		header.endStruct()
+		header.stop()
+
+		chunk := parquetColumnChunk{offset: pw.w.n, values: int64(pw.buffered)}
+		if _, err := pw.w.Write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if _, err := pw.w.Write(data); err != nil {
+			return err
+		}
+		chunk.size = pw.w.n - chunk.offset
+		group.chunks = append(group.chunks, chunk)
+		pw.columns[i].Reset()
+	}
+
+	pw.rowGroups = append(pw.rowGroups, group)
+	pw.numRows += group.numRows
+	pw.buffered = 0
+	return nil
+}
+
+func (pw *parquetWriter) Close() error {
+	if pw.w.n == 0 {
+		if _, err := io.WriteString(pw.w, parquetMagic); err != nil {
+			return err
+		}
+	}
+	if err := pw.flushRowGroup(); err != nil {
+		return err
+	}
+
+	footer := pw.fileMetaData()
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(footer)))
+	if _, err := pw.w.Write(footer); err != nil {
+		return err
+	}
+	if _, err := pw.w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := io.WriteString(pw.w, parquetMagic)
+	return err
+}
+
+// fileMetaData はスキーマと行グループの位置を記録したフッターを作成します
+func (pw *parquetWriter) fileMetaData() []byte {
+	var t thriftWriter
+	t.fieldI32(1, 1) // version
+
+	// スキーマ（先頭はルート要素）
+	t.beginList(2, thriftStruct, len(columns)+1)
+	t.beginElement()
+	t.fieldBinary(4, "schema")
+	t.fieldI32(5, int32(len(columns)))
+	t.endElement()
+	for _, col := range columns {
+		t.beginElement()
+		t.fieldI32(1, col.physicalType())
+		t.fieldI32(3, parquetRepetitionRequired)
+		t.fieldBinary(4, col.name)
+		if converted, ok := col.convertedType(); ok {
+			t.fieldI32(6, converted)
+		}
+		t.endElement()
+	}
+
+	t.fieldI64(3, pw.numRows)
+
+	t.beginList(4, thriftStruct, len(pw.rowGroups))
+	for _, group := range pw.rowGroups {
+		t.beginElement()
+		var total int64
+		t.beginList(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			total += chunk.size
+			t.beginElement()
+			t.fieldI64(2, chunk.offset)
+			t.beginStruct(3)
+			t.fieldI32(1, columns[i].physicalType())
+			t.beginList(2, thriftI32, 2)
+			t.i32(parquetEncodingPlain)
+			t.i32(parquetEncodingRLE)
+			t.beginList(3, thriftBinary, 1)
+			t.binary(columns[i].name)
+			t.fieldI32(4, parquetCodecUncompressed)
+			t.fieldI64(5, chunk.values)
+			t.fieldI64(6, chunk.size)
+			t.fieldI64(7, chunk.size)
+			t.fieldI64(9, chunk.offset)
+			t.endStruct()
+			t.endElement()
+		}
+		t.fieldI64(2, total)
+		t.fieldI64(3, group.numRows)
+		t.endElement()
+	}
+
+	t.fieldBinary(6, "smart-store-admin")
+	t.stop()
+	return t.buf.Bytes()
+}
+
+// physicalType は列の値を格納するParquetの物理型です
+func (c column) physicalType() int32 {
+	switch c.typ {
+	case columnString:
+		return parquetTypeByteArray
+	case columnFloat:
+		return parquetTypeDouble
+	default:
+		return parquetTypeInt64
+	}
+}
+
+// convertedType は物理型に付与する論理型です
+func (c column) convertedType() (int32, bool) {
+	switch c.typ {
+	case columnString:
+		return parquetConvertedUTF8, true
+	case columnTime:
+		return parquetConvertedTimestampMillis, true
+	default:
+		return 0, false
+	}
+}
+
+// countingWriter は書き出したバイト数（＝次に書き出す位置）を数えます
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// Thrift Compact Protocol の型
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter はParquetのメタデータに必要な範囲の Thrift Compact Protocol エンコーダです
+type thriftWriter struct {
+	buf bytes.Buffer
+	// lastField は構造体ごとの直前のフィールドIDです（フィールドIDは差分で書き出す）
+	lastField []int16
+	current   int16
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - t.current; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(uint64(zigzag(int64(id))))
+	}
+	t.current = id
+}
+
+func (t *thriftWriter) fieldI32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.i32(v)
+}
+
+func (t *thriftWriter) fieldI64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) fieldBinary(id int16, v string) {
+	t.fieldHeader(id, thriftBinary)
+	t.binary(v)
+}
+
+// beginStruct は構造体型のフィールドを開始します
+func (t *thriftWriter) beginStruct(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.beginElement()
+}
+
+func (t *thriftWriter) endStruct() {
+	t.endElement()
+}
+
+// beginList はリスト型のフィールドを開始します（要素はこの後に続けて書き出す）
+func (t *thriftWriter) beginList(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xF0 | elemType)
+		t.varint(uint64(size))
+	}
+}
+
+// beginElement はリストの要素となる構造体を開始します
+func (t *thriftWriter) beginElement() {
+	t.lastField = append(t.lastField, t.current)
+	t.current = 0
+}
+
+func (t *thriftWriter) endElement() {
+	t.stop()
+	t.current = t.lastField[len(t.lastField)-1]
+	t.lastField = t.lastField[:len(t.lastField)-1]
+}
+
+// stop は構造体の終端を書き出します
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(0)
+}
+
+func (t *thriftWriter) i32(v int32) {
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) binary(v string) {
+	t.varint(uint64(len(v)))
+	t.buf.WriteString(v)
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	t.buf.Write(b[:n])
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
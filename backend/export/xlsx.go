@@ -0,0 +1,179 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+// xlsxMaxRows はExcelの1シートあたりの最大行数です
+const xlsxMaxRows = 1048576
+
+// ErrTooManyRows は出力行数がExcelの上限を超えた場合のエラーです
+var ErrTooManyRows = errors.New("too many rows for a single xlsx sheet")
+
+// excelEpoch はExcelのシリアル日付の起点です（1900年うるう年バグを考慮した値）
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// xlsx のセルスタイル（styles.xml の cellXfs の並び順）
+const (
+	xlsxStyleHeader   = 1
+	xlsxStyleDateTime = 2
+)
+
+// xlsxWriter はシートを1行ずつZIPに書き出すストリーミング形式のXLSXライターです
+// 文字列は共有文字列テーブルを使わずセルに直接埋め込むため、行数に比例したメモリを使いません
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+	buf   []byte
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	xw := &xlsxWriter{zw: zw, sheet: bufio.NewWriter(sheet)}
+
+	xw.sheet.WriteString(xml.Header)
+	xw.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	xw.sheet.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	xw.sheet.WriteString(`<sheetData>`)
+
+	header := make([]interface{}, len(columns))
+	for i, col := range columns {
+		header[i] = col.name
+	}
+	if err := xw.writeRow(header, xlsxStyleHeader); err != nil {
+		return nil, err
+	}
+	return xw, nil
+}
+
+func (xw *xlsxWriter) Write(row *Row) error {
+	if xw.rows >= xlsxMaxRows {
+		return ErrTooManyRows
+	}
+	return xw.writeRow(row.values(), 0)
+}
+
+// writeRow は1行分のセルを書き出します
+func (xw *xlsxWriter) writeRow(values []interface{}, style int) error {
+	xw.rows++
+	rowRef := strconv.Itoa(xw.rows)
+
+	xw.sheet.WriteString(`<row r="` + rowRef + `">`)
+	for i, v := range values {
+		ref := columnName(i) + rowRef
+		switch v := v.(type) {
+		case string:
+			xw.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"`)
+			if style != 0 {
+				xw.sheet.WriteString(` s="` + strconv.Itoa(style) + `"`)
+			}
+			xw.sheet.WriteString(`><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(xw.sheet, []byte(v)); err != nil {
+				return err
+			}
+			xw.sheet.WriteString(`</t></is></c>`)
+		case int64:
+			xw.buf = strconv.AppendInt(xw.buf[:0], v, 10)
+			xw.writeNumber(ref, 0)
+		case float64:
+			xw.buf = strconv.AppendFloat(xw.buf[:0], v, 'f', -1, 64)
+			xw.writeNumber(ref, 0)
+		case time.Time:
+			xw.buf = strconv.AppendFloat(xw.buf[:0], excelSerial(v), 'f', -1, 64)
+			xw.writeNumber(ref, xlsxStyleDateTime)
+		}
+	}
+	_, err := xw.sheet.WriteString(`</row>`)
+	return err
+}
+
+// writeNumber は buf に用意した数値をセルとして書き出します
+func (xw *xlsxWriter) writeNumber(ref string, style int) {
+	xw.sheet.WriteString(`<c r="` + ref + `"`)
+	if style != 0 {
+		xw.sheet.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	xw.sheet.WriteString(`><v>`)
+	xw.sheet.Write(xw.buf)
+	xw.sheet.WriteString(`</v></c>`)
+}
+
+func (xw *xlsxWriter) Close() error {
+	xw.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := xw.sheet.Flush(); err != nil {
+		return err
+	}
+
+	for _, part := range xlsxParts {
+		f, err := xw.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, xml.Header+part.content); err != nil {
+			return err
+		}
+	}
+	return xw.zw.Close()
+}
+
+// columnName は0始まりの列番号をExcelの列名（A, B, ..., AA）に変換します
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// excelSerial は日時をExcelのシリアル値に変換します（タイムゾーンは日時のものをそのまま使います）
+func excelSerial(t time.Time) float64 {
+	local := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return local.Sub(excelEpoch).Hours() / 24
+}
+
+// xlsxParts はシート以外のXLSXの構成ファイルです
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="sales" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	{"xl/styles.xml", `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="3">` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`</cellXfs>` +
+		`</styleSheet>`},
+}
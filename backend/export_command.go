@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/onoderaryou/smart-store-admin/backend/config"
+	"github.com/onoderaryou/smart-store-admin/backend/db"
+	"github.com/onoderaryou/smart-store-admin/backend/export"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+// runExportCommand は売上を明細単位でファイルまたは標準出力にエクスポートします
+//
+//	使い方: backend export -start 2024-01-01 -end 2024-01-31 [-format csv|xlsx|parquet] [-store ID] [-register ID] [-o FILE]
+//
+// 同期エクスポートの期間の上限（EXPORT_SYNC_MAX_DAYS）は適用しません
+func runExportCommand(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	startFlag := fs.String("start", "", "開始日（YYYY-MM-DD）")
+	endFlag := fs.String("end", "", "終了日（YYYY-MM-DD、この日を含む）")
+	formatFlag := fs.String("format", "csv", "出力形式（csv, xlsx, parquet）")
+	storeFlag := fs.String("store", "", "店舗ID（未指定の場合は全店舗）")
+	registerFlag := fs.String("register", "", "レジID（未指定の場合は全レジ）")
+	outFlag := fs.String("o", "-", "出力先のファイル（- の場合は標準出力）")
+	fs.Parse(args)
+
+	format, err := export.ParseFormat(*formatFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	start, err := time.Parse("2006-01-02", *startFlag)
+	if err != nil {
+		log.Fatal("Invalid -start date:", err)
+	}
+	end, err := time.Parse("2006-01-02", *endFlag)
+	if err != nil {
+		log.Fatal("Invalid -end date:", err)
+	}
+	req := &service.ExportRequest{
+		Format:     format,
+		StoreID:    *storeFlag,
+		RegisterID: *registerFlag,
+		StartDate:  start,
+		EndDate:    end,
+	}
+	if err := req.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	mongodb, err := db.NewMongoDB(cfg.MongoURI)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer mongodb.Close()
+
+	exportService := service.NewExportService(
+		repository.NewSaleRepository(mongodb.GetDB()),
+		repository.NewProductRepository(mongodb.GetDB()),
+		repository.NewExportJobRepository(mongodb.GetDB()),
+		newTaxCalculator(cfg),
+		loadStoreLocation(cfg),
+		newExportConfig(cfg),
+	)
+
+	if *outFlag == "-" {
+		count, err := writeExport(exportService, req, os.Stdout)
+		if err != nil {
+			log.Fatal("Failed to export sales:", err)
+		}
+		fmt.Fprintf(os.Stderr, "exported %d rows\n", count)
+		return
+	}
+
+	f, err := os.Create(*outFlag)
+	if err != nil {
+		log.Fatal("Failed to create output file:", err)
+	}
+	count, err := writeExport(exportService, req, f)
+	// log.Fatal は defer を実行しないため、終了前に明示的に閉じて書き込みエラーを確認する
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("close output file: %w", closeErr)
+	}
+	if err != nil {
+		log.Fatal("Failed to export sales:", err)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d rows\n", count)
+}
+
+// writeExport は売上の明細をバッファ付きで書き出します
+func writeExport(exportService *service.ExportService, req *service.ExportRequest, out io.Writer) (int64, error) {
+	buf := bufio.NewWriter(out)
+	count, err := exportService.ExportSales(context.Background(), req, buf)
+	if err != nil {
+		return count, err
+	}
+	return count, buf.Flush()
+}
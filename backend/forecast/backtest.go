@@ -0,0 +1,68 @@
+package forecast
+
+import "math"
+
+// BacktestResult は過去の期間で予測した場合の精度です
+type BacktestResult struct {
+	Folds   int `json:"folds"`
+	Horizon int `json:"horizon"`
+	// Evaluated はMAPEの計算に使った日数です（実績が0の日は除きます）
+	Evaluated int `json:"evaluated"`
+	// MAPE は平均絶対パーセント誤差（%）です
+	MAPE float64 `json:"mape"`
+	// MAE は平均絶対誤差です
+	MAE float64 `json:"mae"`
+}
+
+// minTrainingDays はバックテストで1回の予測に使う最小の履歴の日数です
+const minTrainingDays = 28
+
+// Backtest は履歴の末尾から horizon 日ずつ遡った時点（最大 folds 回）で予測し、実績との誤差を評価します
+func Backtest(model Model, history []Observation, horizon, folds int) (*BacktestResult, error) {
+	result := &BacktestResult{Horizon: horizon}
+	if horizon <= 0 {
+		return nil, ErrInsufficientHistory
+	}
+
+	var absErr, absPctErr float64
+	var n int
+	for fold := folds; fold >= 1; fold-- {
+		cutoff := len(history) - fold*horizon
+		if cutoff < minTrainingDays {
+			continue
+		}
+		actual := history[cutoff : cutoff+horizon]
+		future := make([]Day, len(actual))
+		for i, o := range actual {
+			future[i] = o.Day
+		}
+
+		predictions, err := model.Forecast(history[:cutoff], future)
+		if err == ErrInsufficientHistory {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result.Folds++
+		for i, o := range actual {
+			diff := math.Abs(o.Value - predictions[i])
+			absErr += diff
+			n++
+			if o.Value > 0 {
+				absPctErr += diff / o.Value
+				result.Evaluated++
+			}
+		}
+	}
+	if result.Folds == 0 {
+		return nil, ErrInsufficientHistory
+	}
+
+	result.MAE = absErr / float64(n)
+	if result.Evaluated > 0 {
+		result.MAPE = absPctErr / float64(result.Evaluated) * 100
+	}
+	return result, nil
+}
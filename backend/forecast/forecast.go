@@ -0,0 +1,59 @@
+// Package forecast は日次売上の予測モデルと、過去データでの精度評価（バックテスト）を提供します
+package forecast
+
+import (
+	"errors"
+	"time"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// ErrInsufficientHistory は予測に必要な履歴が不足している場合のエラーです
+var ErrInsufficientHistory = errors.New("insufficient history for forecasting")
+
+// Day は予測の対象日と、予測に使う日付の属性（共変量）です
+type Day struct {
+	// Date は店舗のタイムゾーンでの日付です
+	Date time.Time
+	// Holiday は国民の祝日、Event は祭り・セールなど店舗のイベント期間、Closed は休業日です
+	Holiday bool
+	Event   bool
+	Closed  bool
+}
+
+// Observation は1日分の実績です
+type Observation struct {
+	Day
+	Value float64
+}
+
+// Model は日次の売上予測モデルのインターフェースです
+// より精度の高いモデルはこのインターフェースを実装して差し替えます
+type Model interface {
+	// Name はモデルの識別子です（APIでの指定に使います）
+	Name() string
+	// Forecast は日付の欠落がない古い順の履歴から、future の各日の値を予測します
+	Forecast(history []Observation, future []Day) ([]float64, error)
+}
+
+// Calendar は日付の種類（祝日・イベント・休業日）を判定する営業カレンダーです
+type Calendar interface {
+	Day(date time.Time) models.CalendarDay
+}
+
+// NewDay は営業カレンダーから日付の属性を設定します
+func NewDay(date time.Time, cal Calendar) Day {
+	day := Day{Date: date}
+	if cal == nil {
+		return day
+	}
+	info := cal.Day(date)
+	day.Holiday = info.HolidayName != ""
+	day.Closed = info.DayType == models.DayTypeClosed
+	for _, event := range info.Events {
+		if event.Type != models.StoreEventClosure {
+			day.Event = true
+		}
+	}
+	return day
+}
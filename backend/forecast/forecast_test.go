@@ -0,0 +1,114 @@
+package forecast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// weeklyPattern は曜日ごとの売上（日曜〜土曜）です
+var weeklyPattern = [7]float64{150000, 80000, 80000, 90000, 100000, 120000, 160000}
+
+// syntheticHistory は2024-01-01（月曜）から days 日分、曜日の傾向どおりの履歴を作成します
+// holidays に含まれる日は日曜日と同じ売上にします
+func syntheticHistory(days int, holidays map[string]bool) []Observation {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	history := make([]Observation, days)
+	for i := range history {
+		d := start.AddDate(0, 0, i)
+		o := Observation{Day: Day{Date: d}, Value: weeklyPattern[d.Weekday()]}
+		if holidays[d.Format("2006-01-02")] {
+			o.Holiday = true
+			o.Value = weeklyPattern[time.Sunday]
+		}
+		history[i] = o
+	}
+	return history
+}
+
+func futureDays(from time.Time, n int) []Day {
+	days := make([]Day, n)
+	for i := range days {
+		days[i] = Day{Date: from.AddDate(0, 0, i)}
+	}
+	return days
+}
+
+func TestWeekdaySeasonal(t *testing.T) {
+	model := NewWeekdaySeasonal()
+
+	t.Run("曜日ごとの傾向を予測", func(t *testing.T) {
+		history := syntheticHistory(56, nil)
+		future := futureDays(history[len(history)-1].Date.AddDate(0, 0, 1), 7)
+
+		got, err := model.Forecast(history, future)
+		assert.NoError(t, err)
+		for i, d := range future {
+			assert.InDelta(t, weeklyPattern[d.Date.Weekday()], got[i], 0.01, d.Date.Format("2006-01-02"))
+		}
+	})
+
+	t.Run("祝日は履歴の祝日係数を使う", func(t *testing.T) {
+		// 月曜日の祝日が日曜日並みに売れる履歴
+		history := syntheticHistory(56, map[string]bool{"2024-01-08": true, "2024-02-12": true})
+		future := []Day{{Date: time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC), Holiday: true}}
+
+		got, err := model.Forecast(history, future)
+		assert.NoError(t, err)
+		assert.InDelta(t, weeklyPattern[time.Sunday], got[0], 0.01)
+	})
+
+	t.Run("祝日の履歴がない場合は日曜日とみなす", func(t *testing.T) {
+		history := syntheticHistory(56, nil)
+		future := []Day{{Date: time.Date(2024, time.March, 6, 0, 0, 0, 0, time.UTC), Holiday: true}}
+
+		got, err := model.Forecast(history, future)
+		assert.NoError(t, err)
+		assert.InDelta(t, weeklyPattern[time.Sunday], got[0], 0.01)
+	})
+
+	t.Run("イベント期間は履歴のイベント係数を使い、休業日は0", func(t *testing.T) {
+		// イベント期間は売上が1.5倍、休業日は売上なし
+		history := syntheticHistory(56, nil)
+		for _, i := range []int{10, 11, 30} {
+			history[i].Event = true
+			history[i].Value *= 1.5
+		}
+		history[40].Closed = true
+		history[40].Value = 0
+
+		friday := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+		future := []Day{{Date: friday, Event: true}, {Date: friday.AddDate(0, 0, 1), Closed: true}}
+
+		got, err := model.Forecast(history, future)
+		assert.NoError(t, err)
+		assert.InDelta(t, weeklyPattern[time.Friday]*1.5, got[0], 0.01)
+		assert.Equal(t, 0.0, got[1])
+	})
+
+	t.Run("履歴不足でエラー", func(t *testing.T) {
+		_, err := model.Forecast(syntheticHistory(10, nil), futureDays(time.Now(), 7))
+		assert.ErrorIs(t, err, ErrInsufficientHistory)
+	})
+}
+
+func TestBacktest(t *testing.T) {
+	history := syntheticHistory(70, nil)
+	// 最終週だけ売上が1割増えた場合、季節ナイーブは直前の週の実績で予測するため誤差が出ます
+	for i := 63; i < 70; i++ {
+		history[i].Value *= 1.1
+	}
+
+	result, err := Backtest(SeasonalNaive{}, history, 7, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Folds)
+	assert.Equal(t, 21, result.Evaluated)
+	// 3回のうち最後の1回だけ全日で 0.1/1.1 の誤差
+	assert.InDelta(t, 100*(0.1/1.1)/3, result.MAPE, 1e-9)
+
+	t.Run("履歴不足", func(t *testing.T) {
+		_, err := Backtest(SeasonalNaive{}, syntheticHistory(30, nil), 7, 3)
+		assert.ErrorIs(t, err, ErrInsufficientHistory)
+	})
+}
@@ -0,0 +1,190 @@
+package forecast
+
+import (
+	"sort"
+	"time"
+)
+
+// SeasonalNaive は直近の同じ曜日の実績をそのまま予測値とする基準モデルです
+// 他のモデルの精度を比較する基準として使います（祝日・イベント・休業日の実績は参照しません）
+type SeasonalNaive struct{}
+
+func (SeasonalNaive) Name() string { return "seasonal_naive" }
+
+func (SeasonalNaive) Forecast(history []Observation, future []Day) ([]float64, error) {
+	if len(history) < 7 {
+		return nil, ErrInsufficientHistory
+	}
+	last := make(map[time.Weekday]float64, 7)
+	for i := len(history) - 1; i >= 0 && len(last) < 7; i-- {
+		o := history[i]
+		if _, ok := last[o.Date.Weekday()]; ok || o.special() {
+			continue
+		}
+		last[o.Date.Weekday()] = o.Value
+	}
+
+	predictions := make([]float64, len(future))
+	for i, d := range future {
+		if !d.Closed {
+			predictions[i] = last[d.Date.Weekday()]
+		}
+	}
+	return predictions, nil
+}
+
+// WeekdaySeasonal は曜日ごとの季節指数と、祝日・イベントの係数を持つ指数平滑化モデルです
+//
+// 直近 Window 日の通常日（祝日・イベント・休業日以外）の実績から曜日ごとの季節指数（平均に対する比率）を求め、
+// 季節指数で割った値を指数平滑化して水準を推定します。祝日・イベントの係数は履歴中の該当日の実績と
+// 前後の通常日から見込まれる値の比率の中央値です。履歴が少ない場合、祝日は日曜日と同じ傾向とみなし、
+// イベントは影響なしとします。休業日の予測は0です。
+type WeekdaySeasonal struct {
+	// Window は季節指数と水準の推定に使う直近の日数です
+	Window int
+	// Alpha は水準の指数平滑化の係数です（大きいほど直近の実績を重視）
+	Alpha float64
+	// MinHolidaySamples・MinEventSamples は係数を履歴から推定するのに必要な祝日・イベントの日数です
+	MinHolidaySamples int
+	MinEventSamples   int
+}
+
+// NewWeekdaySeasonal は既定の設定（直近8週・平滑化係数0.2）のモデルを返します
+func NewWeekdaySeasonal() *WeekdaySeasonal {
+	return &WeekdaySeasonal{
+		Window:            56,
+		Alpha:             0.2,
+		MinHolidaySamples: 2,
+		MinEventSamples:   2,
+	}
+}
+
+// weekdaySeasonalMinDays は季節指数の推定に必要な通常日の日数です（各曜日2日分）
+const weekdaySeasonalMinDays = 14
+
+func (m *WeekdaySeasonal) Name() string { return "weekday_seasonal" }
+
+func (m *WeekdaySeasonal) Forecast(history []Observation, future []Day) ([]float64, error) {
+	window := history
+	if m.Window > 0 && len(window) > m.Window {
+		window = window[len(window)-m.Window:]
+	}
+
+	var regular []Observation
+	for _, o := range window {
+		if !o.special() {
+			regular = append(regular, o)
+		}
+	}
+	if len(regular) < weekdaySeasonalMinDays {
+		return nil, ErrInsufficientHistory
+	}
+
+	var sum float64
+	var sums, counts [7]float64
+	for _, o := range regular {
+		sum += o.Value
+		sums[o.Date.Weekday()] += o.Value
+		counts[o.Date.Weekday()]++
+	}
+	predictions := make([]float64, len(future))
+	mean := sum / float64(len(regular))
+	if mean <= 0 {
+		return predictions, nil
+	}
+
+	// 季節指数: 履歴にない曜日は平均並み、定休日など売上のない曜日は0とします
+	var factors [7]float64
+	for w := range factors {
+		factors[w] = 1
+		if counts[w] > 0 {
+			factors[w] = sums[w] / counts[w] / mean
+		}
+	}
+
+	// 水準: 季節指数で割った値の指数平滑化（初期値は最初の7日分の平均）
+	var level float64
+	var n int
+	for _, o := range regular {
+		factor := factors[o.Date.Weekday()]
+		if factor == 0 {
+			continue
+		}
+		v := o.Value / factor
+		switch {
+		case n < 7:
+			level = (level*float64(n) + v) / float64(n+1)
+		default:
+			level = m.Alpha*v + (1-m.Alpha)*level
+		}
+		n++
+	}
+
+	holidayFactor, holidayOK := specialFactor(history, factors, m.MinHolidaySamples, func(o Observation) bool {
+		return o.Holiday && !o.Event && !o.Closed
+	})
+	eventFactor, eventOK := specialFactor(history, factors, m.MinEventSamples, func(o Observation) bool {
+		return o.Event && !o.Holiday && !o.Closed
+	})
+	for i, d := range future {
+		if d.Closed {
+			continue
+		}
+		prediction := level * factors[d.Date.Weekday()]
+		if d.Holiday {
+			if holidayOK {
+				prediction *= holidayFactor
+			} else {
+				prediction = level * factors[time.Sunday]
+			}
+		}
+		if d.Event && eventOK {
+			prediction *= eventFactor
+		}
+		predictions[i] = prediction
+	}
+	return predictions, nil
+}
+
+// special は祝日・イベント・休業日のいずれかかどうかを返します
+func (o Observation) special() bool {
+	return o.Holiday || o.Event || o.Closed
+}
+
+// specialFactor は履歴中の target に当てはまる日の実績と、前後7日の通常日から見込まれる値の比率の中央値を返します
+// 当てはまる日が minSamples に満たない場合は false を返します
+func specialFactor(history []Observation, factors [7]float64, minSamples int, target func(Observation) bool) (float64, bool) {
+	var ratios []float64
+	for i, o := range history {
+		if !target(o) {
+			continue
+		}
+		var sum float64
+		var n int
+		for k := i - 7; k <= i+7; k++ {
+			if k < 0 || k >= len(history) || history[k].special() || factors[history[k].Date.Weekday()] == 0 {
+				continue
+			}
+			sum += history[k].Value / factors[history[k].Date.Weekday()]
+			n++
+		}
+		if n == 0 || sum <= 0 {
+			continue
+		}
+		expected := sum / float64(n) * factors[o.Date.Weekday()]
+		if expected <= 0 {
+			continue
+		}
+		ratios = append(ratios, o.Value/expected)
+	}
+	if len(ratios) == 0 || len(ratios) < minSamples {
+		return 0, false
+	}
+
+	sort.Float64s(ratios)
+	mid := len(ratios) / 2
+	if len(ratios)%2 == 0 {
+		return (ratios[mid-1] + ratios[mid]) / 2, true
+	}
+	return ratios[mid], true
+}
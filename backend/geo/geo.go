@@ -0,0 +1,22 @@
+// Package geo は配送で使う位置・距離の計算を提供します
+package geo
+
+import (
+	"math"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// earthRadiusKm は地球の平均半径（km）です
+const earthRadiusKm = 6371.0
+
+// Distance は2地点間の大圏距離（km）をハーバーサイン公式で求めます
+func Distance(a, b models.Location) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
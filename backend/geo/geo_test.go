@@ -0,0 +1,20 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+func TestDistance(t *testing.T) {
+	tokyo := models.Location{Latitude: 35.681236, Longitude: 139.767125}
+	shinagawa := models.Location{Latitude: 35.628471, Longitude: 139.738760}
+	osaka := models.Location{Latitude: 34.702485, Longitude: 135.495951}
+
+	assert.Equal(t, 0.0, Distance(tokyo, tokyo))
+	assert.InDelta(t, 6.4, Distance(tokyo, shinagawa), 0.1)
+	assert.InDelta(t, 403, Distance(tokyo, osaka), 1)
+	assert.InDelta(t, Distance(tokyo, osaka), Distance(osaka, tokyo), 1e-9)
+}
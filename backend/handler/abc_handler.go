@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+type ABCHandler struct {
+	abcService service.ABCServiceInterface
+}
+
+func NewABCHandler(as service.ABCServiceInterface) *ABCHandler {
+	return &ABCHandler{
+		abcService: as,
+	}
+}
+
+// Analyze は期間中の実績で商品をABC分析し、結果を各商品に保存します
+func (h *ABCHandler) Analyze(c echo.Context) error {
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	req := &service.ABCRequest{
+		Metric:    models.ABCMetric(c.QueryParam("metric")),
+		StartDate: start,
+		EndDate:   end,
+	}
+	if req.Metric == "" {
+		req.Metric = models.ABCMetricRevenue
+	}
+	for param, threshold := range map[string]*float64{
+		"classA": &req.ClassAThreshold,
+		"classB": &req.ClassBThreshold,
+	} {
+		if v := c.QueryParam(param); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "無効なしきい値です",
+				})
+			}
+			*threshold = f
+		}
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	analysis, err := h.abcService.Analyze(c.Request().Context(), req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "ABC分析に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, analysis)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+type AnomalyHandler struct {
+	anomalyService service.AnomalyServiceInterface
+}
+
+func NewAnomalyHandler(as service.AnomalyServiceInterface) *AnomalyHandler {
+	return &AnomalyHandler{
+		anomalyService: as,
+	}
+}
+
+// ReviewAnomalyRequest は異常イベントの確認結果のリクエストです
+type ReviewAnomalyRequest struct {
+	Status     models.AnomalyStatus `json:"status"`
+	ReviewedBy string               `json:"reviewedBy"`
+	Note       string               `json:"note"`
+}
+
+// ListEvents は異常イベントの一覧を取得します
+func (h *AnomalyHandler) ListEvents(c echo.Context) error {
+	skip, limit := parsePagination(c)
+	query := models.AnomalyQuery{
+		Status:  models.AnomalyStatus(c.QueryParam("status")),
+		Type:    models.AnomalyType(c.QueryParam("type")),
+		StoreID: c.QueryParam("storeId"),
+		Skip:    skip,
+		Limit:   limit,
+	}
+	if c.QueryParam("start") != "" || c.QueryParam("end") != "" {
+		start, end, err := parseDateRange(c)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		query.Start = start
+		query.End = end.AddDate(0, 0, 1)
+	}
+
+	events, err := h.anomalyService.ListEvents(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "異常イベントの取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, events)
+}
+
+// GetEvent は異常イベントを根拠とともに取得します
+func (h *AnomalyHandler) GetEvent(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な異常イベントIDです",
+		})
+	}
+
+	event, err := h.anomalyService.GetEvent(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrAnomalyNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "異常イベントが見つかりません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "異常イベントの取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, event)
+}
+
+// ReviewEvent は異常イベントを確認済みまたは却下として記録します
+func (h *AnomalyHandler) ReviewEvent(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な異常イベントIDです",
+		})
+	}
+
+	var req ReviewAnomalyRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+	if req.Status != models.AnomalyConfirmed && req.Status != models.AnomalyDismissed {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "確認結果は confirmed または dismissed を指定してください",
+		})
+	}
+	if req.ReviewedBy == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "確認者を指定してください",
+		})
+	}
+
+	event, err := h.anomalyService.ReviewEvent(c.Request().Context(), id, req.Status, req.ReviewedBy, req.Note)
+	if err != nil {
+		if errors.Is(err, service.ErrAnomalyNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "異常イベントが見つかりません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "異常イベントの更新に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, event)
+}
+
+// Detect は指定日の異常検知をすぐに実行します（日付未指定の場合は当日）
+func (h *AnomalyHandler) Detect(c echo.Context) error {
+	var date time.Time
+	if c.QueryParam("date") != "" {
+		d, err := time.Parse(dateLayout, c.QueryParam("date"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "無効な日付形式です",
+			})
+		}
+		date = d
+	}
+
+	events, err := h.anomalyService.Detect(c.Request().Context(), c.QueryParam("storeId"), date)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "異常検知に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, events)
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+// maxCalendarRangeDays は営業カレンダーを一度に取得できる日数の上限です
+const maxCalendarRangeDays = 366
+
+type CalendarHandler struct {
+	calendarService service.CalendarServiceInterface
+}
+
+func NewCalendarHandler(cs service.CalendarServiceInterface) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: cs,
+	}
+}
+
+// GetCalendar は期間（終了日を含む）の各日の種類・祝日・イベントを取得します
+func (h *CalendarHandler) GetCalendar(c echo.Context) error {
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if end.Before(start) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "終了日は開始日以降の日付を指定してください",
+		})
+	}
+	if end.Sub(start).Hours()/24 >= maxCalendarRangeDays {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "営業カレンダーは366日以内の期間を指定してください",
+		})
+	}
+
+	days, err := h.calendarService.GetCalendar(c.Request().Context(), c.QueryParam("storeId"), start, end)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "営業カレンダーの取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, days)
+}
+
+// CreateEvent は店舗のイベント（祭り・セール・臨時休業など）を登録します
+func (h *CalendarHandler) CreateEvent(c echo.Context) error {
+	var req service.StoreEventRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	event, err := h.calendarService.CreateEvent(c.Request().Context(), &req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "イベントの登録に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, event)
+}
+
+// ListEvents は店舗のイベントの一覧を取得します
+func (h *CalendarHandler) ListEvents(c echo.Context) error {
+	query := models.StoreEventQuery{
+		StoreID: c.QueryParam("storeId"),
+		Type:    models.StoreEventType(c.QueryParam("type")),
+	}
+	for param, value := range map[string]*string{
+		"start": &query.StartDate,
+		"end":   &query.EndDate,
+	} {
+		date, err := parseOptionalDate(c, param)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		if !date.IsZero() {
+			*value = date.Format(dateLayout)
+		}
+	}
+
+	events, err := h.calendarService.ListEvents(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "イベントの取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, events)
+}
+
+// UpdateEvent は店舗のイベントを更新します
+func (h *CalendarHandler) UpdateEvent(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なイベントIDです",
+		})
+	}
+	var req service.StoreEventRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	event, err := h.calendarService.UpdateEvent(c.Request().Context(), id, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrStoreEventNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "イベントが見つかりません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "イベントの更新に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, event)
+}
+
+// DeleteEvent は店舗のイベントを削除します
+func (h *CalendarHandler) DeleteEvent(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なイベントIDです",
+		})
+	}
+
+	if err := h.calendarService.DeleteEvent(c.Request().Context(), id); err != nil {
+		if errors.Is(err, service.ErrStoreEventNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "イベントが見つかりません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "イベントの削除に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "イベントを削除しました",
+	})
+}
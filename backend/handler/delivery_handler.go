@@ -2,11 +2,18 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
 
 	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type DeliveryHandler struct {
@@ -14,13 +21,17 @@ type DeliveryHandler struct {
 }
 
 type DeliveryService interface {
+	CreateDelivery(ctx context.Context, delivery *models.Delivery, actor string) error
 	GetDeliveries(query *models.DeliveryQuery) (*models.DeliveryResponse, error)
 	GetDelivery(id string) (*models.Delivery, error)
-	UpdateDelivery(id string, delivery *models.Delivery) error
-	UpdateDeliveryStatus(id string, status string) error
+	UpdateDelivery(id string, delivery *models.Delivery, actor string) error
+	UpdateDeliveryStatus(id string, status string, reason string, actor string) error
 	GetDeliveryHistory(id string) (*models.DeliveryHistoryResponse, error)
 	GetActiveDeliveries(ctx context.Context) ([]*models.Delivery, error)
 	GetDeliveriesByRobot(ctx context.Context, robotID string) ([]*models.Delivery, error)
+	UpdateDeliveryLocation(ctx context.Context, id primitive.ObjectID, tracking models.TrackingInfo, actor string) error
+	SubmitProofOfDelivery(ctx context.Context, id primitive.ObjectID, req service.ProofOfDeliveryRequest, actor string) (*models.Delivery, error)
+	OpenProofPhoto(ctx context.Context, id primitive.ObjectID) (io.ReadCloser, *models.ProofOfDelivery, error)
 }
 
 func NewDeliveryHandler(ds DeliveryService) *DeliveryHandler {
@@ -29,6 +40,79 @@ func NewDeliveryHandler(ds DeliveryService) *DeliveryHandler {
 	}
 }
 
+// CreateDelivery handles POST /api/deliveries
+// 配送時間枠（slotId）または仮押さえ（reservationId）を指定すると、枠の予約を確定して登録します
+// 受取人の暗証番号（recipientPin）を省略すると発行し、登録時の応答でのみ返します（冪等キーによる再送の応答には含めません）
+func (h *DeliveryHandler) CreateDelivery(c echo.Context) error {
+	var req struct {
+		models.Delivery
+		RecipientPIN string `json:"recipientPin"`
+		CreatedBy    string `json:"createdBy"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+	delivery := req.Delivery
+	delivery.ID = ""
+	delivery.Attempts = 0
+	delivery.ProofOfDelivery = nil
+	delivery.RecipientPIN = req.RecipientPIN
+	booking := delivery.SlotID != "" || delivery.ReservationID != ""
+
+	switch {
+	case delivery.DeliveryType == "":
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "配送種別を指定してください",
+		})
+	case delivery.Address == "":
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "配送先の住所を入力してください",
+		})
+	case delivery.EstimatedDeliveryTime.IsZero() && !booking:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "配送予定時刻または配送時間枠を指定してください",
+		})
+	case !booking && delivery.WindowStart != nil && delivery.WindowEnd != nil && delivery.WindowEnd.Before(*delivery.WindowStart):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "到着時間帯の終了は開始以降を指定してください",
+		})
+	case delivery.RecipientPIN != "" && !service.ValidRecipientPIN(delivery.RecipientPIN):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "受取人の暗証番号は4〜8桁の数字で指定してください",
+		})
+	}
+	for _, item := range delivery.Items {
+		if !primitive.IsValidObjectID(item.ProductID) || item.Quantity <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "配送する商品のIDと1以上の数量を指定してください",
+			})
+		}
+	}
+
+	if err := h.deliveryService.CreateDelivery(c.Request().Context(), &delivery, requestActor(c, req.CreatedBy)); err != nil {
+		if status, message, ok := slotError(err); ok {
+			return c.JSON(status, map[string]string{
+				"error": message,
+			})
+		}
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "配送する商品の在庫が足りません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送の登録に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, struct {
+		*models.Delivery
+		RecipientPIN string `json:"recipientPin"`
+	}{&delivery, delivery.RecipientPIN})
+}
+
 // GetDeliveries handles GET /api/deliveries
 func (h *DeliveryHandler) GetDeliveries(c echo.Context) error {
 	var query models.DeliveryQuery
@@ -58,20 +142,24 @@ func (h *DeliveryHandler) GetDelivery(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, delivery)
+	return c.JSON(http.StatusOK, withProofPhotoURL(delivery))
 }
 
 // UpdateDelivery handles PATCH /api/deliveries/:id
 func (h *DeliveryHandler) UpdateDelivery(c echo.Context) error {
 	id := c.Param("id")
-	var delivery models.Delivery
-	if err := c.Bind(&delivery); err != nil {
+	var req struct {
+		models.Delivery
+		UpdatedBy string `json:"updatedBy"`
+	}
+	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
 			"error": "無効なリクエストボディです",
 		})
 	}
+	delivery := req.Delivery
 
-	if err := h.deliveryService.UpdateDelivery(id, &delivery); err != nil {
+	if err := h.deliveryService.UpdateDelivery(id, &delivery, requestActor(c, req.UpdatedBy)); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "配送情報の更新に失敗しました",
 		})
@@ -81,10 +169,14 @@ func (h *DeliveryHandler) UpdateDelivery(c echo.Context) error {
 }
 
 // UpdateDeliveryStatus handles PATCH /api/deliveries/:id/status
+// 出発前の取り消し・保留、失敗後の再配送（準備中に戻す）や店舗への返送を含め、定義された遷移のみ受け付けます
+// reason は取り消し・保留の理由などとして配送履歴に記録します
 func (h *DeliveryHandler) UpdateDeliveryStatus(c echo.Context) error {
 	id := c.Param("id")
 	var req struct {
-		Status string `json:"status"`
+		Status    string `json:"status"`
+		Reason    string `json:"reason"`
+		UpdatedBy string `json:"updatedBy"`
 	}
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
@@ -98,7 +190,25 @@ func (h *DeliveryHandler) UpdateDeliveryStatus(c echo.Context) error {
 		})
 	}
 
-	if err := h.deliveryService.UpdateDeliveryStatus(id, req.Status); err != nil {
+	if err := h.deliveryService.UpdateDeliveryStatus(id, req.Status, req.Reason, requestActor(c, req.UpdatedBy)); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidStatusTransition):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "現在の配送ステータスからは変更できません",
+			})
+		case errors.Is(err, service.ErrMaxDeliveryAttempts):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "配送の試行回数が上限に達しているため再配送できません",
+			})
+		case errors.Is(err, service.ErrProofOfDeliveryRequired):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "配送の完了はロボット・ドローンからの配送の証跡の提出で行ってください",
+			})
+		case errors.Is(err, repository.ErrDeliveryStatusChanged):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "配送ステータスが他の操作で変更されました。再度お試しください",
+			})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "配送ステータスの更新に失敗しました",
 		})
@@ -111,6 +221,46 @@ func (h *DeliveryHandler) UpdateDeliveryStatus(c echo.Context) error {
 		})
 	}
 
+	return c.JSON(http.StatusOK, withProofPhotoURL(delivery))
+}
+
+// UpdateDeliveryLocation handles PATCH /api/deliveries/:id/location
+// ロボット・ドローンから現在位置・バッテリー残量・速度を受け取り、ライブ追跡の購読者へ配信します
+func (h *DeliveryHandler) UpdateDeliveryLocation(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な配送IDです",
+		})
+	}
+	var req struct {
+		models.TrackingInfo
+		UpdatedBy string `json:"updatedBy"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+	if err := models.ValidateTrackingInfo(req.TrackingInfo); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.deliveryService.UpdateDeliveryLocation(c.Request().Context(), id, req.TrackingInfo, requestActor(c, req.UpdatedBy)); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送位置の更新に失敗しました",
+		})
+	}
+
+	delivery, err := h.deliveryService.GetDelivery(id.Hex())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送情報の取得に失敗しました",
+		})
+	}
+
 	return c.JSON(http.StatusOK, delivery)
 }
 
@@ -126,3 +276,119 @@ func (h *DeliveryHandler) GetDeliveryHistory(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, history)
 }
+
+// SubmitProofOfDelivery handles POST /api/deliveries/:id/proof
+// ロボット・ドローンから受け渡し写真（photo）、受取人の暗証番号（pin）、受け渡し地点（latitude・longitude）、
+// 受け渡し日時（deliveredAt, RFC 3339）を multipart/form-data で受け取り、配送を完了にします
+func (h *DeliveryHandler) SubmitProofOfDelivery(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な配送IDです",
+		})
+	}
+
+	latitude, latErr := strconv.ParseFloat(c.FormValue("latitude"), 64)
+	longitude, lonErr := strconv.ParseFloat(c.FormValue("longitude"), 64)
+	if latErr != nil || lonErr != nil || latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "受け渡し地点の緯度・経度を指定してください",
+		})
+	}
+	deliveredAt, err := time.Parse(time.RFC3339, c.FormValue("deliveredAt"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "受け渡し日時をRFC 3339形式で指定してください",
+		})
+	}
+	header, err := c.FormFile("photo")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "受け渡し写真を添付してください",
+		})
+	}
+	photo, err := header.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "受け渡し写真を読み込めませんでした",
+		})
+	}
+	defer photo.Close()
+
+	delivery, err := h.deliveryService.SubmitProofOfDelivery(c.Request().Context(), id, service.ProofOfDeliveryRequest{
+		Photo:       photo,
+		PIN:         c.FormValue("pin"),
+		Location:    models.Location{Latitude: latitude, Longitude: longitude},
+		DeliveredAt: deliveredAt,
+	}, requestActor(c, c.FormValue("submittedBy")))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidProofOfDelivery):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "配送の証跡が不正です（写真はJPEG・PNG・WebP、受け渡し日時は現在以前を指定してください）",
+			})
+		case errors.Is(err, service.ErrProofPhotoTooLarge):
+			return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+				"error": "受け渡し写真のサイズが大きすぎます",
+			})
+		case errors.Is(err, service.ErrRecipientPINLocked):
+			return c.JSON(http.StatusLocked, map[string]string{
+				"error": "受取人の暗証番号の照合回数が上限に達したため、暗証番号はロックされています。配送失敗にしてから店舗へ返送してください",
+			})
+		case errors.Is(err, service.ErrRecipientPINMismatch):
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error": "受取人の暗証番号が一致しません",
+			})
+		case errors.Is(err, service.ErrDropOffOutOfRange):
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error": "受け渡し地点が配送先から離れすぎています",
+			})
+		case errors.Is(err, service.ErrDeliveryDestinationMissing):
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error": "配送先の位置が登録されていないため、受け渡し地点を確認できません。配送失敗にしてから店舗へ返送してください",
+			})
+		case errors.Is(err, service.ErrInvalidStatusTransition), errors.Is(err, repository.ErrDeliveryStatusChanged):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "配送中の配送のみ完了にできます",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送の証跡の登録に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, withProofPhotoURL(delivery))
+}
+
+// GetProofPhoto handles GET /api/deliveries/:id/proof/photo
+func (h *DeliveryHandler) GetProofPhoto(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な配送IDです",
+		})
+	}
+
+	photo, proof, err := h.deliveryService.OpenProofPhoto(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrProofOfDeliveryNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "配送の証跡が見つかりません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送の証跡の取得に失敗しました",
+		})
+	}
+	defer photo.Close()
+
+	return c.Stream(http.StatusOK, proof.PhotoContentType, photo)
+}
+
+// withProofPhotoURL は配送の証跡に写真を取得するAPIのパスを設定します
+func withProofPhotoURL(delivery *models.Delivery) *models.Delivery {
+	if delivery != nil && delivery.ProofOfDelivery != nil {
+		delivery.ProofOfDelivery.PhotoURL = "/api/deliveries/" + delivery.ID + "/proof/photo"
+	}
+	return delivery
+}
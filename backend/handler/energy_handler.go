@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+// maxEnergyRangeDays はエネルギー効率レポートを一度に集計できる日数の上限です（1分ごとの計測値の保存期間に合わせています）
+const maxEnergyRangeDays = 92
+
+type EnergyHandler struct {
+	energyService service.EnergyServiceInterface
+}
+
+func NewEnergyHandler(es service.EnergyServiceInterface) *EnergyHandler {
+	return &EnergyHandler{
+		energyService: es,
+	}
+}
+
+// GetEfficiencyReport は期間（終了日を含む）の配送機体のエネルギー効率（1kmあたりの消費電力量）を
+// 配送・機体・配送種別・積載重量・時間帯ごとに取得します。効率が悪化している機体は degrading で示します
+func (h *EnergyHandler) GetEfficiencyReport(c echo.Context) error {
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if end.Before(start) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "終了日は開始日以降の日付を指定してください",
+		})
+	}
+	if end.Sub(start).Hours()/24 >= maxEnergyRangeDays {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "エネルギー効率レポートは92日以内の期間を指定してください",
+		})
+	}
+
+	report, err := h.energyService.GetEfficiencyReport(c.Request().Context(), start, end)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "エネルギー効率レポートの取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
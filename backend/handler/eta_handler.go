@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+// maxOnTimeRangeDays は定時到着の実績を一度に集計できる日数の上限です
+const maxOnTimeRangeDays = 366
+
+type ETAHandler struct {
+	etaService service.ETAServiceInterface
+}
+
+func NewETAHandler(es service.ETAServiceInterface) *ETAHandler {
+	return &ETAHandler{
+		etaService: es,
+	}
+}
+
+// GetDeliveryETA は配送の到着を現在位置・残りの距離・機体の走行速度から予測し直して取得します
+func (h *ETAHandler) GetDeliveryETA(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な配送IDです",
+		})
+	}
+
+	eta, err := h.etaService.Predict(c.Request().Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDeliveryNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "配送が見つかりません",
+			})
+		case errors.Is(err, service.ErrETAUnavailable):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "配送が終了しているか、機体または配送先の位置が登録されていないため到着を予測できません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "到着予測に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, eta)
+}
+
+// RefreshETAs は進行中の全ての配送の到着を予測し直し、遅延アラートを更新します
+func (h *ETAHandler) RefreshETAs(c echo.Context) error {
+	etas, err := h.etaService.Refresh(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "到着予測の更新に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, etas)
+}
+
+// ListAlerts は配送の遅延アラートを取得します（status に open または resolved を指定できます）
+func (h *ETAHandler) ListAlerts(c echo.Context) error {
+	status := models.DeliveryAlertStatus(c.QueryParam("status"))
+	if status != "" && status != models.DeliveryAlertOpen && status != models.DeliveryAlertResolved {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "状態は open または resolved を指定してください",
+		})
+	}
+
+	alerts, err := h.etaService.ListAlerts(c.Request().Context(), status)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "遅延アラートの取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, alerts)
+}
+
+// GetOnTimePerformance は期間（終了日を含む）に完了した配送の定時到着の実績を取得します
+func (h *ETAHandler) GetOnTimePerformance(c echo.Context) error {
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if end.Before(start) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "終了日は開始日以降の日付を指定してください",
+		})
+	}
+	if end.Sub(start).Hours()/24 >= maxOnTimeRangeDays {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "定時到着の実績は366日以内の期間を指定してください",
+		})
+	}
+
+	performance, err := h.etaService.GetOnTimePerformance(c.Request().Context(), start, end)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "定時到着の実績の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, performance)
+}
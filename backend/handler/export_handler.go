@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/export"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+type ExportHandler struct {
+	exportService service.ExportServiceInterface
+}
+
+func NewExportHandler(es service.ExportServiceInterface) *ExportHandler {
+	return &ExportHandler{
+		exportService: es,
+	}
+}
+
+// CreateExportJobRequest は非同期エクスポートのリクエストです
+type CreateExportJobRequest struct {
+	Format     string `json:"format"`
+	StoreID    string `json:"storeId"`
+	RegisterID string `json:"registerId"`
+	Start      string `json:"start"`
+	End        string `json:"end"`
+}
+
+// ExportSales は売上を明細単位でダウンロードします（期間が長い場合は非同期エクスポートを使う）
+func (h *ExportHandler) ExportSales(c echo.Context) error {
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	format, err := export.ParseFormat(c.QueryParam("format"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "出力形式は csv, xlsx, parquet のいずれかを指定してください",
+		})
+	}
+
+	req := &service.ExportRequest{
+		Format:     format,
+		StoreID:    c.QueryParam("storeId"),
+		RegisterID: c.QueryParam("registerId"),
+		StartDate:  start,
+		EndDate:    end,
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := h.exportService.CheckSyncLimit(req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "期間が長いため、非同期エクスポート（POST /api/exports）を利用してください",
+		})
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, format.ContentType())
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, req.FileName()))
+	res.WriteHeader(http.StatusOK)
+
+	// 書き出しを始めた後はステータスを変更できないため、エラーは記録のみ行います
+	if _, err := h.exportService.ExportSales(c.Request().Context(), req, res); err != nil {
+		log.Printf("Failed to export sales: %v", err)
+	}
+	return nil
+}
+
+// CreateJob は非同期エクスポートを開始します
+func (h *ExportHandler) CreateJob(c echo.Context) error {
+	var body CreateExportJobRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+
+	format, err := export.ParseFormat(body.Format)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "出力形式は csv, xlsx, parquet のいずれかを指定してください",
+		})
+	}
+	start, err := time.Parse(dateLayout, body.Start)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な開始日付です",
+		})
+	}
+	end, err := time.Parse(dateLayout, body.End)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な終了日付です",
+		})
+	}
+
+	req := &service.ExportRequest{
+		Format:     format,
+		StoreID:    body.StoreID,
+		RegisterID: body.RegisterID,
+		StartDate:  start,
+		EndDate:    end,
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	job, err := h.exportService.CreateJob(c.Request().Context(), req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "エクスポートの開始に失敗しました",
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, "/api/exports/"+job.ID.Hex())
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// GetJob はエクスポートの進捗を取得します（完了している場合はダウンロードURLを含む）
+func (h *ExportHandler) GetJob(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なエクスポートIDです",
+		})
+	}
+
+	job, err := h.exportService.GetJob(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrExportJobNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "エクスポートが見つかりません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "エクスポートの取得に失敗しました",
+		})
+	}
+
+	if job.Status == models.ExportJobCompleted {
+		job.DownloadURL = "/api/exports/" + job.ID.Hex() + "/download"
+	}
+	return c.JSON(http.StatusOK, job)
+}
+
+// DownloadJob は完了したエクスポートのファイルをダウンロードします
+func (h *ExportHandler) DownloadJob(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なエクスポートIDです",
+		})
+	}
+
+	job, path, err := h.exportService.JobFile(c.Request().Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrExportJobNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "エクスポートが見つかりません",
+			})
+		case errors.Is(err, service.ErrExportNotReady):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "エクスポートはまだ完了していません",
+			})
+		case errors.Is(err, service.ErrExportExpired):
+			return c.JSON(http.StatusGone, map[string]string{
+				"error": "エクスポートの保存期間が過ぎています",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "エクスポートの取得に失敗しました",
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, export.Format(job.Format).ContentType())
+	return c.Attachment(path, job.FileName)
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+type FleetHandler struct {
+	fleetService service.FleetServiceInterface
+}
+
+func NewFleetHandler(fs service.FleetServiceInterface) *FleetHandler {
+	return &FleetHandler{
+		fleetService: fs,
+	}
+}
+
+// RegisterRobot は配送ロボット・ドローンを登録します
+func (h *FleetHandler) RegisterRobot(c echo.Context) error {
+	var req service.RobotRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	robot, err := h.fleetService.RegisterRobot(c.Request().Context(), &req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送機体の登録に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, robot)
+}
+
+// ListRobots は配送機体の一覧を取得します（type / status で絞り込み）
+func (h *FleetHandler) ListRobots(c echo.Context) error {
+	query := models.RobotQuery{
+		Type:   models.RobotType(c.QueryParam("type")),
+		Status: models.RobotStatus(c.QueryParam("status")),
+	}
+	if query.Status != "" && !models.ValidateRobotStatus(query.Status) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な稼働状態です",
+		})
+	}
+
+	robots, err := h.fleetService.ListRobots(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送機体の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, robots)
+}
+
+// GetRobot は配送機体を取得します
+func (h *FleetHandler) GetRobot(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な機体IDです",
+		})
+	}
+
+	robot, err := h.fleetService.GetRobot(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrRobotNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "配送機体が見つかりません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送機体の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, robot)
+}
+
+// UpdateRobot は配送機体の仕様・バッテリー残量・稼働状態を更新します
+func (h *FleetHandler) UpdateRobot(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な機体IDです",
+		})
+	}
+	var req service.RobotRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	robot, err := h.fleetService.UpdateRobot(c.Request().Context(), id, &req, requestActor(c, ""))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRobotNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "配送機体が見つかりません",
+			})
+		case errors.Is(err, service.ErrRobotStatusChanged):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "配送機体の稼働状態が変更されました。最新の状態を確認してから再度更新してください",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送機体の更新に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, robot)
+}
+
+// DeleteRobot は配送機体を削除します
+func (h *FleetHandler) DeleteRobot(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な機体IDです",
+		})
+	}
+
+	if err := h.fleetService.DeleteRobot(c.Request().Context(), id); err != nil {
+		switch {
+		case errors.Is(err, service.ErrRobotNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "配送機体が見つかりません",
+			})
+		case errors.Is(err, service.ErrRobotBusy):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "配送に割り当て中の機体は削除できません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送機体の削除に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "配送機体を削除しました",
+	})
+}
+
+// GetRobotDeliveries は配送機体に割り当てた配送の一覧を取得します
+func (h *FleetHandler) GetRobotDeliveries(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な機体IDです",
+		})
+	}
+
+	deliveries, err := h.fleetService.GetRobotDeliveries(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrRobotNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "配送機体が見つかりません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送履歴の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// AssignDelivery は配送に配送機体を割り当てます（robotId を省略した場合は条件に合う機体を自動で選択）
+func (h *FleetHandler) AssignDelivery(c echo.Context) error {
+	deliveryID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な配送IDです",
+		})
+	}
+	var req struct {
+		RobotID    string `json:"robotId"`
+		AssignedBy string `json:"assignedBy"`
+	}
+	if c.Request().ContentLength != 0 {
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "無効なリクエストボディです",
+			})
+		}
+	}
+	var robotID *primitive.ObjectID
+	if req.RobotID != "" {
+		id, err := primitive.ObjectIDFromHex(req.RobotID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "無効な機体IDです",
+			})
+		}
+		robotID = &id
+	}
+
+	assignment, err := h.fleetService.AssignDelivery(c.Request().Context(), deliveryID, robotID, requestActor(c, req.AssignedBy))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDeliveryNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "配送が見つかりません",
+			})
+		case errors.Is(err, service.ErrRobotNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "配送機体が見つかりません",
+			})
+		case errors.Is(err, service.ErrDeliveryDestinationMissing):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "配送先の位置が登録されていません",
+			})
+		case errors.Is(err, service.ErrDeliveryNotAssignable):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "配送準備中で機体が未割り当ての配送のみ割り当てできます",
+			})
+		case errors.Is(err, service.ErrNoEligibleRobot):
+			return c.JSON(http.StatusConflict, map[string]interface{}{
+				"error":      "条件を満たす配送機体がありません",
+				"candidates": assignment.Candidates,
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送機体の割り当てに失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, assignment)
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/onoderaryou/smart-store-admin/backend/forecast"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+type ForecastHandler struct {
+	forecastService service.ForecastServiceInterface
+}
+
+func NewForecastHandler(fs service.ForecastServiceInterface) *ForecastHandler {
+	return &ForecastHandler{
+		forecastService: fs,
+	}
+}
+
+// ForecastRevenue は店舗の日別売上を予測します
+func (h *ForecastHandler) ForecastRevenue(c echo.Context) error {
+	req := &service.RevenueForecastRequest{
+		StoreID: c.QueryParam("storeId"),
+		Model:   c.QueryParam("model"),
+	}
+	for param, value := range map[string]*int{
+		"horizon":     &req.Horizon,
+		"historyDays": &req.HistoryDays,
+	} {
+		if v := c.QueryParam(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "無効な日数です",
+				})
+			}
+			*value = n
+		}
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	result, err := h.forecastService.ForecastRevenue(c.Request().Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrForecastModelNotFound):
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error":  "指定した予測モデルは存在しません",
+				"models": h.forecastService.Models(),
+			})
+		case errors.Is(err, service.ErrForecastHorizonTooLong):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("予測日数は%d日以下を指定してください", h.forecastService.MaxHorizon()),
+			})
+		case errors.Is(err, forecast.ErrInsufficientHistory):
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error": "予測に必要な売上履歴が不足しています",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "売上予測に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+type MemberHandler struct {
+	loyaltyService service.LoyaltyServiceInterface
+}
+
+func NewMemberHandler(ls service.LoyaltyServiceInterface) *MemberHandler {
+	return &MemberHandler{
+		loyaltyService: ls,
+	}
+}
+
+// AdjustPointsRequest はポイント手動調整のリクエストを表します
+type AdjustPointsRequest struct {
+	Points int    `json:"points"`
+	Note   string `json:"note"`
+}
+
+// CreateMember は新しい会員を登録します
+func (h *MemberHandler) CreateMember(c echo.Context) error {
+	var member models.Member
+	if err := json.NewDecoder(c.Request().Body).Decode(&member); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+
+	if err := h.loyaltyService.CreateMember(c.Request().Context(), &member); err != nil {
+		if errors.Is(err, repository.ErrMemberCodeExists) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "この会員番号は既に登録されています",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "会員の登録に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, member)
+}
+
+// GetMember は会員情報を取得します
+func (h *MemberHandler) GetMember(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な会員IDです",
+		})
+	}
+
+	member, err := h.loyaltyService.GetMember(c.Request().Context(), id)
+	if err != nil {
+		return memberError(c, err, "会員情報の取得に失敗しました")
+	}
+
+	return c.JSON(http.StatusOK, member)
+}
+
+// ListMembers は会員一覧を取得します
+// クエリパラメータ code が指定された場合は会員番号で検索します
+func (h *MemberHandler) ListMembers(c echo.Context) error {
+	if code := c.QueryParam("code"); code != "" {
+		member, err := h.loyaltyService.GetMemberByCode(c.Request().Context(), code)
+		if err != nil {
+			return memberError(c, err, "会員情報の取得に失敗しました")
+		}
+		return c.JSON(http.StatusOK, []*models.Member{member})
+	}
+
+	skip, limit := parsePagination(c)
+	members, err := h.loyaltyService.ListMembers(c.Request().Context(), skip, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "会員一覧の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, members)
+}
+
+// UpdateMember は会員の氏名・連絡先を更新します
+func (h *MemberHandler) UpdateMember(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な会員IDです",
+		})
+	}
+
+	var member models.Member
+	if err := json.NewDecoder(c.Request().Body).Decode(&member); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+
+	member.ID = id
+	if err := h.loyaltyService.UpdateMember(c.Request().Context(), &member); err != nil {
+		return memberError(c, err, "会員情報の更新に失敗しました")
+	}
+
+	updated, err := h.loyaltyService.GetMember(c.Request().Context(), id)
+	if err != nil {
+		return memberError(c, err, "会員情報の取得に失敗しました")
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// GetPurchaseHistory は会員の購入履歴を取得します
+func (h *MemberHandler) GetPurchaseHistory(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な会員IDです",
+		})
+	}
+
+	skip, limit := parsePagination(c)
+	sales, err := h.loyaltyService.GetPurchaseHistory(c.Request().Context(), id, skip, limit)
+	if err != nil {
+		return memberError(c, err, "購入履歴の取得に失敗しました")
+	}
+
+	return c.JSON(http.StatusOK, sales)
+}
+
+// GetPointLedger は会員のポイント履歴を取得します
+func (h *MemberHandler) GetPointLedger(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な会員IDです",
+		})
+	}
+
+	skip, limit := parsePagination(c)
+	txs, err := h.loyaltyService.GetPointLedger(c.Request().Context(), id, skip, limit)
+	if err != nil {
+		return memberError(c, err, "ポイント履歴の取得に失敗しました")
+	}
+
+	return c.JSON(http.StatusOK, txs)
+}
+
+// AdjustPoints はポイントを手動で調整します
+func (h *MemberHandler) AdjustPoints(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な会員IDです",
+		})
+	}
+
+	var req AdjustPointsRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+
+	tx, err := h.loyaltyService.AdjustPoints(c.Request().Context(), id, req.Points, req.Note)
+	if err != nil {
+		if errors.Is(err, repository.ErrInsufficientPoints) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "ポイント残高が不足しています",
+			})
+		}
+		return memberError(c, err, "ポイントの調整に失敗しました")
+	}
+
+	return c.JSON(http.StatusCreated, tx)
+}
+
+// memberError は会員が存在しない場合は404、それ以外は500を返します
+func memberError(c echo.Context, err error, message string) error {
+	if errors.Is(err, service.ErrMemberNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "会員が見つかりません",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": message,
+	})
+}
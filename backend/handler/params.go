@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const dateLayout = "2006-01-02"
+
+// parseDateRange はクエリパラメータ start / end を日付として解析します
+func parseDateRange(c echo.Context) (time.Time, time.Time, error) {
+	start, err := time.Parse(dateLayout, c.QueryParam("start"))
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("無効な開始日付です")
+	}
+
+	end, err := time.Parse(dateLayout, c.QueryParam("end"))
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("無効な終了日付です")
+	}
+
+	return start, end, nil
+}
+
+// parseOptionalDate はクエリパラメータを日付として解析します（未指定の場合はゼロ値）
+func parseOptionalDate(c echo.Context, name string) (time.Time, error) {
+	if c.QueryParam(name) == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(dateLayout, c.QueryParam(name))
+	if err != nil {
+		return time.Time{}, errors.New("無効な日付形式です")
+	}
+	return date, nil
+}
+
+// parsePagination はクエリパラメータ page / limit から取得位置と件数を返します
+func parsePagination(c echo.Context) (int64, int64) {
+	page := 1
+	limit := 10
+	if p, err := strconv.Atoi(c.QueryParam("page")); err == nil && p > 0 {
+		page = p
+	}
+	if l, err := strconv.Atoi(c.QueryParam("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	return int64((page - 1) * limit), int64(limit)
+}
+
+// requestActor は変更者を返します
+// 認証済みの場合はリクエストの指定に関わらず認証済みユーザーのIDを使い、
+// 未認証の場合のみリクエストで指定された変更者（機体IDなど）を使います
+func requestActor(c echo.Context, actor string) string {
+	if userID, ok := c.Get("user_id").(primitive.ObjectID); ok && !userID.IsZero() {
+		return userID.Hex()
+	}
+	return actor
+}
@@ -66,6 +66,7 @@ func (h *ProductHandler) GetProduct(c echo.Context) error {
 }
 
 // ListProducts は商品のリストを取得します
+// category / abcClass / zeroSales で絞り込めます
 func (h *ProductHandler) ListProducts(c echo.Context) error {
 	// クエリパラメータからページネーション情報を取得
 	page := 1
@@ -81,8 +82,27 @@ func (h *ProductHandler) ListProducts(c echo.Context) error {
 		}
 	}
 
+	query := models.ProductQuery{
+		Category: c.QueryParam("category"),
+		ABCClass: models.ABCClass(c.QueryParam("abcClass")),
+	}
+	if query.ABCClass != "" && !models.ValidateABCClass(query.ABCClass) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "ABC区分は A, B, C のいずれかを指定してください",
+		})
+	}
+	if zeroSalesStr := c.QueryParam("zeroSales"); zeroSalesStr != "" {
+		zeroSales, err := strconv.ParseBool(zeroSalesStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "zeroSales は true または false を指定してください",
+			})
+		}
+		query.ZeroSales = &zeroSales
+	}
+
 	skip := int64((page - 1) * limit)
-	products, err := h.productService.List(c.Request().Context(), skip, int64(limit))
+	products, err := h.productService.List(c.Request().Context(), query, skip, int64(limit))
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "商品リストの取得に失敗しました",
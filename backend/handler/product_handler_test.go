@@ -34,8 +34,8 @@ func (m *mockProductService) GetProductByID(ctx context.Context, id primitive.Ob
 	return args.Get(0).(*models.Product), args.Error(1)
 }
 
-func (m *mockProductService) List(ctx context.Context, skip, limit int64) ([]*models.Product, error) {
-	args := m.Called(ctx, skip, limit)
+func (m *mockProductService) List(ctx context.Context, query models.ProductQuery, skip, limit int64) ([]*models.Product, error) {
+	args := m.Called(ctx, query, skip, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -249,7 +249,7 @@ func TestProductHandler_ListProducts(t *testing.T) {
 			name:        "正常系: 商品リストが取得される (ページネーションなし)",
 			queryParams: map[string]string{},
 			mockBehavior: func(s *mockProductService) {
-				s.On("List", mock.Anything, int64(0), int64(10)).Return(products, nil)
+				s.On("List", mock.Anything, models.ProductQuery{}, int64(0), int64(10)).Return(products, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   products,
@@ -258,7 +258,7 @@ func TestProductHandler_ListProducts(t *testing.T) {
 			name:        "異常系: 商品リストの取得に失敗",
 			queryParams: map[string]string{},
 			mockBehavior: func(s *mockProductService) {
-				s.On("List", mock.Anything, int64(0), int64(10)).Return(nil, assert.AnError)
+				s.On("List", mock.Anything, models.ProductQuery{}, int64(0), int64(10)).Return(nil, assert.AnError)
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/receipt"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+type ReceiptHandler struct {
+	receiptService service.ReceiptServiceInterface
+}
+
+func NewReceiptHandler(rs service.ReceiptServiceInterface) *ReceiptHandler {
+	return &ReceiptHandler{
+		receiptService: rs,
+	}
+}
+
+// GetReceipt は売上のレシートを指定された形式（text / html / pdf）で返します
+func (h *ReceiptHandler) GetReceipt(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な売上IDです",
+		})
+	}
+
+	format, err := receipt.ParseFormat(c.QueryParam("format"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なレシート形式です",
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := h.receiptService.RenderReceipt(c.Request().Context(), id, format, &buf); err != nil {
+		if errors.Is(err, service.ErrSaleNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "売上が見つかりません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "レシートの生成に失敗しました",
+		})
+	}
+
+	if format == receipt.FormatPDF {
+		c.Response().Header().Set(echo.HeaderContentDisposition, `inline; filename="receipt-`+id.Hex()+`.pdf"`)
+	}
+	return c.Blob(http.StatusOK, format.ContentType(), buf.Bytes())
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+type RegisterSessionHandler struct {
+	sessionService service.RegisterSessionServiceInterface
+}
+
+func NewRegisterSessionHandler(rs service.RegisterSessionServiceInterface) *RegisterSessionHandler {
+	return &RegisterSessionHandler{
+		sessionService: rs,
+	}
+}
+
+// OpenSession はレジを開局します
+func (h *RegisterSessionHandler) OpenSession(c echo.Context) error {
+	var req service.OpenRegisterSessionRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+
+	session, err := h.sessionService.OpenSession(c.Request().Context(), &req)
+	if err != nil {
+		if errors.Is(err, repository.ErrRegisterSessionAlreadyOpen) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "このレジは既に開局しています",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "レジの開局に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, session)
+}
+
+// CloseSession は実査額を記録してレジを締めます
+func (h *RegisterSessionHandler) CloseSession(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なセッションIDです",
+		})
+	}
+
+	var req service.CloseRegisterSessionRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+
+	session, err := h.sessionService.CloseSession(c.Request().Context(), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRegisterSessionNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "セッションが見つかりません",
+			})
+		case errors.Is(err, repository.ErrRegisterSessionNotOpen):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "このセッションは既に締められています",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "レジ締めに失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, session)
+}
+
+// GetSession は精算セッションを取得します
+func (h *RegisterSessionHandler) GetSession(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なセッションIDです",
+		})
+	}
+
+	session, err := h.sessionService.GetSession(c.Request().Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrRegisterSessionNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "セッションが見つかりません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "セッションの取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, session)
+}
+
+// ListSessions は営業日の精算セッション一覧を取得します
+func (h *RegisterSessionHandler) ListSessions(c echo.Context) error {
+	date, err := time.Parse(dateLayout, c.QueryParam("date"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な日付形式です",
+		})
+	}
+
+	sessions, err := h.sessionService.ListSessions(c.Request().Context(), c.QueryParam("storeId"), c.QueryParam("registerId"), date)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "セッション一覧の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, sessions)
+}
+
+// GetZReport はレジまたは店舗の日次精算レポートを取得します
+func (h *RegisterSessionHandler) GetZReport(c echo.Context) error {
+	date, err := time.Parse(dateLayout, c.QueryParam("date"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な日付形式です",
+		})
+	}
+
+	storeID := c.QueryParam("storeId")
+	if storeID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "店舗IDを指定してください",
+		})
+	}
+
+	report, err := h.sessionService.GetZReport(c.Request().Context(), storeID, c.QueryParam("registerId"), date)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Zレポートの作成に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+type RouteHandler struct {
+	routeService service.RouteServiceInterface
+}
+
+func NewRouteHandler(rs service.RouteServiceInterface) *RouteHandler {
+	return &RouteHandler{
+		routeService: rs,
+	}
+}
+
+// PlanRoutes は配送ロボット・ドローンで配送準備中の配送を巡回するルートと到着予定を計画します
+func (h *RouteHandler) PlanRoutes(c echo.Context) error {
+	var req service.RoutePlanRequest
+	if c.Request().ContentLength != 0 {
+		if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "無効なリクエストボディです",
+			})
+		}
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	plan, err := h.routeService.PlanRoutes(c.Request().Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRobotNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "配送機体が見つかりません",
+			})
+		case errors.Is(err, service.ErrDeliveryNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "配送が見つかりません",
+			})
+		case errors.Is(err, service.ErrDeliveryDestinationMissing):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "配送先の位置が登録されていません",
+			})
+		case errors.Is(err, service.ErrDeliveryNotAssignable):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "配送準備中の配送のみルートに入れられます",
+			})
+		case errors.Is(err, service.ErrRobotUnavailable):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "待機中または出発前の機体のみルートを計画できます",
+			})
+		case errors.Is(err, service.ErrNoRoutableRobot):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "ルートを計画できる配送機体がありません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送ルートの計画に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, plan)
+}
@@ -2,12 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
 
 	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
 	"github.com/onoderaryou/smart-store-admin/backend/service"
 )
 
@@ -31,6 +34,20 @@ func (h *SaleHandler) CreateSale(c echo.Context) error {
 	}
 
 	if err := h.saleService.Create(c.Request().Context(), &sale); err != nil {
+		switch {
+		case errors.Is(err, service.ErrMemberNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "会員が見つかりません",
+			})
+		case errors.Is(err, repository.ErrInsufficientPoints):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "ポイント残高が不足しています",
+			})
+		case errors.Is(err, repository.ErrDuplicateTerminalTransaction):
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "この端末取引は既に記録されています",
+			})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "売上の記録に失敗しました",
 		})
@@ -39,6 +56,59 @@ func (h *SaleHandler) CreateSale(c echo.Context) error {
 	return c.JSON(http.StatusCreated, sale)
 }
 
+// bulkImportSummary は一括登録の応答の最終行です
+type bulkImportSummary struct {
+	Summary *models.BulkSaleSummary `json:"summary"`
+	Error   string                  `json:"error,omitempty"`
+}
+
+// ImportSales は改行区切りJSON（NDJSON）の売上を一括登録します
+// 行ごとの結果をNDJSONで逐次返し、最終行に全体の結果（再送を始める行を含む）を返します
+// 再送時はクエリパラメータ offset に前回の lastAcknowledgedLine を指定します
+func (h *SaleHandler) ImportSales(c echo.Context) error {
+	offset := 0
+	if v := c.QueryParam("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "無効な行番号です",
+			})
+		}
+		offset = n
+	}
+
+	opts := service.ImportSalesOptions{
+		StoreID:    c.QueryParam("storeId"),
+		RegisterID: c.QueryParam("registerId"),
+		LineOffset: offset,
+	}
+
+	// リクエストを読み込みながら結果を返すため、HTTP/1.1でも全二重で通信する
+	res := c.Response()
+	_ = http.NewResponseController(res.Writer).EnableFullDuplex()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	res.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(res)
+	summary, err := h.saleService.ImportSales(c.Request().Context(), c.Request().Body, opts, func(result models.BulkSaleResult) error {
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	})
+
+	final := bulkImportSummary{Summary: summary}
+	if err != nil {
+		final.Error = "売上の一括登録を中断しました: " + err.Error()
+	}
+	if err := enc.Encode(final); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
+
 // GetDailySales は日次の売上データを取得します
 func (h *SaleHandler) GetDailySales(c echo.Context) error {
 	dateStr := c.QueryParam("date")
@@ -116,3 +186,106 @@ func (h *SaleHandler) GetEnvironmentalImpact(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, impact)
 }
+
+// GetSalesByCategory はカテゴリー別の販売数・売上・構成比を前期比較付きで取得します
+func (h *SaleHandler) GetSalesByCategory(c echo.Context) error {
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	report, err := h.saleService.GetCategorySalesReport(c.Request().Context(), start, end)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリー別売上の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetSalesHeatmap は曜日×時間帯の売上ヒートマップとピーク時間帯を取得します（終了日を含む）
+func (h *SaleHandler) GetSalesHeatmap(c echo.Context) error {
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if end.Before(start) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "終了日は開始日以降の日付を指定してください",
+		})
+	}
+
+	heatmap, err := h.saleService.GetSalesHeatmap(c.Request().Context(), c.QueryParam("storeId"), start, end)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "売上ヒートマップの取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, heatmap)
+}
+
+// GetMarginReport は商品・カテゴリ・期間・プロモーション・日の種類別の粗利を取得します（dayType で日の種類を絞り込み）
+func (h *SaleHandler) GetMarginReport(c echo.Context) error {
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	query := service.MarginQuery{
+		GroupBy:   models.MarginGroupBy(c.QueryParam("groupBy")),
+		Interval:  models.MarginInterval(c.QueryParam("interval")),
+		StoreID:   c.QueryParam("storeId"),
+		StartDate: start,
+		EndDate:   end,
+		DayType:   models.DayType(c.QueryParam("dayType")),
+	}
+	if query.GroupBy == "" {
+		query.GroupBy = models.MarginByProduct
+	}
+	if err := query.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	report, err := h.saleService.GetMarginReport(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "粗利レポートの取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetDayTypeSales は平日・週末・祝日・イベント・休業日別の売上を取得します（終了日を含む）
+func (h *SaleHandler) GetDayTypeSales(c echo.Context) error {
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if end.Before(start) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "終了日は開始日以降の日付を指定してください",
+		})
+	}
+
+	report, err := h.saleService.GetDayTypeSales(c.Request().Context(), c.QueryParam("storeId"), start, end)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "日の種類別売上の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
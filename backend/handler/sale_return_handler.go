@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+type SaleReturnHandler struct {
+	returnService service.SaleReturnServiceInterface
+}
+
+func NewSaleReturnHandler(rs service.SaleReturnServiceInterface) *SaleReturnHandler {
+	return &SaleReturnHandler{
+		returnService: rs,
+	}
+}
+
+// CreateReturn は売上の取消・返品を記録します
+func (h *SaleReturnHandler) CreateReturn(c echo.Context) error {
+	saleID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な売上IDです",
+		})
+	}
+
+	var ret models.SaleReturn
+	if err := json.NewDecoder(c.Request().Body).Decode(&ret); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+
+	if err := h.returnService.CreateReturn(c.Request().Context(), saleID, &ret); err != nil {
+		if errors.Is(err, service.ErrSaleNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "売上が見つかりません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "取消・返品の記録に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, ret)
+}
+
+// GetReturns は売上に対する取消・返品を取得します
+func (h *SaleReturnHandler) GetReturns(c echo.Context) error {
+	saleID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な売上IDです",
+		})
+	}
+
+	returns, err := h.returnService.GetReturns(c.Request().Context(), saleID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "取消・返品の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, returns)
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+type SalesTargetHandler struct {
+	targetService service.SalesTargetServiceInterface
+}
+
+func NewSalesTargetHandler(ts service.SalesTargetServiceInterface) *SalesTargetHandler {
+	return &SalesTargetHandler{
+		targetService: ts,
+	}
+}
+
+// SetTarget は売上目標を登録します（同じ店舗・カテゴリ・期間の目標は上書き）
+func (h *SalesTargetHandler) SetTarget(c echo.Context) error {
+	var req service.SalesTargetRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	target, err := h.targetService.SetTarget(c.Request().Context(), &req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "売上目標の登録に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, target)
+}
+
+// ListTargets は売上目標の一覧を取得します
+func (h *SalesTargetHandler) ListTargets(c echo.Context) error {
+	query := models.SalesTargetQuery{
+		StoreID:    c.QueryParam("storeId"),
+		PeriodType: models.TargetPeriodType(c.QueryParam("periodType")),
+	}
+	if c.QueryParam("start") != "" || c.QueryParam("end") != "" {
+		start, end, err := parseDateRange(c)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		query.Start = start
+		query.End = end.AddDate(0, 0, 1)
+	}
+
+	targets, err := h.targetService.ListTargets(c.Request().Context(), query)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "売上目標の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, targets)
+}
+
+// DeleteTarget は売上目標を削除します
+func (h *SalesTargetHandler) DeleteTarget(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な売上目標IDです",
+		})
+	}
+
+	if err := h.targetService.DeleteTarget(c.Request().Context(), id); err != nil {
+		if errors.Is(err, service.ErrSalesTargetNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "売上目標が見つかりません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "売上目標の削除に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "売上目標を削除しました",
+	})
+}
+
+// GetProgress は売上目標の予実（実績・ペース・着地見込み）を取得します
+func (h *SalesTargetHandler) GetProgress(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な売上目標IDです",
+		})
+	}
+	date, err := parseOptionalDate(c, "date")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	progress, err := h.targetService.GetProgress(c.Request().Context(), id, date)
+	if err != nil {
+		if errors.Is(err, service.ErrSalesTargetNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "売上目標が見つかりません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "売上目標の予実の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, progress)
+}
+
+// GetStoreProgress は指定日を含む店舗の日次・月次目標の予実を取得します
+func (h *SalesTargetHandler) GetStoreProgress(c echo.Context) error {
+	storeID := c.QueryParam("storeId")
+	if storeID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "店舗IDを指定してください",
+		})
+	}
+	date, err := parseOptionalDate(c, "date")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	progress, err := h.targetService.GetStoreProgress(c.Request().Context(), storeID, date)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "売上目標の予実の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, progress)
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+// maxSlotRangeDays は配送時間枠の空き状況を一度に照会できる日数の上限です
+const maxSlotRangeDays = 31
+
+type SlotHandler struct {
+	slotService service.SlotServiceInterface
+}
+
+func NewSlotHandler(ss service.SlotServiceInterface) *SlotHandler {
+	return &SlotHandler{
+		slotService: ss,
+	}
+}
+
+// ListTemplates は曜日ごとの配送時間枠のテンプレートを取得します
+func (h *SlotHandler) ListTemplates(c echo.Context) error {
+	templates, err := h.slotService.ListTemplates(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送時間枠のテンプレートの取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, templates)
+}
+
+// SetTemplate は曜日（0: 日曜日 〜 6: 土曜日）の配送時間枠のテンプレートを登録・更新します
+func (h *SlotHandler) SetTemplate(c echo.Context) error {
+	weekday, err := strconv.Atoi(c.Param("weekday"))
+	if err != nil || weekday < 0 || weekday > 6 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "曜日は0（日曜日）〜6（土曜日）で指定してください",
+		})
+	}
+
+	var req service.SlotTemplateRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	template, err := h.slotService.SetTemplate(c.Request().Context(), time.Weekday(weekday), &req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送時間枠のテンプレートの登録に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, template)
+}
+
+// GetAvailability は期間（終了日を含む）の配送時間枠と空き件数を取得します
+func (h *SlotHandler) GetAvailability(c echo.Context) error {
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if end.Before(start) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "終了日は開始日以降の日付を指定してください",
+		})
+	}
+	if end.Sub(start).Hours()/24 >= maxSlotRangeDays {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "配送時間枠は31日以内の期間を指定してください",
+		})
+	}
+
+	slots, err := h.slotService.GetAvailability(c.Request().Context(), start, end)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送時間枠の空き状況の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, slots)
+}
+
+// HoldSlot は配送時間枠を仮押さえします
+// 期限（expiresAt）までに reservationId を指定して配送を登録すると予約が確定します
+func (h *SlotHandler) HoldSlot(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な配送時間枠IDです",
+		})
+	}
+
+	reservation, err := h.slotService.Hold(c.Request().Context(), id)
+	if err != nil {
+		if status, message, ok := slotError(err); ok {
+			return c.JSON(status, map[string]string{
+				"error": message,
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送時間枠の仮押さえに失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, reservation)
+}
+
+// GetReservation は配送時間枠の予約を取得します
+func (h *SlotHandler) GetReservation(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な予約IDです",
+		})
+	}
+
+	reservation, err := h.slotService.GetReservation(c.Request().Context(), id)
+	if err != nil {
+		if status, message, ok := slotError(err); ok {
+			return c.JSON(status, map[string]string{
+				"error": message,
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "予約の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, reservation)
+}
+
+// CancelReservation は配送時間枠の予約を取り消し、枠の空きを戻します
+func (h *SlotHandler) CancelReservation(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な予約IDです",
+		})
+	}
+
+	if err := h.slotService.CancelReservation(c.Request().Context(), id); err != nil {
+		if errors.Is(err, service.ErrReservationNotHeld) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "既に取り消し済み、または期限切れの予約です",
+			})
+		}
+		if status, message, ok := slotError(err); ok {
+			return c.JSON(status, map[string]string{
+				"error": message,
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "予約の取り消しに失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "予約を取り消しました",
+	})
+}
+
+// slotError は配送時間枠の予約のエラーに対応するステータスコードとメッセージを返します
+func slotError(err error) (int, string, bool) {
+	switch {
+	case errors.Is(err, service.ErrSlotNotFound):
+		return http.StatusNotFound, "配送時間枠が見つかりません", true
+	case errors.Is(err, service.ErrReservationNotFound):
+		return http.StatusNotFound, "予約が見つかりません", true
+	case errors.Is(err, service.ErrSlotFull):
+		return http.StatusConflict, "配送時間枠が満席です", true
+	case errors.Is(err, service.ErrSlotClosed):
+		return http.StatusConflict, "配送時間枠の受付は終了しました", true
+	case errors.Is(err, service.ErrReservationNotHeld):
+		return http.StatusConflict, "仮押さえの期限が切れているか、既に使用された予約です", true
+	}
+	return 0, "", false
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+type StoreHandler struct {
+	settingsService service.StoreSettingsServiceInterface
+}
+
+func NewStoreHandler(ss service.StoreSettingsServiceInterface) *StoreHandler {
+	return &StoreHandler{
+		settingsService: ss,
+	}
+}
+
+// GetSettings は店舗の設定（レシートのレイアウト等）を取得します
+func (h *StoreHandler) GetSettings(c echo.Context) error {
+	settings, err := h.settingsService.GetSettings(c.Request().Context(), c.Param("storeId"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "店舗設定の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings は店舗の設定を更新します
+func (h *StoreHandler) UpdateSettings(c echo.Context) error {
+	var settings models.StoreSettings
+	if err := json.NewDecoder(c.Request().Body).Decode(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+
+	settings.StoreID = c.Param("storeId")
+	if err := h.settingsService.UpdateSettings(c.Request().Context(), &settings); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "店舗設定の更新に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
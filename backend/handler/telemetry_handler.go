@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+)
+
+// defaultTrackWindow は走行履歴の期間を省略した場合に取得する直近の期間です
+const defaultTrackWindow = time.Hour
+
+type TelemetryHandler struct {
+	telemetryService service.TelemetryServiceInterface
+}
+
+func NewTelemetryHandler(ts service.TelemetryServiceInterface) *TelemetryHandler {
+	return &TelemetryHandler{
+		telemetryService: ts,
+	}
+}
+
+// IngestTelemetry は配送機体からまとめて送信された計測値（位置・バッテリー残量・速度）を受信します
+func (h *TelemetryHandler) IngestTelemetry(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な機体IDです",
+		})
+	}
+
+	var req service.TelemetryBatchRequest
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効なリクエストボディです",
+		})
+	}
+	if err := req.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	result, err := h.telemetryService.Ingest(c.Request().Context(), id, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRobotNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "配送機体が見つかりません",
+			})
+		case errors.Is(err, service.ErrTelemetryOutOfRange):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "計測日時が現在より先、または保存期間を過ぎています",
+			})
+		case errors.Is(err, service.ErrTelemetryAlreadyRolledUp):
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error": "計測日時の1分間は集約済みのため、計測値を受け付けられません",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "計測値の保存に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// GetTrack は配送機体の走行履歴を取得します
+// from / to（RFC 3339）を省略した場合は直近1時間、resolution は raw または minute を指定できます
+func (h *TelemetryHandler) GetTrack(c echo.Context) error {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "無効な機体IDです",
+		})
+	}
+
+	to := time.Now()
+	if c.QueryParam("to") != "" {
+		if to, err = time.Parse(time.RFC3339, c.QueryParam("to")); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "無効な終了日時です",
+			})
+		}
+	}
+	from := to.Add(-defaultTrackWindow)
+	if c.QueryParam("from") != "" {
+		if from, err = time.Parse(time.RFC3339, c.QueryParam("from")); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "無効な開始日時です",
+			})
+		}
+	}
+	if !to.After(from) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "終了日時は開始日時より後を指定してください",
+		})
+	}
+	resolution := models.TelemetryResolution(c.QueryParam("resolution"))
+	if resolution != "" && resolution != models.TelemetryRaw && resolution != models.TelemetryMinute {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "解像度は raw または minute を指定してください",
+		})
+	}
+
+	track, err := h.telemetryService.GetTrack(c.Request().Context(), id, from, to, resolution)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRobotNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "配送機体が見つかりません",
+			})
+		case errors.Is(err, service.ErrTelemetryWindowTooLarge):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "期間が長すぎます。期間を短くするか、resolution=minute を指定してください",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "走行履歴の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, track)
+}
+
+// GetFleetState は全ての配送機体の最新の状態を取得します
+func (h *TelemetryHandler) GetFleetState(c echo.Context) error {
+	states, err := h.telemetryService.GetFleetState(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "配送機体の状態の取得に失敗しました",
+		})
+	}
+
+	return c.JSON(http.StatusOK, states)
+}
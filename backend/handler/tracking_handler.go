@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
+	"github.com/onoderaryou/smart-store-admin/backend/tracking"
+)
+
+// TrackingHandler は配送のライブ追跡（Server-Sent Events・WebSocket）のハンドラーです
+// クエリパラメータ deliveryId で1件の配送、robotId で1台の機体に絞り込み、どちらも省略した場合は全てのアクティブな配送を購読します
+type TrackingHandler struct {
+	trackingService service.TrackingServiceInterface
+	// heartbeat は接続を維持するために空のイベントを送る間隔です
+	heartbeat time.Duration
+}
+
+// defaultTrackingHeartbeat は送信間隔を指定しなかった場合の接続維持の間隔です
+const defaultTrackingHeartbeat = 15 * time.Second
+
+func NewTrackingHandler(ts service.TrackingServiceInterface, heartbeat time.Duration) *TrackingHandler {
+	if heartbeat <= 0 {
+		heartbeat = defaultTrackingHeartbeat
+	}
+	return &TrackingHandler{
+		trackingService: ts,
+		heartbeat:       heartbeat,
+	}
+}
+
+// StreamEvents は配送の追跡イベントを Server-Sent Events で配信します
+// 再接続時は Last-Event-ID ヘッダー（EventSource が自動で送ります）またはクエリパラメータ cursor から再開します
+func (h *TrackingHandler) StreamEvents(c echo.Context) error {
+	cursor := c.Request().Header.Get("Last-Event-ID")
+	if cursor == "" {
+		cursor = c.QueryParam("cursor")
+	}
+	stream, err := h.trackingService.Subscribe(c.Request().Context(), trackingFilter(c), cursor)
+	if err != nil {
+		return trackingError(c, err)
+	}
+	defer stream.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	for _, event := range stream.Initial {
+		if err := writeServerSentEvent(res, event); err != nil {
+			return nil
+		}
+	}
+	res.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-stream.Events():
+			if !ok {
+				// 受信が追いつかず切断されました。クライアントは最後のカーソルから再接続します
+				return nil
+			}
+			if err := writeServerSentEvent(res, event); err != nil {
+				return nil
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		}
+		res.Flush()
+	}
+}
+
+// StreamWebSocket は配送の追跡イベントを WebSocket（JSON のテキストメッセージ）で配信します
+// 再接続時はクエリパラメータ cursor に最後に受け取ったイベントの cursor を指定して再開します
+func (h *TrackingHandler) StreamWebSocket(c echo.Context) error {
+	stream, err := h.trackingService.Subscribe(c.Request().Context(), trackingFilter(c), c.QueryParam("cursor"))
+	if err != nil {
+		return trackingError(c, err)
+	}
+	defer stream.Close()
+
+	server := websocket.Server{
+		// アクセストークンで認証するため、接続元のオリジンは制限しません
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			// クライアントからの切断を検知するため、受信したメッセージは読み捨てます
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var message string
+				for {
+					if err := websocket.Message.Receive(ws, &message); err != nil {
+						return
+					}
+				}
+			}()
+
+			for _, event := range stream.Initial {
+				if err := websocket.JSON.Send(ws, event); err != nil {
+					return
+				}
+			}
+
+			ticker := time.NewTicker(h.heartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-closed:
+					return
+				case event, ok := <-stream.Events():
+					if !ok {
+						return
+					}
+					if err := websocket.JSON.Send(ws, event); err != nil {
+						return
+					}
+				case now := <-ticker.C:
+					heartbeat := models.TrackingEvent{Type: models.TrackingHeartbeat, Timestamp: now}
+					if err := websocket.JSON.Send(ws, heartbeat); err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// trackingFilter はクエリパラメータ deliveryId / robotId から購読の条件を作成します
+func trackingFilter(c echo.Context) tracking.Filter {
+	return tracking.Filter{
+		DeliveryID: c.QueryParam("deliveryId"),
+		RobotID:    c.QueryParam("robotId"),
+	}
+}
+
+// trackingError は購読を開始できなかった場合の応答を返します
+func trackingError(c echo.Context, err error) error {
+	if errors.Is(err, service.ErrDeliveryNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "配送が見つかりません",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "配送の追跡の開始に失敗しました",
+	})
+}
+
+// writeServerSentEvent はイベントを Server-Sent Events の形式（id にカーソル、event に種類）で書き込みます
+func writeServerSentEvent(w http.ResponseWriter, event models.TrackingEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if event.Cursor != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", event.Cursor); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
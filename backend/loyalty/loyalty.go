@@ -0,0 +1,110 @@
+// Package loyalty は会員ポイントの付与・利用の計算を提供します
+//
+// 通常ポイントは支払額（ポイント利用分を除く）に対して付与し、
+// CO2排出量の少ない商品の購入額にはボーナスポイントを上乗せします。
+package loyalty
+
+import (
+	"errors"
+	"math"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// Config はポイント付与・利用のルールを保持します
+type Config struct {
+	// AmountPerPoint は1ポイントを付与する支払額（円）です
+	AmountPerPoint float64
+	// LowCO2Threshold は低CO2商品とみなす1個あたりのCO2排出量の上限（kg）です
+	LowCO2Threshold float64
+	// LowCO2Multiplier は低CO2商品の購入額に対するポイント倍率です（1で上乗せなし）
+	LowCO2Multiplier float64
+	// PointValue は1ポイントを支払いに利用する際の価値（円）です
+	PointValue float64
+}
+
+// DefaultConfig は標準的なポイントルールを返します
+func DefaultConfig() Config {
+	return Config{
+		AmountPerPoint:   100,
+		LowCO2Threshold:  0.5,
+		LowCO2Multiplier: 2,
+		PointValue:       1,
+	}
+}
+
+// Validate はルールが正しいかどうかを検証します
+func (c Config) Validate() error {
+	if c.AmountPerPoint <= 0 {
+		return errors.New("amount per point must be positive")
+	}
+	if c.LowCO2Threshold < 0 {
+		return errors.New("low CO2 threshold must be non-negative")
+	}
+	if c.LowCO2Multiplier < 1 {
+		return errors.New("low CO2 multiplier must be at least 1")
+	}
+	if c.PointValue <= 0 {
+		return errors.New("point value must be positive")
+	}
+	return nil
+}
+
+// Earned は売上で付与されるポイントを表します
+type Earned struct {
+	Base  int // 通常ポイント
+	Bonus int // 低CO2ボーナスポイント
+}
+
+// Total は付与ポイントの合計を返します
+func (e Earned) Total() int {
+	return e.Base + e.Bonus
+}
+
+// Calculator はポイントを計算します
+type Calculator struct {
+	config Config
+}
+
+// NewCalculator は新しいポイント計算機を作成します
+func NewCalculator(config Config) *Calculator {
+	return &Calculator{config: config}
+}
+
+// Config は計算機の設定を返します
+func (c *Calculator) Config() Config {
+	return c.config
+}
+
+// IsLowCO2 は1個あたりのCO2排出量が低CO2商品の基準を満たすかどうかを返します
+// 排出量が未登録（0）の商品は対象外です
+func (c *Calculator) IsLowCO2(co2Emission float64) bool {
+	return co2Emission > 0 && co2Emission <= c.config.LowCO2Threshold
+}
+
+// RedemptionValue はポイント利用額（円）を返します
+func (c *Calculator) RedemptionValue(points int) float64 {
+	return float64(points) * c.config.PointValue
+}
+
+// Earn は売上の付与ポイントを計算します
+// 値引きとポイント利用分は対象額から除き、低CO2商品の購入額は対象額を上限とします
+func (c *Calculator) Earn(sale *models.Sale) Earned {
+	paid := sale.TotalAmount - sale.PointsPayment
+	if paid <= 0 {
+		return Earned{}
+	}
+
+	var lowCO2 float64
+	for _, item := range sale.Items {
+		if c.IsLowCO2(item.CO2Emission) {
+			lowCO2 += item.PriceAtSale * float64(item.Quantity)
+		}
+	}
+	lowCO2 = math.Min(lowCO2, paid)
+
+	return Earned{
+		Base:  int(math.Floor(paid / c.config.AmountPerPoint)),
+		Bonus: int(math.Floor(lowCO2 * (c.config.LowCO2Multiplier - 1) / c.config.AmountPerPoint)),
+	}
+}
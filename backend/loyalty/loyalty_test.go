@@ -0,0 +1,83 @@
+package loyalty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+func TestEarn(t *testing.T) {
+	calc := NewCalculator(DefaultConfig())
+
+	tests := []struct {
+		name string
+		sale *models.Sale
+		want Earned
+	}{
+		{
+			name: "通常ポイントのみ",
+			sale: &models.Sale{
+				Items:       []models.SaleItem{{Quantity: 2, PriceAtSale: 550, CO2Emission: 1.2}},
+				TotalAmount: 1100,
+			},
+			want: Earned{Base: 11},
+		},
+		{
+			name: "低CO2商品のボーナス",
+			sale: &models.Sale{
+				Items: []models.SaleItem{
+					{Quantity: 1, PriceAtSale: 1000, CO2Emission: 1.2},
+					{Quantity: 3, PriceAtSale: 150, CO2Emission: 0.3},
+				},
+				TotalAmount: 1450,
+			},
+			want: Earned{Base: 14, Bonus: 4},
+		},
+		{
+			name: "CO2排出量が未登録の商品はボーナス対象外",
+			sale: &models.Sale{
+				Items:       []models.SaleItem{{Quantity: 1, PriceAtSale: 500}},
+				TotalAmount: 500,
+			},
+			want: Earned{Base: 5},
+		},
+		{
+			name: "ポイント利用分は付与対象外",
+			sale: &models.Sale{
+				Items:         []models.SaleItem{{Quantity: 1, PriceAtSale: 1000, CO2Emission: 0.2}},
+				TotalAmount:   1000,
+				PointsPayment: 700,
+			},
+			want: Earned{Base: 3, Bonus: 3},
+		},
+		{
+			name: "全額ポイント払いは付与なし",
+			sale: &models.Sale{
+				Items:         []models.SaleItem{{Quantity: 1, PriceAtSale: 300}},
+				TotalAmount:   300,
+				PointsPayment: 300,
+			},
+			want: Earned{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, calc.Earn(tt.sale))
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+
+	cfg := DefaultConfig()
+	cfg.AmountPerPoint = 0
+	assert.Error(t, cfg.Validate())
+
+	cfg = DefaultConfig()
+	cfg.LowCO2Multiplier = 0.5
+	assert.Error(t, cfg.Validate())
+}
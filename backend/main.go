@@ -1,20 +1,38 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"time"
+	_ "time/tzdata"
 
+	"github.com/onoderaryou/smart-store-admin/backend/calendar"
 	"github.com/onoderaryou/smart-store-admin/backend/config"
 	"github.com/onoderaryou/smart-store-admin/backend/db"
+	"github.com/onoderaryou/smart-store-admin/backend/forecast"
 	"github.com/onoderaryou/smart-store-admin/backend/handler"
+	"github.com/onoderaryou/smart-store-admin/backend/loyalty"
+	"github.com/onoderaryou/smart-store-admin/backend/middleware"
 	"github.com/onoderaryou/smart-store-admin/backend/repository"
 	"github.com/onoderaryou/smart-store-admin/backend/router"
+	"github.com/onoderaryou/smart-store-admin/backend/routing"
 	"github.com/onoderaryou/smart-store-admin/backend/service"
+	"github.com/onoderaryou/smart-store-admin/backend/storage"
+	"github.com/onoderaryou/smart-store-admin/backend/tax"
+	"github.com/onoderaryou/smart-store-admin/backend/tracking"
 )
 
 func main() {
 	// 設定の読み込み
 	cfg := config.NewConfig()
 
+	// サブコマンド
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		runExportCommand(cfg, os.Args[2:])
+		return
+	}
+
 	// データベース接続
 	mongodb, err := db.NewMongoDB(cfg.MongoURI)
 	if err != nil {
@@ -22,23 +40,210 @@ func main() {
 	}
 	defer mongodb.Close()
 
+	// 店舗のタイムゾーン
+	storeLocation := loadStoreLocation(cfg)
+
+	// 消費税計算の設定
+	taxCalc := newTaxCalculator(cfg)
+
+	// 会員ポイントの設定
+	loyaltyConfig := loyalty.Config{
+		AmountPerPoint:   cfg.LoyaltyAmountPerPoint,
+		LowCO2Threshold:  cfg.LoyaltyLowCO2Threshold,
+		LowCO2Multiplier: cfg.LoyaltyLowCO2Multiplier,
+		PointValue:       cfg.LoyaltyPointValue,
+	}
+	if err := loyaltyConfig.Validate(); err != nil {
+		log.Fatal("Invalid loyalty configuration:", err)
+	}
+	loyaltyCalc := loyalty.NewCalculator(loyaltyConfig)
+
 	// リポジトリの作成
 	productRepo := repository.NewProductRepository(mongodb.GetDB())
 	saleRepo := repository.NewSaleRepository(mongodb.GetDB())
 	deliveryRepo := repository.NewDeliveryRepository(mongodb.GetDB())
+	storeSettingsRepo := repository.NewStoreSettingsRepository(mongodb.GetDB())
+	registerSessionRepo := repository.NewRegisterSessionRepository(mongodb.GetDB())
+	memberRepo := repository.NewMemberRepository(mongodb.GetDB())
+	pointTransactionRepo := repository.NewPointTransactionRepository(mongodb.GetDB())
+	idempotencyRepo := repository.NewIdempotencyRepository(mongodb.GetDB())
+	saleReturnRepo := repository.NewSaleReturnRepository(mongodb.GetDB())
+	anomalyRepo := repository.NewAnomalyRepository(mongodb.GetDB())
+	exportJobRepo := repository.NewExportJobRepository(mongodb.GetDB())
+	salesTargetRepo := repository.NewSalesTargetRepository(mongodb.GetDB())
+	storeEventRepo := repository.NewStoreEventRepository(mongodb.GetDB())
+	robotRepo := repository.NewRobotRepository(mongodb.GetDB())
+	telemetryRepo := repository.NewTelemetryRepository(mongodb.GetDB())
+	deliveryAlertRepo := repository.NewDeliveryAlertRepository(mongodb.GetDB())
+	deliverySlotRepo := repository.NewDeliverySlotRepository(mongodb.GetDB())
+	slotReservationRepo := repository.NewSlotReservationRepository(mongodb.GetDB())
 	// サービスの作成
-	productService := service.NewProductService(productRepo)
-	saleService := service.NewSaleService(saleRepo, productRepo)
-	deliveryService := service.NewDeliveryService(deliveryRepo)
+	productService := service.NewProductService(productRepo, taxCalc)
+	loyaltyService := service.NewLoyaltyService(memberRepo, pointTransactionRepo, saleRepo, loyaltyCalc)
+	calendarService := service.NewCalendarService(storeEventRepo, calendar.NewJapan(), storeLocation)
+	saleService := service.NewSaleService(saleRepo, productRepo, taxCalc, loyaltyService, calendarService, storeLocation)
+	// 配送のライブ追跡（位置・ステータスの更新を購読者へ配信）
+	trackingHub := tracking.NewHub(cfg.TrackingBufferSize)
+	// 配送時間枠（曜日ごとのテンプレートから枠を作成し、仮押さえ・配送の登録で予約を受け付け）
+	slotConfig := service.DefaultSlotConfig()
+	slotConfig.HoldTTL = cfg.SlotHoldTTL
+	slotConfig.DefaultTripTime = cfg.SlotDefaultTripTime
+	slotService := service.NewSlotService(deliverySlotRepo, slotReservationRepo, robotRepo, deliveryRepo, slotConfig, storeLocation)
+	slotService.Start(context.Background(), cfg.SlotExpiryInterval)
+	deliveryConfig := service.DefaultDeliveryConfig()
+	deliveryConfig.MaxAttempts = cfg.DeliveryMaxAttempts
+	deliveryConfig.ProofRadius = cfg.ProofOfDeliveryRadius
+	deliveryConfig.MaxProofPhotoSize = int64(cfg.ProofOfDeliveryMaxPhotoSize)
+	deliveryConfig.MaxPINAttempts = cfg.ProofOfDeliveryMaxPINAttempts
+	blobStore := storage.NewLocalStore(cfg.BlobDir)
+	deliveryService := service.NewDeliveryService(deliveryRepo, trackingHub, slotService, blobStore, deliveryConfig)
+	trackingService := service.NewTrackingService(trackingHub, deliveryRepo)
+	fleetConfig := service.DefaultFleetConfig()
+	fleetConfig.BatteryReserve = cfg.FleetBatteryReserve
+	fleetService := service.NewFleetService(robotRepo, deliveryRepo, fleetConfig)
+
+	// 配送ルートの計画（道路データファイルを指定した場合は配送ロボットの移動距離に使用）
+	routeConfig := service.DefaultRouteConfig()
+	routeConfig.RobotSpeed = cfg.RoutingRobotSpeed
+	routeConfig.DroneSpeed = cfg.RoutingDroneSpeed
+	routeConfig.ServiceTime = cfg.RoutingServiceTime
+	routeConfig.BatteryReserve = cfg.FleetBatteryReserve
+	var roads routing.Metric
+	if cfg.RoutingRoadGraph != "" {
+		graph, err := routing.LoadRoadGraph(cfg.RoutingRoadGraph)
+		if err != nil {
+			log.Fatal("Failed to load road graph:", err)
+		}
+		roads = graph
+	}
+	routeService := service.NewRouteService(robotRepo, deliveryRepo, roads, routeConfig)
+
+	// 配送機体の計測値（時系列コレクションに保存し、1分ごとに集約）
+	telemetryConfig := service.DefaultTelemetryConfig()
+	telemetryConfig.RawRetention = cfg.TelemetryRawRetention
+	telemetryConfig.RollupRetention = cfg.TelemetryRollupRetention
+	telemetryConfig.OnlineTimeout = cfg.TelemetryOnlineTimeout
+	if err := telemetryRepo.EnsureCollections(context.Background(), telemetryConfig.RawRetention, telemetryConfig.RollupRetention); err != nil {
+		log.Fatal("Failed to set up telemetry collections:", err)
+	}
+	telemetryService := service.NewTelemetryService(telemetryRepo, robotRepo, trackingHub, telemetryConfig)
+	telemetryService.Start(context.Background(), cfg.TelemetryRollupInterval)
+
+	// 配送の到着予測（現在位置・残りの距離・機体の走行速度から予測し、遅延アラートを登録）
+	etaConfig := service.DefaultETAConfig()
+	etaConfig.RobotSpeed = cfg.RoutingRobotSpeed
+	etaConfig.DroneSpeed = cfg.RoutingDroneSpeed
+	etaConfig.SpeedHistory = cfg.ETASpeedHistory
+	etaService := service.NewETAService(deliveryRepo, robotRepo, telemetryRepo, deliveryAlertRepo, roads, etaConfig, storeLocation)
+	etaService.Start(context.Background(), cfg.ETARefreshInterval)
+
+	// 配送機体のエネルギー効率レポート
+	energyConfig := service.DefaultEnergyConfig()
+	energyConfig.RobotBatteryCapacity = cfg.EnergyRobotBatteryCapacity
+	energyConfig.DroneBatteryCapacity = cfg.EnergyDroneBatteryCapacity
+	energyConfig.DegradationThreshold = cfg.EnergyDegradationThreshold
+	energyService := service.NewEnergyService(telemetryRepo, robotRepo, deliveryRepo, energyConfig, storeLocation)
+	storeSettingsService := service.NewStoreSettingsService(storeSettingsRepo)
+	receiptService := service.NewReceiptService(saleRepo, productRepo, storeSettingsService)
+	registerSessionService := service.NewRegisterSessionService(registerSessionRepo, saleRepo, storeLocation)
+	saleReturnService := service.NewSaleReturnService(saleReturnRepo, saleRepo)
+	salesTargetService := service.NewSalesTargetService(salesTargetRepo, saleRepo, storeLocation)
+
+	// 売上・取消・レジ精算の異常検知
+	anomalyConfig := service.DefaultAnomalyConfig()
+	anomalyConfig.Threshold = cfg.AnomalyZThreshold
+	anomalyService := service.NewAnomalyService(anomalyRepo, saleRepo, saleReturnRepo, registerSessionRepo, anomalyConfig, storeLocation)
+	anomalyService.Start(context.Background(), cfg.AnomalyDetectionInterval)
+
+	// 売上エクスポート（保存期間を過ぎたファイルは1時間ごとに削除）
+	exportService := service.NewExportService(saleRepo, productRepo, exportJobRepo, taxCalc, storeLocation, newExportConfig(cfg))
+	exportService.Start(context.Background(), time.Hour)
+
+	// 商品のABC分析
+	abcConfig := service.DefaultABCConfig()
+	abcConfig.ClassAThreshold = cfg.ABCClassAThreshold
+	abcConfig.ClassBThreshold = cfg.ABCClassBThreshold
+	abcService := service.NewABCService(productRepo, saleRepo, taxCalc, abcConfig, storeLocation)
+
+	// 店舗の売上予測（既定のモデルは設定で切り替え）
+	forecastConfig := service.DefaultForecastConfig()
+	forecastConfig.DefaultModel = cfg.ForecastModel
+	forecastConfig.HistoryDays = cfg.ForecastHistoryDays
+	forecastService := service.NewForecastService(saleRepo, calendarService, forecastConfig, storeLocation,
+		forecast.NewWeekdaySeasonal(), forecast.SeasonalNaive{})
 	// ハンドラーの作成
 	productHandler := handler.NewProductHandler(productService)
 	saleHandler := handler.NewSaleHandler(saleService)
 	deliveryHandler := handler.NewDeliveryHandler(deliveryService)
+	receiptHandler := handler.NewReceiptHandler(receiptService)
+	storeHandler := handler.NewStoreHandler(storeSettingsService)
+	registerSessionHandler := handler.NewRegisterSessionHandler(registerSessionService)
+	memberHandler := handler.NewMemberHandler(loyaltyService)
+	saleReturnHandler := handler.NewSaleReturnHandler(saleReturnService)
+	anomalyHandler := handler.NewAnomalyHandler(anomalyService)
+	exportHandler := handler.NewExportHandler(exportService)
+	abcHandler := handler.NewABCHandler(abcService)
+	salesTargetHandler := handler.NewSalesTargetHandler(salesTargetService)
+	forecastHandler := handler.NewForecastHandler(forecastService)
+	calendarHandler := handler.NewCalendarHandler(calendarService)
+	fleetHandler := handler.NewFleetHandler(fleetService)
+	routeHandler := handler.NewRouteHandler(routeService)
+	trackingHandler := handler.NewTrackingHandler(trackingService, cfg.TrackingHeartbeatInterval)
+	telemetryHandler := handler.NewTelemetryHandler(telemetryService)
+	etaHandler := handler.NewETAHandler(etaService)
+	energyHandler := handler.NewEnergyHandler(energyService)
+	slotHandler := handler.NewSlotHandler(slotService)
+	// POS端末からの再送に備えた冪等キーの設定
+	idempotencyConfig := middleware.DefaultIdempotencyConfig()
+	idempotencyConfig.TTL = cfg.IdempotencyKeyTTL
+	idempotency := middleware.Idempotency(idempotencyRepo, idempotencyConfig)
+	// 配送の登録時に発行した受取人の暗証番号は最初の応答でのみ返し、保存する応答には含めません
+	deliveryIdempotencyConfig := idempotencyConfig
+	deliveryIdempotencyConfig.OmitResponseFields = []string{"recipientPin"}
+	deliveryIdempotency := middleware.Idempotency(idempotencyRepo, deliveryIdempotencyConfig)
+	// 配送のライブ追跡はアクセストークンで認証（EventSource・WebSocket のためクエリパラメータでも受け付け）
+	streamAuth := middleware.StreamAuthMiddleware(config.NewAuthConfig())
 	// ルーターの設定
-	r := router.NewRouter(productHandler, saleHandler, deliveryHandler)
+	r := router.NewRouter(productHandler, saleHandler, deliveryHandler, receiptHandler, storeHandler, registerSessionHandler, memberHandler, saleReturnHandler, anomalyHandler, exportHandler, abcHandler, salesTargetHandler, forecastHandler, calendarHandler, fleetHandler, routeHandler, trackingHandler, telemetryHandler, etaHandler, energyHandler, slotHandler, idempotency, deliveryIdempotency, streamAuth)
 
 	// サーバーの起動
 	if err := r.Start(":8080"); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+// loadStoreLocation は店舗のタイムゾーンを読み込みます
+// 集計ではタイムゾーン名をそのまま MongoDB に渡すため、"Local" は受け付けず IANA のタイムゾーン名を要求します
+func loadStoreLocation(cfg *config.Config) *time.Location {
+	location, err := time.LoadLocation(cfg.StoreTimezone)
+	if err != nil {
+		log.Fatal("Invalid store timezone:", err)
+	}
+	if location == time.Local || location.String() == "Local" {
+		log.Fatal("Invalid store timezone: STORE_TIMEZONE must be an IANA time zone name such as Asia/Tokyo")
+	}
+	return location
+}
+
+// newTaxCalculator は設定から消費税の計算機を作成します
+func newTaxCalculator(cfg *config.Config) *tax.Calculator {
+	taxConfig := tax.DefaultConfig()
+	taxConfig.Rounding = tax.RoundingMode(cfg.TaxRounding)
+	taxConfig.PriceIncludesTax = cfg.TaxInclusivePricing
+	taxConfig.RegistrationNumber = cfg.InvoiceRegistrationNumber
+	taxConfig.IssuerName = cfg.InvoiceIssuerName
+	if err := taxConfig.Validate(); err != nil {
+		log.Fatal("Invalid tax configuration:", err)
+	}
+	return tax.NewCalculator(taxConfig)
+}
+
+// newExportConfig は設定から売上エクスポートの設定を作成します
+func newExportConfig(cfg *config.Config) service.ExportConfig {
+	exportConfig := service.DefaultExportConfig()
+	exportConfig.Dir = cfg.ExportDir
+	exportConfig.TTL = cfg.ExportFileTTL
+	exportConfig.SyncMaxDays = cfg.ExportSyncMaxDays
+	exportConfig.MaxConcurrentJobs = cfg.ExportMaxConcurrentJobs
+	return exportConfig
+}
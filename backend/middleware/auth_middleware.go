@@ -18,23 +18,46 @@ func AuthMiddleware(authConfig *config.AuthConfig) echo.MiddlewareFunc {
 			if authHeader == "" {
 				return echo.NewHTTPError(http.StatusUnauthorized, "missing authorization header")
 			}
+			return authenticate(c, next, authHeader, authConfig)
+		}
+	}
+}
 
-			tokenParts := strings.Split(authHeader, " ")
-			if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-				return echo.NewHTTPError(http.StatusUnauthorized, "invalid authorization header format")
-			}
-
-			claims, err := jwt.ValidateToken(tokenParts[1], authConfig.JWTSecret)
-			if err != nil {
-				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+// StreamAuthMiddleware は AuthMiddleware と同様に認証します
+// クエリパラメータのトークンは Logger がアクセスログから伏せます
+// ブラウザの EventSource・WebSocket はヘッダーを指定できないため、クエリパラメータ access_token のトークンも受け付けます
+func StreamAuthMiddleware(authConfig *config.AuthConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			authHeader := c.Request().Header.Get("Authorization")
+			if authHeader == "" {
+				token := c.QueryParam("access_token")
+				if token == "" {
+					return echo.NewHTTPError(http.StatusUnauthorized, "missing access token")
+				}
+				authHeader = "Bearer " + token
 			}
+			return authenticate(c, next, authHeader, authConfig)
+		}
+	}
+}
 
-			c.Set("user_id", claims.UserID)
-			c.Set("role", claims.Role)
+// authenticate は Bearer トークンを検証し、ユーザーIDとロールをコンテキストに設定します
+func authenticate(c echo.Context, next echo.HandlerFunc, authHeader string, authConfig *config.AuthConfig) error {
+	tokenParts := strings.Split(authHeader, " ")
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid authorization header format")
+	}
 
-			return next(c)
-		}
+	claims, err := jwt.ValidateToken(tokenParts[1], authConfig.JWTSecret)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
 	}
+
+	c.Set("user_id", claims.UserID)
+	c.Set("role", claims.Role)
+
+	return next(c)
 }
 
 func RequireRole(roles ...string) echo.MiddlewareFunc {
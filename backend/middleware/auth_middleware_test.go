@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/config"
+	"github.com/onoderaryou/smart-store-admin/backend/utils/jwt"
+)
+
+func TestStreamAuthMiddleware(t *testing.T) {
+	authConfig := &config.AuthConfig{JWTSecret: "test-secret"}
+	userID := primitive.NewObjectID()
+	token, err := jwt.GenerateToken(userID, "staff", authConfig.JWTSecret)
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.GET("/stream", func(c echo.Context) error {
+		return c.String(http.StatusOK, GetUserID(c).Hex()+" "+GetUserRole(c))
+	}, StreamAuthMiddleware(authConfig))
+
+	tests := []struct {
+		name     string
+		url      string
+		header   string
+		wantCode int
+	}{
+		{name: "ヘッダーのトークン", url: "/stream", header: "Bearer " + token, wantCode: http.StatusOK},
+		{name: "クエリパラメータのトークン", url: "/stream?access_token=" + token, wantCode: http.StatusOK},
+		{name: "トークンなし", url: "/stream", wantCode: http.StatusUnauthorized},
+		{name: "無効なトークン", url: "/stream?access_token=invalid", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, userID.Hex()+" staff", rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+const (
+	// IdempotencyKeyHeader は再送されたリクエストを識別するヘッダーです
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader は保存済みの応答を返したことを示すヘッダーです
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	// TerminalIDHeader は冪等キーを発行したPOS端末・レジを識別するヘッダーです
+	TerminalIDHeader = "X-Terminal-ID"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyConfig は冪等キーミドルウェアの設定です
+type IdempotencyConfig struct {
+	// TTL は冪等キーと応答を保存する期間です
+	TTL time.Duration
+	// ProcessingTimeout は処理中のキーを保持する最大時間です（処理が中断された場合に解放されるまでの時間）
+	ProcessingTimeout time.Duration
+	// WaitTimeout は同じキーのリクエストが処理中の場合に完了を待つ最大時間です
+	WaitTimeout time.Duration
+	// PollInterval は処理中のリクエストの完了を確認する間隔です
+	PollInterval time.Duration
+	// OmitResponseFields は保存する応答（JSONオブジェクト）から除くフィールドです
+	// 受取人の暗証番号など、最初の応答でのみ返して保存しない値を指定します（再送時の応答には含まれません）
+	OmitResponseFields []string
+}
+
+// DefaultIdempotencyConfig は標準的な設定を返します
+func DefaultIdempotencyConfig() IdempotencyConfig {
+	return IdempotencyConfig{
+		TTL:               24 * time.Hour,
+		ProcessingTimeout: time.Minute,
+		WaitTimeout:       5 * time.Second,
+		PollInterval:      100 * time.Millisecond,
+	}
+}
+
+// Idempotency は Idempotency-Key ヘッダー付きのリクエストを一度だけ処理するミドルウェアです
+//
+// 同じキーと同じ本文の再送には最初の応答をそのまま返し、同じキーで本文が異なる場合は409を返します。
+// 同じキーのリクエストが処理中の場合は完了を待ってから応答を返します。
+// 5xxの応答は保存せず、同じキーで再試行できるようにします。
+// キーは認証済みユーザー（未認証の場合は X-Terminal-ID ヘッダーの端末）ごとに独立させ、
+// 他の端末が同じキーを使っても応答が共有されないようにします。
+func Idempotency(repo repository.IdempotencyRepository, config IdempotencyConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				return next(c)
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "冪等キーが長すぎます",
+				})
+			}
+
+			scope, ok := idempotencyScope(c)
+			if !ok {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "冪等キーを使用する場合は端末IDを指定してください",
+				})
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": "リクエストボディの読み込みに失敗しました",
+				})
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := c.Request().Context()
+			now := time.Now()
+			record := &models.IdempotencyRecord{
+				// キーは呼び出し元・エンドポイントごとに独立させる
+				ID:          scope + " " + c.Request().Method + " " + c.Path() + " " + key,
+				RequestHash: requestHash(body),
+				ExpiresAt:   now.Add(config.ProcessingTimeout),
+			}
+
+			existing, err := repo.Reserve(ctx, record)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "冪等キーの登録に失敗しました",
+				})
+			}
+			if existing != nil {
+				return replay(c, repo, config, existing, record.RequestHash)
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+
+			if err := next(c); err != nil {
+				// エラーハンドラーが応答を書き出すため、応答は保存せずに再試行を許可する
+				_ = repo.Release(ctx, record.ID)
+				return err
+			}
+
+			status := c.Response().Status
+			if status >= http.StatusInternalServerError {
+				_ = repo.Release(ctx, record.ID)
+				return nil
+			}
+			contentType := c.Response().Header().Get(echo.HeaderContentType)
+			stored, err := omitFields(recorder.body.Bytes(), config.OmitResponseFields)
+			if err != nil {
+				// 除くべき値を含むかもしれない応答は保存せず、キーを解放します
+				c.Logger().Errorf("failed to redact idempotent response: %v", err)
+				_ = repo.Release(ctx, record.ID)
+				return nil
+			}
+			if err := repo.Complete(ctx, record.ID, status, contentType, stored, now.Add(config.TTL)); err != nil {
+				c.Logger().Errorf("failed to store idempotent response: %v", err)
+			}
+			return nil
+		}
+	}
+}
+
+// idempotencyScope は冪等キーの呼び出し元を返します
+// 認証済みの場合はユーザーID、未認証の場合は端末IDを使います
+func idempotencyScope(c echo.Context) (string, bool) {
+	if userID, ok := c.Get("user_id").(primitive.ObjectID); ok && !userID.IsZero() {
+		return "user:" + userID.Hex(), true
+	}
+	terminalID := strings.TrimSpace(c.Request().Header.Get(TerminalIDHeader))
+	if terminalID == "" || len(terminalID) > maxIdempotencyKeyLength {
+		return "", false
+	}
+	return "terminal:" + terminalID, true
+}
+
+// replay は保存済みの応答を返します
+// 最初のリクエストが処理中の場合は完了するまで待ちます
+func replay(c echo.Context, repo repository.IdempotencyRepository, config IdempotencyConfig, record *models.IdempotencyRecord, hash string) error {
+	if record.RequestHash != hash {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "同じ冪等キーで異なるリクエストが送信されました",
+		})
+	}
+
+	ctx := c.Request().Context()
+	deadline := time.Now().Add(config.WaitTimeout)
+	for record.Status != models.IdempotencyCompleted {
+		if time.Now().After(deadline) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "同じ冪等キーのリクエストを処理中です",
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(config.PollInterval):
+		}
+
+		latest, err := repo.Get(ctx, record.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "冪等キーの取得に失敗しました",
+			})
+		}
+		if latest == nil {
+			// 最初のリクエストが失敗してキーが解放された
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "同じ冪等キーのリクエストが失敗しました。再試行してください",
+			})
+		}
+		record = latest
+	}
+
+	c.Response().Header().Set(IdempotentReplayedHeader, "true")
+	return c.Blob(record.ResponseStatus, record.ContentType, record.ResponseBody)
+}
+
+// omitFields は応答本文の JSON オブジェクトから fields を除いた本文を返します
+func omitFields(body []byte, fields []string) ([]byte, error) {
+	if len(fields) == 0 {
+		return body, nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(body, &object); err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		delete(object, field)
+	}
+	return json.Marshal(object)
+}
+
+// requestHash はリクエスト本文のハッシュ値を返します
+func requestHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// responseRecorder はクライアントに書き出す応答本文を記録します
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
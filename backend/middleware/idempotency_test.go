@@ -0,0 +1,254 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+// memoryIdempotencyRepository はテスト用のメモリ上の冪等キーリポジトリです
+type memoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+}
+
+var _ repository.IdempotencyRepository = (*memoryIdempotencyRepository)(nil)
+
+func newMemoryIdempotencyRepository() *memoryIdempotencyRepository {
+	return &memoryIdempotencyRepository{records: make(map[string]models.IdempotencyRecord)}
+}
+
+func (r *memoryIdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.records[record.ID]; ok && existing.ExpiresAt.After(time.Now()) {
+		return &existing, nil
+	}
+	record.Status = models.IdempotencyProcessing
+	r.records[record.ID] = *record
+	return nil, nil
+}
+
+func (r *memoryIdempotencyRepository) Get(ctx context.Context, id string) (*models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.records[id]; ok {
+		return &existing, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryIdempotencyRepository) Complete(ctx context.Context, id string, status int, contentType string, body []byte, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record := r.records[id]
+	record.Status = models.IdempotencyCompleted
+	record.ResponseStatus = status
+	record.ContentType = contentType
+	record.ResponseBody = append([]byte(nil), body...)
+	record.ExpiresAt = expiresAt
+	r.records[id] = record
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) Release(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, id)
+	return nil
+}
+
+func testIdempotencyConfig() IdempotencyConfig {
+	config := DefaultIdempotencyConfig()
+	config.WaitTimeout = 2 * time.Second
+	config.PollInterval = 5 * time.Millisecond
+	return config
+}
+
+func newIdempotentServer(repo repository.IdempotencyRepository, handler echo.HandlerFunc) *echo.Echo {
+	e := echo.New()
+	e.POST("/api/sales", handler, Idempotency(repo, testIdempotencyConfig()))
+	return e
+}
+
+func postSale(e *echo.Echo, key, body string) *httptest.ResponseRecorder {
+	return postSaleFrom(e, "REG-01", key, body)
+}
+
+func postSaleFrom(e *echo.Echo, terminalID, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/sales", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if terminalID != "" {
+		req.Header.Set(TerminalIDHeader, terminalID)
+	}
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency(t *testing.T) {
+	const body = `{"terminalTransactionId":"T-001","items":[]}`
+
+	t.Run("同じキーと本文の再送は最初の応答を返す", func(t *testing.T) {
+		var calls int32
+		e := newIdempotentServer(newMemoryIdempotencyRepository(), func(c echo.Context) error {
+			n := atomic.AddInt32(&calls, 1)
+			return c.JSON(http.StatusCreated, map[string]int32{"call": n})
+		})
+
+		first := postSale(e, "key-1", body)
+		second := postSale(e, "key-1", body)
+
+		assert.Equal(t, http.StatusCreated, first.Code)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("同じキーで本文が異なる場合は409", func(t *testing.T) {
+		e := newIdempotentServer(newMemoryIdempotencyRepository(), func(c echo.Context) error {
+			return c.JSON(http.StatusCreated, map[string]string{"status": "ok"})
+		})
+
+		postSale(e, "key-1", body)
+		conflict := postSale(e, "key-1", `{"terminalTransactionId":"T-002","items":[]}`)
+
+		assert.Equal(t, http.StatusConflict, conflict.Code)
+	})
+
+	t.Run("同時に届いた同じリクエストは1回だけ処理する", func(t *testing.T) {
+		var calls int32
+		release := make(chan struct{})
+		e := newIdempotentServer(newMemoryIdempotencyRepository(), func(c echo.Context) error {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return c.JSON(http.StatusCreated, map[string]string{"status": "ok"})
+		})
+
+		var wg sync.WaitGroup
+		results := make([]*httptest.ResponseRecorder, 2)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = postSale(e, "key-1", body)
+			}(i)
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		for _, rec := range results {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+		}
+	})
+
+	t.Run("サーバーエラーの応答は保存せず再試行できる", func(t *testing.T) {
+		var calls int32
+		e := newIdempotentServer(newMemoryIdempotencyRepository(), func(c echo.Context) error {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "db error"})
+			}
+			return c.JSON(http.StatusCreated, map[string]string{"status": "ok"})
+		})
+
+		first := postSale(e, "key-1", body)
+		second := postSale(e, "key-1", body)
+
+		assert.Equal(t, http.StatusInternalServerError, first.Code)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("同じキーでも端末が異なれば別のリクエストとして処理する", func(t *testing.T) {
+		var calls int32
+		e := newIdempotentServer(newMemoryIdempotencyRepository(), func(c echo.Context) error {
+			n := atomic.AddInt32(&calls, 1)
+			return c.JSON(http.StatusCreated, map[string]int32{"call": n})
+		})
+
+		first := postSaleFrom(e, "REG-01", "key-1", body)
+		second := postSaleFrom(e, "REG-02", "key-1", body)
+
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.NotEqual(t, first.Body.String(), second.Body.String())
+		assert.Empty(t, second.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("端末IDのない冪等キーは400", func(t *testing.T) {
+		e := newIdempotentServer(newMemoryIdempotencyRepository(), func(c echo.Context) error {
+			return c.JSON(http.StatusCreated, map[string]string{"status": "ok"})
+		})
+
+		rec := postSaleFrom(e, "", "key-1", body)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("キーなしのリクエストは毎回処理する", func(t *testing.T) {
+		var calls int32
+		e := newIdempotentServer(newMemoryIdempotencyRepository(), func(c echo.Context) error {
+			atomic.AddInt32(&calls, 1)
+			return c.JSON(http.StatusCreated, map[string]string{"status": "ok"})
+		})
+
+		postSale(e, "", body)
+		postSale(e, "", body)
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestIdempotencyOmitsResponseFields(t *testing.T) {
+	repo := newMemoryIdempotencyRepository()
+	config := testIdempotencyConfig()
+	config.OmitResponseFields = []string{"recipientPin"}
+	e := echo.New()
+	e.POST("/api/deliveries", func(c echo.Context) error {
+		return c.JSON(http.StatusCreated, map[string]string{"id": "delivery-1", "recipientPin": "123456"})
+	}, Idempotency(repo, config))
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/deliveries", strings.NewReader(`{"address":"東京都渋谷区"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(TerminalIDHeader, "REG-01")
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 暗証番号は最初の応答でのみ返します
+	first := post()
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.JSONEq(t, `{"id":"delivery-1","recipientPin":"123456"}`, first.Body.String())
+
+	require.Len(t, repo.records, 1)
+	for _, record := range repo.records {
+		assert.NotContains(t, string(record.ResponseBody), "recipientPin")
+		assert.NotContains(t, string(record.ResponseBody), "123456")
+	}
+
+	second := post()
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+	assert.JSONEq(t, `{"id":"delivery-1"}`, second.Body.String())
+}
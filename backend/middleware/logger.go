@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+)
+
+// redactedQueryParams はアクセスログに値を記録しないクエリパラメータです
+var redactedQueryParams = []string{"access_token"}
+
+// Logger はアクセスログを出力するミドルウェアを返します（echo の既定の形式）
+// ストリームの認証に使うクエリパラメータ access_token のトークンは、URI から伏せて記録します
+func Logger() echo.MiddlewareFunc {
+	return echomiddleware.LoggerWithConfig(loggerConfig())
+}
+
+// loggerConfig は URI の代わりに値を伏せた URI を記録する echo の既定のアクセスログの設定です
+func loggerConfig() echomiddleware.LoggerConfig {
+	config := echomiddleware.DefaultLoggerConfig
+	config.Format = strings.Replace(config.Format, "${uri}", "${custom}", 1)
+	config.CustomTagFunc = func(c echo.Context, buf *bytes.Buffer) (int, error) {
+		return buf.WriteString(redactURI(c.Request().RequestURI))
+	}
+	return config
+}
+
+// redactURI は URI のうち redactedQueryParams の値を伏せた URI を返します
+// 解析できない URI はクエリ文字列を除いて返します
+func redactURI(uri string) string {
+	path, rawQuery, ok := strings.Cut(uri, "?")
+	if !ok {
+		return uri
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return path
+	}
+	redacted := false
+	for _, key := range redactedQueryParams {
+		if query.Has(key) {
+			query.Set(key, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return uri
+	}
+	return path + "?" + query.Encode()
+}
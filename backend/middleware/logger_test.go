@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerRedactsAccessToken(t *testing.T) {
+	var out bytes.Buffer
+	config := loggerConfig()
+	config.Output = &out
+
+	e := echo.New()
+	e.Use(echomiddleware.LoggerWithConfig(config))
+	e.GET("/stream", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream?deliveryId=1&access_token=secret-token", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, out.String(), "secret-token")
+	assert.Contains(t, out.String(), `"uri":"/stream?access_token=REDACTED&deliveryId=1"`)
+
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{uri: "/stream?deliveryId=1", want: "/stream?deliveryId=1"},
+		{uri: "/stream", want: "/stream"},
+		// 解析できないクエリ文字列は記録しません
+		{uri: "/stream?access_token=%zz", want: "/stream"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, redactURI(tt.uri))
+	}
+}
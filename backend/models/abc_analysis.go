@@ -0,0 +1,121 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ABCMetric はABC分析で商品を順位付けする指標です
+type ABCMetric string
+
+const (
+	ABCMetricRevenue ABCMetric = "revenue" // 売上金額
+	ABCMetricUnits   ABCMetric = "units"   // 販売数量
+	ABCMetricMargin  ABCMetric = "margin"  // 粗利（売上金額 - 原価）
+)
+
+// ValidateABCMetric はABC分析の指標が有効かどうかを確認します
+func ValidateABCMetric(metric ABCMetric) bool {
+	switch metric {
+	case ABCMetricRevenue, ABCMetricUnits, ABCMetricMargin:
+		return true
+	}
+	return false
+}
+
+// ABCClass はABC分析の区分です
+type ABCClass string
+
+const (
+	ABCClassA ABCClass = "A" // 上位（累積構成比がAのしきい値まで）
+	ABCClassB ABCClass = "B" // 中位（累積構成比がBのしきい値まで）
+	ABCClassC ABCClass = "C" // 下位（販売実績のない商品を含む）
+)
+
+// ValidateABCClass はABC分析の区分が有効かどうかを確認します
+func ValidateABCClass(class ABCClass) bool {
+	switch class {
+	case ABCClassA, ABCClassB, ABCClassC:
+		return true
+	}
+	return false
+}
+
+// ProductABC は商品に保存するABC分析の結果です
+type ProductABC struct {
+	Class  ABCClass  `bson:"class" json:"class"`
+	Metric ABCMetric `bson:"metric" json:"metric"`
+	// Rank は指標の大きい順の順位です（1始まり）
+	Rank  int     `bson:"rank" json:"rank"`
+	Value float64 `bson:"value" json:"value"`
+	// Share は全商品の合計に占める割合、CumulativeShare は上位からの累積構成比です
+	Share           float64 `bson:"share" json:"share"`
+	CumulativeShare float64 `bson:"cumulative_share" json:"cumulativeShare"`
+	// ZeroSales は期間中に販売実績がないことを表します
+	ZeroSales   bool      `bson:"zero_sales" json:"zeroSales"`
+	PeriodStart time.Time `bson:"period_start" json:"periodStart"`
+	PeriodEnd   time.Time `bson:"period_end" json:"periodEnd"`
+	AnalyzedAt  time.Time `bson:"analyzed_at" json:"analyzedAt"`
+}
+
+// ProductSales は商品別の販売数量・売上金額・値引額（按分後）・原価の集計です
+type ProductSales struct {
+	ProductID primitive.ObjectID `bson:"_id" json:"productId"`
+	Units     int                `bson:"units" json:"units"`
+	Revenue   float64            `bson:"revenue" json:"revenue"`
+	Discount  float64            `bson:"discount" json:"discount"`
+	Cost      float64            `bson:"cost" json:"cost"`
+	// TaxClasses は売上金額・値引額の販売時の税区分ごとの内訳です
+	TaxClasses []TaxClassSales `bson:"tax_classes" json:"taxClasses"`
+}
+
+// TaxClassSales は1つの税区分の売上金額・値引額（按分後）の集計です
+type TaxClassSales struct {
+	TaxClass TaxClass `bson:"tax_class" json:"taxClass"`
+	Revenue  float64  `bson:"revenue" json:"revenue"`
+	Discount float64  `bson:"discount" json:"discount"`
+}
+
+// ABCProduct はABC分析レポートの商品ごとの結果です
+type ABCProduct struct {
+	ProductID primitive.ObjectID `json:"productId"`
+	Name      string             `json:"name"`
+	SKU       string             `json:"sku"`
+	Category  string             `json:"category"`
+	Units     int                `json:"units"`
+	Revenue   float64            `json:"revenue"`
+	Margin    float64            `json:"margin"`
+	ProductABC
+}
+
+// ABCClassSummary は区分ごとの商品数と構成比です
+type ABCClassSummary struct {
+	Class    ABCClass `json:"class"`
+	Products int      `json:"products"`
+	Value    float64  `json:"value"`
+	Share    float64  `json:"share"`
+}
+
+// ABCAnalysis はABC（パレート）分析のレポートです
+type ABCAnalysis struct {
+	Metric    ABCMetric `json:"metric"`
+	StartDate string    `json:"startDate"`
+	EndDate   string    `json:"endDate"`
+	// ClassAThreshold / ClassBThreshold は区分を分ける累積構成比です
+	ClassAThreshold float64           `json:"classAThreshold"`
+	ClassBThreshold float64           `json:"classBThreshold"`
+	Total           float64           `json:"total"`
+	ZeroSalesCount  int               `json:"zeroSalesCount"`
+	Classes         []ABCClassSummary `json:"classes"`
+	Products        []ABCProduct      `json:"products"`
+	AnalyzedAt      time.Time         `json:"analyzedAt"`
+}
+
+// ProductQuery は商品一覧の絞り込み条件です
+type ProductQuery struct {
+	Category string
+	ABCClass ABCClass
+	// ZeroSales を指定すると、直近のABC分析で販売実績がなかった（またはあった）商品に絞り込みます
+	ZeroSales *bool
+}
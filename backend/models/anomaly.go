@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AnomalyType は検知した異常の種類です
+type AnomalyType string
+
+const (
+	AnomalyRevenueDrop      AnomalyType = "revenue_drop"      // 売上の急減
+	AnomalyAbnormalDiscount AnomalyType = "abnormal_discount" // 値引率の異常
+	AnomalyRepeatedVoids    AnomalyType = "repeated_voids"    // 取消・返品の多発
+	AnomalyCashDiscrepancy  AnomalyType = "cash_discrepancy"  // レジ現金の過不足
+)
+
+// AnomalySeverity は異常の重要度です
+type AnomalySeverity string
+
+const (
+	AnomalySeverityLow    AnomalySeverity = "low"
+	AnomalySeverityMedium AnomalySeverity = "medium"
+	AnomalySeverityHigh   AnomalySeverity = "high"
+)
+
+// AnomalyStatus は異常イベントの確認状況です
+type AnomalyStatus string
+
+const (
+	AnomalyOpen      AnomalyStatus = "open"      // 未確認
+	AnomalyConfirmed AnomalyStatus = "confirmed" // 確認済み（対応が必要）
+	AnomalyDismissed AnomalyStatus = "dismissed" // 問題なしとして却下
+)
+
+// AnomalyEvidence は異常と判定した根拠です
+type AnomalyEvidence struct {
+	// Metric は判定に使った指標です（hourly_revenue, discount_rate 等）
+	Metric string  `bson:"metric" json:"metric"`
+	Value  float64 `bson:"value" json:"value"`
+	// 基準値の分布（中央値・MAD）とロバストzスコア
+	Median     float64 `bson:"median" json:"median"`
+	MAD        float64 `bson:"mad" json:"mad"`
+	Scale      float64 `bson:"scale" json:"scale"`
+	ZScore     float64 `bson:"z_score" json:"zScore"`
+	Threshold  float64 `bson:"threshold" json:"threshold"`
+	SampleSize int     `bson:"sample_size" json:"sampleSize"`
+	// Baseline は基準値の取り方の説明です
+	Baseline string `bson:"baseline" json:"baseline"`
+	// 季節性の基準値に使った曜日・時間帯
+	WeekDay   string `bson:"week_day,omitempty" json:"weekDay,omitempty"`
+	TimeOfDay string `bson:"time_of_day,omitempty" json:"timeOfDay,omitempty"`
+	// RelatedIDs は関連する売上・取消・レジ精算セッションのIDです
+	RelatedIDs []primitive.ObjectID `bson:"related_ids,omitempty" json:"relatedIds,omitempty"`
+}
+
+// AnomalyEvent は検知した異常を表します
+type AnomalyEvent struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	// Fingerprint は同じ異常を再検知した場合に重複させないためのキーです
+	Fingerprint string          `bson:"fingerprint" json:"-"`
+	Type        AnomalyType     `bson:"type" json:"type"`
+	Severity    AnomalySeverity `bson:"severity" json:"severity"`
+	Status      AnomalyStatus   `bson:"status" json:"status"`
+	StoreID     string          `bson:"store_id,omitempty" json:"storeId,omitempty"`
+	RegisterID  string          `bson:"register_id,omitempty" json:"registerId,omitempty"`
+	WindowStart time.Time       `bson:"window_start" json:"windowStart"`
+	WindowEnd   time.Time       `bson:"window_end" json:"windowEnd"`
+	Message     string          `bson:"message" json:"message"`
+	Evidence    AnomalyEvidence `bson:"evidence" json:"evidence"`
+
+	// 管理者による確認
+	ReviewedBy string     `bson:"reviewed_by,omitempty" json:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time `bson:"reviewed_at,omitempty" json:"reviewedAt,omitempty"`
+	ReviewNote string     `bson:"review_note,omitempty" json:"reviewNote,omitempty"`
+
+	DetectedAt time.Time `bson:"detected_at" json:"detectedAt"`
+	UpdatedAt  time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// AnomalyQuery は異常イベントの検索条件を表します
+type AnomalyQuery struct {
+	Status  AnomalyStatus
+	Type    AnomalyType
+	StoreID string
+	Start   time.Time
+	End     time.Time
+	Skip    int64
+	Limit   int64
+}
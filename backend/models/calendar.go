@@ -0,0 +1,107 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DayType は売上分析・予測で日を区別する日の種類です
+// 1日に複数が当てはまる場合は 休業日 > イベント > 祝日 > 週末 > 平日 の順に優先します
+type DayType string
+
+const (
+	DayTypeWeekday DayType = "weekday" // 平日（月〜金）
+	DayTypeWeekend DayType = "weekend" // 土日
+	DayTypeHoliday DayType = "holiday" // 国民の祝日・振替休日
+	DayTypeEvent   DayType = "event"   // 祭り・セールなど店舗のイベント期間
+	DayTypeClosed  DayType = "closed"  // 臨時休業など
+)
+
+// DayTypes は日の種類の一覧です
+var DayTypes = []DayType{DayTypeWeekday, DayTypeWeekend, DayTypeHoliday, DayTypeEvent, DayTypeClosed}
+
+// ValidateDayType は日の種類が有効かどうかを確認します
+func ValidateDayType(t DayType) bool {
+	for _, dt := range DayTypes {
+		if t == dt {
+			return true
+		}
+	}
+	return false
+}
+
+// StoreEventType は店舗のイベントの種類です
+type StoreEventType string
+
+const (
+	StoreEventFestival StoreEventType = "festival" // 地域の祭り・催事
+	StoreEventCampaign StoreEventType = "campaign" // セール・キャンペーン
+	StoreEventClosure  StoreEventType = "closure"  // 臨時休業・棚卸など
+	StoreEventOther    StoreEventType = "other"
+)
+
+// ValidateStoreEventType はイベントの種類が有効かどうかを確認します
+func ValidateStoreEventType(t StoreEventType) bool {
+	switch t {
+	case StoreEventFestival, StoreEventCampaign, StoreEventClosure, StoreEventOther:
+		return true
+	}
+	return false
+}
+
+// StoreEvent は店舗の営業カレンダーに登録するイベントです
+type StoreEvent struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	// StoreID が空のイベントは全店舗に適用します
+	StoreID string         `bson:"store_id" json:"storeId,omitempty"`
+	Name    string         `bson:"name" json:"name"`
+	Type    StoreEventType `bson:"type" json:"type"`
+	// StartDate / EndDate は店舗のタイムゾーンでの日付（2006-01-02 形式、EndDate を含む）です
+	StartDate string    `bson:"start_date" json:"startDate"`
+	EndDate   string    `bson:"end_date" json:"endDate"`
+	Note      string    `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// StoreEventQuery は店舗のイベントの検索条件です
+type StoreEventQuery struct {
+	// StoreID を指定すると、その店舗と全店舗共通のイベントに絞り込みます
+	StoreID string
+	Type    StoreEventType
+	// StartDate / EndDate（2006-01-02 形式）を指定すると、期間と重なるイベントに絞り込みます
+	StartDate string
+	EndDate   string
+}
+
+// CalendarDay は営業カレンダーの1日です
+type CalendarDay struct {
+	Date        string        `json:"date"`
+	Weekday     string        `json:"weekday"`
+	DayType     DayType       `json:"dayType"`
+	HolidayName string        `json:"holidayName,omitempty"`
+	Events      []*StoreEvent `json:"events,omitempty"`
+}
+
+// DayTypeSales は日の種類ごとの売上集計です
+type DayTypeSales struct {
+	DayType      DayType `json:"dayType"`
+	Days         int     `json:"days"`
+	Revenue      float64 `json:"revenue"`
+	Transactions int     `json:"transactions"`
+	// AverageDailyRevenue は1日あたりの売上です
+	AverageDailyRevenue float64 `json:"averageDailyRevenue"`
+	// Index は期間全体の1日あたりの売上に対する比率です（1.0 = 平均並み）
+	Index float64 `json:"index"`
+}
+
+// DayTypeSalesReport は日の種類別の売上レポートです
+type DayTypeSalesReport struct {
+	StoreID   string         `json:"storeId,omitempty"`
+	StartDate string         `json:"startDate"`
+	EndDate   string         `json:"endDate"`
+	Days      int            `json:"days"`
+	Revenue   float64        `json:"revenue"`
+	Rows      []DayTypeSales `json:"rows"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // DeliveryStatus represents the status of a delivery
 type DeliveryStatus string
@@ -10,12 +13,17 @@ const (
 	StatusInProgress DeliveryStatus = "配送中"
 	StatusCompleted  DeliveryStatus = "配送完了"
 	StatusFailed     DeliveryStatus = "配送失敗"
+	StatusOnHold     DeliveryStatus = "配送保留"
+	StatusCancelled  DeliveryStatus = "配送キャンセル"
+	StatusReturning  DeliveryStatus = "店舗へ返送中"
+	StatusReturned   DeliveryStatus = "店舗へ返送済み"
 )
 
 // ValidateDeliveryStatus checks if the given status is valid
 func ValidateDeliveryStatus(status string) bool {
 	switch DeliveryStatus(status) {
-	case StatusPreparing, StatusInProgress, StatusCompleted, StatusFailed:
+	case StatusPreparing, StatusInProgress, StatusCompleted, StatusFailed,
+		StatusOnHold, StatusCancelled, StatusReturning, StatusReturned:
 		return true
 	default:
 		return false
@@ -24,23 +32,94 @@ func ValidateDeliveryStatus(status string) bool {
 
 // Delivery represents a delivery record
 type Delivery struct {
-	ID                    string         `json:"id" db:"id"`
-	DeliveryType          string         `json:"deliveryType" db:"delivery_type"`
-	Address               string         `json:"address" db:"address"`
-	EstimatedDeliveryTime time.Time      `json:"estimatedDeliveryTime" db:"estimated_delivery_time"`
-	ActualDeliveryTime    *time.Time     `json:"actualDeliveryTime,omitempty" db:"actual_delivery_time"`
-	Status                DeliveryStatus `json:"status" db:"status"`
-	Notes                 *string        `json:"notes,omitempty" db:"notes"`
-	TrackingInfo          *TrackingInfo  `json:"trackingInfo,omitempty" db:"-"`
-	CreatedAt             time.Time      `json:"createdAt" db:"created_at"`
-	UpdatedAt             time.Time      `json:"updatedAt" db:"updated_at"`
+	ID                    string         `json:"id" bson:"_id,omitempty" db:"id"`
+	DeliveryType          string         `json:"deliveryType" bson:"delivery_type" db:"delivery_type"`
+	Address               string         `json:"address" bson:"address" db:"address"`
+	EstimatedDeliveryTime time.Time      `json:"estimatedDeliveryTime" bson:"estimated_delivery_time" db:"estimated_delivery_time"`
+	ActualDeliveryTime    *time.Time     `json:"actualDeliveryTime,omitempty" bson:"actual_delivery_time,omitempty" db:"actual_delivery_time"`
+	Status                DeliveryStatus `json:"status" bson:"status" db:"status"`
+	Notes                 *string        `json:"notes,omitempty" bson:"notes,omitempty" db:"notes"`
+	TrackingInfo          *TrackingInfo  `json:"trackingInfo,omitempty" bson:"tracking_info,omitempty" db:"-"`
+	CreatedAt             time.Time      `json:"createdAt" bson:"created_at" db:"created_at"`
+	UpdatedAt             time.Time      `json:"updatedAt" bson:"updated_at" db:"updated_at"`
+
+	// Fleet assignment: payload weight (kg) and destination are used to pick a robot or drone
+	PayloadWeight float64    `json:"payloadWeight,omitempty" bson:"payload_weight,omitempty" db:"payload_weight"`
+	Destination   *Location  `json:"destination,omitempty" bson:"destination,omitempty" db:"-"`
+	RobotID       string     `json:"robotId,omitempty" bson:"robot_id,omitempty" db:"robot_id"`
+	AssignedAt    *time.Time `json:"assignedAt,omitempty" bson:"assigned_at,omitempty" db:"assigned_at"`
+
+	// Requested arrival window; route planning keeps the ETA inside it
+	WindowStart *time.Time `json:"windowStart,omitempty" bson:"window_start,omitempty" db:"window_start"`
+	WindowEnd   *time.Time `json:"windowEnd,omitempty" bson:"window_end,omitempty" db:"window_end"`
+
+	// Arrival predicted from the live location, remaining distance and the robot's historical speed
+	PredictedDeliveryTime *time.Time `json:"predictedDeliveryTime,omitempty" bson:"predicted_delivery_time,omitempty" db:"predicted_delivery_time"`
+
+	// Booked delivery slot; the reservation holds one unit of the slot's capacity
+	SlotID        string `json:"slotId,omitempty" bson:"slot_id,omitempty" db:"slot_id"`
+	ReservationID string `json:"reservationId,omitempty" bson:"reservation_id,omitempty" db:"reservation_id"`
+
+	// Dispatch attempts; each move to in-progress counts one, and a failed delivery can be re-dispatched until the limit
+	Attempts int `json:"attempts" bson:"attempts" db:"attempts"`
+	// Goods carried by the delivery; returning undelivered goods to the store restocks inventory
+	Items []DeliveryItem `json:"items,omitempty" bson:"items,omitempty" db:"-"`
+
+	// PIN the recipient gives to the robot or drone; it is only held while the delivery is created so it can be returned once
+	RecipientPIN string `json:"-" bson:"-" db:"-"`
+	// bcrypt hash of the recipient PIN; the PIN itself is never stored
+	RecipientPINHash string `json:"-" bson:"recipient_pin_hash,omitempty" db:"-"`
+	// PIN checks made on proof of delivery; once it reaches the limit the PIN is locked
+	PINAttempts int `json:"pinAttempts,omitempty" bson:"pin_attempts,omitempty" db:"-"`
+	// Evidence submitted on completion: drop-off photo, PIN check, GPS fix and timestamp
+	ProofOfDelivery *ProofOfDelivery `json:"proofOfDelivery,omitempty" bson:"proof_of_delivery,omitempty" db:"-"`
+}
+
+// DeliveryItem represents a product and quantity carried by a delivery
+type DeliveryItem struct {
+	ProductID string `json:"productId" bson:"product_id"`
+	Quantity  int    `json:"quantity" bson:"quantity"`
+}
+
+// DeliveryStatusUpdate represents a status transition written to a delivery.
+// It only applies while the delivery is still in From, so two concurrent updates cannot both move it.
+type DeliveryStatusUpdate struct {
+	From DeliveryStatus
+	To   DeliveryStatus
+	// CountAttempt increments the dispatch attempt counter
+	CountAttempt bool
+	// Note is recorded on the history entry, e.g. the reason for a cancellation or hold
+	Note *string
+	// Proof is stored with a completion submitted by a robot or drone; its drop-off time becomes the actual delivery time
+	Proof *ProofOfDelivery
+	// ReleaseRobot returns the assigned robot to idle, if it is still assigned to this delivery
+	ReleaseRobot bool
+	// UnassignRobot clears the delivery's robot assignment so that it can be assigned again
+	UnassignRobot bool
+	// Restock puts the delivery's items, reserved when it was created, back into stock
+	Restock bool
 }
 
 // TrackingInfo represents the current tracking information of a delivery
 type TrackingInfo struct {
-	CurrentLocation *Location `json:"currentLocation,omitempty"`
-	BatteryLevel    *float64  `json:"batteryLevel,omitempty"`
-	Speed           *float64  `json:"speed,omitempty"`
+	CurrentLocation *Location `json:"currentLocation,omitempty" bson:"current_location,omitempty"`
+	BatteryLevel    *float64  `json:"batteryLevel,omitempty" bson:"battery_level,omitempty"`
+	Speed           *float64  `json:"speed,omitempty" bson:"speed,omitempty"`
+}
+
+// ValidateTrackingInfo checks a tracking update reported by a robot or drone.
+// The current location is required; battery level and speed are optional.
+func ValidateTrackingInfo(info TrackingInfo) error {
+	if info.CurrentLocation == nil || *info.CurrentLocation == (Location{}) {
+		return errors.New("invalid location")
+	}
+	if info.BatteryLevel != nil && (*info.BatteryLevel < 0 || *info.BatteryLevel > 100) {
+		return errors.New("battery level must be between 0 and 100")
+	}
+	if info.Speed != nil && *info.Speed < 0 {
+		return errors.New("speed must not be negative")
+	}
+	return nil
 }
 
 // Location represents a geographical location
@@ -49,14 +128,38 @@ type Location struct {
 	Longitude float64 `json:"longitude"`
 }
 
-// DeliveryHistory represents a historical record of delivery status changes
+// DeliveryHistoryEvent is the kind of change recorded in a delivery history entry
+type DeliveryHistoryEvent string
+
+const (
+	HistoryCreated         DeliveryHistoryEvent = "created"
+	HistoryStatusChanged   DeliveryHistoryEvent = "status_changed"
+	HistoryLocationUpdated DeliveryHistoryEvent = "location_updated"
+	HistoryUpdated         DeliveryHistoryEvent = "updated"
+	HistoryRobotAssigned   DeliveryHistoryEvent = "robot_assigned"
+	HistoryRobotUnassigned DeliveryHistoryEvent = "robot_unassigned"
+)
+
+// FieldChange represents the previous and new value of a single delivery field.
+// Times are recorded as RFC 3339 strings and locations as "latitude,longitude".
+type FieldChange struct {
+	Field    string      `json:"field" bson:"field"`
+	Previous interface{} `json:"previous" bson:"previous"`
+	New      interface{} `json:"new" bson:"new"`
+}
+
+// DeliveryHistory represents a historical record of delivery changes.
+// DeliveryID holds the same hex string as Delivery.ID.
 type DeliveryHistory struct {
-	ID         string    `json:"id" db:"id"`
-	DeliveryID string    `json:"deliveryId" db:"delivery_id"`
-	Status     string    `json:"status" db:"status"`
-	Timestamp  time.Time `json:"timestamp" db:"timestamp"`
-	Location   *Location `json:"location,omitempty" db:"-"`
-	Note       *string   `json:"note,omitempty" db:"note"`
+	ID         string               `json:"id" bson:"_id,omitempty" db:"id"`
+	DeliveryID string               `json:"deliveryId" bson:"delivery_id" db:"delivery_id"`
+	Event      DeliveryHistoryEvent `json:"event" bson:"event" db:"event"`
+	Status     string               `json:"status" bson:"status" db:"status"`
+	Actor      string               `json:"actor" bson:"actor" db:"actor"`
+	Changes    []FieldChange        `json:"changes" bson:"changes" db:"-"`
+	Timestamp  time.Time            `json:"timestamp" bson:"timestamp" db:"timestamp"`
+	Location   *Location            `json:"location,omitempty" bson:"location,omitempty" db:"-"`
+	Note       *string              `json:"note,omitempty" bson:"note,omitempty" db:"note"`
 }
 
 // DeliveryQuery represents query parameters for filtering deliveries
@@ -0,0 +1,53 @@
+package models
+
+// EnergyStats は消費電力量と移動距離の集計です
+type EnergyStats struct {
+	// EnergyWh は消費電力量（Wh）、DistanceKm は移動距離（km）です
+	EnergyWh   float64 `json:"energyWh"`
+	DistanceKm float64 `json:"distanceKm"`
+	// WhPerKm はエネルギー効率（1kmあたりの消費電力量）です（移動距離が短く求められない場合は0）
+	WhPerKm float64 `json:"whPerKm"`
+}
+
+// DeliveryEnergy は配送1件のエネルギー効率です
+type DeliveryEnergy struct {
+	DeliveryID    string  `json:"deliveryId"`
+	RobotID       string  `json:"robotId"`
+	DeliveryType  string  `json:"deliveryType"`
+	PayloadWeight float64 `json:"payloadWeight"`
+	EnergyStats
+}
+
+// RobotEnergy は配送機体のエネルギー効率です
+type RobotEnergy struct {
+	RobotID string    `json:"robotId"`
+	Name    string    `json:"name"`
+	Type    RobotType `json:"type"`
+	EnergyStats
+	// BaselineWhPerKm は直近の期間より前、RecentWhPerKm は直近の期間のエネルギー効率です
+	BaselineWhPerKm float64 `json:"baselineWhPerKm"`
+	RecentWhPerKm   float64 `json:"recentWhPerKm"`
+	// Change は直近の期間の変化率（0.2 は 20% 悪化）です
+	Change float64 `json:"change"`
+	// Degrading はエネルギー効率が悪化しており、整備が必要な可能性があるかどうかです
+	Degrading bool `json:"degrading"`
+}
+
+// EnergyBreakdown は区分（配送種別・積載重量・時間帯）ごとのエネルギー効率です
+type EnergyBreakdown struct {
+	Label string `json:"label"`
+	EnergyStats
+}
+
+// EnergyEfficiencyReport は配送機体のエネルギー効率レポートです
+// 機体の計測値のバッテリー残量の減少とバッテリー容量から消費電力量を、位置の変化から移動距離を求めます
+type EnergyEfficiencyReport struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	EnergyStats
+	Deliveries    []DeliveryEnergy  `json:"deliveries"`
+	Robots        []RobotEnergy     `json:"robots"`
+	DeliveryTypes []EnergyBreakdown `json:"deliveryTypes"`
+	PayloadWeight []EnergyBreakdown `json:"payloadWeight"`
+	TimeOfDay     []EnergyBreakdown `json:"timeOfDay"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ETASource は到着予測に使った値の出所です
+type ETASource string
+
+const (
+	ETASourceTelemetry ETASource = "telemetry" // 機体から受信した最新の計測値
+	ETASourceTracking  ETASource = "tracking"  // 配送の追跡情報（最後に報告された現在位置）
+	ETASourceHomeBase  ETASource = "home_base" // 出発前のため機体の拠点
+	ETASourceHistory   ETASource = "history"   // 機体の過去の走行速度
+	ETASourceDefault   ETASource = "default"   // 機体の種類ごとの既定の速度
+)
+
+// DeliveryETA は配送の到着予測です
+type DeliveryETA struct {
+	DeliveryID string         `json:"deliveryId"`
+	RobotID    string         `json:"robotId"`
+	Status     DeliveryStatus `json:"status"`
+	// PredictedArrival は現在位置・配送先までの残りの距離・機体の走行速度から予測した到着時刻です
+	PredictedArrival time.Time `json:"predictedArrival"`
+	// Deadline は到着時間帯の終了（指定がない場合は配送予定時刻）です
+	Deadline time.Time `json:"deadline"`
+	// RemainingDistance は配送先までの残りの距離（km）、Speed は予測に使った走行速度（km/h）です
+	RemainingDistance float64   `json:"remainingDistance"`
+	Speed             float64   `json:"speed"`
+	LocationSource    ETASource `json:"locationSource"`
+	SpeedSource       ETASource `json:"speedSource"`
+	// Late は到着が Deadline に間に合わない予測かどうか、LateByMinutes はその遅れ（分）です
+	Late          bool      `json:"late"`
+	LateByMinutes float64   `json:"lateByMinutes"`
+	CalculatedAt  time.Time `json:"calculatedAt"`
+}
+
+// DeliveryAlertStatus は遅延アラートの状態です
+type DeliveryAlertStatus string
+
+const (
+	DeliveryAlertOpen     DeliveryAlertStatus = "open"     // 遅延の予測が続いている
+	DeliveryAlertResolved DeliveryAlertStatus = "resolved" // 間に合う予測に戻った、または配送が終了した
+)
+
+// DeliveryAlert は配送の遅延アラートです（1件の配送につき未解決のアラートは1件です）
+type DeliveryAlert struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	DeliveryID string              `bson:"delivery_id" json:"deliveryId"`
+	RobotID    string              `bson:"robot_id" json:"robotId"`
+	Status     DeliveryAlertStatus `bson:"status" json:"status"`
+	Deadline   time.Time           `bson:"deadline" json:"deadline"`
+	// PredictedArrival・LateByMinutes は最新の予測、MaxLateByMinutes は予測した遅れの最大値です
+	PredictedArrival time.Time  `bson:"predicted_arrival" json:"predictedArrival"`
+	LateByMinutes    float64    `bson:"late_by_minutes" json:"lateByMinutes"`
+	MaxLateByMinutes float64    `bson:"max_late_by_minutes" json:"maxLateByMinutes"`
+	RaisedAt         time.Time  `bson:"raised_at" json:"raisedAt"`
+	UpdatedAt        time.Time  `bson:"updated_at" json:"updatedAt"`
+	ResolvedAt       *time.Time `bson:"resolved_at,omitempty" json:"resolvedAt,omitempty"`
+}
+
+// OnTimePerformance は期間内に完了した配送の定時到着の実績です
+type OnTimePerformance struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	OnTimeStats
+	// Days は店舗のタイムゾーンでの日別の実績です
+	Days []DailyOnTimePerformance `json:"days"`
+}
+
+// DailyOnTimePerformance は1日の定時到着の実績です
+type DailyOnTimePerformance struct {
+	Date string `json:"date"`
+	OnTimeStats
+}
+
+// OnTimeStats は定時到着の集計です
+type OnTimeStats struct {
+	Delivered int `json:"delivered"`
+	OnTime    int `json:"onTime"`
+	Late      int `json:"late"`
+	// OnTimeRate は定時到着率（0〜1、配送がない場合は0）です
+	OnTimeRate float64 `json:"onTimeRate"`
+	// AverageLateMinutes は遅れた配送の平均の遅れ（分）です
+	AverageLateMinutes float64 `json:"averageLateMinutes"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExportJobStatus は非同期エクスポートの状態です
+type ExportJobStatus string
+
+const (
+	ExportJobPending   ExportJobStatus = "pending"
+	ExportJobRunning   ExportJobStatus = "running"
+	ExportJobCompleted ExportJobStatus = "completed"
+	ExportJobFailed    ExportJobStatus = "failed"
+)
+
+// ExportJob は売上データの非同期エクスポートを表します
+type ExportJob struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Format     string             `bson:"format" json:"format"`
+	StoreID    string             `bson:"store_id,omitempty" json:"storeId,omitempty"`
+	RegisterID string             `bson:"register_id,omitempty" json:"registerId,omitempty"`
+	// 対象期間（店舗のタイムゾーンでの日付、終了日を含む）
+	StartDate string          `bson:"start_date" json:"startDate"`
+	EndDate   string          `bson:"end_date" json:"endDate"`
+	Status    ExportJobStatus `bson:"status" json:"status"`
+
+	// 完了時の結果
+	RowCount int64  `bson:"row_count" json:"rowCount"`
+	FileName string `bson:"file_name,omitempty" json:"fileName,omitempty"`
+	Error    string `bson:"error,omitempty" json:"error,omitempty"`
+	// DownloadURL は完了したファイルの取得先です（保存せず応答時に設定）
+	DownloadURL string `bson:"-" json:"downloadUrl,omitempty"`
+
+	// Owner はジョブを実行するインスタンス、HeartbeatAt はその実行中の最終確認日時です
+	// 最終確認から一定時間が過ぎた未完了のジョブは、実行していたインスタンスが停止したものとして失敗にします
+	Owner       string    `bson:"owner,omitempty" json:"-"`
+	HeartbeatAt time.Time `bson:"heartbeat_at" json:"-"`
+
+	CreatedAt   time.Time  `bson:"created_at" json:"createdAt"`
+	StartedAt   *time.Time `bson:"started_at,omitempty" json:"startedAt,omitempty"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completedAt,omitempty"`
+	// ExpiresAt を過ぎたジョブとファイルは削除されます
+	ExpiresAt time.Time `bson:"expires_at" json:"expiresAt"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RobotType は配送機体の種類です
+type RobotType string
+
+const (
+	RobotTypeGround RobotType = "robot" // 地上走行の配送ロボット
+	RobotTypeDrone  RobotType = "drone" // ドローン
+)
+
+// RobotTypeForDelivery は配送種別（ロボット配送・ドローン配送）に対応する機体の種類を返します
+// 種別の指定がない場合は空文字を返します（どちらの機体でも配送可能）
+func RobotTypeForDelivery(deliveryType string) RobotType {
+	switch deliveryType {
+	case "ロボット", string(RobotTypeGround):
+		return RobotTypeGround
+	case "ドローン", string(RobotTypeDrone):
+		return RobotTypeDrone
+	}
+	return ""
+}
+
+// RobotStatus は配送機体の稼働状態です
+type RobotStatus string
+
+const (
+	RobotIdle        RobotStatus = "idle"        // 待機中（割り当て可能）
+	RobotAssigned    RobotStatus = "assigned"    // 配送に割り当て済み
+	RobotCharging    RobotStatus = "charging"    // 充電中
+	RobotMaintenance RobotStatus = "maintenance" // 整備中
+)
+
+// ValidateRobotStatus は稼働状態が有効かどうかを確認します
+func ValidateRobotStatus(status RobotStatus) bool {
+	switch status {
+	case RobotIdle, RobotAssigned, RobotCharging, RobotMaintenance:
+		return true
+	}
+	return false
+}
+
+// Robot は配送ロボット・ドローンの機体です
+type Robot struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name string             `bson:"name" json:"name"`
+	Type RobotType          `bson:"type" json:"type"`
+	// PayloadCapacity は最大積載重量（kg）です
+	PayloadCapacity float64 `bson:"payload_capacity" json:"payloadCapacity"`
+	// Range は満充電での航続距離（km）です
+	Range float64 `bson:"range" json:"range"`
+	// BatteryLevel はバッテリー残量（0〜100%）です
+	BatteryLevel float64 `bson:"battery_level" json:"batteryLevel"`
+	// BatteryCapacity はバッテリー容量（Wh）です（0の場合は機体の種類ごとの既定値を使います）
+	BatteryCapacity float64 `bson:"battery_capacity,omitempty" json:"batteryCapacity,omitempty"`
+	// HomeBase は機体が待機・充電する拠点の位置です
+	HomeBase Location    `bson:"home_base" json:"homeBase"`
+	Status   RobotStatus `bson:"status" json:"status"`
+	// CurrentDeliveryID は割り当て中の配送のIDです
+	CurrentDeliveryID string `bson:"current_delivery_id,omitempty" json:"currentDeliveryId,omitempty"`
+	// Telemetry は機体から最後に受信した計測値です
+	Telemetry *RobotTelemetry `bson:"telemetry,omitempty" json:"telemetry,omitempty"`
+	CreatedAt time.Time       `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time       `bson:"updated_at" json:"updatedAt"`
+}
+
+// RobotQuery は配送機体の検索条件です
+type RobotQuery struct {
+	Type   RobotType
+	Status RobotStatus
+}
+
+// RobotCandidate は配送への割り当てを検討した機体と、その判定結果です
+type RobotCandidate struct {
+	RobotID string    `json:"robotId"`
+	Name    string    `json:"name"`
+	Type    RobotType `json:"type"`
+	// TripDistance は拠点から配送先までの往復距離（km）です
+	TripDistance float64 `json:"tripDistance"`
+	// RequiredBattery は往復に必要なバッテリー残量（予備を含む%）です
+	RequiredBattery float64 `json:"requiredBattery"`
+	Eligible        bool    `json:"eligible"`
+	// Reason は割り当てできない理由です
+	Reason string `json:"reason,omitempty"`
+}
+
+// RobotAssignment は配送への機体の割り当て結果です
+type RobotAssignment struct {
+	DeliveryID string           `json:"deliveryId"`
+	Robot      *Robot           `json:"robot"`
+	AssignedAt time.Time        `json:"assignedAt"`
+	Candidates []RobotCandidate `json:"candidates"`
+}
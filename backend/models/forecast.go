@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// DailyRevenue は日別（店舗のタイムゾーン）の売上集計です
+type DailyRevenue struct {
+	// Date は 2006-01-02 形式の日付です
+	Date         string  `bson:"_id" json:"date"`
+	Revenue      float64 `bson:"revenue" json:"revenue"`
+	Transactions int     `bson:"transactions" json:"transactions"`
+}
+
+// ForecastPoint は1日分の売上予測です
+type ForecastPoint struct {
+	Date        string   `json:"date"`
+	Weekday     string   `json:"weekday"`
+	DayType     DayType  `json:"dayType"`
+	HolidayName string   `json:"holidayName,omitempty"`
+	Events      []string `json:"events,omitempty"`
+	Revenue     float64  `json:"revenue"`
+}
+
+// ForecastAccuracy は過去の期間で予測した場合の精度（バックテスト）です
+type ForecastAccuracy struct {
+	// Folds は評価した予測の回数、Horizon は1回の予測日数です
+	Folds   int `json:"folds"`
+	Horizon int `json:"horizon"`
+	// EvaluatedDays はMAPEの計算に使った日数です（実績が0の日は除きます）
+	EvaluatedDays int `json:"evaluatedDays"`
+	// MAPE は平均絶対パーセント誤差（%）です
+	MAPE float64 `json:"mape"`
+	// MAE は平均絶対誤差（円）です
+	MAE float64 `json:"mae"`
+}
+
+// RevenueForecast は店舗の売上予測です
+type RevenueForecast struct {
+	StoreID      string          `json:"storeId,omitempty"`
+	Model        string          `json:"model"`
+	GeneratedAt  time.Time       `json:"generatedAt"`
+	HistoryStart string          `json:"historyStart"`
+	HistoryEnd   string          `json:"historyEnd"`
+	Total        float64         `json:"total"`
+	Points       []ForecastPoint `json:"points"`
+	// Accuracy は履歴が不足してバックテストできない場合はnilです
+	Accuracy *ForecastAccuracy `json:"accuracy,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// IdempotencyStatus は冪等キーで受け付けたリクエストの処理状態です
+type IdempotencyStatus string
+
+const (
+	IdempotencyProcessing IdempotencyStatus = "processing"
+	IdempotencyCompleted  IdempotencyStatus = "completed"
+)
+
+// IdempotencyRecord は冪等キーとそのリクエストへの応答を保存します
+// 有効期限を過ぎたレコードはTTLインデックスにより削除されます
+// 処理中のレコードは短い有効期限で登録し、処理が中断された場合もキーが解放されるようにします
+type IdempotencyRecord struct {
+	// ID はエンドポイントと冪等キーを組み合わせた値です
+	ID          string            `bson:"_id" json:"id"`
+	RequestHash string            `bson:"request_hash" json:"requestHash"`
+	Status      IdempotencyStatus `bson:"status" json:"status"`
+
+	// 保存した応答
+	ResponseStatus int    `bson:"response_status,omitempty" json:"responseStatus,omitempty"`
+	ContentType    string `bson:"content_type,omitempty" json:"contentType,omitempty"`
+	ResponseBody   []byte `bson:"response_body,omitempty" json:"-"`
+
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expiresAt"`
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -32,6 +33,9 @@ func SetupIndexes(db *mongo.Database) error {
 				"shelf_location": 1,
 			},
 		},
+		{
+			Keys: bson.D{{Key: "abc.class", Value: 1}, {Key: "abc.rank", Value: 1}},
+		},
 	}
 
 	if _, err := db.Collection("products").Indexes().CreateMany(ctx, productIndexes); err != nil {
@@ -80,6 +84,31 @@ func SetupIndexes(db *mongo.Database) error {
 				"time_of_day": 1,
 			},
 		},
+		{
+			Keys: bson.D{
+				{Key: "store_id", Value: 1},
+				{Key: "register_id", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "member_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			// 同じ端末取引の売上は1件だけ
+			Keys: bson.D{
+				{Key: "store_id", Value: 1},
+				{Key: "register_id", Value: 1},
+				{Key: "terminal_transaction_id", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"terminal_transaction_id": bson.M{"$exists": true}}),
+		},
 	}
 
 	if _, err := db.Collection("sales").Indexes().CreateMany(ctx, saleIndexes); err != nil {
@@ -111,5 +140,292 @@ func SetupIndexes(db *mongo.Database) error {
 		return err
 	}
 
+	// Store settings collection indexes
+	storeSettingsIndexes := []mongo.IndexModel{
+		{
+			Keys: map[string]interface{}{
+				"store_id": 1,
+			},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	if _, err := db.Collection("store_settings").Indexes().CreateMany(ctx, storeSettingsIndexes); err != nil {
+		log.Printf("Failed to create store settings indexes: %v", err)
+		return err
+	}
+
+	// Register sessions collection indexes
+	registerSessionIndexes := []mongo.IndexModel{
+		{
+			// 1つのレジで同時に開局できるセッションは1つだけ
+			Keys: bson.D{
+				{Key: "store_id", Value: 1},
+				{Key: "register_id", Value: 1},
+			},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": RegisterSessionOpen}),
+		},
+		{
+			Keys: bson.D{
+				{Key: "store_id", Value: 1},
+				{Key: "opened_at", Value: 1},
+			},
+		},
+	}
+
+	if _, err := db.Collection("register_sessions").Indexes().CreateMany(ctx, registerSessionIndexes); err != nil {
+		log.Printf("Failed to create register session indexes: %v", err)
+		return err
+	}
+
+	// Members collection indexes
+	memberIndexes := []mongo.IndexModel{
+		{
+			Keys: map[string]interface{}{
+				"member_code": 1,
+			},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	if _, err := db.Collection("members").Indexes().CreateMany(ctx, memberIndexes); err != nil {
+		log.Printf("Failed to create member indexes: %v", err)
+		return err
+	}
+
+	// Point transactions collection indexes
+	pointTransactionIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "member_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+		},
+	}
+
+	if _, err := db.Collection("point_transactions").Indexes().CreateMany(ctx, pointTransactionIndexes); err != nil {
+		log.Printf("Failed to create point transaction indexes: %v", err)
+		return err
+	}
+
+	// Idempotency keys collection indexes
+	idempotencyIndexes := []mongo.IndexModel{
+		{
+			// 有効期限を過ぎたキーを自動削除
+			Keys: map[string]interface{}{
+				"expires_at": 1,
+			},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	if _, err := db.Collection("idempotency_keys").Indexes().CreateMany(ctx, idempotencyIndexes); err != nil {
+		log.Printf("Failed to create idempotency key indexes: %v", err)
+		return err
+	}
+
+	// Sale returns collection indexes
+	saleReturnIndexes := []mongo.IndexModel{
+		{
+			Keys: map[string]interface{}{
+				"sale_id": 1,
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "store_id", Value: 1},
+				{Key: "register_id", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+		},
+	}
+
+	if _, err := db.Collection("sale_returns").Indexes().CreateMany(ctx, saleReturnIndexes); err != nil {
+		log.Printf("Failed to create sale return indexes: %v", err)
+		return err
+	}
+
+	// Anomaly events collection indexes
+	anomalyIndexes := []mongo.IndexModel{
+		{
+			// 同じ異常は1件のイベントにまとめる
+			Keys: map[string]interface{}{
+				"fingerprint": 1,
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "window_start", Value: -1},
+			},
+		},
+	}
+
+	if _, err := db.Collection("anomaly_events").Indexes().CreateMany(ctx, anomalyIndexes); err != nil {
+		log.Printf("Failed to create anomaly event indexes: %v", err)
+		return err
+	}
+
+	// Export jobs collection indexes
+	exportJobIndexes := []mongo.IndexModel{
+		{
+			// 保存期間を過ぎたジョブを自動削除
+			Keys: map[string]interface{}{
+				"expires_at": 1,
+			},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	if _, err := db.Collection("export_jobs").Indexes().CreateMany(ctx, exportJobIndexes); err != nil {
+		log.Printf("Failed to create export job indexes: %v", err)
+		return err
+	}
+
+	// Sales targets collection indexes
+	salesTargetIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "store_id", Value: 1},
+				{Key: "category", Value: 1},
+				{Key: "period_type", Value: 1},
+				{Key: "period", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "store_id", Value: 1},
+				{Key: "period_start", Value: 1},
+				{Key: "period_end", Value: 1},
+			},
+		},
+	}
+
+	if _, err := db.Collection("sales_targets").Indexes().CreateMany(ctx, salesTargetIndexes); err != nil {
+		log.Printf("Failed to create sales target indexes: %v", err)
+		return err
+	}
+
+	// Store events collection indexes
+	storeEventIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "store_id", Value: 1},
+				{Key: "start_date", Value: 1},
+				{Key: "end_date", Value: 1},
+			},
+		},
+	}
+
+	if _, err := db.Collection("store_events").Indexes().CreateMany(ctx, storeEventIndexes); err != nil {
+		log.Printf("Failed to create store event indexes: %v", err)
+		return err
+	}
+
+	// Robots collection indexes
+	robotIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "type", Value: 1},
+				{Key: "status", Value: 1},
+			},
+		},
+	}
+
+	if _, err := db.Collection("robots").Indexes().CreateMany(ctx, robotIndexes); err != nil {
+		log.Printf("Failed to create robot indexes: %v", err)
+		return err
+	}
+
+	// Delivery history collection indexes
+	deliveryHistoryIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "delivery_id", Value: 1},
+				{Key: "timestamp", Value: 1},
+			},
+		},
+	}
+
+	if _, err := db.Collection("delivery_history").Indexes().CreateMany(ctx, deliveryHistoryIndexes); err != nil {
+		log.Printf("Failed to create delivery history indexes: %v", err)
+		return err
+	}
+
+	// Delivery alert collection indexes
+	deliveryAlertIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "delivery_id", Value: 1},
+				{Key: "status", Value: 1},
+			},
+		},
+		{
+			// 未解決の遅延アラートは配送ごとに1件だけ
+			Keys: bson.D{{Key: "delivery_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": DeliveryAlertOpen}),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "raised_at", Value: -1},
+			},
+		},
+	}
+
+	if _, err := db.Collection("delivery_alerts").Indexes().CreateMany(ctx, deliveryAlertIndexes); err != nil {
+		log.Printf("Failed to create delivery alert indexes: %v", err)
+		return err
+	}
+
+	// Delivery slot collection indexes
+	slotTemplateIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "weekday", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	if _, err := db.Collection("delivery_slot_templates").Indexes().CreateMany(ctx, slotTemplateIndexes); err != nil {
+		log.Printf("Failed to create delivery slot template indexes: %v", err)
+		return err
+	}
+
+	deliverySlotIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "start", Value: 1},
+				{Key: "end", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	if _, err := db.Collection("delivery_slots").Indexes().CreateMany(ctx, deliverySlotIndexes); err != nil {
+		log.Printf("Failed to create delivery slot indexes: %v", err)
+		return err
+	}
+
+	// Slot reservation collection indexes
+	slotReservationIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "expires_at", Value: 1},
+			},
+		},
+	}
+
+	if _, err := db.Collection("slot_reservations").Indexes().CreateMany(ctx, slotReservationIndexes); err != nil {
+		log.Printf("Failed to create slot reservation indexes: %v", err)
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Member はポイント会員を表します
+type Member struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	// MemberCode は会員カード・アプリに表示される会員番号です
+	MemberCode string `bson:"member_code" json:"memberCode"`
+	Name       string `bson:"name" json:"name"`
+	Email      string `bson:"email,omitempty" json:"email,omitempty"`
+	Phone      string `bson:"phone,omitempty" json:"phone,omitempty"`
+
+	// ポイント
+	PointBalance   int `bson:"point_balance" json:"pointBalance"`
+	LifetimePoints int `bson:"lifetime_points" json:"lifetimePoints"`
+
+	// 購買実績
+	TotalSpent  float64    `bson:"total_spent" json:"totalSpent"`
+	VisitCount  int        `bson:"visit_count" json:"visitCount"`
+	LastVisitAt *time.Time `bson:"last_visit_at,omitempty" json:"lastVisitAt,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// PointTransactionType はポイント履歴の種別です
+type PointTransactionType string
+
+const (
+	PointTransactionEarn   PointTransactionType = "earn"   // 購入による付与
+	PointTransactionRedeem PointTransactionType = "redeem" // 支払いへの利用
+	PointTransactionAdjust PointTransactionType = "adjust" // 手動調整
+)
+
+// PointTransaction はポイント台帳の1件を表します
+type PointTransaction struct {
+	ID       primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	MemberID primitive.ObjectID   `bson:"member_id" json:"memberId"`
+	SaleID   *primitive.ObjectID  `bson:"sale_id,omitempty" json:"saleId,omitempty"`
+	Type     PointTransactionType `bson:"type" json:"type"`
+	// Points は増減ポイントです（利用は負の値）
+	Points int `bson:"points" json:"points"`
+	// BonusPoints は付与ポイントのうち低CO2ボーナス分です
+	BonusPoints  int    `bson:"bonus_points,omitempty" json:"bonusPoints,omitempty"`
+	BalanceAfter int    `bson:"balance_after" json:"balanceAfter"`
+	Note         string `bson:"note,omitempty" json:"note,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+}
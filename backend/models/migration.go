@@ -0,0 +1,153 @@
+package models
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fieldRename は以前のキーから現在のキーへの移行です
+type fieldRename struct {
+	From string
+	To   string
+}
+
+// legacyDeliveryFields は bson 名を明示する前に保存された配送のキーと現在のキーの対応です
+// 以前は登録時の項目がドライバーの既定（小文字化した項目名）で保存され、ステータス・位置の更新は
+// updated_at・completed_at・current_location に書き込んでいました。入れ子の項目は親の項目より先に移行します
+var legacyDeliveryFields = []fieldRename{
+	{From: "trackinginfo.currentlocation", To: "trackinginfo.current_location"},
+	{From: "trackinginfo.batterylevel", To: "trackinginfo.battery_level"},
+	{From: "trackinginfo", To: "tracking_info"},
+	{From: "current_location", To: "tracking_info.current_location"},
+	{From: "deliverytype", To: "delivery_type"},
+	{From: "estimateddeliverytime", To: "estimated_delivery_time"},
+	{From: "actualdeliverytime", To: "actual_delivery_time"},
+	{From: "completed_at", To: "actual_delivery_time"},
+	{From: "createdat", To: "created_at"},
+	{From: "updatedat", To: "updated_at"},
+}
+
+// legacyDeliveryHistoryFields は bson 名を明示する前に保存された配送履歴のキーと現在のキーの対応です
+var legacyDeliveryHistoryFields = []fieldRename{
+	{From: "deliveryid", To: "delivery_id"},
+}
+
+// MigrateLegacyFields は以前のキーで保存された配送・配送履歴を現在のキーに移行し、
+// 一意インデックスを設定する前に作られた同じ配送の重複した未解決の遅延アラートを整理します
+// 移行済みのドキュメントは対象にならないため、起動のたびに実行できます
+func MigrateLegacyFields(db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	migrations := []struct {
+		collection string
+		fields     []fieldRename
+	}{
+		{collection: "deliveries", fields: legacyDeliveryFields},
+		{collection: "delivery_history", fields: legacyDeliveryHistoryFields},
+	}
+	for _, m := range migrations {
+		collection := db.Collection(m.collection)
+		// 以前は ID が _id とは別に空の "id" 項目として保存されていました
+		fields := append(m.fields, fieldRename{From: "id"})
+		for _, field := range fields {
+			if err := renameField(ctx, collection, field); err != nil {
+				log.Printf("Failed to migrate %s.%s: %v", m.collection, field.From, err)
+				return err
+			}
+		}
+	}
+
+	if err := resolveDuplicateOpenAlerts(ctx, db.Collection("delivery_alerts")); err != nil {
+		log.Printf("Failed to resolve duplicate delivery alerts: %v", err)
+		return err
+	}
+	return nil
+}
+
+// resolveDuplicateOpenAlerts は同じ配送に未解決の遅延アラートが複数ある場合、最後に更新したもの以外を解決済みにします
+func resolveDuplicateOpenAlerts(ctx context.Context, collection *mongo.Collection) error {
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": DeliveryAlertOpen}}},
+		{{Key: "$sort", Value: bson.D{{Key: "updated_at", Value: -1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$delivery_id",
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var duplicates bson.A
+	for cursor.Next(ctx) {
+		var group struct {
+			IDs bson.A `bson:"ids"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return err
+		}
+		duplicates = append(duplicates, group.IDs[1:]...)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	result, err := collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": duplicates}}, bson.M{
+		"$set": bson.M{
+			"status":      DeliveryAlertResolved,
+			"resolved_at": now,
+			"updated_at":  now,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Resolved %d duplicate open delivery alerts", result.ModifiedCount)
+	return nil
+}
+
+// renameField は以前のキーの値を現在のキーに移し、以前のキーを削除します
+// 現在のキーに既に値がある場合は、新しい書き込みのため以前の値で上書きしません（To が空の場合は削除のみ）
+func renameField(ctx context.Context, collection *mongo.Collection, field fieldRename) error {
+	if field.To != "" {
+		// null の親項目の中には移せないため、先に削除します
+		if i := strings.LastIndex(field.To, "."); i > 0 {
+			parent := field.To[:i]
+			if _, err := collection.UpdateMany(ctx,
+				bson.M{parent: bson.M{"$type": "null"}},
+				bson.M{"$unset": bson.M{parent: ""}},
+			); err != nil {
+				return err
+			}
+		}
+		result, err := collection.UpdateMany(ctx,
+			bson.M{field.From: bson.M{"$exists": true}, field.To: nil},
+			bson.M{"$rename": bson.M{field.From: field.To}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount > 0 {
+			log.Printf("Migrated %d documents in %s from %s to %s", result.ModifiedCount, collection.Name(), field.From, field.To)
+		}
+	}
+
+	_, err := collection.UpdateMany(ctx,
+		bson.M{field.From: bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{field.From: ""}},
+	)
+	return err
+}
@@ -18,6 +18,12 @@ type Product struct {
 	Dimensions  string             `bson:"dimensions" json:"dimensions"`
 	Images      []string           `bson:"images" json:"images"`
 
+	// CostPrice は1個あたりの仕入原価です（0は未登録。更新時に0を指定した場合は登録済みの原価を維持します）
+	CostPrice float64 `bson:"cost_price" json:"costPrice"`
+
+	// 消費税区分
+	TaxClass TaxClass `bson:"tax_class" json:"taxClass"`
+
 	// 環境負荷関連
 	CO2Emission float64 `bson:"co2_emission" json:"co2Emission"`
 	RecycleRate float64 `bson:"recycle_rate" json:"recycleRate"`
@@ -29,6 +35,12 @@ type Product struct {
 	MinStockLevel int `bson:"min_stock_level" json:"minStockLevel"`
 	ReorderPoint  int `bson:"reorder_point" json:"reorderPoint"`
 
+	// ABC分析の結果（未分析の場合はnil）
+	ABC *ProductABC `bson:"abc,omitempty" json:"abc,omitempty"`
+
+	// Warnings は登録・更新時の注意事項です（原価割れなど。保存しない）
+	Warnings []string `bson:"-" json:"warnings,omitempty"`
+
 	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
 }
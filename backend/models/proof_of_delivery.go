@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ProofOfDelivery は配送完了時にロボット・ドローンが提出した配送の証跡です
+type ProofOfDelivery struct {
+	// PhotoKey はブロブストレージ上の受け渡し写真のキーです
+	PhotoKey         string `bson:"photo_key" json:"-"`
+	PhotoContentType string `bson:"photo_content_type" json:"photoContentType"`
+	PhotoSize        int64  `bson:"photo_size" json:"photoSize"`
+	// PhotoURL は写真を取得するAPIのパスです（保存しない）
+	PhotoURL string `bson:"-" json:"photoUrl,omitempty"`
+	// PINVerified は受取人の暗証番号を照合したかどうかです（暗証番号のない配送では false）
+	PINVerified bool `bson:"pin_verified" json:"pinVerified"`
+	// Location は受け渡し地点のGPSの位置です
+	Location Location `bson:"location" json:"location"`
+	// DistanceFromDestination は配送先からの距離（m）です
+	DistanceFromDestination *float64 `bson:"distance_from_destination,omitempty" json:"distanceFromDestination,omitempty"`
+	// DeliveredAt は機体が記録した受け渡し日時、SubmittedAt は証跡を受け付けた日時です
+	DeliveredAt time.Time `bson:"delivered_at" json:"deliveredAt"`
+	SubmittedBy string    `bson:"submitted_by" json:"submittedBy"`
+	SubmittedAt time.Time `bson:"submitted_at" json:"submittedAt"`
+}
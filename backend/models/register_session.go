@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 支払方法
+const (
+	PaymentMethodCash       = "cash"
+	PaymentMethodCreditCard = "credit_card"
+	PaymentMethodEMoney     = "e_money"
+	PaymentMethodQRCode     = "qr_code"
+	PaymentMethodPoints     = "points" // 会員ポイント
+)
+
+// CashDenominations は日本円の金種（紙幣・硬貨）です
+var CashDenominations = []int{10000, 5000, 2000, 1000, 500, 100, 50, 10, 5, 1}
+
+// ValidateDenomination は金種が有効かどうかをチェックします
+func ValidateDenomination(value int) bool {
+	for _, d := range CashDenominations {
+		if d == value {
+			return true
+		}
+	}
+	return false
+}
+
+// DenominationCount は金種ごとの枚数を表します
+type DenominationCount struct {
+	Denomination int `bson:"denomination" json:"denomination"`
+	Count        int `bson:"count" json:"count"`
+}
+
+// RegisterSessionStatus はレジ精算セッションの状態を表します
+type RegisterSessionStatus string
+
+const (
+	RegisterSessionOpen   RegisterSessionStatus = "open"
+	RegisterSessionClosed RegisterSessionStatus = "closed"
+)
+
+// PaymentTakings は支払方法ごとの売上と実査額を表します
+type PaymentTakings struct {
+	PaymentMethod string  `bson:"payment_method" json:"paymentMethod"`
+	SaleCount     int     `bson:"sale_count" json:"saleCount"`
+	Expected      float64 `bson:"expected" json:"expected"`
+	// Counted は実査額です（未申告の場合はnil）
+	Counted *float64 `bson:"counted,omitempty" json:"counted,omitempty"`
+	// Difference は過不足（実査額 - 理論値）です
+	Difference *float64 `bson:"difference,omitempty" json:"difference,omitempty"`
+}
+
+// RegisterSession はレジの開局から締めまでの精算セッションを表します
+type RegisterSession struct {
+	ID         primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	StoreID    string                `bson:"store_id" json:"storeId"`
+	RegisterID string                `bson:"register_id" json:"registerId"`
+	Status     RegisterSessionStatus `bson:"status" json:"status"`
+
+	OpenedBy     string    `bson:"opened_by,omitempty" json:"openedBy,omitempty"`
+	OpenedAt     time.Time `bson:"opened_at" json:"openedAt"`
+	OpeningFloat float64   `bson:"opening_float" json:"openingFloat"`
+
+	ClosedBy    string              `bson:"closed_by,omitempty" json:"closedBy,omitempty"`
+	ClosedAt    *time.Time          `bson:"closed_at,omitempty" json:"closedAt,omitempty"`
+	CountedCash []DenominationCount `bson:"counted_cash,omitempty" json:"countedCash,omitempty"`
+
+	// 締め時点の精算結果
+	SaleCount        int              `bson:"sale_count" json:"saleCount"`
+	Takings          []PaymentTakings `bson:"takings,omitempty" json:"takings,omitempty"`
+	ExpectedCash     float64          `bson:"expected_cash" json:"expectedCash"`
+	CountedCashTotal float64          `bson:"counted_cash_total" json:"countedCashTotal"`
+	CashOverShort    float64          `bson:"cash_over_short" json:"cashOverShort"`
+
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// ZReport はレジまたは店舗の日次精算レポート（Zレポート）を表します
+type ZReport struct {
+	StoreID      string    `json:"storeId"`
+	RegisterID   string    `json:"registerId,omitempty"`
+	BusinessDate string    `json:"businessDate"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+
+	// 売上
+	SaleCount    int            `json:"saleCount"`
+	GrossSales   float64        `json:"grossSales"`
+	Discounts    float64        `json:"discounts"`
+	NetSales     float64        `json:"netSales"`
+	TotalTax     float64        `json:"totalTax"`
+	TaxBreakdown []TaxBreakdown `json:"taxBreakdown"`
+	CO2Saved     float64        `json:"co2Saved"`
+
+	// 精算
+	Takings       []PaymentTakings `json:"takings"`
+	OpeningFloat  float64          `json:"openingFloat"`
+	ExpectedCash  float64          `json:"expectedCash"`
+	CountedCash   float64          `json:"countedCash"`
+	CashOverShort float64          `json:"cashOverShort"`
+	SessionCount  int              `json:"sessionCount"`
+	// OpenSessionCount は未締めのセッション数です（0でない場合、精算は確定していません）
+	OpenSessionCount int `json:"openSessionCount"`
+
+	// Registers は店舗単位のレポートに含まれるレジごとの内訳です
+	Registers []*ZReport `json:"registers,omitempty"`
+}
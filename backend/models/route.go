@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// RoutePlan は配送ロボット・ドローンが複数の配送先を巡回する配送ルートの計画です
+type RoutePlan struct {
+	// DepartureTime は各機体が拠点を出発する時刻です
+	DepartureTime time.Time       `json:"departureTime"`
+	Routes        []DeliveryRoute `json:"routes"`
+	// Unassigned はルートに入らなかった配送です
+	Unassigned []UnroutedDelivery `json:"unassigned"`
+	// TotalDistance は全ルートの移動距離の合計（km）です
+	TotalDistance float64 `json:"totalDistance"`
+}
+
+// DeliveryRoute は1台の機体が拠点を出発し、配送先を順に巡回して拠点に戻るルートです
+type DeliveryRoute struct {
+	RobotID string      `json:"robotId"`
+	Name    string      `json:"name"`
+	Type    RobotType   `json:"type"`
+	Stops   []RouteStop `json:"stops"`
+	// Load は積載する荷物の重量の合計（kg）です
+	Load float64 `json:"load"`
+	// Distance は拠点に戻るまでの移動距離（km）です
+	Distance float64 `json:"distance"`
+	// RequiredBattery は巡回に必要なバッテリー残量（予備を含む%）です
+	RequiredBattery float64   `json:"requiredBattery"`
+	DepartureTime   time.Time `json:"departureTime"`
+	ReturnTime      time.Time `json:"returnTime"`
+}
+
+// RouteStop はルート上の配送先と到着予定です
+type RouteStop struct {
+	// Sequence は巡回の順番（1から）です
+	Sequence   int      `json:"sequence"`
+	DeliveryID string   `json:"deliveryId"`
+	Address    string   `json:"address"`
+	Location   Location `json:"location"`
+	// ETA は到着予定時刻、DepartureTime は荷物を引き渡して出発する予定時刻です
+	ETA           time.Time `json:"eta"`
+	DepartureTime time.Time `json:"departureTime"`
+	// WaitMinutes は到着時間帯の開始まで待つ時間（分）です
+	WaitMinutes float64    `json:"waitMinutes"`
+	WindowStart *time.Time `json:"windowStart,omitempty"`
+	WindowEnd   *time.Time `json:"windowEnd,omitempty"`
+	// Distance は拠点からの累積の移動距離（km）です
+	Distance float64 `json:"distance"`
+}
+
+// UnroutedDelivery はルートに入らなかった配送と、その理由です
+type UnroutedDelivery struct {
+	DeliveryID string `json:"deliveryId"`
+	Reason     string `json:"reason"`
+}
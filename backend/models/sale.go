@@ -7,18 +7,73 @@ import (
 
 type SaleItem struct {
 	ProductID   primitive.ObjectID `bson:"product_id" json:"productId"`
+	Name        string             `bson:"name,omitempty" json:"name,omitempty"`
 	Quantity    int                `bson:"quantity" json:"quantity"`
 	PriceAtSale float64            `bson:"price_at_sale" json:"priceAtSale"`
+
+	// 販売時点の商品カテゴリ（集計用のスナップショット）
+	Category string `bson:"category,omitempty" json:"category,omitempty"`
+
+	// 販売時点の消費税区分
+	TaxClass TaxClass `bson:"tax_class,omitempty" json:"taxClass,omitempty"`
+
+	// 販売時点の1個あたりのCO2排出量（kg）
+	CO2Emission float64 `bson:"co2_emission,omitempty" json:"co2Emission,omitempty"`
+
+	// 販売時点の1個あたりの仕入原価（税抜。粗利の集計用のスナップショット）
+	CostAtSale float64 `bson:"cost_at_sale,omitempty" json:"costAtSale,omitempty"`
+}
+
+// AppliedPromotion は売上に適用された値引き・プロモーションを表します
+type AppliedPromotion struct {
+	Code string `bson:"code" json:"code"`
+	Name string `bson:"name" json:"name"`
+	// Discount は値引額です（正の値）
+	Discount float64 `bson:"discount" json:"discount"`
+	// ProductID は値引の対象商品です（売上全体への値引きの場合は未指定）
+	ProductID *primitive.ObjectID `bson:"product_id,omitempty" json:"productId,omitempty"`
+	// TaxClass は値引を差し引く税区分です（売上全体への値引きで未指定の場合は税区分ごとに按分）
+	TaxClass TaxClass `bson:"tax_class" json:"taxClass"`
+}
+
+// SaleQuery は売上の検索条件を表します
+type SaleQuery struct {
+	StoreID    string
+	RegisterID string
+	Start      time.Time
+	End        time.Time
 }
 
 type Sale struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	StoreID     string             `bson:"store_id,omitempty" json:"storeId,omitempty"`
+	RegisterID  string             `bson:"register_id,omitempty" json:"registerId,omitempty"`
 	Items       []SaleItem         `bson:"items" json:"items"`
+	Promotions  []AppliedPromotion `bson:"promotions,omitempty" json:"promotions,omitempty"`
 	TotalAmount float64            `bson:"total_amount" json:"totalAmount"`
 
+	// TerminalTransactionID はPOS端末が採番した取引IDです（端末からの再送による二重計上を防ぐ）
+	TerminalTransactionID string `bson:"terminal_transaction_id,omitempty" json:"terminalTransactionId,omitempty"`
+
+	// 消費税（税率ごとの内訳は適格請求書の記載事項）
+	Subtotal      float64        `bson:"subtotal" json:"subtotal"`
+	TotalTax      float64        `bson:"total_tax" json:"totalTax"`
+	TaxBreakdown  []TaxBreakdown `bson:"tax_breakdown" json:"taxBreakdown"`
+	InvoiceIssuer *InvoiceIssuer `bson:"invoice_issuer,omitempty" json:"invoiceIssuer,omitempty"`
+
 	// 環境影響
 	TotalCO2Saved float64 `bson:"total_co2_saved" json:"totalCO2Saved"`
 
+	// 会員・ポイント
+	MemberID *primitive.ObjectID `bson:"member_id,omitempty" json:"memberId,omitempty"`
+	// PointsRedeemed は支払いに利用したポイント数です
+	PointsRedeemed int `bson:"points_redeemed,omitempty" json:"pointsRedeemed,omitempty"`
+	// PointsPayment はポイントで支払った金額です（残額は PaymentMethod で支払う）
+	PointsPayment float64 `bson:"points_payment,omitempty" json:"pointsPayment,omitempty"`
+	PointsEarned  int     `bson:"points_earned,omitempty" json:"pointsEarned,omitempty"`
+	// CO2BonusPoints は付与ポイントのうち低CO2商品によるボーナス分です
+	CO2BonusPoints int `bson:"co2_bonus_points,omitempty" json:"co2BonusPoints,omitempty"`
+
 	// 分析用データ
 	PaymentMethod string `bson:"payment_method" json:"paymentMethod"`
 	TimeOfDay     string `bson:"time_of_day" json:"timeOfDay"`
@@ -26,3 +81,34 @@ type Sale struct {
 
 	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
 }
+
+// BulkSaleStatus は一括登録の行ごとの処理結果です
+type BulkSaleStatus string
+
+const (
+	BulkSaleCreated   BulkSaleStatus = "created"   // 登録済み
+	BulkSaleDuplicate BulkSaleStatus = "duplicate" // 同じ端末取引が登録済み（再送）
+	BulkSaleInvalid   BulkSaleStatus = "invalid"   // 検証エラー（再送しても登録されない）
+	BulkSaleFailed    BulkSaleStatus = "failed"    // 一時的なエラー（再送が必要）
+)
+
+// BulkSaleResult は一括登録の1行の処理結果を表します
+type BulkSaleResult struct {
+	Line                  int                 `json:"line"`
+	Status                BulkSaleStatus      `json:"status"`
+	SaleID                *primitive.ObjectID `json:"saleId,omitempty"`
+	TerminalTransactionID string              `json:"terminalTransactionId,omitempty"`
+	Error                 string              `json:"error,omitempty"`
+}
+
+// BulkSaleSummary は一括登録全体の処理結果を表します
+type BulkSaleSummary struct {
+	Received   int `json:"received"`
+	Created    int `json:"created"`
+	Duplicates int `json:"duplicates"`
+	Invalid    int `json:"invalid"`
+	Failed     int `json:"failed"`
+	// LastAcknowledgedLine はこの行までの結果が確定していることを表します
+	// 端末は次の行から再送できます
+	LastAcknowledgedLine int `json:"lastAcknowledgedLine"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SaleReturnType は取消・返品の種別です
+type SaleReturnType string
+
+const (
+	SaleReturnVoid   SaleReturnType = "void"   // 会計直後の取消
+	SaleReturnRefund SaleReturnType = "refund" // 後日の返品・返金
+)
+
+// ValidateSaleReturnType は取消・返品の種別が有効かどうかをチェックします
+func ValidateSaleReturnType(t SaleReturnType) bool {
+	return t == SaleReturnVoid || t == SaleReturnRefund
+}
+
+// SaleReturn は売上の取消・返品（返金）を表します
+// 売上とは別に記録し、元の売上は変更しません
+type SaleReturn struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SaleID     primitive.ObjectID `bson:"sale_id" json:"saleId"`
+	StoreID    string             `bson:"store_id,omitempty" json:"storeId,omitempty"`
+	RegisterID string             `bson:"register_id,omitempty" json:"registerId,omitempty"`
+	Type       SaleReturnType     `bson:"type" json:"type"`
+	// Amount は返金額です
+	Amount      float64 `bson:"amount" json:"amount"`
+	Reason      string  `bson:"reason" json:"reason"`
+	ProcessedBy string  `bson:"processed_by,omitempty" json:"processedBy,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+}
@@ -0,0 +1,139 @@
+package models
+
+import "time"
+
+// CategorySales はカテゴリー別の売上集計を表します
+type CategorySales struct {
+	Category string  `bson:"_id" json:"category"`
+	Units    int     `bson:"units" json:"units"`
+	Revenue  float64 `bson:"revenue" json:"revenue"`
+	Share    float64 `bson:"-" json:"share"`
+}
+
+// CategorySalesComparison は当期と前期のカテゴリー別売上の比較を表します
+type CategorySalesComparison struct {
+	Category        string  `json:"category"`
+	Units           int     `json:"units"`
+	Revenue         float64 `json:"revenue"`
+	Share           float64 `json:"share"`
+	PreviousUnits   int     `json:"previousUnits"`
+	PreviousRevenue float64 `json:"previousRevenue"`
+	PreviousShare   float64 `json:"previousShare"`
+	// RevenueGrowth は前期比の売上成長率です（前期売上が0の場合はnil）
+	RevenueGrowth *float64 `json:"revenueGrowth,omitempty"`
+}
+
+// CategorySalesReport はカテゴリー別売上レポートを表します
+type CategorySalesReport struct {
+	Start                time.Time                 `json:"start"`
+	End                  time.Time                 `json:"end"`
+	PreviousStart        time.Time                 `json:"previousStart"`
+	PreviousEnd          time.Time                 `json:"previousEnd"`
+	TotalUnits           int                       `json:"totalUnits"`
+	TotalRevenue         float64                   `json:"totalRevenue"`
+	PreviousTotalUnits   int                       `json:"previousTotalUnits"`
+	PreviousTotalRevenue float64                   `json:"previousTotalRevenue"`
+	Categories           []CategorySalesComparison `json:"categories"`
+}
+
+// HourlySales は曜日・時間帯ごとの売上集計です（店舗のタイムゾーン）
+type HourlySales struct {
+	// DayOfWeek は曜日です（MongoDBの $dayOfWeek と同じく日曜=1〜土曜=7）
+	DayOfWeek    int     `bson:"day_of_week" json:"dayOfWeek"`
+	Hour         int     `bson:"hour" json:"hour"`
+	Revenue      float64 `bson:"revenue" json:"revenue"`
+	Transactions int     `bson:"transactions" json:"transactions"`
+}
+
+// HeatmapCell はヒートマップの1マス（曜日×時間帯）の集計です
+type HeatmapCell struct {
+	Revenue       float64 `json:"revenue"`
+	Transactions  int     `json:"transactions"`
+	AverageBasket float64 `json:"averageBasket"`
+	// 期間中の同じ曜日の日数で割った1日あたりの値
+	AverageDailyRevenue      float64 `json:"averageDailyRevenue"`
+	AverageDailyTransactions float64 `json:"averageDailyTransactions"`
+	// Intensity は1日あたりの売上が最大のマスを1とした相対値です（色分け用）
+	Intensity float64 `json:"intensity"`
+	Peak      bool    `json:"peak"`
+}
+
+// PeakPeriod は混雑する曜日・時間帯の連続した区間です
+type PeakPeriod struct {
+	Weekday   string `json:"weekday"`
+	StartHour int    `json:"startHour"`
+	// EndHour は区間の終了時刻です（この時刻を含まない）
+	EndHour                  int     `json:"endHour"`
+	Revenue                  float64 `json:"revenue"`
+	Transactions             int     `json:"transactions"`
+	AverageDailyTransactions float64 `json:"averageDailyTransactions"`
+	// RevenueShare は期間の総売上に占める割合です
+	RevenueShare float64 `json:"revenueShare"`
+}
+
+// SalesHeatmap は曜日（月〜日）×時間帯（0〜23時）の売上ヒートマップです
+type SalesHeatmap struct {
+	StoreID   string             `json:"storeId,omitempty"`
+	StartDate string             `json:"startDate"`
+	EndDate   string             `json:"endDate"`
+	Timezone  string             `json:"timezone"`
+	Weekdays  []string           `json:"weekdays"`
+	DayCounts [7]int             `json:"dayCounts"`
+	Cells     [7][24]HeatmapCell `json:"cells"`
+
+	TotalRevenue      float64 `json:"totalRevenue"`
+	TotalTransactions int     `json:"totalTransactions"`
+	AverageBasket     float64 `json:"averageBasket"`
+
+	// Peaks は混雑する区間を売上の大きい順に並べたものです
+	Peaks []PeakPeriod `json:"peaks"`
+}
+
+// MarginGroupBy は粗利レポートの集計単位です
+type MarginGroupBy string
+
+const (
+	MarginByProduct   MarginGroupBy = "product"   // 商品別
+	MarginByCategory  MarginGroupBy = "category"  // カテゴリ別
+	MarginByPeriod    MarginGroupBy = "period"    // 期間（日・週・月）別
+	MarginByPromotion MarginGroupBy = "promotion" // プロモーション別
+	MarginByDayType   MarginGroupBy = "dayType"   // 日の種類（平日・週末・祝日・イベント・休業日）別
+)
+
+// MarginInterval は期間別の粗利レポートの区切りです
+type MarginInterval string
+
+const (
+	MarginIntervalDay   MarginInterval = "day"
+	MarginIntervalWeek  MarginInterval = "week" // 月曜始まり
+	MarginIntervalMonth MarginInterval = "month"
+)
+
+// MarginRow は粗利レポートの1行です
+// 売上は値引後の税抜金額、原価は販売時点の仕入原価（税抜）です
+type MarginRow struct {
+	Key          string  `json:"key"`
+	Label        string  `json:"label"`
+	Transactions int     `json:"transactions"`
+	Units        int     `json:"units"`
+	Revenue      float64 `json:"revenue"`
+	Discount     float64 `json:"discount"`
+	Cost         float64 `json:"cost"`
+	GrossMargin  float64 `json:"grossMargin"`
+	// MarginRate は売上に対する粗利の割合です（売上が0の場合は0）
+	MarginRate float64 `json:"marginRate"`
+}
+
+// MarginReport は粗利レポートです
+type MarginReport struct {
+	GroupBy   MarginGroupBy  `json:"groupBy"`
+	Interval  MarginInterval `json:"interval,omitempty"`
+	DayType   DayType        `json:"dayType,omitempty"`
+	StoreID   string         `json:"storeId,omitempty"`
+	StartDate string         `json:"startDate"`
+	EndDate   string         `json:"endDate"`
+	Total     MarginRow      `json:"total"`
+	Rows      []MarginRow    `json:"rows"`
+	// LinesWithoutCost は原価が登録されていない明細の数です（粗利が実際より大きく集計されます）
+	LinesWithoutCost int `json:"linesWithoutCost"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TargetPeriodType は売上目標の期間の単位です
+type TargetPeriodType string
+
+const (
+	TargetDaily   TargetPeriodType = "daily"   // 日次目標（期間は 2006-01-02 形式）
+	TargetMonthly TargetPeriodType = "monthly" // 月次目標（期間は 2006-01 形式）
+)
+
+// TargetStatus は売上目標の進捗状況です
+type TargetStatus string
+
+const (
+	TargetUpcoming TargetStatus = "upcoming" // 期間開始前
+	TargetAchieved TargetStatus = "achieved" // 目標達成
+	TargetOnTrack  TargetStatus = "on_track" // 現在のペースで達成見込み
+	TargetBehind   TargetStatus = "behind"   // 現在のペースでは未達の見込み
+	TargetMissed   TargetStatus = "missed"   // 期間終了・未達
+)
+
+// SalesTarget は店舗・期間（・カテゴリ）ごとの売上目標です
+type SalesTarget struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	StoreID string             `bson:"store_id" json:"storeId"`
+	// Category を指定した場合はそのカテゴリの売上（値引前の明細金額）の目標です
+	Category   string           `bson:"category" json:"category,omitempty"`
+	PeriodType TargetPeriodType `bson:"period_type" json:"periodType"`
+	Period     string           `bson:"period" json:"period"`
+	// PeriodStart / PeriodEnd は店舗のタイムゾーンでの期間です（PeriodEnd を含まない）
+	PeriodStart time.Time `bson:"period_start" json:"periodStart"`
+	PeriodEnd   time.Time `bson:"period_end" json:"periodEnd"`
+	Amount      float64   `bson:"amount" json:"amount"`
+	CreatedBy   string    `bson:"created_by,omitempty" json:"createdBy,omitempty"`
+	CreatedAt   time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// SalesTargetQuery は売上目標の検索条件です
+type SalesTargetQuery struct {
+	StoreID    string
+	PeriodType TargetPeriodType
+	// At を指定すると、その日時を期間に含む目標に絞り込みます
+	At time.Time
+	// Start / End を指定すると、期間が重なる目標に絞り込みます
+	Start time.Time
+	End   time.Time
+}
+
+// TargetProgress は売上目標に対する実績・ペース・着地見込みです
+type TargetProgress struct {
+	Target *SalesTarget `json:"target"`
+	AsOf   time.Time    `json:"asOf"`
+	Status TargetStatus `json:"status"`
+
+	Actual float64 `json:"actual"`
+	// Achievement は目標に対する実績の割合です
+	Achievement float64 `json:"achievement"`
+	Remaining   float64 `json:"remaining"`
+
+	// ElapsedRatio は期間の経過割合、PaceTarget は経過割合で按分した現時点の目標です
+	ElapsedRatio float64 `json:"elapsedRatio"`
+	PaceTarget   float64 `json:"paceTarget"`
+	PaceVariance float64 `json:"paceVariance"`
+
+	// Projected は現在のペースが続いた場合の期末の着地見込みです
+	Projected            float64 `json:"projected"`
+	ProjectedAchievement float64 `json:"projectedAchievement"`
+	// RequiredDailyAmount は目標達成に必要な残り期間の1日あたりの売上です
+	RequiredDailyAmount float64 `json:"requiredDailyAmount"`
+}
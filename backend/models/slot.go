@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SlotWindow は配送時間枠のテンプレートの1枠です（開始・終了は店舗のタイムゾーンの HH:MM）
+type SlotWindow struct {
+	Start string `bson:"start" json:"start"`
+	End   string `bson:"end" json:"end"`
+	// Capacity は受け付ける配送の件数です（0の場合は機体の台数と平均の往復時間から求めます）
+	Capacity int `bson:"capacity,omitempty" json:"capacity,omitempty"`
+}
+
+// SlotTemplate は曜日ごとの配送時間枠のテンプレートです
+type SlotTemplate struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	// Weekday は曜日（0: 日曜日 〜 6: 土曜日）です
+	Weekday   time.Weekday `bson:"weekday" json:"weekday"`
+	Windows   []SlotWindow `bson:"windows" json:"windows"`
+	UpdatedAt time.Time    `bson:"updated_at" json:"updatedAt"`
+}
+
+// DeliverySlot は日付ごとの配送時間枠です
+// 空き状況を照会した際にテンプレートから作成し、作成時の受付件数を保持します
+// 機体の台数から求めた受付件数は、最初の予約までは現在の機体の台数で求め直します
+type DeliverySlot struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Date  string             `bson:"date" json:"date"`
+	Start time.Time          `bson:"start" json:"start"`
+	End   time.Time          `bson:"end" json:"end"`
+	// Capacity は受付件数、Reserved は仮押さえ中と確定済みの予約の件数です
+	Capacity  int       `bson:"capacity" json:"capacity"`
+	Reserved  int       `bson:"reserved" json:"reserved"`
+	Available int       `bson:"-" json:"available"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+	// DerivedCapacity はテンプレートで受付件数を指定せず、機体の台数と平均の往復時間から求めた場合にtrueです
+	DerivedCapacity bool `bson:"derived_capacity" json:"-"`
+}
+
+// SlotReservationStatus は配送時間枠の予約の状態です
+type SlotReservationStatus string
+
+const (
+	ReservationHeld      SlotReservationStatus = "held"      // 仮押さえ中（期限までに確定しないと失効）
+	ReservationConfirmed SlotReservationStatus = "confirmed" // 配送の登録で確定済み
+	ReservationCancelled SlotReservationStatus = "cancelled" // 取り消し済み
+	ReservationExpired   SlotReservationStatus = "expired"   // 仮押さえの期限切れ
+)
+
+// SlotReservation は配送時間枠の予約です
+type SlotReservation struct {
+	ID     primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	SlotID primitive.ObjectID    `bson:"slot_id" json:"slotId"`
+	Status SlotReservationStatus `bson:"status" json:"status"`
+	// DeliveryID は予約を確定した配送のIDです
+	DeliveryID string `bson:"delivery_id,omitempty" json:"deliveryId,omitempty"`
+	// ExpiresAt は仮押さえの期限です
+	ExpiresAt   time.Time  `bson:"expires_at" json:"expiresAt"`
+	CreatedAt   time.Time  `bson:"created_at" json:"createdAt"`
+	ConfirmedAt *time.Time `bson:"confirmed_at,omitempty" json:"confirmedAt,omitempty"`
+	ReleasedAt  *time.Time `bson:"released_at,omitempty" json:"releasedAt,omitempty"`
+}
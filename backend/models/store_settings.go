@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultReceiptWidth はレシートの1行あたりの既定の桁数（半角換算）です
+const DefaultReceiptWidth = 42
+
+// ReceiptLayout は店舗ごとのレシートのレイアウト設定を表します
+type ReceiptLayout struct {
+	// Width は1行あたりの桁数（半角換算）です。58mm幅のプリンタは32、80mm幅は42または48が目安です
+	Width          int      `bson:"width" json:"width"`
+	HeaderLines    []string `bson:"header_lines" json:"headerLines"`
+	FooterMessages []string `bson:"footer_messages" json:"footerMessages"`
+	HideCO2Saved   bool     `bson:"hide_co2_saved" json:"hideCO2Saved"`
+}
+
+// StoreSettings は店舗ごとの設定を表します
+type StoreSettings struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	StoreID string             `bson:"store_id" json:"storeId"`
+	Name    string             `bson:"name" json:"name"`
+	Address string             `bson:"address" json:"address"`
+	Phone   string             `bson:"phone" json:"phone"`
+
+	ReceiptLayout ReceiptLayout `bson:"receipt_layout" json:"receiptLayout"`
+
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
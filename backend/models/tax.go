@@ -0,0 +1,37 @@
+package models
+
+// TaxClass は商品の消費税区分を表します
+type TaxClass string
+
+const (
+	TaxClassStandard TaxClass = "standard" // 標準税率（10%）
+	TaxClassReduced  TaxClass = "reduced"  // 軽減税率（8%）
+	TaxClassExempt   TaxClass = "exempt"   // 非課税
+)
+
+// ValidateTaxClass は税区分が有効かどうかをチェックします
+func ValidateTaxClass(class TaxClass) bool {
+	switch class {
+	case TaxClassStandard, TaxClassReduced, TaxClassExempt:
+		return true
+	default:
+		return false
+	}
+}
+
+// TaxBreakdown は税率ごとの対象額と消費税額を表します（適格請求書の税率ごとの合計）
+type TaxBreakdown struct {
+	TaxClass TaxClass `bson:"tax_class" json:"taxClass"`
+	Rate     int      `bson:"rate" json:"rate"`
+	// Taxable は税抜の対象額です
+	Taxable float64 `bson:"taxable" json:"taxable"`
+	Tax     float64 `bson:"tax" json:"tax"`
+	// Total は税込の対象額です
+	Total float64 `bson:"total" json:"total"`
+}
+
+// InvoiceIssuer は適格請求書発行事業者の情報を表します
+type InvoiceIssuer struct {
+	RegistrationNumber string `bson:"registration_number" json:"registrationNumber"`
+	Name               string `bson:"name" json:"name"`
+}
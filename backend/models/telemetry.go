@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// TelemetryResolution は走行履歴の時間解像度です
+type TelemetryResolution string
+
+const (
+	// TelemetryRaw は機体から受信した計測値そのものです
+	TelemetryRaw TelemetryResolution = "raw"
+	// TelemetryMinute は1分ごとに集約した計測値です
+	TelemetryMinute TelemetryResolution = "minute"
+)
+
+// TelemetryReading は配送機体から送信される計測値（位置・バッテリー残量・速度）です
+// robot_telemetry（時系列コレクション）に保存し、robot_id をメタデータとして機体ごとにまとめて格納します
+type TelemetryReading struct {
+	RobotID   string    `bson:"robot_id" json:"robotId"`
+	Timestamp time.Time `bson:"timestamp" json:"timestamp"`
+	Location  Location  `bson:"location" json:"location"`
+	// BatteryLevel はバッテリー残量（0〜100%）です
+	BatteryLevel *float64 `bson:"battery_level,omitempty" json:"batteryLevel,omitempty"`
+	// Speed は速度（km/h）、Heading は進行方向（北を0とした時計回りの角度）です
+	Speed   *float64 `bson:"speed,omitempty" json:"speed,omitempty"`
+	Heading *float64 `bson:"heading,omitempty" json:"heading,omitempty"`
+	// DeliveryID は計測時に配送中だった配送のIDです
+	DeliveryID string `bson:"delivery_id,omitempty" json:"deliveryId,omitempty"`
+}
+
+// TelemetryPoint は走行履歴の1点です
+// 1分ごとに集約した場合、位置・バッテリー残量・配送はその1分間の最後の計測値、速度は平均と最大です
+type TelemetryPoint struct {
+	Timestamp    time.Time `bson:"timestamp" json:"timestamp"`
+	Location     Location  `bson:"location" json:"location"`
+	BatteryLevel *float64  `bson:"battery_level,omitempty" json:"batteryLevel,omitempty"`
+	Speed        *float64  `bson:"speed,omitempty" json:"speed,omitempty"`
+	MaxSpeed     *float64  `bson:"max_speed,omitempty" json:"maxSpeed,omitempty"`
+	Heading      *float64  `bson:"heading,omitempty" json:"heading,omitempty"`
+	DeliveryID   string    `bson:"delivery_id,omitempty" json:"deliveryId,omitempty"`
+	// Readings は集約した計測値の件数です
+	Readings int `bson:"readings" json:"readings"`
+}
+
+// TelemetryRollup は1分ごとに集約した計測値です（robot_telemetry_minutely に保存します）
+type TelemetryRollup struct {
+	RobotID        string `bson:"robot_id" json:"robotId"`
+	TelemetryPoint `bson:",inline"`
+}
+
+// TelemetryTrack は配送機体の指定期間の走行履歴です
+type TelemetryTrack struct {
+	RobotID    string              `json:"robotId"`
+	From       time.Time           `json:"from"`
+	To         time.Time           `json:"to"`
+	Resolution TelemetryResolution `json:"resolution"`
+	Points     []TelemetryPoint    `json:"points"`
+}
+
+// RobotTelemetry は配送機体から最後に受信した計測値です（robots に保存します）
+type RobotTelemetry struct {
+	Timestamp    time.Time `bson:"timestamp" json:"timestamp"`
+	Location     Location  `bson:"location" json:"location"`
+	BatteryLevel *float64  `bson:"battery_level,omitempty" json:"batteryLevel,omitempty"`
+	Speed        *float64  `bson:"speed,omitempty" json:"speed,omitempty"`
+	Heading      *float64  `bson:"heading,omitempty" json:"heading,omitempty"`
+	DeliveryID   string    `bson:"delivery_id,omitempty" json:"deliveryId,omitempty"`
+}
+
+// FleetRobotState は配送機体の最新の状態です
+type FleetRobotState struct {
+	RobotID           string      `json:"robotId"`
+	Name              string      `json:"name"`
+	Type              RobotType   `json:"type"`
+	Status            RobotStatus `json:"status"`
+	CurrentDeliveryID string      `json:"currentDeliveryId,omitempty"`
+	// Online は直近（設定した時間内）に計測値を受信しているかどうかです
+	Online    bool            `json:"online"`
+	Telemetry *RobotTelemetry `json:"telemetry,omitempty"`
+}
+
+// TelemetryIngestResult は計測値の受信結果です
+type TelemetryIngestResult struct {
+	RobotID  string `json:"robotId"`
+	Accepted int    `json:"accepted"`
+	// LatestAt は受信した計測値のうち最も新しい計測日時です
+	LatestAt time.Time `json:"latestAt"`
+}
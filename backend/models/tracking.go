@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// TrackingEventType は配送のライブ追跡で配信するイベントの種類です
+type TrackingEventType string
+
+const (
+	// TrackingLocation は現在位置・バッテリー残量・速度の更新です
+	TrackingLocation TrackingEventType = "location"
+	// TrackingStatus は配送ステータスの変更です
+	TrackingStatus TrackingEventType = "status"
+	// TrackingSnapshot は購読開始時点の配送の状態です
+	TrackingSnapshot TrackingEventType = "snapshot"
+	// TrackingReset は再開カーソルが古い（サーバー再起動・保持件数超過）ため、途中のイベントを再送できないことを表します
+	// クライアントは続く snapshot で表示を置き換えます
+	TrackingReset TrackingEventType = "reset"
+	// TrackingHeartbeat は接続を維持するための空のイベントです
+	TrackingHeartbeat TrackingEventType = "heartbeat"
+)
+
+// TrackingEvent は配送のライブ追跡で配信するイベントです
+type TrackingEvent struct {
+	// Cursor は再接続時に渡すと、このイベントより後のイベントから再開できる位置です
+	Cursor       string            `json:"cursor,omitempty"`
+	Type         TrackingEventType `json:"type"`
+	DeliveryID   string            `json:"deliveryId,omitempty"`
+	RobotID      string            `json:"robotId,omitempty"`
+	Status       DeliveryStatus    `json:"status,omitempty"`
+	Location     *Location         `json:"location,omitempty"`
+	BatteryLevel *float64          `json:"batteryLevel,omitempty"`
+	Speed        *float64          `json:"speed,omitempty"`
+	Timestamp    time.Time         `json:"timestamp"`
+}
+
+// TrackingEventFromDelivery は配送の現在の状態からイベントを作成します
+func TrackingEventFromDelivery(eventType TrackingEventType, delivery *Delivery, timestamp time.Time) TrackingEvent {
+	event := TrackingEvent{
+		Type:       eventType,
+		DeliveryID: delivery.ID,
+		RobotID:    delivery.RobotID,
+		Status:     delivery.Status,
+		Timestamp:  timestamp,
+	}
+	if delivery.TrackingInfo != nil {
+		event.Location = delivery.TrackingInfo.CurrentLocation
+		event.BatteryLevel = delivery.TrackingInfo.BatteryLevel
+		event.Speed = delivery.TrackingInfo.Speed
+	}
+	return event
+}
@@ -0,0 +1,80 @@
+package receipt
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+var htmlTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"yen":      formatYen,
+	"co2":      formatCO2,
+	"points":   formatPoints,
+	"taxLabel": taxClassLabel,
+	"payment":  PaymentMethodLabel,
+	"neg":      func(v float64) float64 { return -v },
+	"exempt":   func(b models.TaxBreakdown) bool { return b.TaxClass == models.TaxClassExempt },
+}).Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>レシート {{.SaleID}}</title>
+<style>
+body { font-family: sans-serif; max-width: 420px; margin: 0 auto; padding: 16px; color: #222; }
+header, footer { text-align: center; }
+h1 { font-size: 1.25rem; margin: 0 0 4px; }
+table { width: 100%; border-collapse: collapse; }
+td { padding: 2px 0; vertical-align: top; }
+td.amount { text-align: right; white-space: nowrap; }
+.sub { color: #666; font-size: 0.85rem; padding-left: 1em; }
+hr { border: none; border-top: 1px dashed #999; margin: 8px 0; }
+.total td { font-weight: bold; font-size: 1.1rem; }
+.eco { color: #2e7d32; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.StoreName}}</h1>
+{{with .StoreAddress}}<div>{{.}}</div>{{end}}
+{{with .StorePhone}}<div>{{.}}</div>{{end}}
+{{range .HeaderLines}}<div>{{.}}</div>{{end}}
+{{with .Issuer}}<div>登録番号 {{.RegistrationNumber}}</div>{{end}}
+</header>
+<hr>
+<table>
+<tr><td>{{.IssuedAt.Format "2006/01/02 15:04"}}</td><td class="amount">No.{{.SaleID}}</td></tr>
+</table>
+<hr>
+<table>
+{{range .Items}}<tr><td>{{.Name}}{{if .Reduced}} ※{{end}}{{if gt .Quantity 1}}<div class="sub">{{.Quantity}} x {{yen .UnitPrice}}</div>{{end}}</td><td class="amount">{{yen .Amount}}</td></tr>
+{{end}}</table>
+{{if .Promotions}}<hr>
+<table>
+{{range .Promotions}}<tr><td>[値引] {{.Name}}</td><td class="amount">{{yen (neg .Discount)}}</td></tr>
+{{end}}</table>
+{{end}}<hr>
+<table>
+<tr class="total"><td>合計</td><td class="amount">{{yen .Total}}</td></tr>
+{{range .TaxBreakdown}}<tr><td class="sub">{{taxLabel .}}(税込)</td><td class="amount">{{yen .Total}}</td></tr>
+{{if not (exempt .)}}<tr><td class="sub">うち消費税</td><td class="amount">{{yen .Tax}}</td></tr>
+{{end}}{{end}}{{if gt .PointsPayment 0.0}}<tr><td>お支払 ポイント({{points .PointsRedeemed}})</td><td class="amount">{{yen .PointsPayment}}</td></tr>
+{{end}}{{if .ShowPaymentMethod}}<tr><td>お支払 {{payment .PaymentMethod}}</td><td class="amount">{{yen .RemainingPayment}}</td></tr>
+{{end}}{{if gt .PointsEarned 0}}<tr><td>今回付与ポイント</td><td class="amount">{{points .PointsEarned}}</td></tr>
+{{end}}
+</table>
+<hr>
+{{if .ShowCO2Saved}}<p class="eco">CO2削減量 {{co2 .CO2Saved}}</p>
+{{end}}{{if .HasReducedItems}}<p>※は軽減税率対象商品です</p>
+{{end}}<footer>
+{{range .FooterMessages}}<p>{{.}}</p>
+{{end}}</footer>
+</body>
+</html>
+`))
+
+// renderHTML は電子レシート用のHTMLを書き出します
+func renderHTML(w io.Writer, r *Receipt) error {
+	return htmlTemplate.Execute(w, r)
+}
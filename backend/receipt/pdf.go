@@ -0,0 +1,92 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+// PDFのレイアウト（単位はポイント）
+const (
+	pdfFontSize   = 9.0
+	pdfLineHeight = 12.0
+	pdfMargin     = 12.0
+)
+
+// pdfFontObjects は日本語を表示するためのフォント定義です
+// Acrobat等のビューアが標準で備える平成角ゴシックを、埋め込みなしで参照します
+// UniJIS-UCS2-HW-H エンコーディングでは半角文字が半角幅のCIDに割り当てられるため、
+// テキストレシートと同じ桁揃えで出力できます
+var pdfFontObjects = []string{
+	"<< /Type /Font /Subtype /Type0 /BaseFont /HeiseiKakuGo-W5 /Encoding /UniJIS-UCS2-HW-H /DescendantFonts [6 0 R] >>",
+	"<< /Type /Font /Subtype /CIDFontType0 /BaseFont /HeiseiKakuGo-W5 " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Japan1) /Supplement 2 >> " +
+		"/FontDescriptor 7 0 R /DW 1000 /W [1 95 500 231 632 500] >>",
+	"<< /Type /FontDescriptor /FontName /HeiseiKakuGo-W5 /Flags 4 /FontBBox [-92 -250 1010 922] " +
+		"/ItalicAngle 0 /Ascent 752 /Descent -221 /CapHeight 737 /StemV 58 >>",
+}
+
+// renderPDF はテキストレシートと同じレイアウトのPDFを書き出します
+func renderPDF(w io.Writer, r *Receipt) error {
+	lines := textLines(r)
+	columns := 0
+	for _, l := range lines {
+		if cw := displayWidth(l); cw > columns {
+			columns = cw
+		}
+	}
+
+	pageWidth := float64(columns)*pdfFontSize/2 + pdfMargin*2
+	pageHeight := float64(len(lines))*pdfLineHeight + pdfMargin*2
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT\n/F1 %.1f Tf\n%.1f TL\n%.1f %.1f Td\n",
+		pdfFontSize, pdfLineHeight, pdfMargin, pageHeight-pdfMargin-pdfFontSize)
+	for _, l := range lines {
+		fmt.Fprintf(&content, "<%s> Tj T*\n", utf16Hex(l))
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.1f %.1f] "+
+			"/Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>", pageWidth, pageHeight),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+	objects = append(objects, pdfFontObjects...)
+
+	return writePDF(w, objects)
+}
+
+// writePDF はオブジェクトの一覧からPDFファイルを組み立てます
+func writePDF(w io.Writer, objects []string) error {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// utf16Hex は文字列をUTF-16BEの16進文字列に変換します
+func utf16Hex(s string) string {
+	var b bytes.Buffer
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	return b.String()
+}
@@ -0,0 +1,183 @@
+// Package receipt は売上のレシートをテキスト・HTML・PDFで出力します
+package receipt
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// Format はレシートの出力形式です
+type Format string
+
+const (
+	FormatText Format = "text" // サーマルプリンタ向けの固定幅テキスト
+	FormatHTML Format = "html" // 電子レシート
+	FormatPDF  Format = "pdf"
+)
+
+// ParseFormat は文字列から出力形式を取得します（未指定の場合はテキスト）
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case "":
+		return FormatText, nil
+	case FormatText, FormatHTML, FormatPDF:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported receipt format: %s", s)
+	}
+}
+
+// ContentType は出力形式に対応するContent-Typeを返します
+func (f Format) ContentType() string {
+	switch f {
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatPDF:
+		return "application/pdf"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Item はレシートの明細行です
+type Item struct {
+	Name      string
+	Quantity  int
+	UnitPrice float64
+	Amount    float64
+	// Reduced は軽減税率の対象かどうかを表します（※印を表示）
+	Reduced bool
+}
+
+// Promotion はレシートに表示する値引きです
+type Promotion struct {
+	Name     string
+	Discount float64
+}
+
+// Receipt はレシートの表示内容を表します
+type Receipt struct {
+	StoreName      string
+	StoreAddress   string
+	StorePhone     string
+	HeaderLines    []string
+	FooterMessages []string
+	// Width は1行あたりの桁数（半角換算）です
+	Width int
+
+	SaleID   string
+	IssuedAt time.Time
+	Issuer   *models.InvoiceIssuer
+
+	Items        []Item
+	Promotions   []Promotion
+	TaxBreakdown []models.TaxBreakdown
+	Total        float64
+	TotalTax     float64
+
+	PaymentMethod string
+	CO2Saved      float64
+	ShowCO2Saved  bool
+
+	// 会員ポイント
+	PointsRedeemed int
+	PointsPayment  float64
+	PointsEarned   int
+}
+
+// RemainingPayment はポイント利用分を除いた支払額を返します
+func (r *Receipt) RemainingPayment() float64 {
+	return r.Total - r.PointsPayment
+}
+
+// ShowPaymentMethod はポイント以外の支払方法の行を表示するかどうかを返します
+func (r *Receipt) ShowPaymentMethod() bool {
+	return r.PointsPayment == 0 || r.RemainingPayment() > 0
+}
+
+// HasReducedItems は軽減税率の対象商品が含まれるかどうかを返します
+func (r *Receipt) HasReducedItems() bool {
+	for _, item := range r.Items {
+		if item.Reduced {
+			return true
+		}
+	}
+	return false
+}
+
+// Render は指定された形式でレシートを書き出します
+func Render(w io.Writer, r *Receipt, format Format) error {
+	switch format {
+	case FormatHTML:
+		return renderHTML(w, r)
+	case FormatPDF:
+		return renderPDF(w, r)
+	default:
+		_, err := io.WriteString(w, renderText(r))
+		return err
+	}
+}
+
+// paymentMethodLabels は支払方法の表示名です
+var paymentMethodLabels = map[string]string{
+	"cash":           "現金",
+	"credit_card":    "クレジットカード",
+	"debit_card":     "デビットカード",
+	"e_money":        "電子マネー",
+	"qr_code":        "QRコード決済",
+	"points":         "ポイント",
+	"transportation": "交通系IC",
+}
+
+// PaymentMethodLabel は支払方法の表示名を返します
+func PaymentMethodLabel(method string) string {
+	if label, ok := paymentMethodLabels[method]; ok {
+		return label
+	}
+	return method
+}
+
+// taxClassLabel は税区分の表示名を返します
+func taxClassLabel(b models.TaxBreakdown) string {
+	if b.TaxClass == models.TaxClassExempt {
+		return "非課税"
+	}
+	return strconv.Itoa(b.Rate) + "%対象"
+}
+
+// formatPoints はポイント数を「1,234pt」の形式で返します
+func formatPoints(points int) string {
+	return groupDigits(int64(points)) + "pt"
+}
+
+// formatYen は金額を「¥1,234」の形式で返します
+func formatYen(amount float64) string {
+	v := int64(math.Round(amount))
+	if v < 0 {
+		return "-¥" + groupDigits(-v)
+	}
+	return "¥" + groupDigits(v)
+}
+
+// groupDigits は0以上の整数を3桁区切りの文字列にします
+func groupDigits(v int64) string {
+	digits := strconv.FormatInt(v, 10)
+	var out []byte
+	for i := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out = append(out, ',')
+		}
+		out = append(out, digits[i])
+	}
+	return string(out)
+}
+
+// formatCO2 はCO2削減量を表示用に整形します
+func formatCO2(kg float64) string {
+	return strconv.FormatFloat(kg, 'f', 2, 64) + "kg"
+}
@@ -0,0 +1,125 @@
+package receipt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+func sampleReceipt() *Receipt {
+	return &Receipt{
+		StoreName:      "NEXT MART 2030",
+		StoreAddress:   "東京都渋谷区1-2-3",
+		FooterMessages: []string{"またのご来店をお待ちしております"},
+		Width:          32,
+		SaleID:         "65a1b2c3",
+		IssuedAt:       time.Date(2024, 1, 5, 9, 30, 0, 0, time.UTC),
+		Issuer: &models.InvoiceIssuer{
+			RegistrationNumber: "T1234567890123",
+		},
+		Items: []Item{
+			{Name: "オーガニック牛乳", Quantity: 2, UnitPrice: 216, Amount: 432, Reduced: true},
+			{Name: "エコバッグ", Quantity: 1, UnitPrice: 330, Amount: 330},
+		},
+		Promotions: []Promotion{{Name: "朝市セール", Discount: 50}},
+		TaxBreakdown: []models.TaxBreakdown{
+			{TaxClass: models.TaxClassStandard, Rate: 10, Taxable: 300, Tax: 30, Total: 330},
+			{TaxClass: models.TaxClassReduced, Rate: 8, Taxable: 354, Tax: 28, Total: 382},
+		},
+		Total:         712,
+		TotalTax:      58,
+		PaymentMethod: "cash",
+		CO2Saved:      1.2,
+		ShowCO2Saved:  true,
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	var buf bytes.Buffer
+	err := Render(&buf, sampleReceipt(), FormatText)
+	assert.NoError(t, err)
+
+	out := buf.String()
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		assert.LessOrEqual(t, displayWidth(line), 32, line)
+	}
+	assert.Contains(t, out, "登録番号 T1234567890123")
+	assert.Contains(t, out, "オーガニック牛乳 ※         ¥432")
+	assert.Contains(t, out, "  2 x ¥216")
+	assert.Contains(t, out, "[値引] 朝市セール")
+	assert.Contains(t, out, "-¥50")
+	assert.Contains(t, out, " 8%対象(税込)")
+	assert.Contains(t, out, "お支払 現金")
+	assert.Contains(t, out, "CO2削減量")
+	assert.Contains(t, out, "1.20kg")
+	assert.Contains(t, out, "※は軽減税率対象商品です")
+}
+
+func TestRenderText_Points(t *testing.T) {
+	r := sampleReceipt()
+	r.PointsRedeemed = 200
+	r.PointsPayment = 200
+	r.PointsEarned = 5
+
+	var buf bytes.Buffer
+	err := Render(&buf, r, FormatText)
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "お支払 ポイント(200pt)")
+	assert.Contains(t, out, "¥512")
+	assert.Contains(t, out, "今回付与ポイント")
+	assert.Contains(t, out, "5pt")
+}
+
+func TestRenderHTML(t *testing.T) {
+	r := sampleReceipt()
+	r.FooterMessages = []string{"<script>alert(1)</script>"}
+
+	var buf bytes.Buffer
+	err := Render(&buf, r, FormatHTML)
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "<h1>NEXT MART 2030</h1>")
+	assert.Contains(t, out, "オーガニック牛乳 ※")
+	assert.Contains(t, out, "¥712")
+	assert.NotContains(t, out, "<script>")
+}
+
+func TestRenderPDF(t *testing.T) {
+	var buf bytes.Buffer
+	err := Render(&buf, sampleReceipt(), FormatPDF)
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "%PDF-1.4"))
+	assert.True(t, strings.HasSuffix(out, "%%EOF\n"))
+	assert.Contains(t, out, "/UniJIS-UCS2-HW-H")
+	// 「合計」のUTF-16BE表現
+	assert.Contains(t, out, "54088A08")
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatText, f)
+
+	f, err = ParseFormat("pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, "application/pdf", f.ContentType())
+
+	_, err = ParseFormat("docx")
+	assert.Error(t, err)
+}
+
+func TestFormatYen(t *testing.T) {
+	assert.Equal(t, "¥0", formatYen(0))
+	assert.Equal(t, "¥1,234,567", formatYen(1234567))
+	assert.Equal(t, "-¥1,000", formatYen(-1000))
+}
@@ -0,0 +1,101 @@
+package receipt
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// minTextWidth はテキストレシートの最小桁数です
+const minTextWidth = 24
+
+// renderText はサーマルプリンタ向けの固定幅テキストを生成します
+func renderText(r *Receipt) string {
+	return strings.Join(textLines(r), "\n") + "\n"
+}
+
+// textLines はテキストレシートの各行を返します（PDF出力でも同じレイアウトを使用）
+func textLines(r *Receipt) []string {
+	width := r.Width
+	if width < minTextWidth {
+		width = minTextWidth
+	}
+	rule := strings.Repeat("-", width)
+
+	var lines []string
+	add := func(ls ...string) { lines = append(lines, ls...) }
+
+	// ヘッダー
+	add(center(r.StoreName, width))
+	for _, s := range []string{r.StoreAddress, r.StorePhone} {
+		if s != "" {
+			add(center(s, width))
+		}
+	}
+	for _, h := range r.HeaderLines {
+		for _, l := range wrap(h, width) {
+			add(center(l, width))
+		}
+	}
+	if r.Issuer != nil {
+		add(center("登録番号 "+r.Issuer.RegistrationNumber, width))
+	}
+	add(rule)
+	add(justify(r.IssuedAt.Format("2006/01/02 15:04"), "No."+r.SaleID, width))
+	add(rule)
+
+	// 明細
+	for _, item := range r.Items {
+		name := item.Name
+		if item.Reduced {
+			name += " ※"
+		}
+		add(justify(name, formatYen(item.Amount), width))
+		if item.Quantity > 1 {
+			add("  " + strconv.Itoa(item.Quantity) + " x " + formatYen(item.UnitPrice))
+		}
+	}
+
+	// 値引き
+	if len(r.Promotions) > 0 {
+		add(rule)
+		for _, p := range r.Promotions {
+			add(justify("[値引] "+p.Name, formatYen(-p.Discount), width))
+		}
+	}
+
+	// 合計・税率ごとの内訳
+	add(rule)
+	add(justify("合計", formatYen(r.Total), width))
+	for _, b := range r.TaxBreakdown {
+		add(justify(" "+taxClassLabel(b)+"(税込)", formatYen(b.Total), width))
+		if b.TaxClass != models.TaxClassExempt {
+			add(justify("   うち消費税", formatYen(b.Tax), width))
+		}
+	}
+	if r.PointsPayment > 0 {
+		add(justify("お支払 ポイント("+formatPoints(r.PointsRedeemed)+")", formatYen(r.PointsPayment), width))
+	}
+	if r.ShowPaymentMethod() {
+		add(justify("お支払 "+PaymentMethodLabel(r.PaymentMethod), formatYen(r.RemainingPayment()), width))
+	}
+	if r.PointsEarned > 0 {
+		add(justify("今回付与ポイント", formatPoints(r.PointsEarned), width))
+	}
+
+	// 環境貢献・フッター
+	add(rule)
+	if r.ShowCO2Saved {
+		add(justify("CO2削減量", formatCO2(r.CO2Saved), width))
+	}
+	if r.HasReducedItems() {
+		add("※は軽減税率対象商品です")
+	}
+	for _, f := range r.FooterMessages {
+		for _, l := range wrap(f, width) {
+			add(center(l, width))
+		}
+	}
+	return lines
+}
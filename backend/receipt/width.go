@@ -0,0 +1,92 @@
+package receipt
+
+import "strings"
+
+// runeWidth は文字の表示幅（半角=1、全角=2）を返します
+func runeWidth(r rune) int {
+	switch {
+	case r == '※':
+		// 日本語環境では全角で印字される
+		return 2
+	case r < 0x1100:
+		return 1
+	case r <= 0x115F, // ハングル字母
+		r >= 0x2E80 && r <= 0xA4CF && r != 0x303F, // CJK・ひらがな・カタカナ等
+		r >= 0xAC00 && r <= 0xD7A3,                // ハングル音節
+		r >= 0xF900 && r <= 0xFAFF,                // CJK互換漢字
+		r >= 0xFE30 && r <= 0xFE4F,                // CJK互換形
+		r >= 0xFF00 && r <= 0xFF60,                // 全角英数・記号
+		r >= 0xFFE0 && r <= 0xFFE6:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// displayWidth は文字列の表示幅を返します
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w
+}
+
+// truncate は表示幅が width を超えないように文字列を切り詰めます
+func truncate(s string, width int) string {
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		rw := runeWidth(r)
+		if w+rw > width {
+			break
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	return b.String()
+}
+
+// wrap は表示幅 width ごとに文字列を折り返します
+func wrap(s string, width int) []string {
+	if width <= 0 {
+		return []string{s}
+	}
+	var lines []string
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		rw := runeWidth(r)
+		if w+rw > width {
+			lines = append(lines, b.String())
+			b.Reset()
+			w = 0
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	return append(lines, b.String())
+}
+
+// center は文字列を表示幅 width の中央に配置します
+func center(s string, width int) string {
+	pad := (width - displayWidth(s)) / 2
+	if pad <= 0 {
+		return s
+	}
+	return strings.Repeat(" ", pad) + s
+}
+
+// justify は左右に文字列を配置し、間を空白で埋めます
+// 収まらない場合は左側の文字列を切り詰めます
+func justify(left, right string, width int) string {
+	rw := displayWidth(right)
+	if displayWidth(left)+rw+1 > width {
+		left = truncate(left, width-rw-1)
+	}
+	pad := width - displayWidth(left) - rw
+	if pad < 1 {
+		pad = 1
+	}
+	return left + strings.Repeat(" ", pad) + right
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// AnomalyRepositoryImpl は異常イベントリポジトリの実装です
+type AnomalyRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ AnomalyRepository = (*AnomalyRepositoryImpl)(nil)
+
+func NewAnomalyRepository(db *mongo.Database) AnomalyRepository {
+	return &AnomalyRepositoryImpl{
+		collection: db.Collection("anomaly_events"),
+	}
+}
+
+// Upsert は異常イベントを登録します
+// 同じ Fingerprint のイベントが既にある場合は根拠と重要度のみ更新し、確認状況は保持します
+func (r *AnomalyRepositoryImpl) Upsert(ctx context.Context, event *models.AnomalyEvent) error {
+	now := time.Now()
+	filter := bson.M{"fingerprint": event.Fingerprint}
+	update := bson.M{
+		"$set": bson.M{
+			"severity":     event.Severity,
+			"window_start": event.WindowStart,
+			"window_end":   event.WindowEnd,
+			"message":      event.Message,
+			"evidence":     event.Evidence,
+			"updated_at":   now,
+		},
+		"$setOnInsert": bson.M{
+			"type":        event.Type,
+			"status":      models.AnomalyOpen,
+			"store_id":    event.StoreID,
+			"register_id": event.RegisterID,
+			"detected_at": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	return r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(event)
+}
+
+// GetByID は指定されたIDの異常イベントを取得します（存在しない場合はnil）
+func (r *AnomalyRepositoryImpl) GetByID(ctx context.Context, id primitive.ObjectID) (*models.AnomalyEvent, error) {
+	var event models.AnomalyEvent
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&event)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// List は条件に合う異常イベントを新しい順に取得します
+func (r *AnomalyRepositoryImpl) List(ctx context.Context, query models.AnomalyQuery) ([]*models.AnomalyEvent, error) {
+	filter := bson.M{}
+	if query.Status != "" {
+		filter["status"] = query.Status
+	}
+	if query.Type != "" {
+		filter["type"] = query.Type
+	}
+	if query.StoreID != "" {
+		filter["store_id"] = query.StoreID
+	}
+	if !query.Start.IsZero() || !query.End.IsZero() {
+		window := bson.M{}
+		if !query.Start.IsZero() {
+			window["$gte"] = query.Start
+		}
+		if !query.End.IsZero() {
+			window["$lt"] = query.End
+		}
+		filter["window_start"] = window
+	}
+
+	opts := options.Find().
+		SetSkip(query.Skip).
+		SetLimit(query.Limit).
+		SetSort(bson.D{{Key: "window_start", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []*models.AnomalyEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// UpdateReview は異常イベントの確認結果を記録します
+func (r *AnomalyRepositoryImpl) UpdateReview(ctx context.Context, id primitive.ObjectID, status models.AnomalyStatus, reviewedBy, note string, at time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":      status,
+			"reviewed_by": reviewedBy,
+			"reviewed_at": at,
+			"review_note": note,
+			"updated_at":  time.Now(),
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// etaRefreshLeaseID は到着予測の定期更新を実行中のインスタンスを記録するリースのIDです
+const etaRefreshLeaseID = "delivery_eta_refresh"
+
+// DeliveryAlertRepositoryImpl は配送の遅延アラートリポジトリの実装です
+// 未解決のアラートは配送ごとに1件です（delivery_alerts の一意インデックスで保証します）
+type DeliveryAlertRepositoryImpl struct {
+	collection *mongo.Collection
+	leases     *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ DeliveryAlertRepository = (*DeliveryAlertRepositoryImpl)(nil)
+
+func NewDeliveryAlertRepository(db *mongo.Database) DeliveryAlertRepository {
+	return &DeliveryAlertRepositoryImpl{
+		collection: db.Collection("delivery_alerts"),
+		leases:     db.Collection("leases"),
+	}
+}
+
+// Raise は配送の遅延アラートを登録します
+// 同じ配送の未解決のアラートが既にある場合は最新の予測のみ更新し、遅れの最大値を保持します
+func (r *DeliveryAlertRepositoryImpl) Raise(ctx context.Context, alert *models.DeliveryAlert) error {
+	filter := bson.M{
+		"delivery_id": alert.DeliveryID,
+		"status":      models.DeliveryAlertOpen,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"robot_id":          alert.RobotID,
+			"deadline":          alert.Deadline,
+			"predicted_arrival": alert.PredictedArrival,
+			"late_by_minutes":   alert.LateByMinutes,
+			"updated_at":        alert.UpdatedAt,
+		},
+		"$max": bson.M{"max_late_by_minutes": alert.LateByMinutes},
+		"$setOnInsert": bson.M{
+			"raised_at": alert.UpdatedAt,
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(alert)
+	if mongo.IsDuplicateKeyError(err) {
+		// 同時に登録された未解決のアラートを更新し直します
+		err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(alert)
+	}
+	return err
+}
+
+// Resolve は配送の未解決の遅延アラートを解決済みにします
+func (r *DeliveryAlertRepositoryImpl) Resolve(ctx context.Context, deliveryID string, resolvedAt time.Time) error {
+	filter := bson.M{
+		"delivery_id": deliveryID,
+		"status":      models.DeliveryAlertOpen,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      models.DeliveryAlertResolved,
+			"resolved_at": resolvedAt,
+			"updated_at":  resolvedAt,
+		},
+	}
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+// ListOpenDeliveryIDs は未解決の遅延アラートがある配送のIDを取得します
+func (r *DeliveryAlertRepositoryImpl) ListOpenDeliveryIDs(ctx context.Context) ([]string, error) {
+	values, err := r.collection.Distinct(ctx, "delivery_id", bson.M{"status": models.DeliveryAlertOpen})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// List は遅延アラートを新しい順に取得します（status が空の場合は全て）
+func (r *DeliveryAlertRepositoryImpl) List(ctx context.Context, status models.DeliveryAlertStatus) ([]*models.DeliveryAlert, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "raised_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	alerts := []*models.DeliveryAlert{}
+	if err = cursor.All(ctx, &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// AcquireRefreshLease は到着予測の定期更新のリースを holder が取得（保持している場合は延長）し、取得できた場合はtrueを返します
+// 他のインスタンスが期限内のリースを保持している場合はfalseを返します
+func (r *DeliveryAlertRepositoryImpl) AcquireRefreshLease(ctx context.Context, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	return acquireLease(ctx, r.leases, etaRefreshLeaseID, holder, now, ttl)
+}
+
+// ReleaseRefreshLease は holder が保持している到着予測の定期更新のリースを解放します
+func (r *DeliveryAlertRepositoryImpl) ReleaseRefreshLease(ctx context.Context, holder string) error {
+	return releaseLease(ctx, r.leases, etaRefreshLeaseID, holder)
+}
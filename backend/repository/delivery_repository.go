@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,9 +14,26 @@ import (
 	"github.com/onoderaryou/smart-store-admin/backend/models"
 )
 
+var (
+	// ErrDeliveryStatusChanged はステータスの更新中に配送のステータスが変わっていた場合のエラーです
+	ErrDeliveryStatusChanged = errors.New("delivery status has changed")
+	// ErrDeliveryAlreadyAssigned は機体の割り当て中に配送が他の機体に割り当てられていた、または準備中でなくなっていた場合のエラーです
+	ErrDeliveryAlreadyAssigned = errors.New("delivery is already assigned to a robot")
+	// ErrPINAttemptsExceeded は受取人の暗証番号の照合回数が上限に達している場合のエラーです
+	ErrPINAttemptsExceeded = errors.New("recipient PIN attempts exceeded")
+	// ErrInsufficientStock は配送の登録時に商品の在庫が配送する数量に足りない場合のエラーです
+	ErrInsufficientStock = errors.New("insufficient stock for delivery items")
+)
+
 // DeliveryRepositoryImpl は配送リポジトリの実装です
+// 配送の作成・更新は、変更内容を delivery_history に記録する処理と同じトランザクションで行います
 type DeliveryRepositoryImpl struct {
 	collection *mongo.Collection
+	history    *mongo.Collection
+	// robots は配送の終了時に割り当てた機体を待機中に戻すために使います
+	robots *mongo.Collection
+	// products は配送する商品の在庫を引き当て、取り消し・返送時に戻すために使います
+	products *mongo.Collection
 }
 
 // インターフェースが実装されていることを確認
@@ -23,22 +42,36 @@ var _ DeliveryRepository = (*DeliveryRepositoryImpl)(nil)
 func NewDeliveryRepository(db *mongo.Database) DeliveryRepository {
 	return &DeliveryRepositoryImpl{
 		collection: db.Collection("deliveries"),
+		history:    db.Collection("delivery_history"),
+		robots:     db.Collection("robots"),
+		products:   db.Collection("products"),
 	}
 }
 
 // Create は新しい配送を作成します
-func (r *DeliveryRepositoryImpl) Create(ctx context.Context, delivery *models.Delivery) error {
-	delivery.CreatedAt = time.Now()
-	delivery.UpdatedAt = time.Now()
+// 配送する商品の在庫は同じトランザクションで引き当て、足りない場合は ErrInsufficientStock を返して登録しません
+func (r *DeliveryRepositoryImpl) Create(ctx context.Context, delivery *models.Delivery, actor string) error {
+	now := time.Now()
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
 	delivery.Status = models.StatusPreparing
 
-	result, err := r.collection.InsertOne(ctx, delivery)
-	if err != nil {
-		return err
-	}
+	return r.withTransaction(ctx, func(sc mongo.SessionContext) error {
+		// トランザクションの再試行時に前回の ID で登録しないようにします
+		delivery.ID = ""
+		result, err := r.collection.InsertOne(sc, delivery)
+		if err != nil {
+			return err
+		}
+		delivery.ID = result.InsertedID.(primitive.ObjectID).Hex()
+		if err := r.reserveStock(sc, delivery.Items, now); err != nil {
+			return err
+		}
 
-	delivery.ID = result.InsertedID.(primitive.ObjectID).Hex()
-	return nil
+		return r.appendHistory(sc, delivery, models.HistoryCreated, actor, now, []models.FieldChange{
+			{Field: "status", Previous: nil, New: string(delivery.Status)},
+		}, nil)
+	})
 }
 
 // GetByID は指定されたIDの配送を取得します
@@ -67,36 +100,193 @@ func (r *DeliveryRepositoryImpl) List(ctx context.Context, skip, limit int64) ([
 	return deliveries, nil
 }
 
-// UpdateStatus は配送のステータスを更新します
-func (r *DeliveryRepositoryImpl) UpdateStatus(ctx context.Context, id primitive.ObjectID, status models.DeliveryStatus) error {
-	update := bson.M{
-		"$set": bson.M{
-			"status":     status,
-			"updated_at": time.Now(),
-		},
-	}
+// UpdateStatus は配送のステータスを update.From から update.To に更新します
+// 配送完了にした場合は実際の配送完了日時（配送の証跡がある場合は受け渡し日時）も記録します
+// update.ReleaseRobot の場合は、割り当てた機体がまだこの配送を担当していれば同じトランザクションで待機中に戻します
+// update.Restock の場合は、登録時に引き当てた商品の在庫を同じトランザクションで戻します
+// 現在のステータスが update.From でない場合は ErrDeliveryStatusChanged を返します
+func (r *DeliveryRepositoryImpl) UpdateStatus(ctx context.Context, id primitive.ObjectID, update models.DeliveryStatusUpdate, actor string) error {
+	return r.withTransaction(ctx, func(sc mongo.SessionContext) error {
+		current, err := r.current(sc, id)
+		if err != nil {
+			return err
+		}
+		if current.Status != update.From {
+			return ErrDeliveryStatusChanged
+		}
 
-	if status == models.StatusCompleted {
-		update["$set"].(bson.M)["completed_at"] = time.Now()
-	}
+		now := time.Now()
+		set := bson.M{
+			"status":     update.To,
+			"updated_at": now,
+		}
+		changes := []models.FieldChange{
+			{Field: "status", Previous: string(current.Status), New: string(update.To)},
+		}
+		if update.To == models.StatusCompleted {
+			actual := now
+			if update.Proof != nil {
+				actual = update.Proof.DeliveredAt
+			}
+			set["actual_delivery_time"] = actual
+			changes = append(changes, models.FieldChange{
+				Field:    "actualDeliveryTime",
+				Previous: historyTime(current.ActualDeliveryTime),
+				New:      historyTime(&actual),
+			})
+		}
+		if update.Proof != nil {
+			set["proof_of_delivery"] = update.Proof
+			changes = append(changes, models.FieldChange{
+				Field:    "proofOfDelivery",
+				Previous: nil,
+				New:      historyLocation(&update.Proof.Location),
+			})
+		}
+		modifier := bson.M{"$set": set}
+		if update.CountAttempt {
+			modifier["$inc"] = bson.M{"attempts": 1}
+			changes = append(changes, models.FieldChange{Field: "attempts", Previous: current.Attempts, New: current.Attempts + 1})
+			current.Attempts++
+		}
 
-	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
-	return err
+		if update.UnassignRobot && current.RobotID != "" {
+			modifier["$unset"] = bson.M{"robot_id": "", "assigned_at": ""}
+			changes = append(changes, models.FieldChange{Field: "robotId", Previous: current.RobotID, New: nil})
+		}
+
+		result, err := r.collection.UpdateOne(sc, bson.M{"_id": id, "status": update.From}, modifier)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrDeliveryStatusChanged
+		}
+		if update.ReleaseRobot && current.RobotID != "" {
+			if err := r.releaseRobot(sc, current.RobotID, id.Hex(), now); err != nil {
+				return err
+			}
+		}
+		if update.Restock {
+			if err := r.restock(sc, current.Items, now); err != nil {
+				return err
+			}
+		}
+		current.Status = update.To
+		if update.UnassignRobot {
+			current.RobotID = ""
+		}
+		return r.appendHistory(sc, current, models.HistoryStatusChanged, actor, now, changes, update.Note)
+	})
 }
 
 // UpdateLocation はロボット/ドローンの現在位置を更新します
-func (r *DeliveryRepositoryImpl) UpdateLocation(ctx context.Context, id primitive.ObjectID, location models.Location) error {
-	update := bson.M{
-		"$set": bson.M{
-			"current_location": location,
-			"updated_at":       time.Now(),
-		},
+// バッテリー残量・速度は指定された場合のみ更新し、配送履歴には位置の変更を記録します
+func (r *DeliveryRepositoryImpl) UpdateLocation(ctx context.Context, id primitive.ObjectID, tracking models.TrackingInfo, actor string) error {
+	if tracking.CurrentLocation == nil {
+		return errors.New("current location is required")
 	}
+	location := *tracking.CurrentLocation
+
+	return r.withTransaction(ctx, func(sc mongo.SessionContext) error {
+		current, err := r.current(sc, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		set := bson.M{
+			"tracking_info.current_location": location,
+			"updated_at":                     now,
+		}
+		if tracking.BatteryLevel != nil {
+			set["tracking_info.battery_level"] = *tracking.BatteryLevel
+		}
+		if tracking.Speed != nil {
+			set["tracking_info.speed"] = *tracking.Speed
+		}
+		if _, err := r.collection.UpdateOne(sc, bson.M{"_id": id}, bson.M{"$set": set}); err != nil {
+			return err
+		}
 
+		changes := []models.FieldChange{
+			{Field: "location", Previous: historyLocation(trackedLocation(current)), New: historyLocation(&location)},
+		}
+		if current.TrackingInfo == nil {
+			current.TrackingInfo = &models.TrackingInfo{}
+		}
+		current.TrackingInfo.CurrentLocation = &location
+		return r.appendHistory(sc, current, models.HistoryLocationUpdated, actor, now, changes, nil)
+	})
+}
+
+// UpdatePredictedArrival は予測した到着時刻を更新します
+// 現在位置の更新のたびに再計算する値のため、配送履歴には記録しません
+func (r *DeliveryRepositoryImpl) UpdatePredictedArrival(ctx context.Context, id primitive.ObjectID, predictedArrival time.Time) error {
+	update := bson.M{"$set": bson.M{"predicted_delivery_time": predictedArrival}}
 	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
 	return err
 }
 
+// RecordPINAttempt は受取人の暗証番号の照合回数を1回数えます
+// 照合回数が既に maxAttempts に達している場合は数えずに ErrPINAttemptsExceeded を返します（maxAttempts が0以下の場合は制限しません）
+// 照合の前に数えるため、同時に提出された証跡でも上限を超えて照合しません
+func (r *DeliveryRepositoryImpl) RecordPINAttempt(ctx context.Context, id primitive.ObjectID, maxAttempts int) error {
+	filter := bson.M{"_id": id}
+	if maxAttempts > 0 {
+		filter["pin_attempts"] = bson.M{"$not": bson.M{"$gte": maxAttempts}}
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"pin_attempts": 1}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if maxAttempts > 0 {
+			return ErrPINAttemptsExceeded
+		}
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// GetCompletedDeliveries は指定期間（from 以上 to 未満）に配送が完了した配送を完了日時の順に取得します
+func (r *DeliveryRepositoryImpl) GetCompletedDeliveries(ctx context.Context, from, to time.Time) ([]*models.Delivery, error) {
+	filter := bson.M{
+		"status":               models.StatusCompleted,
+		"actual_delivery_time": bson.M{"$gte": from, "$lt": to},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "actual_delivery_time", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []*models.Delivery{}
+	if err = cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// GetByIDs は指定したIDの配送をまとめて取得します（存在しないIDは結果に含めません）
+func (r *DeliveryRepositoryImpl) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Delivery, error) {
+	deliveries := []*models.Delivery{}
+	if len(ids) == 0 {
+		return deliveries, nil
+	}
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
 // GetActiveDeliveries はアクティブな配送（進行中のもの）を取得します
 func (r *DeliveryRepositoryImpl) GetActiveDeliveries(ctx context.Context) ([]*models.Delivery, error) {
 	filter := bson.M{
@@ -133,6 +323,41 @@ func (r *DeliveryRepositoryImpl) GetDeliveriesByRobot(ctx context.Context, robot
 	return deliveries, nil
 }
 
+// AssignRobot は配送に割り当てた配送ロボット・ドローンを記録します
+// 配送が準備中でない、または既に他の機体を割り当てていた場合は ErrDeliveryAlreadyAssigned を返します
+func (r *DeliveryRepositoryImpl) AssignRobot(ctx context.Context, id primitive.ObjectID, robotID string, assignedAt time.Time, actor string) error {
+	return r.withTransaction(ctx, func(sc mongo.SessionContext) error {
+		current, err := r.current(sc, id)
+		if err != nil {
+			return err
+		}
+
+		filter := bson.M{
+			"_id":      id,
+			"status":   models.StatusPreparing,
+			"robot_id": bson.M{"$in": bson.A{"", nil}},
+		}
+		update := bson.M{
+			"$set": bson.M{
+				"robot_id":    robotID,
+				"assigned_at": assignedAt,
+				"updated_at":  assignedAt,
+			},
+		}
+		result, err := r.collection.UpdateOne(sc, filter, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrDeliveryAlreadyAssigned
+		}
+
+		return r.appendHistory(sc, current, models.HistoryRobotAssigned, actor, assignedAt, []models.FieldChange{
+			{Field: "robotId", Previous: historyString(current.RobotID), New: robotID},
+		}, nil)
+	})
+}
+
 // GetDeliveries retrieves deliveries based on query parameters
 func (r *DeliveryRepositoryImpl) GetDeliveries(query *models.DeliveryQuery) (*models.DeliveryResponse, error) {
 	collection := r.collection
@@ -177,17 +402,16 @@ func (r *DeliveryRepositoryImpl) GetDeliveries(query *models.DeliveryQuery) (*mo
 	}, nil
 }
 
-// GetDeliveryHistory は配送履歴を取得します
+// GetDeliveryHistory は配送履歴を古い順に取得します
 func (r *DeliveryRepositoryImpl) GetDeliveryHistory(ctx context.Context, id primitive.ObjectID) (*models.DeliveryHistoryResponse, error) {
-	collection := r.collection.Database().Collection("delivery_history")
-
-	cursor, err := collection.Find(ctx, bson.M{"delivery_id": id})
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.history.Find(ctx, bson.M{"delivery_id": id.Hex()}, opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var history []models.DeliveryHistory
+	history := []models.DeliveryHistory{}
 	if err := cursor.All(ctx, &history); err != nil {
 		return nil, err
 	}
@@ -198,12 +422,254 @@ func (r *DeliveryRepositoryImpl) GetDeliveryHistory(ctx context.Context, id prim
 }
 
 // Update は配送情報を更新します
-func (r *DeliveryRepositoryImpl) Update(ctx context.Context, id primitive.ObjectID, delivery *models.Delivery) error {
-	delivery.UpdatedAt = time.Now()
-	_, err := r.collection.UpdateOne(
-		ctx,
-		bson.M{"_id": id},
-		bson.M{"$set": delivery},
-	)
+// 更新できるのは配送種別・住所・配送予定日時・配送完了日時・備考・荷物の重量・配送先・到着時間帯です
+// ステータスと機体の割り当ては専用の更新で変更します
+func (r *DeliveryRepositoryImpl) Update(ctx context.Context, id primitive.ObjectID, delivery *models.Delivery, actor string) error {
+	return r.withTransaction(ctx, func(sc mongo.SessionContext) error {
+		current, err := r.current(sc, id)
+		if err != nil {
+			return err
+		}
+
+		changes := deliveryChanges(current, delivery)
+		if len(changes) == 0 {
+			*delivery = *current
+			return nil
+		}
+
+		now := time.Now()
+		update := bson.M{
+			"$set": bson.M{
+				"delivery_type":           delivery.DeliveryType,
+				"address":                 delivery.Address,
+				"estimated_delivery_time": delivery.EstimatedDeliveryTime,
+				"actual_delivery_time":    delivery.ActualDeliveryTime,
+				"notes":                   delivery.Notes,
+				"payload_weight":          delivery.PayloadWeight,
+				"destination":             delivery.Destination,
+				"window_start":            delivery.WindowStart,
+				"window_end":              delivery.WindowEnd,
+				"updated_at":              now,
+			},
+		}
+		if _, err := r.collection.UpdateOne(sc, bson.M{"_id": id}, update); err != nil {
+			return err
+		}
+
+		current.DeliveryType = delivery.DeliveryType
+		current.Address = delivery.Address
+		current.EstimatedDeliveryTime = delivery.EstimatedDeliveryTime
+		current.ActualDeliveryTime = delivery.ActualDeliveryTime
+		current.Notes = delivery.Notes
+		current.PayloadWeight = delivery.PayloadWeight
+		current.Destination = delivery.Destination
+		current.WindowStart = delivery.WindowStart
+		current.WindowEnd = delivery.WindowEnd
+		current.UpdatedAt = now
+		*delivery = *current
+		return r.appendHistory(sc, current, models.HistoryUpdated, actor, now, changes, nil)
+	})
+}
+
+// WithdrawRobot は配送に割り当て中の機体を robot の内容（充電中・メンテナンス中などの状態を含む）に更新し、
+// 担当していた配送 deliveryID の割り当てを同じトランザクションで解除して配送履歴に記録します
+// 機体が配送 deliveryID の担当でなくなっていた場合は ErrRobotStatusChanged を返します
+func (r *DeliveryRepositoryImpl) WithdrawRobot(ctx context.Context, robot *models.Robot, deliveryID string, actor string) error {
+	id, err := primitive.ObjectIDFromHex(deliveryID)
+	if err != nil {
+		return fmt.Errorf("invalid delivery id %q: %w", deliveryID, err)
+	}
+	robot.UpdatedAt = time.Now()
+	return r.withTransaction(ctx, func(sc mongo.SessionContext) error {
+		filter := bson.M{
+			"_id":                 robot.ID,
+			"status":              models.RobotAssigned,
+			"current_delivery_id": deliveryID,
+		}
+		result, err := r.robots.UpdateOne(sc, filter, robotUpdate(robot))
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrRobotStatusChanged
+		}
+
+		current, err := r.current(sc, id)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil
+			}
+			return err
+		}
+		robotID := robot.ID.Hex()
+		if current.RobotID != robotID {
+			return nil
+		}
+		if _, err := r.collection.UpdateOne(sc, bson.M{"_id": id, "robot_id": robotID}, bson.M{
+			"$unset": bson.M{"robot_id": "", "assigned_at": ""},
+			"$set":   bson.M{"updated_at": robot.UpdatedAt},
+		}); err != nil {
+			return err
+		}
+
+		current.RobotID = ""
+		current.AssignedAt = nil
+		note := fmt.Sprintf("機体の稼働状態を %s に変更したため割り当てを解除", robot.Status)
+		return r.appendHistory(sc, current, models.HistoryRobotUnassigned, actor, robot.UpdatedAt, []models.FieldChange{
+			{Field: "robotId", Previous: robotID, New: nil},
+		}, &note)
+	})
+}
+
+// withTransaction は fn を1つのトランザクションで実行します（MongoDB はレプリカセット構成が必要です）
+func (r *DeliveryRepositoryImpl) withTransaction(ctx context.Context, fn func(sc mongo.SessionContext) error) error {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
 	return err
 }
+
+// releaseRobot は機体が配送 deliveryID を担当している場合に待機中に戻します
+// 既に他の配送に割り当て直された機体は変更しません
+func (r *DeliveryRepositoryImpl) releaseRobot(ctx context.Context, robotID, deliveryID string, at time.Time) error {
+	id, err := primitive.ObjectIDFromHex(robotID)
+	if err != nil {
+		return fmt.Errorf("invalid robot id %q: %w", robotID, err)
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.RobotIdle,
+			"updated_at": at,
+		},
+		"$unset": bson.M{"current_delivery_id": ""},
+	}
+	_, err = r.robots.UpdateOne(ctx, bson.M{"_id": id, "current_delivery_id": deliveryID}, update)
+	return err
+}
+
+// reserveStock は配送する商品の在庫を引き当てます
+// 在庫が数量に足りない商品がある場合は ErrInsufficientStock を返します（呼び出し元のトランザクションで取り消されます）
+func (r *DeliveryRepositoryImpl) reserveStock(ctx context.Context, items []models.DeliveryItem, at time.Time) error {
+	for _, item := range items {
+		productID, err := primitive.ObjectIDFromHex(item.ProductID)
+		if err != nil {
+			return fmt.Errorf("invalid product id %q: %w", item.ProductID, err)
+		}
+		result, err := r.products.UpdateOne(ctx, bson.M{"_id": productID, "stock": bson.M{"$gte": item.Quantity}}, bson.M{
+			"$inc": bson.M{"stock": -item.Quantity},
+			"$set": bson.M{"updated_at": at},
+		})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return fmt.Errorf("product %s: %w", item.ProductID, ErrInsufficientStock)
+		}
+	}
+	return nil
+}
+
+// restock は登録時に引き当てた商品の在庫を戻します（削除済みの商品は戻しません）
+func (r *DeliveryRepositoryImpl) restock(ctx context.Context, items []models.DeliveryItem, at time.Time) error {
+	for _, item := range items {
+		productID, err := primitive.ObjectIDFromHex(item.ProductID)
+		if err != nil {
+			return fmt.Errorf("invalid product id %q: %w", item.ProductID, err)
+		}
+		if _, err := r.products.UpdateOne(ctx, bson.M{"_id": productID}, bson.M{
+			"$inc": bson.M{"stock": item.Quantity},
+			"$set": bson.M{"updated_at": at},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// current はトランザクション内で配送の現在の内容を取得します
+func (r *DeliveryRepositoryImpl) current(ctx context.Context, id primitive.ObjectID) (*models.Delivery, error) {
+	var delivery models.Delivery
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// appendHistory は変更後の配送の状態で配送履歴を1件追加します（note は変更の理由などの備考です）
+func (r *DeliveryRepositoryImpl) appendHistory(ctx context.Context, delivery *models.Delivery, event models.DeliveryHistoryEvent, actor string, at time.Time, changes []models.FieldChange, note *string) error {
+	_, err := r.history.InsertOne(ctx, &models.DeliveryHistory{
+		DeliveryID: delivery.ID,
+		Event:      event,
+		Status:     string(delivery.Status),
+		Actor:      actor,
+		Changes:    changes,
+		Timestamp:  at,
+		Location:   trackedLocation(delivery),
+		Note:       note,
+	})
+	return err
+}
+
+// deliveryChanges は配送情報の更新で値が変わる項目を返します
+func deliveryChanges(current, updated *models.Delivery) []models.FieldChange {
+	var changes []models.FieldChange
+	add := func(field string, previous, next interface{}) {
+		if previous != next {
+			changes = append(changes, models.FieldChange{Field: field, Previous: previous, New: next})
+		}
+	}
+	add("deliveryType", current.DeliveryType, updated.DeliveryType)
+	add("address", current.Address, updated.Address)
+	add("estimatedDeliveryTime", historyTime(&current.EstimatedDeliveryTime), historyTime(&updated.EstimatedDeliveryTime))
+	add("actualDeliveryTime", historyTime(current.ActualDeliveryTime), historyTime(updated.ActualDeliveryTime))
+	add("notes", historyNote(current.Notes), historyNote(updated.Notes))
+	add("payloadWeight", current.PayloadWeight, updated.PayloadWeight)
+	add("destination", historyLocation(current.Destination), historyLocation(updated.Destination))
+	add("windowStart", historyTime(current.WindowStart), historyTime(updated.WindowStart))
+	add("windowEnd", historyTime(current.WindowEnd), historyTime(updated.WindowEnd))
+	return changes
+}
+
+// trackedLocation は配送の現在位置を返します（未取得の場合は nil）
+func trackedLocation(delivery *models.Delivery) *models.Location {
+	if delivery.TrackingInfo == nil {
+		return nil
+	}
+	return delivery.TrackingInfo.CurrentLocation
+}
+
+// historyTime は日時を配送履歴の値（MongoDB の精度のミリ秒までの RFC 3339 形式）に変換します
+func historyTime(t *time.Time) interface{} {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano)
+}
+
+// historyLocation は位置を配送履歴の値（"緯度,経度"）に変換します
+func historyLocation(location *models.Location) interface{} {
+	if location == nil {
+		return nil
+	}
+	return fmt.Sprintf("%.6f,%.6f", location.Latitude, location.Longitude)
+}
+
+func historyNote(note *string) interface{} {
+	if note == nil {
+		return nil
+	}
+	return *note
+}
+
+func historyString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// DeliverySlotRepositoryImpl は配送時間枠リポジトリの実装です
+// テンプレートは delivery_slot_templates、日付ごとの枠は delivery_slots に保存します
+type DeliverySlotRepositoryImpl struct {
+	templates *mongo.Collection
+	slots     *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ DeliverySlotRepository = (*DeliverySlotRepositoryImpl)(nil)
+
+func NewDeliverySlotRepository(db *mongo.Database) DeliverySlotRepository {
+	return &DeliverySlotRepositoryImpl{
+		templates: db.Collection("delivery_slot_templates"),
+		slots:     db.Collection("delivery_slots"),
+	}
+}
+
+// UpsertTemplate は曜日の配送時間枠のテンプレートを登録・更新します
+func (r *DeliverySlotRepositoryImpl) UpsertTemplate(ctx context.Context, template *models.SlotTemplate) error {
+	template.UpdatedAt = time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"windows":    template.Windows,
+			"updated_at": template.UpdatedAt,
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	return r.templates.FindOneAndUpdate(ctx, bson.M{"weekday": template.Weekday}, update, opts).Decode(template)
+}
+
+// ListTemplates は配送時間枠のテンプレートを曜日順に取得します
+func (r *DeliverySlotRepositoryImpl) ListTemplates(ctx context.Context) ([]*models.SlotTemplate, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "weekday", Value: 1}})
+	cursor, err := r.templates.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	templates := []*models.SlotTemplate{}
+	if err = cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// EnsureSlot は開始・終了日時が同じ枠がなければ作成し、保存されている枠を slot に読み込みます
+// 既に作成済みの枠の受付件数・予約件数は変更しません
+func (r *DeliverySlotRepositoryImpl) EnsureSlot(ctx context.Context, slot *models.DeliverySlot) error {
+	filter := bson.M{"start": slot.Start, "end": slot.End}
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"date":             slot.Date,
+			"capacity":         slot.Capacity,
+			"derived_capacity": slot.DerivedCapacity,
+			"reserved":         0,
+			"created_at":       time.Now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	return r.slots.FindOneAndUpdate(ctx, filter, update, opts).Decode(slot)
+}
+
+// UpdateDerivedCapacity は機体の台数から受付件数を求めた枠の受付件数を更新します（更新した場合はtrue）
+// 予約が1件でも入った枠は、予約時の受付件数のまま変更しません
+func (r *DeliverySlotRepositoryImpl) UpdateDerivedCapacity(ctx context.Context, id primitive.ObjectID, capacity int) (bool, error) {
+	filter := bson.M{
+		"_id":              id,
+		"derived_capacity": true,
+		"reserved":         0,
+	}
+	result, err := r.slots.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"capacity": capacity}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// GetSlot は指定されたIDの配送時間枠を取得します（存在しない場合はnil）
+func (r *DeliverySlotRepositoryImpl) GetSlot(ctx context.Context, id primitive.ObjectID) (*models.DeliverySlot, error) {
+	var slot models.DeliverySlot
+	err := r.slots.FindOne(ctx, bson.M{"_id": id}).Decode(&slot)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// ListSlots は開始日時が指定期間（from 以上 to 未満）の配送時間枠を開始日時の順に取得します
+func (r *DeliverySlotRepositoryImpl) ListSlots(ctx context.Context, from, to time.Time) ([]*models.DeliverySlot, error) {
+	filter := bson.M{"start": bson.M{"$gte": from, "$lt": to}}
+	opts := options.Find().SetSort(bson.D{{Key: "start", Value: 1}, {Key: "end", Value: 1}})
+	cursor, err := r.slots.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	slots := []*models.DeliverySlot{}
+	if err = cursor.All(ctx, &slots); err != nil {
+		return nil, err
+	}
+	return slots, nil
+}
+
+// Reserve は配送時間枠に空きがあれば予約件数を1件増やします
+// 空きの確認と更新を1回の条件付き更新で行うため、同時に予約しても受付件数を超えません。満席の場合はfalseを返します
+func (r *DeliverySlotRepositoryImpl) Reserve(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id":   id,
+		"$expr": bson.M{"$lt": bson.A{"$reserved", "$capacity"}},
+	}
+	result, err := r.slots.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"reserved": 1}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// Release は配送時間枠の予約件数を1件減らします
+func (r *DeliverySlotRepositoryImpl) Release(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "reserved": bson.M{"$gt": 0}}
+	_, err := r.slots.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"reserved": -1}})
+	return err
+}
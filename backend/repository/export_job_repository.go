@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// ExportJobRepositoryImpl はエクスポートジョブリポジトリの実装です
+type ExportJobRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ ExportJobRepository = (*ExportJobRepositoryImpl)(nil)
+
+func NewExportJobRepository(db *mongo.Database) ExportJobRepository {
+	return &ExportJobRepositoryImpl{
+		collection: db.Collection("export_jobs"),
+	}
+}
+
+// Create は新しいエクスポートジョブを登録します
+func (r *ExportJobRepositoryImpl) Create(ctx context.Context, job *models.ExportJob) error {
+	result, err := r.collection.InsertOne(ctx, job)
+	if err != nil {
+		return err
+	}
+	job.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID は指定されたIDのエクスポートジョブを取得します（存在しない場合はnil）
+func (r *ExportJobRepositoryImpl) GetByID(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// UpdateStatus はジョブの状態と結果を保存します
+func (r *ExportJobRepositoryImpl) UpdateStatus(ctx context.Context, job *models.ExportJob) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":       job.Status,
+			"row_count":    job.RowCount,
+			"file_name":    job.FileName,
+			"error":        job.Error,
+			"started_at":   job.StartedAt,
+			"completed_at": job.CompletedAt,
+			"expires_at":   job.ExpiresAt,
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": job.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Heartbeat は owner が実行中の未完了のジョブの最終確認日時を at に更新します
+func (r *ExportJobRepositoryImpl) Heartbeat(ctx context.Context, id primitive.ObjectID, owner string, at time.Time) error {
+	filter := bson.M{
+		"_id":    id,
+		"owner":  owner,
+		"status": bson.M{"$in": []models.ExportJobStatus{models.ExportJobPending, models.ExportJobRunning}},
+	}
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"heartbeat_at": at}})
+	return err
+}
+
+// FailStale は最終確認日時が staleBefore より前の待機中・実行中のジョブを失敗として記録し、更新した件数を返します
+// 他のインスタンスが実行中のジョブは最終確認日時が更新され続けるため、失敗にしません
+func (r *ExportJobRepositoryImpl) FailStale(ctx context.Context, message string, staleBefore, at time.Time) (int64, error) {
+	filter := bson.M{
+		"status": bson.M{"$in": []models.ExportJobStatus{models.ExportJobPending, models.ExportJobRunning}},
+		// 最終確認日時のない以前のジョブも対象にします
+		"$or": []bson.M{
+			{"heartbeat_at": bson.M{"$lt": staleBefore}},
+			{"heartbeat_at": bson.M{"$exists": false}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.ExportJobFailed,
+			"error":        message,
+			"completed_at": at,
+		},
+	}
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
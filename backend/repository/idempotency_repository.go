@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// IdempotencyRepositoryImpl は冪等キーリポジトリの実装です
+type IdempotencyRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ IdempotencyRepository = (*IdempotencyRepositoryImpl)(nil)
+
+func NewIdempotencyRepository(db *mongo.Database) IdempotencyRepository {
+	return &IdempotencyRepositoryImpl{
+		collection: db.Collection("idempotency_keys"),
+	}
+}
+
+// Reserve は冪等キーを処理中として登録します
+// 同じキーが既に登録されている場合は登録せず、既存のレコードを返します
+// _id の一意性により、同時に届いた同じキーのリクエストのうち1つだけが登録に成功します
+func (r *IdempotencyRepositoryImpl) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	record.CreatedAt = time.Now()
+	record.Status = models.IdempotencyProcessing
+
+	// TTLインデックスによる削除は即時ではないため、期限切れのレコードは自分で削除して登録し直す
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := r.collection.InsertOne(ctx, record)
+		if err == nil {
+			return nil, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		existing, err := r.Get(ctx, record.ID)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			continue
+		}
+		if existing.ExpiresAt.After(record.CreatedAt) {
+			return existing, nil
+		}
+		if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": record.ID, "expires_at": existing.ExpiresAt}); err != nil {
+			return nil, err
+		}
+	}
+	return nil, errors.New("failed to reserve idempotency key")
+}
+
+// Get は冪等キーのレコードを取得します（存在しない場合はnil）
+func (r *IdempotencyRepositoryImpl) Get(ctx context.Context, id string) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Complete は処理結果の応答を保存し、有効期限を延長します
+func (r *IdempotencyRepositoryImpl) Complete(ctx context.Context, id string, status int, contentType string, body []byte, expiresAt time.Time) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":          models.IdempotencyCompleted,
+			"response_status": status,
+			"content_type":    contentType,
+			"response_body":   body,
+			"expires_at":      expiresAt,
+		},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": models.IdempotencyProcessing}, update)
+	return err
+}
+
+// Release は処理中のキーを削除し、同じキーで再試行できるようにします
+func (r *IdempotencyRepositoryImpl) Release(ctx context.Context, id string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "status": models.IdempotencyProcessing})
+	return err
+}
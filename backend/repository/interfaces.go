@@ -13,37 +13,52 @@ import (
 type ProductRepository interface {
 	Create(ctx context.Context, product *models.Product) error
 	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error)
-	List(ctx context.Context, skip, limit int64) ([]*models.Product, error)
+	List(ctx context.Context, query models.ProductQuery, skip, limit int64) ([]*models.Product, error)
 	Update(ctx context.Context, product *models.Product) error
+	UpdateABC(ctx context.Context, results map[primitive.ObjectID]models.ProductABC) error
 	Delete(ctx context.Context, id primitive.ObjectID) error
 	GetByCategory(ctx context.Context, category string) ([]*models.Product, error)
 	GetLowStock(ctx context.Context) ([]*models.Product, error)
 }
 
 // DeliveryRepository は配送リポジトリのインターフェースを定義します
+// 作成・更新系のメソッドは actor（変更者）とともに配送履歴を記録します
 type DeliveryRepository interface {
-	Create(ctx context.Context, delivery *models.Delivery) error
+	Create(ctx context.Context, delivery *models.Delivery, actor string) error
 	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Delivery, error)
 	List(ctx context.Context, skip, limit int64) ([]*models.Delivery, error)
-	Update(ctx context.Context, id primitive.ObjectID, delivery *models.Delivery) error
-	UpdateStatus(ctx context.Context, id primitive.ObjectID, status models.DeliveryStatus) error
-	UpdateLocation(ctx context.Context, id primitive.ObjectID, location models.Location) error
+	Update(ctx context.Context, id primitive.ObjectID, delivery *models.Delivery, actor string) error
+	UpdateStatus(ctx context.Context, id primitive.ObjectID, update models.DeliveryStatusUpdate, actor string) error
+	UpdateLocation(ctx context.Context, id primitive.ObjectID, tracking models.TrackingInfo, actor string) error
 	GetActiveDeliveries(ctx context.Context) ([]*models.Delivery, error)
 	GetDeliveriesByRobot(ctx context.Context, robotID string) ([]*models.Delivery, error)
+	AssignRobot(ctx context.Context, id primitive.ObjectID, robotID string, assignedAt time.Time, actor string) error
+	WithdrawRobot(ctx context.Context, robot *models.Robot, deliveryID string, actor string) error
 	GetDeliveries(query *models.DeliveryQuery) (*models.DeliveryResponse, error)
 	GetDeliveryHistory(ctx context.Context, id primitive.ObjectID) (*models.DeliveryHistoryResponse, error)
+	UpdatePredictedArrival(ctx context.Context, id primitive.ObjectID, predictedArrival time.Time) error
+	RecordPINAttempt(ctx context.Context, id primitive.ObjectID, maxAttempts int) error
+	GetCompletedDeliveries(ctx context.Context, from, to time.Time) ([]*models.Delivery, error)
+	GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Delivery, error)
 }
 
 // SaleRepository は売上リポジトリのインターフェースを定義します
 type SaleRepository interface {
 	Create(ctx context.Context, sale *models.Sale) error
+	CreateMany(ctx context.Context, sales []*models.Sale) ([]error, error)
 	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Sale, error)
 	GetDailySales(ctx context.Context, date time.Time) ([]*models.Sale, error)
 	GetSalesByTimeOfDay(ctx context.Context, timeOfDay string) ([]*models.Sale, error)
 	GetSalesByDateRange(ctx context.Context, start, end time.Time) ([]*models.Sale, error)
-	GetTotalSalesAmount(ctx context.Context, start, end time.Time) (float64, error)
+	FindSales(ctx context.Context, query models.SaleQuery) ([]*models.Sale, error)
+	StreamSales(ctx context.Context, query models.SaleQuery, fn func(*models.Sale) error) error
+	GetSalesByMember(ctx context.Context, memberID primitive.ObjectID, skip, limit int64) ([]*models.Sale, error)
+	GetTotalSalesAmount(ctx context.Context, query models.SaleQuery, category string) (float64, error)
 	GetEnvironmentalImpactAnalytics(ctx context.Context, start, end time.Time) (*models.EnvironmentalImpact, error)
-	GetSalesByCategory(ctx context.Context, start, end time.Time) (map[string]int, error)
+	GetSalesByCategory(ctx context.Context, start, end time.Time) ([]*models.CategorySales, error)
+	GetSalesByProduct(ctx context.Context, query models.SaleQuery) ([]*models.ProductSales, error)
+	GetHourlySales(ctx context.Context, query models.SaleQuery, timezone string) ([]*models.HourlySales, error)
+	GetDailyRevenue(ctx context.Context, query models.SaleQuery, timezone string) ([]*models.DailyRevenue, error)
 }
 
 // StoreOperationRepository は店舗運営リポジトリのインターフェースを定義します
@@ -55,3 +70,143 @@ type StoreOperationRepository interface {
 	UpdateCheckoutStatus(ctx context.Context, opID primitive.ObjectID, status models.CheckoutStatus) error
 	GetAverageEnergyUsage(ctx context.Context, start, end time.Time) (map[string]float64, error)
 }
+
+// StoreSettingsRepository は店舗設定リポジトリのインターフェースを定義します
+type StoreSettingsRepository interface {
+	GetByStoreID(ctx context.Context, storeID string) (*models.StoreSettings, error)
+	Upsert(ctx context.Context, settings *models.StoreSettings) error
+}
+
+// RegisterSessionRepository はレジ精算セッションリポジトリのインターフェースを定義します
+type RegisterSessionRepository interface {
+	Create(ctx context.Context, session *models.RegisterSession) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.RegisterSession, error)
+	GetOpenSession(ctx context.Context, storeID, registerID string) (*models.RegisterSession, error)
+	Close(ctx context.Context, session *models.RegisterSession) error
+	ListByPeriod(ctx context.Context, storeID, registerID string, start, end time.Time) ([]*models.RegisterSession, error)
+}
+
+// MemberRepository は会員リポジトリのインターフェースを定義します
+type MemberRepository interface {
+	Create(ctx context.Context, member *models.Member) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Member, error)
+	GetByCode(ctx context.Context, code string) (*models.Member, error)
+	List(ctx context.Context, skip, limit int64) ([]*models.Member, error)
+	UpdateProfile(ctx context.Context, member *models.Member) error
+	AddPoints(ctx context.Context, id primitive.ObjectID, points int) (*models.Member, error)
+	RecordPurchase(ctx context.Context, id primitive.ObjectID, amount float64, points int, at time.Time) (*models.Member, error)
+}
+
+// PointTransactionRepository はポイント台帳リポジトリのインターフェースを定義します
+type PointTransactionRepository interface {
+	Create(ctx context.Context, tx *models.PointTransaction) error
+	ListByMember(ctx context.Context, memberID primitive.ObjectID, skip, limit int64) ([]*models.PointTransaction, error)
+}
+
+// IdempotencyRepository は冪等キーリポジトリのインターフェースを定義します
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	Get(ctx context.Context, id string) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, id string, status int, contentType string, body []byte, expiresAt time.Time) error
+	Release(ctx context.Context, id string) error
+}
+
+// SaleReturnRepository は取消・返品リポジトリのインターフェースを定義します
+type SaleReturnRepository interface {
+	Create(ctx context.Context, ret *models.SaleReturn) error
+	GetBySale(ctx context.Context, saleID primitive.ObjectID) ([]*models.SaleReturn, error)
+	FindReturns(ctx context.Context, query models.SaleQuery) ([]*models.SaleReturn, error)
+}
+
+// AnomalyRepository は異常イベントリポジトリのインターフェースを定義します
+type AnomalyRepository interface {
+	Upsert(ctx context.Context, event *models.AnomalyEvent) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.AnomalyEvent, error)
+	List(ctx context.Context, query models.AnomalyQuery) ([]*models.AnomalyEvent, error)
+	UpdateReview(ctx context.Context, id primitive.ObjectID, status models.AnomalyStatus, reviewedBy, note string, at time.Time) error
+}
+
+// ExportJobRepository はエクスポートジョブリポジトリのインターフェースを定義します
+type ExportJobRepository interface {
+	Create(ctx context.Context, job *models.ExportJob) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, error)
+	UpdateStatus(ctx context.Context, job *models.ExportJob) error
+	Heartbeat(ctx context.Context, id primitive.ObjectID, owner string, at time.Time) error
+	FailStale(ctx context.Context, message string, staleBefore, at time.Time) (int64, error)
+}
+
+// SalesTargetRepository は売上目標リポジトリのインターフェースを定義します
+type SalesTargetRepository interface {
+	Upsert(ctx context.Context, target *models.SalesTarget) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.SalesTarget, error)
+	List(ctx context.Context, query models.SalesTargetQuery) ([]*models.SalesTarget, error)
+	Delete(ctx context.Context, id primitive.ObjectID) (bool, error)
+}
+
+// StoreEventRepository は店舗イベント（営業カレンダー）リポジトリのインターフェースを定義します
+type StoreEventRepository interface {
+	Create(ctx context.Context, event *models.StoreEvent) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.StoreEvent, error)
+	Update(ctx context.Context, event *models.StoreEvent) (bool, error)
+	List(ctx context.Context, query models.StoreEventQuery) ([]*models.StoreEvent, error)
+	Delete(ctx context.Context, id primitive.ObjectID) (bool, error)
+}
+
+// RobotRepository は配送ロボット・ドローン（フリート）リポジトリのインターフェースを定義します
+type RobotRepository interface {
+	Create(ctx context.Context, robot *models.Robot) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Robot, error)
+	List(ctx context.Context, query models.RobotQuery) ([]*models.Robot, error)
+	Update(ctx context.Context, robot *models.Robot, from models.RobotStatus) (bool, error)
+	Assign(ctx context.Context, id primitive.ObjectID, deliveryID string) (bool, error)
+	Release(ctx context.Context, id primitive.ObjectID, status models.RobotStatus) error
+	Delete(ctx context.Context, id primitive.ObjectID) (bool, error)
+	UpdateTelemetry(ctx context.Context, id primitive.ObjectID, telemetry models.RobotTelemetry) error
+}
+
+// TelemetryRepository は配送機体の計測値（時系列データ）リポジトリのインターフェースを定義します
+type TelemetryRepository interface {
+	EnsureCollections(ctx context.Context, rawRetention, rollupRetention time.Duration) error
+	InsertReadings(ctx context.Context, readings []models.TelemetryReading) error
+	FindReadings(ctx context.Context, robotID string, from, to time.Time, limit int64) ([]models.TelemetryReading, error)
+	AggregateMinutes(ctx context.Context, robotID string, from, to time.Time) ([]models.TelemetryRollup, error)
+	InsertRollups(ctx context.Context, rollups []models.TelemetryRollup) error
+	FindRollups(ctx context.Context, robotID string, from, to time.Time) ([]models.TelemetryRollup, error)
+	LatestRollupTime(ctx context.Context) (time.Time, error)
+	AverageSpeed(ctx context.Context, robotID string, from, to time.Time, minSpeed float64) (float64, int, error)
+	StreamRollups(ctx context.Context, from, to time.Time, fn func(*models.TelemetryRollup) error) error
+	AcquireRollupLease(ctx context.Context, holder string, now time.Time, ttl time.Duration) (bool, error)
+	ReleaseRollupLease(ctx context.Context, holder string) error
+}
+
+// DeliveryAlertRepository は配送の遅延アラートリポジトリのインターフェースを定義します
+type DeliveryAlertRepository interface {
+	Raise(ctx context.Context, alert *models.DeliveryAlert) error
+	Resolve(ctx context.Context, deliveryID string, resolvedAt time.Time) error
+	ListOpenDeliveryIDs(ctx context.Context) ([]string, error)
+	List(ctx context.Context, status models.DeliveryAlertStatus) ([]*models.DeliveryAlert, error)
+	AcquireRefreshLease(ctx context.Context, holder string, now time.Time, ttl time.Duration) (bool, error)
+	ReleaseRefreshLease(ctx context.Context, holder string) error
+}
+
+// DeliverySlotRepository は配送時間枠（テンプレートと日付ごとの枠）リポジトリのインターフェースを定義します
+type DeliverySlotRepository interface {
+	UpsertTemplate(ctx context.Context, template *models.SlotTemplate) error
+	ListTemplates(ctx context.Context) ([]*models.SlotTemplate, error)
+	EnsureSlot(ctx context.Context, slot *models.DeliverySlot) error
+	UpdateDerivedCapacity(ctx context.Context, id primitive.ObjectID, capacity int) (bool, error)
+	GetSlot(ctx context.Context, id primitive.ObjectID) (*models.DeliverySlot, error)
+	ListSlots(ctx context.Context, from, to time.Time) ([]*models.DeliverySlot, error)
+	Reserve(ctx context.Context, id primitive.ObjectID) (bool, error)
+	Release(ctx context.Context, id primitive.ObjectID) error
+}
+
+// SlotReservationRepository は配送時間枠の予約リポジトリのインターフェースを定義します
+type SlotReservationRepository interface {
+	Create(ctx context.Context, reservation *models.SlotReservation) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.SlotReservation, error)
+	Confirm(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error)
+	AttachDelivery(ctx context.Context, id primitive.ObjectID, deliveryID string) error
+	Close(ctx context.Context, id primitive.ObjectID, from []models.SlotReservationStatus, to models.SlotReservationStatus, now time.Time) (bool, error)
+	FindExpired(ctx context.Context, now time.Time) ([]*models.SlotReservation, error)
+}
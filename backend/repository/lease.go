@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// acquireLease は leases のリース id を holder が取得（保持している場合は延長）し、取得できた場合はtrueを返します
+// 他のインスタンスが期限内のリースを保持している場合はfalseを返します
+func acquireLease(ctx context.Context, leases *mongo.Collection, id, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"holder": holder},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"holder": holder, "expires_at": now.Add(ttl)}}
+	_, err := leases.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		// 他のインスタンスが保持しているリースと同じIDで登録しようとした
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// releaseLease は holder が保持しているリース id を解放します
+func releaseLease(ctx context.Context, leases *mongo.Collection, id, holder string) error {
+	_, err := leases.DeleteOne(ctx, bson.M{"_id": id, "holder": holder})
+	return err
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// ErrMemberCodeExists は会員番号が既に使われている場合のエラーです
+var ErrMemberCodeExists = errors.New("member code already exists")
+
+// ErrInsufficientPoints はポイント残高が不足している場合のエラーです
+var ErrInsufficientPoints = errors.New("insufficient points")
+
+// MemberRepositoryImpl は会員リポジトリの実装です
+type MemberRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ MemberRepository = (*MemberRepositoryImpl)(nil)
+
+func NewMemberRepository(db *mongo.Database) MemberRepository {
+	return &MemberRepositoryImpl{
+		collection: db.Collection("members"),
+	}
+}
+
+// Create は新しい会員を登録します
+func (r *MemberRepositoryImpl) Create(ctx context.Context, member *models.Member) error {
+	member.CreatedAt = time.Now()
+	member.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, member)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrMemberCodeExists
+		}
+		return err
+	}
+
+	member.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID は指定されたIDの会員を取得します（存在しない場合はnil）
+func (r *MemberRepositoryImpl) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Member, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// GetByCode は会員番号から会員を取得します（存在しない場合はnil）
+func (r *MemberRepositoryImpl) GetByCode(ctx context.Context, code string) (*models.Member, error) {
+	return r.findOne(ctx, bson.M{"member_code": code})
+}
+
+func (r *MemberRepositoryImpl) findOne(ctx context.Context, filter bson.M) (*models.Member, error) {
+	var member models.Member
+	err := r.collection.FindOne(ctx, filter).Decode(&member)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// List は会員一覧を取得します
+func (r *MemberRepositoryImpl) List(ctx context.Context, skip, limit int64) ([]*models.Member, error) {
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var members []*models.Member
+	if err = cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// UpdateProfile は会員の氏名・連絡先を更新します
+// ポイント残高と購買実績は AddPoints / RecordPurchase でのみ更新します
+func (r *MemberRepositoryImpl) UpdateProfile(ctx context.Context, member *models.Member) error {
+	member.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":       member.Name,
+			"email":      member.Email,
+			"phone":      member.Phone,
+			"updated_at": member.UpdatedAt,
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": member.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// AddPoints はポイント残高を増減し、更新後の会員を返します
+// 減算の場合は残高が足りるときのみ更新するため、同時に利用されても残高は負になりません
+func (r *MemberRepositoryImpl) AddPoints(ctx context.Context, id primitive.ObjectID, points int) (*models.Member, error) {
+	filter := bson.M{"_id": id}
+	if points < 0 {
+		filter["point_balance"] = bson.M{"$gte": -points}
+	}
+	update := bson.M{
+		"$inc": bson.M{"point_balance": points},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	member, err := r.findOneAndUpdate(ctx, filter, update)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		existing, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			return nil, mongo.ErrNoDocuments
+		}
+		return nil, ErrInsufficientPoints
+	}
+	return member, nil
+}
+
+// RecordPurchase は購入金額と来店を記録し、付与ポイントを加算します
+func (r *MemberRepositoryImpl) RecordPurchase(ctx context.Context, id primitive.ObjectID, amount float64, points int, at time.Time) (*models.Member, error) {
+	update := bson.M{
+		"$inc": bson.M{
+			"point_balance":   points,
+			"lifetime_points": points,
+			"total_spent":     amount,
+			"visit_count":     1,
+		},
+		"$set": bson.M{
+			"last_visit_at": at,
+			"updated_at":    time.Now(),
+		},
+	}
+
+	member, err := r.findOneAndUpdate(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	return member, nil
+}
+
+func (r *MemberRepositoryImpl) findOneAndUpdate(ctx context.Context, filter, update bson.M) (*models.Member, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var member models.Member
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&member)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member, nil
+}
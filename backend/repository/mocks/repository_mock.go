@@ -112,18 +112,18 @@ func (mr *MockProductRepositoryMockRecorder) GetLowStock(ctx interface{}) *gomoc
 }
 
 // List mocks base method.
-func (m *MockProductRepository) List(ctx context.Context, skip, limit int64) ([]*models.Product, error) {
+func (m *MockProductRepository) List(ctx context.Context, query models.ProductQuery, skip, limit int64) ([]*models.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List", ctx, skip, limit)
+	ret := m.ctrl.Call(m, "List", ctx, query, skip, limit)
 	ret0, _ := ret[0].([]*models.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // List indicates an expected call of List.
-func (mr *MockProductRepositoryMockRecorder) List(ctx, skip, limit interface{}) *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) List(ctx, query, skip, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockProductRepository)(nil).List), ctx, skip, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockProductRepository)(nil).List), ctx, query, skip, limit)
 }
 
 // Update mocks base method.
@@ -140,6 +140,20 @@ func (mr *MockProductRepositoryMockRecorder) Update(ctx, product interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProductRepository)(nil).Update), ctx, product)
 }
 
+// UpdateABC mocks base method.
+func (m *MockProductRepository) UpdateABC(ctx context.Context, results map[primitive.ObjectID]models.ProductABC) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateABC", ctx, results)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateABC indicates an expected call of UpdateABC.
+func (mr *MockProductRepositoryMockRecorder) UpdateABC(ctx, results interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateABC", reflect.TypeOf((*MockProductRepository)(nil).UpdateABC), ctx, results)
+}
+
 // MockDeliveryRepository is a mock of DeliveryRepository interface.
 type MockDeliveryRepository struct {
 	ctrl     *gomock.Controller
@@ -164,17 +178,17 @@ func (m *MockDeliveryRepository) EXPECT() *MockDeliveryRepositoryMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockDeliveryRepository) Create(ctx context.Context, delivery *models.Delivery) error {
+func (m *MockDeliveryRepository) Create(ctx context.Context, delivery *models.Delivery, actor string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, delivery)
+	ret := m.ctrl.Call(m, "Create", ctx, delivery, actor)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockDeliveryRepositoryMockRecorder) Create(ctx, delivery interface{}) *gomock.Call {
+func (mr *MockDeliveryRepositoryMockRecorder) Create(ctx, delivery, actor interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDeliveryRepository)(nil).Create), ctx, delivery)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDeliveryRepository)(nil).Create), ctx, delivery, actor)
 }
 
 // GetActiveDeliveries mocks base method.
@@ -207,6 +221,48 @@ func (mr *MockDeliveryRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockDeliveryRepository)(nil).GetByID), ctx, id)
 }
 
+// RecordPINAttempt mocks base method.
+func (m *MockDeliveryRepository) RecordPINAttempt(ctx context.Context, id primitive.ObjectID, maxAttempts int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordPINAttempt", ctx, id, maxAttempts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordPINAttempt indicates an expected call of RecordPINAttempt.
+func (mr *MockDeliveryRepositoryMockRecorder) RecordPINAttempt(ctx, id, maxAttempts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordPINAttempt", reflect.TypeOf((*MockDeliveryRepository)(nil).RecordPINAttempt), ctx, id, maxAttempts)
+}
+
+// AssignRobot mocks base method.
+func (m *MockDeliveryRepository) AssignRobot(ctx context.Context, id primitive.ObjectID, robotID string, assignedAt time.Time, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignRobot", ctx, id, robotID, assignedAt, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignRobot indicates an expected call of AssignRobot.
+func (mr *MockDeliveryRepositoryMockRecorder) AssignRobot(ctx, id, robotID, assignedAt, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRobot", reflect.TypeOf((*MockDeliveryRepository)(nil).AssignRobot), ctx, id, robotID, assignedAt, actor)
+}
+
+// WithdrawRobot mocks base method.
+func (m *MockDeliveryRepository) WithdrawRobot(ctx context.Context, robot *models.Robot, deliveryID, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithdrawRobot", ctx, robot, deliveryID, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithdrawRobot indicates an expected call of WithdrawRobot.
+func (mr *MockDeliveryRepositoryMockRecorder) WithdrawRobot(ctx, robot, deliveryID, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithdrawRobot", reflect.TypeOf((*MockDeliveryRepository)(nil).WithdrawRobot), ctx, robot, deliveryID, actor)
+}
+
 // GetDeliveriesByRobot mocks base method.
 func (m *MockDeliveryRepository) GetDeliveriesByRobot(ctx context.Context, robotID string) ([]*models.Delivery, error) {
 	m.ctrl.T.Helper()
@@ -238,31 +294,75 @@ func (mr *MockDeliveryRepositoryMockRecorder) List(ctx, skip, limit interface{})
 }
 
 // UpdateLocation mocks base method.
-func (m *MockDeliveryRepository) UpdateLocation(ctx context.Context, id primitive.ObjectID, location models.Location) error {
+func (m *MockDeliveryRepository) UpdateLocation(ctx context.Context, id primitive.ObjectID, tracking models.TrackingInfo, actor string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateLocation", ctx, id, location)
+	ret := m.ctrl.Call(m, "UpdateLocation", ctx, id, tracking, actor)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateLocation indicates an expected call of UpdateLocation.
-func (mr *MockDeliveryRepositoryMockRecorder) UpdateLocation(ctx, id, location interface{}) *gomock.Call {
+func (mr *MockDeliveryRepositoryMockRecorder) UpdateLocation(ctx, id, tracking, actor interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLocation", reflect.TypeOf((*MockDeliveryRepository)(nil).UpdateLocation), ctx, id, location)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLocation", reflect.TypeOf((*MockDeliveryRepository)(nil).UpdateLocation), ctx, id, tracking, actor)
 }
 
 // UpdateStatus mocks base method.
-func (m *MockDeliveryRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status models.DeliveryStatus) error {
+func (m *MockDeliveryRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, update models.DeliveryStatusUpdate, actor string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateStatus", ctx, id, status)
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, id, update, actor)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateStatus indicates an expected call of UpdateStatus.
-func (mr *MockDeliveryRepositoryMockRecorder) UpdateStatus(ctx, id, status interface{}) *gomock.Call {
+func (mr *MockDeliveryRepositoryMockRecorder) UpdateStatus(ctx, id, update, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockDeliveryRepository)(nil).UpdateStatus), ctx, id, update, actor)
+}
+
+// UpdatePredictedArrival mocks base method.
+func (m *MockDeliveryRepository) UpdatePredictedArrival(ctx context.Context, id primitive.ObjectID, predictedArrival time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePredictedArrival", ctx, id, predictedArrival)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePredictedArrival indicates an expected call of UpdatePredictedArrival.
+func (mr *MockDeliveryRepositoryMockRecorder) UpdatePredictedArrival(ctx, id, predictedArrival interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePredictedArrival", reflect.TypeOf((*MockDeliveryRepository)(nil).UpdatePredictedArrival), ctx, id, predictedArrival)
+}
+
+// GetCompletedDeliveries mocks base method.
+func (m *MockDeliveryRepository) GetCompletedDeliveries(ctx context.Context, from, to time.Time) ([]*models.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCompletedDeliveries", ctx, from, to)
+	ret0, _ := ret[0].([]*models.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCompletedDeliveries indicates an expected call of GetCompletedDeliveries.
+func (mr *MockDeliveryRepositoryMockRecorder) GetCompletedDeliveries(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompletedDeliveries", reflect.TypeOf((*MockDeliveryRepository)(nil).GetCompletedDeliveries), ctx, from, to)
+}
+
+// GetByIDs mocks base method.
+func (m *MockDeliveryRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", ctx, ids)
+	ret0, _ := ret[0].([]*models.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockDeliveryRepositoryMockRecorder) GetByIDs(ctx, ids interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockDeliveryRepository)(nil).UpdateStatus), ctx, id, status)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockDeliveryRepository)(nil).GetByIDs), ctx, ids)
 }
 
 // MockSaleRepository is a mock of SaleRepository interface.
@@ -347,11 +447,70 @@ func (mr *MockSaleRepositoryMockRecorder) GetEnvironmentalImpactAnalytics(ctx, s
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEnvironmentalImpactAnalytics", reflect.TypeOf((*MockSaleRepository)(nil).GetEnvironmentalImpactAnalytics), ctx, start, end)
 }
 
+// CreateMany mocks base method.
+func (m *MockSaleRepository) CreateMany(ctx context.Context, sales []*models.Sale) ([]error, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMany", ctx, sales)
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMany indicates an expected call of CreateMany.
+func (mr *MockSaleRepositoryMockRecorder) CreateMany(ctx, sales interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMany", reflect.TypeOf((*MockSaleRepository)(nil).CreateMany), ctx, sales)
+}
+
+// FindSales mocks base method.
+func (m *MockSaleRepository) FindSales(ctx context.Context, query models.SaleQuery) ([]*models.Sale, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSales", ctx, query)
+	ret0, _ := ret[0].([]*models.Sale)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSales indicates an expected call of FindSales.
+func (mr *MockSaleRepositoryMockRecorder) FindSales(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSales", reflect.TypeOf((*MockSaleRepository)(nil).FindSales), ctx, query)
+}
+
+// StreamSales mocks base method.
+func (m *MockSaleRepository) StreamSales(ctx context.Context, query models.SaleQuery, fn func(*models.Sale) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamSales", ctx, query, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamSales indicates an expected call of StreamSales.
+func (mr *MockSaleRepositoryMockRecorder) StreamSales(ctx, query, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamSales", reflect.TypeOf((*MockSaleRepository)(nil).StreamSales), ctx, query, fn)
+}
+
+// GetSalesByMember mocks base method.
+func (m *MockSaleRepository) GetSalesByMember(ctx context.Context, memberID primitive.ObjectID, skip, limit int64) ([]*models.Sale, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSalesByMember", ctx, memberID, skip, limit)
+	ret0, _ := ret[0].([]*models.Sale)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSalesByMember indicates an expected call of GetSalesByMember.
+func (mr *MockSaleRepositoryMockRecorder) GetSalesByMember(ctx, memberID, skip, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSalesByMember", reflect.TypeOf((*MockSaleRepository)(nil).GetSalesByMember), ctx, memberID, skip, limit)
+}
+
 // GetSalesByCategory mocks base method.
-func (m *MockSaleRepository) GetSalesByCategory(ctx context.Context, start, end time.Time) (map[string]int, error) {
+func (m *MockSaleRepository) GetSalesByCategory(ctx context.Context, start, end time.Time) ([]*models.CategorySales, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSalesByCategory", ctx, start, end)
-	ret0, _ := ret[0].(map[string]int)
+	ret0, _ := ret[0].([]*models.CategorySales)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -362,6 +521,51 @@ func (mr *MockSaleRepositoryMockRecorder) GetSalesByCategory(ctx, start, end int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSalesByCategory", reflect.TypeOf((*MockSaleRepository)(nil).GetSalesByCategory), ctx, start, end)
 }
 
+// GetSalesByProduct mocks base method.
+func (m *MockSaleRepository) GetSalesByProduct(ctx context.Context, query models.SaleQuery) ([]*models.ProductSales, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSalesByProduct", ctx, query)
+	ret0, _ := ret[0].([]*models.ProductSales)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSalesByProduct indicates an expected call of GetSalesByProduct.
+func (mr *MockSaleRepositoryMockRecorder) GetSalesByProduct(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSalesByProduct", reflect.TypeOf((*MockSaleRepository)(nil).GetSalesByProduct), ctx, query)
+}
+
+// GetHourlySales mocks base method.
+func (m *MockSaleRepository) GetHourlySales(ctx context.Context, query models.SaleQuery, timezone string) ([]*models.HourlySales, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHourlySales", ctx, query, timezone)
+	ret0, _ := ret[0].([]*models.HourlySales)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHourlySales indicates an expected call of GetHourlySales.
+func (mr *MockSaleRepositoryMockRecorder) GetHourlySales(ctx, query, timezone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHourlySales", reflect.TypeOf((*MockSaleRepository)(nil).GetHourlySales), ctx, query, timezone)
+}
+
+// GetDailyRevenue mocks base method.
+func (m *MockSaleRepository) GetDailyRevenue(ctx context.Context, query models.SaleQuery, timezone string) ([]*models.DailyRevenue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDailyRevenue", ctx, query, timezone)
+	ret0, _ := ret[0].([]*models.DailyRevenue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDailyRevenue indicates an expected call of GetDailyRevenue.
+func (mr *MockSaleRepositoryMockRecorder) GetDailyRevenue(ctx, query, timezone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDailyRevenue", reflect.TypeOf((*MockSaleRepository)(nil).GetDailyRevenue), ctx, query, timezone)
+}
+
 // GetSalesByDateRange mocks base method.
 func (m *MockSaleRepository) GetSalesByDateRange(ctx context.Context, start, end time.Time) ([]*models.Sale, error) {
 	m.ctrl.T.Helper()
@@ -393,18 +597,18 @@ func (mr *MockSaleRepositoryMockRecorder) GetSalesByTimeOfDay(ctx, timeOfDay int
 }
 
 // GetTotalSalesAmount mocks base method.
-func (m *MockSaleRepository) GetTotalSalesAmount(ctx context.Context, start, end time.Time) (float64, error) {
+func (m *MockSaleRepository) GetTotalSalesAmount(ctx context.Context, query models.SaleQuery, category string) (float64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTotalSalesAmount", ctx, start, end)
+	ret := m.ctrl.Call(m, "GetTotalSalesAmount", ctx, query, category)
 	ret0, _ := ret[0].(float64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetTotalSalesAmount indicates an expected call of GetTotalSalesAmount.
-func (mr *MockSaleRepositoryMockRecorder) GetTotalSalesAmount(ctx, start, end interface{}) *gomock.Call {
+func (mr *MockSaleRepositoryMockRecorder) GetTotalSalesAmount(ctx, query, category interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalSalesAmount", reflect.TypeOf((*MockSaleRepository)(nil).GetTotalSalesAmount), ctx, start, end)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalSalesAmount", reflect.TypeOf((*MockSaleRepository)(nil).GetTotalSalesAmount), ctx, query, category)
 }
 
 // MockStoreOperationRepository is a mock of StoreOperationRepository interface.
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// PointTransactionRepositoryImpl はポイント台帳リポジトリの実装です
+type PointTransactionRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ PointTransactionRepository = (*PointTransactionRepositoryImpl)(nil)
+
+func NewPointTransactionRepository(db *mongo.Database) PointTransactionRepository {
+	return &PointTransactionRepositoryImpl{
+		collection: db.Collection("point_transactions"),
+	}
+}
+
+// Create はポイント台帳に1件追加します
+func (r *PointTransactionRepositoryImpl) Create(ctx context.Context, tx *models.PointTransaction) error {
+	tx.CreatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	tx.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// ListByMember は会員のポイント履歴を新しい順に取得します
+func (r *PointTransactionRepositoryImpl) ListByMember(ctx context.Context, memberID primitive.ObjectID, skip, limit int64) ([]*models.PointTransaction, error) {
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"member_id": memberID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var txs []*models.PointTransaction
+	if err = cursor.All(ctx, &txs); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
@@ -50,10 +50,21 @@ func (r *ProductRepositoryImpl) GetByID(ctx context.Context, id primitive.Object
 	return &product, nil
 }
 
-// List は商品のリストを取得します
-func (r *ProductRepositoryImpl) List(ctx context.Context, skip, limit int64) ([]*models.Product, error) {
+// List は商品のリストを取得します（limit が0の場合は全件）
+func (r *ProductRepositoryImpl) List(ctx context.Context, query models.ProductQuery, skip, limit int64) ([]*models.Product, error) {
+	filter := bson.M{}
+	if query.Category != "" {
+		filter["category"] = query.Category
+	}
+	if query.ABCClass != "" {
+		filter["abc.class"] = query.ABCClass
+	}
+	if query.ZeroSales != nil {
+		filter["abc.zero_sales"] = *query.ZeroSales
+	}
+
 	opts := options.Find().SetSkip(skip).SetLimit(limit)
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +88,22 @@ func (r *ProductRepositoryImpl) Update(ctx context.Context, product *models.Prod
 	return err
 }
 
+// UpdateABC はABC分析の結果を商品ごとに保存します
+func (r *ProductRepositoryImpl) UpdateABC(ctx context.Context, results map[primitive.ObjectID]models.ProductABC) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(results))
+	for id, abc := range results {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{"abc": abc}}))
+	}
+	_, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
 // Delete は商品を削除します
 func (r *ProductRepositoryImpl) Delete(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// ErrRegisterSessionAlreadyOpen はレジに未締めのセッションが既に存在する場合のエラーです
+var ErrRegisterSessionAlreadyOpen = errors.New("register session already open")
+
+// ErrRegisterSessionNotOpen は締め対象のセッションが開局中でない場合のエラーです
+var ErrRegisterSessionNotOpen = errors.New("register session is not open")
+
+// RegisterSessionRepositoryImpl はレジ精算セッションリポジトリの実装です
+type RegisterSessionRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ RegisterSessionRepository = (*RegisterSessionRepositoryImpl)(nil)
+
+func NewRegisterSessionRepository(db *mongo.Database) RegisterSessionRepository {
+	return &RegisterSessionRepositoryImpl{
+		collection: db.Collection("register_sessions"),
+	}
+}
+
+// Create は新しいセッションを開局します
+// 同じレジに開局中のセッションがある場合は一意インデックスによりエラーになります
+func (r *RegisterSessionRepositoryImpl) Create(ctx context.Context, session *models.RegisterSession) error {
+	session.CreatedAt = time.Now()
+	session.UpdatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, session)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrRegisterSessionAlreadyOpen
+		}
+		return err
+	}
+
+	session.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID は指定されたIDのセッションを取得します（存在しない場合はnil）
+func (r *RegisterSessionRepositoryImpl) GetByID(ctx context.Context, id primitive.ObjectID) (*models.RegisterSession, error) {
+	var session models.RegisterSession
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetOpenSession はレジの開局中のセッションを取得します（存在しない場合はnil）
+func (r *RegisterSessionRepositoryImpl) GetOpenSession(ctx context.Context, storeID, registerID string) (*models.RegisterSession, error) {
+	filter := bson.M{
+		"store_id":    storeID,
+		"register_id": registerID,
+		"status":      models.RegisterSessionOpen,
+	}
+
+	var session models.RegisterSession
+	err := r.collection.FindOne(ctx, filter).Decode(&session)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Close はセッションを締め、精算結果を保存します
+// 開局中のセッションのみ更新するため、同時に締め処理が行われても二重に確定しません
+func (r *RegisterSessionRepositoryImpl) Close(ctx context.Context, session *models.RegisterSession) error {
+	session.UpdatedAt = time.Now()
+
+	filter := bson.M{
+		"_id":    session.ID,
+		"status": models.RegisterSessionOpen,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":             models.RegisterSessionClosed,
+			"closed_by":          session.ClosedBy,
+			"closed_at":          session.ClosedAt,
+			"counted_cash":       session.CountedCash,
+			"sale_count":         session.SaleCount,
+			"takings":            session.Takings,
+			"expected_cash":      session.ExpectedCash,
+			"counted_cash_total": session.CountedCashTotal,
+			"cash_over_short":    session.CashOverShort,
+			"updated_at":         session.UpdatedAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrRegisterSessionNotOpen
+	}
+	session.Status = models.RegisterSessionClosed
+	return nil
+}
+
+// ListByPeriod は指定期間に開局したセッションを取得します
+// 店舗IDが空の場合は全店舗、レジIDが空の場合は店舗の全レジを対象にします
+func (r *RegisterSessionRepositoryImpl) ListByPeriod(ctx context.Context, storeID, registerID string, start, end time.Time) ([]*models.RegisterSession, error) {
+	filter := bson.M{
+		"opened_at": bson.M{
+			"$gte": start,
+			"$lt":  end,
+		},
+	}
+	if storeID != "" {
+		filter["store_id"] = storeID
+	}
+	if registerID != "" {
+		filter["register_id"] = registerID
+	}
+
+	opts := options.Find().SetSort(bson.M{"opened_at": 1})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []*models.RegisterSession
+	if err = cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
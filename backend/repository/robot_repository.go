@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+var (
+	// ErrRobotStatusChanged は更新中に配送機体の稼働状態（割り当て中の配送を含む）が変わっていた場合のエラーです
+	ErrRobotStatusChanged = errors.New("robot status has changed")
+)
+
+// RobotRepositoryImpl は配送ロボット・ドローンリポジトリの実装です
+type RobotRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ RobotRepository = (*RobotRepositoryImpl)(nil)
+
+func NewRobotRepository(db *mongo.Database) RobotRepository {
+	return &RobotRepositoryImpl{
+		collection: db.Collection("robots"),
+	}
+}
+
+// Create は配送機体を登録します
+func (r *RobotRepositoryImpl) Create(ctx context.Context, robot *models.Robot) error {
+	now := time.Now()
+	robot.CreatedAt = now
+	robot.UpdatedAt = now
+
+	result, err := r.collection.InsertOne(ctx, robot)
+	if err != nil {
+		return err
+	}
+	robot.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID は指定されたIDの配送機体を取得します（存在しない場合はnil）
+func (r *RobotRepositoryImpl) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Robot, error) {
+	var robot models.Robot
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&robot)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &robot, nil
+}
+
+// List は条件に合う配送機体を名前順に取得します
+func (r *RobotRepositoryImpl) List(ctx context.Context, query models.RobotQuery) ([]*models.Robot, error) {
+	filter := bson.M{}
+	if query.Type != "" {
+		filter["type"] = query.Type
+	}
+	if query.Status != "" {
+		filter["status"] = query.Status
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	robots := []*models.Robot{}
+	if err = cursor.All(ctx, &robots); err != nil {
+		return nil, err
+	}
+	return robots, nil
+}
+
+// Update は配送機体の仕様・状態を更新します（更新した場合はtrue）
+// 稼働状態が読み込んだ時点の from から変わっていた場合は更新せず ErrRobotStatusChanged を返します
+func (r *RobotRepositoryImpl) Update(ctx context.Context, robot *models.Robot, from models.RobotStatus) (bool, error) {
+	robot.UpdatedAt = time.Now()
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": robot.ID, "status": from}, robotUpdate(robot))
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount > 0 {
+		return true, nil
+	}
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": robot.ID})
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, ErrRobotStatusChanged
+	}
+	return false, nil
+}
+
+// robotUpdate は配送機体の仕様・状態を robot の内容に更新する更新内容を返します
+// 割り当て中以外の状態にする場合は、担当していた配送の記録も外します
+func robotUpdate(robot *models.Robot) bson.M {
+	update := bson.M{
+		"$set": bson.M{
+			"name":             robot.Name,
+			"type":             robot.Type,
+			"payload_capacity": robot.PayloadCapacity,
+			"range":            robot.Range,
+			"battery_level":    robot.BatteryLevel,
+			"battery_capacity": robot.BatteryCapacity,
+			"home_base":        robot.HomeBase,
+			"status":           robot.Status,
+			"updated_at":       robot.UpdatedAt,
+		},
+	}
+	if robot.Status != models.RobotAssigned {
+		update["$unset"] = bson.M{"current_delivery_id": ""}
+	}
+	return update
+}
+
+// Assign は待機中の機体を配送に割り当てます
+// 他の配送に先に割り当てられた場合など、機体が待機中でなくなっていた場合はfalseを返します
+func (r *RobotRepositoryImpl) Assign(ctx context.Context, id primitive.ObjectID, deliveryID string) (bool, error) {
+	filter := bson.M{
+		"_id":    id,
+		"status": models.RobotIdle,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":              models.RobotAssigned,
+			"current_delivery_id": deliveryID,
+			"updated_at":          time.Now(),
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// Release は配送の割り当てを解除し、機体の状態を更新します
+func (r *RobotRepositoryImpl) Release(ctx context.Context, id primitive.ObjectID, status models.RobotStatus) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"updated_at": time.Now(),
+		},
+		"$unset": bson.M{"current_delivery_id": ""},
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// Delete は配送機体を削除します（削除した場合はtrue）
+func (r *RobotRepositoryImpl) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// UpdateTelemetry は機体から最後に受信した計測値を更新します
+// 既に保存している計測値より古い場合は更新しません。バッテリー残量を含む場合は機体のバッテリー残量も更新します
+func (r *RobotRepositoryImpl) UpdateTelemetry(ctx context.Context, id primitive.ObjectID, telemetry models.RobotTelemetry) error {
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"telemetry.timestamp": bson.M{"$lt": telemetry.Timestamp}},
+			bson.M{"telemetry": bson.M{"$exists": false}},
+		},
+	}
+	set := bson.M{
+		"telemetry":  telemetry,
+		"updated_at": time.Now(),
+	}
+	if telemetry.BatteryLevel != nil {
+		set["battery_level"] = *telemetry.BatteryLevel
+	}
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	return err
+}
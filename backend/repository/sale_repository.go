@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,6 +13,12 @@ import (
 	"github.com/onoderaryou/smart-store-admin/backend/models"
 )
 
+// UncategorizedLabel はカテゴリが特定できない売上の集計キーです
+const UncategorizedLabel = "未分類"
+
+// ErrDuplicateTerminalTransaction は同じ端末取引IDの売上が既に記録されている場合のエラーです
+var ErrDuplicateTerminalTransaction = errors.New("terminal transaction already recorded")
+
 // SaleRepositoryImpl は売上リポジトリの実装です
 type SaleRepositoryImpl struct {
 	collection *mongo.Collection
@@ -32,6 +39,9 @@ func (r *SaleRepositoryImpl) Create(ctx context.Context, sale *models.Sale) erro
 
 	result, err := r.collection.InsertOne(ctx, sale)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateTerminalTransaction
+		}
 		return err
 	}
 
@@ -39,6 +49,45 @@ func (r *SaleRepositoryImpl) Create(ctx context.Context, sale *models.Sale) erro
 	return nil
 }
 
+// CreateMany は複数の売上を一括で記録します
+// 売上ごとのエラーを返し、記録できた売上には影響しません
+// CreatedAt が設定されている売上は元の日時を保持します
+func (r *SaleRepositoryImpl) CreateMany(ctx context.Context, sales []*models.Sale) ([]error, error) {
+	errs := make([]error, len(sales))
+	if len(sales) == 0 {
+		return errs, nil
+	}
+
+	docs := make([]interface{}, len(sales))
+	for i, sale := range sales {
+		if sale.ID.IsZero() {
+			sale.ID = primitive.NewObjectID()
+		}
+		if sale.CreatedAt.IsZero() {
+			sale.CreatedAt = time.Now()
+		}
+		docs[i] = sale
+	}
+
+	_, err := r.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return errs, nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return nil, err
+	}
+	for _, we := range bulkErr.WriteErrors {
+		if mongo.IsDuplicateKeyError(we) {
+			errs[we.Index] = ErrDuplicateTerminalTransaction
+		} else {
+			errs[we.Index] = we
+		}
+	}
+	return errs, nil
+}
+
 // GetByID は指定されたIDの売上を取得します
 func (r *SaleRepositoryImpl) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Sale, error) {
 	var sale models.Sale
@@ -112,25 +161,148 @@ func (r *SaleRepositoryImpl) GetSalesByDateRange(ctx context.Context, start, end
 	return sales, nil
 }
 
+// FindSales は店舗・レジ・期間を指定して売上を取得します
+// 店舗IDとレジIDは空の場合は条件に含めません
+func (r *SaleRepositoryImpl) FindSales(ctx context.Context, query models.SaleQuery) ([]*models.Sale, error) {
+	filter := bson.M{
+		"created_at": bson.M{
+			"$gte": query.Start,
+			"$lt":  query.End,
+		},
+	}
+	if query.StoreID != "" {
+		filter["store_id"] = query.StoreID
+	}
+	if query.RegisterID != "" {
+		filter["register_id"] = query.RegisterID
+	}
+
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sales []*models.Sale
+	if err = cursor.All(ctx, &sales); err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
+
+// StreamSales は条件に合う売上を古い順に1件ずつ fn に渡します
+// 全件をメモリに読み込まないため、大量の売上のエクスポートに使います。fn がエラーを返すと中断します
+func (r *SaleRepositoryImpl) StreamSales(ctx context.Context, query models.SaleQuery, fn func(*models.Sale) error) error {
+	filter := bson.M{
+		"created_at": bson.M{
+			"$gte": query.Start,
+			"$lt":  query.End,
+		},
+	}
+	if query.StoreID != "" {
+		filter["store_id"] = query.StoreID
+	}
+	if query.RegisterID != "" {
+		filter["register_id"] = query.RegisterID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var sale models.Sale
+		if err := cursor.Decode(&sale); err != nil {
+			return err
+		}
+		if err := fn(&sale); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// GetSalesByMember は会員の購入履歴を新しい順に取得します
+func (r *SaleRepositoryImpl) GetSalesByMember(ctx context.Context, memberID primitive.ObjectID, skip, limit int64) ([]*models.Sale, error) {
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"member_id": memberID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sales []*models.Sale
+	if err = cursor.All(ctx, &sales); err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
+
 // GetTotalSalesAmount は指定期間の総売上金額を取得します
-func (r *SaleRepositoryImpl) GetTotalSalesAmount(ctx context.Context, start, end time.Time) (float64, error) {
+// category を指定した場合は、そのカテゴリの明細金額（値引前）の合計を返します
+// カテゴリは販売時のスナップショットを優先し、未設定の場合は商品マスタから補完します
+func (r *SaleRepositoryImpl) GetTotalSalesAmount(ctx context.Context, query models.SaleQuery, category string) (float64, error) {
+	match := bson.M{
+		"created_at": bson.M{
+			"$gte": query.Start,
+			"$lt":  query.End,
+		},
+	}
+	if query.StoreID != "" {
+		match["store_id"] = query.StoreID
+	}
+	if query.RegisterID != "" {
+		match["register_id"] = query.RegisterID
+	}
+
 	pipeline := mongo.Pipeline{
 		bson.D{
-			primitive.E{Key: "$match", Value: bson.M{
-				"created_at": bson.M{
-					"$gte": start,
-					"$lt":  end,
-				},
-			}},
+			primitive.E{Key: "$match", Value: match},
 		},
-		bson.D{
+	}
+	if category == "" {
+		pipeline = append(pipeline, bson.D{
 			primitive.E{Key: "$group", Value: bson.M{
 				"_id": nil,
 				"total": bson.M{
 					"$sum": "$total_amount",
 				},
 			}},
-		},
+		})
+	} else {
+		pipeline = append(pipeline,
+			bson.D{{Key: "$unwind", Value: "$items"}},
+			bson.D{{Key: "$lookup", Value: bson.M{
+				"from":         "products",
+				"localField":   "items.product_id",
+				"foreignField": "_id",
+				"as":           "product",
+			}}},
+			bson.D{{Key: "$unwind", Value: bson.M{
+				"path":                       "$product",
+				"preserveNullAndEmptyArrays": true,
+			}}},
+			bson.D{{Key: "$match", Value: bson.M{
+				"$expr": bson.M{"$eq": bson.A{
+					bson.M{"$ifNull": bson.A{"$items.category", "$product.category"}},
+					category,
+				}},
+			}}},
+			bson.D{{Key: "$group", Value: bson.M{
+				"_id": nil,
+				"total": bson.M{"$sum": bson.M{
+					"$multiply": bson.A{"$items.quantity", "$items.price_at_sale"},
+				}},
+			}}},
+		)
 	}
 
 	cursor, err := r.collection.Aggregate(ctx, pipeline)
@@ -174,15 +346,33 @@ func (r *SaleRepositoryImpl) GetEnvironmentalImpactAnalytics(ctx context.Context
 	return impact, nil
 }
 
-// GetSalesByCategory はカテゴリー別の売上数を取得します
-func (r *SaleRepositoryImpl) GetSalesByCategory(ctx context.Context, start, end time.Time) (map[string]int, error) {
+// GetSalesByCategory はカテゴリー別の販売数と売上金額を取得します
+// カテゴリは販売時のスナップショットを優先し、未設定の場合は商品マスタから補完します
+func (r *SaleRepositoryImpl) GetSalesByCategory(ctx context.Context, start, end time.Time) ([]*models.CategorySales, error) {
 	pipeline := mongo.Pipeline{
 		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": start, "$lt": end}}}},
 		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "products",
+			"localField":   "items.product_id",
+			"foreignField": "_id",
+			"as":           "product",
+		}}},
+		{{Key: "$unwind", Value: bson.M{
+			"path":                       "$product",
+			"preserveNullAndEmptyArrays": true,
+		}}},
 		{{Key: "$group", Value: bson.M{
-			"_id":   "$items.category",
-			"count": bson.M{"$sum": "$items.quantity"},
+			"_id": bson.M{"$ifNull": bson.A{
+				"$items.category",
+				bson.M{"$ifNull": bson.A{"$product.category", UncategorizedLabel}},
+			}},
+			"units": bson.M{"$sum": "$items.quantity"},
+			"revenue": bson.M{"$sum": bson.M{
+				"$multiply": bson.A{"$items.quantity", "$items.price_at_sale"},
+			}},
 		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "revenue", Value: -1}, {Key: "_id", Value: 1}}}},
 	}
 
 	cursor, err := r.collection.Aggregate(ctx, pipeline)
@@ -191,17 +381,225 @@ func (r *SaleRepositoryImpl) GetSalesByCategory(ctx context.Context, start, end
 	}
 	defer cursor.Close(ctx)
 
-	result := make(map[string]int)
-	for cursor.Next(ctx) {
-		var item struct {
-			Category string `bson:"_id"`
-			Count    int    `bson:"count"`
-		}
-		if err := cursor.Decode(&item); err != nil {
-			return nil, err
-		}
-		result[item.Category] = item.Count
+	var result []*models.CategorySales
+	if err = cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetSalesByProduct は商品別の販売数量・売上金額・値引額・原価を集計します
+// 原価・税区分は販売時のスナップショットを優先し、未設定の場合は商品マスタから補完します
+// 売上金額・値引額は税区分ごとの内訳も集計します
+// 値引額は値引の対象となる明細（商品への値引きは対象商品、売上全体への値引きは対象の税区分の明細）に金額の比率で按分します
+func (r *SaleRepositoryImpl) GetSalesByProduct(ctx context.Context, query models.SaleQuery) ([]*models.ProductSales, error) {
+	match := bson.M{
+		"created_at": bson.M{
+			"$gte": query.Start,
+			"$lt":  query.End,
+		},
+	}
+	if query.StoreID != "" {
+		match["store_id"] = query.StoreID
+	}
+	if query.RegisterID != "" {
+		match["register_id"] = query.RegisterID
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		// 値引の按分に売上全体の明細を使うため、展開前の明細を残す
+		{{Key: "$addFields", Value: bson.M{"basket": "$items"}}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$addFields", Value: bson.M{"item_discount": itemDiscountExpr()}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "products",
+			"localField":   "items.product_id",
+			"foreignField": "_id",
+			"as":           "product",
+		}}},
+		{{Key: "$unwind", Value: bson.M{
+			"path":                       "$product",
+			"preserveNullAndEmptyArrays": true,
+		}}},
+		// 税区分は販売時のスナップショットを優先し、未設定の場合は商品マスタから補完します
+		{{Key: "$addFields", Value: bson.M{"tax_class": bson.M{"$ifNull": bson.A{
+			"$items.tax_class",
+			bson.M{"$ifNull": bson.A{"$product.tax_class", ""}},
+		}}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"product_id": "$items.product_id",
+				"tax_class":  "$tax_class",
+			},
+			"units": bson.M{"$sum": "$items.quantity"},
+			"revenue": bson.M{"$sum": bson.M{
+				"$multiply": bson.A{"$items.quantity", "$items.price_at_sale"},
+			}},
+			"discount": bson.M{"$sum": "$item_discount"},
+			"cost": bson.M{"$sum": bson.M{
+				"$multiply": bson.A{"$items.quantity", bson.M{"$ifNull": bson.A{
+					"$items.cost_at_sale",
+					bson.M{"$ifNull": bson.A{"$product.cost_price", 0}},
+				}}},
+			}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$_id.product_id",
+			"units":    bson.M{"$sum": "$units"},
+			"revenue":  bson.M{"$sum": "$revenue"},
+			"discount": bson.M{"$sum": "$discount"},
+			"cost":     bson.M{"$sum": "$cost"},
+			"tax_classes": bson.M{"$push": bson.M{
+				"tax_class": "$_id.tax_class",
+				"revenue":   "$revenue",
+				"discount":  "$discount",
+			}},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []*models.ProductSales
+	if err = cursor.All(ctx, &result); err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
+// itemDiscountExpr は展開した明細（$items）に按分される値引額の合計を計算する式を返します
+func itemDiscountExpr() bson.M {
+	lineAmount := func(line string) bson.M {
+		return bson.M{"$multiply": bson.A{line + ".quantity", line + ".price_at_sale"}}
+	}
+	// eligible は明細 line が値引 $$promo の対象かどうかを判定します
+	eligible := func(line string) bson.M {
+		taxClass := bson.M{"$ifNull": bson.A{"$$promo.tax_class", ""}}
+		return bson.M{"$cond": bson.A{
+			bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{"$$promo.product_id", nil}}, nil}},
+			bson.M{"$eq": bson.A{line + ".product_id", "$$promo.product_id"}},
+			bson.M{"$or": bson.A{
+				bson.M{"$eq": bson.A{taxClass, ""}},
+				bson.M{"$eq": bson.A{line + ".tax_class", taxClass}},
+			}},
+		}}
+	}
+
+	return bson.M{"$sum": bson.M{"$map": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$promotions", bson.A{}}},
+		"as":    "promo",
+		"in": bson.M{"$let": bson.M{
+			"vars": bson.M{
+				"base": bson.M{"$sum": bson.M{"$map": bson.M{
+					"input": bson.M{"$filter": bson.M{
+						"input": "$basket",
+						"as":    "line",
+						"cond":  eligible("$$line"),
+					}},
+					"as": "line",
+					"in": lineAmount("$$line"),
+				}}},
+			},
+			"in": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{eligible("$items"), bson.M{"$gt": bson.A{"$$base", 0}}}},
+				bson.M{"$divide": bson.A{
+					bson.M{"$multiply": bson.A{"$$promo.discount", lineAmount("$items")}},
+					"$$base",
+				}},
+				0,
+			}},
+		}},
+	}}}
+}
+
+// GetHourlySales は曜日・時間帯ごとの売上金額と取引件数を集計します
+// 曜日と時間帯は timezone（例: Asia/Tokyo）の現地時刻で区切ります
+func (r *SaleRepositoryImpl) GetHourlySales(ctx context.Context, query models.SaleQuery, timezone string) ([]*models.HourlySales, error) {
+	match := bson.M{
+		"created_at": bson.M{
+			"$gte": query.Start,
+			"$lt":  query.End,
+		},
+	}
+	if query.StoreID != "" {
+		match["store_id"] = query.StoreID
+	}
+	if query.RegisterID != "" {
+		match["register_id"] = query.RegisterID
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"day_of_week": bson.M{"$dayOfWeek": bson.M{"date": "$created_at", "timezone": timezone}},
+				"hour":        bson.M{"$hour": bson.M{"date": "$created_at", "timezone": timezone}},
+			},
+			"revenue":      bson.M{"$sum": "$total_amount"},
+			"transactions": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":          0,
+			"day_of_week":  "$_id.day_of_week",
+			"hour":         "$_id.hour",
+			"revenue":      1,
+			"transactions": 1,
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []*models.HourlySales
+	if err = cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetDailyRevenue は日別（店舗のタイムゾーン）の売上金額と取引件数を日付順に集計します
+// 売上のない日は結果に含まれません
+func (r *SaleRepositoryImpl) GetDailyRevenue(ctx context.Context, query models.SaleQuery, timezone string) ([]*models.DailyRevenue, error) {
+	match := bson.M{
+		"created_at": bson.M{
+			"$gte": query.Start,
+			"$lt":  query.End,
+		},
+	}
+	if query.StoreID != "" {
+		match["store_id"] = query.StoreID
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format":   "%Y-%m-%d",
+				"date":     "$created_at",
+				"timezone": timezone,
+			}},
+			"revenue":      bson.M{"$sum": "$total_amount"},
+			"transactions": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []*models.DailyRevenue
+	if err = cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// SaleReturnRepositoryImpl は取消・返品リポジトリの実装です
+type SaleReturnRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ SaleReturnRepository = (*SaleReturnRepositoryImpl)(nil)
+
+func NewSaleReturnRepository(db *mongo.Database) SaleReturnRepository {
+	return &SaleReturnRepositoryImpl{
+		collection: db.Collection("sale_returns"),
+	}
+}
+
+// Create は取消・返品を記録します
+func (r *SaleReturnRepositoryImpl) Create(ctx context.Context, ret *models.SaleReturn) error {
+	ret.CreatedAt = time.Now()
+
+	result, err := r.collection.InsertOne(ctx, ret)
+	if err != nil {
+		return err
+	}
+
+	ret.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetBySale は売上に対する取消・返品を取得します
+func (r *SaleReturnRepositoryImpl) GetBySale(ctx context.Context, saleID primitive.ObjectID) ([]*models.SaleReturn, error) {
+	return r.find(ctx, bson.M{"sale_id": saleID})
+}
+
+// FindReturns は店舗・レジ・期間を指定して取消・返品を取得します
+// 店舗IDとレジIDは空の場合は条件に含めません
+func (r *SaleReturnRepositoryImpl) FindReturns(ctx context.Context, query models.SaleQuery) ([]*models.SaleReturn, error) {
+	filter := bson.M{
+		"created_at": bson.M{
+			"$gte": query.Start,
+			"$lt":  query.End,
+		},
+	}
+	if query.StoreID != "" {
+		filter["store_id"] = query.StoreID
+	}
+	if query.RegisterID != "" {
+		filter["register_id"] = query.RegisterID
+	}
+	return r.find(ctx, filter)
+}
+
+func (r *SaleReturnRepositoryImpl) find(ctx context.Context, filter bson.M) ([]*models.SaleReturn, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": 1})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var returns []*models.SaleReturn
+	if err = cursor.All(ctx, &returns); err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
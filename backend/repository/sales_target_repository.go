@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// SalesTargetRepositoryImpl は売上目標リポジトリの実装です
+type SalesTargetRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ SalesTargetRepository = (*SalesTargetRepositoryImpl)(nil)
+
+func NewSalesTargetRepository(db *mongo.Database) SalesTargetRepository {
+	return &SalesTargetRepositoryImpl{
+		collection: db.Collection("sales_targets"),
+	}
+}
+
+// Upsert は売上目標を登録します
+// 同じ店舗・カテゴリ・期間の目標が既にある場合は金額を更新します
+func (r *SalesTargetRepositoryImpl) Upsert(ctx context.Context, target *models.SalesTarget) error {
+	now := time.Now()
+	filter := bson.M{
+		"store_id":    target.StoreID,
+		"category":    target.Category,
+		"period_type": target.PeriodType,
+		"period":      target.Period,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"amount":     target.Amount,
+			"created_by": target.CreatedBy,
+			"updated_at": now,
+		},
+		"$setOnInsert": bson.M{
+			"period_start": target.PeriodStart,
+			"period_end":   target.PeriodEnd,
+			"created_at":   now,
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	return r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(target)
+}
+
+// GetByID は指定されたIDの売上目標を取得します（存在しない場合はnil）
+func (r *SalesTargetRepositoryImpl) GetByID(ctx context.Context, id primitive.ObjectID) (*models.SalesTarget, error) {
+	var target models.SalesTarget
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&target)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &target, nil
+}
+
+// List は条件に合う売上目標を期間の古い順に取得します
+func (r *SalesTargetRepositoryImpl) List(ctx context.Context, query models.SalesTargetQuery) ([]*models.SalesTarget, error) {
+	filter := bson.M{}
+	if query.StoreID != "" {
+		filter["store_id"] = query.StoreID
+	}
+	if query.PeriodType != "" {
+		filter["period_type"] = query.PeriodType
+	}
+	if !query.At.IsZero() {
+		filter["period_start"] = bson.M{"$lte": query.At}
+		filter["period_end"] = bson.M{"$gt": query.At}
+	}
+	if !query.Start.IsZero() && !query.End.IsZero() {
+		filter["$and"] = bson.A{
+			bson.M{"period_start": bson.M{"$lt": query.End}},
+			bson.M{"period_end": bson.M{"$gt": query.Start}},
+		}
+	}
+
+	opts := options.Find().SetSort(bson.D{
+		{Key: "period_start", Value: 1},
+		{Key: "period_type", Value: -1},
+		{Key: "category", Value: 1},
+	})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	targets := []*models.SalesTarget{}
+	if err = cursor.All(ctx, &targets); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// Delete は売上目標を削除します（削除した場合はtrue）
+func (r *SalesTargetRepositoryImpl) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
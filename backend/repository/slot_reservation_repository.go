@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// SlotReservationRepositoryImpl は配送時間枠の予約リポジトリの実装です
+type SlotReservationRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ SlotReservationRepository = (*SlotReservationRepositoryImpl)(nil)
+
+func NewSlotReservationRepository(db *mongo.Database) SlotReservationRepository {
+	return &SlotReservationRepositoryImpl{
+		collection: db.Collection("slot_reservations"),
+	}
+}
+
+// Create は予約を登録します
+func (r *SlotReservationRepositoryImpl) Create(ctx context.Context, reservation *models.SlotReservation) error {
+	result, err := r.collection.InsertOne(ctx, reservation)
+	if err != nil {
+		return err
+	}
+	reservation.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID は指定されたIDの予約を取得します（存在しない場合はnil）
+func (r *SlotReservationRepositoryImpl) GetByID(ctx context.Context, id primitive.ObjectID) (*models.SlotReservation, error) {
+	var reservation models.SlotReservation
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&reservation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Confirm は期限内の仮押さえを確定済みにします
+// 仮押さえでない、または期限が切れている場合はfalseを返します
+func (r *SlotReservationRepositoryImpl) Confirm(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":        id,
+		"status":     models.ReservationHeld,
+		"expires_at": bson.M{"$gt": now},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.ReservationConfirmed,
+			"confirmed_at": now,
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// AttachDelivery は確定した予約に配送のIDを記録します
+func (r *SlotReservationRepositoryImpl) AttachDelivery(ctx context.Context, id primitive.ObjectID, deliveryID string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"delivery_id": deliveryID}})
+	return err
+}
+
+// Close は状態が from のいずれかの予約を to（取り消し・期限切れ）にします
+// 他の処理が先に状態を変えていた場合はfalseを返すため、予約件数を二重に戻さずに済みます
+func (r *SlotReservationRepositoryImpl) Close(ctx context.Context, id primitive.ObjectID, from []models.SlotReservationStatus, to models.SlotReservationStatus, now time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":    id,
+		"status": bson.M{"$in": from},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      to,
+			"released_at": now,
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// FindExpired は期限が切れた仮押さえを取得します
+func (r *SlotReservationRepositoryImpl) FindExpired(ctx context.Context, now time.Time) ([]*models.SlotReservation, error) {
+	filter := bson.M{
+		"status":     models.ReservationHeld,
+		"expires_at": bson.M{"$lte": now},
+	}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reservations := []*models.SlotReservation{}
+	if err = cursor.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
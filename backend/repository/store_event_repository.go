@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// StoreEventRepositoryImpl は店舗イベントリポジトリの実装です
+type StoreEventRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ StoreEventRepository = (*StoreEventRepositoryImpl)(nil)
+
+func NewStoreEventRepository(db *mongo.Database) StoreEventRepository {
+	return &StoreEventRepositoryImpl{
+		collection: db.Collection("store_events"),
+	}
+}
+
+// Create は店舗のイベントを登録します
+func (r *StoreEventRepositoryImpl) Create(ctx context.Context, event *models.StoreEvent) error {
+	now := time.Now()
+	event.CreatedAt = now
+	event.UpdatedAt = now
+
+	result, err := r.collection.InsertOne(ctx, event)
+	if err != nil {
+		return err
+	}
+	event.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID は指定されたIDのイベントを取得します（存在しない場合はnil）
+func (r *StoreEventRepositoryImpl) GetByID(ctx context.Context, id primitive.ObjectID) (*models.StoreEvent, error) {
+	var event models.StoreEvent
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&event)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Update はイベントの内容を更新します（更新した場合はtrue）
+func (r *StoreEventRepositoryImpl) Update(ctx context.Context, event *models.StoreEvent) (bool, error) {
+	event.UpdatedAt = time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"store_id":   event.StoreID,
+			"name":       event.Name,
+			"type":       event.Type,
+			"start_date": event.StartDate,
+			"end_date":   event.EndDate,
+			"note":       event.Note,
+			"updated_at": event.UpdatedAt,
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": event.ID}, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// List は条件に合うイベントを開始日の古い順に取得します
+func (r *StoreEventRepositoryImpl) List(ctx context.Context, query models.StoreEventQuery) ([]*models.StoreEvent, error) {
+	filter := bson.M{}
+	if query.StoreID != "" {
+		filter["store_id"] = bson.M{"$in": bson.A{query.StoreID, ""}}
+	}
+	if query.Type != "" {
+		filter["type"] = query.Type
+	}
+	// 日付は 2006-01-02 形式のため文字列の大小で比較できます
+	if query.EndDate != "" {
+		filter["start_date"] = bson.M{"$lte": query.EndDate}
+	}
+	if query.StartDate != "" {
+		filter["end_date"] = bson.M{"$gte": query.StartDate}
+	}
+
+	opts := options.Find().SetSort(bson.D{
+		{Key: "start_date", Value: 1},
+		{Key: "end_date", Value: 1},
+	})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []*models.StoreEvent{}
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Delete はイベントを削除します（削除した場合はtrue）
+func (r *StoreEventRepositoryImpl) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
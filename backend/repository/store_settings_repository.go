@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// StoreSettingsRepositoryImpl は店舗設定リポジトリの実装です
+type StoreSettingsRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ StoreSettingsRepository = (*StoreSettingsRepositoryImpl)(nil)
+
+func NewStoreSettingsRepository(db *mongo.Database) StoreSettingsRepository {
+	return &StoreSettingsRepositoryImpl{
+		collection: db.Collection("store_settings"),
+	}
+}
+
+// GetByStoreID は指定された店舗の設定を取得します（未登録の場合はnil）
+func (r *StoreSettingsRepositoryImpl) GetByStoreID(ctx context.Context, storeID string) (*models.StoreSettings, error) {
+	var settings models.StoreSettings
+	err := r.collection.FindOne(ctx, bson.M{"store_id": storeID}).Decode(&settings)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Upsert は店舗の設定を作成または更新します
+func (r *StoreSettingsRepositoryImpl) Upsert(ctx context.Context, settings *models.StoreSettings) error {
+	now := time.Now()
+	settings.UpdatedAt = now
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":           settings.Name,
+			"address":        settings.Address,
+			"phone":          settings.Phone,
+			"receipt_layout": settings.ReceiptLayout,
+			"updated_at":     now,
+		},
+		"$setOnInsert": bson.M{
+			"created_at": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	return r.collection.FindOneAndUpdate(ctx, bson.M{"store_id": settings.StoreID}, update, opts).Decode(settings)
+}
@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+const (
+	telemetryCollection       = "robot_telemetry"
+	telemetryRollupCollection = "robot_telemetry_minutely"
+	// telemetryRollupLeaseID は集約を実行中のインスタンスを記録するリースのIDです
+	telemetryRollupLeaseID = "telemetry_rollup"
+)
+
+// TelemetryRepositoryImpl は配送機体の計測値リポジトリの実装です
+// 受信した計測値は robot_telemetry、1分ごとに集約した計測値は robot_telemetry_minutely の
+// 時系列コレクションに保存し、それぞれ保存期間を過ぎると MongoDB が自動で削除します
+// 時系列コレクションには一意インデックスを作成できないため、集約は leases のリースを保持した1つのインスタンスだけが行います
+type TelemetryRepositoryImpl struct {
+	db       *mongo.Database
+	readings *mongo.Collection
+	rollups  *mongo.Collection
+	leases   *mongo.Collection
+}
+
+// インターフェースが実装されていることを確認
+var _ TelemetryRepository = (*TelemetryRepositoryImpl)(nil)
+
+func NewTelemetryRepository(db *mongo.Database) TelemetryRepository {
+	return &TelemetryRepositoryImpl{
+		db:       db,
+		readings: db.Collection(telemetryCollection),
+		rollups:  db.Collection(telemetryRollupCollection),
+		leases:   db.Collection("leases"),
+	}
+}
+
+// EnsureCollections は時系列コレクションを作成し、保存期間を設定します
+// 既に存在する場合は保存期間のみ更新します
+func (r *TelemetryRepositoryImpl) EnsureCollections(ctx context.Context, rawRetention, rollupRetention time.Duration) error {
+	if err := r.ensureTimeSeries(ctx, telemetryCollection, "seconds", rawRetention); err != nil {
+		return err
+	}
+	return r.ensureTimeSeries(ctx, telemetryRollupCollection, "minutes", rollupRetention)
+}
+
+func (r *TelemetryRepositoryImpl) ensureTimeSeries(ctx context.Context, name, granularity string, retention time.Duration) error {
+	names, err := r.db.ListCollectionNames(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+	expireAfter := int64(retention / time.Second)
+	if len(names) == 0 {
+		opts := options.CreateCollection().
+			SetTimeSeriesOptions(options.TimeSeries().
+				SetTimeField("timestamp").
+				SetMetaField("robot_id").
+				SetGranularity(granularity)).
+			SetExpireAfterSeconds(expireAfter)
+		if err := r.db.CreateCollection(ctx, name, opts); err != nil {
+			return err
+		}
+	} else {
+		command := bson.D{{Key: "collMod", Value: name}, {Key: "expireAfterSeconds", Value: expireAfter}}
+		if err := r.db.RunCommand(ctx, command).Err(); err != nil {
+			return err
+		}
+	}
+
+	index := mongo.IndexModel{Keys: bson.D{{Key: "robot_id", Value: 1}, {Key: "timestamp", Value: 1}}}
+	_, err = r.db.Collection(name).Indexes().CreateOne(ctx, index)
+	return err
+}
+
+// InsertReadings は受信した計測値を保存します
+func (r *TelemetryRepositoryImpl) InsertReadings(ctx context.Context, readings []models.TelemetryReading) error {
+	if len(readings) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(readings))
+	for i, reading := range readings {
+		docs[i] = reading
+	}
+	_, err := r.readings.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	return err
+}
+
+// FindReadings は機体の指定期間（from 以上 to 未満）の計測値を古い順に最大 limit 件取得します
+func (r *TelemetryRepositoryImpl) FindReadings(ctx context.Context, robotID string, from, to time.Time, limit int64) ([]models.TelemetryReading, error) {
+	filter := bson.M{
+		"robot_id":  robotID,
+		"timestamp": bson.M{"$gte": from, "$lt": to},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}).SetLimit(limit)
+	cursor, err := r.readings.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	readings := []models.TelemetryReading{}
+	if err = cursor.All(ctx, &readings); err != nil {
+		return nil, err
+	}
+	return readings, nil
+}
+
+// AggregateMinutes は指定期間（from 以上 to 未満）の計測値を機体ごと・1分ごとに集約します（robotID が空の場合は全機体）
+func (r *TelemetryRepositoryImpl) AggregateMinutes(ctx context.Context, robotID string, from, to time.Time) ([]models.TelemetryRollup, error) {
+	match := bson.M{"timestamp": bson.M{"$gte": from, "$lt": to}}
+	if robotID != "" {
+		match["robot_id"] = robotID
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sort", Value: bson.D{{Key: "timestamp", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"robot_id": "$robot_id",
+				"minute":   bson.M{"$dateTrunc": bson.M{"date": "$timestamp", "unit": "minute"}},
+			},
+			"location":      bson.M{"$last": "$location"},
+			"battery_level": bson.M{"$last": "$battery_level"},
+			"heading":       bson.M{"$last": "$heading"},
+			"delivery_id":   bson.M{"$last": "$delivery_id"},
+			"speed":         bson.M{"$avg": "$speed"},
+			"max_speed":     bson.M{"$max": "$speed"},
+			"readings":      bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.robot_id", Value: 1}, {Key: "_id.minute", Value: 1}}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":           0,
+			"robot_id":      "$_id.robot_id",
+			"timestamp":     "$_id.minute",
+			"location":      1,
+			"battery_level": 1,
+			"heading":       1,
+			"delivery_id":   1,
+			"speed":         1,
+			"max_speed":     1,
+			"readings":      1,
+		}}},
+	}
+
+	cursor, err := r.readings.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rollups := []models.TelemetryRollup{}
+	if err = cursor.All(ctx, &rollups); err != nil {
+		return nil, err
+	}
+	return rollups, nil
+}
+
+// InsertRollups は1分ごとに集約した計測値を保存します
+func (r *TelemetryRepositoryImpl) InsertRollups(ctx context.Context, rollups []models.TelemetryRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(rollups))
+	for i, rollup := range rollups {
+		docs[i] = rollup
+	}
+	_, err := r.rollups.InsertMany(ctx, docs)
+	return err
+}
+
+// FindRollups は機体の指定期間（from 以上 to 未満）の1分ごとの計測値を古い順に取得します
+func (r *TelemetryRepositoryImpl) FindRollups(ctx context.Context, robotID string, from, to time.Time) ([]models.TelemetryRollup, error) {
+	filter := bson.M{
+		"robot_id":  robotID,
+		"timestamp": bson.M{"$gte": from, "$lt": to},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	cursor, err := r.rollups.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rollups := []models.TelemetryRollup{}
+	if err = cursor.All(ctx, &rollups); err != nil {
+		return nil, err
+	}
+	return rollups, nil
+}
+
+// LatestRollupTime は集約済みの最も新しい1分間の開始日時です（集約していない場合はゼロ値）
+func (r *TelemetryRepositoryImpl) LatestRollupTime(ctx context.Context) (time.Time, error) {
+	var latest models.TelemetryRollup
+	opts := options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}})
+	if err := r.rollups.FindOne(ctx, bson.M{}, opts).Decode(&latest); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return latest.Timestamp, nil
+}
+
+// AverageSpeed は1分ごとに集約した計測値から、機体の指定期間の平均の走行速度（km/h）を求めます
+// 停車中を除くため、平均の速度が minSpeed 以下の1分間は含めません。readings は平均に使った計測値の件数です
+func (r *TelemetryRepositoryImpl) AverageSpeed(ctx context.Context, robotID string, from, to time.Time, minSpeed float64) (float64, int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"robot_id":  robotID,
+			"timestamp": bson.M{"$gte": from, "$lt": to},
+			"speed":     bson.M{"$gt": minSpeed},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      nil,
+			"distance": bson.M{"$sum": bson.M{"$multiply": bson.A{"$speed", "$readings"}}},
+			"readings": bson.M{"$sum": "$readings"},
+		}}},
+	}
+	cursor, err := r.rollups.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Distance float64 `bson:"distance"`
+		Readings int     `bson:"readings"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return 0, 0, err
+	}
+	if len(results) == 0 || results[0].Readings == 0 {
+		return 0, 0, nil
+	}
+	return results[0].Distance / float64(results[0].Readings), results[0].Readings, nil
+}
+
+// StreamRollups は指定期間（from 以上 to 未満）の全機体の1分ごとの計測値を機体ごとに古い順に1件ずつ fn に渡します
+// 全件をメモリに読み込まないため、長い期間の分析に使います。fn がエラーを返すと中断します
+func (r *TelemetryRepositoryImpl) StreamRollups(ctx context.Context, from, to time.Time, fn func(*models.TelemetryRollup) error) error {
+	filter := bson.M{"timestamp": bson.M{"$gte": from, "$lt": to}}
+	opts := options.Find().SetSort(bson.D{{Key: "robot_id", Value: 1}, {Key: "timestamp", Value: 1}})
+	cursor, err := r.rollups.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var rollup models.TelemetryRollup
+		if err := cursor.Decode(&rollup); err != nil {
+			return err
+		}
+		if err := fn(&rollup); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// AcquireRollupLease は集約のリースを holder が取得（保持している場合は延長）し、取得できた場合はtrueを返します
+// 他のインスタンスが期限内のリースを保持している場合はfalseを返します
+func (r *TelemetryRepositoryImpl) AcquireRollupLease(ctx context.Context, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	return acquireLease(ctx, r.leases, telemetryRollupLeaseID, holder, now, ttl)
+}
+
+// ReleaseRollupLease は holder が保持している集約のリースを解放します
+func (r *TelemetryRepositoryImpl) ReleaseRollupLease(ctx context.Context, holder string) error {
+	return releaseLease(ctx, r.leases, telemetryRollupLeaseID, holder)
+}
@@ -11,7 +11,7 @@ import (
 
 func SetupRouter(e *echo.Echo, authHandler *handler.AuthHandler, authConfig *config.AuthConfig) {
 	// ミドルウェアの設定
-	e.Use(middleware.Logger())
+	e.Use(authmw.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 
@@ -49,11 +49,32 @@ func NewRouter(
 	productHandler *handler.ProductHandler,
 	saleHandler *handler.SaleHandler,
 	deliveryHandler *handler.DeliveryHandler,
+	receiptHandler *handler.ReceiptHandler,
+	storeHandler *handler.StoreHandler,
+	registerSessionHandler *handler.RegisterSessionHandler,
+	memberHandler *handler.MemberHandler,
+	saleReturnHandler *handler.SaleReturnHandler,
+	anomalyHandler *handler.AnomalyHandler,
+	exportHandler *handler.ExportHandler,
+	abcHandler *handler.ABCHandler,
+	targetHandler *handler.SalesTargetHandler,
+	forecastHandler *handler.ForecastHandler,
+	calendarHandler *handler.CalendarHandler,
+	fleetHandler *handler.FleetHandler,
+	routeHandler *handler.RouteHandler,
+	trackingHandler *handler.TrackingHandler,
+	telemetryHandler *handler.TelemetryHandler,
+	etaHandler *handler.ETAHandler,
+	energyHandler *handler.EnergyHandler,
+	slotHandler *handler.SlotHandler,
+	idempotency echo.MiddlewareFunc,
+	deliveryIdempotency echo.MiddlewareFunc,
+	streamAuth echo.MiddlewareFunc,
 ) *echo.Echo {
 	e := echo.New()
 
 	// ミドルウェアの設定
-	e.Use(middleware.Logger())
+	e.Use(authmw.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 
@@ -64,24 +85,130 @@ func NewRouter(
 	products := api.Group("/products")
 	products.POST("", productHandler.CreateProduct)
 	products.GET("", productHandler.ListProducts)
+	products.POST("/abc-analysis", abcHandler.Analyze)
 	products.GET("/:id", productHandler.GetProduct)
 	products.PUT("/:id", productHandler.UpdateProduct)
 	products.DELETE("/:id", productHandler.DeleteProduct)
 
 	// 売上関連のエンドポイント
 	sales := api.Group("/sales")
-	sales.POST("", saleHandler.CreateSale)
+	sales.POST("", saleHandler.CreateSale, idempotency)
+	sales.POST("/bulk", saleHandler.ImportSales)
 	sales.GET("/daily", saleHandler.GetDailySales)
 	sales.GET("/range", saleHandler.GetSalesByDateRange)
 	sales.GET("/environmental-impact", saleHandler.GetEnvironmentalImpact)
+	sales.GET("/categories", saleHandler.GetSalesByCategory)
+	sales.GET("/heatmap", saleHandler.GetSalesHeatmap)
+	sales.GET("/margins", saleHandler.GetMarginReport)
+	sales.GET("/day-types", saleHandler.GetDayTypeSales)
+	sales.GET("/export", exportHandler.ExportSales)
+	sales.GET("/:id/receipt", receiptHandler.GetReceipt)
+	sales.POST("/:id/returns", saleReturnHandler.CreateReturn)
+	sales.GET("/:id/returns", saleReturnHandler.GetReturns)
 
 	// 配送関連のエンドポイント
 	deliveries := api.Group("/deliveries")
 	deliveries.GET("", deliveryHandler.GetDeliveries)
+	deliveries.POST("", deliveryHandler.CreateDelivery, deliveryIdempotency)
+	deliveries.GET("/stream", trackingHandler.StreamEvents, streamAuth)
+	deliveries.GET("/stream/ws", trackingHandler.StreamWebSocket, streamAuth)
+	deliveries.POST("/eta/refresh", etaHandler.RefreshETAs)
+	deliveries.GET("/alerts", etaHandler.ListAlerts)
+	deliveries.GET("/on-time-performance", etaHandler.GetOnTimePerformance)
 	deliveries.GET("/:id", deliveryHandler.GetDelivery)
 	deliveries.PATCH("/:id", deliveryHandler.UpdateDelivery)
 	deliveries.PATCH("/:id/status", deliveryHandler.UpdateDeliveryStatus)
+	deliveries.PATCH("/:id/location", deliveryHandler.UpdateDeliveryLocation)
 	deliveries.GET("/:id/history", deliveryHandler.GetDeliveryHistory)
+	deliveries.GET("/:id/eta", etaHandler.GetDeliveryETA)
+	deliveries.POST("/:id/assign", fleetHandler.AssignDelivery)
+	deliveries.POST("/:id/proof", deliveryHandler.SubmitProofOfDelivery)
+	deliveries.GET("/:id/proof/photo", deliveryHandler.GetProofPhoto)
+
+	// 配送時間枠（テンプレート・空き状況・仮押さえ）関連のエンドポイント
+	slots := api.Group("/delivery-slots")
+	slots.GET("", slotHandler.GetAvailability)
+	slots.GET("/templates", slotHandler.ListTemplates)
+	slots.PUT("/templates/:weekday", slotHandler.SetTemplate)
+	slots.POST("/:id/hold", slotHandler.HoldSlot, idempotency)
+	slots.GET("/reservations/:id", slotHandler.GetReservation)
+	slots.DELETE("/reservations/:id", slotHandler.CancelReservation)
+
+	// 配送ロボット・ドローン（フリート）関連のエンドポイント
+	robots := api.Group("/fleet/robots")
+	robots.POST("", fleetHandler.RegisterRobot)
+	robots.GET("", fleetHandler.ListRobots)
+	robots.GET("/:id", fleetHandler.GetRobot)
+	robots.PUT("/:id", fleetHandler.UpdateRobot)
+	robots.DELETE("/:id", fleetHandler.DeleteRobot)
+	robots.GET("/:id/deliveries", fleetHandler.GetRobotDeliveries)
+	robots.POST("/:id/telemetry", telemetryHandler.IngestTelemetry)
+	robots.GET("/:id/telemetry", telemetryHandler.GetTrack)
+
+	// 配送機体の最新の状態（最後に受信した計測値）
+	api.GET("/fleet/telemetry/latest", telemetryHandler.GetFleetState)
+
+	// 配送機体のエネルギー効率レポート（1kmあたりの消費電力量）
+	api.GET("/fleet/energy-efficiency", energyHandler.GetEfficiencyReport)
+
+	// 複数の配送先を巡回する配送ルートの計画
+	api.POST("/fleet/routes", routeHandler.PlanRoutes)
+
+	// 店舗設定関連のエンドポイント
+	stores := api.Group("/stores")
+	stores.GET("/:storeId/settings", storeHandler.GetSettings)
+	stores.PUT("/:storeId/settings", storeHandler.UpdateSettings)
+
+	// レジ精算関連のエンドポイント
+	registerSessions := api.Group("/register-sessions")
+	registerSessions.POST("", registerSessionHandler.OpenSession)
+	registerSessions.GET("", registerSessionHandler.ListSessions)
+	registerSessions.GET("/z-report", registerSessionHandler.GetZReport)
+	registerSessions.GET("/:id", registerSessionHandler.GetSession)
+	registerSessions.POST("/:id/close", registerSessionHandler.CloseSession)
+
+	// 会員・ポイント関連のエンドポイント
+	members := api.Group("/members")
+	members.POST("", memberHandler.CreateMember)
+	members.GET("", memberHandler.ListMembers)
+	members.GET("/:id", memberHandler.GetMember)
+	members.PUT("/:id", memberHandler.UpdateMember)
+	members.GET("/:id/sales", memberHandler.GetPurchaseHistory)
+	members.GET("/:id/points", memberHandler.GetPointLedger)
+	members.POST("/:id/points/adjustments", memberHandler.AdjustPoints)
+
+	// 異常検知関連のエンドポイント
+	anomalies := api.Group("/anomalies")
+	anomalies.GET("", anomalyHandler.ListEvents)
+	anomalies.POST("/detect", anomalyHandler.Detect)
+	anomalies.GET("/:id", anomalyHandler.GetEvent)
+	anomalies.POST("/:id/review", anomalyHandler.ReviewEvent)
+
+	// 売上エクスポート関連のエンドポイント
+	exports := api.Group("/exports")
+	exports.POST("", exportHandler.CreateJob)
+	exports.GET("/:id", exportHandler.GetJob)
+	exports.GET("/:id/download", exportHandler.DownloadJob)
+
+	// 売上目標・予実関連のエンドポイント
+	targets := api.Group("/targets")
+	targets.POST("", targetHandler.SetTarget)
+	targets.GET("", targetHandler.ListTargets)
+	targets.GET("/progress", targetHandler.GetStoreProgress)
+	targets.GET("/:id/progress", targetHandler.GetProgress)
+	targets.DELETE("/:id", targetHandler.DeleteTarget)
+
+	// 売上予測関連のエンドポイント
+	forecasts := api.Group("/forecasts")
+	forecasts.GET("/revenue", forecastHandler.ForecastRevenue)
+
+	// 営業カレンダー（祝日・店舗イベント）関連のエンドポイント
+	cal := api.Group("/calendar")
+	cal.GET("", calendarHandler.GetCalendar)
+	cal.POST("/events", calendarHandler.CreateEvent)
+	cal.GET("/events", calendarHandler.ListEvents)
+	cal.PUT("/events/:id", calendarHandler.UpdateEvent)
+	cal.DELETE("/events/:id", calendarHandler.DeleteEvent)
 
 	return e
 }
@@ -0,0 +1,218 @@
+package routing
+
+import (
+	"container/heap"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/onoderaryou/smart-store-admin/backend/geo"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// roadGraphFile は道路データファイル（JSON）の形式です
+// edges の distance（km）を省略した場合はノード間の直線距離を使います。oneway の道路は from から to の向きのみ通行できます
+//
+//	{
+//	  "nodes": [{"id": "a", "latitude": 35.68, "longitude": 139.76}, ...],
+//	  "edges": [{"from": "a", "to": "b", "distance": 0.4, "oneway": false}, ...]
+//	}
+type roadGraphFile struct {
+	Nodes []struct {
+		ID        string  `json:"id"`
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"nodes"`
+	Edges []struct {
+		From     string  `json:"from"`
+		To       string  `json:"to"`
+		Distance float64 `json:"distance"`
+		OneWay   bool    `json:"oneway"`
+	} `json:"edges"`
+}
+
+type roadEdge struct {
+	to       int
+	distance float64
+}
+
+// snap は地点に最も近いノードと、そのノードまでの直線距離です
+type snap struct {
+	node     int
+	distance float64
+}
+
+// maxCachedSources はノード間の最短距離をキャッシュしておく出発ノードの上限です
+const maxCachedSources = 256
+
+// RoadGraph は道路ネットワークに沿った最短距離を移動距離とする Metric です
+// 地点は最も近いノードに寄せ、ノードまでの直線距離を加えます（配送機体の現在地など地点は毎回変わるためキャッシュしません）
+// ノード間の最短距離は、最近使った出発ノードから maxCachedSources 件までキャッシュします
+type RoadGraph struct {
+	nodes []models.Location
+	edges [][]roadEdge
+
+	// mu は shortests と recent だけを保護します（最短距離の計算中は保持しません）
+	mu        sync.Mutex
+	shortests map[int]*list.Element
+	// recent は最近使った順に並べたキャッシュ済みの出発ノードです（値は *cachedShortest）
+	recent *list.List
+}
+
+type cachedShortest struct {
+	source int
+	dist   []float64
+}
+
+// LoadRoadGraph は道路データファイルを読み込みます
+func LoadRoadGraph(path string) (*RoadGraph, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseRoadGraph(f)
+}
+
+// ParseRoadGraph は道路データ（JSON）を解析します
+func ParseRoadGraph(r io.Reader) (*RoadGraph, error) {
+	var file roadGraphFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid road graph: %w", err)
+	}
+	if len(file.Nodes) == 0 {
+		return nil, fmt.Errorf("invalid road graph: no nodes")
+	}
+
+	g := &RoadGraph{
+		nodes:     make([]models.Location, len(file.Nodes)),
+		edges:     make([][]roadEdge, len(file.Nodes)),
+		shortests: map[int]*list.Element{},
+		recent:    list.New(),
+	}
+	index := make(map[string]int, len(file.Nodes))
+	for i, n := range file.Nodes {
+		if _, ok := index[n.ID]; ok {
+			return nil, fmt.Errorf("invalid road graph: duplicate node %q", n.ID)
+		}
+		index[n.ID] = i
+		g.nodes[i] = models.Location{Latitude: n.Latitude, Longitude: n.Longitude}
+	}
+	for _, e := range file.Edges {
+		from, ok := index[e.From]
+		if !ok {
+			return nil, fmt.Errorf("invalid road graph: unknown node %q", e.From)
+		}
+		to, ok := index[e.To]
+		if !ok {
+			return nil, fmt.Errorf("invalid road graph: unknown node %q", e.To)
+		}
+		if e.Distance < 0 {
+			return nil, fmt.Errorf("invalid road graph: negative distance between %q and %q", e.From, e.To)
+		}
+		distance := e.Distance
+		if distance == 0 {
+			distance = geo.Distance(g.nodes[from], g.nodes[to])
+		}
+		g.edges[from] = append(g.edges[from], roadEdge{to: to, distance: distance})
+		if !e.OneWay {
+			g.edges[to] = append(g.edges[to], roadEdge{to: from, distance: distance})
+		}
+	}
+	return g, nil
+}
+
+// Distance は a から b までの道路に沿った距離（km）です（道路がつながっていない場合は +Inf）
+func (g *RoadGraph) Distance(a, b models.Location) float64 {
+	from, to := g.snap(a), g.snap(b)
+	road := g.shortest(from.node)[to.node]
+	if math.IsInf(road, 1) {
+		return road
+	}
+	return from.distance + road + to.distance
+}
+
+func (g *RoadGraph) snap(location models.Location) snap {
+	best := snap{node: -1, distance: math.Inf(1)}
+	for i, n := range g.nodes {
+		if d := geo.Distance(location, n); d < best.distance {
+			best = snap{node: i, distance: d}
+		}
+	}
+	return best
+}
+
+// shortest は出発ノードから各ノードまでの最短距離を返します（キャッシュにない場合はダイクストラ法で求めます）
+func (g *RoadGraph) shortest(source int) []float64 {
+	g.mu.Lock()
+	if e, ok := g.shortests[source]; ok {
+		g.recent.MoveToFront(e)
+		dist := e.Value.(*cachedShortest).dist
+		g.mu.Unlock()
+		return dist
+	}
+	g.mu.Unlock()
+
+	dist := g.dijkstra(source)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// 同じ出発ノードを同時に計算した場合は先にキャッシュした結果を使います
+	if e, ok := g.shortests[source]; ok {
+		g.recent.MoveToFront(e)
+		return e.Value.(*cachedShortest).dist
+	}
+	g.shortests[source] = g.recent.PushFront(&cachedShortest{source: source, dist: dist})
+	if g.recent.Len() > maxCachedSources {
+		oldest := g.recent.Back()
+		g.recent.Remove(oldest)
+		delete(g.shortests, oldest.Value.(*cachedShortest).source)
+	}
+	return dist
+}
+
+// dijkstra は出発ノードから各ノードまでの最短距離をダイクストラ法で求めます
+func (g *RoadGraph) dijkstra(source int) []float64 {
+	dist := make([]float64, len(g.nodes))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+	dist[source] = 0
+	queue := &nodeQueue{{node: source}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(nodeDistance)
+		if item.distance > dist[item.node] {
+			continue
+		}
+		for _, e := range g.edges[item.node] {
+			if d := item.distance + e.distance; d < dist[e.to] {
+				dist[e.to] = d
+				heap.Push(queue, nodeDistance{node: e.to, distance: d})
+			}
+		}
+	}
+	return dist
+}
+
+type nodeDistance struct {
+	node     int
+	distance float64
+}
+
+// nodeQueue は距離の短い順に取り出す優先度付きキューです
+type nodeQueue []nodeDistance
+
+func (q nodeQueue) Len() int            { return len(q) }
+func (q nodeQueue) Less(i, j int) bool  { return q[i].distance < q[j].distance }
+func (q nodeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *nodeQueue) Push(x interface{}) { *q = append(*q, x.(nodeDistance)) }
+func (q *nodeQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,115 @@
+// Package routing は複数の配送先を巡回する配送ルートの最適化（配送計画問題）を提供します
+// 積載重量・航続距離・到着時間帯の制約のもとで最近傍法によりルートを作り、2-opt と or-opt で改善します
+// 移動距離は直線距離（ハーバーサイン）または道路データファイルから求め、外部サービスには依存しません
+package routing
+
+import (
+	"errors"
+	"time"
+
+	"github.com/onoderaryou/smart-store-admin/backend/geo"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// ErrInvalidProblem は車両の速度など、配送計画問題の設定が不正な場合のエラーです
+var ErrInvalidProblem = errors.New("invalid routing problem")
+
+// Metric は2地点間の移動距離（km）を求めます
+// 到達できない場合は +Inf を返します
+type Metric interface {
+	Distance(a, b models.Location) float64
+}
+
+// Haversine は2地点間の大圏距離を移動距離とする Metric です（ドローンや道路データがない場合に使います）
+type Haversine struct{}
+
+func (Haversine) Distance(a, b models.Location) float64 {
+	return geo.Distance(a, b)
+}
+
+// Stop は配送先です
+type Stop struct {
+	ID       string
+	Location models.Location
+	// Demand は荷物の重量（kg）です
+	Demand float64
+	// ServiceTime は到着してから荷物を引き渡すまでの時間です
+	ServiceTime time.Duration
+	// WindowStart・WindowEnd は到着できる時間帯です（ゼロ値は制約なし）
+	// 時間帯の開始より早く着いた場合は開始まで待ちます
+	WindowStart time.Time
+	WindowEnd   time.Time
+	// Vehicles は配送できる車両のIDです（空の場合はすべての車両）
+	Vehicles []string
+}
+
+// Vehicle は拠点を出発して配送先を巡回し、拠点に戻る車両（配送ロボット・ドローン）です
+type Vehicle struct {
+	ID    string
+	Depot models.Location
+	// Capacity は最大積載重量（kg）、MaxDistance は拠点に戻るまでに移動できる距離（km）です（0は制限なし）
+	Capacity    float64
+	MaxDistance float64
+	// Speed は平均移動速度（km/h）です
+	Speed float64
+	// Metric は移動距離の求め方です（nil の場合は Haversine）
+	Metric Metric
+}
+
+// Problem は配送計画問題です
+type Problem struct {
+	Vehicles []Vehicle
+	Stops    []Stop
+	// Start は各車両が拠点を出発する時刻です
+	Start time.Time
+}
+
+// Visit はルート上の配送先への到着予定です
+type Visit struct {
+	StopID    string
+	Arrival   time.Time
+	Wait      time.Duration
+	Departure time.Time
+	// Distance は拠点からの累積の移動距離（km）です
+	Distance float64
+}
+
+// Route は1台の車両のルートです
+type Route struct {
+	VehicleID string
+	Visits    []Visit
+	Load      float64
+	// Distance は拠点に戻るまでの移動距離（km）です
+	Distance  float64
+	Departure time.Time
+	Return    time.Time
+}
+
+// UnassignedReason はルートに入らなかった理由です
+type UnassignedReason string
+
+const (
+	// ReasonNoVehicle は配送できる車両がないことを表します
+	ReasonNoVehicle UnassignedReason = "no_vehicle"
+	// ReasonOverCapacity は荷物がどの車両の最大積載重量も超えることを表します
+	ReasonOverCapacity UnassignedReason = "over_capacity"
+	// ReasonInfeasible は単独で配送しても時間帯・航続距離・到達可能性の条件を満たせないことを表します
+	ReasonInfeasible UnassignedReason = "infeasible"
+	// ReasonCapacityExhausted は単独なら配送できるが、他の配送先とあわせると車両の余裕が足りないことを表します
+	ReasonCapacityExhausted UnassignedReason = "capacity_exhausted"
+)
+
+// Unassigned はルートに入らなかった配送先です
+type Unassigned struct {
+	StopID string
+	Reason UnassignedReason
+}
+
+// Solution は配送計画問題の解です
+type Solution struct {
+	// Routes は配送先を1件以上巡回する車両のルートです（Problem.Vehicles の順）
+	Routes     []Route
+	Unassigned []Unassigned
+	// Distance は全ルートの移動距離の合計（km）です
+	Distance float64
+}
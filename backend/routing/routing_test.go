@@ -0,0 +1,261 @@
+package routing
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onoderaryou/smart-store-admin/backend/geo"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+var (
+	depot = models.Location{Latitude: 35.0, Longitude: 139.0}
+	start = time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+)
+
+// point は拠点から北へ km だけ離れた地点です（緯度0.01度 ≒ 1.11km）
+func point(km float64) models.Location {
+	return models.Location{Latitude: 35.0 + km/111.195, Longitude: 139.0}
+}
+
+func vehicle(id string) Vehicle {
+	return Vehicle{ID: id, Depot: depot, Speed: 10}
+}
+
+func stopIDs(route Route) []string {
+	ids := make([]string, len(route.Visits))
+	for i, v := range route.Visits {
+		ids[i] = v.StopID
+	}
+	return ids
+}
+
+func TestSolveOrdersStopsWithETAs(t *testing.T) {
+	problem := Problem{
+		Vehicles: []Vehicle{vehicle("r1")},
+		Stops: []Stop{
+			{ID: "far", Location: point(3)},
+			{ID: "near", Location: point(1), ServiceTime: 5 * time.Minute},
+			{ID: "mid", Location: point(2)},
+		},
+		Start: start,
+	}
+
+	solution, err := Solve(problem)
+	require.NoError(t, err)
+	require.Len(t, solution.Routes, 1)
+	assert.Empty(t, solution.Unassigned)
+
+	route := solution.Routes[0]
+	assert.Equal(t, []string{"near", "mid", "far"}, stopIDs(route))
+	assert.InDelta(t, 6, route.Distance, 0.01)
+	assert.InDelta(t, 6, solution.Distance, 0.01)
+
+	// 時速10kmで1kmごとに6分、near では5分の引き渡し時間
+	assert.WithinDuration(t, start.Add(6*time.Minute), route.Visits[0].Arrival, time.Second)
+	assert.WithinDuration(t, start.Add(11*time.Minute), route.Visits[0].Departure, time.Second)
+	assert.WithinDuration(t, start.Add(17*time.Minute), route.Visits[1].Arrival, time.Second)
+	assert.WithinDuration(t, start.Add(41*time.Minute), route.Return, time.Second)
+	assert.InDelta(t, 2, route.Visits[1].Distance, 0.01)
+}
+
+func TestSolveIsCloseToOptimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	for trial := 0; trial < 20; trial++ {
+		stops := make([]Stop, 7)
+		for i := range stops {
+			stops[i] = Stop{
+				ID: string(rune('a' + i)),
+				Location: models.Location{
+					Latitude:  35.0 + (rng.Float64()-0.5)*0.1,
+					Longitude: 139.0 + (rng.Float64()-0.5)*0.1,
+				},
+			}
+		}
+		solution, err := Solve(Problem{Vehicles: []Vehicle{vehicle("r1")}, Stops: stops, Start: start})
+		require.NoError(t, err)
+		require.Empty(t, solution.Unassigned)
+
+		optimal := bruteForce(stops)
+		assert.LessOrEqual(t, solution.Distance, optimal*1.05, "trial %d", trial)
+	}
+}
+
+// bruteForce は全順列を調べた最短の巡回距離です
+func bruteForce(stops []Stop) float64 {
+	best := math.Inf(1)
+	order := make([]int, len(stops))
+	for i := range order {
+		order[i] = i
+	}
+	var permute func(k int)
+	permute = func(k int) {
+		if k == len(order) {
+			d, prev := 0.0, depot
+			for _, i := range order {
+				d += geo.Distance(prev, stops[i].Location)
+				prev = stops[i].Location
+			}
+			best = math.Min(best, d+geo.Distance(prev, depot))
+			return
+		}
+		for i := k; i < len(order); i++ {
+			order[k], order[i] = order[i], order[k]
+			permute(k + 1)
+			order[k], order[i] = order[i], order[k]
+		}
+	}
+	permute(0)
+	return best
+}
+
+func TestSolveCapacityAndVehicles(t *testing.T) {
+	small := vehicle("small")
+	small.Capacity = 5
+	drone := vehicle("drone")
+	drone.Capacity = 5
+
+	problem := Problem{
+		Vehicles: []Vehicle{small, drone},
+		Stops: []Stop{
+			{ID: "a", Location: point(1), Demand: 3},
+			{ID: "b", Location: point(2), Demand: 3},
+			{ID: "c", Location: point(3), Demand: 3},
+			{ID: "heavy", Location: point(1), Demand: 10},
+			{ID: "pinned", Location: point(1), Vehicles: []string{"other"}},
+		},
+		Start: start,
+	}
+
+	solution, err := Solve(problem)
+	require.NoError(t, err)
+	require.Len(t, solution.Routes, 2)
+	for _, route := range solution.Routes {
+		assert.LessOrEqual(t, route.Load, 5.0)
+		assert.Len(t, route.Visits, 1)
+	}
+
+	reasons := map[string]UnassignedReason{}
+	for _, u := range solution.Unassigned {
+		reasons[u.StopID] = u.Reason
+	}
+	assert.Len(t, reasons, 3)
+	assert.Equal(t, ReasonOverCapacity, reasons["heavy"])
+	assert.Equal(t, ReasonNoVehicle, reasons["pinned"])
+
+	// a, b, c のうち1件は単独なら配送できるが、2台とも積載の余裕がない
+	exhausted := 0
+	for _, id := range []string{"a", "b", "c"} {
+		if reasons[id] == ReasonCapacityExhausted {
+			exhausted++
+		}
+	}
+	assert.Equal(t, 1, exhausted)
+}
+
+func TestSolveTimeWindowsAndRange(t *testing.T) {
+	limited := vehicle("r1")
+	limited.MaxDistance = 10
+
+	problem := Problem{
+		Vehicles: []Vehicle{limited},
+		Stops: []Stop{
+			// 近い配送先は1時間後からの時間帯指定、遠い配送先は30分以内に到着が必要
+			{ID: "near", Location: point(1), WindowStart: start.Add(time.Hour)},
+			{ID: "far", Location: point(3), WindowEnd: start.Add(30 * time.Minute)},
+			{ID: "toofar", Location: point(6)},
+		},
+		Start: start,
+	}
+
+	solution, err := Solve(problem)
+	require.NoError(t, err)
+	require.Len(t, solution.Routes, 1)
+
+	route := solution.Routes[0]
+	assert.Equal(t, []string{"far", "near"}, stopIDs(route))
+	assert.False(t, route.Visits[0].Arrival.After(start.Add(30*time.Minute)))
+	assert.Positive(t, route.Visits[1].Wait)
+	assert.Equal(t, start.Add(time.Hour), route.Visits[1].Arrival.Add(route.Visits[1].Wait))
+
+	require.Len(t, solution.Unassigned, 1)
+	assert.Equal(t, Unassigned{StopID: "toofar", Reason: ReasonInfeasible}, solution.Unassigned[0])
+}
+
+func TestSolveRejectsVehicleWithoutSpeed(t *testing.T) {
+	_, err := Solve(Problem{Vehicles: []Vehicle{{ID: "r1", Depot: depot}}})
+	assert.ErrorIs(t, err, ErrInvalidProblem)
+}
+
+func TestRoadGraph(t *testing.T) {
+	// a - b - c の道路（b から c は一方通行）と、道路につながっていない d
+	graph, err := ParseRoadGraph(strings.NewReader(`{
+		"nodes": [
+			{"id": "a", "latitude": 35.0, "longitude": 139.0},
+			{"id": "b", "latitude": 35.01, "longitude": 139.0},
+			{"id": "c", "latitude": 35.01, "longitude": 139.01},
+			{"id": "d", "latitude": 36.0, "longitude": 139.0}
+		],
+		"edges": [
+			{"from": "a", "to": "b", "distance": 1.5},
+			{"from": "b", "to": "c", "oneway": true}
+		]
+	}`))
+	require.NoError(t, err)
+
+	a := models.Location{Latitude: 35.0, Longitude: 139.0}
+	b := models.Location{Latitude: 35.01, Longitude: 139.0}
+	c := models.Location{Latitude: 35.01, Longitude: 139.01}
+	d := models.Location{Latitude: 36.0, Longitude: 139.0}
+
+	assert.InDelta(t, 1.5, graph.Distance(a, b), 1e-9)
+	assert.InDelta(t, 1.5+geo.Distance(b, c), graph.Distance(a, c), 1e-9)
+	assert.True(t, math.IsInf(graph.Distance(c, a), 1))
+	assert.True(t, math.IsInf(graph.Distance(a, d), 1))
+
+	// 道路から離れた地点はノードまでの直線距離を加えます
+	offRoad := models.Location{Latitude: 35.0, Longitude: 139.001}
+	assert.InDelta(t, geo.Distance(offRoad, a)+1.5, graph.Distance(offRoad, b), 1e-9)
+
+	// 一方通行を逆走するルートは作りません
+	solution, err := Solve(Problem{
+		Vehicles: []Vehicle{{ID: "r1", Depot: a, Speed: 6, Metric: graph}},
+		Stops:    []Stop{{ID: "c", Location: c}},
+		Start:    start,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, solution.Routes)
+	assert.Equal(t, ReasonInfeasible, solution.Unassigned[0].Reason)
+
+	_, err = ParseRoadGraph(strings.NewReader(`{"nodes": [{"id": "a"}], "edges": [{"from": "a", "to": "x"}]}`))
+	assert.Error(t, err)
+}
+
+func TestRoadGraphCacheIsBounded(t *testing.T) {
+	// 出発ノードが上限より多い一本道
+	var nodes, edges []string
+	for i := 0; i <= maxCachedSources; i++ {
+		nodes = append(nodes, fmt.Sprintf(`{"id": "n%d", "latitude": %f, "longitude": 139.0}`, i, 35.0+float64(i)*0.01))
+		if i > 0 {
+			edges = append(edges, fmt.Sprintf(`{"from": "n%d", "to": "n%d", "distance": 1}`, i-1, i))
+		}
+	}
+	graph, err := ParseRoadGraph(strings.NewReader(`{"nodes": [` + strings.Join(nodes, ",") + `], "edges": [` + strings.Join(edges, ",") + `]}`))
+	require.NoError(t, err)
+
+	last := graph.nodes[maxCachedSources]
+	for i := maxCachedSources; i >= 0; i-- {
+		assert.InDelta(t, float64(maxCachedSources-i), graph.Distance(graph.nodes[i], last), 1e-9)
+	}
+	assert.Len(t, graph.shortests, maxCachedSources)
+	assert.Equal(t, maxCachedSources, graph.recent.Len())
+	// 最も前に使った出発ノードから追い出します
+	assert.NotContains(t, graph.shortests, maxCachedSources)
+}
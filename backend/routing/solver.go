@@ -0,0 +1,428 @@
+package routing
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// epsilon は距離・重量の比較の許容誤差です
+	epsilon = 1e-9
+	// maxImprovementRounds は改善を繰り返す上限の回数です
+	maxImprovementRounds = 100
+	// maxSegmentLength は or-opt で移動する連続した配送先の最大件数です
+	maxSegmentLength = 3
+)
+
+// Solve は配送計画問題を解きます
+// 最近傍法で各車両のルートを作り、入らなかった配送先を最小コストの位置に挿入した後、
+// 2-opt（ルート内の区間の反転）と or-opt（1〜3件の連続した配送先の移動、車両間を含む）で総移動距離を改善します
+func Solve(problem Problem) (*Solution, error) {
+	for _, v := range problem.Vehicles {
+		if v.Speed <= 0 {
+			return nil, fmt.Errorf("%w: vehicle %s has no speed", ErrInvalidProblem, v.ID)
+		}
+	}
+
+	s := newSolver(problem)
+	routes := s.construct()
+	for round := 0; round < maxImprovementRounds; round++ {
+		improved := false
+		for v := range routes {
+			if s.twoOpt(v, routes) {
+				improved = true
+			}
+		}
+		if s.orOpt(routes) {
+			improved = true
+		}
+		if s.insertUnrouted(routes) {
+			improved = true
+		}
+		if !improved {
+			break
+		}
+	}
+	return s.solution(routes), nil
+}
+
+type solver struct {
+	problem Problem
+	n       int
+	// dist は車両ごとの距離行列です（配送先 0..n-1 と各車両の拠点 n+v。同じ Metric の車両は行列を共有します）
+	dist    [][][]float64
+	allowed [][]bool
+}
+
+func newSolver(problem Problem) *solver {
+	n := len(problem.Stops)
+	s := &solver{
+		problem: problem,
+		n:       n,
+		dist:    make([][][]float64, len(problem.Vehicles)),
+		allowed: make([][]bool, len(problem.Vehicles)),
+	}
+
+	points := make([]Stop, 0, n+len(problem.Vehicles))
+	points = append(points, problem.Stops...)
+	for _, v := range problem.Vehicles {
+		points = append(points, Stop{Location: v.Depot})
+	}
+
+	matrices := map[Metric][][]float64{}
+	for vi, v := range problem.Vehicles {
+		metric := v.Metric
+		if metric == nil {
+			metric = Haversine{}
+		}
+		m, ok := matrices[metric]
+		if !ok {
+			m = make([][]float64, len(points))
+			for i := range points {
+				m[i] = make([]float64, len(points))
+				for j := range points {
+					if i != j {
+						m[i][j] = metric.Distance(points[i].Location, points[j].Location)
+					}
+				}
+			}
+			matrices[metric] = m
+		}
+		s.dist[vi] = m
+
+		s.allowed[vi] = make([]bool, n)
+		for si, stop := range problem.Stops {
+			s.allowed[vi][si] = len(stop.Vehicles) == 0 || contains(stop.Vehicles, v.ID)
+		}
+	}
+	return s
+}
+
+// d は車両 v での from から to への移動距離です（-1 は拠点）
+func (s *solver) d(v, from, to int) float64 {
+	if from < 0 {
+		from = s.n + v
+	}
+	if to < 0 {
+		to = s.n + v
+	}
+	return s.dist[v][from][to]
+}
+
+// evaluate は車両 v が seq の順に巡回するルートを評価し、制約を満たす場合は移動距離を返します
+// visits を指定した場合は各配送先への到着予定を記録します
+func (s *solver) evaluate(v int, seq []int, visits *[]Visit) (distance float64, load float64, end time.Time, ok bool) {
+	vehicle := s.problem.Vehicles[v]
+	t := s.problem.Start
+	prev := -1
+	for _, i := range seq {
+		if !s.allowed[v][i] {
+			return 0, 0, time.Time{}, false
+		}
+		stop := s.problem.Stops[i]
+		load += stop.Demand
+		if vehicle.Capacity > 0 && load > vehicle.Capacity+epsilon {
+			return 0, 0, time.Time{}, false
+		}
+		leg := s.d(v, prev, i)
+		if math.IsInf(leg, 1) {
+			return 0, 0, time.Time{}, false
+		}
+		distance += leg
+		arrival := t.Add(travelTime(leg, vehicle.Speed))
+		if !stop.WindowEnd.IsZero() && arrival.After(stop.WindowEnd) {
+			return 0, 0, time.Time{}, false
+		}
+		var wait time.Duration
+		if !stop.WindowStart.IsZero() && arrival.Before(stop.WindowStart) {
+			wait = stop.WindowStart.Sub(arrival)
+		}
+		t = arrival.Add(wait + stop.ServiceTime)
+		if visits != nil {
+			*visits = append(*visits, Visit{
+				StopID:    stop.ID,
+				Arrival:   arrival,
+				Wait:      wait,
+				Departure: t,
+				Distance:  distance,
+			})
+		}
+		prev = i
+	}
+	if len(seq) > 0 {
+		back := s.d(v, prev, -1)
+		if math.IsInf(back, 1) {
+			return 0, 0, time.Time{}, false
+		}
+		distance += back
+		t = t.Add(travelTime(back, vehicle.Speed))
+	}
+	if vehicle.MaxDistance > 0 && distance > vehicle.MaxDistance+epsilon {
+		return 0, 0, time.Time{}, false
+	}
+	return distance, load, t, true
+}
+
+// cost は制約を満たすルートの移動距離です（満たさない場合は +Inf）
+func (s *solver) cost(v int, seq []int) float64 {
+	distance, _, _, ok := s.evaluate(v, seq, nil)
+	if !ok {
+		return math.Inf(1)
+	}
+	return distance
+}
+
+// construct は最近傍法で各車両のルートを作ります
+// 全車両の現在の最後の地点から最も近い、制約を満たす配送先を1件ずつ追加していきます
+func (s *solver) construct() [][]int {
+	routes := make([][]int, len(s.problem.Vehicles))
+	routed := make([]bool, s.n)
+	for {
+		bestV, bestStop, bestLeg := -1, -1, math.Inf(1)
+		for v := range routes {
+			last := -1
+			if len(routes[v]) > 0 {
+				last = routes[v][len(routes[v])-1]
+			}
+			for i := 0; i < s.n; i++ {
+				if routed[i] || !s.allowed[v][i] {
+					continue
+				}
+				leg := s.d(v, last, i)
+				if leg >= bestLeg {
+					continue
+				}
+				if _, _, _, ok := s.evaluate(v, appendStop(routes[v], i), nil); !ok {
+					continue
+				}
+				bestV, bestStop, bestLeg = v, i, leg
+			}
+		}
+		if bestV < 0 {
+			break
+		}
+		routes[bestV] = append(routes[bestV], bestStop)
+		routed[bestStop] = true
+	}
+	s.insertUnrouted(routes)
+	return routes
+}
+
+// insertUnrouted はルートに入っていない配送先を、移動距離の増加が最小になる位置に挿入します
+// 時間帯の終わりが早い配送先から順に挿入します
+func (s *solver) insertUnrouted(routes [][]int) bool {
+	routed := make([]bool, s.n)
+	for _, r := range routes {
+		for _, i := range r {
+			routed[i] = true
+		}
+	}
+	var pending []int
+	for i := 0; i < s.n; i++ {
+		if !routed[i] {
+			pending = append(pending, i)
+		}
+	}
+	sort.SliceStable(pending, func(a, b int) bool {
+		wa, wb := s.problem.Stops[pending[a]].WindowEnd, s.problem.Stops[pending[b]].WindowEnd
+		if wa.IsZero() || wb.IsZero() {
+			return !wa.IsZero() && wb.IsZero()
+		}
+		return wa.Before(wb)
+	})
+
+	inserted := false
+	for _, i := range pending {
+		bestV, bestPos, bestDelta := -1, -1, math.Inf(1)
+		for v := range routes {
+			if !s.allowed[v][i] {
+				continue
+			}
+			current := s.cost(v, routes[v])
+			for pos := 0; pos <= len(routes[v]); pos++ {
+				delta := s.cost(v, insertAt(routes[v], pos, i)) - current
+				if delta < bestDelta {
+					bestV, bestPos, bestDelta = v, pos, delta
+				}
+			}
+		}
+		if bestV >= 0 {
+			routes[bestV] = insertAt(routes[bestV], bestPos, i)
+			inserted = true
+		}
+	}
+	return inserted
+}
+
+// twoOpt はルート内の区間を反転して移動距離が短くなる限り改善します
+func (s *solver) twoOpt(v int, routes [][]int) bool {
+	improved := false
+	current := s.cost(v, routes[v])
+	for changed := true; changed; {
+		changed = false
+		r := routes[v]
+		for i := 0; i < len(r)-1 && !changed; i++ {
+			for j := i + 1; j < len(r); j++ {
+				candidate := reverseSegment(r, i, j)
+				if c := s.cost(v, candidate); c < current-epsilon {
+					routes[v], current = candidate, c
+					changed, improved = true, true
+					break
+				}
+			}
+		}
+	}
+	return improved
+}
+
+// orOpt は1〜3件の連続した配送先を同じ車両または別の車両のルートの別の位置に移し、総移動距離が短くなる場合は採用します
+func (s *solver) orOpt(routes [][]int) bool {
+	improved := false
+	for changed := true; changed; {
+		changed = false
+	search:
+		for from := range routes {
+			for length := 1; length <= maxSegmentLength; length++ {
+				for i := 0; i+length <= len(routes[from]); i++ {
+					segment := routes[from][i : i+length]
+					rest := removeSegment(routes[from], i, length)
+					fromBefore := s.cost(from, routes[from])
+					fromAfter := s.cost(from, rest)
+					for to := range routes {
+						base := rest
+						toBefore := 0.0
+						if to != from {
+							base = routes[to]
+							toBefore = s.cost(to, routes[to])
+						}
+						for pos := 0; pos <= len(base); pos++ {
+							if to == from && pos == i {
+								continue
+							}
+							candidate := insertSegment(base, pos, segment)
+							var before, after float64
+							if to == from {
+								before, after = fromBefore, s.cost(from, candidate)
+							} else {
+								before, after = fromBefore+toBefore, fromAfter+s.cost(to, candidate)
+							}
+							if after < before-epsilon {
+								if to == from {
+									routes[from] = candidate
+								} else {
+									routes[from], routes[to] = rest, candidate
+								}
+								changed, improved = true, true
+								break search
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return improved
+}
+
+// solution はルートから解を作り、入らなかった配送先の理由を判定します
+func (s *solver) solution(routes [][]int) *Solution {
+	solution := &Solution{Routes: []Route{}, Unassigned: []Unassigned{}}
+	routed := make([]bool, s.n)
+	for v, r := range routes {
+		if len(r) == 0 {
+			continue
+		}
+		route := Route{
+			VehicleID: s.problem.Vehicles[v].ID,
+			Departure: s.problem.Start,
+		}
+		route.Distance, route.Load, route.Return, _ = s.evaluate(v, r, &route.Visits)
+		solution.Routes = append(solution.Routes, route)
+		solution.Distance += route.Distance
+		for _, i := range r {
+			routed[i] = true
+		}
+	}
+
+	for i, stop := range s.problem.Stops {
+		if routed[i] {
+			continue
+		}
+		solution.Unassigned = append(solution.Unassigned, Unassigned{StopID: stop.ID, Reason: s.reason(i)})
+	}
+	return solution
+}
+
+// reason はルートに入らなかった配送先の理由を判定します
+func (s *solver) reason(i int) UnassignedReason {
+	stop := s.problem.Stops[i]
+	anyVehicle, fits := false, false
+	for v, vehicle := range s.problem.Vehicles {
+		if !s.allowed[v][i] {
+			continue
+		}
+		anyVehicle = true
+		if vehicle.Capacity > 0 && stop.Demand > vehicle.Capacity+epsilon {
+			continue
+		}
+		fits = true
+		if _, _, _, ok := s.evaluate(v, []int{i}, nil); ok {
+			return ReasonCapacityExhausted
+		}
+	}
+	switch {
+	case !anyVehicle:
+		return ReasonNoVehicle
+	case !fits:
+		return ReasonOverCapacity
+	}
+	return ReasonInfeasible
+}
+
+// travelTime は距離（km）を速度（km/h）で移動する時間です
+func travelTime(distance, speed float64) time.Duration {
+	return time.Duration(distance / speed * float64(time.Hour))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func appendStop(route []int, i int) []int {
+	result := make([]int, len(route), len(route)+1)
+	copy(result, route)
+	return append(result, i)
+}
+
+func insertAt(route []int, pos, i int) []int {
+	return insertSegment(route, pos, []int{i})
+}
+
+func insertSegment(route []int, pos int, segment []int) []int {
+	result := make([]int, 0, len(route)+len(segment))
+	result = append(result, route[:pos]...)
+	result = append(result, segment...)
+	return append(result, route[pos:]...)
+}
+
+func removeSegment(route []int, i, length int) []int {
+	result := make([]int, 0, len(route)-length)
+	result = append(result, route[:i]...)
+	return append(result, route[i+length:]...)
+}
+
+func reverseSegment(route []int, i, j int) []int {
+	result := make([]int, len(route))
+	copy(result, route)
+	for ; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
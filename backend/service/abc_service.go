@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/tax"
+)
+
+// abcShareEpsilon は累積構成比としきい値を比較する際の誤差の許容値です
+const abcShareEpsilon = 1e-9
+
+// ABCConfig はABC分析の既定の区分しきい値を表します
+type ABCConfig struct {
+	// ClassAThreshold 累積構成比がこの値に達するまでの商品をA区分とします
+	ClassAThreshold float64
+	// ClassBThreshold 累積構成比がこの値に達するまでの残りの商品をB区分とします
+	ClassBThreshold float64
+}
+
+// DefaultABCConfig は既定のABC分析のしきい値（A: 80%, B: 95%）を返します
+func DefaultABCConfig() ABCConfig {
+	return ABCConfig{
+		ClassAThreshold: 0.8,
+		ClassBThreshold: 0.95,
+	}
+}
+
+// ABCRequest はABC分析の条件です
+type ABCRequest struct {
+	Metric    models.ABCMetric
+	StartDate time.Time
+	EndDate   time.Time
+	// しきい値が0の場合は既定値を使います
+	ClassAThreshold float64
+	ClassBThreshold float64
+}
+
+// Validate はABC分析の条件を検証します
+func (r *ABCRequest) Validate() error {
+	if !models.ValidateABCMetric(r.Metric) {
+		return errors.New("指標は revenue, units, margin のいずれかを指定してください")
+	}
+	if r.StartDate.IsZero() || r.EndDate.IsZero() {
+		return errors.New("対象期間を指定してください")
+	}
+	if r.EndDate.Before(r.StartDate) {
+		return errors.New("終了日は開始日以降の日付を指定してください")
+	}
+	if r.ClassAThreshold < 0 || r.ClassBThreshold < 0 || r.ClassAThreshold > 1 || r.ClassBThreshold > 1 {
+		return errors.New("区分のしきい値は0より大きく1以下で指定してください")
+	}
+	if r.ClassAThreshold != 0 && r.ClassBThreshold != 0 && r.ClassAThreshold >= r.ClassBThreshold {
+		return errors.New("A区分のしきい値はB区分のしきい値より小さくしてください")
+	}
+	return nil
+}
+
+// ABCServiceInterface はABC分析サービスのインターフェースを定義します
+type ABCServiceInterface interface {
+	Analyze(ctx context.Context, req *ABCRequest) (*models.ABCAnalysis, error)
+}
+
+// ABCService は商品を売上への貢献度でA/B/Cに区分するサービスです
+type ABCService struct {
+	productRepo repository.ProductRepository
+	saleRepo    repository.SaleRepository
+	taxCalc     *tax.Calculator
+	config      ABCConfig
+	location    *time.Location
+	now         func() time.Time
+}
+
+func NewABCService(productRepo repository.ProductRepository, saleRepo repository.SaleRepository, taxCalc *tax.Calculator, config ABCConfig, location *time.Location) *ABCService {
+	return &ABCService{
+		productRepo: productRepo,
+		saleRepo:    saleRepo,
+		taxCalc:     taxCalc,
+		config:      config,
+		location:    location,
+		now:         time.Now,
+	}
+}
+
+// Analyze は期間中の商品別の実績を指標の大きい順に並べ、累積構成比でA/B/Cに区分します
+// 販売実績のない商品もC区分として含め、結果は各商品に保存します
+func (s *ABCService) Analyze(ctx context.Context, req *ABCRequest) (*models.ABCAnalysis, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	thresholdA, thresholdB := req.ClassAThreshold, req.ClassBThreshold
+	if thresholdA == 0 {
+		thresholdA = s.config.ClassAThreshold
+	}
+	if thresholdB == 0 {
+		thresholdB = s.config.ClassBThreshold
+	}
+	if thresholdA >= thresholdB {
+		return nil, errors.New("A区分のしきい値はB区分のしきい値より小さくしてください")
+	}
+
+	start := time.Date(req.StartDate.Year(), req.StartDate.Month(), req.StartDate.Day(), 0, 0, 0, 0, s.location)
+	end := time.Date(req.EndDate.Year(), req.EndDate.Month(), req.EndDate.Day()+1, 0, 0, 0, 0, s.location)
+
+	products, err := s.productRepo.List(ctx, models.ProductQuery{}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := s.saleRepo.GetSalesByProduct(ctx, models.SaleQuery{Start: start, End: end})
+	if err != nil {
+		return nil, err
+	}
+	salesByProduct := make(map[primitive.ObjectID]*models.ProductSales, len(sales))
+	for _, ps := range sales {
+		salesByProduct[ps.ProductID] = ps
+	}
+
+	analyzedAt := s.now()
+	entries := make([]models.ABCProduct, 0, len(products))
+	for _, p := range products {
+		entry := models.ABCProduct{
+			ProductID: p.ID,
+			Name:      p.Name,
+			SKU:       p.SKU,
+			Category:  p.Category,
+		}
+		if ps, ok := salesByProduct[p.ID]; ok {
+			entry.Units = ps.Units
+			entry.Revenue = ps.Revenue
+			// 原価は税抜のため、粗利は値引後の税抜の売上金額から計算します（税率は販売時の税区分）
+			lines := make([]tax.Line, 0, len(ps.TaxClasses))
+			for _, tc := range ps.TaxClasses {
+				lines = append(lines, tax.Line{Class: tc.TaxClass, Amount: tc.Revenue - tc.Discount})
+			}
+			entry.Margin = s.taxCalc.Calculate(lines).Subtotal - ps.Cost
+		}
+		entry.Metric = req.Metric
+		entry.Value = abcValue(req.Metric, &entry)
+		entry.ZeroSales = entry.Units == 0
+		entry.PeriodStart = start
+		entry.PeriodEnd = end
+		entry.AnalyzedAt = analyzedAt
+		entries = append(entries, entry)
+	}
+
+	// 指標の大きい順（同値の場合は売上金額、SKUの順）
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value > entries[j].Value
+		}
+		if entries[i].Revenue != entries[j].Revenue {
+			return entries[i].Revenue > entries[j].Revenue
+		}
+		return entries[i].SKU < entries[j].SKU
+	})
+
+	// 構成比は正の値の合計に対する割合です（粗利がマイナスの商品は構成比0としてC区分）
+	var total float64
+	for _, e := range entries {
+		if e.Value > 0 {
+			total += e.Value
+		}
+	}
+
+	analysis := &models.ABCAnalysis{
+		Metric:          req.Metric,
+		StartDate:       start.Format("2006-01-02"),
+		EndDate:         req.EndDate.Format("2006-01-02"),
+		ClassAThreshold: thresholdA,
+		ClassBThreshold: thresholdB,
+		Total:           total,
+		AnalyzedAt:      analyzedAt,
+	}
+	summaries := map[models.ABCClass]*models.ABCClassSummary{
+		models.ABCClassA: {Class: models.ABCClassA},
+		models.ABCClassB: {Class: models.ABCClassB},
+		models.ABCClassC: {Class: models.ABCClassC},
+	}
+	results := make(map[primitive.ObjectID]models.ProductABC, len(entries))
+
+	var cumulative float64
+	for i := range entries {
+		e := &entries[i]
+		e.Rank = i + 1
+		if e.Value > 0 && total > 0 {
+			e.Share = e.Value / total
+		}
+		// 累積構成比がしきい値に達する商品までを上位の区分に含めます
+		previous := cumulative
+		cumulative += e.Share
+		e.CumulativeShare = cumulative
+
+		switch {
+		case e.ZeroSales || e.Share == 0:
+			e.Class = models.ABCClassC
+		case previous < thresholdA-abcShareEpsilon:
+			e.Class = models.ABCClassA
+		case previous < thresholdB-abcShareEpsilon:
+			e.Class = models.ABCClassB
+		default:
+			e.Class = models.ABCClassC
+		}
+		if e.ZeroSales {
+			analysis.ZeroSalesCount++
+		}
+
+		summary := summaries[e.Class]
+		summary.Products++
+		summary.Value += e.Value
+		summary.Share += e.Share
+		results[e.ProductID] = e.ProductABC
+	}
+
+	analysis.Classes = []models.ABCClassSummary{
+		*summaries[models.ABCClassA],
+		*summaries[models.ABCClassB],
+		*summaries[models.ABCClassC],
+	}
+	analysis.Products = entries
+
+	if err := s.productRepo.UpdateABC(ctx, results); err != nil {
+		return nil, err
+	}
+	return analysis, nil
+}
+
+// abcValue は商品の実績から順位付けに使う値を取り出します
+func abcValue(metric models.ABCMetric, entry *models.ABCProduct) float64 {
+	switch metric {
+	case models.ABCMetricUnits:
+		return float64(entry.Units)
+	case models.ABCMetricMargin:
+		return entry.Margin
+	default:
+		return entry.Revenue
+	}
+}
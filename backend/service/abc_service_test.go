@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/tax"
+)
+
+func TestABCAnalyze(t *testing.T) {
+	ctx := context.Background()
+	products := []*models.Product{
+		{ID: primitive.NewObjectID(), Name: "コーヒー", SKU: "SKU-1"},
+		{ID: primitive.NewObjectID(), Name: "サンドイッチ", SKU: "SKU-2"},
+		{ID: primitive.NewObjectID(), Name: "おにぎり", SKU: "SKU-3"},
+		{ID: primitive.NewObjectID(), Name: "ガム", SKU: "SKU-4"},
+		{ID: primitive.NewObjectID(), Name: "乾電池", SKU: "SKU-5"},
+	}
+	// 売上金額は税込（税抜 60000 / 25000 / 10000 / 5000）
+	standard := func(revenue float64) []models.TaxClassSales {
+		return []models.TaxClassSales{{TaxClass: models.TaxClassStandard, Revenue: revenue}}
+	}
+	sales := []*models.ProductSales{
+		{ProductID: products[0].ID, Units: 300, Revenue: 66000, Cost: 20000, TaxClasses: standard(66000)},
+		{ProductID: products[1].ID, Units: 50, Revenue: 27500, Cost: 20000, TaxClasses: standard(27500)},
+		{ProductID: products[2].ID, Units: 80, Revenue: 11000, Cost: 4000, TaxClasses: standard(11000)},
+		{ProductID: products[3].ID, Units: 40, Revenue: 5500, Cost: 6000, TaxClasses: standard(5500)},
+	}
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	query := models.SaleQuery{Start: start, End: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name      string
+		metric    models.ABCMetric
+		wantOrder []int
+		wantClass []models.ABCClass
+	}{
+		{
+			// 売上金額: 60% / 25% / 10% / 5% / 0%
+			name:      "売上金額で区分",
+			metric:    models.ABCMetricRevenue,
+			wantOrder: []int{0, 1, 2, 3, 4},
+			wantClass: []models.ABCClass{models.ABCClassA, models.ABCClassA, models.ABCClassB, models.ABCClassC, models.ABCClassC},
+		},
+		{
+			// 粗利（税抜売上 - 原価）: 40000 (78%) / 6000 (90%) / 5000 (100%) / 0 / -1000（原価割れはC区分）
+			name:      "粗利で区分",
+			metric:    models.ABCMetricMargin,
+			wantOrder: []int{0, 2, 1, 4, 3},
+			wantClass: []models.ABCClass{models.ABCClassA, models.ABCClassA, models.ABCClassB, models.ABCClassC, models.ABCClassC},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProductRepo := new(MockProductRepository)
+			mockSaleRepo := new(MockSaleRepository)
+			service := NewABCService(mockProductRepo, mockSaleRepo, tax.NewCalculator(tax.DefaultConfig()), DefaultABCConfig(), time.UTC)
+
+			mockProductRepo.On("List", ctx, models.ProductQuery{}, int64(0), int64(0)).Return(products, nil)
+			mockSaleRepo.On("GetSalesByProduct", ctx, query).Return(sales, nil)
+			var saved map[primitive.ObjectID]models.ProductABC
+			mockProductRepo.On("UpdateABC", ctx, mock.Anything).Run(func(args mock.Arguments) {
+				saved = args.Get(1).(map[primitive.ObjectID]models.ProductABC)
+			}).Return(nil)
+
+			analysis, err := service.Analyze(ctx, &ABCRequest{Metric: tt.metric, StartDate: start, EndDate: end})
+			assert.NoError(t, err)
+			assert.Len(t, analysis.Products, len(products))
+			for i, p := range analysis.Products {
+				assert.Equal(t, products[tt.wantOrder[i]].ID, p.ProductID)
+				assert.Equal(t, i+1, p.Rank)
+				assert.Equal(t, tt.wantClass[i], p.Class, p.Name)
+				assert.Equal(t, p.ProductABC, saved[p.ProductID])
+			}
+			assert.Equal(t, 1, analysis.ZeroSalesCount)
+			assert.True(t, saved[products[4].ID].ZeroSales)
+			assert.InDelta(t, 1.0, analysis.Products[len(products)-1].CumulativeShare, 1e-9)
+		})
+	}
+
+	t.Run("しきい値の指定", func(t *testing.T) {
+		mockProductRepo := new(MockProductRepository)
+		mockSaleRepo := new(MockSaleRepository)
+		service := NewABCService(mockProductRepo, mockSaleRepo, tax.NewCalculator(tax.DefaultConfig()), DefaultABCConfig(), time.UTC)
+		mockProductRepo.On("List", ctx, models.ProductQuery{}, int64(0), int64(0)).Return(products, nil)
+		mockSaleRepo.On("GetSalesByProduct", ctx, query).Return(sales, nil)
+		mockProductRepo.On("UpdateABC", ctx, mock.Anything).Return(nil)
+
+		analysis, err := service.Analyze(ctx, &ABCRequest{
+			Metric:          models.ABCMetricRevenue,
+			StartDate:       start,
+			EndDate:         end,
+			ClassAThreshold: 0.5,
+			ClassBThreshold: 0.85,
+		})
+		assert.NoError(t, err)
+		want := []models.ABCClassSummary{
+			{Class: models.ABCClassA, Products: 1, Value: 66000, Share: 0.6},
+			{Class: models.ABCClassB, Products: 1, Value: 27500, Share: 0.25},
+			{Class: models.ABCClassC, Products: 3, Value: 16500, Share: 0.15},
+		}
+		for i, summary := range analysis.Classes {
+			assert.Equal(t, want[i].Class, summary.Class)
+			assert.Equal(t, want[i].Products, summary.Products)
+			assert.Equal(t, want[i].Value, summary.Value)
+			assert.InDelta(t, want[i].Share, summary.Share, 1e-9)
+		}
+	})
+
+	t.Run("粗利は値引後の売上金額から計算", func(t *testing.T) {
+		mockProductRepo := new(MockProductRepository)
+		mockSaleRepo := new(MockSaleRepository)
+		service := NewABCService(mockProductRepo, mockSaleRepo, tax.NewCalculator(tax.DefaultConfig()), DefaultABCConfig(), time.UTC)
+		mockProductRepo.On("List", ctx, models.ProductQuery{}, int64(0), int64(0)).Return(products[:1], nil)
+		mockSaleRepo.On("GetSalesByProduct", ctx, query).Return([]*models.ProductSales{
+			{ProductID: products[0].ID, Units: 300, Revenue: 66000, Discount: 22000, Cost: 20000, TaxClasses: []models.TaxClassSales{
+				{TaxClass: models.TaxClassStandard, Revenue: 66000, Discount: 22000},
+			}},
+		}, nil)
+		mockProductRepo.On("UpdateABC", ctx, mock.Anything).Return(nil)
+
+		analysis, err := service.Analyze(ctx, &ABCRequest{Metric: models.ABCMetricMargin, StartDate: start, EndDate: end})
+		assert.NoError(t, err)
+		// 税抜の値引後売上 40000 - 原価 20000
+		assert.Equal(t, 20000.0, analysis.Products[0].Margin)
+		assert.Equal(t, 66000.0, analysis.Products[0].Revenue)
+	})
+
+	t.Run("粗利は販売時の税区分ごとに税抜の売上金額を計算", func(t *testing.T) {
+		mockProductRepo := new(MockProductRepository)
+		mockSaleRepo := new(MockSaleRepository)
+		service := NewABCService(mockProductRepo, mockSaleRepo, tax.NewCalculator(tax.DefaultConfig()), DefaultABCConfig(), time.UTC)
+		// 期間の途中で標準税率から軽減税率に変更した商品
+		product := &models.Product{ID: primitive.NewObjectID(), Name: "弁当", SKU: "SKU-6", TaxClass: models.TaxClassReduced}
+		mockProductRepo.On("List", ctx, models.ProductQuery{}, int64(0), int64(0)).Return([]*models.Product{product}, nil)
+		mockSaleRepo.On("GetSalesByProduct", ctx, query).Return([]*models.ProductSales{
+			{ProductID: product.ID, Units: 20, Revenue: 21800, Cost: 12000, TaxClasses: []models.TaxClassSales{
+				{TaxClass: models.TaxClassStandard, Revenue: 11000},
+				{TaxClass: models.TaxClassReduced, Revenue: 10800},
+			}},
+		}, nil)
+		mockProductRepo.On("UpdateABC", ctx, mock.Anything).Return(nil)
+
+		analysis, err := service.Analyze(ctx, &ABCRequest{Metric: models.ABCMetricMargin, StartDate: start, EndDate: end})
+		assert.NoError(t, err)
+		// 税抜 10000 + 10000 - 原価 12000
+		assert.Equal(t, 8000.0, analysis.Products[0].Margin)
+	})
+
+	t.Run("無効な条件", func(t *testing.T) {
+		service := NewABCService(new(MockProductRepository), new(MockSaleRepository), tax.NewCalculator(tax.DefaultConfig()), DefaultABCConfig(), time.UTC)
+		_, err := service.Analyze(ctx, &ABCRequest{Metric: "profit", StartDate: start, EndDate: end})
+		assert.Error(t, err)
+		_, err = service.Analyze(ctx, &ABCRequest{Metric: models.ABCMetricUnits, StartDate: start, EndDate: end, ClassAThreshold: 0.9, ClassBThreshold: 0.8})
+		assert.Error(t, err)
+	})
+}
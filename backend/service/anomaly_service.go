@@ -0,0 +1,549 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/anomaly"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+// ErrAnomalyNotFound は異常イベントが存在しない場合のエラーです
+var ErrAnomalyNotFound = errors.New("anomaly event not found")
+
+// AnomalyConfig は異常検知のしきい値を表します
+type AnomalyConfig struct {
+	// Threshold はロバストzスコアの絶対値がこの値以上で異常と判定します
+	Threshold float64
+	// SeasonalWeeks は売上の急減を判定する際に比較する過去の週数です（同じ曜日・時間帯）
+	SeasonalWeeks int
+	// RollingDays は値引率・取消件数・現金過不足の基準値にする過去の日数です
+	RollingDays int
+	// MinBaselineSamples は判定に必要な基準値の最小件数です
+	MinBaselineSamples int
+	// MinHourlyRevenue は売上の急減を判定する時間帯の基準売上（中央値）の下限です
+	MinHourlyRevenue float64
+	// MinDailySales は値引率を判定するレジの1日の最小売上件数です
+	MinDailySales int
+	// MinDiscountRateScale は値引率の標準偏差の推定値の下限です
+	MinDiscountRateScale float64
+	// MinVoids は取消・返品の多発と判定する1日の最小件数です
+	MinVoids int
+	// MinCashDiscrepancy は異常と判定する現金過不足の最小額（円）です
+	MinCashDiscrepancy float64
+	// MinCashScale は現金過不足の標準偏差の推定値の下限（円）です
+	MinCashScale float64
+}
+
+// DefaultAnomalyConfig は既定の異常検知のしきい値を返します
+func DefaultAnomalyConfig() AnomalyConfig {
+	return AnomalyConfig{
+		Threshold:            3.5,
+		SeasonalWeeks:        8,
+		RollingDays:          28,
+		MinBaselineSamples:   4,
+		MinHourlyRevenue:     1000,
+		MinDailySales:        5,
+		MinDiscountRateScale: 0.02,
+		MinVoids:             3,
+		MinCashDiscrepancy:   500,
+		MinCashScale:         100,
+	}
+}
+
+// AnomalyServiceInterface は異常検知サービスのインターフェースを定義します
+type AnomalyServiceInterface interface {
+	Detect(ctx context.Context, storeID string, date time.Time) ([]*models.AnomalyEvent, error)
+	ListEvents(ctx context.Context, query models.AnomalyQuery) ([]*models.AnomalyEvent, error)
+	GetEvent(ctx context.Context, id primitive.ObjectID) (*models.AnomalyEvent, error)
+	ReviewEvent(ctx context.Context, id primitive.ObjectID, status models.AnomalyStatus, reviewedBy, note string) (*models.AnomalyEvent, error)
+}
+
+// AnomalyService は売上・取消・レジ精算から不正や異常の兆候を検知するサービスです
+type AnomalyService struct {
+	repo        repository.AnomalyRepository
+	saleRepo    repository.SaleRepository
+	returnRepo  repository.SaleReturnRepository
+	sessionRepo repository.RegisterSessionRepository
+	config      AnomalyConfig
+	location    *time.Location
+	now         func() time.Time
+}
+
+// NewAnomalyService は新しい異常検知サービスを作成します
+// location は営業日・時間帯の区切りに使う店舗のタイムゾーンです
+func NewAnomalyService(
+	repo repository.AnomalyRepository,
+	saleRepo repository.SaleRepository,
+	returnRepo repository.SaleReturnRepository,
+	sessionRepo repository.RegisterSessionRepository,
+	config AnomalyConfig,
+	location *time.Location,
+) *AnomalyService {
+	return &AnomalyService{
+		repo:        repo,
+		saleRepo:    saleRepo,
+		returnRepo:  returnRepo,
+		sessionRepo: sessionRepo,
+		config:      config,
+		location:    location,
+		now:         time.Now,
+	}
+}
+
+// Start は一定間隔で当日分の異常検知を実行します
+// 日付が変わった直後は前日の最後の時間帯も検知できるよう前日分も実行します
+func (s *AnomalyService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				now := s.now().In(s.location)
+				today, _ := s.businessDay(now)
+				if now.Sub(today) < interval {
+					if _, err := s.Detect(ctx, "", today.AddDate(0, 0, -1)); err != nil {
+						log.Printf("Failed to detect anomalies: %v", err)
+					}
+				}
+				if _, err := s.Detect(ctx, "", now); err != nil {
+					log.Printf("Failed to detect anomalies: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Detect は指定日の異常を検知し、異常イベントとして保存します
+// 日付がゼロ値の場合は当日、店舗IDが空の場合は全店舗を対象にします
+// 同じ異常を再検知した場合は既存のイベントを更新します
+func (s *AnomalyService) Detect(ctx context.Context, storeID string, date time.Time) ([]*models.AnomalyEvent, error) {
+	if date.IsZero() {
+		date = s.now().In(s.location)
+	}
+	start, end := s.businessDay(date)
+	if now := s.now(); now.Before(end) {
+		end = now
+	}
+	if !end.After(start) {
+		return []*models.AnomalyEvent{}, nil
+	}
+
+	baselineDays := s.config.RollingDays
+	if days := s.config.SeasonalWeeks * 7; days > baselineDays {
+		baselineDays = days
+	}
+	sales, err := s.saleRepo.FindSales(ctx, models.SaleQuery{
+		StoreID: storeID,
+		Start:   start.AddDate(0, 0, -baselineDays),
+		End:     end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	returns, err := s.returnRepo.FindReturns(ctx, models.SaleQuery{
+		StoreID: storeID,
+		Start:   start.AddDate(0, 0, -s.config.RollingDays),
+		End:     end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.sessionRepo.ListByPeriod(ctx, storeID, "", start.AddDate(0, 0, -s.config.RollingDays), end)
+	if err != nil {
+		return nil, err
+	}
+
+	salesByStore := make(map[string][]*models.Sale)
+	for _, sale := range sales {
+		salesByStore[sale.StoreID] = append(salesByStore[sale.StoreID], sale)
+	}
+	returnsByStore := make(map[string][]*models.SaleReturn)
+	for _, ret := range returns {
+		returnsByStore[ret.StoreID] = append(returnsByStore[ret.StoreID], ret)
+	}
+	sessionsByStore := make(map[string][]*models.RegisterSession)
+	for _, session := range sessions {
+		sessionsByStore[session.StoreID] = append(sessionsByStore[session.StoreID], session)
+	}
+
+	stores := make(map[string]bool)
+	for id := range salesByStore {
+		stores[id] = true
+	}
+	for id := range returnsByStore {
+		stores[id] = true
+	}
+	for id := range sessionsByStore {
+		stores[id] = true
+	}
+	storeIDs := make([]string, 0, len(stores))
+	for id := range stores {
+		storeIDs = append(storeIDs, id)
+	}
+	sort.Strings(storeIDs)
+
+	var events []*models.AnomalyEvent
+	for _, id := range storeIDs {
+		events = append(events, s.detectRevenueDrop(id, start, end, salesByStore[id])...)
+		events = append(events, s.detectAbnormalDiscount(id, start, end, salesByStore[id])...)
+		events = append(events, s.detectRepeatedVoids(id, start, end, salesByStore[id], returnsByStore[id])...)
+		events = append(events, s.detectCashDiscrepancy(id, start, end, sessionsByStore[id])...)
+	}
+
+	for _, event := range events {
+		if err := s.repo.Upsert(ctx, event); err != nil {
+			return nil, err
+		}
+	}
+	if events == nil {
+		events = []*models.AnomalyEvent{}
+	}
+	return events, nil
+}
+
+// ListEvents は条件に合う異常イベントを取得します
+func (s *AnomalyService) ListEvents(ctx context.Context, query models.AnomalyQuery) ([]*models.AnomalyEvent, error) {
+	return s.repo.List(ctx, query)
+}
+
+// GetEvent は指定されたIDの異常イベントを取得します
+func (s *AnomalyService) GetEvent(ctx context.Context, id primitive.ObjectID) (*models.AnomalyEvent, error) {
+	event, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, ErrAnomalyNotFound
+	}
+	return event, nil
+}
+
+// ReviewEvent は異常イベントを確認済みまたは却下として記録します
+func (s *AnomalyService) ReviewEvent(ctx context.Context, id primitive.ObjectID, status models.AnomalyStatus, reviewedBy, note string) (*models.AnomalyEvent, error) {
+	if status != models.AnomalyConfirmed && status != models.AnomalyDismissed {
+		return nil, errors.New("確認結果は confirmed または dismissed を指定してください")
+	}
+	if reviewedBy == "" {
+		return nil, errors.New("確認者が指定されていません")
+	}
+
+	if err := s.repo.UpdateReview(ctx, id, status, reviewedBy, note, s.now()); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrAnomalyNotFound
+		}
+		return nil, err
+	}
+	return s.GetEvent(ctx, id)
+}
+
+// detectRevenueDrop は時間帯ごとの売上を過去の同じ曜日・時間帯と比較し、急減を検知します
+// 基準値には店舗が営業していた（売上が1件以上あった）日の同じ時間帯の売上を使います
+func (s *AnomalyService) detectRevenueDrop(storeID string, start, end time.Time, sales []*models.Sale) []*models.AnomalyEvent {
+	revenue := make(map[time.Time]float64)
+	openDays := make(map[time.Time]bool)
+	saleIDs := make(map[time.Time][]primitive.ObjectID)
+	for _, sale := range sales {
+		local := sale.CreatedAt.In(s.location)
+		hour := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, s.location)
+		revenue[hour] += sale.TotalAmount
+		openDays[time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.location)] = true
+		if !hour.Before(start) {
+			saleIDs[hour] = append(saleIDs[hour], sale.ID)
+		}
+	}
+
+	var events []*models.AnomalyEvent
+	// 締まっていない時間帯は判定しません
+	for h := 0; h < 24; h++ {
+		hourStart := time.Date(start.Year(), start.Month(), start.Day(), h, 0, 0, 0, s.location)
+		hourEnd := hourStart.Add(time.Hour)
+		if hourEnd.After(end) {
+			break
+		}
+
+		var baseline []float64
+		for week := 1; week <= s.config.SeasonalWeeks; week++ {
+			day := start.AddDate(0, 0, -7*week)
+			if !openDays[day] {
+				continue
+			}
+			past := time.Date(day.Year(), day.Month(), day.Day(), h, 0, 0, 0, s.location)
+			baseline = append(baseline, revenue[past])
+		}
+		if len(baseline) < s.config.MinBaselineSamples {
+			continue
+		}
+
+		score := anomaly.RobustZ(revenue[hourStart], baseline, 0)
+		if score.Median < s.config.MinHourlyRevenue || score.Z > -s.config.Threshold {
+			continue
+		}
+
+		event := s.newEvent(models.AnomalyRevenueDrop, storeID, "", hourStart, hourEnd, score, "hourly_revenue",
+			fmt.Sprintf("過去%d週の同じ曜日・時間帯の売上", s.config.SeasonalWeeks))
+		event.Evidence.WeekDay = hourStart.Weekday().String()
+		event.Evidence.TimeOfDay = fmt.Sprintf("%02d:00-%02d:00", h, h+1)
+		event.Evidence.RelatedIDs = saleIDs[hourStart]
+		event.Message = fmt.Sprintf("%sの売上が%.0f円で、通常（中央値%.0f円）を大きく下回っています",
+			event.Evidence.TimeOfDay, score.Value, score.Median)
+		events = append(events, event)
+	}
+	return events
+}
+
+// detectAbnormalDiscount はレジごとの1日の値引率を過去の値引率と比較し、異常に高い値引きを検知します
+func (s *AnomalyService) detectAbnormalDiscount(storeID string, start, end time.Time, sales []*models.Sale) []*models.AnomalyEvent {
+	type daily struct {
+		gross    float64
+		discount float64
+		count    int
+		saleIDs  []primitive.ObjectID
+	}
+	days := make(map[string]map[time.Time]*daily)
+	for _, sale := range sales {
+		day := s.dayOf(sale.CreatedAt)
+		if day.Before(start.AddDate(0, 0, -s.config.RollingDays)) {
+			continue
+		}
+		if days[sale.RegisterID] == nil {
+			days[sale.RegisterID] = make(map[time.Time]*daily)
+		}
+		d := days[sale.RegisterID][day]
+		if d == nil {
+			d = &daily{}
+			days[sale.RegisterID][day] = d
+		}
+		var discount float64
+		for _, promo := range sale.Promotions {
+			discount += promo.Discount
+		}
+		for _, item := range sale.Items {
+			d.gross += item.PriceAtSale * float64(item.Quantity)
+		}
+		d.discount += discount
+		d.count++
+		if discount > 0 && !day.Before(start) {
+			d.saleIDs = append(d.saleIDs, sale.ID)
+		}
+	}
+
+	rate := func(d *daily) (float64, bool) {
+		if d == nil || d.count < s.config.MinDailySales || d.gross <= 0 {
+			return 0, false
+		}
+		return d.discount / d.gross, true
+	}
+
+	var events []*models.AnomalyEvent
+	for _, registerID := range sortedKeys(days) {
+		today := days[registerID][start]
+		value, ok := rate(today)
+		if !ok {
+			continue
+		}
+		var baseline []float64
+		for day, d := range days[registerID] {
+			if !day.Before(start) {
+				continue
+			}
+			if r, ok := rate(d); ok {
+				baseline = append(baseline, r)
+			}
+		}
+		if len(baseline) < s.config.MinBaselineSamples {
+			continue
+		}
+
+		score := anomaly.RobustZ(value, baseline, s.config.MinDiscountRateScale)
+		if score.Z < s.config.Threshold {
+			continue
+		}
+
+		event := s.newEvent(models.AnomalyAbnormalDiscount, storeID, registerID, start, end, score, "discount_rate",
+			fmt.Sprintf("同じレジの過去%d日の値引率", s.config.RollingDays))
+		event.Evidence.RelatedIDs = today.saleIDs
+		event.Message = fmt.Sprintf("レジ%sの値引率が%.1f%%で、通常（中央値%.1f%%）を大きく上回っています",
+			registerID, value*100, score.Median*100)
+		events = append(events, event)
+	}
+	return events
+}
+
+// detectRepeatedVoids はレジごとの1日の取消・返品件数を過去の件数と比較し、多発を検知します
+// 基準値にはレジで売上があった日の件数（0件を含む）を使います
+func (s *AnomalyService) detectRepeatedVoids(storeID string, start, end time.Time, sales []*models.Sale, returns []*models.SaleReturn) []*models.AnomalyEvent {
+	from := start.AddDate(0, 0, -s.config.RollingDays)
+	activeDays := make(map[string]map[time.Time]bool)
+	for _, sale := range sales {
+		day := s.dayOf(sale.CreatedAt)
+		if day.Before(from) {
+			continue
+		}
+		if activeDays[sale.RegisterID] == nil {
+			activeDays[sale.RegisterID] = make(map[time.Time]bool)
+		}
+		activeDays[sale.RegisterID][day] = true
+	}
+
+	counts := make(map[string]map[time.Time]int)
+	returnIDs := make(map[string][]primitive.ObjectID)
+	for _, ret := range returns {
+		day := s.dayOf(ret.CreatedAt)
+		if counts[ret.RegisterID] == nil {
+			counts[ret.RegisterID] = make(map[time.Time]int)
+		}
+		counts[ret.RegisterID][day]++
+		if !day.Before(start) {
+			returnIDs[ret.RegisterID] = append(returnIDs[ret.RegisterID], ret.ID)
+		}
+	}
+
+	var events []*models.AnomalyEvent
+	for _, registerID := range sortedKeys(counts) {
+		value := counts[registerID][start]
+		if value < s.config.MinVoids {
+			continue
+		}
+		var baseline []float64
+		for day := range activeDays[registerID] {
+			if day.Before(start) {
+				baseline = append(baseline, float64(counts[registerID][day]))
+			}
+		}
+		if len(baseline) < s.config.MinBaselineSamples {
+			continue
+		}
+
+		score := anomaly.RobustZ(float64(value), baseline, 1)
+		if score.Z < s.config.Threshold {
+			continue
+		}
+
+		event := s.newEvent(models.AnomalyRepeatedVoids, storeID, registerID, start, end, score, "return_count",
+			fmt.Sprintf("同じレジの過去%d日の取消・返品件数", s.config.RollingDays))
+		event.Evidence.RelatedIDs = returnIDs[registerID]
+		event.Message = fmt.Sprintf("レジ%sで取消・返品が%d件あり、通常（中央値%.1f件）より多くなっています",
+			registerID, value, score.Median)
+		events = append(events, event)
+	}
+	return events
+}
+
+// detectCashDiscrepancy は締めたセッションの現金過不足を店舗の過去の過不足と比較し、異常な差額を検知します
+func (s *AnomalyService) detectCashDiscrepancy(storeID string, start, end time.Time, sessions []*models.RegisterSession) []*models.AnomalyEvent {
+	var baseline []float64
+	var targets []*models.RegisterSession
+	for _, session := range sessions {
+		if session.Status != models.RegisterSessionClosed {
+			continue
+		}
+		if session.OpenedAt.Before(start) {
+			baseline = append(baseline, session.CashOverShort)
+		} else {
+			targets = append(targets, session)
+		}
+	}
+	if len(baseline) < s.config.MinBaselineSamples {
+		return nil
+	}
+
+	var events []*models.AnomalyEvent
+	for _, session := range targets {
+		if math.Abs(session.CashOverShort) < s.config.MinCashDiscrepancy {
+			continue
+		}
+		score := anomaly.RobustZ(session.CashOverShort, baseline, s.config.MinCashScale)
+		if math.Abs(score.Z) < s.config.Threshold {
+			continue
+		}
+
+		windowEnd := end
+		if session.ClosedAt != nil {
+			windowEnd = *session.ClosedAt
+		}
+		event := s.newEvent(models.AnomalyCashDiscrepancy, storeID, session.RegisterID, session.OpenedAt, windowEnd, score, "cash_over_short",
+			fmt.Sprintf("店舗の過去%d日の締め時の現金過不足", s.config.RollingDays))
+		// 同じレジで1日に複数回締める場合もあるため、セッション単位で識別します
+		event.Fingerprint = fmt.Sprintf("%s|%s", event.Fingerprint, session.ID.Hex())
+		event.Evidence.RelatedIDs = []primitive.ObjectID{session.ID}
+		label := "過剰"
+		if session.CashOverShort < 0 {
+			label = "不足"
+		}
+		event.Message = fmt.Sprintf("レジ%sの締めで現金が%.0f円%sしています", session.RegisterID, math.Abs(session.CashOverShort), label)
+		events = append(events, event)
+	}
+	return events
+}
+
+// newEvent は判定結果から異常イベントを作成します
+func (s *AnomalyService) newEvent(typ models.AnomalyType, storeID, registerID string, windowStart, windowEnd time.Time, score anomaly.Score, metric, baseline string) *models.AnomalyEvent {
+	return &models.AnomalyEvent{
+		Fingerprint: fmt.Sprintf("%s|%s|%s|%s", typ, storeID, registerID, windowStart.UTC().Format(time.RFC3339)),
+		Type:        typ,
+		Severity:    s.severity(score.Z),
+		Status:      models.AnomalyOpen,
+		StoreID:     storeID,
+		RegisterID:  registerID,
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+		Evidence: models.AnomalyEvidence{
+			Metric:     metric,
+			Value:      score.Value,
+			Median:     score.Median,
+			MAD:        score.MAD,
+			Scale:      score.Scale,
+			ZScore:     score.Z,
+			Threshold:  s.config.Threshold,
+			SampleSize: score.SampleSize,
+			Baseline:   baseline,
+		},
+	}
+}
+
+// severity はzスコアの大きさから重要度を判定します
+func (s *AnomalyService) severity(z float64) models.AnomalySeverity {
+	switch z = math.Abs(z); {
+	case z >= s.config.Threshold*2.5:
+		return models.AnomalySeverityHigh
+	case z >= s.config.Threshold*1.5:
+		return models.AnomalySeverityMedium
+	default:
+		return models.AnomalySeverityLow
+	}
+}
+
+// businessDay は日付を含む店舗の営業日の開始・終了時刻を返します
+func (s *AnomalyService) businessDay(date time.Time) (time.Time, time.Time) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, s.location)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// dayOf は時刻を含む営業日の開始時刻を返します
+func (s *AnomalyService) dayOf(t time.Time) time.Time {
+	start, _ := s.businessDay(t.In(s.location))
+	return start
+}
+
+// sortedKeys はマップのキーを昇順に並べて返します
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+type MockAnomalyRepository struct {
+	mock.Mock
+}
+
+var _ repository.AnomalyRepository = (*MockAnomalyRepository)(nil)
+
+func (m *MockAnomalyRepository) Upsert(ctx context.Context, event *models.AnomalyEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockAnomalyRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.AnomalyEvent, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AnomalyEvent), args.Error(1)
+}
+
+func (m *MockAnomalyRepository) List(ctx context.Context, query models.AnomalyQuery) ([]*models.AnomalyEvent, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]*models.AnomalyEvent), args.Error(1)
+}
+
+func (m *MockAnomalyRepository) UpdateReview(ctx context.Context, id primitive.ObjectID, status models.AnomalyStatus, reviewedBy, note string, at time.Time) error {
+	args := m.Called(ctx, id, status, reviewedBy, note, at)
+	return args.Error(0)
+}
+
+// newTestAnomalyService は固定の現在時刻で検知するサービスを作成します
+func newTestAnomalyService(now time.Time) (*AnomalyService, *MockAnomalyRepository, *MockSaleRepository, *MockSaleReturnRepository, *MockRegisterSessionRepository) {
+	repo := new(MockAnomalyRepository)
+	saleRepo := new(MockSaleRepository)
+	returnRepo := new(MockSaleReturnRepository)
+	sessionRepo := new(MockRegisterSessionRepository)
+	svc := NewAnomalyService(repo, saleRepo, returnRepo, sessionRepo, DefaultAnomalyConfig(), time.UTC)
+	svc.now = func() time.Time { return now }
+	return svc, repo, saleRepo, returnRepo, sessionRepo
+}
+
+func TestDetect(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC) // 月曜日
+	now := day.Add(20 * time.Hour)
+
+	newSale := func(at time.Time, registerID string, amount, discount float64) *models.Sale {
+		sale := &models.Sale{
+			ID:          primitive.NewObjectID(),
+			StoreID:     "store-1",
+			RegisterID:  registerID,
+			TotalAmount: amount,
+			Items:       []models.SaleItem{{Quantity: 1, PriceAtSale: amount + discount}},
+			CreatedAt:   at,
+		}
+		if discount > 0 {
+			sale.Promotions = []models.AppliedPromotion{{Code: "P", Discount: discount}}
+		}
+		return sale
+	}
+
+	t.Run("売上の急減と値引率の異常を検知する", func(t *testing.T) {
+		svc, repo, saleRepo, returnRepo, sessionRepo := newTestAnomalyService(now)
+
+		var sales []*models.Sale
+		// 過去8週の月曜日の12時台は売上約1万円
+		for week := 1; week <= 8; week++ {
+			d := day.AddDate(0, 0, -7*week)
+			for i := 0; i < 5; i++ {
+				sales = append(sales, newSale(d.Add(12*time.Hour+time.Duration(i)*time.Minute), "reg-1", 2000+float64(week*10), 0))
+			}
+		}
+		// 過去の毎日、reg-2の値引率は約5%
+		for i := 1; i <= 28; i++ {
+			d := day.AddDate(0, 0, -i)
+			for j := 0; j < 5; j++ {
+				sales = append(sales, newSale(d.Add(15*time.Hour), "reg-2", 950, 50))
+			}
+		}
+		// 当日は12時台の売上がほぼなく、reg-2で半額の値引きが続く
+		sales = append(sales, newSale(day.Add(12*time.Hour), "reg-1", 500, 0))
+		for j := 0; j < 5; j++ {
+			sales = append(sales, newSale(day.Add(15*time.Hour), "reg-2", 500, 500))
+		}
+
+		saleRepo.On("FindSales", ctx, mock.Anything).Return(sales, nil)
+		returnRepo.On("FindReturns", ctx, mock.Anything).Return([]*models.SaleReturn{}, nil)
+		sessionRepo.On("ListByPeriod", ctx, "", "", mock.Anything, now).Return([]*models.RegisterSession{}, nil)
+		repo.On("Upsert", ctx, mock.Anything).Return(nil)
+
+		events, err := svc.Detect(ctx, "", day)
+		assert.NoError(t, err)
+
+		byType := make(map[models.AnomalyType]*models.AnomalyEvent)
+		for _, e := range events {
+			byType[e.Type] = e
+		}
+		assert.Len(t, events, 2)
+
+		drop := byType[models.AnomalyRevenueDrop]
+		if assert.NotNil(t, drop) {
+			assert.Equal(t, day.Add(12*time.Hour), drop.WindowStart)
+			assert.Equal(t, "12:00-13:00", drop.Evidence.TimeOfDay)
+			assert.Equal(t, "Monday", drop.Evidence.WeekDay)
+			assert.Equal(t, 500.0, drop.Evidence.Value)
+			assert.Equal(t, 8, drop.Evidence.SampleSize)
+			assert.Less(t, drop.Evidence.ZScore, -3.5)
+			assert.Equal(t, models.AnomalySeverityHigh, drop.Severity)
+		}
+
+		discount := byType[models.AnomalyAbnormalDiscount]
+		if assert.NotNil(t, discount) {
+			assert.Equal(t, "reg-2", discount.RegisterID)
+			assert.InDelta(t, 0.5, discount.Evidence.Value, 1e-9)
+			assert.InDelta(t, 0.05, discount.Evidence.Median, 1e-9)
+			assert.Len(t, discount.Evidence.RelatedIDs, 5)
+		}
+		repo.AssertNumberOfCalls(t, "Upsert", 2)
+	})
+
+	t.Run("取消の多発と現金過不足を検知する", func(t *testing.T) {
+		svc, repo, saleRepo, returnRepo, sessionRepo := newTestAnomalyService(now)
+
+		var sales []*models.Sale
+		var returns []*models.SaleReturn
+		var sessions []*models.RegisterSession
+		for i := 0; i <= 10; i++ {
+			d := day.AddDate(0, 0, -i)
+			sales = append(sales, newSale(d.Add(10*time.Hour), "reg-1", 100, 0))
+			if i > 0 {
+				// 過去の取消は2日に1件、過不足は±50円程度
+				if i%2 == 0 {
+					returns = append(returns, &models.SaleReturn{ID: primitive.NewObjectID(), StoreID: "store-1", RegisterID: "reg-1", CreatedAt: d.Add(11 * time.Hour)})
+				}
+				sessions = append(sessions, &models.RegisterSession{ID: primitive.NewObjectID(), StoreID: "store-1", RegisterID: "reg-1", Status: models.RegisterSessionClosed, OpenedAt: d.Add(9 * time.Hour), CashOverShort: float64(i%3-1) * 50})
+			}
+		}
+		for j := 0; j < 6; j++ {
+			returns = append(returns, &models.SaleReturn{ID: primitive.NewObjectID(), StoreID: "store-1", RegisterID: "reg-1", CreatedAt: day.Add(11 * time.Hour)})
+		}
+		closedAt := day.Add(19 * time.Hour)
+		today := &models.RegisterSession{ID: primitive.NewObjectID(), StoreID: "store-1", RegisterID: "reg-1", Status: models.RegisterSessionClosed, OpenedAt: day.Add(9 * time.Hour), ClosedAt: &closedAt, CashOverShort: -3000}
+		sessions = append(sessions, today)
+
+		saleRepo.On("FindSales", ctx, mock.Anything).Return(sales, nil)
+		returnRepo.On("FindReturns", ctx, mock.Anything).Return(returns, nil)
+		sessionRepo.On("ListByPeriod", ctx, "store-1", "", mock.Anything, now).Return(sessions, nil)
+		repo.On("Upsert", ctx, mock.Anything).Return(nil)
+
+		events, err := svc.Detect(ctx, "store-1", day)
+		assert.NoError(t, err)
+		assert.Len(t, events, 2)
+
+		byType := make(map[models.AnomalyType]*models.AnomalyEvent)
+		for _, e := range events {
+			byType[e.Type] = e
+		}
+		voids := byType[models.AnomalyRepeatedVoids]
+		if assert.NotNil(t, voids) {
+			assert.Equal(t, 6.0, voids.Evidence.Value)
+			assert.Len(t, voids.Evidence.RelatedIDs, 6)
+		}
+		cash := byType[models.AnomalyCashDiscrepancy]
+		if assert.NotNil(t, cash) {
+			assert.Equal(t, closedAt, cash.WindowEnd)
+			assert.Equal(t, []primitive.ObjectID{today.ID}, cash.Evidence.RelatedIDs)
+			assert.Contains(t, cash.Fingerprint, today.ID.Hex())
+			assert.Contains(t, cash.Message, "3000円不足")
+		}
+	})
+
+	t.Run("基準値が少ない場合は判定しない", func(t *testing.T) {
+		svc, repo, saleRepo, returnRepo, sessionRepo := newTestAnomalyService(now)
+
+		sales := []*models.Sale{
+			newSale(day.AddDate(0, 0, -7).Add(12*time.Hour), "reg-1", 10000, 0),
+			newSale(day.Add(9*time.Hour), "reg-1", 100, 0),
+		}
+		saleRepo.On("FindSales", ctx, mock.Anything).Return(sales, nil)
+		returnRepo.On("FindReturns", ctx, mock.Anything).Return([]*models.SaleReturn{}, nil)
+		sessionRepo.On("ListByPeriod", ctx, "", "", mock.Anything, now).Return([]*models.RegisterSession{}, nil)
+
+		events, err := svc.Detect(ctx, "", day)
+		assert.NoError(t, err)
+		assert.Empty(t, events)
+		repo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+	})
+}
+
+func TestReviewEvent(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 11, 20, 0, 0, 0, time.UTC)
+	id := primitive.NewObjectID()
+
+	t.Run("正常系", func(t *testing.T) {
+		svc, repo, _, _, _ := newTestAnomalyService(now)
+		reviewed := &models.AnomalyEvent{ID: id, Status: models.AnomalyDismissed}
+		repo.On("UpdateReview", ctx, id, models.AnomalyDismissed, "manager", "棚卸しのため", now).Return(nil)
+		repo.On("GetByID", ctx, id).Return(reviewed, nil)
+
+		event, err := svc.ReviewEvent(ctx, id, models.AnomalyDismissed, "manager", "棚卸しのため")
+		assert.NoError(t, err)
+		assert.Equal(t, reviewed, event)
+	})
+
+	t.Run("異常系: 存在しないイベント", func(t *testing.T) {
+		svc, repo, _, _, _ := newTestAnomalyService(now)
+		repo.On("UpdateReview", ctx, id, models.AnomalyConfirmed, "manager", "", now).Return(mongo.ErrNoDocuments)
+
+		_, err := svc.ReviewEvent(ctx, id, models.AnomalyConfirmed, "manager", "")
+		assert.ErrorIs(t, err, ErrAnomalyNotFound)
+	})
+
+	t.Run("異常系: 無効な確認結果", func(t *testing.T) {
+		svc, repo, _, _, _ := newTestAnomalyService(now)
+
+		_, err := svc.ReviewEvent(ctx, id, models.AnomalyOpen, "manager", "")
+		assert.Error(t, err)
+		repo.AssertNotCalled(t, "UpdateReview")
+	})
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/calendar"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+// ErrStoreEventNotFound は店舗のイベントが存在しない場合のエラーです
+var ErrStoreEventNotFound = errors.New("store event not found")
+
+// maxCalendarDays は営業カレンダーを一度に取得できる日数の上限です
+const maxCalendarDays = 366
+
+// StoreEventRequest は店舗のイベントの登録内容です
+type StoreEventRequest struct {
+	// StoreID が空の場合は全店舗共通のイベントです
+	StoreID   string                `json:"storeId"`
+	Name      string                `json:"name"`
+	Type      models.StoreEventType `json:"type"`
+	StartDate string                `json:"startDate"`
+	EndDate   string                `json:"endDate"`
+	Note      string                `json:"note"`
+}
+
+// Validate はイベントの登録内容を検証します
+func (r *StoreEventRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("イベント名を入力してください")
+	}
+	if !models.ValidateStoreEventType(r.Type) {
+		return errors.New("イベントの種類は festival, campaign, closure, other のいずれかを指定してください")
+	}
+	start, err := time.Parse("2006-01-02", r.StartDate)
+	if err != nil {
+		return errors.New("開始日は 2006-01-02 形式で指定してください")
+	}
+	end, err := time.Parse("2006-01-02", r.EndDate)
+	if err != nil {
+		return errors.New("終了日は 2006-01-02 形式で指定してください")
+	}
+	if end.Before(start) {
+		return errors.New("終了日は開始日以降の日付を指定してください")
+	}
+	return nil
+}
+
+// CalendarServiceInterface は営業カレンダーサービスのインターフェースを定義します
+type CalendarServiceInterface interface {
+	CreateEvent(ctx context.Context, req *StoreEventRequest) (*models.StoreEvent, error)
+	UpdateEvent(ctx context.Context, id primitive.ObjectID, req *StoreEventRequest) (*models.StoreEvent, error)
+	DeleteEvent(ctx context.Context, id primitive.ObjectID) error
+	ListEvents(ctx context.Context, query models.StoreEventQuery) ([]*models.StoreEvent, error)
+	GetCalendar(ctx context.Context, storeID string, start, end time.Time) ([]models.CalendarDay, error)
+	StoreCalendar(ctx context.Context, storeID string, start, end time.Time) (*calendar.Store, error)
+}
+
+// CalendarService は国民の祝日と店舗のイベントによる営業カレンダーを管理するサービスです
+type CalendarService struct {
+	repo     repository.StoreEventRepository
+	holidays *calendar.Japan
+	location *time.Location
+}
+
+func NewCalendarService(repo repository.StoreEventRepository, holidays *calendar.Japan, location *time.Location) *CalendarService {
+	return &CalendarService{
+		repo:     repo,
+		holidays: holidays,
+		location: location,
+	}
+}
+
+// CreateEvent は店舗のイベントを登録します
+func (s *CalendarService) CreateEvent(ctx context.Context, req *StoreEventRequest) (*models.StoreEvent, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	event := newStoreEvent(req)
+	if err := s.repo.Create(ctx, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// UpdateEvent は店舗のイベントを更新します
+func (s *CalendarService) UpdateEvent(ctx context.Context, id primitive.ObjectID, req *StoreEventRequest) (*models.StoreEvent, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrStoreEventNotFound
+	}
+
+	event := newStoreEvent(req)
+	event.ID = id
+	event.CreatedAt = existing.CreatedAt
+	updated, err := s.repo.Update(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrStoreEventNotFound
+	}
+	return event, nil
+}
+
+// DeleteEvent は店舗のイベントを削除します
+func (s *CalendarService) DeleteEvent(ctx context.Context, id primitive.ObjectID) error {
+	deleted, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrStoreEventNotFound
+	}
+	return nil
+}
+
+// ListEvents は条件に合う店舗のイベントを取得します
+func (s *CalendarService) ListEvents(ctx context.Context, query models.StoreEventQuery) ([]*models.StoreEvent, error) {
+	return s.repo.List(ctx, query)
+}
+
+// GetCalendar は期間（終了日を含む）の各日の種類・祝日・イベントを取得します
+func (s *CalendarService) GetCalendar(ctx context.Context, storeID string, start, end time.Time) ([]models.CalendarDay, error) {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, s.location)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, s.location)
+	if end.Before(start) {
+		return nil, errors.New("終了日は開始日以降の日付を指定してください")
+	}
+	if end.Sub(start).Hours()/24 >= maxCalendarDays {
+		return nil, errors.New("営業カレンダーは366日以内の期間を指定してください")
+	}
+
+	cal, err := s.StoreCalendar(ctx, storeID, start, end.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	days := []models.CalendarDay{}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		days = append(days, cal.Day(d))
+	}
+	return days, nil
+}
+
+// StoreCalendar は期間 [start, end) の店舗の営業カレンダーを作成します
+// storeID が空の場合は全店舗共通のイベントのみを含みます
+func (s *CalendarService) StoreCalendar(ctx context.Context, storeID string, start, end time.Time) (*calendar.Store, error) {
+	events, err := s.repo.List(ctx, models.StoreEventQuery{
+		StoreID:   storeID,
+		StartDate: start.In(s.location).Format("2006-01-02"),
+		EndDate:   end.In(s.location).AddDate(0, 0, -1).Format("2006-01-02"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if storeID == "" {
+		common := events[:0]
+		for _, event := range events {
+			if event.StoreID == "" {
+				common = append(common, event)
+			}
+		}
+		events = common
+	}
+	return calendar.NewStore(s.holidays, events), nil
+}
+
+func newStoreEvent(req *StoreEventRequest) *models.StoreEvent {
+	return &models.StoreEvent{
+		StoreID:   strings.TrimSpace(req.StoreID),
+		Name:      strings.TrimSpace(req.Name),
+		Type:      req.Type,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Note:      req.Note,
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/calendar"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+type MockStoreEventRepository struct {
+	mock.Mock
+}
+
+var _ repository.StoreEventRepository = (*MockStoreEventRepository)(nil)
+
+func (m *MockStoreEventRepository) Create(ctx context.Context, event *models.StoreEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockStoreEventRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.StoreEvent, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.StoreEvent), args.Error(1)
+}
+
+func (m *MockStoreEventRepository) Update(ctx context.Context, event *models.StoreEvent) (bool, error) {
+	args := m.Called(ctx, event)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockStoreEventRepository) List(ctx context.Context, query models.StoreEventQuery) ([]*models.StoreEvent, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]*models.StoreEvent), args.Error(1)
+}
+
+func (m *MockStoreEventRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// newTestCalendarService は指定したイベントを返す営業カレンダーサービスを作成します
+func newTestCalendarService(location *time.Location, events ...*models.StoreEvent) *CalendarService {
+	repo := new(MockStoreEventRepository)
+	repo.On("List", mock.Anything, mock.AnythingOfType("models.StoreEventQuery")).Return(events, nil)
+	return NewCalendarService(repo, calendar.NewJapan(), location)
+}
+
+func TestGetCalendar(t *testing.T) {
+	ctx := context.Background()
+	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
+	mockRepo := new(MockStoreEventRepository)
+	service := NewCalendarService(mockRepo, calendar.NewJapan(), jst)
+
+	events := []*models.StoreEvent{
+		{StoreID: "store-1", Name: "夏祭り", Type: models.StoreEventFestival, StartDate: "2024-07-13", EndDate: "2024-07-15"},
+		{Name: "棚卸", Type: models.StoreEventClosure, StartDate: "2024-07-15", EndDate: "2024-07-15"},
+	}
+	mockRepo.On("List", ctx, models.StoreEventQuery{StoreID: "store-1", StartDate: "2024-07-12", EndDate: "2024-07-16"}).Return(events, nil)
+	mockRepo.On("List", ctx, models.StoreEventQuery{StartDate: "2024-07-12", EndDate: "2024-07-16"}).Return(events, nil)
+
+	t.Run("祝日・イベント・休業日の判定", func(t *testing.T) {
+		days, err := service.GetCalendar(ctx, "store-1", time.Date(2024, 7, 12, 0, 0, 0, 0, jst), time.Date(2024, 7, 16, 0, 0, 0, 0, jst))
+		assert.NoError(t, err)
+
+		var got []models.DayType
+		for _, d := range days {
+			got = append(got, d.DayType)
+		}
+		// 07-15は海の日・夏祭りだが休業日を優先
+		assert.Equal(t, []models.DayType{
+			models.DayTypeWeekday,
+			models.DayTypeEvent,
+			models.DayTypeEvent,
+			models.DayTypeClosed,
+			models.DayTypeWeekday,
+		}, got)
+		assert.Equal(t, "海の日", days[3].HolidayName)
+		assert.Len(t, days[3].Events, 2)
+	})
+
+	t.Run("店舗未指定の場合は全店舗共通のイベントのみ", func(t *testing.T) {
+		days, err := service.GetCalendar(ctx, "", time.Date(2024, 7, 12, 0, 0, 0, 0, jst), time.Date(2024, 7, 16, 0, 0, 0, 0, jst))
+		assert.NoError(t, err)
+		assert.Equal(t, models.DayTypeWeekend, days[1].DayType)
+		assert.Equal(t, models.DayTypeClosed, days[3].DayType)
+		assert.Len(t, days[3].Events, 1)
+	})
+}
+
+func TestCreateEvent(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockStoreEventRepository)
+	service := NewCalendarService(mockRepo, calendar.NewJapan(), time.UTC)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*models.StoreEvent")).Return(nil)
+
+	tests := []struct {
+		name    string
+		req     StoreEventRequest
+		wantErr bool
+	}{
+		{
+			name:    "正常なイベント登録",
+			req:     StoreEventRequest{StoreID: "store-1", Name: "歳末セール", Type: models.StoreEventCampaign, StartDate: "2024-12-26", EndDate: "2024-12-31"},
+			wantErr: false,
+		},
+		{
+			name:    "無効な種類でエラー",
+			req:     StoreEventRequest{Name: "歳末セール", Type: "sale", StartDate: "2024-12-26", EndDate: "2024-12-31"},
+			wantErr: true,
+		},
+		{
+			name:    "終了日が開始日より前でエラー",
+			req:     StoreEventRequest{Name: "歳末セール", Type: models.StoreEventCampaign, StartDate: "2024-12-31", EndDate: "2024-12-26"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateEvent(ctx, &tt.req)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
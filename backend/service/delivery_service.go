@@ -3,57 +3,204 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/onoderaryou/smart-store-admin/backend/models"
 	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/storage"
 )
 
 // DeliveryServiceInterface は配送サービスのインターフェースを定義します
 type DeliveryServiceInterface interface {
-	CreateDelivery(ctx context.Context, delivery *models.Delivery) error
+	CreateDelivery(ctx context.Context, delivery *models.Delivery, actor string) error
 	GetDeliveryByID(ctx context.Context, id primitive.ObjectID) (*models.Delivery, error)
-	UpdateDeliveryStatus(ctx context.Context, id primitive.ObjectID, status models.DeliveryStatus) error
-	UpdateDeliveryLocation(ctx context.Context, id primitive.ObjectID, location models.Location) error
+	UpdateDeliveryStatus(ctx context.Context, id primitive.ObjectID, status models.DeliveryStatus, actor string) error
+	UpdateDeliveryLocation(ctx context.Context, id primitive.ObjectID, tracking models.TrackingInfo, actor string) error
 	GetActiveDeliveries(ctx context.Context) ([]*models.Delivery, error)
 	GetDeliveriesByRobot(ctx context.Context, robotID string) ([]*models.Delivery, error)
 	GetDeliveries(query *models.DeliveryQuery) (*models.DeliveryResponse, error)
 	GetDeliveryHistory(id string) (*models.DeliveryHistoryResponse, error)
-	UpdateDelivery(id string, delivery *models.Delivery) error
+	UpdateDelivery(id string, delivery *models.Delivery, actor string) error
+}
+
+// TrackingPublisher は配送のライブ追跡イベントを購読者へ配信します
+type TrackingPublisher interface {
+	Publish(event models.TrackingEvent) models.TrackingEvent
+}
+
+// SlotBooker は配送の登録時に配送時間枠の予約を確定します
+type SlotBooker interface {
+	BookSlot(ctx context.Context, slotID, reservationID string) (*models.SlotReservation, *models.DeliverySlot, error)
+	AttachDelivery(ctx context.Context, reservationID primitive.ObjectID, deliveryID string) error
+	CancelReservation(ctx context.Context, id primitive.ObjectID) error
+}
+
+// ErrSlotBookingUnavailable は配送時間枠の予約を扱えない構成で時間枠を指定した場合のエラーです
+var ErrSlotBookingUnavailable = errors.New("delivery slot booking is not available")
+
+// DeliveryConfig は配送サービスの設定です
+type DeliveryConfig struct {
+	// MaxAttempts は配送の試行回数の上限です（0以下の場合は制限しません）
+	MaxAttempts int
+	// ProofRadius は配送の証跡の受け渡し地点として認める配送先からの距離（m）です
+	ProofRadius float64
+	// MaxProofPhotoSize は配送の証跡の写真の最大サイズ（バイト）です
+	MaxProofPhotoSize int64
+	// MaxPINAttempts は受取人の暗証番号の照合回数の上限です（達すると暗証番号をロックします。0以下の場合は制限しません）
+	MaxPINAttempts int
+}
+
+// DefaultDeliveryConfig は既定の設定（試行回数の上限3回、受け渡し地点は配送先から50m以内、写真は10MBまで、暗証番号の照合は5回まで）を返します
+func DefaultDeliveryConfig() DeliveryConfig {
+	return DeliveryConfig{
+		MaxAttempts:       3,
+		ProofRadius:       50,
+		MaxProofPhotoSize: 10 << 20,
+		MaxPINAttempts:    5,
+	}
 }
 
 // DeliveryService は配送サービスを表します
 type DeliveryService struct {
-	repo repository.DeliveryRepository
+	repo      repository.DeliveryRepository
+	publisher TrackingPublisher
+	slots     SlotBooker
+	blobs     storage.BlobStore
+	config    DeliveryConfig
+	states    *DeliveryStateMachine
+	now       func() time.Time
 }
 
 // NewDeliveryService は新しい配送サービスを作成します
-func NewDeliveryService(repo repository.DeliveryRepository) *DeliveryService {
-	return &DeliveryService{
-		repo: repo,
+// publisher を指定した場合、位置・ステータスの更新をライブ追跡イベントとして配信します
+// slots を指定した場合、配送時間枠を指定した配送の登録時に枠の予約を確定し、取り消し時に予約を取り消します
+// blobs を指定した場合、ロボット・ドローンから配送の証跡（受け渡し写真）を受け付けます
+func NewDeliveryService(repo repository.DeliveryRepository, publisher TrackingPublisher, slots SlotBooker, blobs storage.BlobStore, config DeliveryConfig) *DeliveryService {
+	s := &DeliveryService{
+		repo:      repo,
+		publisher: publisher,
+		slots:     slots,
+		blobs:     blobs,
+		config:    config,
+		now:       time.Now,
 	}
+	s.states = NewDeliveryStateMachine(s.transitions())
+	return s
 }
 
-// CreateDelivery は新しい配送を作成します
-func (s *DeliveryService) CreateDelivery(ctx context.Context, delivery *models.Delivery) error {
+// transitions は配送ステータスの遷移の定義です
+// ステータスを追加する場合は、遷移とそのガード・副作用をここに追加します
+func (s *DeliveryService) transitions() []DeliveryTransition {
+	return []DeliveryTransition{
+		// 出発するたびに試行回数を数えます
+		{From: []models.DeliveryStatus{models.StatusPreparing}, To: models.StatusInProgress, CountAttempt: true},
+		// 完了・失敗・取り消し・返送済みでは、割り当てた機体を待機中に戻します
+		// 完了にできるのは配送の証跡を提出した場合（SubmitProofOfDelivery）だけです
+		// 暗証番号がロックされた配送や配送先の位置が登録されていない配送は、失敗にしてから店舗へ返送します
+		{From: []models.DeliveryStatus{models.StatusInProgress}, To: models.StatusCompleted, Guard: requireProof, ReleaseRobot: true},
+		{From: []models.DeliveryStatus{models.StatusPreparing, models.StatusInProgress}, To: models.StatusFailed, ReleaseRobot: true},
+		// 出発前の保留と再開
+		{From: []models.DeliveryStatus{models.StatusPreparing}, To: models.StatusOnHold},
+		{From: []models.DeliveryStatus{models.StatusOnHold}, To: models.StatusPreparing},
+		// 出発前の取り消しでは、引き当てた在庫と配送時間枠の予約を取り消して枠の空きを戻します
+		{
+			From:         []models.DeliveryStatus{models.StatusPreparing, models.StatusOnHold},
+			To:           models.StatusCancelled,
+			ReleaseRobot: true,
+			Restock:      true,
+			Effects:      []func(context.Context, *models.Delivery) error{s.cancelSlotReservation},
+		},
+		// 失敗後は試行回数の上限まで再配送できます（機体は割り当て直します）
+		{From: []models.DeliveryStatus{models.StatusFailed}, To: models.StatusPreparing, Guard: s.checkAttempts, ReleaseRobot: true, UnassignRobot: true},
+		// 配送できなかった商品は店舗へ返送し、到着したら在庫に戻します
+		{From: []models.DeliveryStatus{models.StatusFailed}, To: models.StatusReturning},
+		{From: []models.DeliveryStatus{models.StatusReturning}, To: models.StatusReturned, ReleaseRobot: true, Restock: true},
+	}
+}
+
+// CreateDelivery は新しい配送を作成します（actor は配送履歴に記録する作成者です）
+// 配送時間枠（SlotID）または仮押さえ（ReservationID）を指定した場合は、登録前に枠の予約を確定して
+// 到着時間帯を枠の時間にします。枠が満席の場合や仮押さえの期限が切れている場合は登録しません
+// 配送する商品の在庫は登録と同時に引き当て、足りない場合は repository.ErrInsufficientStock を返します
+func (s *DeliveryService) CreateDelivery(ctx context.Context, delivery *models.Delivery, actor string) error {
+	booking := delivery.SlotID != "" || delivery.ReservationID != ""
 	if delivery.DeliveryType == "" {
 		return errors.New("delivery type is required")
 	}
 	if delivery.Address == "" {
 		return errors.New("address is required")
 	}
-	if delivery.EstimatedDeliveryTime.IsZero() {
+	if delivery.EstimatedDeliveryTime.IsZero() && !booking {
 		return errors.New("estimated delivery time is required")
 	}
+	if !booking {
+		if err := validateDeliveryWindow(delivery); err != nil {
+			return err
+		}
+	}
+	if err := validateDeliveryItems(delivery.Items); err != nil {
+		return err
+	}
+	// 受取人の暗証番号を指定しない場合は発行します（登録時の応答でのみ返し、保存するのはハッシュだけです）
+	if delivery.RecipientPIN == "" {
+		pin, err := newRecipientPIN()
+		if err != nil {
+			return err
+		}
+		delivery.RecipientPIN = pin
+	} else if !ValidRecipientPIN(delivery.RecipientPIN) {
+		return ErrInvalidRecipientPIN
+	}
+	hash, err := hashRecipientPIN(delivery.RecipientPIN)
+	if err != nil {
+		return err
+	}
+	delivery.RecipientPINHash = hash
+	delivery.PINAttempts = 0
+
+	var reservation *models.SlotReservation
+	if booking {
+		if s.slots == nil {
+			return ErrSlotBookingUnavailable
+		}
+		var slot *models.DeliverySlot
+		var err error
+		reservation, slot, err = s.slots.BookSlot(ctx, delivery.SlotID, delivery.ReservationID)
+		if err != nil {
+			return err
+		}
+		delivery.SlotID = slot.ID.Hex()
+		delivery.ReservationID = reservation.ID.Hex()
+		delivery.WindowStart = &slot.Start
+		delivery.WindowEnd = &slot.End
+		if delivery.EstimatedDeliveryTime.IsZero() {
+			delivery.EstimatedDeliveryTime = slot.End
+		}
+	}
 
 	// 初期状態の設定
 	delivery.Status = models.StatusPreparing
 	delivery.CreatedAt = time.Now()
 	delivery.UpdatedAt = time.Now()
 
-	return s.repo.Create(ctx, delivery)
+	if err := s.repo.Create(ctx, delivery, actor); err != nil {
+		if reservation != nil {
+			// 配送を登録できなかった場合は予約を取り消して枠の空きを戻します
+			if cancelErr := s.slots.CancelReservation(ctx, reservation.ID); cancelErr != nil {
+				log.Printf("Failed to cancel slot reservation %s: %v", reservation.ID.Hex(), cancelErr)
+			}
+		}
+		return err
+	}
+	if reservation != nil {
+		if err := s.slots.AttachDelivery(ctx, reservation.ID, delivery.ID); err != nil {
+			log.Printf("Failed to attach delivery %s to slot reservation %s: %v", delivery.ID, reservation.ID.Hex(), err)
+		}
+	}
+	return nil
 }
 
 // GetDelivery は指定されたIDの配送を取得します
@@ -84,8 +231,9 @@ func (s *DeliveryService) GetDeliveryByID(ctx context.Context, id primitive.Obje
 	return s.repo.GetByID(ctx, id)
 }
 
-// UpdateDeliveryStatus は配送のステータスを更新します
-func (s *DeliveryService) UpdateDeliveryStatus(id string, status string) error {
+// UpdateDeliveryStatus は遷移の定義に従って配送のステータスを更新し、遷移の副作用を実行します
+// reason は配送履歴に記録する変更の理由（取り消し・保留の理由など）、actor は変更者です
+func (s *DeliveryService) UpdateDeliveryStatus(id string, status string, reason string, actor string) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
@@ -105,19 +253,104 @@ func (s *DeliveryService) UpdateDeliveryStatus(id string, status string) error {
 		return errors.New("delivery not found")
 	}
 
-	if !isValidStatusTransition(delivery.Status, deliveryStatus) {
-		return errors.New("invalid status transition")
+	var update models.DeliveryStatusUpdate
+	if reason != "" {
+		update.Note = &reason
 	}
+	transition, err := s.states.Check(ctx, delivery, deliveryStatus, update)
+	if err != nil {
+		return err
+	}
+	return s.applyTransition(ctx, objectID, delivery, transition, update, actor)
+}
 
-	return s.repo.UpdateStatus(ctx, objectID, deliveryStatus)
+// applyTransition は遷移を記録してから副作用を実行し、ライブ追跡イベントを配信します
+// update のステータスと試行回数の加算は transition から設定します
+func (s *DeliveryService) applyTransition(ctx context.Context, id primitive.ObjectID, delivery *models.Delivery, transition DeliveryTransition, update models.DeliveryStatusUpdate, actor string) error {
+	update.From = delivery.Status
+	update.To = transition.To
+	update.CountAttempt = transition.CountAttempt
+	update.ReleaseRobot = transition.ReleaseRobot
+	update.UnassignRobot = transition.UnassignRobot
+	update.Restock = transition.Restock
+	if err := s.repo.UpdateStatus(ctx, id, update, actor); err != nil {
+		return err
+	}
+
+	delivery.Status = update.To
+	if update.CountAttempt {
+		delivery.Attempts++
+	}
+	if update.UnassignRobot {
+		delivery.RobotID = ""
+		delivery.AssignedAt = nil
+	}
+	// 遷移は記録済みのため、副作用の失敗ではエラーを返しません
+	for _, effect := range transition.Effects {
+		if err := effect(ctx, delivery); err != nil {
+			log.Printf("Failed to apply status transition effect for delivery %s (%s -> %s): %v", id.Hex(), update.From, update.To, err)
+		}
+	}
+	s.publish(models.TrackingStatus, delivery)
+	return nil
 }
 
-// UpdateDeliveryLocation は配送の現在位置を更新します
-func (s *DeliveryService) UpdateDeliveryLocation(ctx context.Context, id primitive.ObjectID, location models.Location) error {
-	if location == (models.Location{}) {
-		return errors.New("invalid location")
+// checkAttempts は試行回数が上限に達していないことを確認します
+func (s *DeliveryService) checkAttempts(ctx context.Context, delivery *models.Delivery, update models.DeliveryStatusUpdate) error {
+	if s.config.MaxAttempts > 0 && delivery.Attempts >= s.config.MaxAttempts {
+		return ErrMaxDeliveryAttempts
 	}
-	return s.repo.UpdateLocation(ctx, id, location)
+	return nil
+}
+
+// requireProof は配送の証跡とともに遷移することを確認します
+func requireProof(ctx context.Context, delivery *models.Delivery, update models.DeliveryStatusUpdate) error {
+	if update.Proof == nil {
+		return ErrProofOfDeliveryRequired
+	}
+	return nil
+}
+
+// cancelSlotReservation は配送時間枠の予約を取り消して枠の空きを戻します
+func (s *DeliveryService) cancelSlotReservation(ctx context.Context, delivery *models.Delivery) error {
+	if delivery.ReservationID == "" || s.slots == nil {
+		return nil
+	}
+	reservationID, err := primitive.ObjectIDFromHex(delivery.ReservationID)
+	if err != nil {
+		return err
+	}
+	return s.slots.CancelReservation(ctx, reservationID)
+}
+
+// UpdateDeliveryLocation は配送の現在位置（バッテリー残量・速度を含む）を更新します
+func (s *DeliveryService) UpdateDeliveryLocation(ctx context.Context, id primitive.ObjectID, tracking models.TrackingInfo, actor string) error {
+	if err := models.ValidateTrackingInfo(tracking); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateLocation(ctx, id, tracking, actor); err != nil {
+		return err
+	}
+
+	if s.publisher == nil {
+		return nil
+	}
+	// 割り当て済みの機体やステータスを含めて配信するため、更新後の配送を取得します
+	delivery, err := s.repo.GetByID(ctx, id)
+	if err != nil || delivery == nil {
+		log.Printf("Failed to load delivery %s for tracking: %v", id.Hex(), err)
+		return nil
+	}
+	s.publish(models.TrackingLocation, delivery)
+	return nil
+}
+
+// publish は配送の現在の状態をライブ追跡イベントとして配信します
+func (s *DeliveryService) publish(eventType models.TrackingEventType, delivery *models.Delivery) {
+	if s.publisher == nil {
+		return
+	}
+	s.publisher.Publish(models.TrackingEventFromDelivery(eventType, delivery, s.now()))
 }
 
 // GetActiveDeliveries はアクティブな配送一覧を取得します
@@ -138,7 +371,7 @@ func (s *DeliveryService) GetDeliveries(query *models.DeliveryQuery) (*models.De
 	return s.repo.GetDeliveries(query)
 }
 
-// GetDeliveryHistory は配送履歴を取得します
+// GetDeliveryHistory は配送履歴（作成・ステータス・位置・配送情報の変更）を古い順に取得します
 func (s *DeliveryService) GetDeliveryHistory(id string) (*models.DeliveryHistoryResponse, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -148,40 +381,36 @@ func (s *DeliveryService) GetDeliveryHistory(id string) (*models.DeliveryHistory
 	return s.repo.GetDeliveryHistory(ctx, objectID)
 }
 
-// UpdateDelivery は配送情報を更新します
-func (s *DeliveryService) UpdateDelivery(id string, delivery *models.Delivery) error {
+// UpdateDelivery は配送情報を更新します（actor は配送履歴に記録する変更者です）
+func (s *DeliveryService) UpdateDelivery(id string, delivery *models.Delivery, actor string) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
+	if err := validateDeliveryWindow(delivery); err != nil {
+		return err
+	}
 	ctx := context.Background()
-	return s.repo.Update(ctx, objectID, delivery)
+	return s.repo.Update(ctx, objectID, delivery, actor)
 }
 
-// isValidStatusTransition はステータスの遷移が有効かどうかをチェックします
-func isValidStatusTransition(current, new models.DeliveryStatus) bool {
-	validTransitions := map[models.DeliveryStatus][]models.DeliveryStatus{
-		models.StatusPreparing: {
-			models.StatusInProgress,
-			models.StatusFailed,
-		},
-		models.StatusInProgress: {
-			models.StatusCompleted,
-			models.StatusFailed,
-		},
-		models.StatusCompleted: {}, // 完了状態からの遷移は不可
-		models.StatusFailed:    {}, // 失敗状態からの遷移は不可
-	}
-
-	allowedStatuses, exists := validTransitions[current]
-	if !exists {
-		return false
+// validateDeliveryItems は配送の商品のIDと数量を確認します
+func validateDeliveryItems(items []models.DeliveryItem) error {
+	for _, item := range items {
+		if !primitive.IsValidObjectID(item.ProductID) {
+			return errors.New("invalid product ID in delivery items")
+		}
+		if item.Quantity <= 0 {
+			return errors.New("delivery item quantity must be positive")
+		}
 	}
+	return nil
+}
 
-	for _, status := range allowedStatuses {
-		if status == new {
-			return true
-		}
+// validateDeliveryWindow は到着時間帯の開始が終了より後でないことを確認します
+func validateDeliveryWindow(delivery *models.Delivery) error {
+	if delivery.WindowStart != nil && delivery.WindowEnd != nil && delivery.WindowEnd.Before(*delivery.WindowStart) {
+		return errors.New("delivery window end must not be before its start")
 	}
-	return false
+	return nil
 }
@@ -22,8 +22,8 @@ type MockDeliveryRepository struct {
 // インターフェースが実装されていることを確認
 var _ repository.DeliveryRepository = (*MockDeliveryRepository)(nil)
 
-func (m *MockDeliveryRepository) Create(ctx context.Context, delivery *models.Delivery) error {
-	args := m.Called(ctx, delivery)
+func (m *MockDeliveryRepository) Create(ctx context.Context, delivery *models.Delivery, actor string) error {
+	args := m.Called(ctx, delivery, actor)
 	return args.Error(0)
 }
 
@@ -40,13 +40,13 @@ func (m *MockDeliveryRepository) List(ctx context.Context, skip, limit int64) ([
 	return args.Get(0).([]*models.Delivery), args.Error(1)
 }
 
-func (m *MockDeliveryRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status models.DeliveryStatus) error {
-	args := m.Called(ctx, id, status)
+func (m *MockDeliveryRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, update models.DeliveryStatusUpdate, actor string) error {
+	args := m.Called(ctx, id, update, actor)
 	return args.Error(0)
 }
 
-func (m *MockDeliveryRepository) UpdateLocation(ctx context.Context, id primitive.ObjectID, location models.Location) error {
-	args := m.Called(ctx, id, location)
+func (m *MockDeliveryRepository) UpdateLocation(ctx context.Context, id primitive.ObjectID, tracking models.TrackingInfo, actor string) error {
+	args := m.Called(ctx, id, tracking, actor)
 	return args.Error(0)
 }
 
@@ -55,6 +55,21 @@ func (m *MockDeliveryRepository) GetActiveDeliveries(ctx context.Context) ([]*mo
 	return args.Get(0).([]*models.Delivery), args.Error(1)
 }
 
+func (m *MockDeliveryRepository) RecordPINAttempt(ctx context.Context, id primitive.ObjectID, maxAttempts int) error {
+	args := m.Called(ctx, id, maxAttempts)
+	return args.Error(0)
+}
+
+func (m *MockDeliveryRepository) AssignRobot(ctx context.Context, id primitive.ObjectID, robotID string, assignedAt time.Time, actor string) error {
+	args := m.Called(ctx, id, robotID, assignedAt, actor)
+	return args.Error(0)
+}
+
+func (m *MockDeliveryRepository) WithdrawRobot(ctx context.Context, robot *models.Robot, deliveryID string, actor string) error {
+	args := m.Called(ctx, robot, deliveryID, actor)
+	return args.Error(0)
+}
+
 func (m *MockDeliveryRepository) GetDeliveriesByRobot(ctx context.Context, robotID string) ([]*models.Delivery, error) {
 	args := m.Called(ctx, robotID)
 	return args.Get(0).([]*models.Delivery), args.Error(1)
@@ -70,14 +85,29 @@ func (m *MockDeliveryRepository) GetDeliveryHistory(ctx context.Context, id prim
 	return args.Get(0).(*models.DeliveryHistoryResponse), args.Error(1)
 }
 
-func (m *MockDeliveryRepository) Update(ctx context.Context, id primitive.ObjectID, delivery *models.Delivery) error {
-	args := m.Called(ctx, id, delivery)
+func (m *MockDeliveryRepository) Update(ctx context.Context, id primitive.ObjectID, delivery *models.Delivery, actor string) error {
+	args := m.Called(ctx, id, delivery, actor)
+	return args.Error(0)
+}
+
+func (m *MockDeliveryRepository) UpdatePredictedArrival(ctx context.Context, id primitive.ObjectID, predictedArrival time.Time) error {
+	args := m.Called(ctx, id, predictedArrival)
 	return args.Error(0)
 }
 
+func (m *MockDeliveryRepository) GetCompletedDeliveries(ctx context.Context, from, to time.Time) ([]*models.Delivery, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).([]*models.Delivery), args.Error(1)
+}
+
+func (m *MockDeliveryRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Delivery, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]*models.Delivery), args.Error(1)
+}
+
 func TestCreateDelivery(t *testing.T) {
 	mockRepo := new(MockDeliveryRepository)
-	service := NewDeliveryService(mockRepo)
+	service := NewDeliveryService(mockRepo, nil, nil, nil, DefaultDeliveryConfig())
 	ctx := context.Background()
 
 	tests := []struct {
@@ -95,7 +125,7 @@ func TestCreateDelivery(t *testing.T) {
 				Status:                models.StatusPreparing,
 			},
 			mockFn: func() {
-				mockRepo.On("Create", ctx, mock.AnythingOfType("*models.Delivery")).Return(nil)
+				mockRepo.On("Create", ctx, mock.AnythingOfType("*models.Delivery"), "staff-1").Return(nil)
 			},
 			wantErr: false,
 		},
@@ -121,7 +151,7 @@ func TestCreateDelivery(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockFn()
-			err := service.CreateDelivery(ctx, tt.delivery)
+			err := service.CreateDelivery(ctx, tt.delivery, "staff-1")
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -134,7 +164,7 @@ func TestCreateDelivery(t *testing.T) {
 
 func TestUpdateDeliveryStatus(t *testing.T) {
 	mockRepo := new(MockDeliveryRepository)
-	service := NewDeliveryService(mockRepo)
+	service := NewDeliveryService(mockRepo, nil, nil, nil, DefaultDeliveryConfig())
 	deliveryID := primitive.NewObjectID()
 
 	tests := []struct {
@@ -153,23 +183,37 @@ func TestUpdateDeliveryStatus(t *testing.T) {
 					ID:     deliveryID.Hex(),
 					Status: models.StatusPreparing,
 				}, nil)
-				mockRepo.On("UpdateStatus", mock.Anything, deliveryID, models.StatusInProgress).Return(nil)
+				mockRepo.On("UpdateStatus", mock.Anything, deliveryID, models.DeliveryStatusUpdate{
+					From: models.StatusPreparing, To: models.StatusInProgress, CountAttempt: true}, "staff-1").Return(nil)
 			},
 			wantErr: false,
 		},
 		{
-			name:          "InProgress から Completed への遷移",
+			name:          "InProgress から Failed への遷移",
 			currentStatus: models.StatusInProgress,
-			newStatus:     string(models.StatusCompleted),
+			newStatus:     string(models.StatusFailed),
 			mockFn: func() {
 				mockRepo.On("GetByID", mock.Anything, deliveryID).Return(&models.Delivery{
 					ID:     deliveryID.Hex(),
 					Status: models.StatusInProgress,
 				}, nil)
-				mockRepo.On("UpdateStatus", mock.Anything, deliveryID, models.StatusCompleted).Return(nil)
+				mockRepo.On("UpdateStatus", mock.Anything, deliveryID, models.DeliveryStatusUpdate{
+					From: models.StatusInProgress, To: models.StatusFailed, ReleaseRobot: true}, "staff-1").Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name:          "配送の証跡なしでは Completed にできない",
+			currentStatus: models.StatusInProgress,
+			newStatus:     string(models.StatusCompleted),
+			mockFn: func() {
+				mockRepo.On("GetByID", mock.Anything, deliveryID).Return(&models.Delivery{
+					ID:     deliveryID.Hex(),
+					Status: models.StatusInProgress,
+				}, nil)
+			},
+			wantErr: true,
+		},
 		{
 			name:          "Completed から InProgress への無効な遷移",
 			currentStatus: models.StatusCompleted,
@@ -188,7 +232,7 @@ func TestUpdateDeliveryStatus(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo.ExpectedCalls = nil
 			tt.mockFn()
-			err := service.UpdateDeliveryStatus(deliveryID.Hex(), tt.newStatus)
+			err := service.UpdateDeliveryStatus(deliveryID.Hex(), tt.newStatus, "", "staff-1")
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -198,9 +242,95 @@ func TestUpdateDeliveryStatus(t *testing.T) {
 	}
 }
 
+// MockSlotBooker は SlotBooker インターフェースのモック実装です
+type MockSlotBooker struct {
+	mock.Mock
+}
+
+func (m *MockSlotBooker) BookSlot(ctx context.Context, slotID, reservationID string) (*models.SlotReservation, *models.DeliverySlot, error) {
+	args := m.Called(ctx, slotID, reservationID)
+	return args.Get(0).(*models.SlotReservation), args.Get(1).(*models.DeliverySlot), args.Error(2)
+}
+
+func (m *MockSlotBooker) AttachDelivery(ctx context.Context, reservationID primitive.ObjectID, deliveryID string) error {
+	args := m.Called(ctx, reservationID, deliveryID)
+	return args.Error(0)
+}
+
+func (m *MockSlotBooker) CancelReservation(ctx context.Context, id primitive.ObjectID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestDeliveryStatusTransitions(t *testing.T) {
+	mockRepo := new(MockDeliveryRepository)
+	slots := new(MockSlotBooker)
+	service := NewDeliveryService(mockRepo, nil, slots, nil, DeliveryConfig{MaxAttempts: 2})
+	deliveryID := primitive.NewObjectID()
+	reservationID := primitive.NewObjectID()
+	productID := primitive.NewObjectID()
+	current := func(status models.DeliveryStatus, attempts int) {
+		mockRepo.On("GetByID", mock.Anything, deliveryID).Return(&models.Delivery{
+			ID:            deliveryID.Hex(),
+			Status:        status,
+			Attempts:      attempts,
+			ReservationID: reservationID.Hex(),
+			Items:         []models.DeliveryItem{{ProductID: productID.Hex(), Quantity: 2}},
+		}, nil).Once()
+	}
+	reason := "お客様都合"
+
+	t.Run("出発前の取り消しで時間枠の予約を取り消す", func(t *testing.T) {
+		current(models.StatusOnHold, 0)
+		mockRepo.On("UpdateStatus", mock.Anything, deliveryID, models.DeliveryStatusUpdate{
+			From: models.StatusOnHold, To: models.StatusCancelled, Note: &reason, ReleaseRobot: true, Restock: true}, "staff-1").Return(nil).Once()
+		slots.On("CancelReservation", mock.Anything, reservationID).Return(nil).Once()
+
+		assert.NoError(t, service.UpdateDeliveryStatus(deliveryID.Hex(), string(models.StatusCancelled), reason, "staff-1"))
+		slots.AssertExpectations(t)
+	})
+
+	t.Run("出発後は取り消せない", func(t *testing.T) {
+		current(models.StatusInProgress, 1)
+		err := service.UpdateDeliveryStatus(deliveryID.Hex(), string(models.StatusCancelled), "", "staff-1")
+		assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+	})
+
+	t.Run("失敗後は試行回数の上限まで再配送できる", func(t *testing.T) {
+		current(models.StatusFailed, 1)
+		mockRepo.On("UpdateStatus", mock.Anything, deliveryID, models.DeliveryStatusUpdate{
+			From: models.StatusFailed, To: models.StatusPreparing, ReleaseRobot: true, UnassignRobot: true}, "staff-1").Return(nil).Once()
+		assert.NoError(t, service.UpdateDeliveryStatus(deliveryID.Hex(), string(models.StatusPreparing), "", "staff-1"))
+
+		current(models.StatusFailed, 2)
+		err := service.UpdateDeliveryStatus(deliveryID.Hex(), string(models.StatusPreparing), "", "staff-1")
+		assert.ErrorIs(t, err, ErrMaxDeliveryAttempts)
+	})
+
+	t.Run("店舗へ返送した商品を在庫に戻す", func(t *testing.T) {
+		current(models.StatusReturning, 2)
+		mockRepo.On("UpdateStatus", mock.Anything, deliveryID, models.DeliveryStatusUpdate{
+			From: models.StatusReturning, To: models.StatusReturned, ReleaseRobot: true, Restock: true}, "staff-1").Return(nil).Once()
+
+		assert.NoError(t, service.UpdateDeliveryStatus(deliveryID.Hex(), string(models.StatusReturned), "", "staff-1"))
+	})
+
+	t.Run("他の操作でステータスが変わっていた場合は副作用を実行しない", func(t *testing.T) {
+		current(models.StatusPreparing, 0)
+		mockRepo.On("UpdateStatus", mock.Anything, deliveryID, models.DeliveryStatusUpdate{
+			From: models.StatusPreparing, To: models.StatusCancelled, ReleaseRobot: true, Restock: true}, "staff-1").Return(repository.ErrDeliveryStatusChanged).Once()
+
+		err := service.UpdateDeliveryStatus(deliveryID.Hex(), string(models.StatusCancelled), "", "staff-1")
+		assert.ErrorIs(t, err, repository.ErrDeliveryStatusChanged)
+		slots.AssertNumberOfCalls(t, "CancelReservation", 1)
+	})
+
+	mockRepo.AssertExpectations(t)
+}
+
 func TestGetActiveDeliveries(t *testing.T) {
 	mockRepo := new(MockDeliveryRepository)
-	service := NewDeliveryService(mockRepo)
+	service := NewDeliveryService(mockRepo, nil, nil, nil, DefaultDeliveryConfig())
 	ctx := context.Background()
 
 	expectedDeliveries := []*models.Delivery{
@@ -229,7 +359,7 @@ func TestGetActiveDeliveries(t *testing.T) {
 
 func TestGetDeliveryByID(t *testing.T) {
 	mockRepo := new(MockDeliveryRepository)
-	service := NewDeliveryService(mockRepo)
+	service := NewDeliveryService(mockRepo, nil, nil, nil, DefaultDeliveryConfig())
 	ctx := context.Background()
 	deliveryID := primitive.NewObjectID()
 
@@ -286,7 +416,7 @@ func TestGetDeliveryByID(t *testing.T) {
 
 func TestUpdateDeliveryLocation(t *testing.T) {
 	mockRepo := new(MockDeliveryRepository)
-	service := NewDeliveryService(mockRepo)
+	service := NewDeliveryService(mockRepo, nil, nil, nil, DefaultDeliveryConfig())
 	ctx := context.Background()
 	deliveryID := primitive.NewObjectID()
 
@@ -294,41 +424,52 @@ func TestUpdateDeliveryLocation(t *testing.T) {
 		Latitude:  35.6895,
 		Longitude: 139.6917,
 	}
+	battery := 80.0
+	overcharged := 120.0
 
 	tests := []struct {
 		name     string
 		id       primitive.ObjectID
-		location models.Location
+		tracking models.TrackingInfo
 		mockFn   func()
 		wantErr  bool
 	}{
 		{
 			name:     "正常な位置更新",
 			id:       deliveryID,
-			location: validLocation,
+			tracking: models.TrackingInfo{CurrentLocation: &validLocation, BatteryLevel: &battery},
 			mockFn: func() {
-				mockRepo.On("UpdateLocation", ctx, deliveryID, validLocation).Return(nil)
+				mockRepo.On("UpdateLocation", ctx, deliveryID, models.TrackingInfo{CurrentLocation: &validLocation, BatteryLevel: &battery}, "robot-1").Return(nil)
 			},
 			wantErr: false,
 		},
 		{
-			name: "無効な位置",
-			id:   deliveryID,
-			location: models.Location{
-				Latitude:  0,
-				Longitude: 0,
-			},
-			mockFn: func() {
-				mockRepo.On("UpdateLocation", ctx, deliveryID, models.Location{}).Return(errors.New("invalid location"))
-			},
-			wantErr: true,
+			name:     "無効な位置",
+			id:       deliveryID,
+			tracking: models.TrackingInfo{CurrentLocation: &models.Location{}},
+			mockFn:   func() {},
+			wantErr:  true,
+		},
+		{
+			name:     "位置の指定なし",
+			id:       deliveryID,
+			tracking: models.TrackingInfo{BatteryLevel: &battery},
+			mockFn:   func() {},
+			wantErr:  true,
+		},
+		{
+			name:     "無効なバッテリー残量",
+			id:       deliveryID,
+			tracking: models.TrackingInfo{CurrentLocation: &validLocation, BatteryLevel: &overcharged},
+			mockFn:   func() {},
+			wantErr:  true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockFn()
-			err := service.UpdateDeliveryLocation(ctx, tt.id, tt.location)
+			err := service.UpdateDeliveryLocation(ctx, tt.id, tt.tracking, "robot-1")
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -336,11 +477,81 @@ func TestUpdateDeliveryLocation(t *testing.T) {
 			}
 		})
 	}
+	mockRepo.AssertExpectations(t)
+}
+
+// recordingPublisher は配信されたライブ追跡イベントを記録します
+type recordingPublisher struct {
+	events []models.TrackingEvent
+}
+
+func (p *recordingPublisher) Publish(event models.TrackingEvent) models.TrackingEvent {
+	p.events = append(p.events, event)
+	return event
+}
+
+func TestDeliveryServicePublishesTrackingEvents(t *testing.T) {
+	mockRepo := new(MockDeliveryRepository)
+	publisher := &recordingPublisher{}
+	service := NewDeliveryService(mockRepo, publisher, nil, nil, DefaultDeliveryConfig())
+	now := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+	deliveryID := primitive.NewObjectID()
+
+	location := models.Location{Latitude: 35.6895, Longitude: 139.6917}
+	speed := 5.5
+	tracking := models.TrackingInfo{CurrentLocation: &location, Speed: &speed}
+	battery := 64.0
+	updated := &models.Delivery{
+		ID:           deliveryID.Hex(),
+		Status:       models.StatusInProgress,
+		RobotID:      "robot-1",
+		TrackingInfo: &models.TrackingInfo{CurrentLocation: &location, BatteryLevel: &battery, Speed: &speed},
+	}
+
+	mockRepo.On("UpdateLocation", ctx, deliveryID, tracking, "robot-1").Return(nil).Once()
+	mockRepo.On("GetByID", ctx, deliveryID).Return(updated, nil).Once()
+	assert.NoError(t, service.UpdateDeliveryLocation(ctx, deliveryID, tracking, "robot-1"))
+
+	mockRepo.On("GetByID", ctx, deliveryID).Return(&models.Delivery{
+		ID:      deliveryID.Hex(),
+		Status:  models.StatusInProgress,
+		RobotID: "robot-1",
+	}, nil).Once()
+	mockRepo.On("UpdateStatus", ctx, deliveryID, models.DeliveryStatusUpdate{
+		From: models.StatusInProgress, To: models.StatusFailed, ReleaseRobot: true}, "staff-1").Return(nil).Once()
+	assert.NoError(t, service.UpdateDeliveryStatus(deliveryID.Hex(), string(models.StatusFailed), "", "staff-1"))
+
+	// 更新に失敗した場合は配信しません
+	mockRepo.On("UpdateLocation", ctx, deliveryID, tracking, "robot-1").Return(errors.New("db error")).Once()
+	assert.Error(t, service.UpdateDeliveryLocation(ctx, deliveryID, tracking, "robot-1"))
+
+	assert.Equal(t, []models.TrackingEvent{
+		{
+			Type:         models.TrackingLocation,
+			DeliveryID:   deliveryID.Hex(),
+			RobotID:      "robot-1",
+			Status:       models.StatusInProgress,
+			Location:     &location,
+			BatteryLevel: &battery,
+			Speed:        &speed,
+			Timestamp:    now,
+		},
+		{
+			Type:       models.TrackingStatus,
+			DeliveryID: deliveryID.Hex(),
+			RobotID:    "robot-1",
+			Status:     models.StatusFailed,
+			Timestamp:  now,
+		},
+	}, publisher.events)
+	mockRepo.AssertExpectations(t)
 }
 
 func TestGetDeliveriesByRobot(t *testing.T) {
 	mockRepo := new(MockDeliveryRepository)
-	service := NewDeliveryService(mockRepo)
+	service := NewDeliveryService(mockRepo, nil, nil, nil, DefaultDeliveryConfig())
 	ctx := context.Background()
 
 	robotID := "ROBOT-001"
@@ -397,3 +608,25 @@ func TestGetDeliveriesByRobot(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateDelivery(t *testing.T) {
+	mockRepo := new(MockDeliveryRepository)
+	service := NewDeliveryService(mockRepo, nil, nil, nil, DefaultDeliveryConfig())
+	deliveryID := primitive.NewObjectID()
+	delivery := &models.Delivery{
+		DeliveryType: "ドローン",
+		Address:      "東京都新宿区",
+	}
+
+	t.Run("変更者とともに更新", func(t *testing.T) {
+		mockRepo.On("Update", mock.Anything, deliveryID, delivery, "staff-1").Return(nil).Once()
+		err := service.UpdateDelivery(deliveryID.Hex(), delivery, "staff-1")
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("無効な配送IDでエラー", func(t *testing.T) {
+		err := service.UpdateDelivery("invalid", delivery, "staff-1")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+var (
+	// ErrInvalidStatusTransition は定義されていないステータスの遷移を指定した場合のエラーです
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+	// ErrMaxDeliveryAttempts は配送の試行回数が上限に達していて再配送できない場合のエラーです
+	ErrMaxDeliveryAttempts = errors.New("delivery has reached the maximum number of attempts")
+	// ErrProofOfDeliveryRequired は配送の証跡を提出せずに配送を完了にしようとした場合のエラーです
+	ErrProofOfDeliveryRequired = errors.New("proof of delivery is required to complete a delivery")
+)
+
+// DeliveryTransition は配送ステータスの遷移の定義です
+type DeliveryTransition struct {
+	From []models.DeliveryStatus
+	To   models.DeliveryStatus
+	// CountAttempt は配送の試行回数を数える遷移（出発）かどうかです
+	CountAttempt bool
+	// ReleaseRobot は配送を終えた機体を待機中に戻す遷移かどうかです（遷移と同じトランザクションで戻します）
+	ReleaseRobot bool
+	// UnassignRobot は配送の機体の割り当てを解除する遷移（再配送）かどうかです
+	UnassignRobot bool
+	// Restock は登録時に引き当てた商品の在庫を戻す遷移（取り消し・返送済み）かどうかです（遷移と同じトランザクションで戻します）
+	Restock bool
+	// Guard は遷移できない場合にエラーを返します（nil の場合は常に遷移できます）
+	// update は遷移とともに書き込む内容（理由・配送の証跡）です
+	Guard func(ctx context.Context, delivery *models.Delivery, update models.DeliveryStatusUpdate) error
+	// Effects は遷移を記録した後に順に実行する処理です
+	// 記録済みの遷移は取り消さないため、失敗は呼び出し元でログに記録します
+	Effects []func(ctx context.Context, delivery *models.Delivery) error
+}
+
+// DeliveryStateMachine は遷移の定義に従って配送ステータスの遷移を判定します
+type DeliveryStateMachine struct {
+	transitions map[models.DeliveryStatus]map[models.DeliveryStatus]DeliveryTransition
+}
+
+// NewDeliveryStateMachine は遷移の定義からステートマシンを作成します
+// 同じ遷移を複数回定義した場合は後の定義を使います
+func NewDeliveryStateMachine(transitions []DeliveryTransition) *DeliveryStateMachine {
+	m := &DeliveryStateMachine{transitions: make(map[models.DeliveryStatus]map[models.DeliveryStatus]DeliveryTransition)}
+	for _, transition := range transitions {
+		for _, from := range transition.From {
+			if m.transitions[from] == nil {
+				m.transitions[from] = make(map[models.DeliveryStatus]DeliveryTransition)
+			}
+			m.transitions[from][transition.To] = transition
+		}
+	}
+	return m
+}
+
+// Transition は from から to への遷移の定義を返します（定義されていない場合は false）
+func (m *DeliveryStateMachine) Transition(from, to models.DeliveryStatus) (DeliveryTransition, bool) {
+	transition, ok := m.transitions[from][to]
+	return transition, ok
+}
+
+// Check は delivery の現在のステータスから to へ update とともに遷移できるかどうかを、ガードを含めて確認します
+func (m *DeliveryStateMachine) Check(ctx context.Context, delivery *models.Delivery, to models.DeliveryStatus, update models.DeliveryStatusUpdate) (DeliveryTransition, error) {
+	transition, ok := m.Transition(delivery.Status, to)
+	if !ok {
+		return DeliveryTransition{}, ErrInvalidStatusTransition
+	}
+	if transition.Guard != nil {
+		if err := transition.Guard(ctx, delivery, update); err != nil {
+			return DeliveryTransition{}, err
+		}
+	}
+	return transition, nil
+}
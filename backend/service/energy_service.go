@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/geo"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+// unspecifiedDeliveryType は配送種別が登録されていない配送の区分名です
+const unspecifiedDeliveryType = "未指定"
+
+// EnergyConfig はエネルギー効率レポートの設定です
+type EnergyConfig struct {
+	// RobotBatteryCapacity・DroneBatteryCapacity はバッテリー容量が登録されていない機体に使う容量（Wh）です
+	RobotBatteryCapacity float64
+	DroneBatteryCapacity float64
+	// MaxGap はこの時間より間隔の空いた計測値の間は移動・消費を求めません（通信の途絶や電源断）
+	MaxGap time.Duration
+	// MinDistance はエネルギー効率を求めるのに必要な最小の移動距離（km）です
+	MinDistance float64
+	// PayloadBands は積載重量の区分の境界（kg、昇順）です
+	PayloadBands []float64
+	// DegradationWindow は悪化を判定する直近の期間、DegradationThreshold はそれ以前と比べた悪化率のしきい値です
+	DegradationWindow    time.Duration
+	DegradationThreshold float64
+}
+
+// DefaultEnergyConfig は既定の設定（ロボット1000Wh、ドローン500Wh、直近7日間で15%悪化を検知）を返します
+func DefaultEnergyConfig() EnergyConfig {
+	return EnergyConfig{
+		RobotBatteryCapacity: 1000,
+		DroneBatteryCapacity: 500,
+		MaxGap:               5 * time.Minute,
+		MinDistance:          0.5,
+		PayloadBands:         []float64{2, 5, 10},
+		DegradationWindow:    7 * 24 * time.Hour,
+		DegradationThreshold: 0.15,
+	}
+}
+
+// EnergyServiceInterface はエネルギー効率レポートサービスのインターフェースを定義します
+type EnergyServiceInterface interface {
+	GetEfficiencyReport(ctx context.Context, start, end time.Time) (*models.EnergyEfficiencyReport, error)
+}
+
+// EnergyService は配送機体の計測値からエネルギー効率（1kmあたりの消費電力量）を集計するサービスです
+type EnergyService struct {
+	telemetryRepo repository.TelemetryRepository
+	robotRepo     repository.RobotRepository
+	deliveryRepo  repository.DeliveryRepository
+	config        EnergyConfig
+	location      *time.Location
+}
+
+// NewEnergyService はエネルギー効率レポートサービスを作成します
+// location は期間の区切りと時間帯の集計に使う店舗のタイムゾーンです
+func NewEnergyService(
+	telemetryRepo repository.TelemetryRepository,
+	robotRepo repository.RobotRepository,
+	deliveryRepo repository.DeliveryRepository,
+	config EnergyConfig,
+	location *time.Location,
+) *EnergyService {
+	return &EnergyService{
+		telemetryRepo: telemetryRepo,
+		robotRepo:     robotRepo,
+		deliveryRepo:  deliveryRepo,
+		config:        config,
+		location:      location,
+	}
+}
+
+// energyTotal は消費電力量と移動距離の合計です
+type energyTotal struct {
+	energy   float64
+	distance float64
+}
+
+func (t *energyTotal) add(energy, distance float64) {
+	t.energy += energy
+	t.distance += distance
+}
+
+// robotEnergyTotal は機体ごとの合計と、悪化の判定に使う直近の期間・それ以前の合計です
+type robotEnergyTotal struct {
+	energyTotal
+	baseline energyTotal
+	recent   energyTotal
+}
+
+// GetEfficiencyReport は期間（終了日を含む）のエネルギー効率を配送・機体・配送種別・積載重量・時間帯ごとに集計します
+// 1分ごとに集約した計測値のうち、連続する2点の間のバッテリー残量の減少を消費電力量、位置の変化を移動距離とします
+// 充電中（残量が増えた区間）と計測値の間隔が空いた区間は除きます
+func (s *EnergyService) GetEfficiencyReport(ctx context.Context, start, end time.Time) (*models.EnergyEfficiencyReport, error) {
+	from := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, s.location)
+	to := time.Date(end.Year(), end.Month(), end.Day()+1, 0, 0, 0, 0, s.location)
+	recentFrom := to.Add(-s.config.DegradationWindow)
+
+	robots, err := s.robotRepo.List(ctx, models.RobotQuery{})
+	if err != nil {
+		return nil, err
+	}
+	robotsByID := make(map[string]*models.Robot, len(robots))
+	for _, robot := range robots {
+		robotsByID[robot.ID.Hex()] = robot
+	}
+
+	var total energyTotal
+	byRobot := make(map[string]*robotEnergyTotal)
+	byDelivery := make(map[string]*energyTotal)
+	deliveryRobot := make(map[string]string)
+	byHour := make(map[int]*energyTotal)
+
+	var prev *models.TelemetryRollup
+	err = s.telemetryRepo.StreamRollups(ctx, from, to, func(cur *models.TelemetryRollup) error {
+		last := prev
+		prev = cur
+		if last == nil || last.RobotID != cur.RobotID {
+			return nil
+		}
+		gap := cur.Timestamp.Sub(last.Timestamp)
+		if gap <= 0 || gap > s.config.MaxGap || last.BatteryLevel == nil || cur.BatteryLevel == nil {
+			return nil
+		}
+		drop := *last.BatteryLevel - *cur.BatteryLevel
+		if drop < 0 {
+			return nil
+		}
+		energy := drop / 100 * s.batteryCapacity(cur.RobotID, robotsByID)
+		distance := geo.Distance(last.Location, cur.Location)
+
+		total.add(energy, distance)
+		robot := byRobot[cur.RobotID]
+		if robot == nil {
+			robot = &robotEnergyTotal{}
+			byRobot[cur.RobotID] = robot
+		}
+		robot.add(energy, distance)
+		if last.Timestamp.Before(recentFrom) {
+			robot.baseline.add(energy, distance)
+		} else {
+			robot.recent.add(energy, distance)
+		}
+
+		hour := last.Timestamp.In(s.location).Hour()
+		if byHour[hour] == nil {
+			byHour[hour] = &energyTotal{}
+		}
+		byHour[hour].add(energy, distance)
+
+		// 配送中の区間（前後の計測値が同じ配送）のみ配送に計上します
+		if cur.DeliveryID != "" && cur.DeliveryID == last.DeliveryID {
+			if byDelivery[cur.DeliveryID] == nil {
+				byDelivery[cur.DeliveryID] = &energyTotal{}
+				deliveryRobot[cur.DeliveryID] = cur.RobotID
+			}
+			byDelivery[cur.DeliveryID].add(energy, distance)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(byDelivery))
+	for id := range byDelivery {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			ids = append(ids, oid)
+		}
+	}
+	deliveries, err := s.deliveryRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	deliveriesByID := make(map[string]*models.Delivery, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveriesByID[delivery.ID] = delivery
+	}
+
+	report := &models.EnergyEfficiencyReport{
+		Start:         from.Format("2006-01-02"),
+		End:           to.AddDate(0, 0, -1).Format("2006-01-02"),
+		EnergyStats:   s.stats(total),
+		Deliveries:    []models.DeliveryEnergy{},
+		Robots:        []models.RobotEnergy{},
+		DeliveryTypes: []models.EnergyBreakdown{},
+		PayloadWeight: []models.EnergyBreakdown{},
+		TimeOfDay:     []models.EnergyBreakdown{},
+	}
+
+	byType := make(map[string]*energyTotal)
+	byPayload := make(map[int]*energyTotal)
+	for id, t := range byDelivery {
+		entry := models.DeliveryEnergy{
+			DeliveryID:   id,
+			RobotID:      deliveryRobot[id],
+			DeliveryType: unspecifiedDeliveryType,
+			EnergyStats:  s.stats(*t),
+		}
+		band := -1
+		if delivery, ok := deliveriesByID[id]; ok {
+			if delivery.DeliveryType != "" {
+				entry.DeliveryType = delivery.DeliveryType
+			}
+			entry.PayloadWeight = delivery.PayloadWeight
+			band = s.payloadBand(delivery.PayloadWeight)
+		}
+		report.Deliveries = append(report.Deliveries, entry)
+
+		if byType[entry.DeliveryType] == nil {
+			byType[entry.DeliveryType] = &energyTotal{}
+		}
+		byType[entry.DeliveryType].add(t.energy, t.distance)
+		if band >= 0 {
+			if byPayload[band] == nil {
+				byPayload[band] = &energyTotal{}
+			}
+			byPayload[band].add(t.energy, t.distance)
+		}
+	}
+	// エネルギー効率の悪い配送から順に並べます
+	sort.Slice(report.Deliveries, func(i, j int) bool {
+		if report.Deliveries[i].WhPerKm != report.Deliveries[j].WhPerKm {
+			return report.Deliveries[i].WhPerKm > report.Deliveries[j].WhPerKm
+		}
+		return report.Deliveries[i].DeliveryID < report.Deliveries[j].DeliveryID
+	})
+
+	for id, t := range byRobot {
+		entry := models.RobotEnergy{
+			RobotID:         id,
+			EnergyStats:     s.stats(t.energyTotal),
+			BaselineWhPerKm: s.stats(t.baseline).WhPerKm,
+			RecentWhPerKm:   s.stats(t.recent).WhPerKm,
+		}
+		if robot, ok := robotsByID[id]; ok {
+			entry.Name = robot.Name
+			entry.Type = robot.Type
+		}
+		if entry.BaselineWhPerKm > 0 && entry.RecentWhPerKm > 0 {
+			entry.Change = round2(entry.RecentWhPerKm/entry.BaselineWhPerKm - 1)
+			entry.Degrading = entry.Change >= s.config.DegradationThreshold
+		}
+		report.Robots = append(report.Robots, entry)
+	}
+	// 悪化している機体を先頭にし、悪化率の大きい順に並べます
+	sort.Slice(report.Robots, func(i, j int) bool {
+		a, b := report.Robots[i], report.Robots[j]
+		if a.Degrading != b.Degrading {
+			return a.Degrading
+		}
+		if a.Change != b.Change {
+			return a.Change > b.Change
+		}
+		return a.RobotID < b.RobotID
+	})
+
+	types := make([]string, 0, len(byType))
+	for label := range byType {
+		types = append(types, label)
+	}
+	sort.Strings(types)
+	for _, label := range types {
+		report.DeliveryTypes = append(report.DeliveryTypes, models.EnergyBreakdown{Label: label, EnergyStats: s.stats(*byType[label])})
+	}
+	for band := 0; band <= len(s.config.PayloadBands); band++ {
+		if t, ok := byPayload[band]; ok {
+			report.PayloadWeight = append(report.PayloadWeight, models.EnergyBreakdown{Label: s.payloadLabel(band), EnergyStats: s.stats(*t)})
+		}
+	}
+	for hour := 0; hour < 24; hour++ {
+		if t, ok := byHour[hour]; ok {
+			label := fmt.Sprintf("%02d:00-%02d:00", hour, hour+1)
+			report.TimeOfDay = append(report.TimeOfDay, models.EnergyBreakdown{Label: label, EnergyStats: s.stats(*t)})
+		}
+	}
+	return report, nil
+}
+
+// batteryCapacity は機体のバッテリー容量（Wh）を返します
+// 容量が登録されていない、または機体が削除されている場合は種類ごとの既定値を使います
+func (s *EnergyService) batteryCapacity(robotID string, robots map[string]*models.Robot) float64 {
+	robot, ok := robots[robotID]
+	if !ok {
+		return s.config.RobotBatteryCapacity
+	}
+	if robot.BatteryCapacity > 0 {
+		return robot.BatteryCapacity
+	}
+	if robot.Type == models.RobotTypeDrone {
+		return s.config.DroneBatteryCapacity
+	}
+	return s.config.RobotBatteryCapacity
+}
+
+// payloadBand は積載重量の区分の番号を返します
+func (s *EnergyService) payloadBand(weight float64) int {
+	for i, limit := range s.config.PayloadBands {
+		if weight < limit {
+			return i
+		}
+	}
+	return len(s.config.PayloadBands)
+}
+
+// payloadLabel は積載重量の区分の表示名（例: 2-5kg）です
+func (s *EnergyService) payloadLabel(band int) string {
+	bands := s.config.PayloadBands
+	switch {
+	case len(bands) == 0:
+		return "全て"
+	case band == 0:
+		return fmt.Sprintf("0-%gkg", bands[0])
+	case band == len(bands):
+		return fmt.Sprintf("%gkg-", bands[band-1])
+	}
+	return fmt.Sprintf("%g-%gkg", bands[band-1], bands[band])
+}
+
+// stats は合計から消費電力量・移動距離・エネルギー効率を求めます
+func (s *EnergyService) stats(t energyTotal) models.EnergyStats {
+	stats := models.EnergyStats{
+		EnergyWh:   round2(t.energy),
+		DistanceKm: round2(t.distance),
+	}
+	if t.distance >= s.config.MinDistance {
+		stats.WhPerKm = round2(t.energy / t.distance)
+	}
+	return stats
+}
+
+// round2 は小数第2位に丸めます
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+func TestGetEfficiencyReport(t *testing.T) {
+	ctx := context.Background()
+	// north は基準点から北へ km だけ離れた地点です
+	north := func(km float64) models.Location {
+		return models.Location{Latitude: 35.0 + km/111.195, Longitude: 139.0}
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 6, day, hour, minute, 0, 0, time.UTC)
+	}
+	point := func(robotID string, ts time.Time, km, battery float64, deliveryID string) *models.TelemetryRollup {
+		return &models.TelemetryRollup{RobotID: robotID, TelemetryPoint: models.TelemetryPoint{
+			Timestamp: ts, Location: north(km), BatteryLevel: &battery, DeliveryID: deliveryID, Readings: 1}}
+	}
+
+	// バッテリー容量が未登録のロボット（既定の1000Wh）と、400Whのドローン
+	robot := &models.Robot{ID: primitive.NewObjectID(), Name: "R-1", Type: models.RobotTypeGround}
+	drone := &models.Robot{ID: primitive.NewObjectID(), Name: "D-1", Type: models.RobotTypeDrone, BatteryCapacity: 400}
+	d1 := &models.Delivery{ID: primitive.NewObjectID().Hex(), DeliveryType: "ロボット", PayloadWeight: 3}
+	d2 := &models.Delivery{ID: primitive.NewObjectID().Hex(), DeliveryType: "ドローン", PayloadWeight: 1}
+	r, d := robot.ID.Hex(), drone.ID.Hex()
+
+	rollups := []*models.TelemetryRollup{
+		// ロボット: 6/1 の配送中に1km・1%（10Wh/km）
+		point(r, at(1, 10, 0), 0, 100, d1.ID),
+		point(r, at(1, 10, 1), 1, 99, d1.ID),
+		// 直近7日間の 6/10 は配送外で1km・1.5%（15Wh/km）に悪化
+		point(r, at(10, 14, 0), 1, 80, ""),
+		point(r, at(10, 14, 1), 2, 78.5, ""),
+		// 充電中の区間と、計測値の間隔が空いた区間は除く
+		point(r, at(10, 14, 2), 2, 90, ""),
+		point(r, at(10, 14, 30), 5, 60, ""),
+		// ドローン: 配送中に2km・1%（4Wh、2Wh/km）
+		point(d, at(10, 14, 0), 0, 50, d2.ID),
+		point(d, at(10, 14, 1), 2, 49, d2.ID),
+	}
+
+	telemetryRepo := new(MockTelemetryRepository)
+	robotRepo := new(MockRobotRepository)
+	deliveryRepo := new(MockDeliveryRepository)
+	telemetryRepo.On("StreamRollups", ctx, at(1, 0, 0), at(15, 0, 0), mock.Anything).Return(rollups, nil)
+	robotRepo.On("List", ctx, models.RobotQuery{}).Return([]*models.Robot{robot, drone}, nil)
+	deliveryRepo.On("GetByIDs", ctx, mock.MatchedBy(func(ids []primitive.ObjectID) bool { return len(ids) == 2 })).
+		Return([]*models.Delivery{d1, d2}, nil)
+
+	s := NewEnergyService(telemetryRepo, robotRepo, deliveryRepo, DefaultEnergyConfig(), time.UTC)
+	report, err := s.GetEfficiencyReport(ctx, at(1, 0, 0), at(14, 0, 0))
+
+	require.NoError(t, err)
+	assert.Equal(t, "2024-06-01", report.Start)
+	assert.Equal(t, "2024-06-14", report.End)
+	assert.InDelta(t, 29.0, report.EnergyWh, 0.01)
+	assert.InDelta(t, 4.0, report.DistanceKm, 0.01)
+	assert.InDelta(t, 7.25, report.WhPerKm, 0.01)
+
+	// エネルギー効率の悪い配送から順に並ぶ
+	require.Len(t, report.Deliveries, 2)
+	assert.Equal(t, d1.ID, report.Deliveries[0].DeliveryID)
+	assert.Equal(t, r, report.Deliveries[0].RobotID)
+	assert.InDelta(t, 10.0, report.Deliveries[0].WhPerKm, 0.01)
+	assert.Equal(t, d2.ID, report.Deliveries[1].DeliveryID)
+	assert.InDelta(t, 2.0, report.Deliveries[1].WhPerKm, 0.01)
+
+	// 直近7日間に50%悪化したロボットを先頭に示す
+	require.Len(t, report.Robots, 2)
+	assert.Equal(t, "R-1", report.Robots[0].Name)
+	assert.True(t, report.Robots[0].Degrading)
+	assert.InDelta(t, 10.0, report.Robots[0].BaselineWhPerKm, 0.01)
+	assert.InDelta(t, 15.0, report.Robots[0].RecentWhPerKm, 0.01)
+	assert.InDelta(t, 0.5, report.Robots[0].Change, 0.01)
+	assert.Equal(t, "D-1", report.Robots[1].Name)
+	assert.False(t, report.Robots[1].Degrading)
+
+	labels := func(breakdown []models.EnergyBreakdown) []string {
+		result := []string{}
+		for _, b := range breakdown {
+			result = append(result, b.Label)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"ドローン", "ロボット"}, labels(report.DeliveryTypes))
+	assert.Equal(t, []string{"0-2kg", "2-5kg"}, labels(report.PayloadWeight))
+	assert.Equal(t, []string{"10:00-11:00", "14:00-15:00"}, labels(report.TimeOfDay))
+	assert.InDelta(t, 19.0/3, report.TimeOfDay[1].WhPerKm, 0.01)
+}
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/routing"
+)
+
+// ErrETAUnavailable は配送が終了している、または機体・配送先の位置が登録されていないため到着を予測できない場合のエラーです
+var ErrETAUnavailable = errors.New("delivery ETA is unavailable")
+
+// ETAConfig は配送の到着予測の設定です
+type ETAConfig struct {
+	// RobotSpeed・DroneSpeed は走行履歴が少ない機体に使う配送ロボット・ドローンの平均移動速度（km/h）です
+	RobotSpeed float64
+	DroneSpeed float64
+	// SpeedHistory は機体の平均の走行速度を求める過去の期間です
+	SpeedHistory time.Duration
+	// MinMovingSpeed はこの速度（km/h）以下の1分間を停車中として走行速度の平均から除きます
+	MinMovingSpeed float64
+	// MinSpeedReadings は走行履歴の平均の速度を使うのに必要な計測値の最小件数です
+	MinSpeedReadings int
+	// RefreshLeaseTTL は定期更新中に保持するリースの期間です（複数のインスタンスが同時に到着予測を更新しないようにします）
+	RefreshLeaseTTL time.Duration
+}
+
+// DefaultETAConfig は既定の設定（ロボット時速6km、ドローン時速40km、直近7日間の走行履歴）を返します
+func DefaultETAConfig() ETAConfig {
+	return ETAConfig{
+		RobotSpeed:       6,
+		DroneSpeed:       40,
+		SpeedHistory:     7 * 24 * time.Hour,
+		MinMovingSpeed:   0.5,
+		MinSpeedReadings: 30,
+		RefreshLeaseTTL:  5 * time.Minute,
+	}
+}
+
+// ETAServiceInterface は配送の到着予測サービスのインターフェースを定義します
+type ETAServiceInterface interface {
+	Predict(ctx context.Context, deliveryID primitive.ObjectID) (*models.DeliveryETA, error)
+	Refresh(ctx context.Context) ([]*models.DeliveryETA, error)
+	ListAlerts(ctx context.Context, status models.DeliveryAlertStatus) ([]*models.DeliveryAlert, error)
+	GetOnTimePerformance(ctx context.Context, start, end time.Time) (*models.OnTimePerformance, error)
+}
+
+// ETAService は配送機体の現在位置・残りの距離・過去の走行速度から配送の到着を予測し、
+// 到着時間帯に間に合わない配送の遅延アラートを管理するサービスです
+type ETAService struct {
+	deliveryRepo  repository.DeliveryRepository
+	robotRepo     repository.RobotRepository
+	telemetryRepo repository.TelemetryRepository
+	alertRepo     repository.DeliveryAlertRepository
+	roads         routing.Metric
+	config        ETAConfig
+	location      *time.Location
+	now           func() time.Time
+	// instanceID は定期更新のリースを保持するこのインスタンスの識別子です
+	instanceID string
+}
+
+// NewETAService は配送の到着予測サービスを作成します
+// roads は配送ロボットの移動距離の求め方です（nil の場合は直線距離。ドローンは常に直線距離を使います）
+// location は定時到着の実績を日別に集計する店舗のタイムゾーンです
+func NewETAService(
+	deliveryRepo repository.DeliveryRepository,
+	robotRepo repository.RobotRepository,
+	telemetryRepo repository.TelemetryRepository,
+	alertRepo repository.DeliveryAlertRepository,
+	roads routing.Metric,
+	config ETAConfig,
+	location *time.Location,
+) *ETAService {
+	if roads == nil {
+		roads = routing.Haversine{}
+	}
+	return &ETAService{
+		deliveryRepo:  deliveryRepo,
+		robotRepo:     robotRepo,
+		telemetryRepo: telemetryRepo,
+		alertRepo:     alertRepo,
+		roads:         roads,
+		config:        config,
+		location:      location,
+		now:           time.Now,
+		instanceID:    primitive.NewObjectID().Hex(),
+	}
+}
+
+// Start は一定間隔で進行中の全ての配送の到着予測を更新します
+// 複数のインスタンスで起動した場合は、定期更新のリースを取得したインスタンスだけが更新します
+func (s *ETAService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.refreshWithLease(ctx); err != nil {
+					log.Printf("Failed to refresh delivery ETAs: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Predict は配送の到着を予測して保存し、到着時間帯に間に合わない場合は遅延アラートを登録します
+// 間に合う予測に戻った場合や配送が終了している場合は、未解決の遅延アラートを解決済みにします
+func (s *ETAService) Predict(ctx context.Context, deliveryID primitive.ObjectID) (*models.DeliveryETA, error) {
+	delivery, err := s.deliveryRepo.GetByID(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, ErrDeliveryNotFound
+	}
+	if !isActiveDelivery(delivery) {
+		if err := s.alertRepo.Resolve(ctx, delivery.ID, s.now()); err != nil {
+			return nil, err
+		}
+		return nil, ErrETAUnavailable
+	}
+	return s.predict(ctx, delivery)
+}
+
+// Refresh は進行中の全ての配送の到着を予測し直します
+// 到着を予測できない配送は結果に含めず、終了した配送の未解決の遅延アラートは解決済みにします
+func (s *ETAService) Refresh(ctx context.Context) ([]*models.DeliveryETA, error) {
+	deliveries, err := s.deliveryRepo.GetActiveDeliveries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	etas := make([]*models.DeliveryETA, 0, len(deliveries))
+	active := make(map[string]bool, len(deliveries))
+	for _, delivery := range deliveries {
+		active[delivery.ID] = true
+		eta, err := s.predict(ctx, delivery)
+		if err != nil {
+			if errors.Is(err, ErrETAUnavailable) {
+				continue
+			}
+			return nil, err
+		}
+		etas = append(etas, eta)
+	}
+
+	open, err := s.alertRepo.ListOpenDeliveryIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range open {
+		if active[id] {
+			continue
+		}
+		if err := s.alertRepo.Resolve(ctx, id, s.now()); err != nil {
+			return nil, err
+		}
+	}
+	return etas, nil
+}
+
+// refreshWithLease は定期更新のリースを取得できた場合だけ Refresh を実行します
+func (s *ETAService) refreshWithLease(ctx context.Context) error {
+	acquired, err := s.alertRepo.AcquireRefreshLease(ctx, s.instanceID, s.now(), s.config.RefreshLeaseTTL)
+	if err != nil || !acquired {
+		return err
+	}
+	defer func() {
+		if err := s.alertRepo.ReleaseRefreshLease(context.Background(), s.instanceID); err != nil {
+			log.Printf("Failed to release delivery ETA refresh lease: %v", err)
+		}
+	}()
+
+	_, err = s.Refresh(ctx)
+	return err
+}
+
+// ListAlerts は配送の遅延アラートを取得します（status が空の場合は全て）
+func (s *ETAService) ListAlerts(ctx context.Context, status models.DeliveryAlertStatus) ([]*models.DeliveryAlert, error) {
+	return s.alertRepo.List(ctx, status)
+}
+
+// GetOnTimePerformance は期間（終了日を含む）に完了した配送の定時到着の実績を全体と日別に集計します
+// 到着時間帯の終了（指定がない場合は配送予定時刻）までに配送が完了したものを定時到着とします
+func (s *ETAService) GetOnTimePerformance(ctx context.Context, start, end time.Time) (*models.OnTimePerformance, error) {
+	from := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, s.location)
+	to := time.Date(end.Year(), end.Month(), end.Day()+1, 0, 0, 0, 0, s.location)
+
+	deliveries, err := s.deliveryRepo.GetCompletedDeliveries(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var total onTimeCounter
+	daily := make(map[string]*onTimeCounter)
+	for _, delivery := range deliveries {
+		if delivery.ActualDeliveryTime == nil {
+			continue
+		}
+		lateBy := 0.0
+		if deadline, ok := deliveryDeadline(delivery); ok && delivery.ActualDeliveryTime.After(deadline) {
+			lateBy = delivery.ActualDeliveryTime.Sub(deadline).Minutes()
+		}
+		date := delivery.ActualDeliveryTime.In(s.location).Format("2006-01-02")
+		if daily[date] == nil {
+			daily[date] = &onTimeCounter{}
+		}
+		daily[date].add(lateBy)
+		total.add(lateBy)
+	}
+
+	performance := &models.OnTimePerformance{
+		Start:       from.Format("2006-01-02"),
+		End:         to.AddDate(0, 0, -1).Format("2006-01-02"),
+		OnTimeStats: total.stats(),
+		Days:        []models.DailyOnTimePerformance{},
+	}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		stats := models.OnTimeStats{}
+		if counter, ok := daily[date]; ok {
+			stats = counter.stats()
+		}
+		performance.Days = append(performance.Days, models.DailyOnTimePerformance{Date: date, OnTimeStats: stats})
+	}
+	return performance, nil
+}
+
+// predict は進行中の配送の到着を予測して保存し、遅延アラートを更新します
+func (s *ETAService) predict(ctx context.Context, delivery *models.Delivery) (*models.DeliveryETA, error) {
+	if delivery.RobotID == "" || delivery.Destination == nil {
+		return nil, ErrETAUnavailable
+	}
+	robotID, err := primitive.ObjectIDFromHex(delivery.RobotID)
+	if err != nil {
+		return nil, ErrETAUnavailable
+	}
+	robot, err := s.robotRepo.GetByID(ctx, robotID)
+	if err != nil {
+		return nil, err
+	}
+	if robot == nil {
+		return nil, ErrETAUnavailable
+	}
+
+	now := s.now()
+	eta := &models.DeliveryETA{
+		DeliveryID:   delivery.ID,
+		RobotID:      delivery.RobotID,
+		Status:       delivery.Status,
+		CalculatedAt: now,
+	}
+
+	var current models.Location
+	current, eta.LocationSource = currentLocation(delivery, robot)
+	metric := s.roads
+	if robot.Type == models.RobotTypeDrone {
+		metric = routing.Haversine{}
+	}
+	eta.RemainingDistance = metric.Distance(current, *delivery.Destination)
+
+	eta.Speed, eta.SpeedSource, err = s.robotSpeed(ctx, robot, now)
+	if err != nil {
+		return nil, err
+	}
+	travel := time.Duration(eta.RemainingDistance / eta.Speed * float64(time.Hour))
+	eta.PredictedArrival = now.Add(travel).Round(time.Second)
+
+	if deadline, ok := deliveryDeadline(delivery); ok {
+		eta.Deadline = deadline
+		if eta.PredictedArrival.After(deadline) {
+			eta.Late = true
+			eta.LateByMinutes = math.Round(eta.PredictedArrival.Sub(deadline).Minutes()*10) / 10
+		}
+	}
+
+	id, err := primitive.ObjectIDFromHex(delivery.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.deliveryRepo.UpdatePredictedArrival(ctx, id, eta.PredictedArrival); err != nil {
+		return nil, err
+	}
+
+	if !eta.Late {
+		if err := s.alertRepo.Resolve(ctx, delivery.ID, now); err != nil {
+			return nil, err
+		}
+		return eta, nil
+	}
+	alert := &models.DeliveryAlert{
+		DeliveryID:       delivery.ID,
+		RobotID:          delivery.RobotID,
+		Status:           models.DeliveryAlertOpen,
+		Deadline:         eta.Deadline,
+		PredictedArrival: eta.PredictedArrival,
+		LateByMinutes:    eta.LateByMinutes,
+		MaxLateByMinutes: eta.LateByMinutes,
+		RaisedAt:         now,
+		UpdatedAt:        now,
+	}
+	if err := s.alertRepo.Raise(ctx, alert); err != nil {
+		return nil, err
+	}
+	return eta, nil
+}
+
+// robotSpeed は機体の直近の走行履歴の平均の速度を返します
+// 走行履歴が少ない場合は機体の種類ごとの既定の速度を使います
+func (s *ETAService) robotSpeed(ctx context.Context, robot *models.Robot, now time.Time) (float64, models.ETASource, error) {
+	speed, readings, err := s.telemetryRepo.AverageSpeed(ctx, robot.ID.Hex(), now.Add(-s.config.SpeedHistory), now, s.config.MinMovingSpeed)
+	if err != nil {
+		return 0, "", err
+	}
+	if readings >= s.config.MinSpeedReadings && speed > s.config.MinMovingSpeed {
+		return speed, models.ETASourceHistory, nil
+	}
+	if robot.Type == models.RobotTypeDrone {
+		return s.config.DroneSpeed, models.ETASourceDefault, nil
+	}
+	return s.config.RobotSpeed, models.ETASourceDefault, nil
+}
+
+// currentLocation は配送機体の現在位置を返します
+// 配送中は機体の最新の計測値、なければ配送の追跡情報を使い、出発前や位置が分からない場合は拠点から出発するものとします
+func currentLocation(delivery *models.Delivery, robot *models.Robot) (models.Location, models.ETASource) {
+	if delivery.Status == models.StatusInProgress {
+		if robot.Telemetry != nil && robot.Telemetry.Location != (models.Location{}) {
+			return robot.Telemetry.Location, models.ETASourceTelemetry
+		}
+		if delivery.TrackingInfo != nil && delivery.TrackingInfo.CurrentLocation != nil {
+			return *delivery.TrackingInfo.CurrentLocation, models.ETASourceTracking
+		}
+	}
+	return robot.HomeBase, models.ETASourceHomeBase
+}
+
+// deliveryDeadline は配送の到着期限（到着時間帯の終了、指定がない場合は配送予定時刻）を返します
+func deliveryDeadline(delivery *models.Delivery) (time.Time, bool) {
+	if delivery.WindowEnd != nil {
+		return *delivery.WindowEnd, true
+	}
+	if !delivery.EstimatedDeliveryTime.IsZero() {
+		return delivery.EstimatedDeliveryTime, true
+	}
+	return time.Time{}, false
+}
+
+// isActiveDelivery は配送が準備中または配送中かどうかを返します
+func isActiveDelivery(delivery *models.Delivery) bool {
+	return delivery.Status == models.StatusPreparing || delivery.Status == models.StatusInProgress
+}
+
+// onTimeCounter は定時到着の件数と遅れの合計を数えます
+type onTimeCounter struct {
+	delivered   int
+	late        int
+	lateMinutes float64
+}
+
+func (c *onTimeCounter) add(lateBy float64) {
+	c.delivered++
+	if lateBy > 0 {
+		c.late++
+		c.lateMinutes += lateBy
+	}
+}
+
+func (c *onTimeCounter) stats() models.OnTimeStats {
+	stats := models.OnTimeStats{
+		Delivered: c.delivered,
+		OnTime:    c.delivered - c.late,
+		Late:      c.late,
+	}
+	if c.delivered > 0 {
+		stats.OnTimeRate = float64(stats.OnTime) / float64(c.delivered)
+	}
+	if c.late > 0 {
+		stats.AverageLateMinutes = math.Round(c.lateMinutes/float64(c.late)*10) / 10
+	}
+	return stats
+}
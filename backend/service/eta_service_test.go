@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// MockDeliveryAlertRepository は遅延アラートリポジトリのモックです
+type MockDeliveryAlertRepository struct {
+	mock.Mock
+}
+
+func (m *MockDeliveryAlertRepository) Raise(ctx context.Context, alert *models.DeliveryAlert) error {
+	args := m.Called(ctx, alert)
+	return args.Error(0)
+}
+
+func (m *MockDeliveryAlertRepository) Resolve(ctx context.Context, deliveryID string, resolvedAt time.Time) error {
+	args := m.Called(ctx, deliveryID, resolvedAt)
+	return args.Error(0)
+}
+
+func (m *MockDeliveryAlertRepository) AcquireRefreshLease(ctx context.Context, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, holder, now, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDeliveryAlertRepository) ReleaseRefreshLease(ctx context.Context, holder string) error {
+	args := m.Called(ctx, holder)
+	return args.Error(0)
+}
+
+func (m *MockDeliveryAlertRepository) ListOpenDeliveryIDs(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockDeliveryAlertRepository) List(ctx context.Context, status models.DeliveryAlertStatus) ([]*models.DeliveryAlert, error) {
+	args := m.Called(ctx, status)
+	return args.Get(0).([]*models.DeliveryAlert), args.Error(1)
+}
+
+func TestETAPredict(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	base := models.Location{Latitude: 35.0, Longitude: 139.0}
+	// north は拠点から北へ km だけ離れた地点です
+	north := func(km float64) *models.Location {
+		return &models.Location{Latitude: 35.0 + km/111.195, Longitude: 139.0}
+	}
+
+	newService := func() (*ETAService, *MockDeliveryRepository, *MockRobotRepository, *MockTelemetryRepository, *MockDeliveryAlertRepository) {
+		deliveryRepo := new(MockDeliveryRepository)
+		robotRepo := new(MockRobotRepository)
+		telemetryRepo := new(MockTelemetryRepository)
+		alertRepo := new(MockDeliveryAlertRepository)
+		s := NewETAService(deliveryRepo, robotRepo, telemetryRepo, alertRepo, nil, DefaultETAConfig(), time.UTC)
+		s.now = func() time.Time { return now }
+		return s, deliveryRepo, robotRepo, telemetryRepo, alertRepo
+	}
+
+	t.Run("走行履歴の速度で遅れを予測して遅延アラートを登録", func(t *testing.T) {
+		s, deliveryRepo, robotRepo, telemetryRepo, alertRepo := newService()
+		robot := &models.Robot{ID: primitive.NewObjectID(), Type: models.RobotTypeGround, HomeBase: base,
+			Telemetry: &models.RobotTelemetry{Timestamp: now, Location: *north(1)}}
+		deliveryID := primitive.NewObjectID()
+		windowEnd := now.Add(20 * time.Minute)
+		delivery := &models.Delivery{ID: deliveryID.Hex(), Status: models.StatusInProgress, RobotID: robot.ID.Hex(),
+			Destination: north(5), WindowEnd: &windowEnd, EstimatedDeliveryTime: now.Add(time.Hour)}
+
+		deliveryRepo.On("GetByID", ctx, deliveryID).Return(delivery, nil)
+		robotRepo.On("GetByID", ctx, robot.ID).Return(robot, nil)
+		// 残り4kmを時速8kmで30分、到着時間帯の終了より10分遅れる
+		telemetryRepo.On("AverageSpeed", ctx, robot.ID.Hex(), now.Add(-7*24*time.Hour), now, 0.5).Return(8.0, 120, nil)
+		deliveryRepo.On("UpdatePredictedArrival", ctx, deliveryID, now.Add(30*time.Minute)).Return(nil)
+		alertRepo.On("Raise", ctx, mock.MatchedBy(func(a *models.DeliveryAlert) bool {
+			return a.DeliveryID == delivery.ID && a.LateByMinutes == 10 && a.Deadline.Equal(windowEnd)
+		})).Return(nil)
+
+		eta, err := s.Predict(ctx, deliveryID)
+
+		require.NoError(t, err)
+		assert.InDelta(t, 4.0, eta.RemainingDistance, 0.01)
+		assert.Equal(t, models.ETASourceTelemetry, eta.LocationSource)
+		assert.Equal(t, models.ETASourceHistory, eta.SpeedSource)
+		assert.Equal(t, now.Add(30*time.Minute), eta.PredictedArrival)
+		assert.True(t, eta.Late)
+		assert.Equal(t, 10.0, eta.LateByMinutes)
+		alertRepo.AssertExpectations(t)
+		deliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("出発前は拠点から既定の速度で予測し、間に合う場合はアラートを解決", func(t *testing.T) {
+		s, deliveryRepo, robotRepo, telemetryRepo, alertRepo := newService()
+		robot := &models.Robot{ID: primitive.NewObjectID(), Type: models.RobotTypeGround, HomeBase: base}
+		deliveryID := primitive.NewObjectID()
+		delivery := &models.Delivery{ID: deliveryID.Hex(), Status: models.StatusPreparing, RobotID: robot.ID.Hex(),
+			Destination: north(3), EstimatedDeliveryTime: now.Add(time.Hour)}
+
+		deliveryRepo.On("GetByID", ctx, deliveryID).Return(delivery, nil)
+		robotRepo.On("GetByID", ctx, robot.ID).Return(robot, nil)
+		telemetryRepo.On("AverageSpeed", ctx, robot.ID.Hex(), mock.Anything, now, 0.5).Return(0.0, 0, nil)
+		deliveryRepo.On("UpdatePredictedArrival", ctx, deliveryID, now.Add(30*time.Minute)).Return(nil)
+		alertRepo.On("Resolve", ctx, delivery.ID, now).Return(nil)
+
+		eta, err := s.Predict(ctx, deliveryID)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.ETASourceHomeBase, eta.LocationSource)
+		assert.Equal(t, models.ETASourceDefault, eta.SpeedSource)
+		assert.Equal(t, 6.0, eta.Speed)
+		assert.False(t, eta.Late)
+		alertRepo.AssertExpectations(t)
+	})
+
+	t.Run("機体が未割り当ての配送は予測できない", func(t *testing.T) {
+		s, deliveryRepo, _, _, _ := newService()
+		deliveryID := primitive.NewObjectID()
+		deliveryRepo.On("GetByID", ctx, deliveryID).Return(&models.Delivery{ID: deliveryID.Hex(), Status: models.StatusPreparing, Destination: north(1)}, nil)
+
+		_, err := s.Predict(ctx, deliveryID)
+
+		assert.ErrorIs(t, err, ErrETAUnavailable)
+	})
+
+	t.Run("完了した配送はアラートを解決して予測しない", func(t *testing.T) {
+		s, deliveryRepo, _, _, alertRepo := newService()
+		deliveryID := primitive.NewObjectID()
+		deliveryRepo.On("GetByID", ctx, deliveryID).Return(&models.Delivery{ID: deliveryID.Hex(), Status: models.StatusCompleted}, nil)
+		alertRepo.On("Resolve", ctx, deliveryID.Hex(), now).Return(nil)
+
+		_, err := s.Predict(ctx, deliveryID)
+
+		assert.ErrorIs(t, err, ErrETAUnavailable)
+		alertRepo.AssertExpectations(t)
+	})
+}
+
+func TestETARefreshResolvesFinishedDeliveries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	deliveryRepo := new(MockDeliveryRepository)
+	alertRepo := new(MockDeliveryAlertRepository)
+	s := NewETAService(deliveryRepo, new(MockRobotRepository), new(MockTelemetryRepository), alertRepo, nil, DefaultETAConfig(), time.UTC)
+	s.now = func() time.Time { return now }
+
+	// 機体が未割り当ての配送は予測できないため結果に含めない
+	pending := &models.Delivery{ID: primitive.NewObjectID().Hex(), Status: models.StatusPreparing}
+	deliveryRepo.On("GetActiveDeliveries", ctx).Return([]*models.Delivery{pending}, nil)
+	alertRepo.On("ListOpenDeliveryIDs", ctx).Return([]string{pending.ID, "finished"}, nil)
+	alertRepo.On("Resolve", ctx, "finished", now).Return(nil)
+
+	etas, err := s.Refresh(ctx)
+
+	require.NoError(t, err)
+	assert.Empty(t, etas)
+	alertRepo.AssertExpectations(t)
+	alertRepo.AssertNotCalled(t, "Resolve", ctx, pending.ID, now)
+}
+
+func TestETARefreshWithLease(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+
+	t.Run("リースを取得したインスタンスが更新", func(t *testing.T) {
+		deliveryRepo := new(MockDeliveryRepository)
+		alertRepo := new(MockDeliveryAlertRepository)
+		s := NewETAService(deliveryRepo, new(MockRobotRepository), new(MockTelemetryRepository), alertRepo, nil, DefaultETAConfig(), time.UTC)
+		s.now = func() time.Time { return now }
+
+		alertRepo.On("AcquireRefreshLease", ctx, s.instanceID, now, s.config.RefreshLeaseTTL).Return(true, nil).Once()
+		alertRepo.On("ReleaseRefreshLease", mock.Anything, s.instanceID).Return(nil).Once()
+		deliveryRepo.On("GetActiveDeliveries", ctx).Return([]*models.Delivery{}, nil)
+		alertRepo.On("ListOpenDeliveryIDs", ctx).Return([]string{}, nil)
+
+		require.NoError(t, s.refreshWithLease(ctx))
+		alertRepo.AssertExpectations(t)
+		deliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("他のインスタンスが更新中の場合は何もしない", func(t *testing.T) {
+		deliveryRepo := new(MockDeliveryRepository)
+		alertRepo := new(MockDeliveryAlertRepository)
+		s := NewETAService(deliveryRepo, new(MockRobotRepository), new(MockTelemetryRepository), alertRepo, nil, DefaultETAConfig(), time.UTC)
+		s.now = func() time.Time { return now }
+
+		alertRepo.On("AcquireRefreshLease", ctx, s.instanceID, now, s.config.RefreshLeaseTTL).Return(false, nil).Once()
+
+		require.NoError(t, s.refreshWithLease(ctx))
+		deliveryRepo.AssertNotCalled(t, "GetActiveDeliveries", mock.Anything)
+		alertRepo.AssertNotCalled(t, "ReleaseRefreshLease", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetOnTimePerformance(t *testing.T) {
+	ctx := context.Background()
+	jst := time.FixedZone("JST", 9*60*60)
+	deliveryRepo := new(MockDeliveryRepository)
+	s := NewETAService(deliveryRepo, new(MockRobotRepository), new(MockTelemetryRepository), new(MockDeliveryAlertRepository), nil, DefaultETAConfig(), jst)
+
+	at := func(day, hour, minute int) *time.Time {
+		t := time.Date(2024, 6, day, hour, minute, 0, 0, jst)
+		return &t
+	}
+	deliveries := []*models.Delivery{
+		// 6/1: 到着時間帯内に完了
+		{Status: models.StatusCompleted, WindowEnd: at(1, 12, 0), EstimatedDeliveryTime: *at(1, 11, 0), ActualDeliveryTime: at(1, 11, 50)},
+		// 6/1: 到着時間帯の指定がないため配送予定時刻から20分遅れ
+		{Status: models.StatusCompleted, EstimatedDeliveryTime: *at(1, 15, 0), ActualDeliveryTime: at(1, 15, 20)},
+		// 6/3: 到着時間帯の終了から10分遅れ
+		{Status: models.StatusCompleted, WindowEnd: at(3, 9, 0), ActualDeliveryTime: at(3, 9, 10)},
+	}
+	deliveryRepo.On("GetCompletedDeliveries", ctx, *at(1, 0, 0), *at(4, 0, 0)).Return(deliveries, nil)
+
+	performance, err := s.GetOnTimePerformance(ctx, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	assert.Equal(t, "2024-06-01", performance.Start)
+	assert.Equal(t, "2024-06-03", performance.End)
+	assert.Equal(t, 3, performance.Delivered)
+	assert.Equal(t, 1, performance.OnTime)
+	assert.Equal(t, 2, performance.Late)
+	assert.InDelta(t, 1.0/3, performance.OnTimeRate, 1e-9)
+	assert.Equal(t, 15.0, performance.AverageLateMinutes)
+
+	require.Len(t, performance.Days, 3)
+	assert.Equal(t, models.DailyOnTimePerformance{Date: "2024-06-01",
+		OnTimeStats: models.OnTimeStats{Delivered: 2, OnTime: 1, Late: 1, OnTimeRate: 0.5, AverageLateMinutes: 20}}, performance.Days[0])
+	assert.Equal(t, models.DailyOnTimePerformance{Date: "2024-06-02"}, performance.Days[1])
+	assert.Equal(t, 1, performance.Days[2].Late)
+}
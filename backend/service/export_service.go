@@ -0,0 +1,526 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/export"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/tax"
+)
+
+var (
+	// ErrExportJobNotFound はエクスポートジョブが存在しない場合のエラーです
+	ErrExportJobNotFound = errors.New("export job not found")
+	// ErrExportNotReady はエクスポートが完了していない場合のエラーです
+	ErrExportNotReady = errors.New("export is not ready")
+	// ErrExportExpired はエクスポートファイルの保存期間が過ぎた場合のエラーです
+	ErrExportExpired = errors.New("export has expired")
+	// ErrExportRangeTooLarge は同期エクスポートの対象期間が長すぎる場合のエラーです
+	ErrExportRangeTooLarge = errors.New("export range too large for a synchronous export")
+)
+
+// errExportInterrupted は実行していたインスタンスの停止で中断されたジョブに記録するエラーです
+const errExportInterrupted = "export was interrupted because the server running it stopped"
+
+// exportDateLayout はエクスポートの対象期間の日付形式です
+const exportDateLayout = "2006-01-02"
+
+// ExportConfig はエクスポートの設定を表します
+type ExportConfig struct {
+	// Dir は非同期エクスポートのファイルの保存先です
+	Dir string
+	// TTL はエクスポートファイルを保存しておく期間です
+	TTL time.Duration
+	// SyncMaxDays は同期エクスポートで指定できる最大日数です（超える場合は非同期ジョブを使う）
+	SyncMaxDays int
+	// MaxConcurrentJobs は同時に実行する非同期エクスポートの数です
+	MaxConcurrentJobs int
+	// HeartbeatInterval は未完了のジョブの最終確認日時を更新する間隔です
+	HeartbeatInterval time.Duration
+	// HeartbeatTimeout は最終確認日時から、ジョブが中断されたとみなして失敗にするまでの時間です
+	HeartbeatTimeout time.Duration
+}
+
+// DefaultExportConfig は既定のエクスポートの設定を返します
+func DefaultExportConfig() ExportConfig {
+	return ExportConfig{
+		Dir:               filepath.Join(os.TempDir(), "smart-store-exports"),
+		TTL:               24 * time.Hour,
+		SyncMaxDays:       31,
+		MaxConcurrentJobs: 2,
+		HeartbeatInterval: 30 * time.Second,
+		HeartbeatTimeout:  2 * time.Minute,
+	}
+}
+
+// ExportRequest はエクスポートの条件を表します
+type ExportRequest struct {
+	Format     export.Format
+	StoreID    string
+	RegisterID string
+	// 対象期間（店舗のタイムゾーンでの日付、終了日を含む）
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// Validate は条件が正しいかどうかを検証します
+func (r *ExportRequest) Validate() error {
+	if _, err := export.ParseFormat(string(r.Format)); err != nil || r.Format == "" {
+		return errors.New("出力形式は csv, xlsx, parquet のいずれかを指定してください")
+	}
+	if r.StartDate.IsZero() || r.EndDate.IsZero() {
+		return errors.New("対象期間を指定してください")
+	}
+	if r.EndDate.Before(r.StartDate) {
+		return errors.New("終了日は開始日以降の日付を指定してください")
+	}
+	return nil
+}
+
+// Days は対象期間の日数を返します
+func (r *ExportRequest) Days() int {
+	return int(r.EndDate.Sub(r.StartDate).Hours()/24) + 1
+}
+
+// FileName はダウンロード時のファイル名を返します
+func (r *ExportRequest) FileName() string {
+	return fmt.Sprintf("sales_%s_%s%s",
+		r.StartDate.Format("20060102"), r.EndDate.Format("20060102"), r.Format.Extension())
+}
+
+// ExportServiceInterface は売上エクスポートサービスのインターフェースを定義します
+type ExportServiceInterface interface {
+	CheckSyncLimit(req *ExportRequest) error
+	ExportSales(ctx context.Context, req *ExportRequest, w io.Writer) (int64, error)
+	CreateJob(ctx context.Context, req *ExportRequest) (*models.ExportJob, error)
+	GetJob(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, error)
+	JobFile(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, string, error)
+}
+
+// ExportService は売上を明細単位でファイルに出力するサービスです
+type ExportService struct {
+	saleRepo    repository.SaleRepository
+	productRepo repository.ProductRepository
+	jobRepo     repository.ExportJobRepository
+	taxCalc     *tax.Calculator
+	location    *time.Location
+	config      ExportConfig
+	// jobs は同時に実行する非同期エクスポートの数を制限します
+	jobs chan struct{}
+	// instanceID はこのインスタンスが実行するジョブの Owner です
+	instanceID string
+	now        func() time.Time
+}
+
+// NewExportService は新しい売上エクスポートサービスを作成します
+// location は対象期間の日付の区切りと販売日時の表示に使う店舗のタイムゾーンです
+func NewExportService(
+	saleRepo repository.SaleRepository,
+	productRepo repository.ProductRepository,
+	jobRepo repository.ExportJobRepository,
+	taxCalc *tax.Calculator,
+	location *time.Location,
+	config ExportConfig,
+) *ExportService {
+	if config.MaxConcurrentJobs <= 0 {
+		config.MaxConcurrentJobs = 1
+	}
+	return &ExportService{
+		saleRepo:    saleRepo,
+		productRepo: productRepo,
+		jobRepo:     jobRepo,
+		taxCalc:     taxCalc,
+		location:    location,
+		config:      config,
+		jobs:        make(chan struct{}, config.MaxConcurrentJobs),
+		instanceID:  primitive.NewObjectID().Hex(),
+		now:         time.Now,
+	}
+}
+
+// CheckSyncLimit は同期エクスポートで扱える期間かどうかを確認します
+func (s *ExportService) CheckSyncLimit(req *ExportRequest) error {
+	if s.config.SyncMaxDays > 0 && req.Days() > s.config.SyncMaxDays {
+		return ErrExportRangeTooLarge
+	}
+	return nil
+}
+
+// ExportSales は条件に合う売上を明細単位で w に書き出し、出力した行数を返します
+// 売上はデータベースから1件ずつ読み込むため、期間が長くてもメモリ使用量は増えません
+func (s *ExportService) ExportSales(ctx context.Context, req *ExportRequest, w io.Writer) (int64, error) {
+	if err := req.Validate(); err != nil {
+		return 0, err
+	}
+
+	writer, err := export.NewWriter(w, req.Format)
+	if err != nil {
+		return 0, err
+	}
+
+	query := models.SaleQuery{
+		StoreID:    req.StoreID,
+		RegisterID: req.RegisterID,
+		Start:      time.Date(req.StartDate.Year(), req.StartDate.Month(), req.StartDate.Day(), 0, 0, 0, 0, s.location),
+		End:        time.Date(req.EndDate.Year(), req.EndDate.Month(), req.EndDate.Day()+1, 0, 0, 0, 0, s.location),
+	}
+
+	products := make(map[primitive.ObjectID]*models.Product)
+	var count int64
+	err = s.saleRepo.StreamSales(ctx, query, func(sale *models.Sale) error {
+		for _, row := range s.saleRows(ctx, sale, products) {
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, writer.Close()
+}
+
+// CreateJob は非同期エクスポートを登録し、バックグラウンドで実行します
+func (s *ExportService) CreateJob(ctx context.Context, req *ExportRequest) (*models.ExportJob, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	job := &models.ExportJob{
+		Format:      string(req.Format),
+		StoreID:     req.StoreID,
+		RegisterID:  req.RegisterID,
+		StartDate:   req.StartDate.Format(exportDateLayout),
+		EndDate:     req.EndDate.Format(exportDateLayout),
+		Status:      models.ExportJobPending,
+		FileName:    req.FileName(),
+		Owner:       s.instanceID,
+		HeartbeatAt: now,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.config.TTL),
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	go s.runJob(*job, *req)
+	return job, nil
+}
+
+// GetJob は指定されたIDのエクスポートジョブを取得します
+func (s *ExportService) GetJob(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, error) {
+	job, err := s.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrExportJobNotFound
+	}
+	return job, nil
+}
+
+// JobFile は完了したエクスポートジョブと出力ファイルのパスを返します
+func (s *ExportService) JobFile(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, string, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if job.Status != models.ExportJobCompleted {
+		return job, "", ErrExportNotReady
+	}
+	if s.now().After(job.ExpiresAt) {
+		return job, "", ErrExportExpired
+	}
+
+	path := s.jobPath(job)
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return job, "", ErrExportExpired
+		}
+		return job, "", err
+	}
+	return job, path, nil
+}
+
+// Start は保存期間を過ぎたエクスポートファイルを interval ごとに削除します
+// また、実行していたインスタンスの停止で中断された（最終確認日時が HeartbeatTimeout より前の）ジョブを
+// 起動時と HeartbeatInterval ごとに失敗として記録します
+func (s *ExportService) Start(ctx context.Context, interval time.Duration) {
+	s.failStaleJobs(ctx)
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.purgeExpiredFiles(); err != nil {
+					log.Printf("Failed to purge export files: %v", err)
+				}
+			}
+		}
+	}()
+
+	if s.config.HeartbeatInterval <= 0 {
+		return
+	}
+	staleTicker := time.NewTicker(s.config.HeartbeatInterval)
+	go func() {
+		defer staleTicker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-staleTicker.C:
+				s.failStaleJobs(ctx)
+			}
+		}
+	}()
+}
+
+// failStaleJobs は最終確認日時が HeartbeatTimeout より前の未完了のジョブを失敗にします
+// 他のインスタンスが実行中のジョブは最終確認日時が更新され続けるため、失敗にしません
+func (s *ExportService) failStaleJobs(ctx context.Context) {
+	now := s.now()
+	count, err := s.jobRepo.FailStale(ctx, errExportInterrupted, now.Add(-s.config.HeartbeatTimeout), now)
+	if err != nil {
+		log.Printf("Failed to mark interrupted export jobs as failed: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("Marked %d interrupted export jobs as failed", count)
+	}
+}
+
+// heartbeat はジョブが終わるまで（done が閉じられるまで）最終確認日時を更新します
+func (s *ExportService) heartbeat(id primitive.ObjectID, done <-chan struct{}) {
+	if s.config.HeartbeatInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.config.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := s.jobRepo.Heartbeat(context.Background(), id, s.instanceID, s.now()); err != nil {
+				log.Printf("Failed to update export job heartbeat %s: %v", id.Hex(), err)
+			}
+		}
+	}
+}
+
+// runJob はエクスポートジョブを実行し、結果を記録します
+// 実行枠を待つ間も最終確認日時を更新し、他のインスタンスから中断されたとみなされないようにします
+func (s *ExportService) runJob(job models.ExportJob, req ExportRequest) {
+	done := make(chan struct{})
+	defer close(done)
+	go s.heartbeat(job.ID, done)
+
+	s.jobs <- struct{}{}
+	defer func() { <-s.jobs }()
+
+	ctx := context.Background()
+	started := s.now()
+	job.Status = models.ExportJobRunning
+	job.StartedAt = &started
+	if err := s.jobRepo.UpdateStatus(ctx, &job); err != nil {
+		log.Printf("Failed to update export job %s: %v", job.ID.Hex(), err)
+	}
+
+	count, err := s.writeFile(ctx, &req, s.jobPath(&job))
+
+	completed := s.now()
+	job.CompletedAt = &completed
+	job.ExpiresAt = completed.Add(s.config.TTL)
+	job.RowCount = count
+	if err != nil {
+		log.Printf("Failed to export sales for job %s: %v", job.ID.Hex(), err)
+		job.Status = models.ExportJobFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = models.ExportJobCompleted
+	}
+	if err := s.jobRepo.UpdateStatus(ctx, &job); err != nil {
+		log.Printf("Failed to update export job %s: %v", job.ID.Hex(), err)
+	}
+}
+
+// writeFile はエクスポートを一時ファイルに書き出し、完了してから所定のパスに移動します
+func (s *ExportService) writeFile(ctx context.Context, req *ExportRequest, path string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+
+	buf := bufio.NewWriter(f)
+	count, err := s.ExportSales(ctx, req, buf)
+	if err == nil {
+		err = buf.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return count, err
+	}
+	return count, os.Rename(tmp, path)
+}
+
+// jobPath はジョブの出力ファイルの保存先を返します
+func (s *ExportService) jobPath(job *models.ExportJob) string {
+	return filepath.Join(s.config.Dir, job.ID.Hex()+export.Format(job.Format).Extension())
+}
+
+// purgeExpiredFiles は保存期間を過ぎたファイル（中断した一時ファイルを含む）を削除します
+func (s *ExportService) purgeExpiredFiles() error {
+	entries, err := os.ReadDir(s.config.Dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	deadline := s.now().Add(-s.config.TTL)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || !info.ModTime().Before(deadline) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.config.Dir, entry.Name())); err != nil {
+			log.Printf("Failed to remove export file %s: %v", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// saleRows は売上を明細ごとの行に変換します
+// 商品名・カテゴリ・税区分が売上に保存されていない過去の売上は商品マスタから補完します
+func (s *ExportService) saleRows(ctx context.Context, sale *models.Sale, products map[primitive.ObjectID]*models.Product) []*export.Row {
+	rows := make([]*export.Row, len(sale.Items))
+	for i, item := range sale.Items {
+		row := &export.Row{
+			SaleID:                sale.ID.Hex(),
+			SoldAt:                sale.CreatedAt.In(s.location),
+			StoreID:               sale.StoreID,
+			RegisterID:            sale.RegisterID,
+			TerminalTransactionID: sale.TerminalTransactionID,
+			PaymentMethod:         sale.PaymentMethod,
+			LineNo:                i + 1,
+			ProductID:             item.ProductID.Hex(),
+			ProductName:           item.Name,
+			Category:              item.Category,
+			Quantity:              item.Quantity,
+			UnitPrice:             item.PriceAtSale,
+			Amount:                item.PriceAtSale * float64(item.Quantity),
+			TaxClass:              string(item.TaxClass),
+		}
+		if row.ProductName == "" || row.Category == "" || row.TaxClass == "" {
+			if p := s.product(ctx, item.ProductID, products); p != nil {
+				row.ProductName = firstNonEmpty(row.ProductName, p.Name)
+				row.Category = firstNonEmpty(row.Category, p.Category)
+				row.TaxClass = firstNonEmpty(row.TaxClass, string(p.TaxClass))
+			}
+		}
+		if row.TaxClass == "" {
+			row.TaxClass = string(models.TaxClassStandard)
+		}
+		rows[i] = row
+	}
+
+	allocateDiscounts(rows, sale.Promotions)
+
+	for _, row := range rows {
+		class := models.TaxClass(row.TaxClass)
+		row.TaxRate = s.taxCalc.Rate(class)
+		result := s.taxCalc.Calculate([]tax.Line{{Class: class, Amount: row.Amount - row.Discount}})
+		row.TaxableAmount = result.Subtotal
+		row.TaxAmount = result.TotalTax
+	}
+	return rows
+}
+
+// product は商品マスタを取得します（同じエクスポート内ではキャッシュを使います）
+func (s *ExportService) product(ctx context.Context, id primitive.ObjectID, cache map[primitive.ObjectID]*models.Product) *models.Product {
+	if p, ok := cache[id]; ok {
+		return p
+	}
+	p, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		p = nil
+	}
+	cache[id] = p
+	return p
+}
+
+// allocateDiscounts は値引きを明細に割り当てます
+// 商品指定の値引きは対象商品の最初の明細に、売上全体の値引きは同じ税区分の明細に金額で按分します
+// （按分の端数は最後の明細で調整し、明細の値引額の合計が値引額と一致するようにします）
+func allocateDiscounts(rows []*export.Row, promotions []models.AppliedPromotion) {
+	for _, promo := range promotions {
+		if promo.ProductID != nil {
+			for _, row := range rows {
+				if row.ProductID == promo.ProductID.Hex() {
+					row.Discount += promo.Discount
+					break
+				}
+			}
+			continue
+		}
+
+		var targets []*export.Row
+		var base float64
+		for _, row := range rows {
+			if promo.TaxClass == "" || row.TaxClass == string(promo.TaxClass) {
+				targets = append(targets, row)
+				base += row.Amount
+			}
+		}
+		if base <= 0 {
+			continue
+		}
+		remaining := promo.Discount
+		for i, row := range targets {
+			share := remaining
+			if i < len(targets)-1 {
+				share = math.Round(promo.Discount * row.Amount / base)
+				remaining -= share
+			}
+			row.Discount += share
+		}
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
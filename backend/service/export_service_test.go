@@ -0,0 +1,252 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/export"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/tax"
+)
+
+type MockExportJobRepository struct {
+	mock.Mock
+}
+
+var _ repository.ExportJobRepository = (*MockExportJobRepository)(nil)
+
+func (m *MockExportJobRepository) Create(ctx context.Context, job *models.ExportJob) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockExportJobRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.ExportJob, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ExportJob), args.Error(1)
+}
+
+func (m *MockExportJobRepository) UpdateStatus(ctx context.Context, job *models.ExportJob) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockExportJobRepository) Heartbeat(ctx context.Context, id primitive.ObjectID, owner string, at time.Time) error {
+	args := m.Called(ctx, id, owner, at)
+	return args.Error(0)
+}
+
+func (m *MockExportJobRepository) FailStale(ctx context.Context, message string, staleBefore, at time.Time) (int64, error) {
+	args := m.Called(ctx, message, staleBefore, at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestExportSales(t *testing.T) {
+	ctx := context.Background()
+	jst := time.FixedZone("JST", 9*60*60)
+	milk := primitive.NewObjectID()
+	bag := primitive.NewObjectID()
+
+	mockSaleRepo := new(MockSaleRepository)
+	mockProductRepo := new(MockProductRepository)
+	svc := NewExportService(mockSaleRepo, mockProductRepo, new(MockExportJobRepository),
+		tax.NewCalculator(tax.DefaultConfig()), jst, DefaultExportConfig())
+
+	sale := &models.Sale{
+		ID:            primitive.NewObjectID(),
+		StoreID:       "store-1",
+		RegisterID:    "reg-1",
+		PaymentMethod: "cash",
+		CreatedAt:     time.Date(2024, 1, 5, 0, 30, 0, 0, time.UTC),
+		Items: []models.SaleItem{
+			{ProductID: milk, Name: "牛乳", Category: "乳製品", TaxClass: models.TaxClassReduced, Quantity: 2, PriceAtSale: 216},
+			// 商品名等が保存されていない過去の売上
+			{ProductID: bag, Quantity: 1, PriceAtSale: 330},
+		},
+		Promotions: []models.AppliedPromotion{
+			{Code: "MILK", Discount: 32, ProductID: &milk, TaxClass: models.TaxClassReduced},
+			{Code: "ALL", Discount: 30, TaxClass: models.TaxClassStandard},
+		},
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	query := models.SaleQuery{
+		StoreID: "store-1",
+		Start:   time.Date(2024, 1, 1, 0, 0, 0, 0, jst),
+		End:     time.Date(2024, 2, 1, 0, 0, 0, 0, jst),
+	}
+	mockSaleRepo.On("StreamSales", ctx, query, mock.Anything).Return([]*models.Sale{sale}, nil)
+	mockProductRepo.On("GetByID", ctx, bag).
+		Return(&models.Product{ID: bag, Name: "エコバッグ", Category: "雑貨", TaxClass: models.TaxClassStandard}, nil).Once()
+
+	var buf bytes.Buffer
+	count, err := svc.ExportSales(ctx, &ExportRequest{Format: export.FormatCSV, StoreID: "store-1", StartDate: start, EndDate: end}, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\r\n")
+	require.Len(t, lines, 3)
+	// 販売日時は店舗のタイムゾーン、税額は値引後の税込金額から計算
+	assert.Contains(t, lines[1], ",2024-01-05 09:30:00,store-1,reg-1,,cash,1,")
+	assert.Contains(t, lines[1], ",牛乳,乳製品,2,216,432,32,reduced,8,371,29")
+	assert.Contains(t, lines[2], ",エコバッグ,雑貨,1,330,330,30,standard,10,273,27")
+	mockProductRepo.AssertExpectations(t)
+}
+
+func TestAllocateDiscounts(t *testing.T) {
+	rows := []*export.Row{
+		{ProductID: "a", TaxClass: "standard", Amount: 100},
+		{ProductID: "b", TaxClass: "standard", Amount: 200},
+		{ProductID: "c", TaxClass: "reduced", Amount: 500},
+	}
+	allocateDiscounts(rows, []models.AppliedPromotion{
+		{Discount: 100, TaxClass: models.TaxClassStandard},
+	})
+
+	assert.Equal(t, 33.0, rows[0].Discount)
+	assert.Equal(t, 67.0, rows[1].Discount)
+	assert.Equal(t, 0.0, rows[2].Discount)
+}
+
+func TestExportRequestValidate(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	req := &ExportRequest{Format: export.FormatXLSX, StartDate: day(1), EndDate: day(31)}
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, 31, req.Days())
+	assert.Equal(t, "sales_20240101_20240131.xlsx", req.FileName())
+
+	assert.Error(t, (&ExportRequest{Format: export.FormatCSV, StartDate: day(2), EndDate: day(1)}).Validate())
+	assert.Error(t, (&ExportRequest{Format: "json", StartDate: day(1), EndDate: day(1)}).Validate())
+
+	svc := NewExportService(nil, nil, nil, nil, time.UTC, ExportConfig{SyncMaxDays: 7})
+	assert.NoError(t, svc.CheckSyncLimit(&ExportRequest{StartDate: day(1), EndDate: day(7)}))
+	assert.ErrorIs(t, svc.CheckSyncLimit(&ExportRequest{StartDate: day(1), EndDate: day(8)}), ErrExportRangeTooLarge)
+}
+
+func TestExportJob(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	config := DefaultExportConfig()
+	config.Dir = t.TempDir()
+	mockSaleRepo := new(MockSaleRepository)
+	mockJobRepo := new(MockExportJobRepository)
+	svc := NewExportService(mockSaleRepo, new(MockProductRepository), mockJobRepo,
+		tax.NewCalculator(tax.DefaultConfig()), time.UTC, config)
+	svc.now = func() time.Time { return now }
+
+	job := models.ExportJob{
+		ID:       primitive.NewObjectID(),
+		Format:   string(export.FormatParquet),
+		Status:   models.ExportJobPending,
+		FileName: "sales_20240101_20240131.parquet",
+	}
+	req := ExportRequest{
+		Format:    export.FormatParquet,
+		StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+	mockSaleRepo.On("StreamSales", ctx, mock.Anything, mock.Anything).Return([]*models.Sale{
+		{ID: primitive.NewObjectID(), Items: []models.SaleItem{{Name: "牛乳", Category: "乳製品", TaxClass: models.TaxClassReduced, Quantity: 1, PriceAtSale: 200}}},
+	}, nil)
+
+	var saved []models.ExportJob
+	mockJobRepo.On("UpdateStatus", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, *args.Get(1).(*models.ExportJob))
+	}).Return(nil)
+
+	svc.runJob(job, req)
+
+	require.Len(t, saved, 2)
+	assert.Equal(t, models.ExportJobRunning, saved[0].Status)
+	completed := saved[1]
+	assert.Equal(t, models.ExportJobCompleted, completed.Status)
+	assert.Equal(t, int64(1), completed.RowCount)
+	assert.Equal(t, now.Add(config.TTL), completed.ExpiresAt)
+
+	// 完了したファイルのダウンロード
+	mockJobRepo.On("GetByID", ctx, job.ID).Return(&completed, nil)
+	_, path, err := svc.JobFile(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(config.Dir, job.ID.Hex()+".parquet"), path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, []byte("PAR1")))
+
+	// 保存期間を過ぎた場合
+	svc.now = func() time.Time { return completed.ExpiresAt.Add(time.Second) }
+	_, _, err = svc.JobFile(ctx, job.ID)
+	assert.ErrorIs(t, err, ErrExportExpired)
+
+	// 未完了の場合
+	pending := primitive.NewObjectID()
+	mockJobRepo.On("GetByID", ctx, pending).Return(&models.ExportJob{ID: pending, Status: models.ExportJobRunning}, nil)
+	_, _, err = svc.JobFile(ctx, pending)
+	assert.ErrorIs(t, err, ErrExportNotReady)
+}
+
+func TestExportStartFailsInterruptedJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	now := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	mockJobRepo := new(MockExportJobRepository)
+	svc := NewExportService(new(MockSaleRepository), new(MockProductRepository), mockJobRepo,
+		tax.NewCalculator(tax.DefaultConfig()), time.UTC, DefaultExportConfig())
+	svc.now = func() time.Time { return now }
+	// 最終確認日時が HeartbeatTimeout より前のジョブだけを失敗にします（他のインスタンスが実行中のジョブは残します）
+	mockJobRepo.On("FailStale", ctx, errExportInterrupted, now.Add(-2*time.Minute), now).Return(int64(2), nil)
+
+	svc.Start(ctx, time.Hour)
+
+	mockJobRepo.AssertExpectations(t)
+}
+
+func TestExportJobHeartbeat(t *testing.T) {
+	now := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	config := DefaultExportConfig()
+	config.HeartbeatInterval = 5 * time.Millisecond
+
+	mockJobRepo := new(MockExportJobRepository)
+	svc := NewExportService(new(MockSaleRepository), new(MockProductRepository), mockJobRepo,
+		tax.NewCalculator(tax.DefaultConfig()), time.UTC, config)
+	svc.now = func() time.Time { return now }
+	jobID := primitive.NewObjectID()
+	beat := make(chan struct{}, 1)
+	mockJobRepo.On("Heartbeat", mock.Anything, jobID, svc.instanceID, now).Run(func(mock.Arguments) {
+		select {
+		case beat <- struct{}{}:
+		default:
+		}
+	}).Return(nil)
+
+	// 実行中のジョブはこのインスタンスが最終確認日時を更新し続けます
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		svc.heartbeat(jobID, done)
+		close(stopped)
+	}()
+	select {
+	case <-beat:
+	case <-time.After(time.Second):
+		t.Fatal("heartbeat was not recorded")
+	}
+	close(done)
+	<-stopped
+}
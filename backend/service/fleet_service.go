@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/geo"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+var (
+	// ErrRobotNotFound は配送機体が存在しない場合のエラーです
+	ErrRobotNotFound = errors.New("robot not found")
+	// ErrRobotBusy は配送に割り当て中の機体を削除しようとした場合のエラーです
+	ErrRobotBusy = errors.New("robot is assigned to a delivery")
+	// ErrRobotStatusChanged は更新中に機体の稼働状態（割り当て・解除を含む）が変わっていた場合のエラーです
+	ErrRobotStatusChanged = errors.New("robot status has changed")
+	// ErrDeliveryNotFound は配送が存在しない場合のエラーです
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	// ErrDeliveryNotAssignable は配送準備中でない、または既に機体を割り当てた配送の場合のエラーです
+	ErrDeliveryNotAssignable = errors.New("delivery is not awaiting robot assignment")
+	// ErrDeliveryDestinationMissing は配送先の位置が登録されていない場合のエラーです
+	ErrDeliveryDestinationMissing = errors.New("delivery destination is not set")
+	// ErrNoEligibleRobot は割り当て条件を満たす機体がない場合のエラーです
+	ErrNoEligibleRobot = errors.New("no eligible robot")
+)
+
+// FleetConfig は配送機体の割り当て条件の設定です
+type FleetConfig struct {
+	// BatteryReserve は往復に必要なバッテリーに加えて、帰着時に残しておく残量（%）です
+	BatteryReserve float64
+}
+
+// DefaultFleetConfig は既定の割り当て条件（予備バッテリー20%）を返します
+func DefaultFleetConfig() FleetConfig {
+	return FleetConfig{
+		BatteryReserve: 20,
+	}
+}
+
+// RobotRequest は配送機体の登録・更新内容です
+type RobotRequest struct {
+	Name            string           `json:"name"`
+	Type            models.RobotType `json:"type"`
+	PayloadCapacity float64          `json:"payloadCapacity"`
+	Range           float64          `json:"range"`
+	BatteryLevel    float64          `json:"batteryLevel"`
+	BatteryCapacity float64          `json:"batteryCapacity"`
+	HomeBase        models.Location  `json:"homeBase"`
+	// Status を省略した場合、登録時は待機中、更新時は現在の状態のままです
+	Status models.RobotStatus `json:"status"`
+}
+
+// Validate は配送機体の登録内容を検証します
+func (r *RobotRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("機体名を入力してください")
+	}
+	if r.Type != models.RobotTypeGround && r.Type != models.RobotTypeDrone {
+		return errors.New("機体の種類は robot または drone を指定してください")
+	}
+	if r.PayloadCapacity <= 0 {
+		return errors.New("最大積載重量は0より大きい値を指定してください")
+	}
+	if r.Range <= 0 {
+		return errors.New("航続距離は0より大きい値を指定してください")
+	}
+	if r.BatteryLevel < 0 || r.BatteryLevel > 100 {
+		return errors.New("バッテリー残量は0〜100の範囲で指定してください")
+	}
+	if r.BatteryCapacity < 0 {
+		return errors.New("バッテリー容量は0以上の値を指定してください")
+	}
+	if r.HomeBase.Latitude < -90 || r.HomeBase.Latitude > 90 || r.HomeBase.Longitude < -180 || r.HomeBase.Longitude > 180 {
+		return errors.New("拠点の位置が不正です")
+	}
+	if r.Status != "" && !models.ValidateRobotStatus(r.Status) {
+		return errors.New("稼働状態は idle, charging, maintenance のいずれかを指定してください")
+	}
+	if r.Status == models.RobotAssigned {
+		return errors.New("配送への割り当ては割り当てAPIで行ってください")
+	}
+	return nil
+}
+
+// FleetServiceInterface は配送ロボット・ドローンの管理と配送への割り当てを行うサービスのインターフェースを定義します
+type FleetServiceInterface interface {
+	RegisterRobot(ctx context.Context, req *RobotRequest) (*models.Robot, error)
+	GetRobot(ctx context.Context, id primitive.ObjectID) (*models.Robot, error)
+	ListRobots(ctx context.Context, query models.RobotQuery) ([]*models.Robot, error)
+	UpdateRobot(ctx context.Context, id primitive.ObjectID, req *RobotRequest, actor string) (*models.Robot, error)
+	DeleteRobot(ctx context.Context, id primitive.ObjectID) error
+	GetRobotDeliveries(ctx context.Context, id primitive.ObjectID) ([]*models.Delivery, error)
+	AssignDelivery(ctx context.Context, deliveryID primitive.ObjectID, robotID *primitive.ObjectID, actor string) (*models.RobotAssignment, error)
+}
+
+// FleetService は配送ロボット・ドローンを管理し、配送に適した機体を割り当てるサービスです
+type FleetService struct {
+	robotRepo    repository.RobotRepository
+	deliveryRepo repository.DeliveryRepository
+	config       FleetConfig
+	now          func() time.Time
+}
+
+func NewFleetService(robotRepo repository.RobotRepository, deliveryRepo repository.DeliveryRepository, config FleetConfig) *FleetService {
+	return &FleetService{
+		robotRepo:    robotRepo,
+		deliveryRepo: deliveryRepo,
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+// RegisterRobot は配送機体を登録します
+func (s *FleetService) RegisterRobot(ctx context.Context, req *RobotRequest) (*models.Robot, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	robot := &models.Robot{Status: models.RobotIdle}
+	applyRobotRequest(robot, req)
+	if err := s.robotRepo.Create(ctx, robot); err != nil {
+		return nil, err
+	}
+	return robot, nil
+}
+
+// GetRobot は配送機体を取得します
+func (s *FleetService) GetRobot(ctx context.Context, id primitive.ObjectID) (*models.Robot, error) {
+	robot, err := s.robotRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if robot == nil {
+		return nil, ErrRobotNotFound
+	}
+	return robot, nil
+}
+
+// ListRobots は条件に合う配送機体を取得します
+func (s *FleetService) ListRobots(ctx context.Context, query models.RobotQuery) ([]*models.Robot, error) {
+	return s.robotRepo.List(ctx, query)
+}
+
+// UpdateRobot は配送機体の仕様・バッテリー残量・稼働状態を更新します
+// 割り当て中の機体の状態を変更すると、同じトランザクションで配送への割り当ても解除します（actor は配送履歴に記録する実行者です）
+// 読み込んだ後に機体の稼働状態が変わっていた場合は ErrRobotStatusChanged を返します
+func (s *FleetService) UpdateRobot(ctx context.Context, id primitive.ObjectID, req *RobotRequest, actor string) (*models.Robot, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	robot, err := s.GetRobot(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	from, deliveryID := robot.Status, robot.CurrentDeliveryID
+	applyRobotRequest(robot, req)
+
+	if from == models.RobotAssigned && robot.Status != models.RobotAssigned {
+		if err := s.deliveryRepo.WithdrawRobot(ctx, robot, deliveryID, actor); err != nil {
+			if errors.Is(err, repository.ErrRobotStatusChanged) {
+				return nil, ErrRobotStatusChanged
+			}
+			return nil, err
+		}
+		robot.CurrentDeliveryID = ""
+		return robot, nil
+	}
+
+	updated, err := s.robotRepo.Update(ctx, robot, from)
+	if err != nil {
+		if errors.Is(err, repository.ErrRobotStatusChanged) {
+			return nil, ErrRobotStatusChanged
+		}
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrRobotNotFound
+	}
+	return robot, nil
+}
+
+// DeleteRobot は配送機体を削除します（配送に割り当て中の機体は削除できません）
+func (s *FleetService) DeleteRobot(ctx context.Context, id primitive.ObjectID) error {
+	robot, err := s.GetRobot(ctx, id)
+	if err != nil {
+		return err
+	}
+	if robot.Status == models.RobotAssigned {
+		return ErrRobotBusy
+	}
+	deleted, err := s.robotRepo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrRobotNotFound
+	}
+	return nil
+}
+
+// GetRobotDeliveries は配送機体に割り当てた配送の一覧を取得します
+func (s *FleetService) GetRobotDeliveries(ctx context.Context, id primitive.ObjectID) ([]*models.Delivery, error) {
+	if _, err := s.GetRobot(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.deliveryRepo.GetDeliveriesByRobot(ctx, id.Hex())
+}
+
+// AssignDelivery は配送に機体を割り当て、配送に記録します
+// robotID を指定しない場合は、配送種別に合う待機中の機体から、積載重量・往復距離・バッテリー残量の条件を満たし、
+// 拠点が配送先に最も近い機体を選びます（同じ距離ならバッテリーの余裕が大きい機体）
+// 条件を満たす機体がない場合は、各機体の判定結果を含む割り当て結果と ErrNoEligibleRobot を返します
+// actor は配送履歴に記録する割り当ての実行者です
+func (s *FleetService) AssignDelivery(ctx context.Context, deliveryID primitive.ObjectID, robotID *primitive.ObjectID, actor string) (*models.RobotAssignment, error) {
+	delivery, err := s.deliveryRepo.GetByID(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, ErrDeliveryNotFound
+	}
+	if delivery.Status != models.StatusPreparing || delivery.RobotID != "" {
+		return nil, ErrDeliveryNotAssignable
+	}
+	if delivery.Destination == nil {
+		return nil, ErrDeliveryDestinationMissing
+	}
+
+	var robots []*models.Robot
+	if robotID != nil {
+		robot, err := s.GetRobot(ctx, *robotID)
+		if err != nil {
+			return nil, err
+		}
+		robots = []*models.Robot{robot}
+	} else {
+		robots, err = s.robotRepo.List(ctx, models.RobotQuery{Type: models.RobotTypeForDelivery(delivery.DeliveryType)})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	assignment := &models.RobotAssignment{
+		DeliveryID: deliveryID.Hex(),
+		Candidates: make([]models.RobotCandidate, 0, len(robots)),
+	}
+	var eligible []*models.Robot
+	candidates := map[primitive.ObjectID]models.RobotCandidate{}
+	for _, robot := range robots {
+		candidate := s.evaluate(robot, delivery)
+		assignment.Candidates = append(assignment.Candidates, candidate)
+		candidates[robot.ID] = candidate
+		if candidate.Eligible {
+			eligible = append(eligible, robot)
+		}
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		a, b := candidates[eligible[i].ID], candidates[eligible[j].ID]
+		if a.TripDistance != b.TripDistance {
+			return a.TripDistance < b.TripDistance
+		}
+		return eligible[i].BatteryLevel-a.RequiredBattery > eligible[j].BatteryLevel-b.RequiredBattery
+	})
+
+	for _, robot := range eligible {
+		// 他の配送に先に割り当てられた機体は次の候補に譲ります
+		assigned, err := s.robotRepo.Assign(ctx, robot.ID, deliveryID.Hex())
+		if err != nil {
+			return nil, err
+		}
+		if !assigned {
+			continue
+		}
+
+		now := s.now()
+		if err := s.deliveryRepo.AssignRobot(ctx, deliveryID, robot.ID.Hex(), now, actor); err != nil {
+			// 同時に他の機体が割り当てられた場合も、確保した機体は待機中に戻します
+			if releaseErr := s.robotRepo.Release(ctx, robot.ID, models.RobotIdle); releaseErr != nil {
+				return nil, fmt.Errorf("%w (failed to release robot: %v)", err, releaseErr)
+			}
+			if errors.Is(err, repository.ErrDeliveryAlreadyAssigned) {
+				return nil, ErrDeliveryNotAssignable
+			}
+			return nil, err
+		}
+		robot.Status = models.RobotAssigned
+		robot.CurrentDeliveryID = deliveryID.Hex()
+		assignment.Robot = robot
+		assignment.AssignedAt = now
+		return assignment, nil
+	}
+	return assignment, ErrNoEligibleRobot
+}
+
+// evaluate は機体が配送の条件（待機中・積載重量・航続距離・バッテリー残量）を満たすかを判定します
+func (s *FleetService) evaluate(robot *models.Robot, delivery *models.Delivery) models.RobotCandidate {
+	candidate := models.RobotCandidate{
+		RobotID:      robot.ID.Hex(),
+		Name:         robot.Name,
+		Type:         robot.Type,
+		TripDistance: 2 * geo.Distance(robot.HomeBase, *delivery.Destination),
+	}
+	deliveryType := models.RobotTypeForDelivery(delivery.DeliveryType)
+	if robot.Range > 0 {
+		candidate.RequiredBattery = candidate.TripDistance/robot.Range*100 + s.config.BatteryReserve
+	}
+
+	switch {
+	case robot.Status != models.RobotIdle:
+		candidate.Reason = fmt.Sprintf("待機中ではありません（%s）", robot.Status)
+	case deliveryType != "" && robot.Type != deliveryType:
+		candidate.Reason = "配送種別と機体の種類が異なります"
+	case delivery.PayloadWeight > robot.PayloadCapacity:
+		candidate.Reason = "荷物の重量が最大積載重量を超えています"
+	case candidate.TripDistance > robot.Range:
+		candidate.Reason = "往復距離が航続距離を超えています"
+	case robot.BatteryLevel < candidate.RequiredBattery:
+		candidate.Reason = "バッテリー残量が不足しています"
+	default:
+		candidate.Eligible = true
+	}
+	return candidate
+}
+
+func applyRobotRequest(robot *models.Robot, req *RobotRequest) {
+	robot.Name = strings.TrimSpace(req.Name)
+	robot.Type = req.Type
+	robot.PayloadCapacity = req.PayloadCapacity
+	robot.Range = req.Range
+	robot.BatteryLevel = req.BatteryLevel
+	robot.BatteryCapacity = req.BatteryCapacity
+	robot.HomeBase = req.HomeBase
+	if req.Status != "" {
+		robot.Status = req.Status
+	}
+}
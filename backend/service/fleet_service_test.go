@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+type MockRobotRepository struct {
+	mock.Mock
+}
+
+var _ repository.RobotRepository = (*MockRobotRepository)(nil)
+
+func (m *MockRobotRepository) Create(ctx context.Context, robot *models.Robot) error {
+	args := m.Called(ctx, robot)
+	return args.Error(0)
+}
+
+func (m *MockRobotRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Robot, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Robot), args.Error(1)
+}
+
+func (m *MockRobotRepository) List(ctx context.Context, query models.RobotQuery) ([]*models.Robot, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]*models.Robot), args.Error(1)
+}
+
+func (m *MockRobotRepository) Update(ctx context.Context, robot *models.Robot, from models.RobotStatus) (bool, error) {
+	args := m.Called(ctx, robot, from)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRobotRepository) Assign(ctx context.Context, id primitive.ObjectID, deliveryID string) (bool, error) {
+	args := m.Called(ctx, id, deliveryID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRobotRepository) Release(ctx context.Context, id primitive.ObjectID, status models.RobotStatus) error {
+	args := m.Called(ctx, id, status)
+	return args.Error(0)
+}
+
+func (m *MockRobotRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRobotRepository) UpdateTelemetry(ctx context.Context, id primitive.ObjectID, telemetry models.RobotTelemetry) error {
+	args := m.Called(ctx, id, telemetry)
+	return args.Error(0)
+}
+
+func TestAssignDelivery(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.June, 1, 10, 0, 0, 0, time.UTC)
+	store := models.Location{Latitude: 35.681236, Longitude: 139.767125}
+	// 配送先は店舗から約6.4km（往復約12.8km）
+	destination := &models.Location{Latitude: 35.628471, Longitude: 139.738760}
+	// 配送先のすぐ近くの拠点
+	nearBase := models.Location{Latitude: 35.63, Longitude: 139.74}
+
+	newRobot := func(name string, base models.Location, capacity, rangeKm, battery float64, status models.RobotStatus) *models.Robot {
+		return &models.Robot{
+			ID:              primitive.NewObjectID(),
+			Name:            name,
+			Type:            models.RobotTypeDrone,
+			PayloadCapacity: capacity,
+			Range:           rangeKm,
+			BatteryLevel:    battery,
+			HomeBase:        base,
+			Status:          status,
+		}
+	}
+
+	t.Run("条件を満たす機体のうち拠点が最も近い機体を割り当て", func(t *testing.T) {
+		robotRepo := new(MockRobotRepository)
+		deliveryRepo := new(MockDeliveryRepository)
+		service := NewFleetService(robotRepo, deliveryRepo, DefaultFleetConfig())
+		service.now = func() time.Time { return now }
+
+		deliveryID := primitive.NewObjectID()
+		deliveryRepo.On("GetByID", ctx, deliveryID).Return(&models.Delivery{
+			DeliveryType:  "ドローン",
+			Status:        models.StatusPreparing,
+			PayloadWeight: 3,
+			Destination:   destination,
+		}, nil)
+
+		heavy := newRobot("積載不足", nearBase, 2, 30, 100, models.RobotIdle)
+		charging := newRobot("充電中", nearBase, 5, 30, 100, models.RobotCharging)
+		lowBattery := newRobot("残量不足", store, 5, 30, 50, models.RobotIdle) // 必要: 12.8/30*100+20 ≒ 63%
+		far := newRobot("店舗拠点", store, 5, 30, 90, models.RobotIdle)
+		near := newRobot("近隣拠点", nearBase, 5, 30, 80, models.RobotIdle)
+		robotRepo.On("List", ctx, models.RobotQuery{Type: models.RobotTypeDrone}).
+			Return([]*models.Robot{heavy, charging, lowBattery, far, near}, nil)
+
+		// 近隣拠点の機体は直前に他の配送に割り当てられたため、次の候補を割り当てます
+		robotRepo.On("Assign", ctx, near.ID, deliveryID.Hex()).Return(false, nil)
+		robotRepo.On("Assign", ctx, far.ID, deliveryID.Hex()).Return(true, nil)
+		deliveryRepo.On("AssignRobot", ctx, deliveryID, far.ID.Hex(), now, "staff-1").Return(nil)
+
+		assignment, err := service.AssignDelivery(ctx, deliveryID, nil, "staff-1")
+		assert.NoError(t, err)
+		assert.Equal(t, far.ID, assignment.Robot.ID)
+		assert.Equal(t, models.RobotAssigned, assignment.Robot.Status)
+		assert.Equal(t, now, assignment.AssignedAt)
+
+		reasons := map[string]string{}
+		for _, c := range assignment.Candidates {
+			reasons[c.Name] = c.Reason
+		}
+		assert.Equal(t, "荷物の重量が最大積載重量を超えています", reasons["積載不足"])
+		assert.Equal(t, "待機中ではありません（charging）", reasons["充電中"])
+		assert.Equal(t, "バッテリー残量が不足しています", reasons["残量不足"])
+		assert.Empty(t, reasons["店舗拠点"])
+		deliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("条件を満たす機体がない", func(t *testing.T) {
+		robotRepo := new(MockRobotRepository)
+		deliveryRepo := new(MockDeliveryRepository)
+		service := NewFleetService(robotRepo, deliveryRepo, DefaultFleetConfig())
+
+		deliveryID := primitive.NewObjectID()
+		deliveryRepo.On("GetByID", ctx, deliveryID).Return(&models.Delivery{
+			Status:        models.StatusPreparing,
+			PayloadWeight: 3,
+			Destination:   destination,
+		}, nil)
+		shortRange := newRobot("航続距離不足", store, 5, 10, 100, models.RobotIdle)
+		robotRepo.On("List", ctx, models.RobotQuery{}).Return([]*models.Robot{shortRange}, nil)
+
+		assignment, err := service.AssignDelivery(ctx, deliveryID, nil, "staff-1")
+		assert.ErrorIs(t, err, ErrNoEligibleRobot)
+		assert.Len(t, assignment.Candidates, 1)
+		assert.Equal(t, "往復距離が航続距離を超えています", assignment.Candidates[0].Reason)
+	})
+
+	t.Run("割り当て済みの配送はエラー", func(t *testing.T) {
+		deliveryRepo := new(MockDeliveryRepository)
+		service := NewFleetService(new(MockRobotRepository), deliveryRepo, DefaultFleetConfig())
+
+		deliveryID := primitive.NewObjectID()
+		deliveryRepo.On("GetByID", ctx, deliveryID).Return(&models.Delivery{
+			Status:      models.StatusPreparing,
+			Destination: destination,
+			RobotID:     primitive.NewObjectID().Hex(),
+		}, nil)
+
+		_, err := service.AssignDelivery(ctx, deliveryID, nil, "staff-1")
+		assert.ErrorIs(t, err, ErrDeliveryNotAssignable)
+	})
+
+	t.Run("同時に他の機体が割り当てられた場合は確保した機体を待機中に戻す", func(t *testing.T) {
+		robotRepo := new(MockRobotRepository)
+		deliveryRepo := new(MockDeliveryRepository)
+		service := NewFleetService(robotRepo, deliveryRepo, DefaultFleetConfig())
+		service.now = func() time.Time { return now }
+
+		deliveryID := primitive.NewObjectID()
+		deliveryRepo.On("GetByID", ctx, deliveryID).Return(&models.Delivery{
+			Status:      models.StatusPreparing,
+			Destination: destination,
+		}, nil)
+		near := newRobot("近隣拠点", nearBase, 5, 30, 80, models.RobotIdle)
+		robotRepo.On("List", ctx, models.RobotQuery{}).Return([]*models.Robot{near}, nil)
+		robotRepo.On("Assign", ctx, near.ID, deliveryID.Hex()).Return(true, nil)
+		deliveryRepo.On("AssignRobot", ctx, deliveryID, near.ID.Hex(), now, "staff-1").Return(repository.ErrDeliveryAlreadyAssigned)
+		robotRepo.On("Release", ctx, near.ID, models.RobotIdle).Return(nil).Once()
+
+		_, err := service.AssignDelivery(ctx, deliveryID, nil, "staff-1")
+		assert.ErrorIs(t, err, ErrDeliveryNotAssignable)
+		robotRepo.AssertExpectations(t)
+	})
+}
+
+func TestUpdateRobot(t *testing.T) {
+	ctx := context.Background()
+	base := models.Location{Latitude: 35.63, Longitude: 139.74}
+	newRequest := func(status models.RobotStatus) *RobotRequest {
+		return &RobotRequest{
+			Name:            "ドローン1号機",
+			Type:            models.RobotTypeDrone,
+			PayloadCapacity: 5,
+			Range:           30,
+			BatteryLevel:    40,
+			HomeBase:        base,
+			Status:          status,
+		}
+	}
+
+	t.Run("割り当て中の機体を充電中にすると配送の割り当ても解除", func(t *testing.T) {
+		robotRepo := new(MockRobotRepository)
+		deliveryRepo := new(MockDeliveryRepository)
+		service := NewFleetService(robotRepo, deliveryRepo, DefaultFleetConfig())
+
+		deliveryID := primitive.NewObjectID().Hex()
+		robot := &models.Robot{ID: primitive.NewObjectID(), Type: models.RobotTypeDrone, Status: models.RobotAssigned, CurrentDeliveryID: deliveryID}
+		robotRepo.On("GetByID", ctx, robot.ID).Return(robot, nil)
+		deliveryRepo.On("WithdrawRobot", ctx, mock.MatchedBy(func(r *models.Robot) bool {
+			return r.Status == models.RobotCharging
+		}), deliveryID, "staff-1").Return(nil)
+
+		updated, err := service.UpdateRobot(ctx, robot.ID, newRequest(models.RobotCharging), "staff-1")
+		assert.NoError(t, err)
+		assert.Equal(t, models.RobotCharging, updated.Status)
+		assert.Empty(t, updated.CurrentDeliveryID)
+		robotRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+		deliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("稼働状態を省略した場合は読み込んだ状態のまま更新", func(t *testing.T) {
+		robotRepo := new(MockRobotRepository)
+		deliveryRepo := new(MockDeliveryRepository)
+		service := NewFleetService(robotRepo, deliveryRepo, DefaultFleetConfig())
+
+		deliveryID := primitive.NewObjectID().Hex()
+		robot := &models.Robot{ID: primitive.NewObjectID(), Type: models.RobotTypeDrone, Status: models.RobotAssigned, CurrentDeliveryID: deliveryID}
+		robotRepo.On("GetByID", ctx, robot.ID).Return(robot, nil)
+		robotRepo.On("Update", ctx, robot, models.RobotAssigned).Return(true, nil)
+
+		updated, err := service.UpdateRobot(ctx, robot.ID, newRequest(""), "staff-1")
+		assert.NoError(t, err)
+		assert.Equal(t, models.RobotAssigned, updated.Status)
+		assert.Equal(t, deliveryID, updated.CurrentDeliveryID)
+		deliveryRepo.AssertNotCalled(t, "WithdrawRobot", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("読み込んだ後に稼働状態が変わっていた", func(t *testing.T) {
+		robotRepo := new(MockRobotRepository)
+		deliveryRepo := new(MockDeliveryRepository)
+		service := NewFleetService(robotRepo, deliveryRepo, DefaultFleetConfig())
+
+		robot := &models.Robot{ID: primitive.NewObjectID(), Type: models.RobotTypeDrone, Status: models.RobotIdle}
+		robotRepo.On("GetByID", ctx, robot.ID).Return(robot, nil)
+		robotRepo.On("Update", ctx, robot, models.RobotIdle).Return(false, repository.ErrRobotStatusChanged)
+
+		_, err := service.UpdateRobot(ctx, robot.ID, newRequest(models.RobotMaintenance), "staff-1")
+		assert.ErrorIs(t, err, ErrRobotStatusChanged)
+	})
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onoderaryou/smart-store-admin/backend/forecast"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+var (
+	// ErrForecastModelNotFound は指定した予測モデルが登録されていない場合のエラーです
+	ErrForecastModelNotFound = errors.New("forecast model not found")
+	// ErrForecastHorizonTooLong は予測日数が上限を超えている場合のエラーです
+	ErrForecastHorizonTooLong = errors.New("forecast horizon too long")
+)
+
+// ForecastConfig は売上予測の設定です
+type ForecastConfig struct {
+	// DefaultModel はモデルを指定しない場合に使うモデル名です（未設定の場合は最初に登録したモデル）
+	DefaultModel string
+	// HistoryDays は予測に使う売上履歴の日数です
+	HistoryDays int
+	// DefaultHorizon・MaxHorizon は予測日数の既定値と上限です
+	DefaultHorizon int
+	MaxHorizon     int
+	// BacktestFolds はバックテストで過去に遡って予測する回数です
+	BacktestFolds int
+}
+
+// DefaultForecastConfig は既定の売上予測の設定（履歴182日・4週間先まで予測）を返します
+func DefaultForecastConfig() ForecastConfig {
+	return ForecastConfig{
+		HistoryDays:    182,
+		DefaultHorizon: 28,
+		MaxHorizon:     90,
+		BacktestFolds:  3,
+	}
+}
+
+// RevenueForecastRequest は売上予測の条件です
+type RevenueForecastRequest struct {
+	// StoreID が空の場合は全店舗の合計を予測します
+	StoreID string
+	// Horizon は当日から予測する日数です（0の場合は既定値）
+	Horizon int
+	// HistoryDays は予測に使う履歴の日数です（0の場合は既定値）
+	HistoryDays int
+	// Model は予測モデル名です（空の場合は既定のモデル）
+	Model string
+}
+
+// Validate は売上予測の条件を検証します
+func (r *RevenueForecastRequest) Validate() error {
+	if r.Horizon < 0 {
+		return errors.New("予測日数は1以上を指定してください")
+	}
+	if r.HistoryDays < 0 {
+		return errors.New("履歴の日数は1以上を指定してください")
+	}
+	return nil
+}
+
+// ForecastServiceInterface は売上予測サービスのインターフェースを定義します
+type ForecastServiceInterface interface {
+	ForecastRevenue(ctx context.Context, req *RevenueForecastRequest) (*models.RevenueForecast, error)
+	Models() []string
+	MaxHorizon() int
+}
+
+// ForecastService は日別の売上履歴から店舗の売上を予測するサービスです
+// 予測モデルは forecast.Model を実装したものを登録して切り替えます
+type ForecastService struct {
+	saleRepo repository.SaleRepository
+	calendar CalendarServiceInterface
+	models   map[string]forecast.Model
+	config   ForecastConfig
+	location *time.Location
+	now      func() time.Time
+}
+
+func NewForecastService(saleRepo repository.SaleRepository, calendar CalendarServiceInterface, config ForecastConfig, location *time.Location, forecastModels ...forecast.Model) *ForecastService {
+	s := &ForecastService{
+		saleRepo: saleRepo,
+		calendar: calendar,
+		models:   make(map[string]forecast.Model, len(forecastModels)),
+		config:   config,
+		location: location,
+		now:      time.Now,
+	}
+	for _, m := range forecastModels {
+		s.models[m.Name()] = m
+	}
+	if s.config.DefaultModel == "" && len(forecastModels) > 0 {
+		s.config.DefaultModel = forecastModels[0].Name()
+	}
+	return s
+}
+
+// Models は登録されている予測モデル名を返します
+func (s *ForecastService) Models() []string {
+	names := make([]string, 0, len(s.models))
+	for name := range s.models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MaxHorizon は予測できる日数の上限を返します
+func (s *ForecastService) MaxHorizon() int {
+	return s.config.MaxHorizon
+}
+
+// ForecastRevenue は当日から指定日数分の日別売上を予測し、同じモデルで過去の期間を予測した精度（MAPE）を添えて返します
+// 当日は営業中のため、履歴は前日までの売上を使います
+func (s *ForecastService) ForecastRevenue(ctx context.Context, req *RevenueForecastRequest) (*models.RevenueForecast, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Model)
+	if name == "" {
+		name = s.config.DefaultModel
+	}
+	model, ok := s.models[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrForecastModelNotFound, name)
+	}
+	horizon := req.Horizon
+	if horizon == 0 {
+		horizon = s.config.DefaultHorizon
+	}
+	if horizon > s.config.MaxHorizon {
+		return nil, fmt.Errorf("%w: max %d days", ErrForecastHorizonTooLong, s.config.MaxHorizon)
+	}
+	historyDays := req.HistoryDays
+	if historyDays == 0 {
+		historyDays = s.config.HistoryDays
+	}
+
+	now := s.now().In(s.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	start := today.AddDate(0, 0, -historyDays)
+
+	cal, err := s.calendar.StoreCalendar(ctx, req.StoreID, start, today.AddDate(0, 0, horizon))
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.history(ctx, req.StoreID, start, today, cal)
+	if err != nil {
+		return nil, err
+	}
+
+	future := make([]forecast.Day, horizon)
+	for i := range future {
+		future[i] = forecast.NewDay(today.AddDate(0, 0, i), cal)
+	}
+	predictions, err := model.Forecast(history, future)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.RevenueForecast{
+		StoreID:     req.StoreID,
+		Model:       model.Name(),
+		GeneratedAt: now,
+		HistoryEnd:  today.AddDate(0, 0, -1).Format("2006-01-02"),
+		Points:      make([]models.ForecastPoint, len(future)),
+	}
+	if len(history) > 0 {
+		result.HistoryStart = history[0].Date.Format("2006-01-02")
+	}
+	for i, d := range future {
+		day := cal.Day(d.Date)
+		point := models.ForecastPoint{
+			Date:        day.Date,
+			Weekday:     day.Weekday,
+			DayType:     day.DayType,
+			HolidayName: day.HolidayName,
+			Revenue:     predictions[i],
+		}
+		for _, event := range day.Events {
+			point.Events = append(point.Events, event.Name)
+		}
+		result.Points[i] = point
+		result.Total += predictions[i]
+	}
+
+	backtest, err := forecast.Backtest(model, history, horizon, s.config.BacktestFolds)
+	switch {
+	case err == nil:
+		result.Accuracy = &models.ForecastAccuracy{
+			Folds:         backtest.Folds,
+			Horizon:       backtest.Horizon,
+			EvaluatedDays: backtest.Evaluated,
+			MAPE:          backtest.MAPE,
+			MAE:           backtest.MAE,
+		}
+	case !errors.Is(err, forecast.ErrInsufficientHistory):
+		return nil, err
+	}
+	return result, nil
+}
+
+// history は期間の日別売上を売上のない日を0として埋めた履歴に変換します
+// 最初の売上より前の日（開店前やデータ移行前）は履歴に含めません
+func (s *ForecastService) history(ctx context.Context, storeID string, start, end time.Time, cal forecast.Calendar) ([]forecast.Observation, error) {
+	daily, err := s.saleRepo.GetDailyRevenue(ctx, models.SaleQuery{
+		StoreID: storeID,
+		Start:   start,
+		End:     end,
+	}, s.location.String())
+	if err != nil {
+		return nil, err
+	}
+	revenue := make(map[string]float64, len(daily))
+	for _, d := range daily {
+		revenue[d.Date] = d.Revenue
+	}
+
+	var history []forecast.Observation
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		value, ok := revenue[d.Format("2006-01-02")]
+		if !ok && len(history) == 0 {
+			continue
+		}
+		history = append(history, forecast.Observation{
+			Day:   forecast.NewDay(d, cal),
+			Value: value,
+		})
+	}
+	return history, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/onoderaryou/smart-store-admin/backend/forecast"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+func TestForecastRevenue(t *testing.T) {
+	mockSaleRepo := new(MockSaleRepository)
+	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
+	service := NewForecastService(mockSaleRepo, newTestCalendarService(jst), DefaultForecastConfig(), jst,
+		forecast.NewWeekdaySeasonal(), forecast.SeasonalNaive{})
+	// 2024-05-01（水曜）10時時点の予測
+	service.now = func() time.Time { return time.Date(2024, time.May, 1, 10, 0, 0, 0, jst) }
+	ctx := context.Background()
+
+	today := time.Date(2024, time.May, 1, 0, 0, 0, 0, jst)
+	query := models.SaleQuery{Start: today.AddDate(0, 0, -182), End: today}
+
+	// 2024-03-01から毎日10万円（日曜日は売上なし）
+	var daily []*models.DailyRevenue
+	for d := time.Date(2024, time.March, 1, 0, 0, 0, 0, jst); d.Before(today); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() == time.Sunday {
+			continue
+		}
+		daily = append(daily, &models.DailyRevenue{Date: d.Format("2006-01-02"), Revenue: 100000})
+	}
+	mockSaleRepo.On("GetDailyRevenue", ctx, query, jst.String()).Return(daily, nil)
+
+	t.Run("既定のモデルで予測", func(t *testing.T) {
+		result, err := service.ForecastRevenue(ctx, &RevenueForecastRequest{Horizon: 7})
+		assert.NoError(t, err)
+		assert.Equal(t, "weekday_seasonal", result.Model)
+		assert.Equal(t, "2024-03-01", result.HistoryStart)
+		assert.Equal(t, "2024-04-30", result.HistoryEnd)
+		assert.Len(t, result.Points, 7)
+
+		// 05-03〜05-06は祝日、05-05は日曜日
+		assert.Equal(t, "2024-05-03", result.Points[2].Date)
+		assert.Equal(t, models.DayTypeHoliday, result.Points[2].DayType)
+		assert.Equal(t, "憲法記念日", result.Points[2].HolidayName)
+		assert.Equal(t, "Sunday", result.Points[4].Weekday)
+		assert.InDelta(t, 0, result.Points[4].Revenue, 0.01)
+		assert.InDelta(t, 100000, result.Points[0].Revenue, 0.01)
+
+		if assert.NotNil(t, result.Accuracy) {
+			assert.Equal(t, 3, result.Accuracy.Folds)
+		}
+	})
+
+	t.Run("未登録のモデルでエラー", func(t *testing.T) {
+		_, err := service.ForecastRevenue(ctx, &RevenueForecastRequest{Model: "prophet"})
+		assert.ErrorIs(t, err, ErrForecastModelNotFound)
+	})
+
+	t.Run("予測日数の上限超過でエラー", func(t *testing.T) {
+		_, err := service.ForecastRevenue(ctx, &RevenueForecastRequest{Horizon: 91})
+		assert.ErrorIs(t, err, ErrForecastHorizonTooLong)
+	})
+}
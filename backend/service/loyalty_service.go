@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/loyalty"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+// ErrMemberNotFound は指定された会員が存在しない場合のエラーです
+var ErrMemberNotFound = errors.New("member not found")
+
+// LoyaltyServiceInterface は会員・ポイントサービスのインターフェースを定義します
+type LoyaltyServiceInterface interface {
+	CreateMember(ctx context.Context, member *models.Member) error
+	GetMember(ctx context.Context, id primitive.ObjectID) (*models.Member, error)
+	GetMemberByCode(ctx context.Context, code string) (*models.Member, error)
+	ListMembers(ctx context.Context, skip, limit int64) ([]*models.Member, error)
+	UpdateMember(ctx context.Context, member *models.Member) error
+	GetPurchaseHistory(ctx context.Context, id primitive.ObjectID, skip, limit int64) ([]*models.Sale, error)
+	GetPointLedger(ctx context.Context, id primitive.ObjectID, skip, limit int64) ([]*models.PointTransaction, error)
+	AdjustPoints(ctx context.Context, id primitive.ObjectID, points int, note string) (*models.PointTransaction, error)
+
+	// 売上記録時のポイント処理
+	PrepareSale(ctx context.Context, sale *models.Sale) error
+	ReleaseRedemption(ctx context.Context, sale *models.Sale) error
+	CompleteSale(ctx context.Context, sale *models.Sale) error
+}
+
+// LoyaltyService は会員とポイントを扱うサービスです
+type LoyaltyService struct {
+	memberRepo repository.MemberRepository
+	ledgerRepo repository.PointTransactionRepository
+	saleRepo   repository.SaleRepository
+	calc       *loyalty.Calculator
+}
+
+// NewLoyaltyService は新しい会員・ポイントサービスを作成します
+func NewLoyaltyService(memberRepo repository.MemberRepository, ledgerRepo repository.PointTransactionRepository, saleRepo repository.SaleRepository, calc *loyalty.Calculator) *LoyaltyService {
+	return &LoyaltyService{
+		memberRepo: memberRepo,
+		ledgerRepo: ledgerRepo,
+		saleRepo:   saleRepo,
+		calc:       calc,
+	}
+}
+
+// CreateMember は新しい会員を登録します
+func (s *LoyaltyService) CreateMember(ctx context.Context, member *models.Member) error {
+	if member.MemberCode == "" {
+		return errors.New("会員番号が指定されていません")
+	}
+	if member.Name == "" {
+		return errors.New("会員名が指定されていません")
+	}
+
+	// ポイント残高と購買実績は登録時に0から始める
+	member.PointBalance = 0
+	member.LifetimePoints = 0
+	member.TotalSpent = 0
+	member.VisitCount = 0
+	member.LastVisitAt = nil
+
+	return s.memberRepo.Create(ctx, member)
+}
+
+// GetMember は指定されたIDの会員を取得します
+func (s *LoyaltyService) GetMember(ctx context.Context, id primitive.ObjectID) (*models.Member, error) {
+	member, err := s.memberRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrMemberNotFound
+	}
+	return member, nil
+}
+
+// GetMemberByCode は会員番号から会員を取得します
+func (s *LoyaltyService) GetMemberByCode(ctx context.Context, code string) (*models.Member, error) {
+	member, err := s.memberRepo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrMemberNotFound
+	}
+	return member, nil
+}
+
+// ListMembers は会員一覧を取得します
+func (s *LoyaltyService) ListMembers(ctx context.Context, skip, limit int64) ([]*models.Member, error) {
+	return s.memberRepo.List(ctx, skip, limit)
+}
+
+// UpdateMember は会員の氏名・連絡先を更新します
+func (s *LoyaltyService) UpdateMember(ctx context.Context, member *models.Member) error {
+	if member.Name == "" {
+		return errors.New("会員名が指定されていません")
+	}
+	if err := s.memberRepo.UpdateProfile(ctx, member); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// GetPurchaseHistory は会員の購入履歴を取得します
+func (s *LoyaltyService) GetPurchaseHistory(ctx context.Context, id primitive.ObjectID, skip, limit int64) ([]*models.Sale, error) {
+	if _, err := s.GetMember(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.saleRepo.GetSalesByMember(ctx, id, skip, limit)
+}
+
+// GetPointLedger は会員のポイント履歴を取得します
+func (s *LoyaltyService) GetPointLedger(ctx context.Context, id primitive.ObjectID, skip, limit int64) ([]*models.PointTransaction, error) {
+	if _, err := s.GetMember(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.ledgerRepo.ListByMember(ctx, id, skip, limit)
+}
+
+// AdjustPoints はポイントを手動で増減し、台帳に記録します
+func (s *LoyaltyService) AdjustPoints(ctx context.Context, id primitive.ObjectID, points int, note string) (*models.PointTransaction, error) {
+	if points == 0 {
+		return nil, errors.New("調整ポイントが指定されていません")
+	}
+	if note == "" {
+		return nil, errors.New("調整理由が指定されていません")
+	}
+
+	member, err := s.memberRepo.AddPoints(ctx, id, points)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, err
+	}
+
+	tx := &models.PointTransaction{
+		MemberID:     id,
+		Type:         models.PointTransactionAdjust,
+		Points:       points,
+		BalanceAfter: member.PointBalance,
+		Note:         note,
+	}
+	if err := s.ledgerRepo.Create(ctx, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// PrepareSale は売上の会員・ポイント利用を検証し、付与ポイントを計算します
+// ポイント利用がある場合はこの時点で残高から差し引きます
+func (s *LoyaltyService) PrepareSale(ctx context.Context, sale *models.Sale) error {
+	sale.PointsPayment = 0
+	sale.PointsEarned = 0
+	sale.CO2BonusPoints = 0
+
+	if sale.MemberID == nil {
+		if sale.PointsRedeemed != 0 || sale.PaymentMethod == models.PaymentMethodPoints {
+			return errors.New("ポイントの利用には会員IDが必要です")
+		}
+		return nil
+	}
+	if sale.PointsRedeemed < 0 {
+		return errors.New("利用ポイントは0以上である必要があります")
+	}
+
+	if _, err := s.GetMember(ctx, *sale.MemberID); err != nil {
+		return err
+	}
+
+	sale.PointsPayment = s.calc.RedemptionValue(sale.PointsRedeemed)
+	if sale.PointsPayment > sale.TotalAmount {
+		return errors.New("ポイント利用額が支払金額を超えています")
+	}
+	if sale.PointsRedeemed > 0 && sale.PointsPayment == sale.TotalAmount {
+		sale.PaymentMethod = models.PaymentMethodPoints
+	} else if sale.PaymentMethod == models.PaymentMethodPoints {
+		return errors.New("ポイントで支払えない残額の支払方法が指定されていません")
+	}
+
+	earned := s.calc.Earn(sale)
+	sale.PointsEarned = earned.Total()
+	sale.CO2BonusPoints = earned.Bonus
+
+	if sale.PointsRedeemed > 0 {
+		if _, err := s.memberRepo.AddPoints(ctx, *sale.MemberID, -sale.PointsRedeemed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseRedemption は売上の記録に失敗した場合に利用ポイントを残高へ戻します
+func (s *LoyaltyService) ReleaseRedemption(ctx context.Context, sale *models.Sale) error {
+	if sale.MemberID == nil || sale.PointsRedeemed == 0 {
+		return nil
+	}
+	_, err := s.memberRepo.AddPoints(ctx, *sale.MemberID, sale.PointsRedeemed)
+	return err
+}
+
+// CompleteSale は記録済みの売上について購買実績とポイント付与を反映し、台帳に記録します
+func (s *LoyaltyService) CompleteSale(ctx context.Context, sale *models.Sale) error {
+	if sale.MemberID == nil {
+		return nil
+	}
+
+	at := sale.CreatedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	member, err := s.memberRepo.RecordPurchase(ctx, *sale.MemberID, sale.TotalAmount, sale.PointsEarned, at)
+	if err != nil {
+		return err
+	}
+
+	saleID := sale.ID
+	if sale.PointsRedeemed > 0 {
+		tx := &models.PointTransaction{
+			MemberID:     *sale.MemberID,
+			SaleID:       &saleID,
+			Type:         models.PointTransactionRedeem,
+			Points:       -sale.PointsRedeemed,
+			BalanceAfter: member.PointBalance - sale.PointsEarned,
+		}
+		if err := s.ledgerRepo.Create(ctx, tx); err != nil {
+			return err
+		}
+	}
+	if sale.PointsEarned > 0 {
+		tx := &models.PointTransaction{
+			MemberID:     *sale.MemberID,
+			SaleID:       &saleID,
+			Type:         models.PointTransactionEarn,
+			Points:       sale.PointsEarned,
+			BonusPoints:  sale.CO2BonusPoints,
+			BalanceAfter: member.PointBalance,
+		}
+		if err := s.ledgerRepo.Create(ctx, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/loyalty"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/tax"
+)
+
+type MockMemberRepository struct {
+	mock.Mock
+}
+
+var _ repository.MemberRepository = (*MockMemberRepository)(nil)
+
+func (m *MockMemberRepository) Create(ctx context.Context, member *models.Member) error {
+	args := m.Called(ctx, member)
+	return args.Error(0)
+}
+
+func (m *MockMemberRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Member, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Member), args.Error(1)
+}
+
+func (m *MockMemberRepository) GetByCode(ctx context.Context, code string) (*models.Member, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Member), args.Error(1)
+}
+
+func (m *MockMemberRepository) List(ctx context.Context, skip, limit int64) ([]*models.Member, error) {
+	args := m.Called(ctx, skip, limit)
+	return args.Get(0).([]*models.Member), args.Error(1)
+}
+
+func (m *MockMemberRepository) UpdateProfile(ctx context.Context, member *models.Member) error {
+	args := m.Called(ctx, member)
+	return args.Error(0)
+}
+
+func (m *MockMemberRepository) AddPoints(ctx context.Context, id primitive.ObjectID, points int) (*models.Member, error) {
+	args := m.Called(ctx, id, points)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Member), args.Error(1)
+}
+
+func (m *MockMemberRepository) RecordPurchase(ctx context.Context, id primitive.ObjectID, amount float64, points int, at time.Time) (*models.Member, error) {
+	args := m.Called(ctx, id, amount, points, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Member), args.Error(1)
+}
+
+type MockPointTransactionRepository struct {
+	mock.Mock
+}
+
+var _ repository.PointTransactionRepository = (*MockPointTransactionRepository)(nil)
+
+func (m *MockPointTransactionRepository) Create(ctx context.Context, tx *models.PointTransaction) error {
+	args := m.Called(ctx, tx)
+	return args.Error(0)
+}
+
+func (m *MockPointTransactionRepository) ListByMember(ctx context.Context, memberID primitive.ObjectID, skip, limit int64) ([]*models.PointTransaction, error) {
+	args := m.Called(ctx, memberID, skip, limit)
+	return args.Get(0).([]*models.PointTransaction), args.Error(1)
+}
+
+// newTestLoyaltyService は会員を伴わない売上のテスト用にポイントサービスを作成します
+func newTestLoyaltyService(saleRepo repository.SaleRepository) *LoyaltyService {
+	return NewLoyaltyService(new(MockMemberRepository), new(MockPointTransactionRepository), saleRepo, loyalty.NewCalculator(loyalty.DefaultConfig()))
+}
+
+func TestPrepareSale(t *testing.T) {
+	ctx := context.Background()
+	memberID := primitive.NewObjectID()
+	member := &models.Member{ID: memberID, MemberCode: "M0001", Name: "山田花子", PointBalance: 500}
+
+	tests := []struct {
+		name       string
+		sale       *models.Sale
+		mockFn     func(*MockMemberRepository)
+		wantErr    error
+		wantAnyErr bool
+		wantMethod string
+		wantEarned int
+		wantBonus  int
+	}{
+		{
+			name:       "会員なしの売上はポイント処理なし",
+			sale:       &models.Sale{TotalAmount: 1000, PaymentMethod: models.PaymentMethodCash},
+			mockFn:     func(m *MockMemberRepository) {},
+			wantMethod: models.PaymentMethodCash,
+		},
+		{
+			name:       "会員なしのポイント利用はエラー",
+			sale:       &models.Sale{TotalAmount: 1000, PointsRedeemed: 100},
+			mockFn:     func(m *MockMemberRepository) {},
+			wantAnyErr: true,
+		},
+		{
+			name:    "存在しない会員はエラー",
+			sale:    &models.Sale{MemberID: &memberID, TotalAmount: 1000},
+			mockFn:  func(m *MockMemberRepository) { m.On("GetByID", ctx, memberID).Return(nil, nil) },
+			wantErr: ErrMemberNotFound,
+		},
+		{
+			name: "低CO2商品のボーナスを付与",
+			sale: &models.Sale{
+				MemberID: &memberID,
+				Items: []models.SaleItem{
+					{Quantity: 1, PriceAtSale: 800, CO2Emission: 2.0},
+					{Quantity: 2, PriceAtSale: 200, CO2Emission: 0.1},
+				},
+				TotalAmount:   1200,
+				PaymentMethod: models.PaymentMethodCreditCard,
+			},
+			mockFn:     func(m *MockMemberRepository) { m.On("GetByID", ctx, memberID).Return(member, nil) },
+			wantMethod: models.PaymentMethodCreditCard,
+			wantEarned: 16,
+			wantBonus:  4,
+		},
+		{
+			name: "全額ポイント払い",
+			sale: &models.Sale{MemberID: &memberID, TotalAmount: 300, PointsRedeemed: 300},
+			mockFn: func(m *MockMemberRepository) {
+				m.On("GetByID", ctx, memberID).Return(member, nil)
+				m.On("AddPoints", ctx, memberID, -300).Return(member, nil)
+			},
+			wantMethod: models.PaymentMethodPoints,
+		},
+		{
+			name:       "支払金額を超えるポイント利用はエラー",
+			sale:       &models.Sale{MemberID: &memberID, TotalAmount: 300, PointsRedeemed: 400},
+			mockFn:     func(m *MockMemberRepository) { m.On("GetByID", ctx, memberID).Return(member, nil) },
+			wantAnyErr: true,
+		},
+		{
+			name:       "残額があるのに支払方法がポイントのみはエラー",
+			sale:       &models.Sale{MemberID: &memberID, TotalAmount: 1000, PointsRedeemed: 100, PaymentMethod: models.PaymentMethodPoints},
+			mockFn:     func(m *MockMemberRepository) { m.On("GetByID", ctx, memberID).Return(member, nil) },
+			wantAnyErr: true,
+		},
+		{
+			name: "ポイント残高不足はエラー",
+			sale: &models.Sale{MemberID: &memberID, TotalAmount: 1000, PointsRedeemed: 800, PaymentMethod: models.PaymentMethodCash},
+			mockFn: func(m *MockMemberRepository) {
+				m.On("GetByID", ctx, memberID).Return(member, nil)
+				m.On("AddPoints", ctx, memberID, -800).Return(nil, repository.ErrInsufficientPoints)
+			},
+			wantErr: repository.ErrInsufficientPoints,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memberRepo := new(MockMemberRepository)
+			tt.mockFn(memberRepo)
+			s := NewLoyaltyService(memberRepo, new(MockPointTransactionRepository), new(MockSaleRepository), loyalty.NewCalculator(loyalty.DefaultConfig()))
+
+			err := s.PrepareSale(ctx, tt.sale)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantAnyErr:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantMethod, tt.sale.PaymentMethod)
+				assert.Equal(t, tt.wantEarned, tt.sale.PointsEarned)
+				assert.Equal(t, tt.wantBonus, tt.sale.CO2BonusPoints)
+				memberRepo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestCompleteSale(t *testing.T) {
+	ctx := context.Background()
+	memberID := primitive.NewObjectID()
+	saleID := primitive.NewObjectID()
+	createdAt := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+
+	memberRepo := new(MockMemberRepository)
+	ledgerRepo := new(MockPointTransactionRepository)
+	s := NewLoyaltyService(memberRepo, ledgerRepo, new(MockSaleRepository), loyalty.NewCalculator(loyalty.DefaultConfig()))
+
+	sale := &models.Sale{
+		ID:             saleID,
+		MemberID:       &memberID,
+		TotalAmount:    1000,
+		PointsRedeemed: 200,
+		PointsPayment:  200,
+		PointsEarned:   10,
+		CO2BonusPoints: 2,
+		CreatedAt:      createdAt,
+	}
+
+	// 利用200ポイント差し引き後の残高300に付与10ポイントを加算
+	memberRepo.On("RecordPurchase", ctx, memberID, 1000.0, 10, createdAt).
+		Return(&models.Member{ID: memberID, PointBalance: 310}, nil)
+
+	var recorded []*models.PointTransaction
+	ledgerRepo.On("Create", ctx, mock.AnythingOfType("*models.PointTransaction")).
+		Run(func(args mock.Arguments) {
+			recorded = append(recorded, args.Get(1).(*models.PointTransaction))
+		}).
+		Return(nil)
+
+	err := s.CompleteSale(ctx, sale)
+	assert.NoError(t, err)
+	assert.Len(t, recorded, 2)
+
+	assert.Equal(t, models.PointTransactionRedeem, recorded[0].Type)
+	assert.Equal(t, -200, recorded[0].Points)
+	assert.Equal(t, 300, recorded[0].BalanceAfter)
+	assert.Equal(t, saleID, *recorded[0].SaleID)
+
+	assert.Equal(t, models.PointTransactionEarn, recorded[1].Type)
+	assert.Equal(t, 10, recorded[1].Points)
+	assert.Equal(t, 2, recorded[1].BonusPoints)
+	assert.Equal(t, 310, recorded[1].BalanceAfter)
+}
+
+func TestAdjustPoints(t *testing.T) {
+	ctx := context.Background()
+	memberID := primitive.NewObjectID()
+
+	t.Run("手動調整を台帳に記録", func(t *testing.T) {
+		memberRepo := new(MockMemberRepository)
+		ledgerRepo := new(MockPointTransactionRepository)
+		s := NewLoyaltyService(memberRepo, ledgerRepo, new(MockSaleRepository), loyalty.NewCalculator(loyalty.DefaultConfig()))
+
+		memberRepo.On("AddPoints", ctx, memberID, 50).Return(&models.Member{ID: memberID, PointBalance: 150}, nil)
+		ledgerRepo.On("Create", ctx, mock.AnythingOfType("*models.PointTransaction")).Return(nil)
+
+		tx, err := s.AdjustPoints(ctx, memberID, 50, "レシート紛失による付与漏れ")
+		assert.NoError(t, err)
+		assert.Equal(t, models.PointTransactionAdjust, tx.Type)
+		assert.Equal(t, 150, tx.BalanceAfter)
+	})
+
+	t.Run("理由なしはエラー", func(t *testing.T) {
+		s := newTestLoyaltyService(new(MockSaleRepository))
+
+		_, err := s.AdjustPoints(ctx, memberID, 50, "")
+		assert.Error(t, err)
+	})
+}
+
+func TestCreate_MemberSaleReleasesPointsOnFailure(t *testing.T) {
+	ctx := context.Background()
+	memberID := primitive.NewObjectID()
+	productID := primitive.NewObjectID()
+
+	mockSaleRepo := new(MockSaleRepository)
+	mockProductRepo := new(MockProductRepository)
+	memberRepo := new(MockMemberRepository)
+	loyaltyService := NewLoyaltyService(memberRepo, new(MockPointTransactionRepository), mockSaleRepo, loyalty.NewCalculator(loyalty.DefaultConfig()))
+	service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(tax.DefaultConfig()), loyaltyService, nil, time.UTC)
+
+	mockProductRepo.On("GetByID", ctx, productID).Return(&models.Product{ID: productID, Name: "テスト商品"}, nil)
+	memberRepo.On("GetByID", ctx, memberID).Return(&models.Member{ID: memberID, PointBalance: 500}, nil)
+	memberRepo.On("AddPoints", ctx, memberID, -100).Return(&models.Member{ID: memberID, PointBalance: 400}, nil)
+	memberRepo.On("AddPoints", ctx, memberID, 100).Return(&models.Member{ID: memberID, PointBalance: 500}, nil)
+	mockSaleRepo.On("Create", ctx, mock.AnythingOfType("*models.Sale")).Return(errors.New("db error"))
+
+	err := service.Create(ctx, &models.Sale{
+		MemberID:       &memberID,
+		Items:          []models.SaleItem{{ProductID: productID, Quantity: 1, PriceAtSale: 1000}},
+		PointsRedeemed: 100,
+		PaymentMethod:  models.PaymentMethodCash,
+	})
+	assert.Error(t, err)
+	memberRepo.AssertCalled(t, "AddPoints", ctx, memberID, 100)
+}
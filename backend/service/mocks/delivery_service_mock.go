@@ -38,17 +38,17 @@ func (m *MockDeliveryServiceInterface) EXPECT() *MockDeliveryServiceInterfaceMoc
 }
 
 // CreateDelivery mocks base method.
-func (m *MockDeliveryServiceInterface) CreateDelivery(ctx context.Context, delivery *models.Delivery) error {
+func (m *MockDeliveryServiceInterface) CreateDelivery(ctx context.Context, delivery *models.Delivery, actor string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateDelivery", ctx, delivery)
+	ret := m.ctrl.Call(m, "CreateDelivery", ctx, delivery, actor)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateDelivery indicates an expected call of CreateDelivery.
-func (mr *MockDeliveryServiceInterfaceMockRecorder) CreateDelivery(ctx, delivery interface{}) *gomock.Call {
+func (mr *MockDeliveryServiceInterfaceMockRecorder) CreateDelivery(ctx, delivery, actor interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDelivery", reflect.TypeOf((*MockDeliveryServiceInterface)(nil).CreateDelivery), ctx, delivery)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDelivery", reflect.TypeOf((*MockDeliveryServiceInterface)(nil).CreateDelivery), ctx, delivery, actor)
 }
 
 // GetActiveDeliveries mocks base method.
@@ -97,29 +97,29 @@ func (mr *MockDeliveryServiceInterfaceMockRecorder) GetDeliveryByID(ctx, id inte
 }
 
 // UpdateDeliveryLocation mocks base method.
-func (m *MockDeliveryServiceInterface) UpdateDeliveryLocation(ctx context.Context, id primitive.ObjectID, location models.Location) error {
+func (m *MockDeliveryServiceInterface) UpdateDeliveryLocation(ctx context.Context, id primitive.ObjectID, tracking models.TrackingInfo, actor string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateDeliveryLocation", ctx, id, location)
+	ret := m.ctrl.Call(m, "UpdateDeliveryLocation", ctx, id, tracking, actor)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateDeliveryLocation indicates an expected call of UpdateDeliveryLocation.
-func (mr *MockDeliveryServiceInterfaceMockRecorder) UpdateDeliveryLocation(ctx, id, location interface{}) *gomock.Call {
+func (mr *MockDeliveryServiceInterfaceMockRecorder) UpdateDeliveryLocation(ctx, id, tracking, actor interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeliveryLocation", reflect.TypeOf((*MockDeliveryServiceInterface)(nil).UpdateDeliveryLocation), ctx, id, location)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeliveryLocation", reflect.TypeOf((*MockDeliveryServiceInterface)(nil).UpdateDeliveryLocation), ctx, id, tracking, actor)
 }
 
 // UpdateDeliveryStatus mocks base method.
-func (m *MockDeliveryServiceInterface) UpdateDeliveryStatus(ctx context.Context, id primitive.ObjectID, status models.DeliveryStatus) error {
+func (m *MockDeliveryServiceInterface) UpdateDeliveryStatus(ctx context.Context, id primitive.ObjectID, status models.DeliveryStatus, actor string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateDeliveryStatus", ctx, id, status)
+	ret := m.ctrl.Call(m, "UpdateDeliveryStatus", ctx, id, status, actor)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateDeliveryStatus indicates an expected call of UpdateDeliveryStatus.
-func (mr *MockDeliveryServiceInterfaceMockRecorder) UpdateDeliveryStatus(ctx, id, status interface{}) *gomock.Call {
+func (mr *MockDeliveryServiceInterfaceMockRecorder) UpdateDeliveryStatus(ctx, id, status, actor interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeliveryStatus", reflect.TypeOf((*MockDeliveryServiceInterface)(nil).UpdateDeliveryStatus), ctx, id, status)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeliveryStatus", reflect.TypeOf((*MockDeliveryServiceInterface)(nil).UpdateDeliveryStatus), ctx, id, status, actor)
 }
@@ -6,10 +6,12 @@ package mock_service
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
 	time "time"
 
 	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/service"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -51,6 +53,81 @@ func (mr *MockSaleServiceInterfaceMockRecorder) Create(ctx, sale interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSaleServiceInterface)(nil).Create), ctx, sale)
 }
 
+// ImportSales mocks base method.
+func (m *MockSaleServiceInterface) ImportSales(ctx context.Context, r io.Reader, opts service.ImportSalesOptions, emit func(models.BulkSaleResult) error) (*models.BulkSaleSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportSales", ctx, r, opts, emit)
+	ret0, _ := ret[0].(*models.BulkSaleSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportSales indicates an expected call of ImportSales.
+func (mr *MockSaleServiceInterfaceMockRecorder) ImportSales(ctx, r, opts, emit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportSales", reflect.TypeOf((*MockSaleServiceInterface)(nil).ImportSales), ctx, r, opts, emit)
+}
+
+// GetCategorySalesReport mocks base method.
+func (m *MockSaleServiceInterface) GetCategorySalesReport(ctx context.Context, start, end time.Time) (*models.CategorySalesReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategorySalesReport", ctx, start, end)
+	ret0, _ := ret[0].(*models.CategorySalesReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategorySalesReport indicates an expected call of GetCategorySalesReport.
+func (mr *MockSaleServiceInterfaceMockRecorder) GetCategorySalesReport(ctx, start, end interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategorySalesReport", reflect.TypeOf((*MockSaleServiceInterface)(nil).GetCategorySalesReport), ctx, start, end)
+}
+
+// GetSalesHeatmap mocks base method.
+func (m *MockSaleServiceInterface) GetSalesHeatmap(ctx context.Context, storeID string, startDate, endDate time.Time) (*models.SalesHeatmap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSalesHeatmap", ctx, storeID, startDate, endDate)
+	ret0, _ := ret[0].(*models.SalesHeatmap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSalesHeatmap indicates an expected call of GetSalesHeatmap.
+func (mr *MockSaleServiceInterfaceMockRecorder) GetSalesHeatmap(ctx, storeID, startDate, endDate interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSalesHeatmap", reflect.TypeOf((*MockSaleServiceInterface)(nil).GetSalesHeatmap), ctx, storeID, startDate, endDate)
+}
+
+// GetMarginReport mocks base method.
+func (m *MockSaleServiceInterface) GetMarginReport(ctx context.Context, query service.MarginQuery) (*models.MarginReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMarginReport", ctx, query)
+	ret0, _ := ret[0].(*models.MarginReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMarginReport indicates an expected call of GetMarginReport.
+func (mr *MockSaleServiceInterfaceMockRecorder) GetMarginReport(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMarginReport", reflect.TypeOf((*MockSaleServiceInterface)(nil).GetMarginReport), ctx, query)
+}
+
+// GetDayTypeSales mocks base method.
+func (m *MockSaleServiceInterface) GetDayTypeSales(ctx context.Context, storeID string, startDate, endDate time.Time) (*models.DayTypeSalesReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDayTypeSales", ctx, storeID, startDate, endDate)
+	ret0, _ := ret[0].(*models.DayTypeSalesReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDayTypeSales indicates an expected call of GetDayTypeSales.
+func (mr *MockSaleServiceInterfaceMockRecorder) GetDayTypeSales(ctx, storeID, startDate, endDate interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDayTypeSales", reflect.TypeOf((*MockSaleServiceInterface)(nil).GetDayTypeSales), ctx, storeID, startDate, endDate)
+}
+
 // GetDailySales mocks base method.
 func (m *MockSaleServiceInterface) GetDailySales(ctx context.Context, date time.Time) ([]*models.Sale, error) {
 	m.ctrl.T.Helper()
@@ -82,10 +159,10 @@ func (mr *MockSaleServiceInterfaceMockRecorder) GetEnvironmentalImpactAnalytics(
 }
 
 // GetSalesByCategory mocks base method.
-func (m *MockSaleServiceInterface) GetSalesByCategory(ctx context.Context, start, end time.Time) (map[string]int, error) {
+func (m *MockSaleServiceInterface) GetSalesByCategory(ctx context.Context, start, end time.Time) ([]*models.CategorySales, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetSalesByCategory", ctx, start, end)
-	ret0, _ := ret[0].(map[string]int)
+	ret0, _ := ret[0].([]*models.CategorySales)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -3,15 +3,20 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
 
 	"github.com/onoderaryou/smart-store-admin/backend/models"
 	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/tax"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ProductService struct {
 	repo repository.ProductRepository
+	// taxCalc は原価割れの判定で販売価格を税抜にするために使います（nilの場合は価格をそのまま比較）
+	taxCalc *tax.Calculator
 }
 
 type ProductServiceInterface interface {
@@ -19,13 +24,13 @@ type ProductServiceInterface interface {
 	CreateProduct(ctx context.Context, product *models.Product) error
 	UpdateStock(ctx context.Context, id primitive.ObjectID, quantity int) error
 	GetProductByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error)
-	List(ctx context.Context, skip, limit int64) ([]*models.Product, error)
+	List(ctx context.Context, query models.ProductQuery, skip, limit int64) ([]*models.Product, error)
 	Update(ctx context.Context, product *models.Product) error
 	Delete(ctx context.Context, id primitive.ObjectID) error
 }
 
-func NewProductService(repo repository.ProductRepository) *ProductService {
-	return &ProductService{repo: repo}
+func NewProductService(repo repository.ProductRepository, taxCalc *tax.Calculator) *ProductService {
+	return &ProductService{repo: repo, taxCalc: taxCalc}
 }
 
 func (ps *ProductService) GetProductsByCategory(ctx context.Context, category string) ([]*models.Product, error) {
@@ -46,7 +51,17 @@ func (ps *ProductService) CreateProduct(ctx context.Context, product *models.Pro
 	if product.Price < 0 {
 		return errors.New("price must be non-negative")
 	}
-	return ps.repo.Create(ctx, product)
+	if product.CostPrice < 0 {
+		return errors.New("cost price must be non-negative")
+	}
+	if err := normalizeTaxClass(product); err != nil {
+		return err
+	}
+	if err := ps.repo.Create(ctx, product); err != nil {
+		return err
+	}
+	product.Warnings = ps.priceWarnings(product)
+	return nil
 }
 
 func (ps *ProductService) UpdateStock(ctx context.Context, id primitive.ObjectID, quantity int) error {
@@ -68,14 +83,17 @@ func (ps *ProductService) GetProductByID(ctx context.Context, id primitive.Objec
 	return ps.repo.GetByID(ctx, id)
 }
 
-func (ps *ProductService) List(ctx context.Context, skip, limit int64) ([]*models.Product, error) {
+func (ps *ProductService) List(ctx context.Context, query models.ProductQuery, skip, limit int64) ([]*models.Product, error) {
 	if skip < 0 {
 		return nil, errors.New("skip must be non-negative")
 	}
 	if limit <= 0 {
 		return nil, errors.New("limit must be positive")
 	}
-	return ps.repo.List(ctx, skip, limit)
+	if query.ABCClass != "" && !models.ValidateABCClass(query.ABCClass) {
+		return nil, errors.New("invalid ABC class")
+	}
+	return ps.repo.List(ctx, query, skip, limit)
 }
 
 func (ps *ProductService) Update(ctx context.Context, product *models.Product) error {
@@ -88,7 +106,39 @@ func (ps *ProductService) Update(ctx context.Context, product *models.Product) e
 	if product.Price < 0 {
 		return errors.New("price must be non-negative")
 	}
-	return ps.repo.Update(ctx, product)
+	if product.CostPrice < 0 {
+		return errors.New("cost price must be non-negative")
+	}
+	if product.TaxClass != "" && !models.ValidateTaxClass(product.TaxClass) {
+		return errors.New("invalid tax class")
+	}
+	// 原価・税区分が指定されていない場合（価格のみの更新など）は登録済みの値を維持します
+	if product.CostPrice == 0 || product.TaxClass == "" {
+		stored, err := ps.repo.GetByID(ctx, product.ID)
+		if err != nil {
+			return err
+		}
+		if product.CostPrice == 0 {
+			product.CostPrice = stored.CostPrice
+		}
+		if product.TaxClass == "" {
+			product.TaxClass = stored.TaxClass
+		}
+		// 税区分を登録する前の商品は標準税率です
+		if err := normalizeTaxClass(product); err != nil {
+			return err
+		}
+	}
+	if err := ps.repo.Update(ctx, product); err != nil {
+		return err
+	}
+
+	// 原価割れの価格は誤入力の可能性があるため、更新したうえで警告を返します
+	product.Warnings = ps.priceWarnings(product)
+	if len(product.Warnings) > 0 {
+		log.Printf("Product %s is priced below cost: price=%.0f cost=%.0f", product.ID.Hex(), product.Price, product.CostPrice)
+	}
+	return nil
 }
 
 func (ps *ProductService) Delete(ctx context.Context, id primitive.ObjectID) error {
@@ -97,3 +147,30 @@ func (ps *ProductService) Delete(ctx context.Context, id primitive.ObjectID) err
 	}
 	return ps.repo.Delete(ctx, id)
 }
+
+// priceWarnings は販売価格（税抜）が仕入原価を下回っている場合の警告を返します
+func (ps *ProductService) priceWarnings(product *models.Product) []string {
+	if product.CostPrice <= 0 {
+		return nil
+	}
+	price := product.Price
+	if ps.taxCalc != nil {
+		price = ps.taxCalc.Calculate([]tax.Line{{Class: product.TaxClass, Amount: product.Price}}).Subtotal
+	}
+	if price < product.CostPrice {
+		return []string{fmt.Sprintf("販売価格（税抜%.0f円）が仕入原価（%.0f円）を下回っています", price, product.CostPrice)}
+	}
+	return nil
+}
+
+// normalizeTaxClass は税区分を検証し、未指定の場合は標準税率を設定します
+func normalizeTaxClass(product *models.Product) error {
+	if product.TaxClass == "" {
+		product.TaxClass = models.TaxClassStandard
+		return nil
+	}
+	if !models.ValidateTaxClass(product.TaxClass) {
+		return errors.New("invalid tax class")
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/onoderaryou/smart-store-admin/backend/models"
 	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/tax"
 )
 
 type MockProductRepository struct {
@@ -31,8 +32,8 @@ func (m *MockProductRepository) GetByID(ctx context.Context, id primitive.Object
 	return args.Get(0).(*models.Product), args.Error(1)
 }
 
-func (m *MockProductRepository) List(ctx context.Context, skip, limit int64) ([]*models.Product, error) {
-	args := m.Called(ctx, skip, limit)
+func (m *MockProductRepository) List(ctx context.Context, query models.ProductQuery, skip, limit int64) ([]*models.Product, error) {
+	args := m.Called(ctx, query, skip, limit)
 	return args.Get(0).([]*models.Product), args.Error(1)
 }
 
@@ -41,6 +42,11 @@ func (m *MockProductRepository) Update(ctx context.Context, product *models.Prod
 	return args.Error(0)
 }
 
+func (m *MockProductRepository) UpdateABC(ctx context.Context, results map[primitive.ObjectID]models.ProductABC) error {
+	args := m.Called(ctx, results)
+	return args.Error(0)
+}
+
 func (m *MockProductRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -101,6 +107,16 @@ func TestCreateProduct(t *testing.T) {
 			mockFn:  func() {},
 			wantErr: true,
 		},
+		{
+			name: "無効な税区分でエラー",
+			product: &models.Product{
+				Name:     "テスト商品",
+				Price:    1000,
+				TaxClass: "luxury",
+			},
+			mockFn:  func() {},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -179,6 +195,76 @@ func TestUpdateStock(t *testing.T) {
 	}
 }
 
+func TestUpdatePriceBelowCost(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := NewProductService(mockRepo, tax.NewCalculator(tax.DefaultConfig()))
+	ctx := context.Background()
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*models.Product")).Return(nil)
+
+	tests := []struct {
+		name        string
+		price       float64
+		cost        float64
+		wantWarning bool
+	}{
+		// 税込1100円 = 税抜1000円
+		{name: "原価を上回る価格", price: 1100, cost: 900, wantWarning: false},
+		{name: "税込では原価を上回るが税抜では原価割れ", price: 1050, cost: 1000, wantWarning: true},
+		{name: "原価未登録", price: 100, cost: 0, wantWarning: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := &models.Product{
+				ID:        primitive.NewObjectID(),
+				Name:      "テスト商品",
+				Price:     tt.price,
+				CostPrice: tt.cost,
+			}
+			mockRepo.On("GetByID", ctx, product.ID).Return(&models.Product{ID: product.ID}, nil)
+			err := service.Update(ctx, product)
+			assert.NoError(t, err)
+			if tt.wantWarning {
+				assert.Len(t, product.Warnings, 1)
+			} else {
+				assert.Empty(t, product.Warnings)
+			}
+		})
+	}
+
+	t.Run("価格のみの更新では登録済みの原価を維持", func(t *testing.T) {
+		id := primitive.NewObjectID()
+		mockRepo.On("GetByID", ctx, id).Return(&models.Product{ID: id, Price: 1100, CostPrice: 900}, nil)
+
+		product := &models.Product{ID: id, Name: "テスト商品", Price: 880}
+		err := service.Update(ctx, product)
+		assert.NoError(t, err)
+		assert.Equal(t, 900.0, product.CostPrice)
+		assert.Len(t, product.Warnings, 1)
+	})
+
+	t.Run("税区分を省略した更新では登録済みの税区分を維持", func(t *testing.T) {
+		id := primitive.NewObjectID()
+		mockRepo.On("GetByID", ctx, id).Return(&models.Product{ID: id, Price: 216, CostPrice: 150, TaxClass: models.TaxClassReduced}, nil)
+
+		product := &models.Product{ID: id, Name: "テスト食品", Price: 216}
+		err := service.Update(ctx, product)
+		assert.NoError(t, err)
+		assert.Equal(t, models.TaxClassReduced, product.TaxClass)
+		assert.Equal(t, 150.0, product.CostPrice)
+	})
+
+	t.Run("不正な税区分でエラー", func(t *testing.T) {
+		err := service.Update(ctx, &models.Product{ID: primitive.NewObjectID(), Name: "テスト商品", Price: 100, TaxClass: "luxury"})
+		assert.Error(t, err)
+	})
+
+	t.Run("負の原価でエラー", func(t *testing.T) {
+		err := service.Update(ctx, &models.Product{ID: primitive.NewObjectID(), Name: "テスト商品", Price: 100, CostPrice: -1})
+		assert.Error(t, err)
+	})
+}
+
 func TestGetProductsByCategory(t *testing.T) {
 	mockRepo := new(MockProductRepository)
 	service := ProductService{repo: mockRepo}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/onoderaryou/smart-store-admin/backend/geo"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/storage"
+)
+
+var (
+	// ErrProofOfDeliveryUnavailable は証跡の保存先がない構成で配送の証跡を提出した場合のエラーです
+	ErrProofOfDeliveryUnavailable = errors.New("proof of delivery is not available")
+	// ErrProofOfDeliveryNotFound は配送の証跡（写真）が存在しない場合のエラーです
+	ErrProofOfDeliveryNotFound = errors.New("proof of delivery not found")
+	// ErrInvalidProofOfDelivery は配送の証跡の位置・日時・写真が不正な場合のエラーです
+	ErrInvalidProofOfDelivery = errors.New("invalid proof of delivery")
+	// ErrProofPhotoTooLarge は配送の証跡の写真が最大サイズを超えている場合のエラーです
+	ErrProofPhotoTooLarge = errors.New("proof of delivery photo is too large")
+	// ErrRecipientPINMismatch は受取人の暗証番号が一致しない場合のエラーです
+	ErrRecipientPINMismatch = errors.New("recipient PIN does not match")
+	// ErrRecipientPINLocked は受取人の暗証番号の照合回数が上限に達していて照合できない場合のエラーです
+	ErrRecipientPINLocked = errors.New("recipient PIN is locked after too many attempts")
+	// ErrDropOffOutOfRange は受け渡し地点が配送先から離れすぎている場合のエラーです
+	ErrDropOffOutOfRange = errors.New("drop-off point is too far from the destination")
+	// ErrInvalidRecipientPIN は受取人の暗証番号の形式が不正な場合のエラーです
+	ErrInvalidRecipientPIN = errors.New("recipient PIN must be 4 to 8 digits")
+)
+
+// proofClockSkew は受け渡し日時として認める、機体と店舗システムの時刻のずれです
+const proofClockSkew = 5 * time.Minute
+
+// recipientPINLength は発行する受取人の暗証番号の桁数です
+const recipientPINLength = 6
+
+// proofPhotoExtensions は受け付ける写真のコンテンツタイプと保存時の拡張子です
+var proofPhotoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// ProofOfDeliveryRequest は配送完了時にロボット・ドローンが提出する配送の証跡です
+type ProofOfDeliveryRequest struct {
+	// Photo は受け渡し写真（JPEG・PNG・WebP）です
+	Photo io.Reader
+	// PIN は受取人から受け取った暗証番号です
+	PIN string
+	// Location は受け渡し地点のGPSの位置、DeliveredAt は受け渡し日時です
+	Location    models.Location
+	DeliveredAt time.Time
+}
+
+// SubmitProofOfDelivery は配送の証跡を保存して配送を完了にします
+// 配送中でない場合、配送先の位置が登録されていない場合、受け渡し地点が配送先から ProofRadius より離れている場合、暗証番号が一致しない場合は完了にしません
+// 暗証番号は照合のたびに数え、MaxPINAttempts 回に達するとロックします（ErrRecipientPINLocked）
+func (s *DeliveryService) SubmitProofOfDelivery(ctx context.Context, id primitive.ObjectID, req ProofOfDeliveryRequest, actor string) (*models.Delivery, error) {
+	if s.blobs == nil {
+		return nil, ErrProofOfDeliveryUnavailable
+	}
+	now := s.now()
+	if req.Photo == nil {
+		return nil, fmt.Errorf("%w: photo is required", ErrInvalidProofOfDelivery)
+	}
+	if req.Location == (models.Location{}) {
+		return nil, fmt.Errorf("%w: drop-off location is required", ErrInvalidProofOfDelivery)
+	}
+	if req.DeliveredAt.IsZero() || req.DeliveredAt.After(now.Add(proofClockSkew)) {
+		return nil, fmt.Errorf("%w: delivered time must not be in the future", ErrInvalidProofOfDelivery)
+	}
+
+	delivery, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, errors.New("delivery not found")
+	}
+	proof := &models.ProofOfDelivery{
+		Location:    req.Location,
+		DeliveredAt: req.DeliveredAt,
+		SubmittedBy: actor,
+		SubmittedAt: now,
+	}
+	update := models.DeliveryStatusUpdate{Proof: proof}
+	transition, err := s.states.Check(ctx, delivery, models.StatusCompleted, update)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Destination == nil {
+		return nil, ErrDeliveryDestinationMissing
+	}
+	distance := geo.Distance(*delivery.Destination, req.Location) * 1000
+	if distance > s.config.ProofRadius {
+		return nil, fmt.Errorf("%w (%.0f m)", ErrDropOffOutOfRange, distance)
+	}
+	distance = round2(distance)
+	proof.DistanceFromDestination = &distance
+	if delivery.RecipientPINHash != "" {
+		// 総当たりを防ぐため、照合の前に照合回数を数えます
+		if err := s.repo.RecordPINAttempt(ctx, id, s.config.MaxPINAttempts); err != nil {
+			if errors.Is(err, repository.ErrPINAttemptsExceeded) {
+				return nil, ErrRecipientPINLocked
+			}
+			return nil, err
+		}
+		if bcrypt.CompareHashAndPassword([]byte(delivery.RecipientPINHash), []byte(req.PIN)) != nil {
+			return nil, ErrRecipientPINMismatch
+		}
+		proof.PINVerified = true
+	}
+
+	if err := s.storeProofPhoto(ctx, delivery.ID, proof, req.Photo); err != nil {
+		return nil, err
+	}
+	if err := s.applyTransition(ctx, id, delivery, transition, update, actor); err != nil {
+		// 完了にできなかった配送の写真は残しません
+		if deleteErr := s.blobs.Delete(ctx, proof.PhotoKey); deleteErr != nil {
+			log.Printf("Failed to delete proof of delivery photo %s: %v", proof.PhotoKey, deleteErr)
+		}
+		return nil, err
+	}
+	delivery.ActualDeliveryTime = &proof.DeliveredAt
+	delivery.ProofOfDelivery = proof
+	return delivery, nil
+}
+
+// storeProofPhoto は写真の種類とサイズを確認してブロブストレージに保存し、キーを proof に設定します
+func (s *DeliveryService) storeProofPhoto(ctx context.Context, deliveryID string, proof *models.ProofOfDelivery, photo io.Reader) error {
+	r := bufio.NewReader(photo)
+	head, err := r.Peek(512)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	contentType := http.DetectContentType(head)
+	ext, ok := proofPhotoExtensions[contentType]
+	if !ok {
+		return fmt.Errorf("%w: photo must be JPEG, PNG or WebP", ErrInvalidProofOfDelivery)
+	}
+
+	key := fmt.Sprintf("proof-of-delivery/%s/%d%s", deliveryID, proof.SubmittedAt.UnixNano(), ext)
+	size, err := s.blobs.Put(ctx, key, io.LimitReader(r, s.config.MaxProofPhotoSize+1))
+	if err == nil && size > s.config.MaxProofPhotoSize {
+		err = ErrProofPhotoTooLarge
+	}
+	if err != nil {
+		if deleteErr := s.blobs.Delete(ctx, key); deleteErr != nil {
+			log.Printf("Failed to delete proof of delivery photo %s: %v", key, deleteErr)
+		}
+		return err
+	}
+	proof.PhotoKey = key
+	proof.PhotoContentType = contentType
+	proof.PhotoSize = size
+	return nil
+}
+
+// OpenProofPhoto は配送の証跡の写真を読み出します（呼び出し元で閉じてください）
+func (s *DeliveryService) OpenProofPhoto(ctx context.Context, id primitive.ObjectID) (io.ReadCloser, *models.ProofOfDelivery, error) {
+	if s.blobs == nil {
+		return nil, nil, ErrProofOfDeliveryUnavailable
+	}
+	delivery, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if delivery == nil || delivery.ProofOfDelivery == nil {
+		return nil, nil, ErrProofOfDeliveryNotFound
+	}
+	photo, err := s.blobs.Open(ctx, delivery.ProofOfDelivery.PhotoKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrProofOfDeliveryNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return photo, delivery.ProofOfDelivery, nil
+}
+
+// ValidRecipientPIN は受取人の暗証番号が4〜8桁の数字かどうかを確認します
+func ValidRecipientPIN(pin string) bool {
+	if len(pin) < 4 || len(pin) > 8 {
+		return false
+	}
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// hashRecipientPIN は保存する受取人の暗証番号のハッシュ（bcrypt）を返します
+func hashRecipientPIN(pin string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// newRecipientPIN は受取人の暗証番号を乱数で発行します
+func newRecipientPIN() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < recipientPINLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", recipientPINLength, n), nil
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/storage"
+)
+
+func TestSubmitProofOfDelivery(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	mockRepo := new(MockDeliveryRepository)
+	s := NewDeliveryService(mockRepo, nil, nil, storage.NewLocalStore(dir), DefaultDeliveryConfig())
+	s.now = func() time.Time { return now }
+
+	deliveryID := primitive.NewObjectID()
+	destination := models.Location{Latitude: 35.0, Longitude: 139.0}
+	pinHash, err := hashRecipientPIN("1234")
+	require.NoError(t, err)
+	// north は配送先から北へ m だけ離れた地点です
+	north := func(m float64) models.Location {
+		return models.Location{Latitude: 35.0 + m/111195, Longitude: 139.0}
+	}
+	current := func(status models.DeliveryStatus) {
+		mockRepo.On("GetByID", ctx, deliveryID).Return(&models.Delivery{
+			ID:               deliveryID.Hex(),
+			Status:           status,
+			Destination:      &destination,
+			RecipientPINHash: pinHash,
+		}, nil).Once()
+	}
+	// attempt は暗証番号の照合回数を数えます（err は照合回数が上限に達している場合のエラーです）
+	attempt := func(err error) {
+		mockRepo.On("RecordPINAttempt", ctx, deliveryID, 5).Return(err).Once()
+	}
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	request := func(pin string, location models.Location, photo []byte) ProofOfDeliveryRequest {
+		return ProofOfDeliveryRequest{
+			Photo:       bytes.NewReader(photo),
+			PIN:         pin,
+			Location:    location,
+			DeliveredAt: now.Add(-time.Minute),
+		}
+	}
+	blobs := func() []string {
+		files, _ := filepath.Glob(filepath.Join(dir, "proof-of-delivery", deliveryID.Hex(), "*"))
+		return files
+	}
+
+	t.Run("暗証番号が一致しない場合は完了にしない", func(t *testing.T) {
+		current(models.StatusInProgress)
+		attempt(nil)
+		_, err := s.SubmitProofOfDelivery(ctx, deliveryID, request("9999", north(10), png), "robot-1")
+		assert.ErrorIs(t, err, ErrRecipientPINMismatch)
+	})
+
+	t.Run("照合回数が上限に達した暗証番号は照合しない", func(t *testing.T) {
+		current(models.StatusInProgress)
+		attempt(repository.ErrPINAttemptsExceeded)
+		_, err := s.SubmitProofOfDelivery(ctx, deliveryID, request("1234", north(10), png), "robot-1")
+		assert.ErrorIs(t, err, ErrRecipientPINLocked)
+		assert.Empty(t, blobs())
+	})
+
+	t.Run("受け渡し地点が配送先から離れすぎている場合は完了にしない", func(t *testing.T) {
+		current(models.StatusInProgress)
+		_, err := s.SubmitProofOfDelivery(ctx, deliveryID, request("1234", north(200), png), "robot-1")
+		assert.ErrorIs(t, err, ErrDropOffOutOfRange)
+	})
+
+	t.Run("配送先の位置がない配送は完了にしない", func(t *testing.T) {
+		mockRepo.On("GetByID", ctx, deliveryID).Return(&models.Delivery{
+			ID:               deliveryID.Hex(),
+			Status:           models.StatusInProgress,
+			RecipientPINHash: pinHash,
+		}, nil).Once()
+		_, err := s.SubmitProofOfDelivery(ctx, deliveryID, request("1234", north(10), png), "robot-1")
+		assert.ErrorIs(t, err, ErrDeliveryDestinationMissing)
+		assert.Empty(t, blobs())
+	})
+
+	t.Run("画像でない写真は保存しない", func(t *testing.T) {
+		current(models.StatusInProgress)
+		attempt(nil)
+		_, err := s.SubmitProofOfDelivery(ctx, deliveryID, request("1234", north(10), []byte("not an image")), "robot-1")
+		assert.ErrorIs(t, err, ErrInvalidProofOfDelivery)
+		assert.Empty(t, blobs())
+	})
+
+	t.Run("配送中でない配送は完了にできない", func(t *testing.T) {
+		current(models.StatusPreparing)
+		_, err := s.SubmitProofOfDelivery(ctx, deliveryID, request("1234", north(10), png), "robot-1")
+		assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+	})
+
+	t.Run("完了にできなかった場合は写真を残さない", func(t *testing.T) {
+		current(models.StatusInProgress)
+		attempt(nil)
+		mockRepo.On("UpdateStatus", ctx, deliveryID, mock.Anything, "robot-1").Return(assert.AnError).Once()
+		_, err := s.SubmitProofOfDelivery(ctx, deliveryID, request("1234", north(10), png), "robot-1")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Empty(t, blobs())
+	})
+
+	t.Run("証跡を保存して配送を完了にする", func(t *testing.T) {
+		current(models.StatusInProgress)
+		attempt(nil)
+		mockRepo.On("UpdateStatus", ctx, deliveryID, mock.MatchedBy(func(update models.DeliveryStatusUpdate) bool {
+			return update.From == models.StatusInProgress && update.To == models.StatusCompleted && update.Proof != nil
+		}), "robot-1").Return(nil).Once()
+
+		delivery, err := s.SubmitProofOfDelivery(ctx, deliveryID, request("1234", north(10), png), "robot-1")
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusCompleted, delivery.Status)
+		assert.Equal(t, now.Add(-time.Minute), *delivery.ActualDeliveryTime)
+		proof := delivery.ProofOfDelivery
+		assert.True(t, proof.PINVerified)
+		assert.InDelta(t, 10.0, *proof.DistanceFromDestination, 0.1)
+		assert.Equal(t, "image/png", proof.PhotoContentType)
+		assert.Equal(t, int64(len(png)), proof.PhotoSize)
+		assert.Equal(t, "robot-1", proof.SubmittedBy)
+		require.Len(t, blobs(), 1)
+
+		mockRepo.On("GetByID", ctx, deliveryID).Return(delivery, nil).Once()
+		photo, stored, err := s.OpenProofPhoto(ctx, deliveryID)
+		require.NoError(t, err)
+		defer photo.Close()
+		data, err := io.ReadAll(photo)
+		require.NoError(t, err)
+		assert.Equal(t, png, data)
+		assert.Equal(t, proof, stored)
+	})
+
+	t.Run("最大サイズを超える写真は保存しない", func(t *testing.T) {
+		for _, file := range blobs() {
+			require.NoError(t, os.Remove(file))
+		}
+		small := NewDeliveryService(mockRepo, nil, nil, storage.NewLocalStore(dir), DeliveryConfig{ProofRadius: 50, MaxProofPhotoSize: 8, MaxPINAttempts: 5})
+		small.now = s.now
+		current(models.StatusInProgress)
+		attempt(nil)
+		_, err := small.SubmitProofOfDelivery(ctx, deliveryID, request("1234", north(10), png), "robot-1")
+		assert.ErrorIs(t, err, ErrProofPhotoTooLarge)
+		assert.Empty(t, blobs())
+	})
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateDeliveryStoresRecipientPINHash(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockDeliveryRepository)
+	s := NewDeliveryService(mockRepo, nil, nil, nil, DefaultDeliveryConfig())
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*models.Delivery"), "staff-1").Return(nil).Once()
+
+	delivery := &models.Delivery{
+		DeliveryType:          "ロボット",
+		Address:               "東京都渋谷区",
+		EstimatedDeliveryTime: time.Now().Add(2 * time.Hour),
+		PINAttempts:           3,
+	}
+	require.NoError(t, s.CreateDelivery(ctx, delivery, "staff-1"))
+
+	// 発行した暗証番号は応答用に保持し、保存するのはハッシュだけです
+	assert.Len(t, delivery.RecipientPIN, recipientPINLength)
+	assert.True(t, ValidRecipientPIN(delivery.RecipientPIN))
+	assert.NotContains(t, delivery.RecipientPINHash, delivery.RecipientPIN)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(delivery.RecipientPINHash), []byte(delivery.RecipientPIN)))
+	assert.Zero(t, delivery.PINAttempts)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/receipt"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+// ErrSaleNotFound は指定された売上が存在しない場合のエラーです
+var ErrSaleNotFound = errors.New("sale not found")
+
+// ReceiptServiceInterface はレシートサービスのインターフェースを定義します
+type ReceiptServiceInterface interface {
+	RenderReceipt(ctx context.Context, saleID primitive.ObjectID, format receipt.Format, w io.Writer) error
+}
+
+// ReceiptService は売上のレシートを生成するサービスです
+type ReceiptService struct {
+	saleRepo      repository.SaleRepository
+	productRepo   repository.ProductRepository
+	storeSettings StoreSettingsServiceInterface
+}
+
+// NewReceiptService は新しいレシートサービスを作成します
+func NewReceiptService(saleRepo repository.SaleRepository, productRepo repository.ProductRepository, storeSettings StoreSettingsServiceInterface) *ReceiptService {
+	return &ReceiptService{
+		saleRepo:      saleRepo,
+		productRepo:   productRepo,
+		storeSettings: storeSettings,
+	}
+}
+
+// RenderReceipt は売上のレシートを指定された形式で書き出します
+func (s *ReceiptService) RenderReceipt(ctx context.Context, saleID primitive.ObjectID, format receipt.Format, w io.Writer) error {
+	r, err := s.BuildReceipt(ctx, saleID)
+	if err != nil {
+		return err
+	}
+	return receipt.Render(w, r, format)
+}
+
+// BuildReceipt は売上と店舗設定からレシートの表示内容を組み立てます
+func (s *ReceiptService) BuildReceipt(ctx context.Context, saleID primitive.ObjectID) (*receipt.Receipt, error) {
+	sale, err := s.saleRepo.GetByID(ctx, saleID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrSaleNotFound
+		}
+		return nil, err
+	}
+
+	settings, err := s.storeSettings.GetSettings(ctx, sale.StoreID)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &receipt.Receipt{
+		StoreName:      settings.Name,
+		StoreAddress:   settings.Address,
+		StorePhone:     settings.Phone,
+		HeaderLines:    settings.ReceiptLayout.HeaderLines,
+		FooterMessages: settings.ReceiptLayout.FooterMessages,
+		Width:          settings.ReceiptLayout.Width,
+		SaleID:         sale.ID.Hex(),
+		IssuedAt:       sale.CreatedAt,
+		Issuer:         sale.InvoiceIssuer,
+		TaxBreakdown:   sale.TaxBreakdown,
+		Total:          sale.TotalAmount,
+		TotalTax:       sale.TotalTax,
+		PaymentMethod:  sale.PaymentMethod,
+		CO2Saved:       sale.TotalCO2Saved,
+		ShowCO2Saved:   !settings.ReceiptLayout.HideCO2Saved,
+		PointsRedeemed: sale.PointsRedeemed,
+		PointsPayment:  sale.PointsPayment,
+		PointsEarned:   sale.PointsEarned,
+	}
+
+	for _, item := range sale.Items {
+		r.Items = append(r.Items, receipt.Item{
+			Name:      s.itemName(ctx, item),
+			Quantity:  item.Quantity,
+			UnitPrice: item.PriceAtSale,
+			Amount:    item.PriceAtSale * float64(item.Quantity),
+			Reduced:   item.TaxClass == models.TaxClassReduced,
+		})
+	}
+	for _, promo := range sale.Promotions {
+		r.Promotions = append(r.Promotions, receipt.Promotion{
+			Name:     promo.Name,
+			Discount: promo.Discount,
+		})
+	}
+
+	return r, nil
+}
+
+// itemName は明細の商品名を返します
+// 商品名が保存されていない過去の売上は商品マスタから補完します
+func (s *ReceiptService) itemName(ctx context.Context, item models.SaleItem) string {
+	if item.Name != "" {
+		return item.Name
+	}
+	p, err := s.productRepo.GetByID(ctx, item.ProductID)
+	if err != nil || p == nil {
+		return "商品"
+	}
+	return p.Name
+}
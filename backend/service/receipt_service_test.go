@@ -0,0 +1,174 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/receipt"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+// MockStoreSettingsRepository はrepository.StoreSettingsRepositoryインターフェースのモック実装です
+type MockStoreSettingsRepository struct {
+	mock.Mock
+}
+
+var _ repository.StoreSettingsRepository = (*MockStoreSettingsRepository)(nil)
+
+func (m *MockStoreSettingsRepository) GetByStoreID(ctx context.Context, storeID string) (*models.StoreSettings, error) {
+	args := m.Called(ctx, storeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.StoreSettings), args.Error(1)
+}
+
+func (m *MockStoreSettingsRepository) Upsert(ctx context.Context, settings *models.StoreSettings) error {
+	args := m.Called(ctx, settings)
+	return args.Error(0)
+}
+
+func TestGetStoreSettings(t *testing.T) {
+	mockRepo := new(MockStoreSettingsRepository)
+	service := NewStoreSettingsService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("GetByStoreID", ctx, "store-1").Return(&models.StoreSettings{
+		StoreID: "store-1",
+		Name:    "NEXT MART 渋谷店",
+	}, nil)
+	mockRepo.On("GetByStoreID", ctx, "unknown").Return(nil, nil)
+
+	settings, err := service.GetSettings(ctx, "store-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "NEXT MART 渋谷店", settings.Name)
+	assert.Equal(t, models.DefaultReceiptWidth, settings.ReceiptLayout.Width)
+
+	settings, err = service.GetSettings(ctx, "unknown")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultStoreName, settings.Name)
+}
+
+func TestUpdateStoreSettings(t *testing.T) {
+	mockRepo := new(MockStoreSettingsRepository)
+	service := NewStoreSettingsService(mockRepo)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		settings *models.StoreSettings
+		mockFn   func()
+		wantErr  bool
+	}{
+		{
+			name: "正常な設定更新",
+			settings: &models.StoreSettings{
+				StoreID: "store-1",
+				Name:    "NEXT MART 渋谷店",
+				ReceiptLayout: models.ReceiptLayout{
+					Width:          32,
+					FooterMessages: []string{"ありがとうございました"},
+				},
+			},
+			mockFn: func() {
+				mockRepo.On("Upsert", ctx, mock.AnythingOfType("*models.StoreSettings")).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "店舗名なしでエラー",
+			settings: &models.StoreSettings{
+				StoreID: "store-1",
+			},
+			mockFn:  func() {},
+			wantErr: true,
+		},
+		{
+			name: "桁数が範囲外でエラー",
+			settings: &models.StoreSettings{
+				StoreID:       "store-1",
+				Name:          "NEXT MART 渋谷店",
+				ReceiptLayout: models.ReceiptLayout{Width: 120},
+			},
+			mockFn:  func() {},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo.ExpectedCalls = nil
+			tt.mockFn()
+			err := service.UpdateSettings(ctx, tt.settings)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRenderReceipt(t *testing.T) {
+	mockSaleRepo := new(MockSaleRepository)
+	mockProductRepo := new(MockProductRepository)
+	mockSettingsRepo := new(MockStoreSettingsRepository)
+	service := NewReceiptService(mockSaleRepo, mockProductRepo, NewStoreSettingsService(mockSettingsRepo))
+	ctx := context.Background()
+
+	saleID := primitive.NewObjectID()
+	legacyProductID := primitive.NewObjectID()
+	sale := &models.Sale{
+		ID:      saleID,
+		StoreID: "store-1",
+		Items: []models.SaleItem{
+			{Name: "国産りんご", Quantity: 3, PriceAtSale: 108, TaxClass: models.TaxClassReduced},
+			{ProductID: legacyProductID, Quantity: 1, PriceAtSale: 550},
+		},
+		Promotions: []models.AppliedPromotion{
+			{Name: "まとめ買い", Discount: 24, TaxClass: models.TaxClassReduced},
+		},
+		TotalAmount:   850,
+		PaymentMethod: "qr_code",
+		TotalCO2Saved: 0.5,
+		CreatedAt:     time.Date(2024, 1, 5, 9, 30, 0, 0, time.UTC),
+	}
+
+	mockSaleRepo.On("GetByID", ctx, saleID).Return(sale, nil)
+	mockProductRepo.On("GetByID", ctx, legacyProductID).Return(&models.Product{Name: "詰め替え洗剤"}, nil)
+	mockSettingsRepo.On("GetByStoreID", ctx, "store-1").Return(&models.StoreSettings{
+		StoreID: "store-1",
+		Name:    "NEXT MART 渋谷店",
+		ReceiptLayout: models.ReceiptLayout{
+			Width:          32,
+			FooterMessages: []string{"ありがとうございました"},
+			HideCO2Saved:   true,
+		},
+	}, nil)
+
+	var buf bytes.Buffer
+	err := service.RenderReceipt(ctx, saleID, receipt.FormatText, &buf)
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "NEXT MART 渋谷店")
+	assert.Contains(t, out, "国産りんご ※")
+	assert.Contains(t, out, "詰め替え洗剤")
+	assert.Contains(t, out, "[値引] まとめ買い")
+	assert.Contains(t, out, "お支払 QRコード決済")
+	assert.Contains(t, out, "ありがとうございました")
+	assert.NotContains(t, out, "CO2削減量")
+
+	missingID := primitive.NewObjectID()
+	mockSaleRepo.On("GetByID", ctx, missingID).Return(nil, mongo.ErrNoDocuments)
+	err = service.RenderReceipt(ctx, missingID, receipt.FormatText, &buf)
+	assert.ErrorIs(t, err, ErrSaleNotFound)
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+// ErrRegisterSessionNotFound は指定されたセッションが存在しない場合のエラーです
+var ErrRegisterSessionNotFound = errors.New("register session not found")
+
+// RegisterSessionServiceInterface はレジ精算サービスのインターフェースを定義します
+type RegisterSessionServiceInterface interface {
+	OpenSession(ctx context.Context, req *OpenRegisterSessionRequest) (*models.RegisterSession, error)
+	CloseSession(ctx context.Context, id primitive.ObjectID, req *CloseRegisterSessionRequest) (*models.RegisterSession, error)
+	GetSession(ctx context.Context, id primitive.ObjectID) (*models.RegisterSession, error)
+	ListSessions(ctx context.Context, storeID, registerID string, date time.Time) ([]*models.RegisterSession, error)
+	GetZReport(ctx context.Context, storeID, registerID string, date time.Time) (*models.ZReport, error)
+}
+
+// OpenRegisterSessionRequest はレジ開局のリクエストを表します
+type OpenRegisterSessionRequest struct {
+	StoreID      string  `json:"storeId"`
+	RegisterID   string  `json:"registerId"`
+	OpeningFloat float64 `json:"openingFloat"`
+	OpenedBy     string  `json:"openedBy"`
+}
+
+// CloseRegisterSessionRequest はレジ締めのリクエストを表します
+type CloseRegisterSessionRequest struct {
+	// CountedCash は金種ごとの実査枚数です（釣銭準備金を含むドロア内の全現金）
+	CountedCash []models.DenominationCount `json:"countedCash"`
+	// CountedNonCash は現金以外の支払方法ごとの実査額（決済端末の集計値等）です
+	CountedNonCash map[string]float64 `json:"countedNonCash"`
+	ClosedBy       string             `json:"closedBy"`
+}
+
+// RegisterSessionService はレジの開局・締めと日次精算を扱うサービスです
+type RegisterSessionService struct {
+	repo     repository.RegisterSessionRepository
+	saleRepo repository.SaleRepository
+	location *time.Location
+}
+
+// NewRegisterSessionService は新しいレジ精算サービスを作成します
+// location は営業日の区切りに使う店舗のタイムゾーンです
+func NewRegisterSessionService(repo repository.RegisterSessionRepository, saleRepo repository.SaleRepository, location *time.Location) *RegisterSessionService {
+	return &RegisterSessionService{
+		repo:     repo,
+		saleRepo: saleRepo,
+		location: location,
+	}
+}
+
+// OpenSession は釣銭準備金を設定してレジを開局します
+func (s *RegisterSessionService) OpenSession(ctx context.Context, req *OpenRegisterSessionRequest) (*models.RegisterSession, error) {
+	if req.StoreID == "" {
+		return nil, errors.New("store ID is required")
+	}
+	if req.RegisterID == "" {
+		return nil, errors.New("register ID is required")
+	}
+	if req.OpeningFloat < 0 {
+		return nil, errors.New("opening float must be non-negative")
+	}
+
+	existing, err := s.repo.GetOpenSession(ctx, req.StoreID, req.RegisterID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, repository.ErrRegisterSessionAlreadyOpen
+	}
+
+	session := &models.RegisterSession{
+		StoreID:      req.StoreID,
+		RegisterID:   req.RegisterID,
+		Status:       models.RegisterSessionOpen,
+		OpenedBy:     req.OpenedBy,
+		OpenedAt:     time.Now(),
+		OpeningFloat: req.OpeningFloat,
+	}
+	if err := s.repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// CloseSession は実査額を記録してレジを締めます
+// セッション中の売上から支払方法ごとの理論値を計算し、過不足を記録します
+func (s *RegisterSessionService) CloseSession(ctx context.Context, id primitive.ObjectID, req *CloseRegisterSessionRequest) (*models.RegisterSession, error) {
+	countedCash, err := countCash(req.CountedCash)
+	if err != nil {
+		return nil, err
+	}
+	for method, amount := range req.CountedNonCash {
+		if method == models.PaymentMethodCash {
+			return nil, errors.New("cash must be counted by denomination")
+		}
+		if amount < 0 {
+			return nil, errors.New("counted amount must be non-negative")
+		}
+	}
+
+	session, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrRegisterSessionNotFound
+	}
+	if session.Status != models.RegisterSessionOpen {
+		return nil, repository.ErrRegisterSessionNotOpen
+	}
+
+	closedAt := time.Now()
+	sales, err := s.saleRepo.FindSales(ctx, models.SaleQuery{
+		StoreID:    session.StoreID,
+		RegisterID: session.RegisterID,
+		Start:      session.OpenedAt,
+		End:        closedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	takings := summarizeTakings(sales)
+	session.SaleCount = len(sales)
+	session.ExpectedCash = session.OpeningFloat + expectedFor(takings, models.PaymentMethodCash)
+	session.CountedCash = req.CountedCash
+	session.CountedCashTotal = countedCash
+	session.CashOverShort = countedCash - session.ExpectedCash
+
+	// ドロア内の現金から釣銭準備金を除いた額を現金の実査額とする
+	counted := map[string]float64{models.PaymentMethodCash: countedCash - session.OpeningFloat}
+	for method, amount := range req.CountedNonCash {
+		counted[method] = amount
+	}
+	session.Takings = applyCounted(takings, counted)
+
+	session.ClosedBy = req.ClosedBy
+	session.ClosedAt = &closedAt
+	if err := s.repo.Close(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetSession は指定されたIDのセッションを取得します
+func (s *RegisterSessionService) GetSession(ctx context.Context, id primitive.ObjectID) (*models.RegisterSession, error) {
+	session, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrRegisterSessionNotFound
+	}
+	return session, nil
+}
+
+// ListSessions は営業日に開局したセッションを取得します
+func (s *RegisterSessionService) ListSessions(ctx context.Context, storeID, registerID string, date time.Time) ([]*models.RegisterSession, error) {
+	if storeID == "" {
+		return nil, errors.New("store ID is required")
+	}
+	start, end := s.businessDay(date)
+	return s.repo.ListByPeriod(ctx, storeID, registerID, start, end)
+}
+
+// GetZReport は営業日のZレポートを作成します
+// レジIDを指定しない場合は店舗全体のレポートにレジごとの内訳を含めます
+func (s *RegisterSessionService) GetZReport(ctx context.Context, storeID, registerID string, date time.Time) (*models.ZReport, error) {
+	if storeID == "" {
+		return nil, errors.New("store ID is required")
+	}
+
+	start, end := s.businessDay(date)
+	sales, err := s.saleRepo.FindSales(ctx, models.SaleQuery{
+		StoreID:    storeID,
+		RegisterID: registerID,
+		Start:      start,
+		End:        end,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.repo.ListByPeriod(ctx, storeID, registerID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	report := buildZReport(storeID, registerID, start, end, sales, sessions)
+	if registerID != "" {
+		return report, nil
+	}
+
+	// レジごとの内訳
+	salesByRegister := make(map[string][]*models.Sale)
+	sessionsByRegister := make(map[string][]*models.RegisterSession)
+	for _, sale := range sales {
+		salesByRegister[sale.RegisterID] = append(salesByRegister[sale.RegisterID], sale)
+	}
+	for _, session := range sessions {
+		sessionsByRegister[session.RegisterID] = append(sessionsByRegister[session.RegisterID], session)
+	}
+
+	registerIDs := make([]string, 0, len(salesByRegister))
+	for id := range salesByRegister {
+		registerIDs = append(registerIDs, id)
+	}
+	for id := range sessionsByRegister {
+		if _, ok := salesByRegister[id]; !ok {
+			registerIDs = append(registerIDs, id)
+		}
+	}
+	sort.Strings(registerIDs)
+
+	for _, id := range registerIDs {
+		report.Registers = append(report.Registers,
+			buildZReport(storeID, id, start, end, salesByRegister[id], sessionsByRegister[id]))
+	}
+	return report, nil
+}
+
+// businessDay は日付を含む店舗の営業日の開始・終了時刻を返します
+func (s *RegisterSessionService) businessDay(date time.Time) (time.Time, time.Time) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, s.location)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// buildZReport は売上とセッションからZレポートを組み立てます
+func buildZReport(storeID, registerID string, start, end time.Time, sales []*models.Sale, sessions []*models.RegisterSession) *models.ZReport {
+	report := &models.ZReport{
+		StoreID:      storeID,
+		RegisterID:   registerID,
+		BusinessDate: start.Format("2006-01-02"),
+		Start:        start,
+		End:          end,
+		SaleCount:    len(sales),
+		TaxBreakdown: []models.TaxBreakdown{},
+		SessionCount: len(sessions),
+	}
+
+	for _, sale := range sales {
+		for _, item := range sale.Items {
+			report.GrossSales += item.PriceAtSale * float64(item.Quantity)
+		}
+		for _, promo := range sale.Promotions {
+			report.Discounts += promo.Discount
+		}
+		report.NetSales += sale.TotalAmount
+		report.TotalTax += sale.TotalTax
+		report.CO2Saved += sale.TotalCO2Saved
+		report.TaxBreakdown = mergeTaxBreakdown(report.TaxBreakdown, sale.TaxBreakdown)
+	}
+
+	takings := summarizeTakings(sales)
+	counted := make(map[string]float64)
+	hasClosed := false
+	for _, session := range sessions {
+		report.OpeningFloat += session.OpeningFloat
+		if session.Status != models.RegisterSessionClosed {
+			report.OpenSessionCount++
+			continue
+		}
+		hasClosed = true
+		report.CountedCash += session.CountedCashTotal
+		report.CashOverShort += session.CashOverShort
+		for _, t := range session.Takings {
+			if t.Counted != nil {
+				counted[t.PaymentMethod] += *t.Counted
+			}
+		}
+	}
+	if hasClosed {
+		takings = applyCounted(takings, counted)
+	}
+	report.Takings = takings
+	report.ExpectedCash = report.OpeningFloat + expectedFor(takings, models.PaymentMethodCash)
+
+	return report
+}
+
+// countCash は金種ごとの枚数から現金の合計額を計算します
+func countCash(counts []models.DenominationCount) (float64, error) {
+	var total float64
+	for _, c := range counts {
+		if !models.ValidateDenomination(c.Denomination) {
+			return 0, errors.New("invalid denomination")
+		}
+		if c.Count < 0 {
+			return 0, errors.New("count must be non-negative")
+		}
+		total += float64(c.Denomination * c.Count)
+	}
+	return total, nil
+}
+
+// summarizeTakings は売上を支払方法ごとに集計します
+// ポイントと他の支払方法を併用した売上は、それぞれの支払方法に金額を振り分けます
+func summarizeTakings(sales []*models.Sale) []models.PaymentTakings {
+	byMethod := make(map[string]*models.PaymentTakings)
+	add := func(method string, amount float64) {
+		t, ok := byMethod[method]
+		if !ok {
+			t = &models.PaymentTakings{PaymentMethod: method}
+			byMethod[method] = t
+		}
+		t.SaleCount++
+		t.Expected += amount
+	}
+	for _, sale := range sales {
+		if sale.PointsPayment > 0 && sale.PaymentMethod != models.PaymentMethodPoints {
+			add(models.PaymentMethodPoints, sale.PointsPayment)
+			add(sale.PaymentMethod, sale.TotalAmount-sale.PointsPayment)
+			continue
+		}
+		add(sale.PaymentMethod, sale.TotalAmount)
+	}
+
+	takings := make([]models.PaymentTakings, 0, len(byMethod))
+	for _, t := range byMethod {
+		takings = append(takings, *t)
+	}
+	sortTakings(takings)
+	return takings
+}
+
+// applyCounted は実査額を設定し、理論値との過不足を計算します
+// 売上のない支払方法でも実査額があれば追加します
+func applyCounted(takings []models.PaymentTakings, counted map[string]float64) []models.PaymentTakings {
+	seen := make(map[string]bool, len(takings))
+	for i := range takings {
+		seen[takings[i].PaymentMethod] = true
+		if amount, ok := counted[takings[i].PaymentMethod]; ok {
+			setCounted(&takings[i], amount)
+		}
+	}
+	for method, amount := range counted {
+		if seen[method] {
+			continue
+		}
+		t := models.PaymentTakings{PaymentMethod: method}
+		setCounted(&t, amount)
+		takings = append(takings, t)
+	}
+	sortTakings(takings)
+	return takings
+}
+
+func setCounted(t *models.PaymentTakings, amount float64) {
+	diff := amount - t.Expected
+	t.Counted = &amount
+	t.Difference = &diff
+}
+
+// sortTakings は現金を先頭に、それ以外を支払方法名の順に並べます
+func sortTakings(takings []models.PaymentTakings) {
+	sort.Slice(takings, func(i, j int) bool {
+		a, b := takings[i].PaymentMethod, takings[j].PaymentMethod
+		if (a == models.PaymentMethodCash) != (b == models.PaymentMethodCash) {
+			return a == models.PaymentMethodCash
+		}
+		return a < b
+	})
+}
+
+// expectedFor は支払方法の理論値を返します
+func expectedFor(takings []models.PaymentTakings, method string) float64 {
+	for _, t := range takings {
+		if t.PaymentMethod == method {
+			return t.Expected
+		}
+	}
+	return 0
+}
+
+// mergeTaxBreakdown は税率ごとの内訳を合算します
+func mergeTaxBreakdown(dst, src []models.TaxBreakdown) []models.TaxBreakdown {
+	for _, b := range src {
+		merged := false
+		for i := range dst {
+			if dst[i].TaxClass == b.TaxClass && dst[i].Rate == b.Rate {
+				dst[i].Taxable += b.Taxable
+				dst[i].Tax += b.Tax
+				dst[i].Total += b.Total
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			dst = append(dst, b)
+		}
+	}
+	return dst
+}
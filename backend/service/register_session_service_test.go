@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+type MockRegisterSessionRepository struct {
+	mock.Mock
+}
+
+var _ repository.RegisterSessionRepository = (*MockRegisterSessionRepository)(nil)
+
+func (m *MockRegisterSessionRepository) Create(ctx context.Context, session *models.RegisterSession) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockRegisterSessionRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.RegisterSession, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RegisterSession), args.Error(1)
+}
+
+func (m *MockRegisterSessionRepository) GetOpenSession(ctx context.Context, storeID, registerID string) (*models.RegisterSession, error) {
+	args := m.Called(ctx, storeID, registerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RegisterSession), args.Error(1)
+}
+
+func (m *MockRegisterSessionRepository) Close(ctx context.Context, session *models.RegisterSession) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockRegisterSessionRepository) ListByPeriod(ctx context.Context, storeID, registerID string, start, end time.Time) ([]*models.RegisterSession, error) {
+	args := m.Called(ctx, storeID, registerID, start, end)
+	return args.Get(0).([]*models.RegisterSession), args.Error(1)
+}
+
+func TestOpenSession(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		req     *OpenRegisterSessionRequest
+		mockFn  func(*MockRegisterSessionRepository)
+		wantErr error
+	}{
+		{
+			name: "正常な開局",
+			req:  &OpenRegisterSessionRequest{StoreID: "store-1", RegisterID: "reg-1", OpeningFloat: 30000},
+			mockFn: func(m *MockRegisterSessionRepository) {
+				m.On("GetOpenSession", ctx, "store-1", "reg-1").Return(nil, nil)
+				m.On("Create", ctx, mock.AnythingOfType("*models.RegisterSession")).Return(nil)
+			},
+		},
+		{
+			name: "開局中のセッションがある場合はエラー",
+			req:  &OpenRegisterSessionRequest{StoreID: "store-1", RegisterID: "reg-1", OpeningFloat: 30000},
+			mockFn: func(m *MockRegisterSessionRepository) {
+				m.On("GetOpenSession", ctx, "store-1", "reg-1").Return(&models.RegisterSession{Status: models.RegisterSessionOpen}, nil)
+			},
+			wantErr: repository.ErrRegisterSessionAlreadyOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRegisterSessionRepository)
+			tt.mockFn(mockRepo)
+			s := NewRegisterSessionService(mockRepo, new(MockSaleRepository), time.UTC)
+
+			session, err := s.OpenSession(ctx, tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, models.RegisterSessionOpen, session.Status)
+			assert.Equal(t, tt.req.OpeningFloat, session.OpeningFloat)
+		})
+	}
+}
+
+func TestCloseSession(t *testing.T) {
+	ctx := context.Background()
+	id := primitive.NewObjectID()
+	openSession := func() *models.RegisterSession {
+		return &models.RegisterSession{
+			ID:           id,
+			StoreID:      "store-1",
+			RegisterID:   "reg-1",
+			Status:       models.RegisterSessionOpen,
+			OpenedAt:     time.Now().Add(-8 * time.Hour),
+			OpeningFloat: 30000,
+		}
+	}
+	sales := []*models.Sale{
+		{PaymentMethod: models.PaymentMethodCash, TotalAmount: 1500},
+		{PaymentMethod: models.PaymentMethodCash, TotalAmount: 2500},
+		{PaymentMethod: models.PaymentMethodCreditCard, TotalAmount: 5000},
+	}
+
+	t.Run("支払方法ごとの過不足を記録", func(t *testing.T) {
+		mockRepo := new(MockRegisterSessionRepository)
+		mockSaleRepo := new(MockSaleRepository)
+		mockRepo.On("GetByID", ctx, id).Return(openSession(), nil)
+		mockSaleRepo.On("FindSales", ctx, mock.MatchedBy(func(q models.SaleQuery) bool {
+			return q.StoreID == "store-1" && q.RegisterID == "reg-1"
+		})).Return(sales, nil)
+		mockRepo.On("Close", ctx, mock.AnythingOfType("*models.RegisterSession")).Return(nil)
+		s := NewRegisterSessionService(mockRepo, mockSaleRepo, time.UTC)
+
+		// ドロア内: 10000円×3 + 1000円×3 + 500円×1 = 33500円（理論値 34000円）
+		session, err := s.CloseSession(ctx, id, &CloseRegisterSessionRequest{
+			CountedCash: []models.DenominationCount{
+				{Denomination: 10000, Count: 3},
+				{Denomination: 1000, Count: 3},
+				{Denomination: 500, Count: 1},
+			},
+			CountedNonCash: map[string]float64{models.PaymentMethodCreditCard: 5000},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, session.SaleCount)
+		assert.Equal(t, 34000.0, session.ExpectedCash)
+		assert.Equal(t, 33500.0, session.CountedCashTotal)
+		assert.Equal(t, -500.0, session.CashOverShort)
+		assert.NotNil(t, session.ClosedAt)
+
+		assert.Len(t, session.Takings, 2)
+		cash := session.Takings[0]
+		assert.Equal(t, models.PaymentMethodCash, cash.PaymentMethod)
+		assert.Equal(t, 4000.0, cash.Expected)
+		assert.Equal(t, 3500.0, *cash.Counted)
+		assert.Equal(t, -500.0, *cash.Difference)
+		card := session.Takings[1]
+		assert.Equal(t, models.PaymentMethodCreditCard, card.PaymentMethod)
+		assert.Equal(t, 0.0, *card.Difference)
+	})
+
+	t.Run("締め済みのセッションはエラー", func(t *testing.T) {
+		mockRepo := new(MockRegisterSessionRepository)
+		closed := openSession()
+		closed.Status = models.RegisterSessionClosed
+		mockRepo.On("GetByID", ctx, id).Return(closed, nil)
+		s := NewRegisterSessionService(mockRepo, new(MockSaleRepository), time.UTC)
+
+		_, err := s.CloseSession(ctx, id, &CloseRegisterSessionRequest{})
+		assert.ErrorIs(t, err, repository.ErrRegisterSessionNotOpen)
+	})
+
+	t.Run("存在しないセッションはエラー", func(t *testing.T) {
+		mockRepo := new(MockRegisterSessionRepository)
+		mockRepo.On("GetByID", ctx, id).Return(nil, nil)
+		s := NewRegisterSessionService(mockRepo, new(MockSaleRepository), time.UTC)
+
+		_, err := s.CloseSession(ctx, id, &CloseRegisterSessionRequest{})
+		assert.ErrorIs(t, err, ErrRegisterSessionNotFound)
+	})
+
+	t.Run("無効な金種はエラー", func(t *testing.T) {
+		s := NewRegisterSessionService(new(MockRegisterSessionRepository), new(MockSaleRepository), time.UTC)
+
+		_, err := s.CloseSession(ctx, id, &CloseRegisterSessionRequest{
+			CountedCash: []models.DenominationCount{{Denomination: 3000, Count: 1}},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestGetZReport(t *testing.T) {
+	ctx := context.Background()
+	jst := time.FixedZone("JST", 9*60*60)
+	date := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2024, 4, 1, 0, 0, 0, 0, jst)
+	end := start.AddDate(0, 0, 1)
+
+	counted := func(v float64) *float64 { return &v }
+	sales := []*models.Sale{
+		{
+			RegisterID:    "reg-1",
+			PaymentMethod: models.PaymentMethodCash,
+			Items:         []models.SaleItem{{Quantity: 2, PriceAtSale: 550}},
+			TotalAmount:   1100,
+			TotalTax:      100,
+			TaxBreakdown:  []models.TaxBreakdown{{TaxClass: models.TaxClassStandard, Rate: 10, Taxable: 1000, Tax: 100, Total: 1100}},
+		},
+		{
+			RegisterID:    "reg-2",
+			PaymentMethod: models.PaymentMethodEMoney,
+			Items:         []models.SaleItem{{Quantity: 1, PriceAtSale: 648}},
+			TotalAmount:   648,
+			TotalTax:      48,
+			TaxBreakdown:  []models.TaxBreakdown{{TaxClass: models.TaxClassReduced, Rate: 8, Taxable: 600, Tax: 48, Total: 648}},
+		},
+	}
+	sessions := []*models.RegisterSession{
+		{
+			RegisterID:       "reg-1",
+			Status:           models.RegisterSessionClosed,
+			OpeningFloat:     10000,
+			CountedCashTotal: 11000,
+			CashOverShort:    -100,
+			Takings: []models.PaymentTakings{
+				{PaymentMethod: models.PaymentMethodCash, SaleCount: 1, Expected: 1100, Counted: counted(1000)},
+			},
+		},
+		{
+			RegisterID:   "reg-2",
+			Status:       models.RegisterSessionOpen,
+			OpeningFloat: 5000,
+		},
+	}
+
+	t.Run("店舗全体とレジごとの内訳", func(t *testing.T) {
+		mockRepo := new(MockRegisterSessionRepository)
+		mockSaleRepo := new(MockSaleRepository)
+		mockSaleRepo.On("FindSales", ctx, models.SaleQuery{StoreID: "store-1", Start: start, End: end}).Return(sales, nil)
+		mockRepo.On("ListByPeriod", ctx, "store-1", "", start, end).Return(sessions, nil)
+		s := NewRegisterSessionService(mockRepo, mockSaleRepo, jst)
+
+		report, err := s.GetZReport(ctx, "store-1", "", date)
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-04-01", report.BusinessDate)
+		assert.Equal(t, 2, report.SaleCount)
+		assert.Equal(t, 1748.0, report.NetSales)
+		assert.Equal(t, 148.0, report.TotalTax)
+		assert.Len(t, report.TaxBreakdown, 2)
+		assert.Equal(t, 15000.0, report.OpeningFloat)
+		assert.Equal(t, 16100.0, report.ExpectedCash)
+		assert.Equal(t, -100.0, report.CashOverShort)
+		assert.Equal(t, 2, report.SessionCount)
+		assert.Equal(t, 1, report.OpenSessionCount)
+
+		assert.Len(t, report.Registers, 2)
+		assert.Equal(t, "reg-1", report.Registers[0].RegisterID)
+		assert.Equal(t, 1100.0, report.Registers[0].NetSales)
+		assert.Equal(t, -100.0, *report.Registers[0].Takings[0].Difference)
+		assert.Equal(t, "reg-2", report.Registers[1].RegisterID)
+		assert.Equal(t, 1, report.Registers[1].OpenSessionCount)
+	})
+
+	t.Run("店舗IDなしでエラー", func(t *testing.T) {
+		s := NewRegisterSessionService(new(MockRegisterSessionRepository), new(MockSaleRepository), jst)
+
+		_, err := s.GetZReport(ctx, "", "", date)
+		assert.Error(t, err)
+	})
+}
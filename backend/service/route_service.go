@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/routing"
+)
+
+var (
+	// ErrRobotUnavailable は指定した機体が待機中・割り当て済み（出発前）でない場合のエラーです
+	ErrRobotUnavailable = errors.New("robot is not available for route planning")
+	// ErrNoRoutableRobot はルートの計画に使える機体がない場合のエラーです
+	ErrNoRoutableRobot = errors.New("no robot available for route planning")
+)
+
+// routeUnassignedReasons はルートに入らなかった理由の表示名です
+var routeUnassignedReasons = map[routing.UnassignedReason]string{
+	routing.ReasonNoVehicle:         "配送種別に合い、バッテリー残量に余裕のある機体がありません",
+	routing.ReasonOverCapacity:      "荷物の重量が最大積載重量を超えています",
+	routing.ReasonInfeasible:        "到着時間帯・航続距離の条件を満たすルートがありません",
+	routing.ReasonCapacityExhausted: "機体の積載重量・航続距離・時間の余裕が不足しています",
+}
+
+// RouteConfig は配送ルートの計画の設定です
+type RouteConfig struct {
+	// RobotSpeed・DroneSpeed は配送ロボット・ドローンの平均移動速度（km/h）です
+	RobotSpeed float64
+	DroneSpeed float64
+	// ServiceTime は配送先での荷物の引き渡しにかかる時間です
+	ServiceTime time.Duration
+	// BatteryReserve は巡回して拠点に戻った後も残しておくバッテリー残量（%）です
+	BatteryReserve float64
+}
+
+// DefaultRouteConfig は既定の設定（ロボット時速6km、ドローン時速40km、引き渡し3分、予備バッテリー20%）を返します
+func DefaultRouteConfig() RouteConfig {
+	return RouteConfig{
+		RobotSpeed:     6,
+		DroneSpeed:     40,
+		ServiceTime:    3 * time.Minute,
+		BatteryReserve: 20,
+	}
+}
+
+// RoutePlanRequest は配送ルートの計画の条件です
+type RoutePlanRequest struct {
+	// RobotIDs を省略した場合は、待機中と出発前の割り当て済みのすべての機体を使います
+	RobotIDs []string `json:"robotIds"`
+	// DeliveryIDs を省略した場合は、配送準備中で配送先の位置が登録された配送のうち、
+	// 機体が未割り当てのものと計画に使う機体に割り当て済みのものを対象にします
+	DeliveryIDs []string `json:"deliveryIds"`
+	// DepartureTime は拠点を出発する時刻です（省略した場合は現在時刻）
+	DepartureTime *time.Time `json:"departureTime"`
+}
+
+// Validate は配送ルートの計画の条件を検証します
+func (r *RoutePlanRequest) Validate() error {
+	for _, id := range r.RobotIDs {
+		if _, err := primitive.ObjectIDFromHex(id); err != nil {
+			return fmt.Errorf("無効な機体IDです: %s", id)
+		}
+	}
+	for _, id := range r.DeliveryIDs {
+		if _, err := primitive.ObjectIDFromHex(id); err != nil {
+			return fmt.Errorf("無効な配送IDです: %s", id)
+		}
+	}
+	return nil
+}
+
+// RouteServiceInterface は配送ルートの計画を行うサービスのインターフェースを定義します
+type RouteServiceInterface interface {
+	PlanRoutes(ctx context.Context, req *RoutePlanRequest) (*models.RoutePlan, error)
+}
+
+// RouteService は配送ロボット・ドローンで複数の配送先を巡回するルートを計画するサービスです
+type RouteService struct {
+	robotRepo    repository.RobotRepository
+	deliveryRepo repository.DeliveryRepository
+	roads        routing.Metric
+	config       RouteConfig
+	now          func() time.Time
+}
+
+// NewRouteService は配送ルートの計画サービスを作成します
+// roads は配送ロボットの移動距離の求め方です（nil の場合は直線距離。ドローンは常に直線距離を使います）
+func NewRouteService(robotRepo repository.RobotRepository, deliveryRepo repository.DeliveryRepository, roads routing.Metric, config RouteConfig) *RouteService {
+	if roads == nil {
+		roads = routing.Haversine{}
+	}
+	return &RouteService{
+		robotRepo:    robotRepo,
+		deliveryRepo: deliveryRepo,
+		roads:        roads,
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+// PlanRoutes は配送の積載重量・到着時間帯と機体の積載重量・航続距離・バッテリー残量を満たし、
+// 総移動距離が短くなるように各機体の巡回ルートと到着予定を計画します（機体の割り当ては変更しません）
+// 機体が割り当て済みの配送は、その機体のルートに入れます
+func (s *RouteService) PlanRoutes(ctx context.Context, req *RoutePlanRequest) (*models.RoutePlan, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	departure := s.now()
+	if req.DepartureTime != nil {
+		departure = *req.DepartureTime
+	}
+
+	active, err := s.deliveryRepo.GetActiveDeliveries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 配送中の配送がある機体は出発済みのため計画に使いません
+	departed := map[string]bool{}
+	for _, d := range active {
+		if d.Status == models.StatusInProgress && d.RobotID != "" {
+			departed[d.RobotID] = true
+		}
+	}
+
+	robots, err := s.routableRobots(ctx, req.RobotIDs, departed)
+	if err != nil {
+		return nil, err
+	}
+	if len(robots) == 0 {
+		return nil, ErrNoRoutableRobot
+	}
+	robotsByID := make(map[string]*models.Robot, len(robots))
+	for _, robot := range robots {
+		robotsByID[robot.ID.Hex()] = robot
+	}
+
+	deliveries, err := s.pendingDeliveries(ctx, req.DeliveryIDs, active, robotsByID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.RoutePlan{
+		DepartureTime: departure,
+		Routes:        []models.DeliveryRoute{},
+		Unassigned:    []models.UnroutedDelivery{},
+	}
+	problem := routing.Problem{Start: departure}
+	usable := make([]*models.Robot, 0, len(robots))
+	for _, robot := range robots {
+		// 予備を除いたバッテリー残量で移動できる距離が上限です
+		maxDistance := robot.Range * (robot.BatteryLevel - s.config.BatteryReserve) / 100
+		if maxDistance <= 0 {
+			continue
+		}
+		vehicle := routing.Vehicle{
+			ID:          robot.ID.Hex(),
+			Depot:       robot.HomeBase,
+			Capacity:    robot.PayloadCapacity,
+			MaxDistance: maxDistance,
+			Speed:       s.config.RobotSpeed,
+			Metric:      s.roads,
+		}
+		if robot.Type == models.RobotTypeDrone {
+			vehicle.Speed = s.config.DroneSpeed
+			vehicle.Metric = routing.Haversine{}
+		}
+		problem.Vehicles = append(problem.Vehicles, vehicle)
+		usable = append(usable, robot)
+	}
+
+	deliveriesByID := make(map[string]*models.Delivery, len(deliveries))
+	for _, d := range deliveries {
+		deliveriesByID[d.ID] = d
+		vehicles := s.eligibleRobots(d, usable)
+		if len(vehicles) == 0 {
+			plan.Unassigned = append(plan.Unassigned, models.UnroutedDelivery{
+				DeliveryID: d.ID,
+				Reason:     routeUnassignedReasons[routing.ReasonNoVehicle],
+			})
+			continue
+		}
+		stop := routing.Stop{
+			ID:          d.ID,
+			Location:    *d.Destination,
+			Demand:      d.PayloadWeight,
+			ServiceTime: s.config.ServiceTime,
+			Vehicles:    vehicles,
+		}
+		if d.WindowStart != nil {
+			stop.WindowStart = *d.WindowStart
+		}
+		if d.WindowEnd != nil {
+			stop.WindowEnd = *d.WindowEnd
+		}
+		problem.Stops = append(problem.Stops, stop)
+	}
+
+	solution, err := routing.Solve(problem)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range solution.Routes {
+		robot := robotsByID[r.VehicleID]
+		route := models.DeliveryRoute{
+			RobotID:         r.VehicleID,
+			Name:            robot.Name,
+			Type:            robot.Type,
+			Stops:           make([]models.RouteStop, len(r.Visits)),
+			Load:            r.Load,
+			Distance:        r.Distance,
+			RequiredBattery: r.Distance/robot.Range*100 + s.config.BatteryReserve,
+			DepartureTime:   r.Departure,
+			ReturnTime:      r.Return,
+		}
+		for i, visit := range r.Visits {
+			d := deliveriesByID[visit.StopID]
+			route.Stops[i] = models.RouteStop{
+				Sequence:      i + 1,
+				DeliveryID:    d.ID,
+				Address:       d.Address,
+				Location:      *d.Destination,
+				ETA:           visit.Arrival,
+				DepartureTime: visit.Departure,
+				WaitMinutes:   visit.Wait.Minutes(),
+				WindowStart:   d.WindowStart,
+				WindowEnd:     d.WindowEnd,
+				Distance:      visit.Distance,
+			}
+		}
+		plan.Routes = append(plan.Routes, route)
+	}
+	for _, u := range solution.Unassigned {
+		plan.Unassigned = append(plan.Unassigned, models.UnroutedDelivery{
+			DeliveryID: u.StopID,
+			Reason:     routeUnassignedReasons[u.Reason],
+		})
+	}
+	plan.TotalDistance = solution.Distance
+	return plan, nil
+}
+
+// routableRobots はルートの計画に使う機体を取得します
+// 待機中の機体と、割り当て済みで配送中の配送がない（出発前の）機体が対象です
+func (s *RouteService) routableRobots(ctx context.Context, ids []string, departed map[string]bool) ([]*models.Robot, error) {
+	routable := func(robot *models.Robot) bool {
+		return (robot.Status == models.RobotIdle || robot.Status == models.RobotAssigned) && !departed[robot.ID.Hex()]
+	}
+
+	if len(ids) == 0 {
+		robots, err := s.robotRepo.List(ctx, models.RobotQuery{})
+		if err != nil {
+			return nil, err
+		}
+		result := make([]*models.Robot, 0, len(robots))
+		for _, robot := range robots {
+			if routable(robot) {
+				result = append(result, robot)
+			}
+		}
+		return result, nil
+	}
+
+	robots := make([]*models.Robot, 0, len(ids))
+	for _, id := range ids {
+		objectID, _ := primitive.ObjectIDFromHex(id)
+		robot, err := s.robotRepo.GetByID(ctx, objectID)
+		if err != nil {
+			return nil, err
+		}
+		if robot == nil {
+			return nil, ErrRobotNotFound
+		}
+		if !routable(robot) {
+			return nil, ErrRobotUnavailable
+		}
+		robots = append(robots, robot)
+	}
+	return robots, nil
+}
+
+// pendingDeliveries はルートに入れる配送準備中の配送を取得します
+func (s *RouteService) pendingDeliveries(ctx context.Context, ids []string, active []*models.Delivery, robots map[string]*models.Robot) ([]*models.Delivery, error) {
+	if len(ids) == 0 {
+		var result []*models.Delivery
+		for _, d := range active {
+			if d.Status != models.StatusPreparing || d.Destination == nil {
+				continue
+			}
+			if d.RobotID != "" && robots[d.RobotID] == nil {
+				continue
+			}
+			result = append(result, d)
+		}
+		return result, nil
+	}
+
+	result := make([]*models.Delivery, 0, len(ids))
+	for _, id := range ids {
+		objectID, _ := primitive.ObjectIDFromHex(id)
+		d, err := s.deliveryRepo.GetByID(ctx, objectID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, ErrDeliveryNotFound
+			}
+			return nil, err
+		}
+		if d == nil {
+			return nil, ErrDeliveryNotFound
+		}
+		if d.Status != models.StatusPreparing {
+			return nil, ErrDeliveryNotAssignable
+		}
+		if d.Destination == nil {
+			return nil, ErrDeliveryDestinationMissing
+		}
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// eligibleRobots は配送を担当できる機体のIDです（割り当て済みの配送はその機体、それ以外は配送種別に合う機体）
+func (s *RouteService) eligibleRobots(delivery *models.Delivery, robots []*models.Robot) []string {
+	var ids []string
+	for _, robot := range robots {
+		if delivery.RobotID != "" {
+			if robot.ID.Hex() == delivery.RobotID {
+				ids = append(ids, delivery.RobotID)
+			}
+			continue
+		}
+		if t := models.RobotTypeForDelivery(delivery.DeliveryType); t == "" || robot.Type == t {
+			ids = append(ids, robot.ID.Hex())
+		}
+	}
+	return ids
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+func TestPlanRoutes(t *testing.T) {
+	ctx := context.Background()
+	departure := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+	base := models.Location{Latitude: 35.0, Longitude: 139.0}
+	// north は拠点から北へ km だけ離れた地点です
+	north := func(km float64) *models.Location {
+		return &models.Location{Latitude: 35.0 + km/111.195, Longitude: 139.0}
+	}
+
+	robot := &models.Robot{ID: primitive.NewObjectID(), Name: "R-1", Type: models.RobotTypeGround,
+		PayloadCapacity: 10, Range: 20, BatteryLevel: 100, HomeBase: base, Status: models.RobotIdle}
+	busy := &models.Robot{ID: primitive.NewObjectID(), Name: "R-2", Type: models.RobotTypeGround,
+		PayloadCapacity: 10, Range: 20, BatteryLevel: 100, HomeBase: base, Status: models.RobotAssigned}
+
+	deadline := departure.Add(21 * time.Minute)
+	deliveries := []*models.Delivery{
+		{ID: primitive.NewObjectID().Hex(), DeliveryType: "ロボット", Address: "2km", Status: models.StatusPreparing, Destination: north(2), PayloadWeight: 2, WindowEnd: &deadline},
+		{ID: primitive.NewObjectID().Hex(), DeliveryType: "ロボット", Address: "1km", Status: models.StatusPreparing, Destination: north(1), PayloadWeight: 2},
+		{ID: primitive.NewObjectID().Hex(), DeliveryType: "ドローン", Address: "drone", Status: models.StatusPreparing, Destination: north(1)},
+		{ID: primitive.NewObjectID().Hex(), DeliveryType: "ロボット", Address: "heavy", Status: models.StatusPreparing, Destination: north(1), PayloadWeight: 30},
+		// 出発済みの機体の配送と、配送先の位置がない配送は対象外
+		{ID: primitive.NewObjectID().Hex(), DeliveryType: "ロボット", Status: models.StatusInProgress, Destination: north(1), RobotID: busy.ID.Hex()},
+		{ID: primitive.NewObjectID().Hex(), DeliveryType: "ロボット", Status: models.StatusPreparing},
+	}
+
+	robotRepo := new(MockRobotRepository)
+	deliveryRepo := new(MockDeliveryRepository)
+	robotRepo.On("List", ctx, models.RobotQuery{}).Return([]*models.Robot{robot, busy}, nil)
+	deliveryRepo.On("GetActiveDeliveries", ctx).Return(deliveries, nil)
+
+	service := NewRouteService(robotRepo, deliveryRepo, nil, DefaultRouteConfig())
+	plan, err := service.PlanRoutes(ctx, &RoutePlanRequest{DepartureTime: &departure})
+	require.NoError(t, err)
+
+	require.Len(t, plan.Routes, 1)
+	route := plan.Routes[0]
+	assert.Equal(t, robot.ID.Hex(), route.RobotID)
+	require.Len(t, route.Stops, 2)
+	assert.Equal(t, "2km", route.Stops[0].Address)
+	assert.Equal(t, "1km", route.Stops[1].Address)
+	assert.Equal(t, 1, route.Stops[0].Sequence)
+	// 21分以内の到着が必要な2km先（時速6kmで20分）を先に回り、引き渡し3分の後に1km戻る（10分）
+	assert.WithinDuration(t, departure.Add(20*time.Minute), route.Stops[0].ETA, time.Second)
+	assert.WithinDuration(t, departure.Add(33*time.Minute), route.Stops[1].ETA, time.Second)
+	assert.WithinDuration(t, departure.Add(46*time.Minute), route.ReturnTime, time.Second)
+	assert.InDelta(t, 4, route.Distance, 0.01)
+	assert.InDelta(t, 4, route.Load, 1e-9)
+	assert.InDelta(t, 40, route.RequiredBattery, 0.1)
+
+	reasons := map[string]string{}
+	for _, u := range plan.Unassigned {
+		reasons[u.DeliveryID] = u.Reason
+	}
+	assert.Equal(t, map[string]string{
+		deliveries[2].ID: routeUnassignedReasons["no_vehicle"],
+		deliveries[3].ID: routeUnassignedReasons["over_capacity"],
+	}, reasons)
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+const (
+	// DefaultImportBatchSize は一括登録で1回に書き込む売上の件数です
+	DefaultImportBatchSize = 100
+	// maxImportLineSize は一括登録の1行の最大サイズです
+	maxImportLineSize = 1 << 20
+	// maxClockSkew は端末の時計のずれとして許容する未来の日時の幅です
+	maxClockSkew = 5 * time.Minute
+)
+
+// ImportSalesOptions は売上の一括登録の設定です
+type ImportSalesOptions struct {
+	// StoreID / RegisterID は行に店舗・レジが指定されていない場合の既定値です
+	StoreID    string
+	RegisterID string
+	// LineOffset は再送時に先頭行へ加算する行番号です（前回の LastAcknowledgedLine を指定する）
+	LineOffset int
+	BatchSize  int
+}
+
+// pendingSale は書き込み待ちの売上です
+type pendingSale struct {
+	line int
+	sale *models.Sale
+	// index は行ごとの結果の中での位置です
+	index int
+}
+
+// ImportSales は改行区切りJSON（NDJSON）の売上を読み込み、バッチごとに記録します
+//
+// 各行は Create と同じ規則で検証し、端末取引IDが必須です。記録済みの端末取引は
+// duplicate として扱うため、端末は LastAcknowledgedLine の次の行から安全に再送できます。
+// 行ごとの結果はバッチの書き込みが終わるたびに emit に渡します。
+// 一時的なエラーが起きた場合はそれ以降の行を処理せずに終了します。
+func (ss *SaleService) ImportSales(ctx context.Context, r io.Reader, opts ImportSalesOptions, emit func(models.BulkSaleResult) error) (*models.BulkSaleSummary, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultImportBatchSize
+	}
+
+	summary := &models.BulkSaleSummary{LastAcknowledgedLine: opts.LineOffset}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+
+	var results []models.BulkSaleResult
+	var batch []pendingSale
+	stopped := false
+
+	// バッチを書き込み、結果を行番号順に確定させる
+	flush := func() error {
+		if len(batch) > 0 {
+			for i, result := range ss.insertBatch(ctx, batch) {
+				results[batch[i].index] = result
+			}
+			batch = batch[:0]
+		}
+		for _, result := range results {
+			if stopped || result.Status == models.BulkSaleFailed {
+				stopped = true
+				summary.Failed++
+			} else {
+				summary.LastAcknowledgedLine = result.Line
+				switch result.Status {
+				case models.BulkSaleCreated:
+					summary.Created++
+				case models.BulkSaleDuplicate:
+					summary.Duplicates++
+				case models.BulkSaleInvalid:
+					summary.Invalid++
+				}
+			}
+			if err := emit(result); err != nil {
+				return err
+			}
+		}
+		results = results[:0]
+		return nil
+	}
+
+	line := opts.LineOffset
+	for !stopped && scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		summary.Received++
+
+		sale, result := ss.prepareImportLine(ctx, line, raw, opts)
+		if sale == nil {
+			results = append(results, result)
+			if result.Status == models.BulkSaleFailed {
+				break
+			}
+			continue
+		}
+		batch = append(batch, pendingSale{line: line, sale: sale, index: len(results)})
+		results = append(results, result)
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return summary, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return summary, err
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return summary, fmt.Errorf("line %d exceeds %d bytes", line+1, maxImportLineSize)
+		}
+		return summary, err
+	}
+	return summary, nil
+}
+
+// prepareImportLine は1行を解析・検証します
+// 登録対象の場合は売上を返し、それ以外は行の結果を返します
+func (ss *SaleService) prepareImportLine(ctx context.Context, line int, raw []byte, opts ImportSalesOptions) (*models.Sale, models.BulkSaleResult) {
+	result := models.BulkSaleResult{Line: line}
+
+	var sale models.Sale
+	if err := json.Unmarshal(raw, &sale); err != nil {
+		result.Status = models.BulkSaleInvalid
+		result.Error = "JSONの解析に失敗しました"
+		return nil, result
+	}
+	result.TerminalTransactionID = sale.TerminalTransactionID
+
+	if sale.TerminalTransactionID == "" {
+		result.Status = models.BulkSaleInvalid
+		result.Error = "端末取引IDが指定されていません"
+		return nil, result
+	}
+	if sale.StoreID == "" {
+		sale.StoreID = opts.StoreID
+	}
+	if sale.RegisterID == "" {
+		sale.RegisterID = opts.RegisterID
+	}
+	if sale.CreatedAt.After(time.Now().Add(maxClockSkew)) {
+		result.Status = models.BulkSaleInvalid
+		result.Error = "売上日時が未来の日時です"
+		return nil, result
+	}
+
+	// 登録済みの端末取引も含めて Create と同じ規則で検証する
+	err := ss.prepare(ctx, &sale)
+	if err == nil {
+		err = ss.loyalty.PrepareSale(ctx, &sale)
+	}
+	if err != nil {
+		result.Status = models.BulkSaleInvalid
+		if isTemporaryError(err) {
+			result.Status = models.BulkSaleFailed
+		}
+		result.Error = err.Error()
+		return nil, result
+	}
+	return &sale, result
+}
+
+// insertBatch は検証済みの売上をまとめて書き込み、行ごとの結果を返します
+func (ss *SaleService) insertBatch(ctx context.Context, batch []pendingSale) []models.BulkSaleResult {
+	sales := make([]*models.Sale, len(batch))
+	for i, p := range batch {
+		sales[i] = p.sale
+	}
+
+	errs, err := ss.repo.CreateMany(ctx, sales)
+	results := make([]models.BulkSaleResult, len(batch))
+	for i, p := range batch {
+		result := models.BulkSaleResult{
+			Line:                  p.line,
+			TerminalTransactionID: p.sale.TerminalTransactionID,
+		}
+
+		var saleErr error
+		if err != nil {
+			saleErr = err
+		} else {
+			saleErr = errs[i]
+		}
+
+		switch {
+		case saleErr == nil:
+			id := p.sale.ID
+			result.Status = models.BulkSaleCreated
+			result.SaleID = &id
+			ss.completeSale(ctx, p.sale)
+		case errors.Is(saleErr, repository.ErrDuplicateTerminalTransaction):
+			result.Status = models.BulkSaleDuplicate
+			ss.releaseRedemption(ctx, p.sale)
+		case err != nil:
+			// バッチ全体の書き込みに失敗した
+			result.Status = models.BulkSaleFailed
+			result.Error = saleErr.Error()
+			ss.releaseRedemption(ctx, p.sale)
+		default:
+			result.Status = models.BulkSaleInvalid
+			result.Error = saleErr.Error()
+			ss.releaseRedemption(ctx, p.sale)
+		}
+		results[i] = result
+	}
+	return results
+}
+
+// isTemporaryError は再送すれば成功する可能性のあるエラーかどうかを返します
+func isTemporaryError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr)
+}
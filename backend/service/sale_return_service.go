@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+// SaleReturnServiceInterface は取消・返品サービスのインターフェースを定義します
+type SaleReturnServiceInterface interface {
+	CreateReturn(ctx context.Context, saleID primitive.ObjectID, ret *models.SaleReturn) error
+	GetReturns(ctx context.Context, saleID primitive.ObjectID) ([]*models.SaleReturn, error)
+}
+
+// SaleReturnService は売上の取消・返品を扱うサービスです
+type SaleReturnService struct {
+	repo     repository.SaleReturnRepository
+	saleRepo repository.SaleRepository
+}
+
+// NewSaleReturnService は新しい取消・返品サービスを作成します
+func NewSaleReturnService(repo repository.SaleReturnRepository, saleRepo repository.SaleRepository) *SaleReturnService {
+	return &SaleReturnService{
+		repo:     repo,
+		saleRepo: saleRepo,
+	}
+}
+
+// CreateReturn は売上の取消・返品を記録します
+// 返金額が未指定の場合は未返金の全額を返金します
+func (s *SaleReturnService) CreateReturn(ctx context.Context, saleID primitive.ObjectID, ret *models.SaleReturn) error {
+	if !models.ValidateSaleReturnType(ret.Type) {
+		return errors.New("無効な取消・返品の種別です")
+	}
+	if ret.Reason == "" {
+		return errors.New("取消・返品の理由が指定されていません")
+	}
+	if ret.Amount < 0 {
+		return errors.New("返金額は0以上である必要があります")
+	}
+
+	sale, err := s.saleRepo.GetByID(ctx, saleID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrSaleNotFound
+		}
+		return err
+	}
+
+	existing, err := s.repo.GetBySale(ctx, saleID)
+	if err != nil {
+		return err
+	}
+	remaining := sale.TotalAmount
+	for _, r := range existing {
+		remaining -= r.Amount
+	}
+	if ret.Amount == 0 {
+		ret.Amount = remaining
+	}
+	if ret.Amount <= 0 || ret.Amount > remaining {
+		return errors.New("返金額が売上の未返金額を超えています")
+	}
+
+	ret.SaleID = saleID
+	ret.StoreID = sale.StoreID
+	ret.RegisterID = sale.RegisterID
+	return s.repo.Create(ctx, ret)
+}
+
+// GetReturns は売上に対する取消・返品を取得します
+func (s *SaleReturnService) GetReturns(ctx context.Context, saleID primitive.ObjectID) ([]*models.SaleReturn, error) {
+	return s.repo.GetBySale(ctx, saleID)
+}
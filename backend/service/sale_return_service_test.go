@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+type MockSaleReturnRepository struct {
+	mock.Mock
+}
+
+var _ repository.SaleReturnRepository = (*MockSaleReturnRepository)(nil)
+
+func (m *MockSaleReturnRepository) Create(ctx context.Context, ret *models.SaleReturn) error {
+	args := m.Called(ctx, ret)
+	return args.Error(0)
+}
+
+func (m *MockSaleReturnRepository) GetBySale(ctx context.Context, saleID primitive.ObjectID) ([]*models.SaleReturn, error) {
+	args := m.Called(ctx, saleID)
+	return args.Get(0).([]*models.SaleReturn), args.Error(1)
+}
+
+func (m *MockSaleReturnRepository) FindReturns(ctx context.Context, query models.SaleQuery) ([]*models.SaleReturn, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]*models.SaleReturn), args.Error(1)
+}
+
+func TestCreateReturn(t *testing.T) {
+	ctx := context.Background()
+	saleID := primitive.NewObjectID()
+	sale := &models.Sale{ID: saleID, StoreID: "store-1", RegisterID: "reg-1", TotalAmount: 1000}
+
+	tests := []struct {
+		name      string
+		ret       *models.SaleReturn
+		existing  []*models.SaleReturn
+		saleErr   error
+		wantErr   error
+		wantError bool
+		want      float64
+	}{
+		{
+			name: "正常系: 返金額未指定の場合は未返金の全額",
+			ret:  &models.SaleReturn{Type: models.SaleReturnRefund, Reason: "破損"},
+			existing: []*models.SaleReturn{
+				{Amount: 300},
+			},
+			want: 700,
+		},
+		{
+			name: "異常系: 未返金額を超える返金",
+			ret:  &models.SaleReturn{Type: models.SaleReturnRefund, Reason: "破損", Amount: 800},
+			existing: []*models.SaleReturn{
+				{Amount: 300},
+			},
+			wantError: true,
+		},
+		{
+			name:      "異常系: 無効な種別",
+			ret:       &models.SaleReturn{Type: "exchange", Reason: "破損"},
+			wantError: true,
+		},
+		{
+			name:    "異常系: 売上が存在しない",
+			ret:     &models.SaleReturn{Type: models.SaleReturnVoid, Reason: "打ち間違い"},
+			saleErr: mongo.ErrNoDocuments,
+			wantErr: ErrSaleNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockSaleReturnRepository)
+			mockSaleRepo := new(MockSaleRepository)
+			svc := NewSaleReturnService(mockRepo, mockSaleRepo)
+
+			if tt.saleErr != nil {
+				mockSaleRepo.On("GetByID", ctx, saleID).Return(nil, tt.saleErr)
+			} else {
+				mockSaleRepo.On("GetByID", ctx, saleID).Return(sale, nil)
+			}
+			mockRepo.On("GetBySale", ctx, saleID).Return(tt.existing, nil)
+			mockRepo.On("Create", ctx, tt.ret).Return(nil)
+
+			err := svc.CreateReturn(ctx, saleID, tt.ret)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantError:
+				assert.Error(t, err)
+				mockRepo.AssertNotCalled(t, "Create", ctx, tt.ret)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, tt.ret.Amount)
+				assert.Equal(t, "reg-1", tt.ret.RegisterID)
+			}
+		})
+	}
+}
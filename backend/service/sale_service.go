@@ -3,42 +3,80 @@ package service
 import (
 	"context"
 	"errors"
+	"io"
+	"log"
+	"math"
 	"time"
 
 	"github.com/onoderaryou/smart-store-admin/backend/models"
 	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/tax"
 )
 
 type SaleServiceInterface interface {
 	Create(ctx context.Context, sale *models.Sale) error
+	ImportSales(ctx context.Context, r io.Reader, opts ImportSalesOptions, emit func(models.BulkSaleResult) error) (*models.BulkSaleSummary, error)
 	GetDailySales(ctx context.Context, date time.Time) ([]*models.Sale, error)
 	GetSalesByDateRange(ctx context.Context, start, end time.Time) ([]*models.Sale, error)
 	GetEnvironmentalImpactAnalytics(ctx context.Context, start, end time.Time) (*models.EnvironmentalImpact, error)
 	GetSalesByTimeOfDay(ctx context.Context, timeOfDay string) ([]*models.Sale, error)
-	GetSalesByCategory(ctx context.Context, start, end time.Time) (map[string]int, error)
+	GetSalesByCategory(ctx context.Context, start, end time.Time) ([]*models.CategorySales, error)
+	GetCategorySalesReport(ctx context.Context, start, end time.Time) (*models.CategorySalesReport, error)
+	GetSalesHeatmap(ctx context.Context, storeID string, startDate, endDate time.Time) (*models.SalesHeatmap, error)
+	GetMarginReport(ctx context.Context, query MarginQuery) (*models.MarginReport, error)
+	GetDayTypeSales(ctx context.Context, storeID string, startDate, endDate time.Time) (*models.DayTypeSalesReport, error)
 }
 
 type SaleService struct {
 	repo        repository.SaleRepository
 	productRepo repository.ProductRepository
+	taxCalc     *tax.Calculator
+	loyalty     LoyaltyServiceInterface
+	// calendar は日の種類（祝日・イベントなど）別の分析に使う営業カレンダーです
+	calendar CalendarServiceInterface
+	// location は曜日・時間帯の集計に使う店舗のタイムゾーンです
+	location *time.Location
 }
 
 // オプション: コンストラクタ
-func NewSaleService(repo repository.SaleRepository, productRepo repository.ProductRepository) *SaleService {
+func NewSaleService(repo repository.SaleRepository, productRepo repository.ProductRepository, taxCalc *tax.Calculator, loyalty LoyaltyServiceInterface, calendar CalendarServiceInterface, location *time.Location) *SaleService {
 	return &SaleService{
 		repo:        repo,
 		productRepo: productRepo,
+		taxCalc:     taxCalc,
+		loyalty:     loyalty,
+		calendar:    calendar,
+		location:    location,
 	}
 }
 
 // Create は新しい売上を記録します
 func (ss *SaleService) Create(ctx context.Context, sale *models.Sale) error {
+	if err := ss.prepare(ctx, sale); err != nil {
+		return err
+	}
+
+	// 会員の場合はポイント利用を確定してから売上を記録する
+	if err := ss.loyalty.PrepareSale(ctx, sale); err != nil {
+		return err
+	}
+	if err := ss.repo.Create(ctx, sale); err != nil {
+		ss.releaseRedemption(ctx, sale)
+		return err
+	}
+
+	ss.completeSale(ctx, sale)
+	return nil
+}
+
+// prepare は売上を検証し、販売時点の商品情報・値引き・消費税を設定します
+func (ss *SaleService) prepare(ctx context.Context, sale *models.Sale) error {
 	if sale == nil || len(sale.Items) == 0 {
 		return errors.New("商品が指定されていません")
 	}
 
 	// 商品の存在チェック
-	for _, item := range sale.Items {
+	for i, item := range sale.Items {
 		if item.ProductID.IsZero() {
 			return errors.New("無効な商品IDです")
 		}
@@ -49,9 +87,152 @@ func (ss *SaleService) Create(ctx context.Context, sale *models.Sale) error {
 		if p == nil {
 			return errors.New("指定された商品が存在しません")
 		}
+
+		// レシート・集計のために販売時点の商品情報を保存
+		sale.Items[i].Name = p.Name
+		sale.Items[i].Category = p.Category
+		sale.Items[i].TaxClass = p.TaxClass
+		sale.Items[i].CO2Emission = p.CO2Emission
+		sale.Items[i].CostAtSale = p.CostPrice
+		if sale.Items[i].TaxClass == "" {
+			sale.Items[i].TaxClass = models.TaxClassStandard
+		}
+	}
+
+	if err := normalizePromotions(sale); err != nil {
+		return err
+	}
+
+	ss.applyTax(sale)
+	return nil
+}
+
+// releaseRedemption は記録できなかった売上の利用ポイントを残高へ戻します
+func (ss *SaleService) releaseRedemption(ctx context.Context, sale *models.Sale) {
+	if err := ss.loyalty.ReleaseRedemption(ctx, sale); err != nil {
+		log.Printf("Failed to release redeemed points: %v", err)
+	}
+}
+
+// completeSale は記録済みの売上のポイント付与を行います
+// 売上は記録済みのため、ポイント付与の失敗では売上をエラーにしない
+func (ss *SaleService) completeSale(ctx context.Context, sale *models.Sale) {
+	if err := ss.loyalty.CompleteSale(ctx, sale); err != nil {
+		log.Printf("Failed to award points for sale %s: %v", sale.ID.Hex(), err)
+	}
+}
+
+// normalizePromotions は値引きを検証し、対象商品の税区分を設定します
+// 売上全体への値引きで税区分が未指定の場合は、売上に含まれる税区分ごとに按分します
+func normalizePromotions(sale *models.Sale) error {
+	gross := make(map[models.TaxClass]float64)
+	for _, item := range sale.Items {
+		gross[item.TaxClass] += item.PriceAtSale * float64(item.Quantity)
+	}
+
+	for i, promo := range sale.Promotions {
+		if promo.Name == "" {
+			return errors.New("プロモーション名が指定されていません")
+		}
+		if promo.Discount < 0 {
+			return errors.New("値引額は0以上である必要があります")
+		}
+
+		if promo.ProductID == nil {
+			if promo.TaxClass == "" {
+				continue
+			}
+			if !models.ValidateTaxClass(promo.TaxClass) {
+				return errors.New("無効な税区分です")
+			}
+			if _, ok := gross[promo.TaxClass]; !ok {
+				return errors.New("値引対象の税区分の商品が売上に含まれていません")
+			}
+			continue
+		}
+
+		// 商品への値引きは対象商品と同じ税率から差し引く
+		found := false
+		for _, item := range sale.Items {
+			if item.ProductID == *promo.ProductID {
+				sale.Promotions[i].TaxClass = item.TaxClass
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("値引対象の商品が売上に含まれていません")
+		}
+	}
+
+	// 税区分ごとに値引額が対象額を超えないことを確認する
+	for _, line := range promotionLines(sale) {
+		gross[line.Class] += line.Amount
+	}
+	for _, amount := range gross {
+		if amount < 0 {
+			return errors.New("値引額が売上金額を超えています")
+		}
+	}
+	return nil
+}
+
+// promotionLines は値引きを税計算用の明細（負の金額）に変換します
+// 税区分が未指定の値引きは明細の金額に応じて税区分ごとに按分します（端数は最後の税区分で調整）
+func promotionLines(sale *models.Sale) []tax.Line {
+	var classes []models.TaxClass
+	gross := make(map[models.TaxClass]float64)
+	var total float64
+	for _, item := range sale.Items {
+		amount := item.PriceAtSale * float64(item.Quantity)
+		if _, ok := gross[item.TaxClass]; !ok {
+			classes = append(classes, item.TaxClass)
+		}
+		gross[item.TaxClass] += amount
+		total += amount
 	}
 
-	return ss.repo.Create(ctx, sale)
+	lines := make([]tax.Line, 0, len(sale.Promotions))
+	for _, promo := range sale.Promotions {
+		if promo.TaxClass != "" {
+			lines = append(lines, tax.Line{Class: promo.TaxClass, Amount: -promo.Discount})
+			continue
+		}
+		if total <= 0 {
+			// 按分先がない場合は全額を値引きとして扱い、上限チェックでエラーにする
+			lines = append(lines, tax.Line{Class: models.TaxClassStandard, Amount: -promo.Discount})
+			continue
+		}
+		remaining := promo.Discount
+		for i, class := range classes {
+			share := remaining
+			if i < len(classes)-1 {
+				share = math.Round(promo.Discount * gross[class] / total)
+				remaining -= share
+			}
+			lines = append(lines, tax.Line{Class: class, Amount: -share})
+		}
+	}
+	return lines
+}
+
+// applyTax は明細から税率ごとの内訳を計算し、売上の合計額に反映します
+func (ss *SaleService) applyTax(sale *models.Sale) {
+	lines := make([]tax.Line, 0, len(sale.Items)+len(sale.Promotions))
+	for _, item := range sale.Items {
+		lines = append(lines, tax.Line{
+			Class:  item.TaxClass,
+			Amount: item.PriceAtSale * float64(item.Quantity),
+		})
+	}
+	lines = append(lines, promotionLines(sale)...)
+
+	result := ss.taxCalc.Calculate(lines)
+	sale.TaxBreakdown = result.Breakdown
+	sale.Subtotal = result.Subtotal
+	sale.TotalTax = result.TotalTax
+	sale.TotalAmount = result.Total
+	sale.InvoiceIssuer = ss.taxCalc.Issuer()
 }
 
 func (ss *SaleService) GetDailySales(ctx context.Context, date time.Time) ([]*models.Sale, error) {
@@ -70,6 +251,96 @@ func (ss *SaleService) GetSalesByTimeOfDay(ctx context.Context, timeOfDay string
 	return ss.repo.GetSalesByTimeOfDay(ctx, timeOfDay)
 }
 
-func (ss *SaleService) GetSalesByCategory(ctx context.Context, start, end time.Time) (map[string]int, error) {
+func (ss *SaleService) GetSalesByCategory(ctx context.Context, start, end time.Time) ([]*models.CategorySales, error) {
 	return ss.repo.GetSalesByCategory(ctx, start, end)
 }
+
+// GetCategorySalesReport はカテゴリー別の販売数・売上・構成比を前期と比較して返します
+// 前期は指定期間と同じ長さの直前の期間です
+func (ss *SaleService) GetCategorySalesReport(ctx context.Context, start, end time.Time) (*models.CategorySalesReport, error) {
+	if !end.After(start) {
+		return nil, errors.New("終了日は開始日より後である必要があります")
+	}
+
+	prevEnd := start
+	prevStart := start.Add(-end.Sub(start))
+
+	current, err := ss.repo.GetSalesByCategory(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := ss.repo.GetSalesByCategory(ctx, prevStart, prevEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.CategorySalesReport{
+		Start:         start,
+		End:           end,
+		PreviousStart: prevStart,
+		PreviousEnd:   prevEnd,
+		Categories:    []models.CategorySalesComparison{},
+	}
+	report.TotalUnits, report.TotalRevenue = applyCategoryShares(current)
+	report.PreviousTotalUnits, report.PreviousTotalRevenue = applyCategoryShares(previous)
+
+	previousByCategory := make(map[string]*models.CategorySales, len(previous))
+	for _, p := range previous {
+		previousByCategory[p.Category] = p
+	}
+
+	// 当期の売上順に並べ、前期のみ存在するカテゴリは末尾に追加
+	for _, c := range current {
+		comparison := models.CategorySalesComparison{
+			Category: c.Category,
+			Units:    c.Units,
+			Revenue:  c.Revenue,
+			Share:    c.Share,
+		}
+		if p, ok := previousByCategory[c.Category]; ok {
+			comparison.PreviousUnits = p.Units
+			comparison.PreviousRevenue = p.Revenue
+			comparison.PreviousShare = p.Share
+			delete(previousByCategory, c.Category)
+		}
+		if comparison.PreviousRevenue > 0 {
+			growth := (comparison.Revenue - comparison.PreviousRevenue) / comparison.PreviousRevenue
+			comparison.RevenueGrowth = &growth
+		}
+		report.Categories = append(report.Categories, comparison)
+	}
+	for _, p := range previous {
+		if _, ok := previousByCategory[p.Category]; !ok {
+			continue
+		}
+		comparison := models.CategorySalesComparison{
+			Category:        p.Category,
+			PreviousUnits:   p.Units,
+			PreviousRevenue: p.Revenue,
+			PreviousShare:   p.Share,
+		}
+		if p.Revenue > 0 {
+			growth := -1.0
+			comparison.RevenueGrowth = &growth
+		}
+		report.Categories = append(report.Categories, comparison)
+	}
+
+	return report, nil
+}
+
+// applyCategoryShares は各カテゴリの売上構成比を設定し、合計販売数と合計売上を返します
+func applyCategoryShares(categories []*models.CategorySales) (int, float64) {
+	var units int
+	var revenue float64
+	for _, c := range categories {
+		units += c.Units
+		revenue += c.Revenue
+	}
+	for _, c := range categories {
+		if revenue > 0 {
+			c.Share = c.Revenue / revenue
+		}
+	}
+	return units, revenue
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 
 	"github.com/onoderaryou/smart-store-admin/backend/models"
 	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/tax"
 )
 
 // MockSaleRepository はrepository.SaleRepositoryインターフェースのモック実装です
@@ -44,6 +46,39 @@ func (m *MockSaleRepository) GetSalesByDateRange(ctx context.Context, start, end
 	return args.Get(0).([]*models.Sale), args.Error(1)
 }
 
+func (m *MockSaleRepository) CreateMany(ctx context.Context, sales []*models.Sale) ([]error, error) {
+	args := m.Called(ctx, sales)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]error), args.Error(1)
+}
+
+func (m *MockSaleRepository) FindSales(ctx context.Context, query models.SaleQuery) ([]*models.Sale, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Sale), args.Error(1)
+}
+
+func (m *MockSaleRepository) StreamSales(ctx context.Context, query models.SaleQuery, fn func(*models.Sale) error) error {
+	args := m.Called(ctx, query, fn)
+	if sales, ok := args.Get(0).([]*models.Sale); ok {
+		for _, sale := range sales {
+			if err := fn(sale); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockSaleRepository) GetSalesByMember(ctx context.Context, memberID primitive.ObjectID, skip, limit int64) ([]*models.Sale, error) {
+	args := m.Called(ctx, memberID, skip, limit)
+	return args.Get(0).([]*models.Sale), args.Error(1)
+}
+
 func (m *MockSaleRepository) GetEnvironmentalImpactAnalytics(ctx context.Context, start, end time.Time) (*models.EnvironmentalImpact, error) {
 	args := m.Called(ctx, start, end)
 	if args.Get(0) == nil {
@@ -60,20 +95,38 @@ func (m *MockSaleRepository) GetSalesByTimeOfDay(ctx context.Context, timeOfDay
 	return args.Get(0).([]*models.Sale), args.Error(1)
 }
 
-func (m *MockSaleRepository) GetSalesByCategory(ctx context.Context, start, end time.Time) (map[string]int, error) {
+func (m *MockSaleRepository) GetSalesByCategory(ctx context.Context, start, end time.Time) ([]*models.CategorySales, error) {
 	args := m.Called(ctx, start, end)
-	return args.Get(0).(map[string]int), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.CategorySales), args.Error(1)
 }
 
-func (m *MockSaleRepository) GetTotalSalesAmount(ctx context.Context, start, end time.Time) (float64, error) {
-	args := m.Called(ctx, start, end)
+func (m *MockSaleRepository) GetHourlySales(ctx context.Context, query models.SaleQuery, timezone string) ([]*models.HourlySales, error) {
+	args := m.Called(ctx, query, timezone)
+	return args.Get(0).([]*models.HourlySales), args.Error(1)
+}
+
+func (m *MockSaleRepository) GetDailyRevenue(ctx context.Context, query models.SaleQuery, timezone string) ([]*models.DailyRevenue, error) {
+	args := m.Called(ctx, query, timezone)
+	return args.Get(0).([]*models.DailyRevenue), args.Error(1)
+}
+
+func (m *MockSaleRepository) GetSalesByProduct(ctx context.Context, query models.SaleQuery) ([]*models.ProductSales, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]*models.ProductSales), args.Error(1)
+}
+
+func (m *MockSaleRepository) GetTotalSalesAmount(ctx context.Context, query models.SaleQuery, category string) (float64, error) {
+	args := m.Called(ctx, query, category)
 	return args.Get(0).(float64), args.Error(1)
 }
 
 func TestCreate(t *testing.T) {
 	mockSaleRepo := new(MockSaleRepository)
 	mockProductRepo := new(MockProductRepository)
-	service := NewSaleService(mockSaleRepo, mockProductRepo)
+	service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(tax.DefaultConfig()), newTestLoyaltyService(mockSaleRepo), nil, time.UTC)
 	ctx := context.Background()
 
 	productID := primitive.NewObjectID()
@@ -102,9 +155,10 @@ func TestCreate(t *testing.T) {
 			sale: validSale,
 			mockFn: func() {
 				mockProductRepo.On("GetByID", ctx, productID).Return(&models.Product{
-					ID:    productID,
-					Name:  "Test Product",
-					Price: 1000,
+					ID:       productID,
+					Name:     "Test Product",
+					Price:    1000,
+					Category: "食品",
 				}, nil)
 				mockSaleRepo.On("Create", ctx, mock.AnythingOfType("*models.Sale")).Return(nil)
 			},
@@ -134,15 +188,208 @@ func TestCreate(t *testing.T) {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, "食品", tt.sale.Items[0].Category)
 			}
 		})
 	}
 }
 
+func TestCreate_TaxBreakdown(t *testing.T) {
+	mockSaleRepo := new(MockSaleRepository)
+	mockProductRepo := new(MockProductRepository)
+	taxConfig := tax.DefaultConfig()
+	taxConfig.RegistrationNumber = "T1234567890123"
+	taxConfig.IssuerName = "NEXT MART 2030"
+	service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(taxConfig), newTestLoyaltyService(mockSaleRepo), nil, time.UTC)
+	ctx := context.Background()
+
+	foodID := primitive.NewObjectID()
+	goodsID := primitive.NewObjectID()
+	mockProductRepo.On("GetByID", ctx, foodID).Return(&models.Product{
+		ID:       foodID,
+		Category: "食品",
+		TaxClass: models.TaxClassReduced,
+	}, nil)
+	mockProductRepo.On("GetByID", ctx, goodsID).Return(&models.Product{
+		ID:       goodsID,
+		Category: "日用品",
+	}, nil)
+	mockSaleRepo.On("Create", ctx, mock.AnythingOfType("*models.Sale")).Return(nil)
+
+	sale := &models.Sale{
+		Items: []models.SaleItem{
+			{ProductID: foodID, Quantity: 2, PriceAtSale: 216},
+			{ProductID: goodsID, Quantity: 1, PriceAtSale: 330},
+		},
+		PaymentMethod: "cash",
+	}
+
+	err := service.Create(ctx, sale)
+	assert.NoError(t, err)
+	assert.Equal(t, models.TaxClassReduced, sale.Items[0].TaxClass)
+	assert.Equal(t, models.TaxClassStandard, sale.Items[1].TaxClass)
+	assert.Equal(t, []models.TaxBreakdown{
+		{TaxClass: models.TaxClassStandard, Rate: 10, Taxable: 300, Tax: 30, Total: 330},
+		{TaxClass: models.TaxClassReduced, Rate: 8, Taxable: 400, Tax: 32, Total: 432},
+	}, sale.TaxBreakdown)
+	assert.Equal(t, 762.0, sale.TotalAmount)
+	assert.Equal(t, 62.0, sale.TotalTax)
+	assert.Equal(t, 700.0, sale.Subtotal)
+	assert.Equal(t, "T1234567890123", sale.InvoiceIssuer.RegistrationNumber)
+}
+
+func TestCreate_Promotions(t *testing.T) {
+	mockSaleRepo := new(MockSaleRepository)
+	mockProductRepo := new(MockProductRepository)
+	service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(tax.DefaultConfig()), newTestLoyaltyService(mockSaleRepo), nil, time.UTC)
+	ctx := context.Background()
+
+	foodID := primitive.NewObjectID()
+	otherID := primitive.NewObjectID()
+	mockProductRepo.On("GetByID", ctx, foodID).Return(&models.Product{
+		ID:       foodID,
+		Name:     "国産りんご",
+		TaxClass: models.TaxClassReduced,
+	}, nil)
+	mockSaleRepo.On("Create", ctx, mock.AnythingOfType("*models.Sale")).Return(nil)
+
+	tests := []struct {
+		name       string
+		promotions []models.AppliedPromotion
+		wantErr    bool
+		wantTotal  float64
+	}{
+		{
+			name: "商品値引きは対象商品の税率から差し引く",
+			promotions: []models.AppliedPromotion{
+				{Code: "MORNING", Name: "朝市セール", Discount: 108, ProductID: &foodID},
+			},
+			wantTotal: 972,
+		},
+		{
+			name: "売上に含まれない商品への値引きはエラー",
+			promotions: []models.AppliedPromotion{
+				{Name: "朝市セール", Discount: 100, ProductID: &otherID},
+			},
+			wantErr: true,
+		},
+		{
+			name: "売上金額を超える値引きはエラー",
+			promotions: []models.AppliedPromotion{
+				{Name: "全品無料", Discount: 2000},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sale := &models.Sale{
+				Items: []models.SaleItem{
+					{ProductID: foodID, Quantity: 10, PriceAtSale: 108},
+				},
+				Promotions:    tt.promotions,
+				PaymentMethod: "cash",
+			}
+			err := service.Create(ctx, sale)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "国産りんご", sale.Items[0].Name)
+			assert.Equal(t, models.TaxClassReduced, sale.Promotions[0].TaxClass)
+			assert.Equal(t, tt.wantTotal, sale.TotalAmount)
+			assert.Equal(t, 72.0, sale.TotalTax)
+		})
+	}
+}
+
+func TestCreate_SaleLevelPromotion(t *testing.T) {
+	mockSaleRepo := new(MockSaleRepository)
+	mockProductRepo := new(MockProductRepository)
+	service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(tax.DefaultConfig()), newTestLoyaltyService(mockSaleRepo), nil, time.UTC)
+	ctx := context.Background()
+
+	foodID := primitive.NewObjectID()
+	goodsID := primitive.NewObjectID()
+	mockProductRepo.On("GetByID", ctx, foodID).Return(&models.Product{ID: foodID, TaxClass: models.TaxClassReduced}, nil)
+	mockProductRepo.On("GetByID", ctx, goodsID).Return(&models.Product{ID: goodsID, TaxClass: models.TaxClassStandard}, nil)
+	mockSaleRepo.On("Create", ctx, mock.AnythingOfType("*models.Sale")).Return(nil)
+
+	food := models.SaleItem{ProductID: foodID, Quantity: 2, PriceAtSale: 216}
+	goods := models.SaleItem{ProductID: goodsID, Quantity: 1, PriceAtSale: 330}
+
+	tests := []struct {
+		name          string
+		items         []models.SaleItem
+		promotion     models.AppliedPromotion
+		wantErr       bool
+		wantBreakdown map[models.TaxClass]float64
+	}{
+		{
+			name:          "軽減税率のみの売上では軽減税率から差し引く",
+			items:         []models.SaleItem{food},
+			promotion:     models.AppliedPromotion{Name: "会員値引", Discount: 108},
+			wantBreakdown: map[models.TaxClass]float64{models.TaxClassReduced: 324},
+		},
+		{
+			name:      "複数の税区分には金額に応じて按分する",
+			items:     []models.SaleItem{food, goods},
+			promotion: models.AppliedPromotion{Name: "会員値引", Discount: 127},
+			wantBreakdown: map[models.TaxClass]float64{
+				models.TaxClassReduced:  360,
+				models.TaxClassStandard: 275,
+			},
+		},
+		{
+			name:      "未知の税区分はエラー",
+			items:     []models.SaleItem{food, goods},
+			promotion: models.AppliedPromotion{Name: "会員値引", Discount: 100, TaxClass: "luxury"},
+			wantErr:   true,
+		},
+		{
+			name:      "売上に含まれない税区分への値引きはエラー",
+			items:     []models.SaleItem{food},
+			promotion: models.AppliedPromotion{Name: "会員値引", Discount: 100, TaxClass: models.TaxClassStandard},
+			wantErr:   true,
+		},
+		{
+			name:      "税区分の対象額を超える値引きはエラー",
+			items:     []models.SaleItem{food, goods},
+			promotion: models.AppliedPromotion{Name: "日用品値引", Discount: 400, TaxClass: models.TaxClassStandard},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sale := &models.Sale{
+				Items:         append([]models.SaleItem(nil), tt.items...),
+				Promotions:    []models.AppliedPromotion{tt.promotion},
+				PaymentMethod: "cash",
+			}
+			err := service.Create(ctx, sale)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, sale.TaxBreakdown, len(tt.wantBreakdown))
+			var total float64
+			for _, b := range sale.TaxBreakdown {
+				assert.Equal(t, tt.wantBreakdown[b.TaxClass], b.Total, b.TaxClass)
+				total += b.Total
+			}
+			assert.Equal(t, total, sale.TotalAmount)
+		})
+	}
+}
+
 func TestGetDailySales(t *testing.T) {
 	mockSaleRepo := new(MockSaleRepository)
 	mockProductRepo := new(MockProductRepository)
-	service := NewSaleService(mockSaleRepo, mockProductRepo)
+	service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(tax.DefaultConfig()), newTestLoyaltyService(mockSaleRepo), nil, time.UTC)
 	ctx := context.Background()
 
 	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -205,7 +452,7 @@ func TestGetDailySales(t *testing.T) {
 func TestGetSalesByDateRange(t *testing.T) {
 	mockSaleRepo := new(MockSaleRepository)
 	mockProductRepo := new(MockProductRepository)
-	service := NewSaleService(mockSaleRepo, mockProductRepo)
+	service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(tax.DefaultConfig()), newTestLoyaltyService(mockSaleRepo), nil, time.UTC)
 	ctx := context.Background()
 
 	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -273,7 +520,7 @@ func TestGetSalesByDateRange(t *testing.T) {
 func TestGetEnvironmentalImpactAnalytics(t *testing.T) {
 	mockSaleRepo := new(MockSaleRepository)
 	mockProductRepo := new(MockProductRepository)
-	service := NewSaleService(mockSaleRepo, mockProductRepo)
+	service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(tax.DefaultConfig()), newTestLoyaltyService(mockSaleRepo), nil, time.UTC)
 	ctx := context.Background()
 
 	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -333,7 +580,7 @@ func TestGetEnvironmentalImpactAnalytics(t *testing.T) {
 func TestGetSalesByTimeOfDay(t *testing.T) {
 	mockSaleRepo := new(MockSaleRepository)
 	mockProductRepo := new(MockProductRepository)
-	service := NewSaleService(mockSaleRepo, mockProductRepo)
+	service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(tax.DefaultConfig()), newTestLoyaltyService(mockSaleRepo), nil, time.UTC)
 	ctx := context.Background()
 
 	expectedSales := []*models.Sale{
@@ -396,15 +643,15 @@ func TestGetSalesByTimeOfDay(t *testing.T) {
 func TestGetSalesByCategory(t *testing.T) {
 	mockSaleRepo := new(MockSaleRepository)
 	mockProductRepo := new(MockProductRepository)
-	service := NewSaleService(mockSaleRepo, mockProductRepo)
+	service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(tax.DefaultConfig()), newTestLoyaltyService(mockSaleRepo), nil, time.UTC)
 	ctx := context.Background()
 
 	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	end := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
 
-	expectedCategories := map[string]int{
-		"食品": 10,
-		"飲料": 5,
+	expectedCategories := []*models.CategorySales{
+		{Category: "食品", Units: 10, Revenue: 5000},
+		{Category: "飲料", Units: 5, Revenue: 1500},
 	}
 
 	tests := []struct {
@@ -412,7 +659,7 @@ func TestGetSalesByCategory(t *testing.T) {
 		start   time.Time
 		end     time.Time
 		mockFn  func()
-		want    map[string]int
+		want    []*models.CategorySales
 		wantErr bool
 	}{
 		{
@@ -430,9 +677,9 @@ func TestGetSalesByCategory(t *testing.T) {
 			start: start.AddDate(0, 1, 0),
 			end:   end.AddDate(0, 1, 0),
 			mockFn: func() {
-				mockSaleRepo.On("GetSalesByCategory", ctx, start.AddDate(0, 1, 0), end.AddDate(0, 1, 0)).Return(map[string]int{}, nil)
+				mockSaleRepo.On("GetSalesByCategory", ctx, start.AddDate(0, 1, 0), end.AddDate(0, 1, 0)).Return([]*models.CategorySales{}, nil)
 			},
-			want:    map[string]int{},
+			want:    []*models.CategorySales{},
 			wantErr: false,
 		},
 	}
@@ -451,3 +698,343 @@ func TestGetSalesByCategory(t *testing.T) {
 		})
 	}
 }
+
+func TestGetCategorySalesReport(t *testing.T) {
+	mockSaleRepo := new(MockSaleRepository)
+	mockProductRepo := new(MockProductRepository)
+	service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(tax.DefaultConfig()), newTestLoyaltyService(mockSaleRepo), nil, time.UTC)
+	ctx := context.Background()
+
+	start := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	prevStart := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockSaleRepo.On("GetSalesByCategory", ctx, start, end).Return([]*models.CategorySales{
+		{Category: "食品", Units: 30, Revenue: 6000},
+		{Category: "飲料", Units: 20, Revenue: 2000},
+	}, nil)
+	mockSaleRepo.On("GetSalesByCategory", ctx, prevStart, start).Return([]*models.CategorySales{
+		{Category: "食品", Units: 20, Revenue: 4000},
+		{Category: "日用品", Units: 5, Revenue: 1000},
+	}, nil)
+
+	report, err := service.GetCategorySalesReport(ctx, start, end)
+	assert.NoError(t, err)
+	assert.Equal(t, prevStart, report.PreviousStart)
+	assert.Equal(t, start, report.PreviousEnd)
+	assert.Equal(t, 50, report.TotalUnits)
+	assert.Equal(t, 8000.0, report.TotalRevenue)
+	assert.Equal(t, 5000.0, report.PreviousTotalRevenue)
+	assert.Len(t, report.Categories, 3)
+
+	food := report.Categories[0]
+	assert.Equal(t, "食品", food.Category)
+	assert.InDelta(t, 0.75, food.Share, 1e-9)
+	assert.InDelta(t, 0.8, food.PreviousShare, 1e-9)
+	assert.InDelta(t, 0.5, *food.RevenueGrowth, 1e-9)
+
+	drinks := report.Categories[1]
+	assert.Equal(t, "飲料", drinks.Category)
+	assert.Nil(t, drinks.RevenueGrowth)
+
+	daily := report.Categories[2]
+	assert.Equal(t, "日用品", daily.Category)
+	assert.Equal(t, 0.0, daily.Revenue)
+	assert.InDelta(t, -1.0, *daily.RevenueGrowth, 1e-9)
+
+	_, err = service.GetCategorySalesReport(ctx, end, start)
+	assert.Error(t, err)
+}
+
+func TestImportSales(t *testing.T) {
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+	soldAt := time.Date(2024, 4, 1, 9, 15, 0, 0, time.UTC)
+
+	line := func(txID string) string {
+		return `{"terminalTransactionId":"` + txID + `","items":[{"productId":"` + productID.Hex() + `","quantity":1,"priceAtSale":110}],"paymentMethod":"cash","createdAt":"` + soldAt.Format(time.RFC3339) + `"}`
+	}
+
+	t.Run("行ごとの結果と再送開始位置", func(t *testing.T) {
+		mockSaleRepo := new(MockSaleRepository)
+		mockProductRepo := new(MockProductRepository)
+		service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(tax.DefaultConfig()), newTestLoyaltyService(mockSaleRepo), nil, time.UTC)
+
+		mockProductRepo.On("GetByID", ctx, productID).Return(&models.Product{ID: productID, Name: "テスト商品"}, nil)
+		mockSaleRepo.On("CreateMany", ctx, mock.MatchedBy(func(sales []*models.Sale) bool {
+			return len(sales) == 2 && sales[0].TerminalTransactionID == "T-1"
+		})).Return([]error{nil, nil}, nil)
+		mockSaleRepo.On("CreateMany", ctx, mock.MatchedBy(func(sales []*models.Sale) bool {
+			return len(sales) == 1 && sales[0].TerminalTransactionID == "T-1"
+		})).Return([]error{repository.ErrDuplicateTerminalTransaction}, nil)
+
+		body := strings.Join([]string{
+			line("T-1"),
+			`{"items":`,
+			`{"items":[]}`,
+			"",
+			line("T-2"),
+			line("T-1"),
+		}, "\n")
+
+		var results []models.BulkSaleResult
+		summary, err := service.ImportSales(ctx, strings.NewReader(body), ImportSalesOptions{StoreID: "store-1", BatchSize: 2}, func(r models.BulkSaleResult) error {
+			results = append(results, r)
+			return nil
+		})
+		assert.NoError(t, err)
+
+		var lines []int
+		var statuses []models.BulkSaleStatus
+		for _, r := range results {
+			lines = append(lines, r.Line)
+			statuses = append(statuses, r.Status)
+		}
+		assert.Equal(t, []int{1, 2, 3, 5, 6}, lines)
+		assert.Equal(t, []models.BulkSaleStatus{
+			models.BulkSaleCreated,
+			models.BulkSaleInvalid,
+			models.BulkSaleInvalid,
+			models.BulkSaleCreated,
+			models.BulkSaleDuplicate,
+		}, statuses)
+		assert.Equal(t, &models.BulkSaleSummary{
+			Received:             5,
+			Created:              2,
+			Duplicates:           1,
+			Invalid:              2,
+			LastAcknowledgedLine: 6,
+		}, summary)
+
+		inserted := mockSaleRepo.Calls[0].Arguments.Get(1).([]*models.Sale)
+		assert.True(t, soldAt.Equal(inserted[0].CreatedAt))
+		assert.Equal(t, "store-1", inserted[0].StoreID)
+		assert.Equal(t, 110.0, inserted[0].TotalAmount)
+	})
+
+	t.Run("書き込みに失敗した行の前から再送できる", func(t *testing.T) {
+		mockSaleRepo := new(MockSaleRepository)
+		mockProductRepo := new(MockProductRepository)
+		service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(tax.DefaultConfig()), newTestLoyaltyService(mockSaleRepo), nil, time.UTC)
+
+		mockProductRepo.On("GetByID", ctx, productID).Return(&models.Product{ID: productID, Name: "テスト商品"}, nil)
+		mockSaleRepo.On("CreateMany", ctx, mock.Anything).Return([]error{nil}, nil).Once()
+		mockSaleRepo.On("CreateMany", ctx, mock.Anything).Return(nil, errors.New("connection reset")).Once()
+
+		body := strings.Join([]string{line("T-11"), line("T-12"), line("T-13")}, "\n")
+
+		var results []models.BulkSaleResult
+		summary, err := service.ImportSales(ctx, strings.NewReader(body), ImportSalesOptions{LineOffset: 10, BatchSize: 1}, func(r models.BulkSaleResult) error {
+			results = append(results, r)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.Equal(t, 11, results[0].Line)
+		assert.Equal(t, models.BulkSaleFailed, results[1].Status)
+		assert.Equal(t, 11, summary.LastAcknowledgedLine)
+		assert.Equal(t, 1, summary.Failed)
+		mockSaleRepo.AssertNumberOfCalls(t, "CreateMany", 2)
+	})
+}
+
+func TestGetSalesHeatmap(t *testing.T) {
+	ctx := context.Background()
+	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
+	mockSaleRepo := new(MockSaleRepository)
+	service := NewSaleService(mockSaleRepo, new(MockProductRepository), tax.NewCalculator(tax.DefaultConfig()), newTestLoyaltyService(mockSaleRepo), nil, jst)
+
+	// 2024-01-01（月）〜01-14（日）: 各曜日2日ずつ
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)
+
+	var hourly []*models.HourlySales
+	for dow := 1; dow <= 7; dow++ {
+		for hour := 8; hour <= 20; hour++ {
+			tx := 10
+			switch {
+			case dow == 2 && (hour == 12 || hour == 13): // 月曜の昼
+				tx = 40
+			case dow == 7 && hour == 15: // 土曜の午後
+				tx = 50
+			}
+			hourly = append(hourly, &models.HourlySales{DayOfWeek: dow, Hour: hour, Transactions: tx, Revenue: float64(tx) * 500})
+		}
+	}
+	query := models.SaleQuery{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, jst),
+		End:   time.Date(2024, 1, 15, 0, 0, 0, 0, jst),
+	}
+	mockSaleRepo.On("GetHourlySales", ctx, query, "Asia/Tokyo").Return(hourly, nil)
+
+	heatmap, err := service.GetSalesHeatmap(ctx, "", start, end)
+	assert.NoError(t, err)
+	assert.Equal(t, [7]int{2, 2, 2, 2, 2, 2, 2}, heatmap.DayCounts)
+
+	monday := heatmap.Cells[0]
+	assert.Equal(t, 40, monday[12].Transactions)
+	assert.Equal(t, 20.0, monday[12].AverageDailyTransactions)
+	assert.Equal(t, 500.0, monday[12].AverageBasket)
+	assert.True(t, monday[12].Peak)
+	assert.False(t, monday[11].Peak)
+	assert.Equal(t, 0, monday[3].Transactions)
+	assert.Equal(t, 1.0, heatmap.Cells[5][15].Intensity)
+	assert.Equal(t, 0.2, heatmap.Cells[6][8].Intensity)
+
+	if assert.Len(t, heatmap.Peaks, 2) {
+		assert.Equal(t, models.PeakPeriod{
+			Weekday:                  "Monday",
+			StartHour:                12,
+			EndHour:                  14,
+			Revenue:                  40000,
+			Transactions:             80,
+			AverageDailyTransactions: 40,
+			RevenueShare:             40000 / heatmap.TotalRevenue,
+		}, heatmap.Peaks[0])
+		assert.Equal(t, "Saturday", heatmap.Peaks[1].Weekday)
+		assert.Equal(t, 15, heatmap.Peaks[1].StartHour)
+		assert.Equal(t, 16, heatmap.Peaks[1].EndHour)
+	}
+
+	_, err = service.GetSalesHeatmap(ctx, "", end, start)
+	assert.Error(t, err)
+}
+
+func TestGetMarginReport(t *testing.T) {
+	ctx := context.Background()
+	coffee := &models.Product{ID: primitive.NewObjectID(), Name: "コーヒー", Category: "飲料", CostPrice: 650}
+	sandwich := &models.Product{ID: primitive.NewObjectID(), Name: "サンドイッチ", Category: "食品", CostPrice: 300, TaxClass: models.TaxClassReduced}
+	promoTarget := coffee.ID
+
+	sales := []*models.Sale{
+		{
+			ID: primitive.NewObjectID(),
+			Items: []models.SaleItem{
+				// 原価は販売時点のスナップショット（現在の商品マスタは650円）
+				{ProductID: coffee.ID, Name: "コーヒー", Category: "飲料", Quantity: 2, PriceAtSale: 1100, TaxClass: models.TaxClassStandard, CostAtSale: 600},
+				// 原価が保存されていない過去の売上は商品マスタから補完
+				{ProductID: sandwich.ID, Name: "サンドイッチ", Category: "食品", Quantity: 1, PriceAtSale: 540, TaxClass: models.TaxClassReduced},
+			},
+			CreatedAt: time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			ID: primitive.NewObjectID(),
+			Items: []models.SaleItem{
+				{ProductID: coffee.ID, Name: "コーヒー", Category: "飲料", Quantity: 1, PriceAtSale: 1100, TaxClass: models.TaxClassStandard, CostAtSale: 600},
+			},
+			Promotions: []models.AppliedPromotion{
+				{Code: "SPRING", Name: "春のコーヒーフェア", Discount: 110, ProductID: &promoTarget, TaxClass: models.TaxClassStandard},
+			},
+			CreatedAt: time.Date(2024, 3, 12, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		groupBy  models.MarginGroupBy
+		interval models.MarginInterval
+		want     []models.MarginRow
+	}{
+		{
+			name:    "商品別",
+			groupBy: models.MarginByProduct,
+			want: []models.MarginRow{
+				{Key: coffee.ID.Hex(), Label: "コーヒー", Transactions: 2, Units: 3, Revenue: 2900, Discount: 110, Cost: 1800, GrossMargin: 1100},
+				{Key: sandwich.ID.Hex(), Label: "サンドイッチ", Transactions: 1, Units: 1, Revenue: 500, Cost: 300, GrossMargin: 200},
+			},
+		},
+		{
+			name:    "カテゴリ別",
+			groupBy: models.MarginByCategory,
+			want: []models.MarginRow{
+				{Key: "飲料", Label: "飲料", Transactions: 2, Units: 3, Revenue: 2900, Discount: 110, Cost: 1800, GrossMargin: 1100},
+				{Key: "食品", Label: "食品", Transactions: 1, Units: 1, Revenue: 500, Cost: 300, GrossMargin: 200},
+			},
+		},
+		{
+			name:     "週別",
+			groupBy:  models.MarginByPeriod,
+			interval: models.MarginIntervalWeek,
+			want: []models.MarginRow{
+				{Key: "2024-03-04", Label: "2024-03-04", Transactions: 1, Units: 3, Revenue: 2500, Cost: 1500, GrossMargin: 1000},
+				{Key: "2024-03-11", Label: "2024-03-11", Transactions: 1, Units: 1, Revenue: 900, Discount: 110, Cost: 600, GrossMargin: 300},
+			},
+		},
+		{
+			name:    "プロモーション別",
+			groupBy: models.MarginByPromotion,
+			want: []models.MarginRow{
+				{Key: "", Label: "プロモーションなし", Transactions: 1, Units: 3, Revenue: 2500, Cost: 1500, GrossMargin: 1000},
+				{Key: "SPRING", Label: "春のコーヒーフェア", Transactions: 1, Units: 1, Revenue: 900, Discount: 110, Cost: 600, GrossMargin: 300},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSaleRepo := new(MockSaleRepository)
+			mockProductRepo := new(MockProductRepository)
+			service := NewSaleService(mockSaleRepo, mockProductRepo, tax.NewCalculator(tax.DefaultConfig()), newTestLoyaltyService(mockSaleRepo), nil, time.UTC)
+
+			mockSaleRepo.On("StreamSales", ctx, models.SaleQuery{Start: start, End: end.AddDate(0, 0, 1)}, mock.Anything).Return(sales, nil)
+			mockProductRepo.On("GetByID", ctx, sandwich.ID).Return(sandwich, nil).Once()
+
+			report, err := service.GetMarginReport(ctx, MarginQuery{GroupBy: tt.groupBy, Interval: tt.interval, StartDate: start, EndDate: end})
+			assert.NoError(t, err)
+			for i := range tt.want {
+				finishMarginRow(&tt.want[i])
+			}
+			assert.Equal(t, tt.want, report.Rows)
+			assert.Equal(t, 2, report.Total.Transactions)
+			assert.Equal(t, 3400.0, report.Total.Revenue)
+			assert.Equal(t, 1300.0, report.Total.GrossMargin)
+			assert.Equal(t, 0, report.LinesWithoutCost)
+			mockProductRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("無効な集計単位", func(t *testing.T) {
+		service := NewSaleService(new(MockSaleRepository), new(MockProductRepository), tax.NewCalculator(tax.DefaultConfig()), nil, nil, time.UTC)
+		_, err := service.GetMarginReport(ctx, MarginQuery{GroupBy: "store", StartDate: start, EndDate: end})
+		assert.Error(t, err)
+	})
+}
+
+func TestGetDayTypeSales(t *testing.T) {
+	ctx := context.Background()
+	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
+	mockSaleRepo := new(MockSaleRepository)
+	campaign := &models.StoreEvent{Name: "母の日セール", Type: models.StoreEventCampaign, StartDate: "2024-05-07", EndDate: "2024-05-07"}
+	service := NewSaleService(mockSaleRepo, new(MockProductRepository), tax.NewCalculator(tax.DefaultConfig()), nil, newTestCalendarService(jst, campaign), jst)
+
+	// 2024-05-01（水）〜05-07（火）: 05-03〜05-06は祝日（05-04, 05-05は土日と重なる）
+	start := time.Date(2024, time.May, 1, 0, 0, 0, 0, jst)
+	query := models.SaleQuery{Start: start, End: start.AddDate(0, 0, 7)}
+	mockSaleRepo.On("GetDailyRevenue", ctx, query, jst.String()).Return([]*models.DailyRevenue{
+		{Date: "2024-05-01", Revenue: 100000, Transactions: 100},
+		{Date: "2024-05-02", Revenue: 100000, Transactions: 100},
+		{Date: "2024-05-03", Revenue: 200000, Transactions: 150},
+		{Date: "2024-05-04", Revenue: 200000, Transactions: 150},
+		{Date: "2024-05-05", Revenue: 200000, Transactions: 150},
+		{Date: "2024-05-06", Revenue: 200000, Transactions: 150},
+		{Date: "2024-05-07", Revenue: 300000, Transactions: 200},
+	}, nil)
+
+	report, err := service.GetDayTypeSales(ctx, "", start, start.AddDate(0, 0, 6))
+	assert.NoError(t, err)
+	assert.Equal(t, 7, report.Days)
+	assert.Equal(t, 1300000.0, report.Revenue)
+
+	rows := map[models.DayType]models.DayTypeSales{}
+	for _, r := range report.Rows {
+		rows[r.DayType] = r
+	}
+	assert.Len(t, rows, 3)
+	assert.Equal(t, 2, rows[models.DayTypeWeekday].Days)
+	assert.Equal(t, 4, rows[models.DayTypeHoliday].Days)
+	assert.Equal(t, 200000.0, rows[models.DayTypeHoliday].AverageDailyRevenue)
+	assert.Equal(t, 300000.0, rows[models.DayTypeEvent].Revenue)
+	assert.InDelta(t, 300000.0/(1300000.0/7), rows[models.DayTypeEvent].Index, 1e-9)
+}
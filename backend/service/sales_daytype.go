@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// GetDayTypeSales は期間（終了日を含む）の日別売上を、平日・週末・祝日・イベント・休業日の種類別に集計します
+// 売上のない日も日数に含め、種類ごとの1日あたりの売上を期間全体の平均と比較します
+func (ss *SaleService) GetDayTypeSales(ctx context.Context, storeID string, startDate, endDate time.Time) (*models.DayTypeSalesReport, error) {
+	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, ss.location)
+	end := time.Date(endDate.Year(), endDate.Month(), endDate.Day()+1, 0, 0, 0, 0, ss.location)
+
+	cal, err := ss.calendar.StoreCalendar(ctx, storeID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	daily, err := ss.repo.GetDailyRevenue(ctx, models.SaleQuery{
+		StoreID: storeID,
+		Start:   start,
+		End:     end,
+	}, ss.location.String())
+	if err != nil {
+		return nil, err
+	}
+	byDate := make(map[string]*models.DailyRevenue, len(daily))
+	for _, d := range daily {
+		byDate[d.Date] = d
+	}
+
+	report := &models.DayTypeSalesReport{
+		StoreID:   storeID,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   endDate.Format("2006-01-02"),
+		Rows:      []models.DayTypeSales{},
+	}
+	rows := make(map[models.DayType]*models.DayTypeSales, len(models.DayTypes))
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		day := cal.Day(d)
+		row, ok := rows[day.DayType]
+		if !ok {
+			row = &models.DayTypeSales{DayType: day.DayType}
+			rows[day.DayType] = row
+		}
+		row.Days++
+		report.Days++
+		if sales, ok := byDate[day.Date]; ok {
+			row.Revenue += sales.Revenue
+			row.Transactions += sales.Transactions
+			report.Revenue += sales.Revenue
+		}
+	}
+
+	var average float64
+	if report.Days > 0 {
+		average = report.Revenue / float64(report.Days)
+	}
+	for _, dayType := range models.DayTypes {
+		row, ok := rows[dayType]
+		if !ok {
+			continue
+		}
+		row.AverageDailyRevenue = row.Revenue / float64(row.Days)
+		if average > 0 {
+			row.Index = row.AverageDailyRevenue / average
+		}
+		report.Rows = append(report.Rows, *row)
+	}
+	return report, nil
+}
+
+// dayTypeClassifier は期間の営業カレンダーから、売上日時の日の種類を判定する関数を返します
+func (ss *SaleService) dayTypeClassifier(ctx context.Context, storeID string, start, end time.Time) (func(t time.Time) models.DayType, error) {
+	cal, err := ss.calendar.StoreCalendar(ctx, storeID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	days := map[string]models.DayType{}
+	return func(t time.Time) models.DayType {
+		local := t.In(ss.location)
+		key := local.Format("2006-01-02")
+		dayType, ok := days[key]
+		if !ok {
+			dayType = cal.Day(local).DayType
+			days[key] = dayType
+		}
+		return dayType
+	}, nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/onoderaryou/smart-store-admin/backend/anomaly"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// heatmapWeekdays はヒートマップの行の並び（月曜始まり）です
+var heatmapWeekdays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// heatmapPeakZ はピークと判定するロバストzスコアのしきい値です
+// 売上のある時間帯の1日あたりの取引件数の分布で、中央値より標準偏差の推定値1つ分以上多い時間帯をピークとします
+const heatmapPeakZ = 1.0
+
+// heatmapMinCells はピークを判定するのに必要な売上のある時間帯の数です
+const heatmapMinCells = 4
+
+// GetSalesHeatmap は曜日×時間帯の売上・取引件数・平均客単価を集計し、混雑する区間を検出します
+// 期間は店舗のタイムゾーンでの日付で、終了日を含みます
+func (ss *SaleService) GetSalesHeatmap(ctx context.Context, storeID string, startDate, endDate time.Time) (*models.SalesHeatmap, error) {
+	if endDate.Before(startDate) {
+		return nil, errors.New("終了日は開始日以降の日付を指定してください")
+	}
+
+	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, ss.location)
+	end := time.Date(endDate.Year(), endDate.Month(), endDate.Day()+1, 0, 0, 0, 0, ss.location)
+	hourly, err := ss.repo.GetHourlySales(ctx, models.SaleQuery{
+		StoreID: storeID,
+		Start:   start,
+		End:     end,
+	}, ss.location.String())
+	if err != nil {
+		return nil, err
+	}
+
+	heatmap := &models.SalesHeatmap{
+		StoreID:   storeID,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   endDate.Format("2006-01-02"),
+		Timezone:  ss.location.String(),
+		Weekdays:  heatmapWeekdays,
+		Peaks:     []models.PeakPeriod{},
+	}
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		heatmap.DayCounts[weekdayIndex(d.Weekday())]++
+	}
+
+	for _, h := range hourly {
+		if h.DayOfWeek < 1 || h.DayOfWeek > 7 || h.Hour < 0 || h.Hour > 23 {
+			continue
+		}
+		day := weekdayIndex(time.Weekday(h.DayOfWeek - 1))
+		cell := &heatmap.Cells[day][h.Hour]
+		cell.Revenue += h.Revenue
+		cell.Transactions += h.Transactions
+		heatmap.TotalRevenue += h.Revenue
+		heatmap.TotalTransactions += h.Transactions
+	}
+	if heatmap.TotalTransactions > 0 {
+		heatmap.AverageBasket = heatmap.TotalRevenue / float64(heatmap.TotalTransactions)
+	}
+
+	var maxDailyRevenue float64
+	var activity []float64
+	for day := range heatmap.Cells {
+		for hour := range heatmap.Cells[day] {
+			cell := &heatmap.Cells[day][hour]
+			if cell.Transactions == 0 {
+				continue
+			}
+			cell.AverageBasket = cell.Revenue / float64(cell.Transactions)
+			if days := heatmap.DayCounts[day]; days > 0 {
+				cell.AverageDailyRevenue = cell.Revenue / float64(days)
+				cell.AverageDailyTransactions = float64(cell.Transactions) / float64(days)
+			}
+			if cell.AverageDailyRevenue > maxDailyRevenue {
+				maxDailyRevenue = cell.AverageDailyRevenue
+			}
+			activity = append(activity, cell.AverageDailyTransactions)
+		}
+	}
+	if maxDailyRevenue > 0 {
+		for day := range heatmap.Cells {
+			for hour := range heatmap.Cells[day] {
+				cell := &heatmap.Cells[day][hour]
+				cell.Intensity = cell.AverageDailyRevenue / maxDailyRevenue
+			}
+		}
+	}
+
+	if len(activity) >= heatmapMinCells {
+		for day := range heatmap.Cells {
+			for hour := range heatmap.Cells[day] {
+				cell := &heatmap.Cells[day][hour]
+				if cell.Transactions > 0 && anomaly.RobustZ(cell.AverageDailyTransactions, activity, 0).Z >= heatmapPeakZ {
+					cell.Peak = true
+				}
+			}
+		}
+		heatmap.Peaks = peakPeriods(heatmap)
+	}
+
+	return heatmap, nil
+}
+
+// peakPeriods は同じ曜日で連続するピークの時間帯をまとめ、売上の大きい順に並べます
+func peakPeriods(heatmap *models.SalesHeatmap) []models.PeakPeriod {
+	peaks := []models.PeakPeriod{}
+	for day := range heatmap.Cells {
+		for hour := 0; hour < 24; hour++ {
+			if !heatmap.Cells[day][hour].Peak {
+				continue
+			}
+			period := models.PeakPeriod{Weekday: heatmapWeekdays[day], StartHour: hour}
+			for ; hour < 24 && heatmap.Cells[day][hour].Peak; hour++ {
+				cell := heatmap.Cells[day][hour]
+				period.Revenue += cell.Revenue
+				period.Transactions += cell.Transactions
+				period.AverageDailyTransactions += cell.AverageDailyTransactions
+			}
+			period.EndHour = hour
+			if heatmap.TotalRevenue > 0 {
+				period.RevenueShare = period.Revenue / heatmap.TotalRevenue
+			}
+			peaks = append(peaks, period)
+		}
+	}
+
+	sort.SliceStable(peaks, func(i, j int) bool {
+		return peaks[i].Revenue > peaks[j].Revenue
+	})
+	return peaks
+}
+
+// weekdayIndex は曜日を月曜=0〜日曜=6の行番号に変換します
+func weekdayIndex(w time.Weekday) int {
+	return (int(w) + 6) % 7
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/export"
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/tax"
+)
+
+// noPromotionLabel はプロモーションを適用していない売上の集計キーです
+const noPromotionLabel = "プロモーションなし"
+
+// MarginQuery は粗利レポートの条件です
+type MarginQuery struct {
+	GroupBy models.MarginGroupBy
+	// Interval は期間別の場合の区切りです（未指定の場合は日別）
+	Interval  models.MarginInterval
+	StoreID   string
+	StartDate time.Time
+	EndDate   time.Time
+	// DayType を指定した場合はその種類の日の売上のみを集計します
+	DayType models.DayType
+}
+
+// Validate は粗利レポートの条件を検証します
+func (q *MarginQuery) Validate() error {
+	switch q.GroupBy {
+	case models.MarginByProduct, models.MarginByCategory, models.MarginByPeriod, models.MarginByPromotion, models.MarginByDayType:
+	default:
+		return errors.New("集計単位は product, category, period, promotion, dayType のいずれかを指定してください")
+	}
+	if q.DayType != "" && !models.ValidateDayType(q.DayType) {
+		return errors.New("日の種類は weekday, weekend, holiday, event, closed のいずれかを指定してください")
+	}
+	switch q.Interval {
+	case "", models.MarginIntervalDay, models.MarginIntervalWeek, models.MarginIntervalMonth:
+	default:
+		return errors.New("期間の区切りは day, week, month のいずれかを指定してください")
+	}
+	if q.StartDate.IsZero() || q.EndDate.IsZero() {
+		return errors.New("対象期間を指定してください")
+	}
+	if q.EndDate.Before(q.StartDate) {
+		return errors.New("終了日は開始日以降の日付を指定してください")
+	}
+	return nil
+}
+
+// marginLine は明細1行分の粗利の計算結果です
+type marginLine struct {
+	productID primitive.ObjectID
+	name      string
+	category  string
+	units     int
+	revenue   float64
+	discount  float64
+	cost      float64
+}
+
+// GetMarginReport は商品・カテゴリ・期間・プロモーション別の粗利を集計します
+// 売上は値引きを按分した後の税抜金額、原価は販売時点のスナップショット（過去の売上は商品マスタ）を使います
+// プロモーション別では、プロモーションを適用した売上全体を集計します（複数適用した売上はそれぞれに計上）
+// 日の種類の判定には指定店舗（未指定の場合は全店舗共通）の営業カレンダーを使います
+func (ss *SaleService) GetMarginReport(ctx context.Context, query MarginQuery) (*models.MarginReport, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	if query.GroupBy == models.MarginByPeriod && query.Interval == "" {
+		query.Interval = models.MarginIntervalDay
+	}
+	if query.GroupBy != models.MarginByPeriod {
+		query.Interval = ""
+	}
+
+	start := time.Date(query.StartDate.Year(), query.StartDate.Month(), query.StartDate.Day(), 0, 0, 0, 0, ss.location)
+	end := time.Date(query.EndDate.Year(), query.EndDate.Month(), query.EndDate.Day()+1, 0, 0, 0, 0, ss.location)
+
+	var dayTypeOf func(time.Time) models.DayType
+	if query.GroupBy == models.MarginByDayType || query.DayType != "" {
+		var err error
+		if dayTypeOf, err = ss.dayTypeClassifier(ctx, query.StoreID, start, end); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &models.MarginReport{
+		GroupBy:   query.GroupBy,
+		Interval:  query.Interval,
+		DayType:   query.DayType,
+		StoreID:   query.StoreID,
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   query.EndDate.Format("2006-01-02"),
+		Total:     models.MarginRow{Key: "total", Label: "合計"},
+		Rows:      []models.MarginRow{},
+	}
+	rows := map[string]*models.MarginRow{}
+	products := map[primitive.ObjectID]*models.Product{}
+
+	err := ss.repo.StreamSales(ctx, models.SaleQuery{
+		StoreID: query.StoreID,
+		Start:   start,
+		End:     end,
+	}, func(sale *models.Sale) error {
+		if query.DayType != "" && dayTypeOf(sale.CreatedAt) != query.DayType {
+			return nil
+		}
+		lines := ss.marginLines(ctx, sale, products, &report.LinesWithoutCost)
+
+		report.Total.Transactions++
+		for _, line := range lines {
+			addMarginLine(&report.Total, line)
+		}
+
+		// 売上ごとの取引件数は、その売上が含まれる行に1回だけ数えます
+		counted := map[string]bool{}
+		row := func(key, label string) *models.MarginRow {
+			r, ok := rows[key]
+			if !ok {
+				r = &models.MarginRow{Key: key, Label: label}
+				rows[key] = r
+			}
+			if !counted[key] {
+				r.Transactions++
+				counted[key] = true
+			}
+			return r
+		}
+
+		switch query.GroupBy {
+		case models.MarginByProduct:
+			for _, line := range lines {
+				addMarginLine(row(line.productID.Hex(), line.name), line)
+			}
+		case models.MarginByCategory:
+			for _, line := range lines {
+				addMarginLine(row(line.category, line.category), line)
+			}
+		case models.MarginByPeriod:
+			key := marginPeriod(sale.CreatedAt.In(ss.location), query.Interval)
+			r := row(key, key)
+			for _, line := range lines {
+				addMarginLine(r, line)
+			}
+		case models.MarginByDayType:
+			key := string(dayTypeOf(sale.CreatedAt))
+			r := row(key, key)
+			for _, line := range lines {
+				addMarginLine(r, line)
+			}
+		case models.MarginByPromotion:
+			if len(sale.Promotions) == 0 {
+				r := row("", noPromotionLabel)
+				for _, line := range lines {
+					addMarginLine(r, line)
+				}
+				break
+			}
+			for _, promo := range sale.Promotions {
+				key := firstNonEmpty(promo.Code, promo.Name)
+				if counted[key] {
+					rows[key].Discount += promo.Discount
+					continue
+				}
+				r := row(key, promo.Name)
+				for _, line := range lines {
+					line.discount = 0
+					addMarginLine(r, line)
+				}
+				r.Discount += promo.Discount
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range rows {
+		report.Rows = append(report.Rows, *r)
+	}
+	for i := range report.Rows {
+		finishMarginRow(&report.Rows[i])
+	}
+	finishMarginRow(&report.Total)
+
+	// 期間別は古い順、それ以外は粗利の大きい順に並べます
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if query.GroupBy != models.MarginByPeriod && a.GrossMargin != b.GrossMargin {
+			return a.GrossMargin > b.GrossMargin
+		}
+		return a.Key < b.Key
+	})
+	return report, nil
+}
+
+// marginLines は売上の明細ごとに値引後の税抜売上と原価を計算します
+func (ss *SaleService) marginLines(ctx context.Context, sale *models.Sale, products map[primitive.ObjectID]*models.Product, withoutCost *int) []marginLine {
+	rows := make([]*export.Row, len(sale.Items))
+	lines := make([]marginLine, len(sale.Items))
+	for i, item := range sale.Items {
+		line := marginLine{
+			productID: item.ProductID,
+			name:      item.Name,
+			category:  item.Category,
+			units:     item.Quantity,
+		}
+		unitCost := item.CostAtSale
+		taxClass := item.TaxClass
+		if unitCost == 0 || line.name == "" || line.category == "" || taxClass == "" {
+			if p := ss.cachedProduct(ctx, item.ProductID, products); p != nil {
+				if unitCost == 0 {
+					unitCost = p.CostPrice
+				}
+				line.name = firstNonEmpty(line.name, p.Name)
+				line.category = firstNonEmpty(line.category, p.Category)
+				if taxClass == "" {
+					taxClass = p.TaxClass
+				}
+			}
+		}
+		if line.category == "" {
+			line.category = repository.UncategorizedLabel
+		}
+		if taxClass == "" {
+			taxClass = models.TaxClassStandard
+		}
+		if unitCost == 0 {
+			*withoutCost++
+		}
+		line.cost = unitCost * float64(item.Quantity)
+
+		rows[i] = &export.Row{
+			ProductID: item.ProductID.Hex(),
+			Amount:    item.PriceAtSale * float64(item.Quantity),
+			TaxClass:  string(taxClass),
+		}
+		lines[i] = line
+	}
+
+	allocateDiscounts(rows, sale.Promotions)
+
+	for i, row := range rows {
+		result := ss.taxCalc.Calculate([]tax.Line{{Class: models.TaxClass(row.TaxClass), Amount: row.Amount - row.Discount}})
+		lines[i].revenue = result.Subtotal
+		lines[i].discount = row.Discount
+	}
+	return lines
+}
+
+// cachedProduct は商品マスタを取得します（同じレポート内ではキャッシュを使います）
+func (ss *SaleService) cachedProduct(ctx context.Context, id primitive.ObjectID, cache map[primitive.ObjectID]*models.Product) *models.Product {
+	if p, ok := cache[id]; ok {
+		return p
+	}
+	p, err := ss.productRepo.GetByID(ctx, id)
+	if err != nil {
+		p = nil
+	}
+	cache[id] = p
+	return p
+}
+
+func addMarginLine(row *models.MarginRow, line marginLine) {
+	row.Units += line.units
+	row.Revenue += line.revenue
+	row.Discount += line.discount
+	row.Cost += line.cost
+}
+
+func finishMarginRow(row *models.MarginRow) {
+	row.GrossMargin = row.Revenue - row.Cost
+	if row.Revenue != 0 {
+		row.MarginRate = row.GrossMargin / row.Revenue
+	}
+}
+
+// marginPeriod は売上日時を期間別の集計キー（日: 2006-01-02, 週: 月曜日の日付, 月: 2006-01）に変換します
+func marginPeriod(t time.Time, interval models.MarginInterval) string {
+	switch interval {
+	case models.MarginIntervalWeek:
+		return t.AddDate(0, 0, -weekdayIndex(t.Weekday())).Format("2006-01-02")
+	case models.MarginIntervalMonth:
+		return t.Format("2006-01")
+	default:
+		return t.Format("2006-01-02")
+	}
+}
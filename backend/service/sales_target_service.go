@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+// ErrSalesTargetNotFound は売上目標が存在しない場合のエラーです
+var ErrSalesTargetNotFound = errors.New("sales target not found")
+
+// SalesTargetRequest は売上目標の登録内容です
+type SalesTargetRequest struct {
+	StoreID    string                  `json:"storeId"`
+	Category   string                  `json:"category"`
+	PeriodType models.TargetPeriodType `json:"periodType"`
+	// Period は日次目標の場合 2006-01-02、月次目標の場合 2006-01 形式です
+	Period    string  `json:"period"`
+	Amount    float64 `json:"amount"`
+	CreatedBy string  `json:"createdBy"`
+}
+
+// Validate は売上目標の登録内容を検証します
+func (r *SalesTargetRequest) Validate() error {
+	if strings.TrimSpace(r.StoreID) == "" {
+		return errors.New("店舗IDを指定してください")
+	}
+	if _, _, err := targetPeriod(r.PeriodType, r.Period, time.UTC); err != nil {
+		return err
+	}
+	if r.Amount <= 0 {
+		return errors.New("目標金額は0より大きい値を指定してください")
+	}
+	return nil
+}
+
+// targetPeriod は期間の文字列を店舗のタイムゾーンでの開始・終了日時（終了を含まない）に変換します
+func targetPeriod(periodType models.TargetPeriodType, period string, location *time.Location) (time.Time, time.Time, error) {
+	switch periodType {
+	case models.TargetDaily:
+		d, err := time.ParseInLocation("2006-01-02", period, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("日次目標の期間は 2006-01-02 形式で指定してください")
+		}
+		return d, d.AddDate(0, 0, 1), nil
+	case models.TargetMonthly:
+		m, err := time.ParseInLocation("2006-01", period, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("月次目標の期間は 2006-01 形式で指定してください")
+		}
+		return m, m.AddDate(0, 1, 0), nil
+	}
+	return time.Time{}, time.Time{}, errors.New("期間の単位は daily または monthly を指定してください")
+}
+
+// SalesTargetServiceInterface は売上目標サービスのインターフェースを定義します
+type SalesTargetServiceInterface interface {
+	SetTarget(ctx context.Context, req *SalesTargetRequest) (*models.SalesTarget, error)
+	ListTargets(ctx context.Context, query models.SalesTargetQuery) ([]*models.SalesTarget, error)
+	DeleteTarget(ctx context.Context, id primitive.ObjectID) error
+	GetProgress(ctx context.Context, id primitive.ObjectID, date time.Time) (*models.TargetProgress, error)
+	GetStoreProgress(ctx context.Context, storeID string, date time.Time) ([]*models.TargetProgress, error)
+}
+
+// SalesTargetService は店舗の売上目標と予実を管理するサービスです
+type SalesTargetService struct {
+	repo     repository.SalesTargetRepository
+	saleRepo repository.SaleRepository
+	location *time.Location
+	now      func() time.Time
+}
+
+func NewSalesTargetService(repo repository.SalesTargetRepository, saleRepo repository.SaleRepository, location *time.Location) *SalesTargetService {
+	return &SalesTargetService{
+		repo:     repo,
+		saleRepo: saleRepo,
+		location: location,
+		now:      time.Now,
+	}
+}
+
+// SetTarget は売上目標を登録します（同じ店舗・カテゴリ・期間の目標は上書きします）
+func (s *SalesTargetService) SetTarget(ctx context.Context, req *SalesTargetRequest) (*models.SalesTarget, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	start, end, err := targetPeriod(req.PeriodType, req.Period, s.location)
+	if err != nil {
+		return nil, err
+	}
+
+	target := &models.SalesTarget{
+		StoreID:     req.StoreID,
+		Category:    strings.TrimSpace(req.Category),
+		PeriodType:  req.PeriodType,
+		Period:      req.Period,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Amount:      req.Amount,
+		CreatedBy:   req.CreatedBy,
+	}
+	if err := s.repo.Upsert(ctx, target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// ListTargets は条件に合う売上目標を取得します
+func (s *SalesTargetService) ListTargets(ctx context.Context, query models.SalesTargetQuery) ([]*models.SalesTarget, error) {
+	return s.repo.List(ctx, query)
+}
+
+// DeleteTarget は売上目標を削除します
+func (s *SalesTargetService) DeleteTarget(ctx context.Context, id primitive.ObjectID) error {
+	deleted, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSalesTargetNotFound
+	}
+	return nil
+}
+
+// GetProgress は売上目標の予実を取得します
+// date を指定した場合はその日の終わり時点、未指定の場合は現在時点の実績で計算します
+func (s *SalesTargetService) GetProgress(ctx context.Context, id primitive.ObjectID, date time.Time) (*models.TargetProgress, error) {
+	target, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, ErrSalesTargetNotFound
+	}
+	return s.progress(ctx, target, s.asOf(date))
+}
+
+// GetStoreProgress は指定日（未指定の場合は当日）を含む店舗の日次・月次目標の予実を取得します
+func (s *SalesTargetService) GetStoreProgress(ctx context.Context, storeID string, date time.Time) ([]*models.TargetProgress, error) {
+	if date.IsZero() {
+		date = s.now().In(s.location)
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, s.location)
+
+	targets, err := s.repo.List(ctx, models.SalesTargetQuery{StoreID: storeID, At: day})
+	if err != nil {
+		return nil, err
+	}
+
+	asOf := s.asOf(date)
+	progress := make([]*models.TargetProgress, 0, len(targets))
+	for _, target := range targets {
+		p, err := s.progress(ctx, target, asOf)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, p)
+	}
+	return progress, nil
+}
+
+// asOf は予実を計算する時点です（指定日の終わり。ただし現在より後にはしない）
+func (s *SalesTargetService) asOf(date time.Time) time.Time {
+	now := s.now()
+	if date.IsZero() {
+		return now
+	}
+	end := time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, s.location)
+	if end.After(now) {
+		return now
+	}
+	return end
+}
+
+// progress は asOf 時点までの実績から、経過割合に応じたペースと期末の着地見込みを計算します
+// 着地見込みは期間の経過時間に対する実績のペースが期末まで続くものとして求めます
+func (s *SalesTargetService) progress(ctx context.Context, target *models.SalesTarget, asOf time.Time) (*models.TargetProgress, error) {
+	p := &models.TargetProgress{
+		Target:    target,
+		AsOf:      asOf,
+		Remaining: target.Amount,
+	}
+	if !asOf.After(target.PeriodStart) {
+		p.Status = models.TargetUpcoming
+		return p, nil
+	}
+
+	cutoff := asOf
+	if cutoff.After(target.PeriodEnd) {
+		cutoff = target.PeriodEnd
+	}
+	actual, err := s.saleRepo.GetTotalSalesAmount(ctx, models.SaleQuery{
+		StoreID: target.StoreID,
+		Start:   target.PeriodStart,
+		End:     cutoff,
+	}, target.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Actual = actual
+	p.Achievement = actual / target.Amount
+	p.Remaining = math.Max(target.Amount-actual, 0)
+	p.ElapsedRatio = cutoff.Sub(target.PeriodStart).Seconds() / target.PeriodEnd.Sub(target.PeriodStart).Seconds()
+	p.PaceTarget = target.Amount * p.ElapsedRatio
+	p.PaceVariance = actual - p.PaceTarget
+	p.Projected = actual / p.ElapsedRatio
+	p.ProjectedAchievement = p.Projected / target.Amount
+	if remainingDays := target.PeriodEnd.Sub(cutoff).Hours() / 24; remainingDays > 0 {
+		p.RequiredDailyAmount = p.Remaining / remainingDays
+	}
+
+	switch {
+	case actual >= target.Amount:
+		p.Status = models.TargetAchieved
+	case !cutoff.Before(target.PeriodEnd):
+		p.Status = models.TargetMissed
+	case p.Projected >= target.Amount:
+		p.Status = models.TargetOnTrack
+	default:
+		p.Status = models.TargetBehind
+	}
+	return p, nil
+}
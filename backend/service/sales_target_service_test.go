@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+type MockSalesTargetRepository struct {
+	mock.Mock
+}
+
+var _ repository.SalesTargetRepository = (*MockSalesTargetRepository)(nil)
+
+func (m *MockSalesTargetRepository) Upsert(ctx context.Context, target *models.SalesTarget) error {
+	args := m.Called(ctx, target)
+	return args.Error(0)
+}
+
+func (m *MockSalesTargetRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.SalesTarget, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SalesTarget), args.Error(1)
+}
+
+func (m *MockSalesTargetRepository) List(ctx context.Context, query models.SalesTargetQuery) ([]*models.SalesTarget, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]*models.SalesTarget), args.Error(1)
+}
+
+func (m *MockSalesTargetRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestSetTarget(t *testing.T) {
+	ctx := context.Background()
+	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
+	mockRepo := new(MockSalesTargetRepository)
+	service := NewSalesTargetService(mockRepo, new(MockSaleRepository), jst)
+
+	mockRepo.On("Upsert", ctx, mock.AnythingOfType("*models.SalesTarget")).Return(nil)
+
+	target, err := service.SetTarget(ctx, &SalesTargetRequest{
+		StoreID:    "store-1",
+		Category:   " 飲料 ",
+		PeriodType: models.TargetMonthly,
+		Period:     "2024-02",
+		Amount:     500000,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "飲料", target.Category)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, jst), target.PeriodStart)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, jst), target.PeriodEnd)
+
+	invalid := []*SalesTargetRequest{
+		{PeriodType: models.TargetDaily, Period: "2024-02-01", Amount: 1000},
+		{StoreID: "store-1", PeriodType: models.TargetDaily, Period: "2024-02", Amount: 1000},
+		{StoreID: "store-1", PeriodType: "weekly", Period: "2024-02-01", Amount: 1000},
+		{StoreID: "store-1", PeriodType: models.TargetMonthly, Period: "2024-02", Amount: 0},
+	}
+	for _, req := range invalid {
+		_, err := service.SetTarget(ctx, req)
+		assert.Error(t, err)
+	}
+	mockRepo.AssertNumberOfCalls(t, "Upsert", 1)
+}
+
+func TestGetProgress(t *testing.T) {
+	ctx := context.Background()
+	march := &models.SalesTarget{
+		ID:          primitive.NewObjectID(),
+		StoreID:     "store-1",
+		PeriodType:  models.TargetMonthly,
+		Period:      "2024-03",
+		PeriodStart: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		Amount:      310000,
+	}
+
+	tests := []struct {
+		name         string
+		now          time.Time
+		date         time.Time
+		cutoff       time.Time
+		actual       float64
+		wantStatus   models.TargetStatus
+		wantPace     float64
+		wantProject  float64
+		wantRequired float64
+	}{
+		{
+			// 3/10の終わり時点（31日中10日経過）
+			name:         "ペースを上回っている",
+			now:          time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC),
+			date:         time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+			cutoff:       time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+			actual:       120000,
+			wantStatus:   models.TargetOnTrack,
+			wantPace:     100000,
+			wantProject:  372000,
+			wantRequired: 190000.0 / 21,
+		},
+		{
+			name:         "ペースを下回っている",
+			now:          time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC),
+			date:         time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+			cutoff:       time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+			actual:       80000,
+			wantStatus:   models.TargetBehind,
+			wantPace:     100000,
+			wantProject:  248000,
+			wantRequired: 230000.0 / 21,
+		},
+		{
+			// 日付未指定の場合は現在時点
+			name:         "現在時点で達成済み",
+			now:          time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC),
+			cutoff:       time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC),
+			actual:       320000,
+			wantStatus:   models.TargetAchieved,
+			wantPace:     200000,
+			wantProject:  496000,
+			wantRequired: 0,
+		},
+		{
+			name:        "期間終了・未達",
+			now:         time.Date(2024, 4, 5, 0, 0, 0, 0, time.UTC),
+			cutoff:      time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			actual:      300000,
+			wantStatus:  models.TargetMissed,
+			wantPace:    310000,
+			wantProject: 300000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockSalesTargetRepository)
+			mockSaleRepo := new(MockSaleRepository)
+			service := NewSalesTargetService(mockRepo, mockSaleRepo, time.UTC)
+			service.now = func() time.Time { return tt.now }
+
+			mockRepo.On("GetByID", ctx, march.ID).Return(march, nil)
+			mockSaleRepo.On("GetTotalSalesAmount", ctx, models.SaleQuery{
+				StoreID: "store-1",
+				Start:   march.PeriodStart,
+				End:     tt.cutoff,
+			}, "").Return(tt.actual, nil)
+
+			progress, err := service.GetProgress(ctx, march.ID, tt.date)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, progress.Status)
+			assert.Equal(t, tt.actual, progress.Actual)
+			assert.InDelta(t, tt.wantPace, progress.PaceTarget, 1e-6)
+			assert.InDelta(t, tt.actual-tt.wantPace, progress.PaceVariance, 1e-6)
+			assert.InDelta(t, tt.wantProject, progress.Projected, 1e-6)
+			assert.InDelta(t, tt.wantRequired, progress.RequiredDailyAmount, 1e-6)
+		})
+	}
+
+	t.Run("期間開始前", func(t *testing.T) {
+		mockRepo := new(MockSalesTargetRepository)
+		service := NewSalesTargetService(mockRepo, new(MockSaleRepository), time.UTC)
+		service.now = func() time.Time { return time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC) }
+		mockRepo.On("GetByID", ctx, march.ID).Return(march, nil)
+
+		progress, err := service.GetProgress(ctx, march.ID, time.Time{})
+		assert.NoError(t, err)
+		assert.Equal(t, models.TargetUpcoming, progress.Status)
+		assert.Equal(t, march.Amount, progress.Remaining)
+	})
+
+	t.Run("存在しない目標", func(t *testing.T) {
+		mockRepo := new(MockSalesTargetRepository)
+		service := NewSalesTargetService(mockRepo, new(MockSaleRepository), time.UTC)
+		id := primitive.NewObjectID()
+		mockRepo.On("GetByID", ctx, id).Return(nil, nil)
+
+		_, err := service.GetProgress(ctx, id, time.Time{})
+		assert.ErrorIs(t, err, ErrSalesTargetNotFound)
+	})
+}
@@ -0,0 +1,488 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+var (
+	// ErrSlotNotFound は配送時間枠が存在しない場合のエラーです
+	ErrSlotNotFound = errors.New("delivery slot not found")
+	// ErrSlotFull は配送時間枠の受付件数に達している場合のエラーです
+	ErrSlotFull = errors.New("delivery slot is full")
+	// ErrSlotClosed は配送時間枠の開始日時を過ぎて予約を受け付けられない場合のエラーです
+	ErrSlotClosed = errors.New("delivery slot is closed")
+	// ErrReservationNotFound は予約が存在しない場合のエラーです
+	ErrReservationNotFound = errors.New("slot reservation not found")
+	// ErrReservationNotHeld は予約が仮押さえ中でない（確定・取り消し・期限切れ）場合のエラーです
+	ErrReservationNotHeld = errors.New("slot reservation is not held")
+)
+
+// slotTimeLayout はテンプレートの時刻の形式です
+const slotTimeLayout = "15:04"
+
+// SlotConfig は配送時間枠の設定です
+type SlotConfig struct {
+	// HoldTTL は仮押さえの有効期間です
+	HoldTTL time.Duration
+	// DefaultTripTime は配送実績が少ない場合に使う1件あたりの往復時間です
+	DefaultTripTime time.Duration
+	// TripHistory は平均の往復時間を求める過去の期間、MinTripSamples はそれに必要な配送の最小件数です
+	TripHistory    time.Duration
+	MinTripSamples int
+}
+
+// DefaultSlotConfig は既定の設定（仮押さえ10分、往復45分、直近14日間の配送実績）を返します
+func DefaultSlotConfig() SlotConfig {
+	return SlotConfig{
+		HoldTTL:         10 * time.Minute,
+		DefaultTripTime: 45 * time.Minute,
+		TripHistory:     14 * 24 * time.Hour,
+		MinTripSamples:  20,
+	}
+}
+
+// SlotTemplateRequest は曜日の配送時間枠のテンプレートの登録内容です
+type SlotTemplateRequest struct {
+	Windows []models.SlotWindow `json:"windows"`
+}
+
+// Validate は配送時間枠のテンプレートを検証します（枠は重ならないように指定します）
+func (r *SlotTemplateRequest) Validate() error {
+	type span struct{ start, end time.Time }
+	spans := make([]span, 0, len(r.Windows))
+	for _, w := range r.Windows {
+		start, err := time.Parse(slotTimeLayout, w.Start)
+		if err != nil {
+			return errors.New("開始時刻は 15:04 形式で指定してください")
+		}
+		end, err := time.Parse(slotTimeLayout, w.End)
+		if err != nil {
+			return errors.New("終了時刻は 15:04 形式で指定してください")
+		}
+		if !end.After(start) {
+			return errors.New("終了時刻は開始時刻より後を指定してください")
+		}
+		if w.Capacity < 0 {
+			return errors.New("受付件数は0以上の値を指定してください")
+		}
+		spans = append(spans, span{start, end})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+	for i := 1; i < len(spans); i++ {
+		if spans[i].start.Before(spans[i-1].end) {
+			return errors.New("配送時間枠が重なっています")
+		}
+	}
+	return nil
+}
+
+// SlotServiceInterface は配送時間枠の管理と予約を行うサービスのインターフェースを定義します
+type SlotServiceInterface interface {
+	ListTemplates(ctx context.Context) ([]*models.SlotTemplate, error)
+	SetTemplate(ctx context.Context, weekday time.Weekday, req *SlotTemplateRequest) (*models.SlotTemplate, error)
+	GetAvailability(ctx context.Context, start, end time.Time) ([]*models.DeliverySlot, error)
+	Hold(ctx context.Context, slotID primitive.ObjectID) (*models.SlotReservation, error)
+	GetReservation(ctx context.Context, id primitive.ObjectID) (*models.SlotReservation, error)
+	CancelReservation(ctx context.Context, id primitive.ObjectID) error
+}
+
+// SlotService は曜日ごとのテンプレートから配送時間枠を作成し、受付件数を超えないように予約を受け付けるサービスです
+// 予約は仮押さえの時点で枠の予約件数に数え、配送の登録で確定します。期限までに確定しない仮押さえは失効させます
+type SlotService struct {
+	slotRepo        repository.DeliverySlotRepository
+	reservationRepo repository.SlotReservationRepository
+	robotRepo       repository.RobotRepository
+	deliveryRepo    repository.DeliveryRepository
+	config          SlotConfig
+	location        *time.Location
+	now             func() time.Time
+}
+
+// NewSlotService は配送時間枠サービスを作成します
+// location はテンプレートの時刻を解釈する店舗のタイムゾーンです
+func NewSlotService(
+	slotRepo repository.DeliverySlotRepository,
+	reservationRepo repository.SlotReservationRepository,
+	robotRepo repository.RobotRepository,
+	deliveryRepo repository.DeliveryRepository,
+	config SlotConfig,
+	location *time.Location,
+) *SlotService {
+	return &SlotService{
+		slotRepo:        slotRepo,
+		reservationRepo: reservationRepo,
+		robotRepo:       robotRepo,
+		deliveryRepo:    deliveryRepo,
+		config:          config,
+		location:        location,
+		now:             time.Now,
+	}
+}
+
+// Start は一定間隔で期限切れの仮押さえを失効させ、枠の予約件数を戻します
+func (s *SlotService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.ExpireHolds(ctx); err != nil {
+					log.Printf("Failed to expire slot holds: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// ListTemplates は配送時間枠のテンプレートを曜日順に取得します
+func (s *SlotService) ListTemplates(ctx context.Context) ([]*models.SlotTemplate, error) {
+	return s.slotRepo.ListTemplates(ctx)
+}
+
+// SetTemplate は曜日の配送時間枠のテンプレートを登録・更新します
+// 作成済みの日付の枠は変更せず、まだ作成していない日付から反映します
+func (s *SlotService) SetTemplate(ctx context.Context, weekday time.Weekday, req *SlotTemplateRequest) (*models.SlotTemplate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	windows := append([]models.SlotWindow{}, req.Windows...)
+	sort.Slice(windows, func(i, j int) bool { return windows[i].Start < windows[j].Start })
+
+	template := &models.SlotTemplate{Weekday: weekday, Windows: windows}
+	if err := s.slotRepo.UpsertTemplate(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// GetAvailability は期間（終了日を含む）の配送時間枠と空き件数を取得します
+// 今日以降でまだ作成していない枠はテンプレートから作成します。開始日時を過ぎた枠の空きは0です
+// 機体の台数から求めた受付件数は、最初の予約が入るまで照会のたびに求め直します
+func (s *SlotService) GetAvailability(ctx context.Context, start, end time.Time) ([]*models.DeliverySlot, error) {
+	from := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, s.location)
+	to := time.Date(end.Year(), end.Month(), end.Day()+1, 0, 0, 0, 0, s.location)
+	now := s.now()
+
+	existing, err := s.slotRepo.ListSlots(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	created := make(map[[2]int64]bool, len(existing))
+	for _, slot := range existing {
+		created[[2]int64{slot.Start.Unix(), slot.End.Unix()}] = true
+	}
+
+	templates, err := s.slotRepo.ListTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	windows := make(map[time.Weekday][]models.SlotWindow, len(templates))
+	for _, template := range templates {
+		windows[template.Weekday] = template.Windows
+	}
+
+	local := now.In(s.location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.location)
+	day := from
+	if day.Before(today) {
+		day = today
+	}
+	fleet := &fleetEstimate{service: s, now: now}
+	missing := false
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, window := range windows[day.Weekday()] {
+			slot, err := s.slotFromWindow(day, window)
+			if err != nil {
+				return nil, err
+			}
+			if !slot.End.After(now) || created[[2]int64{slot.Start.Unix(), slot.End.Unix()}] {
+				continue
+			}
+			slot.Capacity = window.Capacity
+			if slot.Capacity == 0 {
+				slot.DerivedCapacity = true
+				if slot.Capacity, err = fleet.capacity(ctx, slot); err != nil {
+					return nil, err
+				}
+			}
+			if err := s.slotRepo.EnsureSlot(ctx, slot); err != nil {
+				return nil, err
+			}
+			missing = true
+		}
+	}
+
+	slots := existing
+	if missing {
+		if slots, err = s.slotRepo.ListSlots(ctx, from, to); err != nil {
+			return nil, err
+		}
+	}
+	for _, slot := range slots {
+		if err := s.refreshCapacity(ctx, slot, fleet); err != nil {
+			return nil, err
+		}
+	}
+	for _, slot := range slots {
+		slot.Available = 0
+		if slot.Start.After(now) && slot.Reserved < slot.Capacity {
+			slot.Available = slot.Capacity - slot.Reserved
+		}
+	}
+	return slots, nil
+}
+
+// Hold は配送時間枠を仮押さえします（期限までに配送を登録すると確定します）
+func (s *SlotService) Hold(ctx context.Context, slotID primitive.ObjectID) (*models.SlotReservation, error) {
+	reservation, _, err := s.reserve(ctx, slotID, models.ReservationHeld)
+	return reservation, err
+}
+
+// GetReservation は予約を取得します
+func (s *SlotService) GetReservation(ctx context.Context, id primitive.ObjectID) (*models.SlotReservation, error) {
+	reservation, err := s.reservationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation == nil {
+		return nil, ErrReservationNotFound
+	}
+	return reservation, nil
+}
+
+// CancelReservation は仮押さえ中または確定済みの予約を取り消し、枠の予約件数を戻します
+func (s *SlotService) CancelReservation(ctx context.Context, id primitive.ObjectID) error {
+	return s.close(ctx, id, []models.SlotReservationStatus{models.ReservationHeld, models.ReservationConfirmed}, models.ReservationCancelled)
+}
+
+// ExpireHolds は期限切れの仮押さえを失効させ、枠の予約件数を戻します
+func (s *SlotService) ExpireHolds(ctx context.Context) (int, error) {
+	expired, err := s.reservationRepo.FindExpired(ctx, s.now())
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, reservation := range expired {
+		err := s.close(ctx, reservation.ID, []models.SlotReservationStatus{models.ReservationHeld}, models.ReservationExpired)
+		if err != nil {
+			if errors.Is(err, ErrReservationNotHeld) {
+				// 失効させる前に確定・取り消しされた仮押さえです
+				continue
+			}
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// BookSlot は配送の登録時に配送時間枠の予約を確定します
+// reservationID を指定した場合は期限内の仮押さえを確定し、指定しない場合は slotID の枠を直接予約します
+func (s *SlotService) BookSlot(ctx context.Context, slotID, reservationID string) (*models.SlotReservation, *models.DeliverySlot, error) {
+	if reservationID == "" {
+		id, err := primitive.ObjectIDFromHex(slotID)
+		if err != nil {
+			return nil, nil, ErrSlotNotFound
+		}
+		return s.reserve(ctx, id, models.ReservationConfirmed)
+	}
+
+	id, err := primitive.ObjectIDFromHex(reservationID)
+	if err != nil {
+		return nil, nil, ErrReservationNotFound
+	}
+	reservation, err := s.GetReservation(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if slotID != "" && slotID != reservation.SlotID.Hex() {
+		return nil, nil, ErrReservationNotFound
+	}
+	now := s.now()
+	confirmed, err := s.reservationRepo.Confirm(ctx, id, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !confirmed {
+		return nil, nil, ErrReservationNotHeld
+	}
+	reservation.Status = models.ReservationConfirmed
+	reservation.ConfirmedAt = &now
+
+	slot, err := s.slotRepo.GetSlot(ctx, reservation.SlotID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if slot == nil {
+		return nil, nil, ErrSlotNotFound
+	}
+	return reservation, slot, nil
+}
+
+// AttachDelivery は確定した予約に登録した配送のIDを記録します
+func (s *SlotService) AttachDelivery(ctx context.Context, reservationID primitive.ObjectID, deliveryID string) error {
+	return s.reservationRepo.AttachDelivery(ctx, reservationID, deliveryID)
+}
+
+// reserve は配送時間枠の空きを1件確保し、指定した状態の予約を登録します
+func (s *SlotService) reserve(ctx context.Context, slotID primitive.ObjectID, status models.SlotReservationStatus) (*models.SlotReservation, *models.DeliverySlot, error) {
+	slot, err := s.slotRepo.GetSlot(ctx, slotID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if slot == nil {
+		return nil, nil, ErrSlotNotFound
+	}
+	now := s.now()
+	if !slot.Start.After(now) {
+		return nil, nil, ErrSlotClosed
+	}
+	if err := s.refreshCapacity(ctx, slot, &fleetEstimate{service: s, now: now}); err != nil {
+		return nil, nil, err
+	}
+
+	ok, err := s.slotRepo.Reserve(ctx, slotID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, ErrSlotFull
+	}
+
+	reservation := &models.SlotReservation{
+		SlotID:    slotID,
+		Status:    status,
+		ExpiresAt: now.Add(s.config.HoldTTL),
+		CreatedAt: now,
+	}
+	if status == models.ReservationConfirmed {
+		reservation.ConfirmedAt = &now
+	}
+	if err := s.reservationRepo.Create(ctx, reservation); err != nil {
+		// 予約を登録できなかった場合は確保した空きを戻します
+		if releaseErr := s.slotRepo.Release(ctx, slotID); releaseErr != nil {
+			log.Printf("Failed to release delivery slot %s: %v", slotID.Hex(), releaseErr)
+		}
+		return nil, nil, err
+	}
+	return reservation, slot, nil
+}
+
+// close は予約の状態を from から to に変え、変更できた場合のみ枠の予約件数を戻します
+func (s *SlotService) close(ctx context.Context, id primitive.ObjectID, from []models.SlotReservationStatus, to models.SlotReservationStatus) error {
+	reservation, err := s.GetReservation(ctx, id)
+	if err != nil {
+		return err
+	}
+	closed, err := s.reservationRepo.Close(ctx, id, from, to, s.now())
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return ErrReservationNotHeld
+	}
+	return s.slotRepo.Release(ctx, reservation.SlotID)
+}
+
+// slotFromWindow はテンプレートの枠から日付の配送時間枠を作ります
+func (s *SlotService) slotFromWindow(day time.Time, window models.SlotWindow) (*models.DeliverySlot, error) {
+	start, err := time.Parse(slotTimeLayout, window.Start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid slot window start %q: %w", window.Start, err)
+	}
+	end, err := time.Parse(slotTimeLayout, window.End)
+	if err != nil {
+		return nil, fmt.Errorf("invalid slot window end %q: %w", window.End, err)
+	}
+	return &models.DeliverySlot{
+		Date:  day.Format("2006-01-02"),
+		Start: time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, s.location),
+		End:   time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, s.location),
+	}, nil
+}
+
+// refreshCapacity は機体の台数から受付件数を求めた受付前の枠に予約がまだない場合、現在の機体の台数で受付件数を求め直します
+func (s *SlotService) refreshCapacity(ctx context.Context, slot *models.DeliverySlot, fleet *fleetEstimate) error {
+	if !slot.DerivedCapacity || slot.Reserved > 0 || !slot.Start.After(fleet.now) {
+		return nil
+	}
+	capacity, err := fleet.capacity(ctx, slot)
+	if err != nil || capacity == slot.Capacity {
+		return err
+	}
+	updated, err := s.slotRepo.UpdateDerivedCapacity(ctx, slot.ID, capacity)
+	if err != nil {
+		return err
+	}
+	if updated {
+		slot.Capacity = capacity
+	}
+	return nil
+}
+
+// fleetEstimate は受付件数の既定値を求める機体の台数と平均の往復時間です（必要になった時に1回だけ求めます）
+type fleetEstimate struct {
+	service  *SlotService
+	now      time.Time
+	loaded   bool
+	fleet    int
+	tripTime time.Duration
+}
+
+// capacity は機体の台数と平均の往復時間から枠の受付件数の既定値を求めます
+func (e *fleetEstimate) capacity(ctx context.Context, slot *models.DeliverySlot) (int, error) {
+	if !e.loaded {
+		fleet, tripTime, err := e.service.fleetCapacity(ctx, e.now)
+		if err != nil {
+			return 0, err
+		}
+		e.fleet, e.tripTime, e.loaded = fleet, tripTime, true
+	}
+	return e.fleet * int(slot.End.Sub(slot.Start)/e.tripTime), nil
+}
+
+// fleetCapacity は配送に使える機体（整備中を除く）の台数と、1件あたりの平均の往復時間を返します
+// 往復時間は直近の配送の割り当てから完了までの時間の2倍とし、配送実績が少ない場合は既定値を使います
+func (s *SlotService) fleetCapacity(ctx context.Context, now time.Time) (int, time.Duration, error) {
+	robots, err := s.robotRepo.List(ctx, models.RobotQuery{})
+	if err != nil {
+		return 0, 0, err
+	}
+	fleet := 0
+	for _, robot := range robots {
+		if robot.Status != models.RobotMaintenance {
+			fleet++
+		}
+	}
+
+	deliveries, err := s.deliveryRepo.GetCompletedDeliveries(ctx, now.Add(-s.config.TripHistory), now)
+	if err != nil {
+		return 0, 0, err
+	}
+	var total time.Duration
+	samples := 0
+	for _, delivery := range deliveries {
+		if delivery.AssignedAt == nil || delivery.ActualDeliveryTime == nil || !delivery.ActualDeliveryTime.After(*delivery.AssignedAt) {
+			continue
+		}
+		total += delivery.ActualDeliveryTime.Sub(*delivery.AssignedAt)
+		samples++
+	}
+	if samples < s.config.MinTripSamples || samples == 0 {
+		return fleet, s.config.DefaultTripTime, nil
+	}
+	return fleet, 2 * total / time.Duration(samples), nil
+}
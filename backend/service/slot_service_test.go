@@ -0,0 +1,427 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// memorySlotRepository はメモリ上の配送時間枠リポジトリです
+// Reserve は MongoDB の条件付き更新と同じく、空きの確認と予約件数の加算を排他的に行います
+type memorySlotRepository struct {
+	mu        sync.Mutex
+	templates map[time.Weekday]*models.SlotTemplate
+	slots     map[primitive.ObjectID]*models.DeliverySlot
+}
+
+func newMemorySlotRepository() *memorySlotRepository {
+	return &memorySlotRepository{
+		templates: make(map[time.Weekday]*models.SlotTemplate),
+		slots:     make(map[primitive.ObjectID]*models.DeliverySlot),
+	}
+}
+
+func (r *memorySlotRepository) UpsertTemplate(ctx context.Context, template *models.SlotTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *template
+	r.templates[template.Weekday] = &stored
+	return nil
+}
+
+func (r *memorySlotRepository) ListTemplates(ctx context.Context) ([]*models.SlotTemplate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	templates := []*models.SlotTemplate{}
+	for _, template := range r.templates {
+		copied := *template
+		templates = append(templates, &copied)
+	}
+	return templates, nil
+}
+
+func (r *memorySlotRepository) EnsureSlot(ctx context.Context, slot *models.DeliverySlot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.slots {
+		if stored.Start.Equal(slot.Start) && stored.End.Equal(slot.End) {
+			*slot = *stored
+			return nil
+		}
+	}
+	slot.ID = primitive.NewObjectID()
+	stored := *slot
+	r.slots[slot.ID] = &stored
+	return nil
+}
+
+func (r *memorySlotRepository) UpdateDerivedCapacity(ctx context.Context, id primitive.ObjectID, capacity int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	slot, ok := r.slots[id]
+	if !ok || !slot.DerivedCapacity || slot.Reserved > 0 {
+		return false, nil
+	}
+	slot.Capacity = capacity
+	return true, nil
+}
+
+func (r *memorySlotRepository) GetSlot(ctx context.Context, id primitive.ObjectID) (*models.DeliverySlot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	slot, ok := r.slots[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *slot
+	return &copied, nil
+}
+
+func (r *memorySlotRepository) ListSlots(ctx context.Context, from, to time.Time) ([]*models.DeliverySlot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	slots := []*models.DeliverySlot{}
+	for _, slot := range r.slots {
+		if !slot.Start.Before(from) && slot.Start.Before(to) {
+			copied := *slot
+			slots = append(slots, &copied)
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Start.Before(slots[j].Start) })
+	return slots, nil
+}
+
+func (r *memorySlotRepository) Reserve(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	slot, ok := r.slots[id]
+	if !ok || slot.Reserved >= slot.Capacity {
+		return false, nil
+	}
+	slot.Reserved++
+	return true, nil
+}
+
+func (r *memorySlotRepository) Release(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slot, ok := r.slots[id]; ok && slot.Reserved > 0 {
+		slot.Reserved--
+	}
+	return nil
+}
+
+// memoryReservationRepository はメモリ上の配送時間枠の予約リポジトリです
+type memoryReservationRepository struct {
+	mu           sync.Mutex
+	reservations map[primitive.ObjectID]*models.SlotReservation
+}
+
+func newMemoryReservationRepository() *memoryReservationRepository {
+	return &memoryReservationRepository{reservations: make(map[primitive.ObjectID]*models.SlotReservation)}
+}
+
+func (r *memoryReservationRepository) Create(ctx context.Context, reservation *models.SlotReservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reservation.ID = primitive.NewObjectID()
+	stored := *reservation
+	r.reservations[reservation.ID] = &stored
+	return nil
+}
+
+func (r *memoryReservationRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.SlotReservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reservation, ok := r.reservations[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *reservation
+	return &copied, nil
+}
+
+func (r *memoryReservationRepository) Confirm(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reservation, ok := r.reservations[id]
+	if !ok || reservation.Status != models.ReservationHeld || !reservation.ExpiresAt.After(now) {
+		return false, nil
+	}
+	reservation.Status = models.ReservationConfirmed
+	reservation.ConfirmedAt = &now
+	return true, nil
+}
+
+func (r *memoryReservationRepository) AttachDelivery(ctx context.Context, id primitive.ObjectID, deliveryID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if reservation, ok := r.reservations[id]; ok {
+		reservation.DeliveryID = deliveryID
+	}
+	return nil
+}
+
+func (r *memoryReservationRepository) Close(ctx context.Context, id primitive.ObjectID, from []models.SlotReservationStatus, to models.SlotReservationStatus, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reservation, ok := r.reservations[id]
+	if !ok {
+		return false, nil
+	}
+	for _, status := range from {
+		if reservation.Status == status {
+			reservation.Status = to
+			reservation.ReleasedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryReservationRepository) FindExpired(ctx context.Context, now time.Time) ([]*models.SlotReservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expired := []*models.SlotReservation{}
+	for _, reservation := range r.reservations {
+		if reservation.Status == models.ReservationHeld && !reservation.ExpiresAt.After(now) {
+			copied := *reservation
+			expired = append(expired, &copied)
+		}
+	}
+	return expired, nil
+}
+
+func TestSlotHoldNeverOverbooks(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	slotRepo := newMemorySlotRepository()
+	s := NewSlotService(slotRepo, newMemoryReservationRepository(), new(MockRobotRepository), new(MockDeliveryRepository), DefaultSlotConfig(), time.UTC)
+	s.now = func() time.Time { return now }
+
+	slot := &models.DeliverySlot{Date: "2024-06-03", Start: now.Add(time.Hour), End: now.Add(3 * time.Hour), Capacity: 5}
+	require.NoError(t, slotRepo.EnsureSlot(ctx, slot))
+
+	// 同時に50件の仮押さえを受けても受付件数の5件までしか確保しない
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	held, full := 0, 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Hold(ctx, slot.ID)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				held++
+			case assert.ErrorIs(t, err, ErrSlotFull):
+				full++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, held)
+	assert.Equal(t, 45, full)
+	stored, _ := slotRepo.GetSlot(ctx, slot.ID)
+	assert.Equal(t, 5, stored.Reserved)
+}
+
+func TestSlotHoldAndConfirm(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	slotRepo := newMemorySlotRepository()
+	reservationRepo := newMemoryReservationRepository()
+	s := NewSlotService(slotRepo, reservationRepo, new(MockRobotRepository), new(MockDeliveryRepository), DefaultSlotConfig(), time.UTC)
+	s.now = func() time.Time { return now }
+
+	slot := &models.DeliverySlot{Date: "2024-06-03", Start: now.Add(time.Hour), End: now.Add(3 * time.Hour), Capacity: 2}
+	require.NoError(t, slotRepo.EnsureSlot(ctx, slot))
+
+	t.Run("期限内の仮押さえは配送の登録で確定する", func(t *testing.T) {
+		hold, err := s.Hold(ctx, slot.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReservationHeld, hold.Status)
+		assert.Equal(t, now.Add(10*time.Minute), hold.ExpiresAt)
+
+		reservation, booked, err := s.BookSlot(ctx, "", hold.ID.Hex())
+		require.NoError(t, err)
+		assert.Equal(t, models.ReservationConfirmed, reservation.Status)
+		assert.Equal(t, slot.ID, booked.ID)
+
+		// 確定済みの予約で二重に登録することはできない
+		_, _, err = s.BookSlot(ctx, "", hold.ID.Hex())
+		assert.ErrorIs(t, err, ErrReservationNotHeld)
+	})
+
+	t.Run("期限切れの仮押さえは失効して空きが戻る", func(t *testing.T) {
+		hold, err := s.Hold(ctx, slot.ID)
+		require.NoError(t, err)
+		_, err = s.Hold(ctx, slot.ID)
+		assert.ErrorIs(t, err, ErrSlotFull)
+
+		s.now = func() time.Time { return now.Add(11 * time.Minute) }
+		defer func() { s.now = func() time.Time { return now } }()
+
+		_, _, err = s.BookSlot(ctx, slot.ID.Hex(), hold.ID.Hex())
+		assert.ErrorIs(t, err, ErrReservationNotHeld)
+
+		count, err := s.ExpireHolds(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		stored, _ := reservationRepo.GetByID(ctx, hold.ID)
+		assert.Equal(t, models.ReservationExpired, stored.Status)
+		remaining, _ := slotRepo.GetSlot(ctx, slot.ID)
+		assert.Equal(t, 1, remaining.Reserved)
+	})
+
+	t.Run("取り消した予約は二重に空きを戻さない", func(t *testing.T) {
+		reservation, _, err := s.BookSlot(ctx, slot.ID.Hex(), "")
+		require.NoError(t, err)
+		require.NoError(t, s.CancelReservation(ctx, reservation.ID))
+		assert.ErrorIs(t, s.CancelReservation(ctx, reservation.ID), ErrReservationNotHeld)
+		remaining, _ := slotRepo.GetSlot(ctx, slot.ID)
+		assert.Equal(t, 1, remaining.Reserved)
+	})
+
+	t.Run("開始日時を過ぎた枠は予約できない", func(t *testing.T) {
+		s.now = func() time.Time { return slot.Start }
+		defer func() { s.now = func() time.Time { return now } }()
+
+		_, err := s.Hold(ctx, slot.ID)
+		assert.ErrorIs(t, err, ErrSlotClosed)
+	})
+}
+
+func TestSlotAvailability(t *testing.T) {
+	ctx := context.Background()
+	// 2024-06-03 は月曜日
+	now := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	slotRepo := newMemorySlotRepository()
+	robotRepo := new(MockRobotRepository)
+	deliveryRepo := new(MockDeliveryRepository)
+	s := NewSlotService(slotRepo, newMemoryReservationRepository(), robotRepo, deliveryRepo, DefaultSlotConfig(), time.UTC)
+	s.now = func() time.Time { return now }
+
+	_, err := s.SetTemplate(ctx, time.Monday, &SlotTemplateRequest{Windows: []models.SlotWindow{
+		{Start: "14:00", End: "15:00", Capacity: 3},
+		{Start: "10:00", End: "12:00"},
+	}})
+	require.NoError(t, err)
+
+	// 整備中を除く2台で、配送実績が少ないため往復45分とすると2時間の枠は2台×2往復
+	robotRepo.On("List", ctx, models.RobotQuery{}).Return([]*models.Robot{
+		{Status: models.RobotIdle}, {Status: models.RobotAssigned}, {Status: models.RobotMaintenance},
+	}, nil).Once()
+	deliveryRepo.On("GetCompletedDeliveries", ctx, now.Add(-14*24*time.Hour), now).Return([]*models.Delivery{}, nil)
+
+	slots, err := s.GetAvailability(ctx, now, now.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, slots, 2)
+	assert.Equal(t, time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC), slots[0].Start)
+	assert.Equal(t, 4, slots[0].Capacity)
+	assert.Equal(t, 4, slots[0].Available)
+	assert.Equal(t, 3, slots[1].Capacity)
+
+	// 予約のない枠の受付件数は現在の機体の台数で求め直す（3台×2往復）
+	robotRepo.On("List", ctx, models.RobotQuery{}).Return([]*models.Robot{
+		{Status: models.RobotIdle}, {Status: models.RobotIdle}, {Status: models.RobotCharging},
+	}, nil).Twice()
+	slots, err = s.GetAvailability(ctx, now, now.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, slots, 2)
+	assert.Equal(t, 6, slots[0].Available)
+
+	// 予約が入った枠は受付件数を求め直さず、予約した分だけ空きが減る
+	_, err = s.Hold(ctx, slots[0].ID)
+	require.NoError(t, err)
+	_, err = s.Hold(ctx, slots[1].ID)
+	require.NoError(t, err)
+	slots, err = s.GetAvailability(ctx, now, now.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, slots, 2)
+	assert.Equal(t, 5, slots[0].Available)
+	assert.Equal(t, 2, slots[1].Available)
+	robotRepo.AssertNumberOfCalls(t, "List", 3)
+
+	t.Run("機体がない間に作成した枠も機体の登録後に受け付ける", func(t *testing.T) {
+		slotRepo := newMemorySlotRepository()
+		robotRepo := new(MockRobotRepository)
+		s := NewSlotService(slotRepo, newMemoryReservationRepository(), robotRepo, deliveryRepo, DefaultSlotConfig(), time.UTC)
+		s.now = func() time.Time { return now }
+		_, err := s.SetTemplate(ctx, time.Monday, &SlotTemplateRequest{Windows: []models.SlotWindow{{Start: "10:00", End: "12:00"}}})
+		require.NoError(t, err)
+
+		robotRepo.On("List", ctx, models.RobotQuery{}).Return([]*models.Robot{}, nil).Once()
+		slots, err := s.GetAvailability(ctx, now, now)
+		require.NoError(t, err)
+		require.Len(t, slots, 1)
+		assert.Zero(t, slots[0].Capacity)
+
+		robotRepo.On("List", ctx, models.RobotQuery{}).Return([]*models.Robot{{Status: models.RobotIdle}}, nil)
+		_, err = s.Hold(ctx, slots[0].ID)
+		require.NoError(t, err)
+		stored, _ := slotRepo.GetSlot(ctx, slots[0].ID)
+		assert.Equal(t, 2, stored.Capacity)
+		assert.Equal(t, 1, stored.Reserved)
+	})
+
+	assert.Error(t, (&SlotTemplateRequest{Windows: []models.SlotWindow{
+		{Start: "10:00", End: "12:00"}, {Start: "11:00", End: "13:00"},
+	}}).Validate())
+	assert.Error(t, (&SlotTemplateRequest{Windows: []models.SlotWindow{{Start: "12:00", End: "10:00"}}}).Validate())
+	mock.AssertExpectationsForObjects(t, robotRepo, deliveryRepo)
+}
+
+func TestCreateDeliveryWithSlot(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	slotRepo := newMemorySlotRepository()
+	reservationRepo := newMemoryReservationRepository()
+	slots := NewSlotService(slotRepo, reservationRepo, new(MockRobotRepository), new(MockDeliveryRepository), DefaultSlotConfig(), time.UTC)
+	slots.now = func() time.Time { return now }
+	deliveryRepo := new(MockDeliveryRepository)
+	s := NewDeliveryService(deliveryRepo, nil, slots, nil, DefaultDeliveryConfig())
+
+	slot := &models.DeliverySlot{Date: "2024-06-03", Start: now.Add(time.Hour), End: now.Add(3 * time.Hour), Capacity: 1}
+	require.NoError(t, slotRepo.EnsureSlot(ctx, slot))
+
+	t.Run("登録に失敗した場合は予約を取り消して空きを戻す", func(t *testing.T) {
+		deliveryRepo.On("Create", ctx, mock.AnythingOfType("*models.Delivery"), "staff-1").Return(assert.AnError).Once()
+		err := s.CreateDelivery(ctx, &models.Delivery{DeliveryType: "ロボット", Address: "東京都渋谷区", SlotID: slot.ID.Hex()}, "staff-1")
+		assert.ErrorIs(t, err, assert.AnError)
+		stored, _ := slotRepo.GetSlot(ctx, slot.ID)
+		assert.Equal(t, 0, stored.Reserved)
+	})
+
+	t.Run("枠の時間帯を配送に設定して予約と紐付ける", func(t *testing.T) {
+		deliveryRepo.On("Create", ctx, mock.AnythingOfType("*models.Delivery"), "staff-1").
+			Run(func(args mock.Arguments) { args.Get(1).(*models.Delivery).ID = "delivery-1" }).Return(nil).Once()
+		delivery := &models.Delivery{DeliveryType: "ロボット", Address: "東京都渋谷区", SlotID: slot.ID.Hex()}
+		require.NoError(t, s.CreateDelivery(ctx, delivery, "staff-1"))
+		assert.Equal(t, slot.Start, *delivery.WindowStart)
+		assert.Equal(t, slot.End, *delivery.WindowEnd)
+		assert.Equal(t, slot.End, delivery.EstimatedDeliveryTime)
+
+		id, _ := primitive.ObjectIDFromHex(delivery.ReservationID)
+		reservation, _ := reservationRepo.GetByID(ctx, id)
+		assert.Equal(t, models.ReservationConfirmed, reservation.Status)
+		assert.Equal(t, "delivery-1", reservation.DeliveryID)
+
+		// 満杯の枠を指定した配送は登録しない
+		err := s.CreateDelivery(ctx, &models.Delivery{DeliveryType: "ロボット", Address: "東京都渋谷区", SlotID: slot.ID.Hex()}, "staff-1")
+		assert.ErrorIs(t, err, ErrSlotFull)
+		deliveryRepo.AssertNumberOfCalls(t, "Create", 2)
+	})
+}
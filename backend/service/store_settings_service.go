@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+// DefaultStoreName は店舗設定が未登録の場合に表示する店舗名です
+const DefaultStoreName = "NEXT MART 2030"
+
+// maxReceiptWidth はレシートの1行あたりの最大桁数です
+const maxReceiptWidth = 80
+
+// StoreSettingsServiceInterface は店舗設定サービスのインターフェースを定義します
+type StoreSettingsServiceInterface interface {
+	GetSettings(ctx context.Context, storeID string) (*models.StoreSettings, error)
+	UpdateSettings(ctx context.Context, settings *models.StoreSettings) error
+}
+
+// StoreSettingsService は店舗設定サービスを表します
+type StoreSettingsService struct {
+	repo repository.StoreSettingsRepository
+}
+
+// NewStoreSettingsService は新しい店舗設定サービスを作成します
+func NewStoreSettingsService(repo repository.StoreSettingsRepository) *StoreSettingsService {
+	return &StoreSettingsService{
+		repo: repo,
+	}
+}
+
+// GetSettings は店舗の設定を取得します
+// 未登録の場合は既定の設定を返します
+func (s *StoreSettingsService) GetSettings(ctx context.Context, storeID string) (*models.StoreSettings, error) {
+	settings, err := s.repo.GetByStoreID(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &models.StoreSettings{
+			StoreID: storeID,
+			Name:    DefaultStoreName,
+		}
+	}
+	if settings.ReceiptLayout.Width == 0 {
+		settings.ReceiptLayout.Width = models.DefaultReceiptWidth
+	}
+	return settings, nil
+}
+
+// UpdateSettings は店舗の設定を更新します
+func (s *StoreSettingsService) UpdateSettings(ctx context.Context, settings *models.StoreSettings) error {
+	if settings.StoreID == "" {
+		return errors.New("store ID is required")
+	}
+	if settings.Name == "" {
+		return errors.New("store name is required")
+	}
+	if settings.ReceiptLayout.Width == 0 {
+		settings.ReceiptLayout.Width = models.DefaultReceiptWidth
+	}
+	if settings.ReceiptLayout.Width < 24 || settings.ReceiptLayout.Width > maxReceiptWidth {
+		return errors.New("receipt width must be between 24 and 80")
+	}
+	return s.repo.Upsert(ctx, settings)
+}
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+var (
+	// ErrTelemetryOutOfRange は計測日時が現在より先、または保存期間を過ぎている場合のエラーです
+	ErrTelemetryOutOfRange = errors.New("telemetry timestamp is out of range")
+	// ErrTelemetryAlreadyRolledUp は計測日時の1分間が既に集約済みで、受信しても1分ごとの履歴に反映できない場合のエラーです
+	ErrTelemetryAlreadyRolledUp = errors.New("telemetry timestamp is in a minute that has already been rolled up")
+	// ErrTelemetryWindowTooLarge は走行履歴の取得期間・件数が上限を超える場合のエラーです
+	ErrTelemetryWindowTooLarge = errors.New("telemetry window is too large")
+)
+
+// maxTelemetryBatch は1回に受信できる計測値の件数です（10Hzで1分間に送る件数に余裕を持たせた値）
+const maxTelemetryBatch = 1000
+
+// rollupChunk は1回の集約で扱う期間です（停止していた期間をまとめて集約する場合に分割します）
+const rollupChunk = 6 * time.Hour
+
+// TelemetryConfig は配送機体の計測値の保存・取得の設定です
+type TelemetryConfig struct {
+	// RawRetention は受信した計測値、RollupRetention は1分ごとに集約した計測値の保存期間です
+	RawRetention    time.Duration
+	RollupRetention time.Duration
+	// RollupDelay は遅れて届く計測値を待ってから集約するまでの時間です
+	RollupDelay time.Duration
+	// RawWindow は解像度を省略した場合に、受信した計測値をそのまま返す期間の上限です
+	RawWindow time.Duration
+	// MaxRawPoints は受信した計測値をそのまま返す場合の件数の上限です
+	MaxRawPoints int
+	// MaxWindow は走行履歴を取得できる期間の上限です
+	MaxWindow time.Duration
+	// MaxClockSkew は機体の時計のずれとして許容する、現在より先の計測日時の幅です
+	MaxClockSkew time.Duration
+	// OnlineTimeout はこの時間内に計測値を受信した機体を通信中とみなす時間です
+	OnlineTimeout time.Duration
+	// RollupLeaseTTL は集約中に保持するリースの期間です（複数のインスタンスが同じ1分間を重複して集約しないようにします）
+	RollupLeaseTTL time.Duration
+}
+
+// DefaultTelemetryConfig は既定の設定（受信した計測値は7日間、1分ごとの集約は90日間保存）を返します
+func DefaultTelemetryConfig() TelemetryConfig {
+	return TelemetryConfig{
+		RawRetention:    7 * 24 * time.Hour,
+		RollupRetention: 90 * 24 * time.Hour,
+		RollupDelay:     2 * time.Minute,
+		RawWindow:       time.Hour,
+		MaxRawPoints:    20000,
+		MaxWindow:       31 * 24 * time.Hour,
+		MaxClockSkew:    time.Minute,
+		OnlineTimeout:   30 * time.Second,
+		RollupLeaseTTL:  5 * time.Minute,
+	}
+}
+
+// TelemetryBatchRequest は配送機体からまとめて送信される計測値です
+type TelemetryBatchRequest struct {
+	// Readings の robotId は省略できます（送信先の機体のIDを使います）
+	Readings []models.TelemetryReading `json:"readings"`
+}
+
+// Validate は計測値を検証します
+func (r *TelemetryBatchRequest) Validate() error {
+	if len(r.Readings) == 0 {
+		return errors.New("計測値を1件以上指定してください")
+	}
+	if len(r.Readings) > maxTelemetryBatch {
+		return fmt.Errorf("計測値は1回に%d件まで送信できます", maxTelemetryBatch)
+	}
+	for i, reading := range r.Readings {
+		if reading.Timestamp.IsZero() {
+			return fmt.Errorf("%d件目の計測日時を指定してください", i+1)
+		}
+		location := reading.Location
+		if location == (models.Location{}) || location.Latitude < -90 || location.Latitude > 90 || location.Longitude < -180 || location.Longitude > 180 {
+			return fmt.Errorf("%d件目の位置が不正です", i+1)
+		}
+		if reading.BatteryLevel != nil && (*reading.BatteryLevel < 0 || *reading.BatteryLevel > 100) {
+			return fmt.Errorf("%d件目のバッテリー残量は0〜100の範囲で指定してください", i+1)
+		}
+		if reading.Speed != nil && *reading.Speed < 0 {
+			return fmt.Errorf("%d件目の速度は0以上を指定してください", i+1)
+		}
+		if reading.Heading != nil && (*reading.Heading < 0 || *reading.Heading >= 360) {
+			return fmt.Errorf("%d件目の進行方向は0以上360未満で指定してください", i+1)
+		}
+	}
+	return nil
+}
+
+// TelemetryServiceInterface は配送機体の計測値サービスのインターフェースです
+type TelemetryServiceInterface interface {
+	Ingest(ctx context.Context, robotID primitive.ObjectID, req *TelemetryBatchRequest) (*models.TelemetryIngestResult, error)
+	GetTrack(ctx context.Context, robotID primitive.ObjectID, from, to time.Time, resolution models.TelemetryResolution) (*models.TelemetryTrack, error)
+	GetFleetState(ctx context.Context) ([]models.FleetRobotState, error)
+}
+
+// TelemetryService は配送機体の計測値を受信・集約し、走行履歴と最新の状態を提供するサービスです
+type TelemetryService struct {
+	telemetryRepo repository.TelemetryRepository
+	robotRepo     repository.RobotRepository
+	publisher     TrackingPublisher
+	config        TelemetryConfig
+	// instanceID は集約のリースを保持するこのインスタンスの識別子です
+	instanceID string
+	now        func() time.Time
+}
+
+// NewTelemetryService は新しい配送機体の計測値サービスを作成します
+// publisher を指定した場合、受信した最新の位置をライブ追跡イベントとして配信します
+func NewTelemetryService(telemetryRepo repository.TelemetryRepository, robotRepo repository.RobotRepository, publisher TrackingPublisher, config TelemetryConfig) *TelemetryService {
+	return &TelemetryService{
+		telemetryRepo: telemetryRepo,
+		robotRepo:     robotRepo,
+		publisher:     publisher,
+		config:        config,
+		instanceID:    primitive.NewObjectID().Hex(),
+		now:           time.Now,
+	}
+}
+
+// Start は一定間隔で計測値を1分ごとに集約します
+func (s *TelemetryService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.Rollup(ctx); err != nil {
+					log.Printf("Failed to roll up robot telemetry: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Ingest は配送機体からまとめて送信された計測値を保存し、機体の最新の状態を更新します
+// 1分ごとの集約は後から更新できないため、集約済みの1分間の計測値を含む場合は ErrTelemetryAlreadyRolledUp を返して保存しません
+// （遅れて届く計測値は RollupDelay の間に送信してください）
+func (s *TelemetryService) Ingest(ctx context.Context, robotID primitive.ObjectID, req *TelemetryBatchRequest) (*models.TelemetryIngestResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	robot, err := s.robotRepo.GetByID(ctx, robotID)
+	if err != nil {
+		return nil, err
+	}
+	if robot == nil {
+		return nil, ErrRobotNotFound
+	}
+
+	rolledUp, err := s.telemetryRepo.LatestRollupTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	readings := make([]models.TelemetryReading, len(req.Readings))
+	latest := 0
+	for i, reading := range req.Readings {
+		if reading.Timestamp.After(now.Add(s.config.MaxClockSkew)) || !reading.Timestamp.After(now.Add(-s.config.RawRetention)) {
+			return nil, ErrTelemetryOutOfRange
+		}
+		if !rolledUp.IsZero() && reading.Timestamp.Before(rolledUp.Add(time.Minute)) {
+			return nil, ErrTelemetryAlreadyRolledUp
+		}
+		reading.RobotID = robotID.Hex()
+		reading.Timestamp = reading.Timestamp.UTC()
+		if reading.DeliveryID == "" {
+			reading.DeliveryID = robot.CurrentDeliveryID
+		}
+		readings[i] = reading
+		if reading.Timestamp.After(readings[latest].Timestamp) {
+			latest = i
+		}
+	}
+
+	if err := s.telemetryRepo.InsertReadings(ctx, readings); err != nil {
+		return nil, err
+	}
+
+	last := readings[latest]
+	telemetry := models.RobotTelemetry{
+		Timestamp:    last.Timestamp,
+		Location:     last.Location,
+		BatteryLevel: last.BatteryLevel,
+		Speed:        last.Speed,
+		Heading:      last.Heading,
+		DeliveryID:   last.DeliveryID,
+	}
+	if err := s.robotRepo.UpdateTelemetry(ctx, robotID, telemetry); err != nil {
+		return nil, err
+	}
+
+	if s.publisher != nil {
+		location := last.Location
+		s.publisher.Publish(models.TrackingEvent{
+			Type:         models.TrackingLocation,
+			DeliveryID:   last.DeliveryID,
+			RobotID:      robotID.Hex(),
+			Location:     &location,
+			BatteryLevel: last.BatteryLevel,
+			Speed:        last.Speed,
+			Timestamp:    last.Timestamp,
+		})
+	}
+
+	return &models.TelemetryIngestResult{
+		RobotID:  robotID.Hex(),
+		Accepted: len(readings),
+		LatestAt: last.Timestamp,
+	}, nil
+}
+
+// GetTrack は配送機体の指定期間（from 以上 to 未満）の走行履歴を取得します
+// 解像度を省略した場合、期間が短ければ受信した計測値をそのまま、長ければ1分ごとに集約して返します
+func (s *TelemetryService) GetTrack(ctx context.Context, robotID primitive.ObjectID, from, to time.Time, resolution models.TelemetryResolution) (*models.TelemetryTrack, error) {
+	if !to.After(from) {
+		return nil, errors.New("end of the window must be after its start")
+	}
+	if to.Sub(from) > s.config.MaxWindow {
+		return nil, ErrTelemetryWindowTooLarge
+	}
+	robot, err := s.robotRepo.GetByID(ctx, robotID)
+	if err != nil {
+		return nil, err
+	}
+	if robot == nil {
+		return nil, ErrRobotNotFound
+	}
+
+	if resolution == "" {
+		resolution = models.TelemetryMinute
+		if to.Sub(from) <= s.config.RawWindow {
+			resolution = models.TelemetryRaw
+		}
+	}
+
+	track := &models.TelemetryTrack{
+		RobotID:    robotID.Hex(),
+		From:       from,
+		To:         to,
+		Resolution: resolution,
+		Points:     []models.TelemetryPoint{},
+	}
+	switch resolution {
+	case models.TelemetryRaw:
+		readings, err := s.telemetryRepo.FindReadings(ctx, robotID.Hex(), from, to, int64(s.config.MaxRawPoints+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(readings) > s.config.MaxRawPoints {
+			return nil, ErrTelemetryWindowTooLarge
+		}
+		for _, r := range readings {
+			track.Points = append(track.Points, models.TelemetryPoint{
+				Timestamp:    r.Timestamp,
+				Location:     r.Location,
+				BatteryLevel: r.BatteryLevel,
+				Speed:        r.Speed,
+				Heading:      r.Heading,
+				DeliveryID:   r.DeliveryID,
+				Readings:     1,
+			})
+		}
+	case models.TelemetryMinute:
+		rollups, err := s.minuteTrack(ctx, robotID.Hex(), from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rollups {
+			track.Points = append(track.Points, r.TelemetryPoint)
+		}
+	default:
+		return nil, fmt.Errorf("unknown telemetry resolution: %s", resolution)
+	}
+	return track, nil
+}
+
+// minuteTrack は1分ごとの走行履歴です
+// 集約済みの期間は保存した集約値を使い、まだ集約していない直近の期間は計測値をその場で集約します
+func (s *TelemetryService) minuteTrack(ctx context.Context, robotID string, from, to time.Time) ([]models.TelemetryRollup, error) {
+	latest, err := s.telemetryRepo.LatestRollupTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rolledUntil := from
+	if !latest.IsZero() {
+		rolledUntil = latest.Add(time.Minute)
+	}
+
+	var rollups []models.TelemetryRollup
+	if rolledUntil.After(from) {
+		end := rolledUntil
+		if to.Before(end) {
+			end = to
+		}
+		stored, err := s.telemetryRepo.FindRollups(ctx, robotID, from, end)
+		if err != nil {
+			return nil, err
+		}
+		rollups = append(rollups, stored...)
+	}
+	if to.After(rolledUntil) {
+		start := rolledUntil
+		if from.After(start) {
+			start = from
+		}
+		recent, err := s.telemetryRepo.AggregateMinutes(ctx, robotID, start, to)
+		if err != nil {
+			return nil, err
+		}
+		rollups = append(rollups, recent...)
+	}
+	return rollups, nil
+}
+
+// Rollup は前回の集約以降の計測値を1分ごとに集約して保存し、保存した件数を返します
+// 遅れて届く計測値を待つため、集約するのは RollupDelay より前に終わった1分間までです
+// 他のインスタンスが集約のリースを保持している場合は何もしません
+func (s *TelemetryService) Rollup(ctx context.Context) (int, error) {
+	acquired, err := s.telemetryRepo.AcquireRollupLease(ctx, s.instanceID, s.now(), s.config.RollupLeaseTTL)
+	if err != nil || !acquired {
+		return 0, err
+	}
+	defer func() {
+		if err := s.telemetryRepo.ReleaseRollupLease(context.Background(), s.instanceID); err != nil {
+			log.Printf("Failed to release telemetry rollup lease: %v", err)
+		}
+	}()
+
+	end := s.now().Add(-s.config.RollupDelay).Truncate(time.Minute)
+	latest, err := s.telemetryRepo.LatestRollupTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+	start := end.Add(-s.config.RawRetention).Truncate(time.Minute)
+	if !latest.IsZero() && latest.Add(time.Minute).After(start) {
+		start = latest.Add(time.Minute)
+	}
+
+	count := 0
+	for start.Before(end) {
+		chunkEnd := start.Add(rollupChunk)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+		rollups, err := s.telemetryRepo.AggregateMinutes(ctx, "", start, chunkEnd)
+		if err != nil {
+			return count, err
+		}
+		if err := s.telemetryRepo.InsertRollups(ctx, rollups); err != nil {
+			return count, err
+		}
+		count += len(rollups)
+		start = chunkEnd
+
+		// 停止していた期間をまとめて集約する場合に期限が切れないよう、分割した期間ごとにリースを延長します
+		if start.Before(end) {
+			acquired, err := s.telemetryRepo.AcquireRollupLease(ctx, s.instanceID, s.now(), s.config.RollupLeaseTTL)
+			if err != nil || !acquired {
+				return count, err
+			}
+		}
+	}
+	return count, nil
+}
+
+// GetFleetState は全ての配送機体の最新の状態（最後に受信した計測値）を取得します
+func (s *TelemetryService) GetFleetState(ctx context.Context) ([]models.FleetRobotState, error) {
+	robots, err := s.robotRepo.List(ctx, models.RobotQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	states := make([]models.FleetRobotState, 0, len(robots))
+	for _, robot := range robots {
+		states = append(states, models.FleetRobotState{
+			RobotID:           robot.ID.Hex(),
+			Name:              robot.Name,
+			Type:              robot.Type,
+			Status:            robot.Status,
+			CurrentDeliveryID: robot.CurrentDeliveryID,
+			Online:            robot.Telemetry != nil && now.Sub(robot.Telemetry.Timestamp) <= s.config.OnlineTimeout,
+			Telemetry:         robot.Telemetry,
+		})
+	}
+	return states, nil
+}
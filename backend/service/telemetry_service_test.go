@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+)
+
+type MockTelemetryRepository struct {
+	mock.Mock
+}
+
+var _ repository.TelemetryRepository = (*MockTelemetryRepository)(nil)
+
+func (m *MockTelemetryRepository) EnsureCollections(ctx context.Context, rawRetention, rollupRetention time.Duration) error {
+	args := m.Called(ctx, rawRetention, rollupRetention)
+	return args.Error(0)
+}
+
+func (m *MockTelemetryRepository) InsertReadings(ctx context.Context, readings []models.TelemetryReading) error {
+	args := m.Called(ctx, readings)
+	return args.Error(0)
+}
+
+func (m *MockTelemetryRepository) FindReadings(ctx context.Context, robotID string, from, to time.Time, limit int64) ([]models.TelemetryReading, error) {
+	args := m.Called(ctx, robotID, from, to, limit)
+	return args.Get(0).([]models.TelemetryReading), args.Error(1)
+}
+
+func (m *MockTelemetryRepository) AggregateMinutes(ctx context.Context, robotID string, from, to time.Time) ([]models.TelemetryRollup, error) {
+	args := m.Called(ctx, robotID, from, to)
+	return args.Get(0).([]models.TelemetryRollup), args.Error(1)
+}
+
+func (m *MockTelemetryRepository) InsertRollups(ctx context.Context, rollups []models.TelemetryRollup) error {
+	args := m.Called(ctx, rollups)
+	return args.Error(0)
+}
+
+func (m *MockTelemetryRepository) FindRollups(ctx context.Context, robotID string, from, to time.Time) ([]models.TelemetryRollup, error) {
+	args := m.Called(ctx, robotID, from, to)
+	return args.Get(0).([]models.TelemetryRollup), args.Error(1)
+}
+
+func (m *MockTelemetryRepository) AcquireRollupLease(ctx context.Context, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, holder, now, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTelemetryRepository) ReleaseRollupLease(ctx context.Context, holder string) error {
+	args := m.Called(ctx, holder)
+	return args.Error(0)
+}
+
+func (m *MockTelemetryRepository) LatestRollupTime(ctx context.Context) (time.Time, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockTelemetryRepository) AverageSpeed(ctx context.Context, robotID string, from, to time.Time, minSpeed float64) (float64, int, error) {
+	args := m.Called(ctx, robotID, from, to, minSpeed)
+	return args.Get(0).(float64), args.Int(1), args.Error(2)
+}
+
+func (m *MockTelemetryRepository) StreamRollups(ctx context.Context, from, to time.Time, fn func(*models.TelemetryRollup) error) error {
+	args := m.Called(ctx, from, to, fn)
+	if rollups, ok := args.Get(0).([]*models.TelemetryRollup); ok {
+		for _, rollup := range rollups {
+			if err := fn(rollup); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func TestTelemetryIngest(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	robot := &models.Robot{ID: primitive.NewObjectID(), Name: "1号機", CurrentDeliveryID: "delivery-1"}
+	battery := 72.5
+	speed := 5.4
+	location := func(i int) models.Location {
+		return models.Location{Latitude: 35.68 + float64(i)*0.0001, Longitude: 139.76}
+	}
+
+	newService := func() (*TelemetryService, *MockTelemetryRepository, *MockRobotRepository, *recordingPublisher) {
+		telemetryRepo := new(MockTelemetryRepository)
+		robotRepo := new(MockRobotRepository)
+		publisher := &recordingPublisher{}
+		s := NewTelemetryService(telemetryRepo, robotRepo, publisher, DefaultTelemetryConfig())
+		s.now = func() time.Time { return now }
+		return s, telemetryRepo, robotRepo, publisher
+	}
+
+	t.Run("計測値を保存し最新の状態を更新", func(t *testing.T) {
+		s, telemetryRepo, robotRepo, publisher := newService()
+		robotRepo.On("GetByID", ctx, robot.ID).Return(robot, nil)
+		telemetryRepo.On("LatestRollupTime", ctx).Return(now.Add(-3*time.Minute), nil)
+
+		// 送信順と計測日時の順は一致しないことがあります
+		req := &TelemetryBatchRequest{Readings: []models.TelemetryReading{
+			{Timestamp: now.Add(-400 * time.Millisecond), Location: location(1)},
+			{Timestamp: now.Add(-200 * time.Millisecond), Location: location(3), BatteryLevel: &battery, Speed: &speed},
+			{Timestamp: now.Add(-300 * time.Millisecond), Location: location(2), DeliveryID: "delivery-0"},
+		}}
+		telemetryRepo.On("InsertReadings", ctx, mock.MatchedBy(func(readings []models.TelemetryReading) bool {
+			return len(readings) == 3 &&
+				readings[0].RobotID == robot.ID.Hex() &&
+				readings[0].DeliveryID == "delivery-1" &&
+				readings[2].DeliveryID == "delivery-0"
+		})).Return(nil)
+		robotRepo.On("UpdateTelemetry", ctx, robot.ID, models.RobotTelemetry{
+			Timestamp:    now.Add(-200 * time.Millisecond),
+			Location:     location(3),
+			BatteryLevel: &battery,
+			Speed:        &speed,
+			DeliveryID:   "delivery-1",
+		}).Return(nil)
+
+		result, err := s.Ingest(ctx, robot.ID, req)
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Accepted)
+		assert.Equal(t, now.Add(-200*time.Millisecond), result.LatestAt)
+
+		require.Len(t, publisher.events, 1)
+		assert.Equal(t, robot.ID.Hex(), publisher.events[0].RobotID)
+		assert.Equal(t, "delivery-1", publisher.events[0].DeliveryID)
+		assert.Equal(t, location(3), *publisher.events[0].Location)
+		telemetryRepo.AssertExpectations(t)
+		robotRepo.AssertExpectations(t)
+	})
+
+	t.Run("計測日時が範囲外", func(t *testing.T) {
+		s, telemetryRepo, robotRepo, _ := newService()
+		robotRepo.On("GetByID", ctx, robot.ID).Return(robot, nil)
+		telemetryRepo.On("LatestRollupTime", ctx).Return(time.Time{}, nil)
+
+		for _, ts := range []time.Time{now.Add(2 * time.Minute), now.Add(-8 * 24 * time.Hour)} {
+			_, err := s.Ingest(ctx, robot.ID, &TelemetryBatchRequest{Readings: []models.TelemetryReading{{Timestamp: ts, Location: location(1)}}})
+			assert.ErrorIs(t, err, ErrTelemetryOutOfRange)
+		}
+		telemetryRepo.AssertNotCalled(t, "InsertReadings", mock.Anything, mock.Anything)
+	})
+
+	t.Run("集約済みの1分間の計測値は受け付けない", func(t *testing.T) {
+		s, telemetryRepo, robotRepo, _ := newService()
+		robotRepo.On("GetByID", ctx, robot.ID).Return(robot, nil)
+		telemetryRepo.On("LatestRollupTime", ctx).Return(now.Add(-3*time.Minute), nil)
+
+		// 8:57 の1分間まで集約済みのため、8:57:59 の計測値は1分ごとの履歴に反映できません
+		_, err := s.Ingest(ctx, robot.ID, &TelemetryBatchRequest{Readings: []models.TelemetryReading{
+			{Timestamp: now.Add(-2*time.Minute - time.Second), Location: location(1)},
+			{Timestamp: now, Location: location(2)},
+		}})
+		assert.ErrorIs(t, err, ErrTelemetryAlreadyRolledUp)
+		telemetryRepo.AssertNotCalled(t, "InsertReadings", mock.Anything, mock.Anything)
+	})
+
+	t.Run("存在しない機体", func(t *testing.T) {
+		s, _, robotRepo, _ := newService()
+		robotRepo.On("GetByID", ctx, robot.ID).Return(nil, nil)
+
+		_, err := s.Ingest(ctx, robot.ID, &TelemetryBatchRequest{Readings: []models.TelemetryReading{{Timestamp: now, Location: location(1)}}})
+		assert.ErrorIs(t, err, ErrRobotNotFound)
+	})
+
+	t.Run("不正な計測値", func(t *testing.T) {
+		s, _, _, _ := newService()
+		overcharged := 101.0
+		heading := 360.0
+		for _, reading := range []models.TelemetryReading{
+			{Location: location(1)},
+			{Timestamp: now},
+			{Timestamp: now, Location: models.Location{Latitude: 91, Longitude: 139}},
+			{Timestamp: now, Location: location(1), BatteryLevel: &overcharged},
+			{Timestamp: now, Location: location(1), Heading: &heading},
+		} {
+			_, err := s.Ingest(ctx, robot.ID, &TelemetryBatchRequest{Readings: []models.TelemetryReading{reading}})
+			assert.Error(t, err)
+		}
+		_, err := s.Ingest(ctx, robot.ID, &TelemetryBatchRequest{})
+		assert.Error(t, err)
+	})
+}
+
+func TestTelemetryGetTrack(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	robot := &models.Robot{ID: primitive.NewObjectID()}
+	robotID := robot.ID.Hex()
+
+	newService := func() (*TelemetryService, *MockTelemetryRepository) {
+		telemetryRepo := new(MockTelemetryRepository)
+		robotRepo := new(MockRobotRepository)
+		robotRepo.On("GetByID", ctx, robot.ID).Return(robot, nil)
+		config := DefaultTelemetryConfig()
+		config.MaxRawPoints = 2
+		s := NewTelemetryService(telemetryRepo, robotRepo, nil, config)
+		s.now = func() time.Time { return now }
+		return s, telemetryRepo
+	}
+
+	t.Run("短い期間は受信した計測値をそのまま返す", func(t *testing.T) {
+		s, telemetryRepo := newService()
+		from := now.Add(-10 * time.Minute)
+		telemetryRepo.On("FindReadings", ctx, robotID, from, now, int64(3)).Return([]models.TelemetryReading{
+			{RobotID: robotID, Timestamp: from.Add(time.Second)},
+		}, nil)
+
+		track, err := s.GetTrack(ctx, robot.ID, from, now, "")
+		require.NoError(t, err)
+		assert.Equal(t, models.TelemetryRaw, track.Resolution)
+		require.Len(t, track.Points, 1)
+		assert.Equal(t, 1, track.Points[0].Readings)
+	})
+
+	t.Run("件数が上限を超える場合", func(t *testing.T) {
+		s, telemetryRepo := newService()
+		from := now.Add(-10 * time.Minute)
+		telemetryRepo.On("FindReadings", ctx, robotID, from, now, int64(3)).Return(make([]models.TelemetryReading, 3), nil)
+
+		_, err := s.GetTrack(ctx, robot.ID, from, now, models.TelemetryRaw)
+		assert.ErrorIs(t, err, ErrTelemetryWindowTooLarge)
+	})
+
+	t.Run("長い期間は集約済みの値とまだ集約していない直近の値をつなげる", func(t *testing.T) {
+		s, telemetryRepo := newService()
+		from := now.Add(-6 * time.Hour)
+		latest := now.Add(-5 * time.Minute)
+		telemetryRepo.On("LatestRollupTime", ctx).Return(latest, nil)
+		telemetryRepo.On("FindRollups", ctx, robotID, from, latest.Add(time.Minute)).Return([]models.TelemetryRollup{
+			{RobotID: robotID, TelemetryPoint: models.TelemetryPoint{Timestamp: latest, Readings: 300}},
+		}, nil)
+		telemetryRepo.On("AggregateMinutes", ctx, robotID, latest.Add(time.Minute), now).Return([]models.TelemetryRollup{
+			{RobotID: robotID, TelemetryPoint: models.TelemetryPoint{Timestamp: latest.Add(time.Minute), Readings: 240}},
+		}, nil)
+
+		track, err := s.GetTrack(ctx, robot.ID, from, now, "")
+		require.NoError(t, err)
+		assert.Equal(t, models.TelemetryMinute, track.Resolution)
+		require.Len(t, track.Points, 2)
+		assert.Equal(t, 300, track.Points[0].Readings)
+		assert.Equal(t, 240, track.Points[1].Readings)
+	})
+
+	t.Run("期間が長すぎる場合", func(t *testing.T) {
+		s, _ := newService()
+		_, err := s.GetTrack(ctx, robot.ID, now.Add(-40*24*time.Hour), now, "")
+		assert.ErrorIs(t, err, ErrTelemetryWindowTooLarge)
+	})
+}
+
+func TestTelemetryRollup(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 3, 9, 0, 30, 0, time.UTC)
+	telemetryRepo := new(MockTelemetryRepository)
+	s := NewTelemetryService(telemetryRepo, new(MockRobotRepository), nil, DefaultTelemetryConfig())
+	s.now = func() time.Time { return now }
+
+	// 前回は 8:50 の1分間まで集約済みで、遅れて届く計測値を2分待つため 8:58 より前の1分間を集約します
+	latest := time.Date(2024, 6, 3, 8, 50, 0, 0, time.UTC)
+	end := time.Date(2024, 6, 3, 8, 58, 0, 0, time.UTC)
+	rollups := []models.TelemetryRollup{{RobotID: "r1"}, {RobotID: "r2"}}
+	telemetryRepo.On("AcquireRollupLease", ctx, s.instanceID, now, s.config.RollupLeaseTTL).Return(true, nil).Once()
+	telemetryRepo.On("ReleaseRollupLease", mock.Anything, s.instanceID).Return(nil).Once()
+	telemetryRepo.On("LatestRollupTime", ctx).Return(latest, nil)
+	telemetryRepo.On("AggregateMinutes", ctx, "", latest.Add(time.Minute), end).Return(rollups, nil)
+	telemetryRepo.On("InsertRollups", ctx, rollups).Return(nil)
+
+	count, err := s.Rollup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	telemetryRepo.AssertExpectations(t)
+
+	t.Run("他のインスタンスが集約中の場合は何もしない", func(t *testing.T) {
+		telemetryRepo := new(MockTelemetryRepository)
+		other := NewTelemetryService(telemetryRepo, new(MockRobotRepository), nil, DefaultTelemetryConfig())
+		other.now = func() time.Time { return now }
+		telemetryRepo.On("AcquireRollupLease", ctx, other.instanceID, now, other.config.RollupLeaseTTL).Return(false, nil).Once()
+
+		count, err := other.Rollup(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		telemetryRepo.AssertExpectations(t)
+		telemetryRepo.AssertNotCalled(t, "InsertRollups", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTelemetryGetFleetState(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	robotRepo := new(MockRobotRepository)
+	s := NewTelemetryService(new(MockTelemetryRepository), robotRepo, nil, DefaultTelemetryConfig())
+	s.now = func() time.Time { return now }
+
+	online := &models.Robot{ID: primitive.NewObjectID(), Name: "通信中", Telemetry: &models.RobotTelemetry{Timestamp: now.Add(-10 * time.Second)}}
+	offline := &models.Robot{ID: primitive.NewObjectID(), Name: "通信途絶", Telemetry: &models.RobotTelemetry{Timestamp: now.Add(-time.Minute)}}
+	unseen := &models.Robot{ID: primitive.NewObjectID(), Name: "未受信"}
+	robotRepo.On("List", ctx, models.RobotQuery{}).Return([]*models.Robot{online, offline, unseen}, nil)
+
+	states, err := s.GetFleetState(ctx)
+	require.NoError(t, err)
+	require.Len(t, states, 3)
+	assert.True(t, states[0].Online)
+	assert.False(t, states[1].Online)
+	assert.False(t, states[2].Online)
+	assert.Nil(t, states[2].Telemetry)
+}
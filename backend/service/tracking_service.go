@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/repository"
+	"github.com/onoderaryou/smart-store-admin/backend/tracking"
+)
+
+// TrackingServiceInterface は配送のライブ追跡サービスのインターフェースです
+type TrackingServiceInterface interface {
+	Subscribe(ctx context.Context, filter tracking.Filter, cursor string) (*TrackingStream, error)
+}
+
+// TrackingStream は配送のライブ追跡の購読です
+type TrackingStream struct {
+	// Initial は購読開始時に最初に送るイベントです
+	// カーソルから再開できた場合はその後のイベント、それ以外は現在の状態のスナップショット
+	// （古いカーソルを指定した場合はその前にリセット）です
+	Initial      []models.TrackingEvent
+	subscription *tracking.Subscription
+}
+
+// Events は購読開始後に発生したイベントです（受信が追いつかず切断された場合は閉じられます）
+func (s *TrackingStream) Events() <-chan models.TrackingEvent {
+	return s.subscription.Events()
+}
+
+// Close は購読を終了します
+func (s *TrackingStream) Close() {
+	s.subscription.Close()
+}
+
+// TrackingService は配送の位置・ステータスの変化をライブ配信するサービスです
+type TrackingService struct {
+	hub          *tracking.Hub
+	deliveryRepo repository.DeliveryRepository
+	now          func() time.Time
+}
+
+// NewTrackingService は新しい配送のライブ追跡サービスを作成します
+func NewTrackingService(hub *tracking.Hub, deliveryRepo repository.DeliveryRepository) *TrackingService {
+	return &TrackingService{
+		hub:          hub,
+		deliveryRepo: deliveryRepo,
+		now:          time.Now,
+	}
+}
+
+// Subscribe は1件の配送・1台の機体・全てのアクティブな配送（条件なし）の追跡イベントの購読を開始します
+func (s *TrackingService) Subscribe(ctx context.Context, filter tracking.Filter, cursor string) (*TrackingStream, error) {
+	sub, replay, resumed := s.hub.Subscribe(filter, cursor)
+	if resumed {
+		return &TrackingStream{Initial: replay, subscription: sub}, nil
+	}
+
+	// 購読を開始してから現在の状態を読むことで、その間の更新を取りこぼさないようにします
+	deliveries, err := s.snapshot(ctx, filter)
+	if err != nil {
+		sub.Close()
+		return nil, err
+	}
+
+	now := s.now()
+	var initial []models.TrackingEvent
+	if cursor != "" {
+		initial = append(initial, models.TrackingEvent{Cursor: sub.Cursor(), Type: models.TrackingReset, Timestamp: now})
+	}
+	for _, d := range deliveries {
+		event := models.TrackingEventFromDelivery(models.TrackingSnapshot, d, now)
+		event.Cursor = sub.Cursor()
+		initial = append(initial, event)
+	}
+	return &TrackingStream{Initial: initial, subscription: sub}, nil
+}
+
+// snapshot は購読の条件に一致する配送の現在の状態を取得します
+func (s *TrackingService) snapshot(ctx context.Context, filter tracking.Filter) ([]*models.Delivery, error) {
+	if filter.DeliveryID != "" {
+		id, err := primitive.ObjectIDFromHex(filter.DeliveryID)
+		if err != nil {
+			return nil, ErrDeliveryNotFound
+		}
+		delivery, err := s.deliveryRepo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, ErrDeliveryNotFound
+			}
+			return nil, err
+		}
+		if delivery == nil {
+			return nil, ErrDeliveryNotFound
+		}
+		if filter.RobotID != "" && delivery.RobotID != filter.RobotID {
+			return nil, nil
+		}
+		return []*models.Delivery{delivery}, nil
+	}
+
+	if filter.RobotID != "" {
+		deliveries, err := s.deliveryRepo.GetDeliveriesByRobot(ctx, filter.RobotID)
+		if err != nil {
+			return nil, err
+		}
+		active := make([]*models.Delivery, 0, len(deliveries))
+		for _, d := range deliveries {
+			if d.Status == models.StatusPreparing || d.Status == models.StatusInProgress {
+				active = append(active, d)
+			}
+		}
+		return active, nil
+	}
+
+	return s.deliveryRepo.GetActiveDeliveries(ctx)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+	"github.com/onoderaryou/smart-store-admin/backend/tracking"
+)
+
+func TestTrackingSubscribe(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	location := models.Location{Latitude: 35.6895, Longitude: 139.6917}
+	deliveryID := primitive.NewObjectID()
+	active := &models.Delivery{
+		ID:           deliveryID.Hex(),
+		Status:       models.StatusInProgress,
+		RobotID:      "robot-1",
+		TrackingInfo: &models.TrackingInfo{CurrentLocation: &location},
+	}
+
+	newService := func() (*TrackingService, *MockDeliveryRepository, *tracking.Hub) {
+		repo := new(MockDeliveryRepository)
+		hub := tracking.NewHub(10)
+		s := NewTrackingService(hub, repo)
+		s.now = func() time.Time { return now }
+		return s, repo, hub
+	}
+
+	t.Run("全てのアクティブな配送のスナップショットから開始", func(t *testing.T) {
+		s, repo, hub := newService()
+		repo.On("GetActiveDeliveries", ctx).Return([]*models.Delivery{active}, nil)
+
+		stream, err := s.Subscribe(ctx, tracking.Filter{}, "")
+		require.NoError(t, err)
+		defer stream.Close()
+
+		require.Len(t, stream.Initial, 1)
+		snapshot := stream.Initial[0]
+		assert.Equal(t, models.TrackingSnapshot, snapshot.Type)
+		assert.Equal(t, "robot-1", snapshot.RobotID)
+		assert.Equal(t, &location, snapshot.Location)
+		assert.NotEmpty(t, snapshot.Cursor)
+
+		published := hub.Publish(models.TrackingEvent{Type: models.TrackingStatus, DeliveryID: "other"})
+		assert.Equal(t, published, <-stream.Events())
+
+		// スナップショットのカーソルから再開すると、その後のイベントだけを受け取ります
+		resumed, err := s.Subscribe(ctx, tracking.Filter{}, snapshot.Cursor)
+		require.NoError(t, err)
+		defer resumed.Close()
+		assert.Equal(t, []models.TrackingEvent{published}, resumed.Initial)
+		repo.AssertNumberOfCalls(t, "GetActiveDeliveries", 1)
+	})
+
+	t.Run("古いカーソルはリセットしてスナップショットを送る", func(t *testing.T) {
+		s, repo, _ := newService()
+		repo.On("GetDeliveriesByRobot", ctx, "robot-1").Return([]*models.Delivery{
+			active,
+			{ID: primitive.NewObjectID().Hex(), Status: models.StatusCompleted, RobotID: "robot-1"},
+		}, nil)
+
+		stream, err := s.Subscribe(ctx, tracking.Filter{RobotID: "robot-1"}, "expired-42")
+		require.NoError(t, err)
+		defer stream.Close()
+
+		require.Len(t, stream.Initial, 2)
+		assert.Equal(t, models.TrackingReset, stream.Initial[0].Type)
+		assert.Equal(t, models.TrackingSnapshot, stream.Initial[1].Type)
+		assert.Equal(t, deliveryID.Hex(), stream.Initial[1].DeliveryID)
+	})
+
+	t.Run("存在しない配送", func(t *testing.T) {
+		s, repo, _ := newService()
+		repo.On("GetByID", ctx, deliveryID).Return(nil, mongo.ErrNoDocuments)
+
+		_, err := s.Subscribe(ctx, tracking.Filter{DeliveryID: deliveryID.Hex()}, "")
+		assert.ErrorIs(t, err, ErrDeliveryNotFound)
+		_, err = s.Subscribe(ctx, tracking.Filter{DeliveryID: "invalid"}, "")
+		assert.ErrorIs(t, err, ErrDeliveryNotFound)
+	})
+}
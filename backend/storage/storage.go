@@ -0,0 +1,101 @@
+// Package storage は配送の証跡写真などのファイル（ブロブ）の保存先を抽象化します
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound は指定したキーのブロブが存在しない場合のエラーです
+var ErrNotFound = errors.New("blob not found")
+
+// ErrInvalidKey はブロブのキーが不正な場合（空、絶対パス、親ディレクトリへの参照）のエラーです
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// BlobStore はキーを指定してブロブを保存・取得します
+// キーは "/" 区切りの相対パスです。コンテンツタイプなどのメタデータは呼び出し元で保持します
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalStore はローカルのディレクトリにブロブを保存します
+type LocalStore struct {
+	dir string
+}
+
+// インターフェースが実装されていることを確認
+var _ BlobStore = (*LocalStore)(nil)
+
+// NewLocalStore は dir 以下にブロブを保存するストアを作成します
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{dir: dir}
+}
+
+// Put はブロブを一時ファイルに書き出し、完了してからキーの位置に移動します（既存のブロブは上書きします）
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(f.Name())
+
+	n, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, err
+	}
+	return n, os.Rename(f.Name(), path)
+}
+
+// Open はブロブを読み出します
+func (s *LocalStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete はブロブを削除します（存在しない場合は何もしません）
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path はキーに対応するファイルのパスを返します
+func (s *LocalStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore(t.TempDir())
+
+	n, err := store.Put(ctx, "proof-of-delivery/abc/photo.jpg", strings.NewReader("jpeg"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), n)
+
+	r, err := store.Open(ctx, "proof-of-delivery/abc/photo.jpg")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, r.Close())
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", string(data))
+
+	require.NoError(t, store.Delete(ctx, "proof-of-delivery/abc/photo.jpg"))
+	_, err = store.Open(ctx, "proof-of-delivery/abc/photo.jpg")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, store.Delete(ctx, "proof-of-delivery/abc/photo.jpg"))
+
+	// 保存先のディレクトリの外を指すキーは受け付けない
+	for _, key := range []string{"", "/etc/passwd", "../outside", "a/../../outside", "a//b"} {
+		_, err := store.Put(ctx, key, strings.NewReader("x"))
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,210 @@
+// Package tax は日本の消費税（標準税率・軽減税率）の計算を提供します
+//
+// 適格請求書（インボイス）の要件に合わせ、端数処理は税率ごとの合計額に対して
+// 1回だけ行います。
+package tax
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// RoundingMode は消費税額の端数処理方法です
+type RoundingMode string
+
+const (
+	RoundDown   RoundingMode = "floor"   // 切り捨て
+	RoundUp     RoundingMode = "ceil"    // 切り上げ
+	RoundHalfUp RoundingMode = "half_up" // 四捨五入
+)
+
+// ParseRoundingMode は文字列から端数処理方法を取得します
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	switch mode := RoundingMode(s); mode {
+	case RoundDown, RoundUp, RoundHalfUp:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid rounding mode: %s", s)
+	}
+}
+
+// registrationNumberPattern は適格請求書発行事業者の登録番号（T + 13桁）の形式です
+var registrationNumberPattern = regexp.MustCompile(`^T[0-9]{13}$`)
+
+// ValidateRegistrationNumber は登録番号の形式が正しいかどうかをチェックします
+func ValidateRegistrationNumber(number string) bool {
+	return registrationNumberPattern.MatchString(number)
+}
+
+// Config は消費税計算の設定を保持します
+type Config struct {
+	StandardRate int // 標準税率（%）
+	ReducedRate  int // 軽減税率（%）
+	Rounding     RoundingMode
+	// PriceIncludesTax は販売価格が税込（総額表示）かどうかを表します
+	PriceIncludesTax bool
+
+	// 適格請求書発行事業者の情報
+	RegistrationNumber string
+	IssuerName         string
+}
+
+// DefaultConfig は標準的な小売店向けの設定を返します
+func DefaultConfig() Config {
+	return Config{
+		StandardRate:     10,
+		ReducedRate:      8,
+		Rounding:         RoundDown,
+		PriceIncludesTax: true,
+	}
+}
+
+// Validate は設定が正しいかどうかを検証します
+func (c Config) Validate() error {
+	if c.StandardRate < 0 || c.ReducedRate < 0 {
+		return errors.New("tax rate must be non-negative")
+	}
+	if _, err := ParseRoundingMode(string(c.Rounding)); err != nil {
+		return err
+	}
+	if c.RegistrationNumber != "" && !ValidateRegistrationNumber(c.RegistrationNumber) {
+		return errors.New("invalid invoice registration number")
+	}
+	return nil
+}
+
+// Line は税計算の対象となる明細です（値引きは負の金額で表します）
+type Line struct {
+	Class  models.TaxClass
+	Amount float64
+}
+
+// Result は税計算の結果を表します
+type Result struct {
+	Breakdown []models.TaxBreakdown
+	Subtotal  float64 // 税抜合計
+	TotalTax  float64
+	Total     float64 // 税込合計
+}
+
+// Calculator は消費税を計算します
+type Calculator struct {
+	config Config
+}
+
+// NewCalculator は新しい消費税計算機を作成します
+func NewCalculator(config Config) *Calculator {
+	return &Calculator{config: config}
+}
+
+// Config は計算機の設定を返します
+func (c *Calculator) Config() Config {
+	return c.config
+}
+
+// Rate は税区分に対応する税率（%）を返します
+func (c *Calculator) Rate(class models.TaxClass) int {
+	switch class {
+	case models.TaxClassReduced:
+		return c.config.ReducedRate
+	case models.TaxClassExempt:
+		return 0
+	default:
+		return c.config.StandardRate
+	}
+}
+
+// Issuer は適格請求書発行事業者の情報を返します（未登録の場合はnil）
+func (c *Calculator) Issuer() *models.InvoiceIssuer {
+	if c.config.RegistrationNumber == "" {
+		return nil
+	}
+	return &models.InvoiceIssuer{
+		RegistrationNumber: c.config.RegistrationNumber,
+		Name:               c.config.IssuerName,
+	}
+}
+
+// calculationOrder は内訳の表示順です
+var calculationOrder = []models.TaxClass{
+	models.TaxClassStandard,
+	models.TaxClassReduced,
+	models.TaxClassExempt,
+}
+
+// Calculate は明細から税率ごとの内訳と合計を計算します
+// 税区分が未設定の明細は標準税率として扱います
+func (c *Calculator) Calculate(lines []Line) *Result {
+	amounts := make(map[models.TaxClass]float64)
+	for _, line := range lines {
+		class := line.Class
+		if class == "" {
+			class = models.TaxClassStandard
+		}
+		amounts[class] += line.Amount
+	}
+
+	result := &Result{Breakdown: []models.TaxBreakdown{}}
+	for _, class := range calculationOrder {
+		amount, ok := amounts[class]
+		if !ok {
+			continue
+		}
+		b := c.breakdown(class, amount)
+		result.Breakdown = append(result.Breakdown, b)
+		result.Subtotal += b.Taxable
+		result.TotalTax += b.Tax
+		result.Total += b.Total
+	}
+	return result
+}
+
+// breakdown は1つの税率の対象額から税額を計算します
+func (c *Calculator) breakdown(class models.TaxClass, amount float64) models.TaxBreakdown {
+	rate := c.Rate(class)
+	b := models.TaxBreakdown{TaxClass: class, Rate: rate}
+
+	if c.config.PriceIncludesTax {
+		b.Total = amount
+		b.Tax = c.round(amount * float64(rate) / float64(100+rate))
+		b.Taxable = amount - b.Tax
+	} else {
+		b.Taxable = amount
+		b.Tax = c.round(amount * float64(rate) / 100)
+		b.Total = amount + b.Tax
+	}
+	return b
+}
+
+// roundingEpsilon は浮動小数点の誤差で端数処理の結果が変わらないようにするための許容値です
+const roundingEpsilon = 1e-9
+
+// round は設定された方法で円未満の端数を処理します
+func (c *Calculator) round(v float64) float64 {
+	var r float64
+	switch c.config.Rounding {
+	case RoundUp:
+		r = math.Ceil(v - roundingEpsilon)
+	case RoundHalfUp:
+		if v < 0 {
+			r = -math.Floor(-v + 0.5 + roundingEpsilon)
+		} else {
+			r = math.Floor(v + 0.5 + roundingEpsilon)
+		}
+	default:
+		if v < 0 {
+			r = -math.Floor(-v + roundingEpsilon)
+		} else {
+			r = math.Floor(v + roundingEpsilon)
+		}
+	}
+	if r == 0 {
+		// -0 を避ける
+		return 0
+	}
+	return r
+}
@@ -0,0 +1,135 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    Config
+		lines     []Line
+		want      []models.TaxBreakdown
+		wantTotal float64
+		wantTax   float64
+	}{
+		{
+			name:   "税込価格の標準税率と軽減税率の混在",
+			config: DefaultConfig(),
+			lines: []Line{
+				{Class: models.TaxClassStandard, Amount: 1100},
+				{Class: models.TaxClassReduced, Amount: 540},
+				{Class: models.TaxClassReduced, Amount: 324},
+			},
+			want: []models.TaxBreakdown{
+				{TaxClass: models.TaxClassStandard, Rate: 10, Taxable: 1000, Tax: 100, Total: 1100},
+				{TaxClass: models.TaxClassReduced, Rate: 8, Taxable: 800, Tax: 64, Total: 864},
+			},
+			wantTotal: 1964,
+			wantTax:   164,
+		},
+		{
+			name:   "税込価格の端数切り捨て",
+			config: DefaultConfig(),
+			lines: []Line{
+				{Class: models.TaxClassReduced, Amount: 150},
+			},
+			// 150 * 8 / 108 = 11.11...
+			want: []models.TaxBreakdown{
+				{TaxClass: models.TaxClassReduced, Rate: 8, Taxable: 139, Tax: 11, Total: 150},
+			},
+			wantTotal: 150,
+			wantTax:   11,
+		},
+		{
+			name: "税抜価格の四捨五入",
+			config: Config{
+				StandardRate: 10,
+				ReducedRate:  8,
+				Rounding:     RoundHalfUp,
+			},
+			lines: []Line{
+				{Class: models.TaxClassReduced, Amount: 119},
+				{Amount: 105},
+			},
+			// 119 * 0.08 = 9.52 -> 10, 105 * 0.1 = 10.5 -> 11
+			want: []models.TaxBreakdown{
+				{TaxClass: models.TaxClassStandard, Rate: 10, Taxable: 105, Tax: 11, Total: 116},
+				{TaxClass: models.TaxClassReduced, Rate: 8, Taxable: 119, Tax: 10, Total: 129},
+			},
+			wantTotal: 245,
+			wantTax:   21,
+		},
+		{
+			name: "税抜価格の切り上げ",
+			config: Config{
+				StandardRate: 10,
+				ReducedRate:  8,
+				Rounding:     RoundUp,
+			},
+			lines: []Line{
+				{Class: models.TaxClassStandard, Amount: 101},
+			},
+			want: []models.TaxBreakdown{
+				{TaxClass: models.TaxClassStandard, Rate: 10, Taxable: 101, Tax: 11, Total: 112},
+			},
+			wantTotal: 112,
+			wantTax:   11,
+		},
+		{
+			name:   "値引きは同じ税率の対象額から差し引く",
+			config: DefaultConfig(),
+			lines: []Line{
+				{Class: models.TaxClassReduced, Amount: 1080},
+				{Class: models.TaxClassReduced, Amount: -108},
+				{Class: models.TaxClassExempt, Amount: 500},
+			},
+			want: []models.TaxBreakdown{
+				{TaxClass: models.TaxClassReduced, Rate: 8, Taxable: 900, Tax: 72, Total: 972},
+				{TaxClass: models.TaxClassExempt, Rate: 0, Taxable: 500, Tax: 0, Total: 500},
+			},
+			wantTotal: 1472,
+			wantTax:   72,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewCalculator(tt.config).Calculate(tt.lines)
+			assert.Equal(t, tt.want, result.Breakdown)
+			assert.Equal(t, tt.wantTotal, result.Total)
+			assert.Equal(t, tt.wantTax, result.TotalTax)
+			assert.Equal(t, tt.wantTotal-tt.wantTax, result.Subtotal)
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := DefaultConfig()
+	valid.RegistrationNumber = "T1234567890123"
+	assert.NoError(t, valid.Validate())
+
+	invalidNumber := DefaultConfig()
+	invalidNumber.RegistrationNumber = "1234567890123"
+	assert.Error(t, invalidNumber.Validate())
+
+	invalidRounding := DefaultConfig()
+	invalidRounding.Rounding = "banker"
+	assert.Error(t, invalidRounding.Validate())
+}
+
+func TestIssuer(t *testing.T) {
+	assert.Nil(t, NewCalculator(DefaultConfig()).Issuer())
+
+	config := DefaultConfig()
+	config.RegistrationNumber = "T1234567890123"
+	config.IssuerName = "NEXT MART 2030"
+	assert.Equal(t, &models.InvoiceIssuer{
+		RegistrationNumber: "T1234567890123",
+		Name:               "NEXT MART 2030",
+	}, NewCalculator(config).Issuer())
+}
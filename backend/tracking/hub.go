@@ -0,0 +1,170 @@
+// Package tracking は配送の位置・ステータスの変化を購読者へ配信するハブを提供します
+package tracking
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+// subscriberBuffer は購読者ごとに配信を待てるイベントの件数です
+// 受信が追いつかず溢れた購読者は切断し、クライアントはカーソルを指定して再接続します
+const subscriberBuffer = 64
+
+// Filter は購読する配送の条件です（両方とも空の場合は全ての配送）
+type Filter struct {
+	DeliveryID string
+	RobotID    string
+}
+
+// Matches はイベントが条件に一致するかどうかを返します
+func (f Filter) Matches(event models.TrackingEvent) bool {
+	if f.DeliveryID != "" && event.DeliveryID != f.DeliveryID {
+		return false
+	}
+	if f.RobotID != "" && event.RobotID != f.RobotID {
+		return false
+	}
+	return true
+}
+
+type entry struct {
+	seq   uint64
+	event models.TrackingEvent
+}
+
+// Hub は配送の追跡イベントを購読者へ配信し、再接続に備えて直近のイベントを保持します
+// カーソルは「起動ごとの識別子-連番」で、サーバーの再起動や保持件数を超えて古くなったカーソルは再開できません
+type Hub struct {
+	mu          sync.Mutex
+	epoch       string
+	seq         uint64
+	buffer      []entry
+	next        int
+	size        int
+	subscribers map[*Subscription]struct{}
+}
+
+// NewHub は直近 bufferSize 件のイベントを保持するハブを作成します
+func NewHub(bufferSize int) *Hub {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	return &Hub{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		buffer:      make([]entry, bufferSize),
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Subscription はハブの購読です
+type Subscription struct {
+	hub    *Hub
+	filter Filter
+	events chan models.TrackingEvent
+	cursor string
+}
+
+// Cursor は購読を開始した時点のカーソルです（スナップショットに付けて、再接続時の再開位置にします）
+func (s *Subscription) Cursor() string {
+	return s.cursor
+}
+
+// Events は購読中に発生したイベントです
+// 購読を閉じた場合、または受信が追いつかず切断された場合に閉じられます
+func (s *Subscription) Events() <-chan models.TrackingEvent {
+	return s.events
+}
+
+// Close は購読を終了します
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// Publish はイベントにカーソルを付けて保持し、条件に一致する購読者へ配信します
+func (h *Hub) Publish(event models.TrackingEvent) models.TrackingEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	event.Cursor = h.cursor(h.seq)
+	h.buffer[h.next] = entry{seq: h.seq, event: event}
+	h.next = (h.next + 1) % len(h.buffer)
+	if h.size < len(h.buffer) {
+		h.size++
+	}
+
+	for s := range h.subscribers {
+		if !s.filter.Matches(event) {
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+			h.remove(s)
+		}
+	}
+	return event
+}
+
+// Subscribe は条件に一致するイベントの購読を開始します
+// cursor を指定した場合は、そのカーソルより後に保持しているイベントを replay として返します
+// resumed は cursor から途切れずに再開できたかどうかです（cursor が空、または古い場合は false）
+func (h *Hub) Subscribe(filter Filter, cursor string) (sub *Subscription, replay []models.TrackingEvent, resumed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if after, ok := h.parseCursor(cursor); ok {
+		resumed = true
+		for i := 0; i < h.size; i++ {
+			e := h.buffer[(h.next-h.size+i+len(h.buffer))%len(h.buffer)]
+			if e.seq > after && filter.Matches(e.event) {
+				replay = append(replay, e.event)
+			}
+		}
+	}
+
+	sub = &Subscription{
+		hub:    h,
+		filter: filter,
+		events: make(chan models.TrackingEvent, subscriberBuffer),
+		cursor: h.cursor(h.seq),
+	}
+	h.subscribers[sub] = struct{}{}
+	return sub, replay, resumed
+}
+
+func (h *Hub) cursor(seq uint64) string {
+	return fmt.Sprintf("%s-%d", h.epoch, seq)
+}
+
+// parseCursor はカーソルの連番を返します（保持しているイベントから再開できない場合は false）
+func (h *Hub) parseCursor(cursor string) (uint64, bool) {
+	epoch, seqText, found := strings.Cut(cursor, "-")
+	if !found || epoch != h.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil || seq > h.seq {
+		return 0, false
+	}
+	// 保持している最も古いイベントの直前までのカーソルであれば、途切れずに再開できます
+	if oldest := h.seq - uint64(h.size) + 1; seq+1 < oldest {
+		return 0, false
+	}
+	return seq, true
+}
+
+func (h *Hub) remove(s *Subscription) {
+	if _, ok := h.subscribers[s]; !ok {
+		return
+	}
+	delete(h.subscribers, s)
+	close(s.events)
+}
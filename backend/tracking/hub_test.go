@@ -0,0 +1,103 @@
+package tracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onoderaryou/smart-store-admin/backend/models"
+)
+
+func event(deliveryID, robotID string) models.TrackingEvent {
+	return models.TrackingEvent{Type: models.TrackingLocation, DeliveryID: deliveryID, RobotID: robotID, Timestamp: time.Now()}
+}
+
+func deliveryIDs(events []models.TrackingEvent) []string {
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.DeliveryID
+	}
+	return ids
+}
+
+func TestHubDeliversMatchingEvents(t *testing.T) {
+	hub := NewHub(10)
+	all, _, _ := hub.Subscribe(Filter{}, "")
+	byDelivery, _, _ := hub.Subscribe(Filter{DeliveryID: "d1"}, "")
+	byRobot, _, _ := hub.Subscribe(Filter{RobotID: "r2"}, "")
+	defer all.Close()
+	defer byDelivery.Close()
+	defer byRobot.Close()
+
+	first := hub.Publish(event("d1", "r1"))
+	hub.Publish(event("d2", "r2"))
+	assert.NotEmpty(t, first.Cursor)
+
+	assert.Equal(t, "d1", (<-all.Events()).DeliveryID)
+	assert.Equal(t, "d2", (<-all.Events()).DeliveryID)
+	assert.Equal(t, first, <-byDelivery.Events())
+	assert.Equal(t, "d2", (<-byRobot.Events()).DeliveryID)
+	assert.Empty(t, byDelivery.Events())
+	assert.Empty(t, byRobot.Events())
+}
+
+func TestHubResumesFromCursor(t *testing.T) {
+	hub := NewHub(3)
+	sub, replay, resumed := hub.Subscribe(Filter{}, "")
+	sub.Close()
+	assert.Empty(t, replay)
+	assert.False(t, resumed)
+	start := sub.Cursor()
+
+	cursors := make([]string, 4)
+	for i, id := range []string{"d1", "d2", "d3", "d4"} {
+		cursors[i] = hub.Publish(event(id, "r1")).Cursor
+	}
+
+	// d2 より後のイベントを再送します
+	sub, replay, resumed = hub.Subscribe(Filter{}, cursors[1])
+	sub.Close()
+	assert.True(t, resumed)
+	assert.Equal(t, []string{"d3", "d4"}, deliveryIDs(replay))
+
+	// 保持している最も古いイベント（d2）の直前からは再開できます
+	sub, replay, resumed = hub.Subscribe(Filter{}, cursors[0])
+	sub.Close()
+	assert.True(t, resumed)
+	assert.Equal(t, []string{"d2", "d3", "d4"}, deliveryIDs(replay))
+
+	// 保持件数を超えて古いカーソル、別の起動のカーソル、未来のカーソルは再開できません
+	for _, cursor := range []string{start, "other-1", hub.cursor(99), "invalid"} {
+		sub, replay, resumed = hub.Subscribe(Filter{}, cursor)
+		sub.Close()
+		assert.False(t, resumed, cursor)
+		assert.Empty(t, replay, cursor)
+	}
+
+	// 最新のカーソルからは再送なしで再開します
+	sub, replay, resumed = hub.Subscribe(Filter{DeliveryID: "d4"}, cursors[3])
+	sub.Close()
+	assert.True(t, resumed)
+	assert.Empty(t, replay)
+	assert.Equal(t, cursors[3], sub.Cursor())
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub(10)
+	slow, _, _ := hub.Subscribe(Filter{}, "")
+	for i := 0; i <= subscriberBuffer; i++ {
+		hub.Publish(event("d1", "r1"))
+	}
+
+	received := 0
+	for range slow.Events() {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+
+	// 切断済みの購読を閉じても問題ありません
+	slow.Close()
+	require.Empty(t, hub.subscribers)
+}
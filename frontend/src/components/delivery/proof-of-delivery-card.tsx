@@ -0,0 +1,86 @@
+import { useEffect, useState } from 'react';
+import { useQuery } from '@tanstack/react-query';
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from '@/components/ui/card';
+import { Skeleton } from '@/components/ui/skeleton';
+import { deliveriesApi, ProofOfDelivery } from '@/lib/api/deliveries';
+
+interface ProofOfDeliveryCardProps {
+  deliveryId: string;
+  proof: ProofOfDelivery;
+}
+
+export function ProofOfDeliveryCard({ deliveryId, proof }: ProofOfDeliveryCardProps) {
+  const [photoUrl, setPhotoUrl] = useState<string>();
+
+  // 写真の取得には認証が必要なため、APIクライアントで取得してから表示します
+  const { data: photo, isLoading } = useQuery({
+    queryKey: ['delivery-proof-photo', deliveryId],
+    queryFn: () => deliveriesApi.getProofPhoto(deliveryId),
+  });
+
+  useEffect(() => {
+    if (!photo) return;
+    const url = URL.createObjectURL(photo);
+    setPhotoUrl(url);
+    return () => URL.revokeObjectURL(url);
+  }, [photo]);
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>配送の証跡</CardTitle>
+        <CardDescription>配送完了時に機体から提出された証跡</CardDescription>
+      </CardHeader>
+      <CardContent className="grid gap-6 md:grid-cols-2">
+        <div>
+          {isLoading ? (
+            <Skeleton className="h-48 w-full" />
+          ) : photoUrl ? (
+            <img
+              src={photoUrl}
+              alt="受け渡し写真"
+              className="max-h-80 rounded-md border object-contain"
+            />
+          ) : (
+            <p className="text-sm text-gray-500">写真を取得できませんでした</p>
+          )}
+        </div>
+        <div className="space-y-4">
+          <div>
+            <h3 className="text-sm font-medium text-gray-500">受け渡し日時</h3>
+            <p className="mt-1">{new Date(proof.deliveredAt).toLocaleString('ja-JP')}</p>
+          </div>
+          <div>
+            <h3 className="text-sm font-medium text-gray-500">受け渡し地点</h3>
+            <p className="mt-1">
+              緯度: {proof.location.latitude}
+              <br />
+              経度: {proof.location.longitude}
+            </p>
+            {proof.distanceFromDestination !== undefined && (
+              <p className="mt-1 text-sm text-gray-600">
+                配送先から {proof.distanceFromDestination} m
+              </p>
+            )}
+          </div>
+          <div>
+            <h3 className="text-sm font-medium text-gray-500">受取人の暗証番号</h3>
+            <p className="mt-1">{proof.pinVerified ? '照合済み' : '照合なし'}</p>
+          </div>
+          <div>
+            <h3 className="text-sm font-medium text-gray-500">提出者</h3>
+            <p className="mt-1">
+              {proof.submittedBy}（{new Date(proof.submittedAt).toLocaleString('ja-JP')}）
+            </p>
+          </div>
+        </div>
+      </CardContent>
+    </Card>
+  );
+}
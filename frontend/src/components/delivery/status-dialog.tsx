@@ -56,10 +56,20 @@ export function DeliveryStatusDialog({
             <SelectContent>
               <SelectItem value="配送準備中">配送準備中</SelectItem>
               <SelectItem value="配送中">配送中</SelectItem>
-              <SelectItem value="配送完了">配送完了</SelectItem>
+              <SelectItem value="配送完了" disabled>
+                配送完了
+              </SelectItem>
               <SelectItem value="配送失敗">配送失敗</SelectItem>
+              <SelectItem value="配送保留">配送保留</SelectItem>
+              <SelectItem value="配送キャンセル">配送キャンセル</SelectItem>
+              <SelectItem value="店舗へ返送中">店舗へ返送中</SelectItem>
+              <SelectItem value="店舗へ返送済み">店舗へ返送済み</SelectItem>
             </SelectContent>
           </Select>
+          <p className="text-sm text-gray-500">
+            配送完了は機体からの配送の証跡（受け渡し写真・暗証番号）の提出で記録されます。
+            暗証番号がロックされた配送や配送先の位置が登録されていない配送は、配送失敗にしてから店舗へ返送してください。
+          </p>
           <div className="flex justify-end gap-2">
             <Button variant="outline" onClick={() => setOpen(false)}>
               キャンセル
@@ -28,6 +28,22 @@ export interface Delivery {
     batteryLevel?: number;
     speed?: number;
   };
+  proofOfDelivery?: ProofOfDelivery;
+}
+
+export interface ProofOfDelivery {
+  photoContentType: string;
+  photoSize: number;
+  photoUrl?: string;
+  pinVerified: boolean;
+  location: {
+    latitude: number;
+    longitude: number;
+  };
+  distanceFromDestination?: number;
+  deliveredAt: string;
+  submittedBy: string;
+  submittedAt: string;
 }
 
 export interface DeliveryUpdateRequest {
@@ -71,4 +87,9 @@ export const deliveriesApi = {
 
   getDeliveryHistory: (id: string) =>
     api.get<DeliveryHistory>(`/api/deliveries/${id}/history`).then((res) => res.data),
+
+  getProofPhoto: (id: string) =>
+    api
+      .get<Blob>(`/api/deliveries/${id}/proof/photo`, { responseType: 'blob' })
+      .then((res) => res.data),
 }; 
\ No newline at end of file
@@ -12,6 +12,7 @@ import {
 import { Skeleton } from '@/components/ui/skeleton';
 import { deliveriesApi } from '@/lib/api/deliveries';
 import { DeliveryStatusDialog } from '@/components/delivery/status-dialog';
+import { ProofOfDeliveryCard } from '@/components/delivery/proof-of-delivery-card';
 
 interface DeliveryHistoryItem {
   status: string;
@@ -170,6 +171,10 @@ function DeliveryDetailPage() {
         )}
       </div>
 
+      {delivery.proofOfDelivery && (
+        <ProofOfDeliveryCard deliveryId={deliveryId} proof={delivery.proofOfDelivery} />
+      )}
+
       <Card>
         <CardHeader>
           <CardTitle>配送履歴</CardTitle>
@@ -187,7 +187,9 @@ function DeliveryEditPage() {
                   <SelectContent>
                     <SelectItem value="配送準備中">配送準備中</SelectItem>
                     <SelectItem value="配送中">配送中</SelectItem>
-                    <SelectItem value="配送完了">配送完了</SelectItem>
+                    <SelectItem value="配送完了" disabled>
+                      配送完了
+                    </SelectItem>
                     <SelectItem value="配送失敗">配送失敗</SelectItem>
                   </SelectContent>
                 </Select>